@@ -0,0 +1,17 @@
+// Package api holds the published OpenAPI description of the public API and example
+// responses of its endpoints. The handler contract tests validate responses and examples
+// against it, so it must change together with the DTOs.
+package api
+
+import _ "embed"
+
+// Spec is the OpenAPI 3.0 document, served at /openapi.json
+//
+//go:embed openapi.json
+var Spec []byte
+
+// Examples are canonical responses of every documented endpoint, recorded from the contract
+// test seed data and served at /api/v1/meta/examples
+//
+//go:embed examples.json
+var Examples []byte
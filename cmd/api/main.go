@@ -2,6 +2,8 @@ package main
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
 	"os"
@@ -9,38 +11,96 @@ import (
 	"syscall"
 	"time"
 
+	"schools-be/internal/analytics"
+	"schools-be/internal/auth"
+	"schools-be/internal/cache"
+	"schools-be/internal/capability"
 	"schools-be/internal/config"
 	"schools-be/internal/database"
 	"schools-be/internal/fetcher"
 	"schools-be/internal/handler"
+	"schools-be/internal/logging"
+	"schools-be/internal/queue"
+	"schools-be/internal/reporting"
 	"schools-be/internal/repository"
 	"schools-be/internal/scheduler"
 	"schools-be/internal/scraper"
+	"schools-be/internal/scraper/rulesfile"
 	"schools-be/internal/server"
 	"schools-be/internal/service"
+	"schools-be/internal/usage"
+)
+
+// Modes of the binary: the API server, which also runs the scheduler unless
+// SCHEDULER_ENABLED=false, and a worker running only the scheduler and scrapers, without HTTP
+const (
+	modeServe  = "serve"
+	modeWorker = "worker"
 )
 
 func main() {
-	// Initialize structured logger
-	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
-		Level: slog.LevelInfo,
-	}))
+	mode := modeServe
+	if len(os.Args) > 1 {
+		mode = os.Args[1]
+	}
+	if mode != modeServe && mode != modeWorker {
+		fmt.Fprintf(os.Stderr, "usage: %s [%s|%s]\n", os.Args[0], modeServe, modeWorker)
+		os.Exit(2)
+	}
+
+	// Initialize structured logger; LOG_LEVEL, LOG_FORMAT and LOG_DEBUG apply once the
+	// configuration is loaded
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
 	slog.SetDefault(logger)
 
 	// Load configuration
 	cfg, err := config.Load()
 	if err != nil {
-		logger.Error("failed to load config", slog.String("error", err.Error()))
+		var cfgErr *config.Error
+		if errors.As(err, &cfgErr) {
+			logger.Error("invalid configuration", slog.Any("problems", cfgErr.Problems))
+		} else {
+			logger.Error("failed to load config", slog.String("error", err.Error()))
+		}
 		os.Exit(1)
 	}
+	live := config.NewLive(cfg)
+
+	// The log level and debug loggers can change on config reloads
+	levels := logging.NewLevels(cfg.LogLevel, cfg.LogDebug)
+	live.OnReload(func(cfg *config.Config) {
+		levels.Set(cfg.LogLevel, cfg.LogDebug)
+	})
+
+	// Redact API keys, emails and coordinates from everything logged from here on
+	redactor := logging.NewRedactor(cfg.LogRedactCategories(), cfg.APIKey, cfg.AdminAPIKey, cfg.SecondaryAPIKey, cfg.SecondaryAdminKey, cfg.URLSigningKey, cfg.GeminiAPIKey, cfg.OpenRouteServiceAPIKey, cfg.GooglePlacesAPIKey)
+	redactor.AddSecrets(cfg.PartnerAPIKeys...)
+	live.OnReload(func(cfg *config.Config) {
+		redactor.AddSecrets(cfg.APIKey, cfg.AdminAPIKey, cfg.SecondaryAPIKey, cfg.SecondaryAdminKey)
+		redactor.AddSecrets(cfg.PartnerAPIKeys...)
+	})
+	logger = slog.New(logging.NewContextHandler(logging.NewHandler(logging.NewOutput(os.Stdout, cfg.LogFormat, levels), redactor)))
+	slog.SetDefault(logger)
 
 	logger.Info("starting application",
+		slog.String("mode", mode),
 		slog.String("port", cfg.Port),
 		slog.String("env", cfg.Env),
 	)
+	for _, warning := range cfg.Warnings {
+		logger.Warn(warning)
+	}
+
+	// Report panics to the error tracker (no-op without SENTRY_DSN)
+	reporter, err := reporting.New(cfg.SentryDSN, cfg.Env, logger)
+	if err != nil {
+		logger.Error("failed to configure error reporting", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+	defer reporter.Flush(5 * time.Second)
 
 	// Initialize database
-	db, err := database.New(cfg.DBPath)
+	db, err := database.New(cfg.DBPath, cfg.DBMaxReadConns, cfg.SlowQueryThreshold, logger)
 	if err != nil {
 		logger.Error("failed to initialize database", slog.String("error", err.Error()))
 		os.Exit(1)
@@ -48,58 +108,216 @@ func main() {
 	defer db.Close()
 
 	// Run migrations
-	if err := database.RunMigrations(db); err != nil {
+	if err := database.RunMigrationsFrom(db.Writer, cfg.MigrationsDir, logger); err != nil {
 		logger.Error("failed to run migrations", slog.String("error", err.Error()))
 		os.Exit(1)
 	}
 	logger.Info("database migrations completed")
 
 	// Initialize repositories
-	schoolRepo := repository.NewSchoolRepository(db)
+	schoolRepo := repository.NewSchoolRepository(db).WithCache(cache.New("schools", cfg.RepositoryCacheTTL))
 	constructionRepo := repository.NewConstructionProjectRepository(db)
-	statisticRepo := repository.NewStatisticRepository(db)
+	statisticRepo := repository.NewStatisticRepository(db).WithCache(cache.New("statistics", cfg.RepositoryCacheTTL))
 	schoolDetailRepo := repository.NewSchoolDetailRepository(db)
 	schoolStatsRepo := repository.NewSchoolStatisticsRepository(db)
+	enrichedSchoolRepo := repository.NewEnrichedSchoolRepository(db)
+	chatRepo := repository.NewChatRepository(db)
+	locationRepo := repository.NewLocationRepository(db)
+	travelTimeRepo := repository.NewTravelTimeRepository(db)
+	analyticsRepo := repository.NewAnalyticsRepository(db)
+	usageRepo := repository.NewUsageRepository(db)
+	adminRepo := repository.NewAdminRepository(db)
+	applicationRepo := repository.NewSchoolApplicationRepository(db)
+	examResultRepo := repository.NewExamResultRepository(db)
+	syncRepo := repository.NewSyncRepository(db)
+	queryRepo := repository.NewQueryRepository(db)
+	publicRepo := repository.NewPublicRepository(db)
+	schoolAliasRepo := repository.NewSchoolAliasRepository(db)
+	correctionRepo := repository.NewSchoolCorrectionRepository(db)
+	searchRepo := repository.NewSchoolSearchRepository(db)
+	externalRatingRepo := repository.NewExternalRatingRepository(db)
 
 	// Initialize fetchers and scrapers
-	schoolFetcher := fetcher.NewSchoolFetcher()
-	statisticsScraper := scraper.NewStatisticsScraper()
-	schoolDetailScraper := scraper.NewSchoolDetailsScraper()
+	schoolFetcher := fetcher.NewSchoolFetcher(logger)
+	scraperRules, err := rulesfile.Load(cfg.NormalizationRules)
+	if err != nil {
+		logger.Error("failed to load normalization rules", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+	schoolAliases, err := fetcher.LoadSchoolAliases(cfg.SchoolAliases)
+	if err != nil {
+		logger.Error("failed to load school aliases", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+	statisticsScraper := scraper.NewStatisticsScraper(cfg.CacheDir, scraperRules, logger)
+	schoolDetailScraper := scraper.NewSchoolDetailsScraper(cfg.CacheDir, scraperRules, logger)
+	applicationFetcher := fetcher.NewApplicationFetcher(cfg.ApplicationsURL, logger)
+	examResultFetcher := fetcher.NewExamResultFetcher(cfg.ExamResultsURL, logger)
+
+	// Queue between the details job and the page scrapers
+	jobQueue, err := queue.New(cfg.QueueDriver, repository.NewQueueRepository(db), queue.Options{
+		MaxAttempts: cfg.QueueMaxAttempts,
+		RetryDelay:  cfg.QueueRetryDelay,
+		Holder:      cfg.InstanceID,
+	}, logger)
+	if err != nil {
+		logger.Error("failed to create job queue", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+
+	scrapeOrder, err := service.NewScrapeOrder(cfg.DetailsScrapeOrder, schoolDetailScraper, analyticsRepo)
+	if err != nil {
+		logger.Error("failed to create scrape order", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
 
 	// Initialize services
-	schoolService := service.NewSchoolService(schoolRepo, constructionRepo, schoolDetailRepo, schoolStatsRepo, statisticRepo, schoolFetcher)
-	statisticService := service.NewStatisticService(statisticRepo, statisticsScraper)
-	schoolDetailService := service.NewSchoolDetailService(schoolDetailRepo, schoolStatsRepo, schoolDetailScraper)
-	constructionProjectService := service.NewConstructionProjectService(constructionRepo)
+	schoolService := service.NewSchoolService(schoolRepo, constructionRepo, schoolDetailRepo, schoolStatsRepo, statisticRepo, enrichedSchoolRepo, applicationRepo, schoolAliasRepo, schoolAliases, searchRepo, schoolFetcher, logger)
+	statisticService := service.NewStatisticService(statisticRepo, schoolStatsRepo, statisticsScraper, logger)
+	applicationService := service.NewApplicationService(applicationRepo, applicationFetcher, logger)
+	examResultService := service.NewExamResultService(examResultRepo, schoolRepo, examResultFetcher, logger)
+	schoolDetailService := service.NewSchoolDetailService(schoolDetailRepo, schoolStatsRepo, schoolDetailScraper, jobQueue, scrapeOrder, cfg.DetailsScrapeBudget, logger)
+	constructionProjectService := service.NewConstructionProjectService(constructionRepo, logger)
+	forecastService := service.NewForecastService(statisticRepo, constructionRepo, logger)
+	syncService := service.NewSyncService(syncRepo, logger)
+	queryService := service.NewQueryService(queryRepo, cfg.QueryMaxRows, cfg.QueryTimeout, logger)
+	publicService := service.NewPublicService(publicRepo, constructionRepo, cache.New("public", cfg.PublicCacheTTL), logger)
+	exportService := service.NewExportService(schoolService, cfg.ExportDir, logger)
+	externalRatingService := service.NewExternalRatingService(cfg, externalRatingRepo, logger)
+	if externalRatingService.Enabled() {
+		schoolService.WithExternalRatings(externalRatingRepo)
+	}
+	correctionService := service.NewCorrectionService(correctionRepo, schoolService, logger)
+	adminService := service.NewAdminService(adminRepo, schoolService, schoolDetailService, cfg.ExportDir, logger)
 
 	// Initialize AI service (may be nil if API key is not configured)
 	ctx := context.Background()
-	aiService, err := service.NewAIService(ctx, cfg)
+	aiService, err := service.NewAIService(ctx, cfg, logger)
 	if err != nil {
 		logger.Warn("AI service not available", slog.String("error", err.Error()))
 		aiService = nil
+	} else {
+		aiService.WithSummaryRepository(searchRepo)
 	}
 
-	// Initialize routes service
-	routesService := service.NewRoutesService(cfg)
-
-	// Initialize handlers
-	schoolHandler := handler.NewSchoolHandler(schoolService, aiService, routesService)
-	constructionProjectHandler := handler.NewConstructionProjectHandler(constructionProjectService)
-
-	// Initialize HTTP server
-	srv := server.New(cfg, schoolHandler, constructionProjectHandler)
+	// Initialize chat service (requires the AI service)
+	var chatService *service.ChatService
+	if aiService != nil {
+		chatService = service.NewChatService(chatRepo, schoolService, aiService, cfg.ChatSessionTTL, cfg.ChatMaxHistory, logger)
+	}
 
-	// Initialize and start scheduler
-	sched := scheduler.New(cfg, schoolService, statisticService, schoolDetailService)
-	sched.Start()
-	defer sched.Stop()
+	// Initialize routes service
+	routesService := service.NewRoutesService(cfg, travelTimeRepo, logger)
+	locationService := service.NewLocationService(locationRepo, schoolService, routesService, logger)
+	pdfService := service.NewPDFService(logger)
+	mapService := service.NewMapService(cfg, logger)
+	previewService := service.NewPreviewService(schoolService, aiService, cache.New("previews", cfg.PreviewCacheTTL), logger)
 
 	// Ensure AI service is closed on shutdown
 	if aiService != nil {
 		defer aiService.Close()
 	}
 
+	// Initialize the scheduler (jobs can also be triggered from the admin dashboard)
+	sched := scheduler.New(cfg, schoolService, statisticService, applicationService, examResultService, externalRatingService, schoolDetailService, exportService, chatService, routesService, repository.NewLeaseRepository(db), repository.NewJobRequestRepository(db), reporter, logger)
+
+	// Switch off the endpoints and jobs whose dependencies are missing on this instance, so
+	// they answer 503 with the reason instead of failing mid-request
+	missing := capability.Detect(cfg, scheduler.JobDetails)
+	if aiService == nil && cfg.GeminiAPIKey != "" {
+		missing = append(missing, capability.Missing{Dependency: "gemini", Reason: "the AI service failed to start", Features: []string{"ai", "chat"}})
+	}
+	if !searchRepo.Enabled() {
+		missing = append(missing, capability.Missing{Dependency: "fts5", Reason: "SQLite was built without FTS5 (build with -tags sqlite_fts5)", Features: []string{"search"}})
+	}
+	for _, m := range missing {
+		logger.Warn("optional dependency missing",
+			slog.String("dependency", m.Dependency),
+			slog.String("reason", m.Reason),
+			slog.Any("features", m.Features),
+			slog.Any("jobs", m.Jobs),
+		)
+		for _, feature := range m.Features {
+			live.SetUnavailable(feature, m.Reason)
+		}
+		// Jobs triggered on an instance without a scheduler run on a worker, which may have them
+		if mode == modeWorker || cfg.SchedulerEnabled {
+			for _, job := range m.Jobs {
+				sched.SetUnavailable(job, m.Reason)
+			}
+		}
+	}
+
+	if mode == modeWorker {
+		// Scheduled jobs and jobs queued by API instances; instances sharing the database
+		// run each job once
+		sched.Start()
+		defer sched.Stop()
+		reloadOnHangup(live, logger)
+		waitForShutdown()
+		logger.Info("shutting down worker")
+		return
+	}
+	if cfg.SchedulerEnabled {
+		sched.Start()
+		defer sched.Stop()
+	} else {
+		logger.Info("scheduler disabled, jobs run on a worker")
+	}
+
+	// Initialize handlers
+	templates, err := handler.LoadTemplates(cfg.TemplateDir)
+	if err != nil {
+		logger.Error("failed to load templates", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+	schoolHandler := handler.NewSchoolHandler(schoolService, aiService, routesService, locationService, pdfService, mapService, templates, logger)
+	constructionProjectHandler := handler.NewConstructionProjectHandler(constructionProjectService, logger)
+	// Sign expiring export download links
+	if cfg.URLSigningKey == "" {
+		logger.Warn("no URL_SIGNING_KEY configured - download links stop working on restart")
+	}
+	signer := auth.NewURLSigner(cfg.URLSigningKey)
+	exportHandler := handler.NewExportHandler(exportService, signer, cfg.SignedURLTTL, cfg.PublicBaseURL, logger)
+	chatHandler := handler.NewChatHandler(chatService, logger)
+	locationHandler := handler.NewLocationHandler(locationService, logger)
+	statisticHandler := handler.NewStatisticHandler(statisticService, logger)
+	schoolDetailHandler := handler.NewSchoolDetailHandler(schoolDetailService, logger)
+	forecastHandler := handler.NewForecastHandler(forecastService, logger)
+	examResultHandler := handler.NewExamResultHandler(examResultService, logger)
+	previewHandler := handler.NewPreviewHandler(previewService, cfg.PublicBaseURL, logger)
+	syncHandler := handler.NewSyncHandler(syncService, logger)
+	queryHandler := handler.NewQueryHandler(queryService, logger)
+	publicHandler := handler.NewPublicHandler(publicService, cfg.PublicCacheTTL, logger)
+	correctionHandler := handler.NewCorrectionHandler(correctionService, logger)
+
+	// Initialize usage analytics (opt-in)
+	var collector *analytics.Collector
+	if cfg.AnalyticsEnabled {
+		collector = analytics.NewCollector(analyticsRepo, cfg.AnalyticsFlushInterval, logger)
+		collector.Start()
+		defer collector.Stop()
+	}
+
+	// Initialize per-key usage accounting and partner quotas
+	meter := usage.NewMeter(usageRepo, cfg.UsageFlushInterval, usage.Quota{
+		Requests: int64(cfg.QuotaDailyRequests),
+		Bytes:    int64(cfg.QuotaDailyBytes),
+	}, logger)
+	meter.Start()
+	defer meter.Stop()
+	live.OnReload(func(cfg *config.Config) {
+		meter.SetQuota(usage.Quota{
+			Requests: int64(cfg.QuotaDailyRequests),
+			Bytes:    int64(cfg.QuotaDailyBytes),
+		})
+	})
+
+	adminHandler := handler.NewAdminHandler(adminService, sched, collector, meter, live, templates, logger)
+
+	// Initialize HTTP server
+	srv := server.New(cfg, live, logger, redactor, signer, reporter, schoolHandler, constructionProjectHandler, exportHandler, chatHandler, locationHandler, statisticHandler, schoolDetailHandler, forecastHandler, examResultHandler, previewHandler, syncHandler, queryHandler, publicHandler, correctionHandler, adminHandler, collector, meter)
+
 	// Start server in a goroutine
 	go func() {
 		logger.Info("starting http server", slog.String("port", cfg.Port))
@@ -109,10 +327,8 @@ func main() {
 		}
 	}()
 
-	// Wait for interrupt signal to gracefully shutdown the server
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
+	reloadOnHangup(live, logger)
+	waitForShutdown()
 
 	logger.Info("shutting down server")
 
@@ -127,3 +343,27 @@ func main() {
 
 	logger.Info("server stopped gracefully")
 }
+
+// reloadOnHangup reloads the log level, CORS origins, quotas and disabled features on SIGHUP;
+// a restart would abort running scrapes
+func reloadOnHangup(live *config.Live, logger *slog.Logger) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		for range hup {
+			result, err := live.Reload()
+			if err != nil {
+				logger.Error("config reload failed, keeping the running configuration", slog.String("error", err.Error()))
+				continue
+			}
+			logger.Info("config reloaded", slog.Any("applied", result.Applied), slog.Any("restart_required", result.RestartRequired))
+		}
+	}()
+}
+
+// waitForShutdown blocks until the process is interrupted or terminated
+func waitForShutdown() {
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"schools-be/internal/config"
+	"schools-be/internal/database"
+	"schools-be/internal/logging"
+	"schools-be/internal/repository"
+)
+
+// export writes every table of the public dataset as a file per table for research tooling,
+// e.g. `SELECT * FROM 'data/exports/parquet/schools.parquet'` in DuckDB or
+// pandas.read_parquet. Visitor data (chats, home locations, commute times) is not exported.
+func main() {
+	format := flag.String("format", "parquet", "output format (parquet)")
+	outDir := flag.String("out", "", "output directory (default: EXPORT_DIR/<format>)")
+	flag.Parse()
+
+	logger := slog.New(slog.NewJSONHandler(os.Stderr, nil))
+	slog.SetDefault(logger)
+
+	if *format != "parquet" {
+		logger.Error("unsupported format", slog.String("format", *format))
+		os.Exit(2)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		logger.Error("failed to load config", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+	logger = slog.New(logging.NewOutput(os.Stderr, cfg.LogFormat, logging.NewLevels(cfg.LogLevel, cfg.LogDebug)))
+	slog.SetDefault(logger)
+
+	if *outDir == "" {
+		*outDir = filepath.Join(cfg.ExportDir, *format)
+	}
+
+	db, err := database.New(cfg.DBPath, cfg.DBMaxReadConns, cfg.SlowQueryThreshold, logger)
+	if err != nil {
+		logger.Error("failed to initialize database", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+	defer db.Close()
+
+	if err := database.RunMigrationsFrom(db.Writer, cfg.MigrationsDir, logger); err != nil {
+		logger.Error("failed to run migrations", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+
+	if err := os.MkdirAll(*outDir, 0755); err != nil {
+		logger.Error("failed to create output directory", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+
+	ctx := context.Background()
+	repo := repository.NewAdminRepository(db)
+	tables, err := repo.DatasetTables(ctx)
+	if err != nil {
+		logger.Error("failed to list tables", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+
+	for _, table := range tables {
+		path := filepath.Join(*outDir, table.Name+".parquet")
+		rows, err := writeParquet(ctx, repo, table, path)
+		if err != nil {
+			logger.Error("failed to export table", slog.String("table", table.Name), slog.String("error", err.Error()))
+			db.Close()
+			os.Exit(1)
+		}
+		fmt.Printf("%-26s %8d rows  %s\n", table.Name, rows, path)
+	}
+}
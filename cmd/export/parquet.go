@@ -0,0 +1,263 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"schools-be/internal/models"
+	"schools-be/internal/repository"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// parquetBatchSize is the number of rows buffered before they are handed to the writer
+const parquetBatchSize = 1000
+
+// columnKind is the Parquet type a column is written as, derived from its declared SQLite type
+type columnKind int
+
+const (
+	kindString columnKind = iota
+	kindInt
+	kindDouble
+	kindBool
+	kindTimestamp
+)
+
+// columnKindOf follows SQLite's type affinity rules, with BOOLEAN and DATETIME mapped to
+// their Parquet equivalents instead of NUMERIC
+func columnKindOf(declared string) columnKind {
+	t := strings.ToUpper(declared)
+	switch {
+	case strings.Contains(t, "INT"):
+		return kindInt
+	case strings.Contains(t, "BOOL"):
+		return kindBool
+	case strings.Contains(t, "DATE"), strings.Contains(t, "TIME"):
+		return kindTimestamp
+	case strings.Contains(t, "REAL"), strings.Contains(t, "FLOA"), strings.Contains(t, "DOUB"):
+		return kindDouble
+	default:
+		return kindString
+	}
+}
+
+func (k columnKind) node() parquet.Node {
+	switch k {
+	case kindInt:
+		return parquet.Optional(parquet.Int(64))
+	case kindDouble:
+		return parquet.Optional(parquet.Leaf(parquet.DoubleType))
+	case kindBool:
+		return parquet.Optional(parquet.Leaf(parquet.BooleanType))
+	case kindTimestamp:
+		return parquet.Optional(parquet.Timestamp(parquet.Millisecond))
+	default:
+		return parquet.Optional(parquet.String())
+	}
+}
+
+// parquetColumn maps a table column to its position in the Parquet schema. Groups order
+// their fields by name, so leaf indexes differ from the table's column order.
+type parquetColumn struct {
+	name  string
+	kind  columnKind
+	index int
+}
+
+// writeParquet writes a table to path as a zstd-compressed Parquet file with all columns
+// optional, mirroring SQLite's nullable columns. The file is written to a temp file and
+// renamed, so readers never see a partial export. It returns the number of rows written.
+func writeParquet(ctx context.Context, repo *repository.AdminRepository, table models.TableSchema, path string) (int, error) {
+	group := make(parquet.Group, len(table.Columns))
+	columns := make([]parquetColumn, len(table.Columns))
+	for i, c := range table.Columns {
+		columns[i] = parquetColumn{name: c.Name, kind: columnKindOf(c.Type)}
+		group[c.Name] = columns[i].kind.node()
+	}
+
+	names := make([]string, 0, len(group))
+	for name := range group {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for i := range columns {
+		columns[i].index = sort.SearchStrings(names, columns[i].name)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return 0, fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	writer := parquet.NewWriter(tmp,
+		parquet.NewSchema(table.Name, group),
+		parquet.Compression(&parquet.Zstd),
+		parquet.CreatedBy("schools-be", "", ""),
+	)
+
+	count := 0
+	invalid := make(map[string]int)
+	batch := make([]parquet.Row, 0, parquetBatchSize)
+	flush := func() error {
+		if _, err := writer.WriteRows(batch); err != nil {
+			return fmt.Errorf("failed to write rows: %w", err)
+		}
+		batch = batch[:0]
+		return nil
+	}
+
+	err = repo.StreamTable(ctx, table, func(values []interface{}) error {
+		row := make(parquet.Row, len(columns))
+		for i, c := range columns {
+			value, ok := parquetValue(c.kind, values[i])
+			if !ok {
+				invalid[c.name]++
+			}
+			row[c.index] = value.Level(0, definitionLevel(value), c.index)
+		}
+		batch = append(batch, row)
+		count++
+		if len(batch) == parquetBatchSize {
+			return flush()
+		}
+		return nil
+	})
+	if err == nil && len(batch) > 0 {
+		err = flush()
+	}
+	if err != nil {
+		writer.Close()
+		tmp.Close()
+		return 0, err
+	}
+
+	if err := writer.Close(); err != nil {
+		tmp.Close()
+		return 0, fmt.Errorf("failed to finish parquet file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return 0, fmt.Errorf("failed to close temp file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return 0, fmt.Errorf("failed to publish parquet file: %w", err)
+	}
+
+	// SQLite does not enforce declared types; values that do not fit are written as null
+	for column, n := range invalid {
+		slog.Warn("values not matching the column type were exported as null",
+			slog.String("table", table.Name),
+			slog.String("column", column),
+			slog.Int("count", n),
+		)
+	}
+	return count, nil
+}
+
+// definitionLevel is 1 for present values of an optional column and 0 for nulls
+func definitionLevel(v parquet.Value) int {
+	if v.IsNull() {
+		return 0
+	}
+	return 1
+}
+
+// parquetValue converts a value as returned by the SQLite driver; ok is false when a
+// non-null value could not be converted and null is returned instead
+func parquetValue(kind columnKind, v interface{}) (parquet.Value, bool) {
+	if v == nil {
+		return parquet.Value{}, true
+	}
+	if b, isBytes := v.([]byte); isBytes {
+		v = string(b)
+	}
+
+	switch kind {
+	case kindInt:
+		switch x := v.(type) {
+		case int64:
+			return parquet.Int64Value(x), true
+		case float64:
+			if x == float64(int64(x)) {
+				return parquet.Int64Value(int64(x)), true
+			}
+		case bool:
+			if x {
+				return parquet.Int64Value(1), true
+			}
+			return parquet.Int64Value(0), true
+		case string:
+			if n, err := strconv.ParseInt(strings.TrimSpace(x), 10, 64); err == nil {
+				return parquet.Int64Value(n), true
+			}
+		}
+	case kindDouble:
+		switch x := v.(type) {
+		case float64:
+			return parquet.DoubleValue(x), true
+		case int64:
+			return parquet.DoubleValue(float64(x)), true
+		case string:
+			if f, err := strconv.ParseFloat(strings.TrimSpace(x), 64); err == nil {
+				return parquet.DoubleValue(f), true
+			}
+		}
+	case kindBool:
+		switch x := v.(type) {
+		case bool:
+			return parquet.BooleanValue(x), true
+		case int64:
+			return parquet.BooleanValue(x != 0), true
+		case string:
+			if b, err := strconv.ParseBool(strings.TrimSpace(x)); err == nil {
+				return parquet.BooleanValue(b), true
+			}
+		}
+	case kindTimestamp:
+		switch x := v.(type) {
+		case time.Time:
+			return parquet.Int64Value(x.UnixMilli()), true
+		case string:
+			if t, ok := parseTimestamp(x); ok {
+				return parquet.Int64Value(t.UnixMilli()), true
+			}
+		}
+	default:
+		switch x := v.(type) {
+		case string:
+			return parquet.ByteArrayValue([]byte(x)), true
+		case time.Time:
+			return parquet.ByteArrayValue([]byte(x.UTC().Format(time.RFC3339Nano))), true
+		default:
+			return parquet.ByteArrayValue([]byte(fmt.Sprint(x))), true
+		}
+	}
+	return parquet.Value{}, false
+}
+
+// timestampLayouts are the formats SQLite timestamps are stored in by this application
+var timestampLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999-07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+}
+
+func parseTimestamp(s string) (time.Time, bool) {
+	s = strings.TrimSpace(s)
+	for _, layout := range timestampLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
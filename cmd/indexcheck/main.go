@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"schools-be/internal/database"
+)
+
+// indexcheck migrates a scratch database and verifies that the key queries are served by indexes.
+// It exits non-zero when a query plan uses a full table scan or temporary sort, and runs in CI.
+func main() {
+	dir, err := os.MkdirTemp("", "indexcheck")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to create temp dir: %v\n", err)
+		os.Exit(1)
+	}
+	defer os.RemoveAll(dir)
+
+	// Only the query plan report is printed
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	db, err := database.New(filepath.Join(dir, "indexcheck.db"), 1, 0, logger)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to open database: %v\n", err)
+		os.Exit(1)
+	}
+	defer db.Close()
+
+	if err := database.RunMigrations(db.Writer, logger); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to run migrations: %v\n", err)
+		os.Exit(1)
+	}
+
+	violations, err := database.CheckQueryPlans(db.Reader, database.KeyQueries)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to check query plans: %v\n", err)
+		os.Exit(1)
+	}
+
+	if len(violations) > 0 {
+		fmt.Fprintln(os.Stderr, "queries not covered by indexes:")
+		for _, v := range violations {
+			fmt.Fprintf(os.Stderr, "  - %s\n", v)
+		}
+		os.Exit(1)
+	}
+
+	fmt.Printf("all %d key queries use indexes\n", len(database.KeyQueries))
+}
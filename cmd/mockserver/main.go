@@ -0,0 +1,484 @@
+package main
+
+import (
+	_ "embed"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log/slog"
+	"math"
+	"math/rand"
+	"net/http"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"schools-be/internal/dto"
+	"schools-be/internal/logging"
+	"schools-be/internal/utils"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/cors"
+)
+
+//go:embed seed.json
+var seedData []byte
+
+// Response shapes of the AI and routing endpoints. They mirror the service types, which are
+// not imported so the mock builds without the Gemini and OpenRouteService clients.
+type (
+	citation struct {
+		Field string `json:"field"`
+		Value string `json:"value"`
+	}
+	schoolAnswer struct {
+		Answer     string     `json:"answer"`
+		Answerable bool       `json:"answerable"`
+		Citations  []citation `json:"citations"`
+	}
+	summaryValidation struct {
+		Valid bool `json:"valid"`
+		Words int  `json:"words"`
+	}
+	travelTimeRequest struct {
+		Start [2]float64 `json:"start"` // [lng, lat]
+		End   [2]float64 `json:"end"`   // [lng, lat]
+		Modes []string   `json:"modes"`
+	}
+	travelTime struct {
+		Mode            string  `json:"mode"`
+		DurationMinutes int     `json:"durationMinutes"`
+		DistanceKm      float64 `json:"distanceKm"`
+		Error           string  `json:"error,omitempty"`
+	}
+)
+
+// Average speeds used to fake travel times, in km/h
+var modeSpeeds = map[string]float64{
+	"walking": 4.5,
+	"bicycle": 14,
+	"car":     22,
+}
+
+// mockserver serves canned responses of the read API from a seed dataset, so the frontend
+// can be developed without API keys for Gemini or OpenRouteService and without a populated
+// database. Latency and errors can be injected to exercise loading and error states.
+func main() {
+	addr := flag.String("addr", ":8080", "listen address")
+	seedPath := flag.String("seed", "", "JSON array of enriched schools, e.g. saved from GET /api/v1/schools (default: built-in seed)")
+	latency := flag.Duration("latency", 150*time.Millisecond, "base latency added to every response")
+	jitter := flag.Duration("jitter", 100*time.Millisecond, "random extra latency of up to this duration")
+	aiLatency := flag.Duration("ai-latency", 2*time.Second, "extra latency of summary and ask responses, like a model call")
+	errorRate := flag.Float64("error-rate", 0, "fraction of API requests (0-1) answered with -error-status")
+	errorStatus := flag.Int("error-status", http.StatusInternalServerError, "status code of injected errors")
+	flag.Parse()
+
+	// The mock reads no configuration, only LOG_LEVEL and LOG_FORMAT (text by default)
+	level := slog.LevelInfo
+	if err := level.UnmarshalText([]byte(os.Getenv("LOG_LEVEL"))); err != nil {
+		level = slog.LevelInfo
+	}
+	format := os.Getenv("LOG_FORMAT")
+	if format == "" {
+		format = logging.FormatText
+	}
+	logger := slog.New(logging.NewOutput(os.Stderr, format, logging.NewLevels(level, nil)))
+	slog.SetDefault(logger)
+
+	data := seedData
+	if *seedPath != "" {
+		var err error
+		if data, err = os.ReadFile(*seedPath); err != nil {
+			logger.Error("failed to read seed file", slog.String("error", err.Error()))
+			os.Exit(1)
+		}
+	}
+	var schools []dto.EnrichedSchool
+	if err := json.Unmarshal(data, &schools); err != nil {
+		logger.Error("failed to parse seed data", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+
+	m := &mock{
+		schools:     schools,
+		latency:     *latency,
+		jitter:      *jitter,
+		aiLatency:   *aiLatency,
+		errorRate:   *errorRate,
+		errorStatus: *errorStatus,
+	}
+
+	logger.Info("mock server listening",
+		slog.String("addr", *addr),
+		slog.Int("schools", len(schools)),
+		slog.Duration("latency", *latency),
+		slog.Float64("error_rate", *errorRate),
+	)
+	if err := http.ListenAndServe(*addr, m.routes()); err != nil {
+		logger.Error("server error", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+}
+
+type mock struct {
+	schools     []dto.EnrichedSchool
+	latency     time.Duration
+	jitter      time.Duration
+	aiLatency   time.Duration
+	errorRate   float64
+	errorStatus int
+}
+
+func (m *mock) routes() http.Handler {
+	r := chi.NewRouter()
+	r.Use(middleware.Logger)
+	r.Use(cors.Handler(cors.Options{
+		AllowedOrigins: []string{"*"},
+		AllowedMethods: []string{"GET", "POST", "OPTIONS"},
+		AllowedHeaders: []string{"*"},
+		ExposedHeaders: []string{"ETag", "Retry-After", "Warning"},
+		MaxAge:         300,
+	}))
+
+	r.Get("/health", func(w http.ResponseWriter, r *http.Request) {
+		respondJSON(w, http.StatusOK, map[string]string{"status": "ok", "mode": "mock"})
+	})
+
+	r.Route("/api/v1", func(r chi.Router) {
+		r.Use(m.simulate)
+
+		r.Route("/schools", func(r chi.Router) {
+			r.Get("/", m.listSchools)
+			r.Get("/{id}", m.getSchool)
+			r.With(m.slow).Get("/{id}/summary", m.getSummary)
+			r.Get("/{id}/similar", m.getSimilar)
+			r.With(m.slow).Post("/{id}/ask", m.ask)
+			r.Post("/{id}/routes", m.routesFor)
+		})
+
+		r.Route("/construction-projects", func(r chi.Router) {
+			r.Get("/", m.listProjects)
+			r.Get("/timeline", m.projectTimeline)
+			r.Get("/{id}", m.getProject)
+		})
+	})
+
+	r.NotFound(func(w http.ResponseWriter, r *http.Request) {
+		respondError(w, http.StatusNotFound, "not available in the mock server")
+	})
+	return r
+}
+
+// simulate delays every API response and fails a share of them. A request can force an
+// error with the X-Mock-Status header, e.g. "X-Mock-Status: 503".
+func (m *mock) simulate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		delay := m.latency
+		if m.jitter > 0 {
+			delay += time.Duration(rand.Int63n(int64(m.jitter)))
+		}
+		if !sleep(r, delay) {
+			return
+		}
+
+		status := 0
+		if forced, err := strconv.Atoi(r.Header.Get("X-Mock-Status")); err == nil && forced >= 400 {
+			status = forced
+		} else if m.errorRate > 0 && rand.Float64() < m.errorRate {
+			status = m.errorStatus
+		}
+		if status != 0 {
+			if status == http.StatusServiceUnavailable || status == http.StatusTooManyRequests {
+				w.Header().Set("Retry-After", "30")
+			}
+			respondError(w, status, fmt.Sprintf("injected error (%s)", strings.ToLower(http.StatusText(status))))
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// slow adds the latency of a model call
+func (m *mock) slow(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if sleep(r, m.aiLatency) {
+			next.ServeHTTP(w, r)
+		}
+	})
+}
+
+func (m *mock) listSchools(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	schools := make([]dto.EnrichedSchool, 0, len(m.schools))
+	for _, s := range m.schools {
+		if t := query.Get("type"); t != "" && s.School.SchoolType != t {
+			continue
+		}
+		if v := query.Get("has_construction"); v != "" {
+			want, err := strconv.ParseBool(v)
+			if err != nil {
+				respondError(w, http.StatusBadRequest, "has_construction must be true or false")
+				return
+			}
+			if (len(s.ConstructionProjects) > 0) != want {
+				continue
+			}
+		}
+		if allDay := query.Get("all_day"); allDay != "" && s.AllDayType != allDay {
+			continue
+		}
+		if kind := query.Get("operator_kind"); kind != "" && s.OperatorKind != kind {
+			continue
+		}
+		if v := query.Get("accessible"); v != "" {
+			want, err := strconv.ParseBool(v)
+			if err != nil {
+				respondError(w, http.StatusBadRequest, "accessible must be true or false")
+				return
+			}
+			if s.Details == nil || s.Details.WheelchairAccessible != map[bool]string{true: "yes", false: "no"}[want] {
+				continue
+			}
+		}
+		if focus := query.Get("support_focus"); focus != "" && (s.Details == nil || !slices.Contains(s.Details.SupportFocuses, focus)) {
+			continue
+		}
+		schools = append(schools, s)
+	}
+
+	switch query.Get("sort") {
+	case "":
+	case "name":
+		sort.SliceStable(schools, func(i, j int) bool { return schools[i].School.Name < schools[j].School.Name })
+	case "investment":
+		sort.SliceStable(schools, func(i, j int) bool {
+			return schools[i].ConstructionInvestment > schools[j].ConstructionInvestment
+		})
+	case "commute":
+		respondError(w, http.StatusBadRequest, "sort=commute is not available in the mock server")
+		return
+	default:
+		respondError(w, http.StatusBadRequest, "sort must be one of: name, commute, investment")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, schools)
+}
+
+func (m *mock) getSchool(w http.ResponseWriter, r *http.Request) {
+	school, ok := m.school(w, r)
+	if !ok {
+		return
+	}
+	w.Header().Set("ETag", fmt.Sprintf(`"%d"`, school.School.Version))
+	respondJSON(w, http.StatusOK, school)
+}
+
+func (m *mock) getSummary(w http.ResponseWriter, r *http.Request) {
+	school, ok := m.school(w, r)
+	if !ok {
+		return
+	}
+
+	summary := fmt.Sprintf("%s (%s) liegt in %s, Bezirk %s.", school.School.Name, school.School.SchoolType,
+		school.School.Neighborhood, school.School.District)
+	if len(school.Statistics) > 0 {
+		latest := school.Statistics[0]
+		summary += fmt.Sprintf(" Im Schuljahr %s lernen hier %s Schülerinnen und Schüler in %s Klassen.",
+			latest.SchoolYear, latest.Students, latest.Classes)
+	}
+	if school.Details != nil && school.Details.Languages != "" {
+		summary += " Sprachen: " + school.Details.Languages + "."
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success":    true,
+		"summary":    summary,
+		"schoolName": school.School.Name,
+		"flagged":    false,
+		"validation": summaryValidation{Valid: true, Words: len(strings.Fields(summary))},
+		"stale":      false,
+	})
+}
+
+func (m *mock) getSimilar(w http.ResponseWriter, r *http.Request) {
+	school, ok := m.school(w, r)
+	if !ok {
+		return
+	}
+
+	similar := make([]dto.SimilarSchool, 0)
+	for _, other := range m.schools {
+		if other.School.ID == school.School.ID || other.School.SchoolType != school.School.SchoolType {
+			continue
+		}
+		entry := dto.SimilarSchool{School: other.School, DistanceKm: schoolDistanceKm(school.School, other.School)}
+		if len(other.Statistics) > 0 {
+			entry.SchoolYear = other.Statistics[0].SchoolYear
+			entry.Students = other.Statistics[0].Students
+			entry.Teachers = other.Statistics[0].Teachers
+			entry.Classes = other.Statistics[0].Classes
+		}
+		similar = append(similar, entry)
+	}
+	sort.Slice(similar, func(i, j int) bool { return similar[i].DistanceKm < similar[j].DistanceKm })
+
+	respondJSON(w, http.StatusOK, similar)
+}
+
+func (m *mock) ask(w http.ResponseWriter, r *http.Request) {
+	school, ok := m.school(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Question string `json:"question"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || strings.TrimSpace(req.Question) == "" || len(req.Question) > 500 {
+		respondError(w, http.StatusBadRequest, "question is required and must be at most 500 characters")
+		return
+	}
+
+	answer := schoolAnswer{Answerable: false, Answer: "Dazu enthalten die Schuldaten keine Angaben.", Citations: []citation{}}
+	if school.Details != nil && school.Details.LunchInfo != "" {
+		answer = schoolAnswer{
+			Answerable: true,
+			Answer:     "Laut Schulportrait: " + school.Details.LunchInfo + ".",
+			Citations:  []citation{{Field: "details.lunch_info", Value: school.Details.LunchInfo}},
+		}
+	}
+	respondJSON(w, http.StatusOK, answer)
+}
+
+// routesFor fakes travel times from the straight-line distance and an average speed per mode
+func (m *mock) routesFor(w http.ResponseWriter, r *http.Request) {
+	if _, ok := m.school(w, r); !ok {
+		return
+	}
+
+	var req travelTimeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if len(req.Modes) == 0 {
+		respondError(w, http.StatusBadRequest, "at least one travel mode is required")
+		return
+	}
+
+	// Streets are not straight: add 30% to the distance as the crow flies
+	distance := utils.DistanceKm(req.Start[1], req.Start[0], req.End[1], req.End[0]) * 1.3
+	results := make([]travelTime, 0, len(req.Modes))
+	for _, mode := range req.Modes {
+		speed, ok := modeSpeeds[mode]
+		if !ok {
+			results = append(results, travelTime{Mode: mode, Error: "unsupported travel mode"})
+			continue
+		}
+		results = append(results, travelTime{
+			Mode:            mode,
+			DurationMinutes: int(math.Ceil(distance / speed * 60)),
+			DistanceKm:      math.Round(distance*10) / 10,
+		})
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{"results": results})
+}
+
+func (m *mock) listProjects(w http.ResponseWriter, r *http.Request) {
+	projects := make([]dto.ConstructionProject, 0)
+	for _, s := range m.schools {
+		projects = append(projects, s.ConstructionProjects...)
+	}
+	respondJSON(w, http.StatusOK, projects)
+}
+
+// projectTimeline serves the seed projects with their years as timeline bars, unfiltered
+func (m *mock) projectTimeline(w http.ResponseWriter, r *http.Request) {
+	entries := make([]dto.ProjectTimelineEntry, 0)
+	for _, s := range m.schools {
+		for _, p := range s.ConstructionProjects {
+			if p.StartYear == nil && p.EndYear == nil {
+				continue
+			}
+			entries = append(entries, dto.ProjectTimelineEntry{
+				ID: p.ID, ProjectID: p.ProjectID, SchoolNumber: p.SchoolNumber, SchoolName: p.SchoolName,
+				District: p.District, ConstructionMeasure: p.ConstructionMeasure,
+				StartYear: p.StartYear, EndYear: p.EndYear, Status: p.Status,
+			})
+		}
+	}
+	respondJSON(w, http.StatusOK, entries)
+}
+
+func (m *mock) getProject(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid construction project id")
+		return
+	}
+	for _, s := range m.schools {
+		for _, project := range s.ConstructionProjects {
+			if project.ID == id {
+				respondJSON(w, http.StatusOK, project)
+				return
+			}
+		}
+	}
+	respondError(w, http.StatusNotFound, "construction project not found")
+}
+
+// school looks up the school of the {id} URL parameter and writes the error response if
+// there is none
+func (m *mock) school(w http.ResponseWriter, r *http.Request) (dto.EnrichedSchool, bool) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid school id")
+		return dto.EnrichedSchool{}, false
+	}
+	for _, s := range m.schools {
+		if s.School.ID == id {
+			return s, true
+		}
+	}
+	respondError(w, http.StatusNotFound, "school not found")
+	return dto.EnrichedSchool{}, false
+}
+
+func schoolDistanceKm(a, b dto.School) float64 {
+	if a.Latitude == nil || a.Longitude == nil || b.Latitude == nil || b.Longitude == nil {
+		return 0
+	}
+	return math.Round(utils.DistanceKm(*a.Latitude, *a.Longitude, *b.Latitude, *b.Longitude)*100) / 100
+}
+
+// sleep waits for d and reports false if the client went away meanwhile
+func sleep(r *http.Request, d time.Duration) bool {
+	if d <= 0 {
+		return true
+	}
+	select {
+	case <-time.After(d):
+		return true
+	case <-r.Context().Done():
+		return false
+	}
+}
+
+func respondJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(data); err != nil {
+		slog.Error("failed to encode response", slog.String("error", err.Error()))
+	}
+}
+
+func respondError(w http.ResponseWriter, status int, message string) {
+	respondJSON(w, status, map[string]string{"error": message})
+}
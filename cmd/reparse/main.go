@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log/slog"
+	"os"
+
+	"schools-be/internal/config"
+	"schools-be/internal/database"
+	"schools-be/internal/logging"
+	"schools-be/internal/queue"
+	"schools-be/internal/repository"
+	"schools-be/internal/scraper"
+	"schools-be/internal/scraper/rulesfile"
+	"schools-be/internal/service"
+)
+
+// reparse re-runs table parsing and field extraction over the cached raw school pages and stores
+// the results, without touching the live site. Run it after bumping scraper.ParserVersion.
+func main() {
+	force := flag.Bool("force", false, "re-parse records already produced by the current parser version")
+	flag.Parse()
+
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+	slog.SetDefault(logger)
+
+	cfg, err := config.Load()
+	if err != nil {
+		logger.Error("failed to load config", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+	logger = slog.New(logging.NewOutput(os.Stdout, cfg.LogFormat, logging.NewLevels(cfg.LogLevel, cfg.LogDebug)))
+	slog.SetDefault(logger)
+
+	rules, err := rulesfile.Load(cfg.NormalizationRules)
+	if err != nil {
+		logger.Error("failed to load normalization rules", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+
+	db, err := database.New(cfg.DBPath, cfg.DBMaxReadConns, cfg.SlowQueryThreshold, logger)
+	if err != nil {
+		logger.Error("failed to initialize database", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+	defer db.Close()
+
+	if err := database.RunMigrationsFrom(db.Writer, cfg.MigrationsDir, logger); err != nil {
+		logger.Error("failed to run migrations", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+
+	schoolDetailService := service.NewSchoolDetailService(
+		repository.NewSchoolDetailRepository(db),
+		repository.NewSchoolStatisticsRepository(db),
+		scraper.NewSchoolDetailsScraper(cfg.CacheDir, rules, logger),
+		queue.NewMemory(queue.Options{}, logger), // Re-parsing does not scrape
+		nil,
+		cfg.DetailsScrapeBudget,
+		logger,
+	)
+
+	if err := schoolDetailService.ReparseAndStoreDetails(context.Background(), *force); err != nil {
+		logger.Error("re-parse failed", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+}
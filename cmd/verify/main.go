@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	"schools-be/internal/config"
+	"schools-be/internal/database"
+	"schools-be/internal/logging"
+	"schools-be/internal/models"
+	"schools-be/internal/repository"
+	"schools-be/internal/service"
+)
+
+// verify cross-checks referential integrity and totals across the data tables and prints
+// a report. It exits with status 1 when issues remain, so it can gate deployments or CI.
+func main() {
+	fix := flag.Bool("fix", false, "delete detail and statistics rows whose school number is missing from schools")
+	asJSON := flag.Bool("json", false, "print the report as JSON")
+	flag.Parse()
+
+	logger := slog.New(slog.NewJSONHandler(os.Stderr, nil))
+	slog.SetDefault(logger)
+
+	cfg, err := config.Load()
+	if err != nil {
+		logger.Error("failed to load config", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+
+	// Only warnings by default, so the report is not buried in progress logs
+	level := cfg.LogLevel
+	if os.Getenv("LOG_LEVEL") == "" {
+		level = slog.LevelWarn
+	}
+	logger = slog.New(logging.NewOutput(os.Stderr, cfg.LogFormat, logging.NewLevels(level, cfg.LogDebug)))
+	slog.SetDefault(logger)
+
+	db, err := database.New(cfg.DBPath, cfg.DBMaxReadConns, cfg.SlowQueryThreshold, logger)
+	if err != nil {
+		logger.Error("failed to initialize database", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+	defer db.Close()
+
+	if err := database.RunMigrationsFrom(db.Writer, cfg.MigrationsDir, logger); err != nil {
+		logger.Error("failed to run migrations", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+
+	adminService := service.NewAdminService(repository.NewAdminRepository(db), nil, nil, "", logger)
+
+	report, err := adminService.VerifyConsistency(context.Background(), *fix)
+	if err != nil {
+		logger.Error("verification failed", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(report)
+	} else {
+		printReport(report)
+	}
+
+	if report.HasIssues() {
+		db.Close()
+		os.Exit(1)
+	}
+}
+
+func printReport(report *models.ConsistencyReport) {
+	fmt.Println("Rows with a school number missing from schools:")
+	if len(report.Orphans) == 0 {
+		fmt.Println("  none")
+	}
+	for _, o := range report.Orphans {
+		note := ""
+		switch {
+		case !o.Fixable:
+			note = " (kept as standalone)"
+		case o.Deleted > 0:
+			note = fmt.Sprintf(" (deleted %d)", o.Deleted)
+		}
+		fmt.Printf("  %-26s %6d%s  e.g. %s\n", o.Table, o.Rows, note, strings.Join(o.SchoolNumbers, ", "))
+	}
+
+	fmt.Printf("\nSchools without details: %d\n", len(report.SchoolsMissingDetails))
+	if n := len(report.SchoolsMissingDetails); n > 0 {
+		sample := report.SchoolsMissingDetails[:min(n, 20)]
+		fmt.Printf("  e.g. %s\n", strings.Join(sample, ", "))
+	}
+
+	fmt.Printf("\nTotals differing from the sum of female and male counts: %d\n", len(report.TotalMismatches))
+	for _, m := range report.TotalMismatches {
+		fmt.Printf("  %-26s %s %-30s total %d, sum %d\n", m.Table, m.SchoolNumber, m.Detail, m.Total, m.Sum)
+	}
+}
@@ -0,0 +1,293 @@
+// Package analytics aggregates anonymous endpoint usage into daily rollups.
+// Only route patterns, known filter names, known categorical filter values and school IDs
+// are counted; no client addresses, tokens or free-text values are recorded.
+package analytics
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"schools-be/internal/i18n"
+	"schools-be/internal/models"
+	"schools-be/internal/repository"
+
+	"github.com/go-chi/chi/v5"
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
+)
+
+const dayFormat = "2006-01-02"
+
+// otherValue is counted for values of categorical filters outside their known set
+const otherValue = "other"
+
+// berlinDistricts are the twelve districts (Bezirke) of Berlin
+var berlinDistricts = []string{
+	"Mitte", "Friedrichshain-Kreuzberg", "Pankow", "Charlottenburg-Wilmersdorf", "Spandau",
+	"Steglitz-Zehlendorf", "Tempelhof-Schöneberg", "Neukölln", "Treptow-Köpenick",
+	"Marzahn-Hellersdorf", "Lichtenberg", "Reinickendorf",
+}
+
+// filters are the query parameters counted as filter usage. Categorical filters have the
+// values they are counted with; the others are counted by name only, since their values are
+// free text, coordinates or tokens. Unknown parameters are not counted, so callers cannot
+// add keys of their own to the rollups.
+var filters = map[string]func(value string) string{
+	"type":                      schoolType,
+	"school_type":               schoolType,
+	"district":                  oneOf(berlinDistricts...),
+	"sort":                      oneOf("name", "commute", "investment"),
+	"mode":                      oneOf("walking", "bicycle", "car"),
+	"operator_kind":             oneOf(models.OperatorKindPublic, models.OperatorKindConfessional, models.OperatorKindPrivateSecular),
+	"all_day":                   oneOf(models.AllDayTypes...),
+	"support_focus":             oneOf(models.SupportFocuses...),
+	"accessible":                oneOf("true", "false"),
+	"has_construction":          oneOf("true", "false"),
+	"available_after_4th_grade": oneOf("true", "false"),
+	"language":                  oneOf(models.BilingualLanguages...),
+	"religion":                  oneOf(models.ReligiousEducations...),
+	"status":                    oneOf(models.ProjectStatuses...),
+	"operator":                  nil,
+	"postal_code":               nil,
+	"neighborhood":              nil,
+	"location":                  nil,
+	"q":                         nil,
+	"lat":                       nil,
+	"lng":                       nil,
+	"radius_km":                 nil,
+	"limit":                     nil,
+	"from":                      nil,
+	"to":                        nil,
+	"from_year":                 nil,
+	"to_year":                   nil,
+	"group_by":                  nil,
+}
+
+// oneOf counts the given values as they are and any other value as otherValue
+func oneOf(values ...string) func(string) string {
+	return func(value string) string {
+		if slices.Contains(values, value) {
+			return value
+		}
+		return otherValue
+	}
+}
+
+// schoolType counts school types of the open data by their lower-cased name
+func schoolType(value string) string {
+	if i18n.IsSourceLabel(value) {
+		return strings.ToLower(strings.TrimSpace(value))
+	}
+	return otherValue
+}
+
+// filterKey returns the key a query parameter is counted with, and false for parameters that
+// are not counted
+func filterKey(name string, values []string) (string, bool) {
+	value, known := filters[name]
+	if !known {
+		return "", false
+	}
+	if value == nil || len(values) == 0 || values[0] == "" {
+		return name, true
+	}
+	return name + "=" + value(values[0]), true
+}
+
+type rollupKey struct {
+	day  string
+	kind string
+	key  string
+}
+
+// Collector counts hits in memory and periodically adds them to the daily rollups
+type Collector struct {
+	repo     *repository.AnalyticsRepository
+	interval time.Duration
+	logger   *slog.Logger
+
+	mu     sync.Mutex
+	counts map[rollupKey]int64
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+func NewCollector(repo *repository.AnalyticsRepository, flushInterval time.Duration, logger *slog.Logger) *Collector {
+	return &Collector{
+		repo:     repo,
+		interval: flushInterval,
+		logger:   logger.With(slog.String("component", "analytics")),
+		counts:   make(map[rollupKey]int64),
+	}
+}
+
+// Record counts one hit of a key for today
+func (c *Collector) Record(kind, key string) {
+	k := rollupKey{day: time.Now().UTC().Format(dayFormat), kind: kind, key: key}
+
+	c.mu.Lock()
+	c.counts[k]++
+	c.mu.Unlock()
+}
+
+// Middleware records the matched route pattern, filter usage and viewed school of each request.
+// It is a no-op when the collector is nil (analytics disabled).
+func (c *Collector) Middleware(next http.Handler) http.Handler {
+	if c == nil {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ww := chimiddleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r)
+
+		rctx := chi.RouteContext(r.Context())
+		if rctx == nil || rctx.RoutePattern() == "" {
+			return
+		}
+		pattern := strings.TrimSuffix(rctx.RoutePattern(), "/")
+
+		c.Record(models.AnalyticsKindEndpoint, r.Method+" "+pattern)
+
+		for name, values := range r.URL.Query() {
+			if key, ok := filterKey(name, values); ok {
+				c.Record(models.AnalyticsKindFilter, key)
+			}
+		}
+
+		if r.Method == http.MethodGet && ww.Status() == http.StatusOK && strings.HasSuffix(pattern, "/schools/{id}") {
+			c.Record(models.AnalyticsKindSchool, rctx.URLParam("id"))
+		}
+	})
+}
+
+// Flush adds the in-memory counts to the database and resets them
+func (c *Collector) Flush(ctx context.Context) error {
+	c.mu.Lock()
+	counts := c.counts
+	c.counts = make(map[rollupKey]int64)
+	c.mu.Unlock()
+
+	rollups := make([]models.AnalyticsRollup, 0, len(counts))
+	for k, count := range counts {
+		rollups = append(rollups, models.AnalyticsRollup{Day: k.day, Kind: k.kind, Key: k.key, Count: count})
+	}
+
+	if err := c.repo.AddCounts(ctx, rollups); err != nil {
+		// Put the counts back so they are retried on the next flush
+		c.mu.Lock()
+		for k, count := range counts {
+			c.counts[k] += count
+		}
+		c.mu.Unlock()
+		return err
+	}
+
+	return nil
+}
+
+// Start flushes the counts periodically until Stop is called
+func (c *Collector) Start() {
+	c.stop = make(chan struct{})
+	c.done = make(chan struct{})
+
+	go func() {
+		defer close(c.done)
+		ticker := time.NewTicker(c.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				c.flushWithTimeout()
+			case <-c.stop:
+				c.flushWithTimeout()
+				return
+			}
+		}
+	}()
+}
+
+// Stop flushes the remaining counts and stops the background flushing
+func (c *Collector) Stop() {
+	close(c.stop)
+	<-c.done
+}
+
+func (c *Collector) flushWithTimeout() {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if err := c.Flush(ctx); err != nil {
+		c.logger.Error("failed to flush analytics", slog.String("error", err.Error()))
+	}
+}
+
+// Count is the total of one key over a report range
+type Count struct {
+	Key   string `json:"key"`
+	Count int64  `json:"count"`
+}
+
+// Report summarizes the rollups of a day range
+type Report struct {
+	From      string                   `json:"from"`
+	To        string                   `json:"to"`
+	Endpoints []Count                  `json:"endpoints"`
+	Filters   []Count                  `json:"filters"`
+	Schools   []Count                  `json:"schools"`
+	Daily     []models.AnalyticsRollup `json:"daily"`
+}
+
+// Report flushes pending counts and summarizes the last days (including today), most used first
+func (c *Collector) Report(ctx context.Context, days int) (*Report, error) {
+	if err := c.Flush(ctx); err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	from := now.AddDate(0, 0, -(days - 1)).Format(dayFormat)
+	to := now.Format(dayFormat)
+
+	rollups, err := c.repo.GetRange(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	totals := map[string]map[string]int64{}
+	for _, rollup := range rollups {
+		if totals[rollup.Kind] == nil {
+			totals[rollup.Kind] = map[string]int64{}
+		}
+		totals[rollup.Kind][rollup.Key] += rollup.Count
+	}
+
+	return &Report{
+		From:      from,
+		To:        to,
+		Endpoints: sortedCounts(totals[models.AnalyticsKindEndpoint]),
+		Filters:   sortedCounts(totals[models.AnalyticsKindFilter]),
+		Schools:   sortedCounts(totals[models.AnalyticsKindSchool]),
+		Daily:     rollups,
+	}, nil
+}
+
+func sortedCounts(totals map[string]int64) []Count {
+	counts := make([]Count, 0, len(totals))
+	for key, count := range totals {
+		counts = append(counts, Count{Key: key, Count: count})
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Count != counts[j].Count {
+			return counts[i].Count > counts[j].Count
+		}
+		return counts[i].Key < counts[j].Key
+	})
+	return counts
+}
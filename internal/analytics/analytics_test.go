@@ -0,0 +1,31 @@
+package analytics
+
+import "testing"
+
+func TestFilterKey(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []string
+		want   string
+		ok     bool
+	}{
+		{"district", []string{"Pankow"}, "district=Pankow", true},
+		{"district", []string{"Atlantis"}, "district=other", true},
+		{"type", []string{"Gymnasium"}, "type=gymnasium", true},
+		{"school_type", []string{"<script>"}, "school_type=other", true},
+		{"sort", []string{"commute"}, "sort=commute", true},
+		{"mode", []string{"teleport"}, "mode=other", true},
+		{"mode", []string{""}, "mode", true},
+		{"q", []string{"Lessing Gymnasium"}, "q", true},
+		{"location", []string{"9f86d081884c7d659a2feaa0c55ad015"}, "location", true},
+		{"api_key", []string{"k3y"}, "", false},
+		{"utm_source_0123456789", []string{"x"}, "", false},
+	}
+
+	for _, tt := range tests {
+		got, ok := filterKey(tt.name, tt.values)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("filterKey(%q, %q) = %q, %v; want %q, %v", tt.name, tt.values, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	// oidcClockSkew tolerates clock differences between the identity provider and this server
+	// for exp; go-oidc allows five minutes for nbf
+	oidcClockSkew = time.Minute
+	// oidcRediscoverInterval limits discovery attempts while the identity provider is
+	// unreachable, so every request with a token does not wait for it to time out
+	oidcRediscoverInterval = time.Minute
+)
+
+// oidcSigningAlgs are the accepted token signature algorithms. "none" and HMAC are never
+// accepted: they would let anyone who knows the (public) key forge tokens.
+var oidcSigningAlgs = []string{oidc.RS256, oidc.RS384, oidc.RS512, oidc.ES256, oidc.ES384}
+
+// ErrInvalidToken is returned for tokens that are malformed, expired, not signed by the
+// identity provider or issued for another audience
+var ErrInvalidToken = errors.New("invalid token")
+
+// OIDCVerifier validates JWT access tokens of an OpenID Connect identity provider. The
+// provider is discovered from the issuer's /.well-known/openid-configuration on the first
+// token; go-oidc caches its signing keys and fetches them again for a token signed with an
+// unknown key (the provider may have rotated keys).
+type OIDCVerifier struct {
+	issuer    string
+	audience  string
+	roleClaim []string // Path to the roles claim, e.g. ["realm_access", "roles"]
+	client    *http.Client
+	now       func() time.Time
+
+	discovery singleflight.Group
+
+	mu              sync.Mutex
+	verifier        *oidc.IDTokenVerifier
+	discoveryErr    error
+	discoveryFailed time.Time
+}
+
+// NewOIDCVerifier creates a verifier for tokens of issuer whose aud contains audience.
+// roleClaim is a dot-separated claim path holding role names (a string or list).
+func NewOIDCVerifier(issuer, audience, roleClaim string, client *http.Client) *OIDCVerifier {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &OIDCVerifier{
+		issuer:    issuer,
+		audience:  audience,
+		roleClaim: strings.Split(roleClaim, "."),
+		client:    client,
+		now:       time.Now,
+	}
+}
+
+// LooksLikeJWT reports whether a bearer token has the three-part JWT shape, as opposed to a
+// static API key
+func LooksLikeJWT(token string) bool {
+	return strings.Count(token, ".") == 2
+}
+
+// Verify checks a token's signature, issuer, audience and validity period and returns the
+// caller. The role is the highest known role named in the role claim, public if none is.
+// An error other than ErrInvalidToken means the identity provider could not be discovered.
+func (v *OIDCVerifier) Verify(ctx context.Context, token string) (Principal, error) {
+	verifier, err := v.idTokenVerifier()
+	if err != nil {
+		return Principal{}, err
+	}
+
+	idToken, err := verifier.Verify(ctx, token)
+	if err != nil {
+		return Principal{}, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	if idToken.Subject == "" {
+		return Principal{}, fmt.Errorf("%w: missing sub", ErrInvalidToken)
+	}
+
+	var claims map[string]interface{}
+	if err := idToken.Claims(&claims); err != nil {
+		return Principal{}, fmt.Errorf("%w: claims: %v", ErrInvalidToken, err)
+	}
+	return Principal{
+		Role:    v.role(claims),
+		Subject: "oidc",
+		UserID:  idToken.Subject,
+	}, nil
+}
+
+// idTokenVerifier returns the verifier of the discovered provider, discovering it first.
+// Concurrent callers share one discovery, which runs without holding v.mu; after a failed
+// discovery the error is returned for oidcRediscoverInterval.
+func (v *OIDCVerifier) idTokenVerifier() (*oidc.IDTokenVerifier, error) {
+	v.mu.Lock()
+	if v.verifier != nil {
+		defer v.mu.Unlock()
+		return v.verifier, nil
+	}
+	if v.discoveryErr != nil && v.now().Sub(v.discoveryFailed) < oidcRediscoverInterval {
+		defer v.mu.Unlock()
+		return nil, v.discoveryErr
+	}
+	v.mu.Unlock()
+
+	verifier, err, _ := v.discovery.Do(v.issuer, func() (interface{}, error) {
+		// The provider keeps this context for fetching signing keys later, so it must not
+		// be the context of the request that triggered discovery
+		provider, err := oidc.NewProvider(oidc.ClientContext(context.Background(), v.client), v.issuer)
+
+		v.mu.Lock()
+		defer v.mu.Unlock()
+		if err != nil {
+			v.discoveryErr = fmt.Errorf("failed to discover OIDC configuration: %w", err)
+			v.discoveryFailed = v.now()
+			return nil, v.discoveryErr
+		}
+		v.verifier = provider.Verifier(&oidc.Config{
+			ClientID:             v.audience,
+			SupportedSigningAlgs: oidcSigningAlgs,
+			Now:                  func() time.Time { return v.now().Add(-oidcClockSkew) },
+		})
+		v.discoveryErr = nil
+		return v.verifier, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return verifier.(*oidc.IDTokenVerifier), nil
+}
+
+// role returns the highest role named in the role claim
+func (v *OIDCVerifier) role(claims map[string]interface{}) Role {
+	var value interface{} = claims
+	for _, name := range v.roleClaim {
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return RolePublic
+		}
+		value = m[name]
+	}
+
+	var names []string
+	switch x := value.(type) {
+	case string:
+		names = strings.Fields(x)
+	case []interface{}:
+		for _, n := range x {
+			if s, ok := n.(string); ok {
+				names = append(names, s)
+			}
+		}
+	}
+
+	role := RolePublic
+	for _, name := range names {
+		if r := Role(strings.ToLower(name)); r.Valid() && r.Allows(role) {
+			role = r
+		}
+	}
+	return role
+}
@@ -0,0 +1,291 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testProvider is an identity provider serving its discovery document and signing keys
+type testProvider struct {
+	server    *httptest.Server
+	down      atomic.Bool  // Answer discovery requests with 503
+	discovery atomic.Int32 // Requests of the discovery document
+	jwks      atomic.Int32 // Requests of the key set
+
+	mu   sync.Mutex
+	keys map[string]*rsa.PrivateKey
+}
+
+func newTestProvider(t *testing.T) *testProvider {
+	t.Helper()
+	p := &testProvider{keys: map[string]*rsa.PrivateKey{"key-1": newRSAKey(t)}}
+	p.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/.well-known/openid-configuration":
+			p.discovery.Add(1)
+			if p.down.Load() {
+				http.Error(w, "unavailable", http.StatusServiceUnavailable)
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"issuer":                                p.server.URL,
+				"jwks_uri":                              p.server.URL + "/keys",
+				"id_token_signing_alg_values_supported": []string{"RS256", "HS256", "none"},
+			})
+		case "/keys":
+			p.jwks.Add(1)
+			p.mu.Lock()
+			defer p.mu.Unlock()
+			var keys []map[string]string
+			for kid, key := range p.keys {
+				keys = append(keys, map[string]string{
+					"kty": "RSA", "use": "sig", "alg": "RS256", "kid": kid,
+					"n": base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+					"e": base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+				})
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{"keys": keys})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(p.server.Close)
+	return p
+}
+
+func (p *testProvider) key(kid string) *rsa.PrivateKey {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.keys[kid]
+}
+
+func (p *testProvider) addKey(kid string, key *rsa.PrivateKey) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.keys[kid] = key
+}
+
+func newRSAKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	return key
+}
+
+// claims returns valid claims for the provider, changed by the given overrides
+func (p *testProvider) claims(overrides map[string]interface{}) map[string]interface{} {
+	now := time.Now()
+	claims := map[string]interface{}{
+		"iss":   p.server.URL,
+		"aud":   []string{"account", "schools-api"},
+		"sub":   "user-1",
+		"exp":   now.Add(time.Hour).Unix(),
+		"iat":   now.Unix(),
+		"roles": []string{"offline_access", "Partner"},
+	}
+	for name, value := range overrides {
+		if value == nil {
+			delete(claims, name)
+		} else {
+			claims[name] = value
+		}
+	}
+	return claims
+}
+
+// signRS256 creates a token signed with key
+func signRS256(t *testing.T, key *rsa.PrivateKey, kid string, claims map[string]interface{}) string {
+	t.Helper()
+	signed := encodeSegment(t, map[string]string{"alg": "RS256", "typ": "JWT", "kid": kid}) + "." + encodeSegment(t, claims)
+	digest := sha256.Sum256([]byte(signed))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func encodeSegment(t *testing.T, v interface{}) string {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func TestOIDCVerify(t *testing.T) {
+	provider := newTestProvider(t)
+	key := provider.key("key-1")
+	verifier := NewOIDCVerifier(provider.server.URL, "schools-api", "roles", nil)
+
+	// An HS256 token whose secret is the provider's public key, which an attacker can download
+	publicKey, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatalf("marshal public key: %v", err)
+	}
+	hs256 := encodeSegment(t, map[string]string{"alg": "HS256", "typ": "JWT", "kid": "key-1"}) + "." + encodeSegment(t, provider.claims(nil))
+	mac := hmac.New(sha256.New, publicKey)
+	mac.Write([]byte(hs256))
+	hs256 += "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+
+	tests := []struct {
+		name     string
+		token    string
+		wantRole Role
+		wantErr  bool
+	}{
+		{"valid", signRS256(t, key, "key-1", provider.claims(nil)), RolePartner, false},
+		{"audience string", signRS256(t, key, "key-1", provider.claims(map[string]interface{}{"aud": "schools-api"})), RolePartner, false},
+		{"roles string", signRS256(t, key, "key-1", provider.claims(map[string]interface{}{"roles": "partner admin"})), RoleAdmin, false},
+		{"without roles", signRS256(t, key, "key-1", provider.claims(map[string]interface{}{"roles": nil})), RolePublic, false},
+		{"expired within clock skew", signRS256(t, key, "key-1", provider.claims(map[string]interface{}{"exp": time.Now().Add(-30 * time.Second).Unix()})), RolePartner, false},
+		{"alg none", encodeSegment(t, map[string]string{"alg": "none", "typ": "JWT"}) + "." + encodeSegment(t, provider.claims(nil)) + ".", "", true},
+		{"alg HS256 with the public key", hs256, "", true},
+		{"expired", signRS256(t, key, "key-1", provider.claims(map[string]interface{}{"exp": time.Now().Add(-2 * time.Minute).Unix()})), "", true},
+		{"without exp", signRS256(t, key, "key-1", provider.claims(map[string]interface{}{"exp": nil})), "", true},
+		{"not valid yet", signRS256(t, key, "key-1", provider.claims(map[string]interface{}{"nbf": time.Now().Add(10 * time.Minute).Unix()})), "", true},
+		{"wrong issuer", signRS256(t, key, "key-1", provider.claims(map[string]interface{}{"iss": "https://evil.example"})), "", true},
+		{"wrong audience", signRS256(t, key, "key-1", provider.claims(map[string]interface{}{"aud": "other-api"})), "", true},
+		{"unknown kid", signRS256(t, newRSAKey(t), "key-2", provider.claims(nil)), "", true},
+		{"known kid, other key", signRS256(t, newRSAKey(t), "key-1", provider.claims(nil)), "", true},
+		{"tampered claims", tamper(signRS256(t, key, "key-1", provider.claims(nil)), encodeSegment(t, provider.claims(map[string]interface{}{"roles": "admin"}))), "", true},
+		{"without sub", signRS256(t, key, "key-1", provider.claims(map[string]interface{}{"sub": nil})), "", true},
+		{"not a JWT", "a.b.c", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			principal, err := verifier.Verify(context.Background(), tt.token)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidToken) {
+					t.Fatalf("Verify() error = %v, want ErrInvalidToken", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Verify() error = %v", err)
+			}
+			if principal.Role != tt.wantRole || principal.Subject != "oidc" || principal.UserID != "user-1" {
+				t.Errorf("Verify() = %+v, want role %s for user-1", principal, tt.wantRole)
+			}
+		})
+	}
+
+	if got := provider.discovery.Load(); got != 1 {
+		t.Errorf("discovery requests = %d, want 1", got)
+	}
+}
+
+// tamper replaces the claims of a token, keeping its header and signature
+func tamper(token, claims string) string {
+	parts := strings.Split(token, ".")
+	return parts[0] + "." + claims + "." + parts[2]
+}
+
+func TestOIDCVerifyNestedRoleClaim(t *testing.T) {
+	provider := newTestProvider(t)
+	verifier := NewOIDCVerifier(provider.server.URL, "schools-api", "realm_access.roles", nil)
+
+	token := signRS256(t, provider.key("key-1"), "key-1", provider.claims(map[string]interface{}{
+		"realm_access": map[string]interface{}{"roles": []string{"admin"}},
+	}))
+	principal, err := verifier.Verify(context.Background(), token)
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if principal.Role != RoleAdmin {
+		t.Errorf("role = %s, want admin", principal.Role)
+	}
+}
+
+func TestOIDCVerifyRotatedKey(t *testing.T) {
+	provider := newTestProvider(t)
+	verifier := NewOIDCVerifier(provider.server.URL, "schools-api", "roles", nil)
+
+	if _, err := verifier.Verify(context.Background(), signRS256(t, provider.key("key-1"), "key-1", provider.claims(nil))); err != nil {
+		t.Fatalf("Verify() with the first key error = %v", err)
+	}
+
+	provider.addKey("key-2", newRSAKey(t))
+	if _, err := verifier.Verify(context.Background(), signRS256(t, provider.key("key-2"), "key-2", provider.claims(nil))); err != nil {
+		t.Fatalf("Verify() with the rotated key error = %v", err)
+	}
+	if got := provider.jwks.Load(); got != 2 {
+		t.Errorf("key set requests = %d, want 2", got)
+	}
+}
+
+func TestOIDCVerifyProviderUnavailable(t *testing.T) {
+	provider := newTestProvider(t)
+	provider.down.Store(true)
+	token := signRS256(t, provider.key("key-1"), "key-1", provider.claims(nil))
+
+	now := time.Now()
+	verifier := NewOIDCVerifier(provider.server.URL, "schools-api", "roles", nil)
+	verifier.now = func() time.Time { return now }
+
+	for range 3 {
+		if _, err := verifier.Verify(context.Background(), token); err == nil || errors.Is(err, ErrInvalidToken) {
+			t.Fatalf("Verify() error = %v, want a discovery error", err)
+		}
+	}
+	if got := provider.discovery.Load(); got != 1 {
+		t.Errorf("discovery requests = %d, want 1 within %s", got, oidcRediscoverInterval)
+	}
+
+	provider.down.Store(false)
+	now = now.Add(oidcRediscoverInterval)
+	if _, err := verifier.Verify(context.Background(), token); err != nil {
+		t.Fatalf("Verify() after the provider is back error = %v", err)
+	}
+	if got := provider.discovery.Load(); got != 2 {
+		t.Errorf("discovery requests = %d, want 2", got)
+	}
+}
+
+func TestOIDCVerifyConcurrentDiscovery(t *testing.T) {
+	provider := newTestProvider(t)
+	verifier := NewOIDCVerifier(provider.server.URL, "schools-api", "roles", nil)
+	token := signRS256(t, provider.key("key-1"), "key-1", provider.claims(nil))
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 20)
+	for range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := verifier.Verify(context.Background(), token)
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Errorf("Verify() error = %v", err)
+		}
+	}
+	if got := provider.discovery.Load(); got != 1 {
+		t.Errorf("discovery requests = %d, want 1 shared by all callers", got)
+	}
+}
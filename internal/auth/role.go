@@ -0,0 +1,53 @@
+package auth
+
+import "context"
+
+// Role is the access level of a caller. Roles are ordered: each role may do everything
+// the roles before it may.
+type Role string
+
+const (
+	// RolePublic reads the dataset and uses the visitor features (locations, chat)
+	RolePublic Role = "public"
+	// RolePartner additionally corrects school data
+	RolePartner Role = "partner"
+	// RoleAdmin additionally runs jobs, exports the database and sees operational data
+	RoleAdmin Role = "admin"
+)
+
+var roleLevels = map[Role]int{
+	RolePublic:  1,
+	RolePartner: 2,
+	RoleAdmin:   3,
+}
+
+// Valid reports whether r is a known role
+func (r Role) Valid() bool {
+	_, ok := roleLevels[r]
+	return ok
+}
+
+// Allows reports whether a caller with role r may access routes that require role required
+func (r Role) Allows(required Role) bool {
+	return r.Valid() && roleLevels[r] >= roleLevels[required]
+}
+
+// Principal is the authenticated caller of a request
+type Principal struct {
+	Role    Role
+	Subject string // Which credential matched, e.g. "api_key" or "partner_key:1"; never the secret
+	UserID  string // Identity provider user (sub claim) for OIDC tokens; empty for API keys
+}
+
+type principalKey struct{}
+
+// WithPrincipal returns a context carrying the caller
+func WithPrincipal(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// FromContext returns the caller of the request; ok is false for unauthenticated requests
+func FromContext(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(Principal)
+	return p, ok
+}
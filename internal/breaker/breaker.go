@@ -0,0 +1,163 @@
+// Package breaker implements circuit breakers for external APIs. After a run of failures
+// the breaker opens and calls fail fast with ErrOpen for a cooldown, so an outage of a
+// third party costs callers nothing instead of a timeout each. After the cooldown one
+// trial call is let through; its outcome closes the breaker or opens it again.
+package breaker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"schools-be/internal/metrics"
+)
+
+// Defaults for the external APIs: five failures in a row open the breaker for half a minute
+const (
+	DefaultThreshold = 5
+	DefaultCooldown  = 30 * time.Second
+)
+
+// ErrOpen is returned (wrapped in an OpenError) for calls rejected while a breaker is open
+var ErrOpen = errors.New("circuit breaker is open")
+
+// OpenError rejects a call to an API whose breaker is open
+type OpenError struct {
+	Name       string
+	RetryAfter time.Duration // Until the next trial call is let through
+}
+
+func (e *OpenError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Name, ErrOpen)
+}
+
+func (e *OpenError) Unwrap() error {
+	return ErrOpen
+}
+
+var breakerOpen = metrics.NewGaugeVec("schools_circuit_breaker_open",
+	"Whether the circuit breaker of an external API is open (1) or closed (0)", "name")
+
+type state int
+
+const (
+	closed state = iota
+	open
+	halfOpen
+)
+
+// Breaker guards calls to one external API
+type Breaker struct {
+	name      string
+	threshold int
+	cooldown  time.Duration
+	logger    *slog.Logger
+
+	mu       sync.Mutex
+	state    state
+	failures int
+	openedAt time.Time
+}
+
+// New creates a breaker that opens after threshold consecutive failures and stays open for
+// cooldown. A threshold below 1 disables the breaker.
+func New(name string, threshold int, cooldown time.Duration, logger *slog.Logger) *Breaker {
+	breakerOpen.Set(0, name)
+	return &Breaker{
+		name:      name,
+		threshold: threshold,
+		cooldown:  cooldown,
+		logger:    logger.With(slog.String("breaker", name)),
+	}
+}
+
+// Do runs fn unless the breaker is open and records its outcome
+func (b *Breaker) Do(fn func() error) error {
+	if err := b.Allow(); err != nil {
+		return err
+	}
+	err := fn()
+	b.Record(err)
+	return err
+}
+
+// Allow returns ErrOpen while the breaker is open; callers that are let through must
+// report the outcome with Record
+func (b *Breaker) Allow() error {
+	if b.threshold < 1 {
+		return nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case open:
+		if wait := b.cooldown - time.Since(b.openedAt); wait > 0 {
+			return &OpenError{Name: b.name, RetryAfter: wait}
+		}
+		b.state = halfOpen // Let one trial call through
+		return nil
+	case halfOpen:
+		return &OpenError{Name: b.name, RetryAfter: b.cooldown}
+	}
+	return nil
+}
+
+// Record reports the outcome of a call that Allow let through. Calls canceled by the caller
+// say nothing about the API and are not counted. Callers pass nil for errors that are the
+// API's answer rather than its failure (e.g. "no route found").
+func (b *Breaker) Record(err error) {
+	if b.threshold < 1 {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if errors.Is(err, context.Canceled) {
+		if b.state == halfOpen {
+			b.state = open // The next call becomes the trial
+		}
+		return
+	}
+
+	if err == nil {
+		if b.state != closed {
+			b.logger.Info("circuit breaker closed", slog.String("name", b.name))
+			breakerOpen.Set(0, b.name)
+		}
+		b.state = closed
+		b.failures = 0
+		return
+	}
+
+	b.failures++
+	if b.state == halfOpen || b.failures >= b.threshold {
+		if b.state != open {
+			b.logger.Warn("circuit breaker opened",
+				slog.String("name", b.name),
+				slog.Int("failures", b.failures),
+				slog.Duration("cooldown", b.cooldown),
+				slog.String("error", err.Error()),
+			)
+			breakerOpen.Set(1, b.name)
+		}
+		b.state = open
+		b.openedAt = time.Now()
+	}
+}
+
+// Available reports whether calls are currently let through
+func (b *Breaker) Available() bool {
+	if b.threshold < 1 {
+		return true
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state != open || time.Since(b.openedAt) >= b.cooldown
+}
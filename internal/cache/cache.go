@@ -0,0 +1,135 @@
+package cache
+
+import (
+	"sync"
+	"time"
+
+	"schools-be/internal/metrics"
+)
+
+var (
+	cacheHits = metrics.NewCounterVec("schools_cache_hits_total",
+		"Number of cache lookups served from cache", "cache")
+	cacheMisses = metrics.NewCounterVec("schools_cache_misses_total",
+		"Number of cache lookups that had to load from the source", "cache")
+	cacheInvalidations = metrics.NewCounterVec("schools_cache_invalidations_total",
+		"Number of times a cache was invalidated by a write", "cache")
+)
+
+type entry struct {
+	value     interface{}
+	expiresAt time.Time
+}
+
+// Cache is a simple in-memory TTL cache. A nil *Cache is valid and never caches,
+// so callers can treat caching as optional.
+type Cache struct {
+	name    string
+	ttl     time.Duration
+	mu      sync.RWMutex
+	entries map[string]entry
+
+	// generation counts invalidations, so a load that started before one does not store
+	// its stale result after it
+	generation uint64
+}
+
+// New creates a cache with the given name (used as metrics label) and TTL.
+// Returns nil (caching disabled) when ttl is not positive.
+func New(name string, ttl time.Duration) *Cache {
+	if ttl <= 0 {
+		return nil
+	}
+	return &Cache{
+		name:    name,
+		ttl:     ttl,
+		entries: make(map[string]entry),
+	}
+}
+
+// Get returns a cached value if present and not expired
+func (c *Cache) Get(key string) (interface{}, bool) {
+	if c == nil {
+		return nil, false
+	}
+
+	c.mu.RLock()
+	e, ok := c.entries[key]
+	c.mu.RUnlock()
+
+	if !ok || time.Now().After(e.expiresAt) {
+		cacheMisses.Inc(c.name)
+		return nil, false
+	}
+
+	cacheHits.Inc(c.name)
+	return e.value, true
+}
+
+// Set stores a value under key for the cache TTL
+func (c *Cache) Set(key string, value interface{}) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	c.entries[key] = entry{value: value, expiresAt: time.Now().Add(c.ttl)}
+	c.mu.Unlock()
+}
+
+// currentGeneration returns the number of invalidations so far
+func (c *Cache) currentGeneration() uint64 {
+	if c == nil {
+		return 0
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.generation
+}
+
+// setIfGeneration stores a value like Set unless the cache was invalidated since generation
+func (c *Cache) setIfGeneration(key string, value interface{}, generation uint64) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	if c.generation == generation {
+		c.entries[key] = entry{value: value, expiresAt: time.Now().Add(c.ttl)}
+	}
+	c.mu.Unlock()
+}
+
+// Invalidate drops all cached entries (called on writes)
+func (c *Cache) Invalidate() {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	c.entries = make(map[string]entry)
+	c.generation++
+	c.mu.Unlock()
+	cacheInvalidations.Inc(c.name)
+}
+
+// GetOrLoad returns the cached value for key or calls load and caches its result.
+// Errors are never cached, and neither is a result whose load overlapped an invalidation:
+// it may predate the write that invalidated the cache.
+func GetOrLoad[T any](c *Cache, key string, load func() (T, error)) (T, error) {
+	if cached, ok := c.Get(key); ok {
+		if value, ok := cached.(T); ok {
+			return value, nil
+		}
+	}
+
+	generation := c.currentGeneration()
+	value, err := load()
+	if err != nil {
+		return value, err
+	}
+
+	c.setIfGeneration(key, value, generation)
+	return value, nil
+}
@@ -0,0 +1,29 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+// TestGetOrLoadInvalidatedDuringLoad checks that a load overlapping an invalidation does not
+// store its result, so the next read loads the data written in between
+func TestGetOrLoadInvalidatedDuringLoad(t *testing.T) {
+	c := New("test", time.Minute)
+
+	value, err := GetOrLoad(c, "key", func() (string, error) {
+		c.Invalidate() // a write lands while the stale value is being read
+		return "stale", nil
+	})
+	if err != nil || value != "stale" {
+		t.Fatalf("first load = %q, %v", value, err)
+	}
+
+	value, _ = GetOrLoad(c, "key", func() (string, error) { return "fresh", nil })
+	if value != "fresh" {
+		t.Errorf("after invalidation during load got %q, want fresh", value)
+	}
+	value, _ = GetOrLoad(c, "key", func() (string, error) { return "reloaded", nil })
+	if value != "fresh" {
+		t.Errorf("undisturbed load was not cached, got %q", value)
+	}
+}
@@ -0,0 +1,86 @@
+// Package capability detects at startup which optional dependencies are present, so the
+// endpoints and jobs needing a missing one are switched off with a reason instead of failing
+// mid-request.
+package capability
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+
+	"schools-be/internal/config"
+)
+
+// Missing is an optional dependency that is not available, with the features that need it
+type Missing struct {
+	Dependency string
+	Reason     string   // Served with the 503 of the dependent endpoints
+	Features   []string // Endpoint groups of config.Features
+	Jobs       []string // Scheduler jobs that cannot run on this instance
+}
+
+// chromeLocations are the executables chromedp tries when starting a browser, in its order
+func chromeLocations() []string {
+	switch runtime.GOOS {
+	case "darwin":
+		return []string{
+			"/Applications/Chromium.app/Contents/MacOS/Chromium",
+			"/Applications/Google Chrome.app/Contents/MacOS/Google Chrome",
+		}
+	case "windows":
+		return []string{
+			"chrome",
+			"chrome.exe",
+			`C:\Program Files (x86)\Google\Chrome\Application\chrome.exe`,
+			`C:\Program Files\Google\Chrome\Application\chrome.exe`,
+			filepath.Join(os.Getenv("USERPROFILE"), `AppData\Local\Google\Chrome\Application\chrome.exe`),
+			filepath.Join(os.Getenv("USERPROFILE"), `AppData\Local\Chromium\Application\chrome.exe`),
+		}
+	default:
+		return []string{
+			"headless_shell", "headless-shell", "chromium", "chromium-browser",
+			"google-chrome", "google-chrome-stable", "google-chrome-beta", "google-chrome-unstable",
+			"/usr/bin/google-chrome", "/usr/local/bin/chrome", "/snap/bin/chromium", "chrome",
+		}
+	}
+}
+
+// Detect checks for Chrome and the API keys of Gemini and OpenRouteService. jobDetails is
+// the name of the scheduler job that scrapes with Chrome.
+func Detect(cfg *config.Config, jobDetails string) []Missing {
+	var missing []Missing
+	if !ChromeInstalled() {
+		missing = append(missing, Missing{
+			Dependency: "chrome",
+			Reason:     "Chrome or Chromium is not installed on this server",
+			Features:   []string{"pdf"},
+			Jobs:       []string{jobDetails},
+		})
+	}
+	if cfg.GeminiAPIKey == "" {
+		missing = append(missing, Missing{
+			Dependency: "gemini",
+			Reason:     "no GEMINI_API_KEY is configured",
+			Features:   []string{"ai", "chat"},
+		})
+	}
+	if cfg.OpenRouteServiceAPIKey == "" && cfg.OpenRouteServiceBaseURL == config.DefaultOpenRouteServiceBaseURL {
+		missing = append(missing, Missing{
+			Dependency: "openrouteservice",
+			Reason:     "no OPENROUTESERVICE_API_KEY is configured",
+			Features:   []string{"routes"},
+		})
+	}
+	return missing
+}
+
+// ChromeInstalled reports whether chromedp can find a browser to start
+func ChromeInstalled() bool {
+	for _, name := range chromeLocations() {
+		if _, err := exec.LookPath(name); err == nil {
+			return true
+		}
+	}
+	return false
+}
@@ -2,36 +2,178 @@ package config
 
 import (
 	"fmt"
+	"log/slog"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
 )
 
 type Config struct {
-	Port                   string
-	Env                    string
-	DBPath                 string
-	FetchSchedule          string
-	APITimeout             time.Duration
-	APIKey                 string
-	GeminiAPIKey           string
-	OpenRouteServiceAPIKey string
+	Port                    string
+	Env                     string
+	DBPath                  string
+	DBMaxReadConns          int
+	ExportDir               string
+	FetchSchedule           string
+	InstanceID              string
+	JobLeaseTTL             time.Duration
+	SchedulerEnabled        bool
+	JobPollInterval         time.Duration
+	QueueDriver             string
+	QueueMaxAttempts        int
+	QueueRetryDelay         time.Duration
+	DetailsScrapeBudget     time.Duration
+	DetailsScrapeOrder      string
+	APITimeout              time.Duration
+	APIKey                  string
+	AdminAPIKey             string
+	PartnerAPIKeys          []string
+	SecondaryAPIKey         string    // Accepted next to API_KEY while keys are rotated
+	SecondaryAPIKeyUntil    time.Time // Zero accepts the secondary key until it is removed
+	SecondaryAdminKey       string
+	SecondaryAdminKeyUntil  time.Time
+	OIDCIssuer              string
+	OIDCAudience            string
+	OIDCRoleClaim           string
+	URLSigningKey           string
+	SignedURLTTL            time.Duration
+	GeminiAPIKey            string
+	AIPromptDir             string
+	TemplateDir             string
+	MigrationsDir           string
+	AIPromptTemplates       []string
+	AISummaryMaxWords       int
+	AISummaryMaxAttempts    int
+	ChatSessionTTL          time.Duration
+	ChatMaxHistory          int
+	OpenRouteServiceAPIKey  string
+	OpenRouteServiceBaseURL string
+	TravelTimeCacheTTL      time.Duration
+	GooglePlacesAPIKey      string
+	ExternalRatingsTTL      time.Duration
+	RepositoryCacheTTL      time.Duration
+	SlowQueryThreshold      time.Duration
+	LogRedact               []string
+	SentryDSN               string
+	AnalyticsEnabled        bool
+	AnalyticsFlushInterval  time.Duration
+	UsageFlushInterval      time.Duration
+	QuotaDailyRequests      int
+	QuotaDailyBytes         int
+	ApplicationsURL         string
+	ExamResultsURL          string
+	MapTileURL              string
+	MapCacheDir             string
+	CacheDir                string
+	NormalizationRules      string
+	SchoolAliases           string
+	PublicBaseURL           string
+	PreviewCacheTTL         time.Duration
+	PublicCacheTTL          time.Duration
+	QueryMaxRows            int
+	QueryTimeout            time.Duration
+	LogFormat               string
+
+	// Reloaded on SIGHUP and POST /api/v1/admin/config/reload, see Live
+	LogLevel         slog.Level
+	LogDebug         []string
+	CORSOrigins      []string
+	DisabledFeatures []string
+
+	// Warnings lists settings that are valid but probably unintended, such as production
+	// without an API key; callers log them at startup
+	Warnings []string
 }
 
+// DefaultOpenRouteServiceBaseURL is the public OpenRouteService API
+const DefaultOpenRouteServiceBaseURL = "https://api.openrouteservice.org/v2"
+
+// DefaultMapTileURL is the OpenStreetMap standard tile layer; {z}, {x} and {y} are replaced per tile
+const DefaultMapTileURL = "https://tile.openstreetmap.org/{z}/{x}/{y}.png"
+
+// Load reads the configuration from the environment and an optional .env file. Malformed
+// or unusable values fail the whole load with an *Error listing every problem.
 func Load() (*Config, error) {
 	// Load .env file if it exists (ignore error if it doesn't)
 	_ = godotenv.Load()
 
+	l := &loader{}
 	cfg := &Config{
-		Port:                   getEnv("PORT", "8080"),
-		Env:                    getEnv("ENV", "development"),
-		DBPath:                 getEnv("DB_PATH", "./data/schools.db"),
-		FetchSchedule:          getEnv("FETCH_SCHEDULE", "0 2 * * 0"), // 2 AM Sunday
-		APITimeout:             parseDuration(getEnv("API_TIMEOUT", "30s"), 30*time.Second),
-		APIKey:                 getEnv("API_KEY", ""),
-		GeminiAPIKey:           getEnv("GEMINI_API_KEY", ""),
-		OpenRouteServiceAPIKey: getEnv("OPENROUTESERVICE_API_KEY", ""),
+		Port:                    getEnv("PORT", "8080"),
+		Env:                     getEnv("ENV", "development"),
+		DBPath:                  getEnv("DB_PATH", "./data/schools.db"),
+		DBMaxReadConns:          l.int("DB_MAX_READ_CONNS", 4),
+		ExportDir:               getEnv("EXPORT_DIR", "./data/exports"),
+		FetchSchedule:           getEnv("FETCH_SCHEDULE", "0 2 * * 0"), // 2 AM Sunday
+		InstanceID:              getEnv("INSTANCE_ID", defaultInstanceID()),
+		JobLeaseTTL:             l.duration("JOB_LEASE_TTL", time.Minute),
+		SchedulerEnabled:        l.bool("SCHEDULER_ENABLED", true), // false leaves scheduled jobs and scraping to `schools-be worker`
+		JobPollInterval:         l.duration("JOB_POLL_INTERVAL", 10*time.Second),
+		QueueDriver:             getEnv("QUEUE_DRIVER", "memory"), // database shares detail scraping between workers
+		QueueMaxAttempts:        l.int("QUEUE_MAX_ATTEMPTS", 3),
+		QueueRetryDelay:         l.duration("QUEUE_RETRY_DELAY", 5*time.Minute),
+		DetailsScrapeBudget:     l.duration("DETAILS_SCRAPE_BUDGET", 3*time.Hour+30*time.Minute), // 0 disables the budget
+		DetailsScrapeOrder:      getEnv("DETAILS_SCRAPE_ORDER", "popular"),
+		APITimeout:              l.duration("API_TIMEOUT", 30*time.Second),
+		APIKey:                  getEnv("API_KEY", ""),
+		AdminAPIKey:             getEnv("ADMIN_API_KEY", ""), // empty disables admin endpoints
+		PartnerAPIKeys:          parseList(getEnv("PARTNER_API_KEYS", "")),
+		SecondaryAPIKey:         getEnv("API_KEY_SECONDARY", ""),
+		SecondaryAPIKeyUntil:    l.time("API_KEY_SECONDARY_UNTIL"),
+		SecondaryAdminKey:       getEnv("ADMIN_API_KEY_SECONDARY", ""),
+		SecondaryAdminKeyUntil:  l.time("ADMIN_API_KEY_SECONDARY_UNTIL"),
+		OIDCIssuer:              getEnv("OIDC_ISSUER", ""), // empty disables token authentication
+		OIDCAudience:            getEnv("OIDC_AUDIENCE", ""),
+		OIDCRoleClaim:           getEnv("OIDC_ROLE_CLAIM", "roles"), // dot path, e.g. realm_access.roles
+		URLSigningKey:           getEnv("URL_SIGNING_KEY", ""),      // empty uses a random key per process
+		SignedURLTTL:            l.duration("SIGNED_URL_TTL", 15*time.Minute),
+		GeminiAPIKey:            getEnv("GEMINI_API_KEY", ""),
+		AIPromptDir:             getEnv("AI_PROMPT_DIR", ""),  // empty uses the templates embedded in the binary
+		TemplateDir:             getEnv("TEMPLATE_DIR", ""),   // admin.html and school_profile.html found here replace the embedded ones
+		MigrationsDir:           getEnv("MIGRATIONS_DIR", ""), // empty uses the migrations embedded in the binary
+		AIPromptTemplates:       parseList(getEnv("AI_PROMPT_TEMPLATES", "school_summary")),
+		AISummaryMaxWords:       l.int("AI_SUMMARY_MAX_WORDS", 300),
+		AISummaryMaxAttempts:    l.int("AI_SUMMARY_MAX_ATTEMPTS", 2), // 1 disables re-prompting
+		ChatSessionTTL:          l.duration("CHAT_SESSION_TTL", 24*time.Hour),
+		ChatMaxHistory:          l.int("CHAT_MAX_HISTORY", 20),
+		OpenRouteServiceAPIKey:  getEnv("OPENROUTESERVICE_API_KEY", ""),
+		OpenRouteServiceBaseURL: strings.TrimSuffix(getEnv("OPENROUTESERVICE_BASE_URL", DefaultOpenRouteServiceBaseURL), "/"),
+		TravelTimeCacheTTL:      l.duration("TRAVEL_TIME_CACHE_TTL", 168*time.Hour),             // 0 disables caching
+		GooglePlacesAPIKey:      getEnv("GOOGLE_PLACES_API_KEY", ""),                            // empty disables external ratings
+		ExternalRatingsTTL:      l.duration("EXTERNAL_RATINGS_TTL", 168*time.Hour),              // looked up again by the first refresh after it
+		RepositoryCacheTTL:      l.duration("REPOSITORY_CACHE_TTL", 5*time.Minute),              // 0 disables caching
+		LogRedact:               parseList(getEnv("LOG_REDACT", "api_keys,emails,coordinates")), // "none" disables redaction
+		SentryDSN:               getEnv("SENTRY_DSN", ""),                                       // empty disables error reporting
+		AnalyticsEnabled:        l.bool("ANALYTICS_ENABLED", false),
+		AnalyticsFlushInterval:  l.duration("ANALYTICS_FLUSH_INTERVAL", time.Minute),
+		UsageFlushInterval:      l.duration("USAGE_FLUSH_INTERVAL", time.Minute),
+		QuotaDailyRequests:      l.int("QUOTA_DAILY_REQUESTS", 0), // per partner key, 0 is unlimited
+		QuotaDailyBytes:         l.int("QUOTA_DAILY_BYTES", 0),
+		SlowQueryThreshold:      l.duration("SLOW_QUERY_THRESHOLD", 200*time.Millisecond),
+		ApplicationsURL:         getEnv("APPLICATIONS_URL", ""), // empty disables the Anmeldezahlen import
+		ExamResultsURL:          getEnv("EXAM_RESULTS_URL", ""), // empty disables the Abitur results import
+		MapTileURL:              getEnv("MAP_TILE_URL", DefaultMapTileURL),
+		MapCacheDir:             getEnv("MAP_CACHE_DIR", "./data/maps"),
+		CacheDir:                getEnv("CACHE_DIR", "./cache"),
+		NormalizationRules:      getEnv("NORMALIZATION_RULES", ""),                      // empty uses the built-in scraper rules
+		SchoolAliases:           getEnv("SCHOOL_ALIASES", ""),                           // empty uses the built-in school number mapping
+		PublicBaseURL:           strings.TrimSuffix(getEnv("PUBLIC_BASE_URL", ""), "/"), // empty derives it from the request
+		PreviewCacheTTL:         l.duration("PREVIEW_CACHE_TTL", 24*time.Hour),          // 0 disables caching
+		PublicCacheTTL:          l.duration("PUBLIC_CACHE_TTL", time.Hour),              // 0 disables caching
+		QueryMaxRows:            l.int("QUERY_MAX_ROWS", 1000),
+		QueryTimeout:            l.duration("QUERY_TIMEOUT", 5*time.Second),
+		LogFormat:               getEnv("LOG_FORMAT", "json"),
+		LogLevel:                l.level("LOG_LEVEL", slog.LevelInfo),
+		LogDebug:                parseList(getEnv("LOG_DEBUG", "")), // e.g. scraper or scraper=details
+		CORSOrigins:             parseList(getEnv("CORS_ALLOWED_ORIGINS", "http://localhost:3000,http://localhost:8080")),
+		DisabledFeatures:        parseList(getEnv("DISABLED_FEATURES", "")),
+	}
+
+	cfg.validate(l)
+	if len(l.errs) > 0 {
+		return nil, &Error{Problems: l.errs}
 	}
 
 	return cfg, nil
@@ -44,12 +186,36 @@ func getEnv(key, defaultValue string) string {
 	return defaultValue
 }
 
-func parseDuration(s string, defaultValue time.Duration) time.Duration {
-	duration, err := time.ParseDuration(s)
+// defaultInstanceID names this process among instances sharing the database
+func defaultInstanceID() string {
+	host, err := os.Hostname()
 	if err != nil {
-		return defaultValue
+		host = "unknown"
+	}
+	return fmt.Sprintf("%s-%d", host, os.Getpid())
+}
+
+func parseList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
 	}
-	return duration
+	return items
+}
+
+// LogRedactCategories returns the enabled log redaction categories
+func (c *Config) LogRedactCategories() []string {
+	if len(c.LogRedact) == 1 && c.LogRedact[0] == "none" {
+		return nil
+	}
+	return c.LogRedact
+}
+
+// OIDCEnabled reports whether identity provider tokens are accepted
+func (c *Config) OIDCEnabled() bool {
+	return c.OIDCIssuer != "" && c.OIDCAudience != ""
 }
 
 func (c *Config) IsDevelopment() bool {
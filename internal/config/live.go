@@ -0,0 +1,176 @@
+package config
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"reflect"
+	"slices"
+	"sync"
+
+	"github.com/joho/godotenv"
+)
+
+// Features are the endpoint groups DISABLED_FEATURES can switch off
+var Features = []string{"ai", "chat", "routes", "query", "previews", "exports", "pdf", "corrections", "search"}
+
+// ReloadResult describes what a reload changed
+type ReloadResult struct {
+	Applied         []string `json:"applied"`          // Settings now in effect
+	RestartRequired []string `json:"restart_required"` // Changed settings that only a restart applies
+}
+
+// Live holds the configuration of the running server and applies the settings that can
+// change without a restart: the log level and debug loggers, the CORS origins, API keys,
+// partner quotas and disabled features. Reloading instead of restarting keeps long-running scrapes
+// alive.
+type Live struct {
+	reloadMu sync.Mutex // One reload at a time, since reloads change the process environment
+
+	mu          sync.RWMutex
+	cfg         *Config
+	listeners   []func(*Config)
+	unavailable map[string]string // Features whose dependency is missing, with the reason
+}
+
+// NewLive starts from the configuration loaded at startup
+func NewLive(cfg *Config) *Live {
+	return &Live{cfg: cfg}
+}
+
+// Config returns the current configuration; do not modify it
+func (l *Live) Config() *Config {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.cfg
+}
+
+// OnReload registers fn to be called with the new configuration after each reload that
+// changed a setting
+func (l *Live) OnReload(fn func(*Config)) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.listeners = append(l.listeners, fn)
+}
+
+// FeatureEnabled reports whether an endpoint group is neither switched off by
+// DISABLED_FEATURES nor unavailable
+func (l *Live) FeatureEnabled(feature string) bool {
+	enabled, _ := l.FeatureStatus(feature)
+	return enabled
+}
+
+// FeatureStatus reports whether an endpoint group is enabled, and otherwise why not
+func (l *Live) FeatureStatus(feature string) (enabled bool, reason string) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	if reason, ok := l.unavailable[feature]; ok {
+		return false, reason
+	}
+	if slices.Contains(l.cfg.DisabledFeatures, feature) {
+		return false, feature + " is disabled"
+	}
+	return true, ""
+}
+
+// SetUnavailable switches off a feature whose dependency is missing, such as an API key or
+// a browser. Unlike DISABLED_FEATURES this lasts until restart.
+func (l *Live) SetUnavailable(feature, reason string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.unavailable == nil {
+		l.unavailable = make(map[string]string)
+	}
+	l.unavailable[feature] = reason
+}
+
+// OriginAllowed reports whether browsers may call the API from origin
+func (l *Live) OriginAllowed(origin string) bool {
+	origins := l.Config().CORSOrigins
+	return slices.Contains(origins, "*") || slices.Contains(origins, origin)
+}
+
+// Reload reads the .env file again, with its values taking precedence over the process
+// environment, and applies the reloadable settings. A variable removed from the file keeps
+// its last value. An invalid configuration is rejected as a whole and nothing changes.
+func (l *Live) Reload() (*ReloadResult, error) {
+	l.reloadMu.Lock()
+	defer l.reloadMu.Unlock()
+
+	values, err := godotenv.Read()
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	previous := make(map[string]*string, len(values))
+	for key, value := range values {
+		if old, ok := os.LookupEnv(key); ok {
+			previous[key] = &old
+		} else {
+			previous[key] = nil
+		}
+		os.Setenv(key, value)
+	}
+
+	next, err := Load()
+	if err != nil {
+		// Put the environment back so the next reload starts from the running values
+		for key, old := range previous {
+			if old == nil {
+				os.Unsetenv(key)
+			} else {
+				os.Setenv(key, *old)
+			}
+		}
+		return nil, err
+	}
+
+	l.mu.Lock()
+	current := l.cfg
+	updated := *current
+	updated.LogLevel = next.LogLevel
+	updated.LogDebug = next.LogDebug
+	updated.CORSOrigins = next.CORSOrigins
+	updated.DisabledFeatures = next.DisabledFeatures
+	updated.APIKey = next.APIKey
+	updated.AdminAPIKey = next.AdminAPIKey
+	updated.PartnerAPIKeys = next.PartnerAPIKeys
+	updated.SecondaryAPIKey = next.SecondaryAPIKey
+	updated.SecondaryAPIKeyUntil = next.SecondaryAPIKeyUntil
+	updated.SecondaryAdminKey = next.SecondaryAdminKey
+	updated.SecondaryAdminKeyUntil = next.SecondaryAdminKeyUntil
+	updated.QuotaDailyRequests = next.QuotaDailyRequests
+	updated.QuotaDailyBytes = next.QuotaDailyBytes
+	result := diff(current, &updated, next)
+	if len(result.Applied) > 0 {
+		l.cfg = &updated
+	}
+	listeners := l.listeners
+	l.mu.Unlock()
+
+	if len(result.Applied) > 0 {
+		for _, fn := range listeners {
+			fn(&updated)
+		}
+	}
+	return result, nil
+}
+
+// diff lists the settings updated changed compared to current, and those next changed that
+// were not applied
+func diff(current, updated, next *Config) *ReloadResult {
+	result := &ReloadResult{Applied: []string{}, RestartRequired: []string{}}
+	cur, upd, nxt := reflect.ValueOf(current).Elem(), reflect.ValueOf(updated).Elem(), reflect.ValueOf(next).Elem()
+	for i := 0; i < cur.NumField(); i++ {
+		name := cur.Type().Field(i).Name
+		if name == "Warnings" {
+			continue
+		}
+		switch {
+		case !reflect.DeepEqual(cur.Field(i).Interface(), upd.Field(i).Interface()):
+			result.Applied = append(result.Applied, name)
+		case !reflect.DeepEqual(cur.Field(i).Interface(), nxt.Field(i).Interface()):
+			result.RestartRequired = append(result.RestartRequired, name)
+		}
+	}
+	return result
+}
@@ -0,0 +1,290 @@
+package config
+
+import (
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// Error lists every invalid setting found by Load
+type Error struct {
+	Problems []string
+}
+
+func (e *Error) Error() string {
+	return "invalid configuration:\n  - " + strings.Join(e.Problems, "\n  - ")
+}
+
+// loader reads typed environment variables and collects malformed values instead of
+// silently falling back to the default, so Load can report all of them at once
+type loader struct {
+	errs []string
+}
+
+func (l *loader) errorf(format string, args ...interface{}) {
+	l.errs = append(l.errs, fmt.Sprintf(format, args...))
+}
+
+func (l *loader) duration(key string, defaultValue time.Duration) time.Duration {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return defaultValue
+	}
+	d, err := time.ParseDuration(raw)
+	if err != nil {
+		l.errorf("%s: %q is not a duration, use e.g. 30s, 5m or 24h", key, raw)
+		return defaultValue
+	}
+	if d < 0 {
+		l.errorf("%s: must not be negative, got %s", key, raw)
+	}
+	return d
+}
+
+// time parses an RFC 3339 timestamp; unset is the zero time
+func (l *loader) time(key string) time.Time {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return time.Time{}
+	}
+	t, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		l.errorf("%s: %q is not an RFC 3339 timestamp, use e.g. 2026-03-01T00:00:00Z", key, raw)
+	}
+	return t
+}
+
+func (l *loader) int(key string, defaultValue int) int {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return defaultValue
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil {
+		l.errorf("%s: %q is not a whole number", key, raw)
+		return defaultValue
+	}
+	if n < 0 {
+		l.errorf("%s: must not be negative, got %d", key, n)
+	}
+	return n
+}
+
+func (l *loader) bool(key string, defaultValue bool) bool {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return defaultValue
+	}
+	b, err := strconv.ParseBool(raw)
+	if err != nil {
+		l.errorf("%s: %q is not a boolean, use true or false", key, raw)
+		return defaultValue
+	}
+	return b
+}
+
+func (l *loader) level(key string, defaultValue slog.Level) slog.Level {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return defaultValue
+	}
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(raw)); err != nil {
+		l.errorf("%s: %q is not a log level, use debug, info, warn or error", key, raw)
+		return defaultValue
+	}
+	return level
+}
+
+// validate checks the values that parse but cannot work, and fills in Warnings
+func (c *Config) validate(l *loader) {
+	if port, err := strconv.Atoi(c.Port); err != nil || port < 1 || port > 65535 {
+		l.errorf("PORT: %q is not a port number between 1 and 65535", c.Port)
+	}
+
+	// Same parser as the scheduler (five fields, or descriptors such as @daily)
+	if _, err := cron.ParseStandard(c.FetchSchedule); err != nil {
+		l.errorf("FETCH_SCHEDULE: %q is not a cron expression: %v", c.FetchSchedule, err)
+	}
+
+	for _, setting := range []struct {
+		key   string
+		value time.Duration
+	}{
+		{"API_TIMEOUT", c.APITimeout},
+		{"SIGNED_URL_TTL", c.SignedURLTTL},
+		{"CHAT_SESSION_TTL", c.ChatSessionTTL},
+		{"ANALYTICS_FLUSH_INTERVAL", c.AnalyticsFlushInterval},
+		{"USAGE_FLUSH_INTERVAL", c.UsageFlushInterval},
+		{"QUERY_TIMEOUT", c.QueryTimeout},
+		{"JOB_LEASE_TTL", c.JobLeaseTTL},
+		{"JOB_POLL_INTERVAL", c.JobPollInterval},
+		{"EXTERNAL_RATINGS_TTL", c.ExternalRatingsTTL},
+	} {
+		if setting.value == 0 {
+			l.errorf("%s: must be greater than 0", setting.key)
+		}
+	}
+	if c.JobLeaseTTL > 0 && c.JobLeaseTTL < time.Second {
+		l.errorf("JOB_LEASE_TTL: must be at least 1s, got %s", c.JobLeaseTTL)
+	}
+	for _, setting := range []struct {
+		key   string
+		value int
+	}{
+		{"DB_MAX_READ_CONNS", c.DBMaxReadConns},
+		{"AI_SUMMARY_MAX_WORDS", c.AISummaryMaxWords},
+		{"AI_SUMMARY_MAX_ATTEMPTS", c.AISummaryMaxAttempts},
+		{"CHAT_MAX_HISTORY", c.ChatMaxHistory},
+		{"QUERY_MAX_ROWS", c.QueryMaxRows},
+		{"QUEUE_MAX_ATTEMPTS", c.QueueMaxAttempts},
+	} {
+		if setting.value == 0 {
+			l.errorf("%s: must be at least 1", setting.key)
+		}
+	}
+
+	for _, setting := range []struct{ key, value string }{
+		{"OPENROUTESERVICE_BASE_URL", c.OpenRouteServiceBaseURL},
+		{"OIDC_ISSUER", c.OIDCIssuer},
+		{"APPLICATIONS_URL", c.ApplicationsURL},
+		{"EXAM_RESULTS_URL", c.ExamResultsURL},
+		{"PUBLIC_BASE_URL", c.PublicBaseURL},
+		{"MAP_TILE_URL", c.MapTileURL},
+	} {
+		if setting.value == "" {
+			continue
+		}
+		if u, err := url.Parse(setting.value); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			l.errorf("%s: %q is not an absolute http(s) URL", setting.key, setting.value)
+		}
+	}
+	for _, placeholder := range []string{"{z}", "{x}", "{y}"} {
+		if !strings.Contains(c.MapTileURL, placeholder) {
+			l.errorf("MAP_TILE_URL: %q lacks the %s placeholder", c.MapTileURL, placeholder)
+		}
+	}
+
+	if c.QueueDriver != "memory" && c.QueueDriver != "database" {
+		l.errorf("QUEUE_DRIVER: %q is not a queue driver, use memory or database", c.QueueDriver)
+	}
+
+	if c.DetailsScrapeOrder != "oldest" && c.DetailsScrapeOrder != "popular" {
+		l.errorf("DETAILS_SCRAPE_ORDER: %q is not a scrape order, use oldest or popular", c.DetailsScrapeOrder)
+	}
+
+	if c.LogFormat != "json" && c.LogFormat != "text" {
+		l.errorf("LOG_FORMAT: %q is not a log format, use json or text", c.LogFormat)
+	}
+
+	for _, origin := range c.CORSOrigins {
+		if origin == "*" {
+			continue
+		}
+		if u, err := url.Parse(origin); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || u.Path != "" {
+			l.errorf("CORS_ALLOWED_ORIGINS: %q is not an origin such as https://example.org", origin)
+		}
+	}
+	for _, feature := range c.DisabledFeatures {
+		if !slices.Contains(Features, feature) {
+			l.errorf("DISABLED_FEATURES: unknown feature %q, use %s", feature, strings.Join(Features, ", "))
+		}
+	}
+
+	if (c.OIDCIssuer == "") != (c.OIDCAudience == "") {
+		l.errorf("OIDC_ISSUER and OIDC_AUDIENCE: set both to enable token authentication, or neither")
+	}
+
+	for _, setting := range []struct{ key, value string }{
+		{"AI_PROMPT_DIR", c.AIPromptDir},
+		{"TEMPLATE_DIR", c.TemplateDir},
+		{"MIGRATIONS_DIR", c.MigrationsDir},
+	} {
+		if setting.value == "" {
+			continue
+		}
+		if info, err := os.Stat(setting.value); err != nil || !info.IsDir() {
+			l.errorf("%s: %q is not a directory", setting.key, setting.value)
+		}
+	}
+
+	for _, setting := range []struct{ key, value string }{
+		{"NORMALIZATION_RULES", c.NormalizationRules},
+		{"SCHOOL_ALIASES", c.SchoolAliases},
+	} {
+		if setting.value == "" {
+			continue
+		}
+		if info, err := os.Stat(setting.value); err != nil || !info.Mode().IsRegular() {
+			l.errorf("%s: %q is not a file", setting.key, setting.value)
+		}
+	}
+
+	if err := checkWritable(c.DBPath); err != nil {
+		l.errorf("DB_PATH: %q is not writable: %v", c.DBPath, err)
+	}
+	if c.CacheDir == "" {
+		l.errorf("CACHE_DIR: must not be empty")
+	} else if err := checkDirWritable(c.CacheDir); err != nil {
+		l.errorf("CACHE_DIR: %q is not writable: %v", c.CacheDir, err)
+	}
+
+	for _, key := range []struct {
+		name, primary, secondary string
+		until                    time.Time
+	}{
+		{"API_KEY", c.APIKey, c.SecondaryAPIKey, c.SecondaryAPIKeyUntil},
+		{"ADMIN_API_KEY", c.AdminAPIKey, c.SecondaryAdminKey, c.SecondaryAdminKeyUntil},
+	} {
+		switch {
+		case key.secondary != "" && key.primary == "":
+			l.errorf("%s_SECONDARY: requires %s to be set", key.name, key.name)
+		case key.secondary == "" && !key.until.IsZero():
+			l.errorf("%s_SECONDARY_UNTIL: requires %s_SECONDARY to be set", key.name, key.name)
+		case key.secondary != "" && key.secondary == key.primary:
+			c.Warnings = append(c.Warnings, key.name+"_SECONDARY is the same as "+key.name)
+		case key.secondary != "" && !key.until.IsZero() && time.Now().After(key.until):
+			c.Warnings = append(c.Warnings, key.name+"_SECONDARY has expired and can be removed")
+		}
+	}
+
+	if c.Env == "production" && c.APIKey == "" {
+		c.Warnings = append(c.Warnings, "API_KEY is empty in production: the API accepts unauthenticated requests")
+	}
+}
+
+// checkWritable reports whether the database file can be created or opened for writing,
+// creating its directory like database.New does
+func checkWritable(path string) error {
+	if _, err := os.Stat(path); err == nil {
+		f, err := os.OpenFile(path, os.O_WRONLY, 0)
+		if err != nil {
+			return err
+		}
+		return f.Close()
+	}
+
+	// The database does not exist yet: SQLite has to create it and its journal files here
+	return checkDirWritable(filepath.Dir(path))
+}
+
+// checkDirWritable creates dir if needed and reports whether files can be created in it
+func checkDirWritable(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(dir, ".write-check-*")
+	if err != nil {
+		return err
+	}
+	f.Close()
+	return os.Remove(f.Name())
+}
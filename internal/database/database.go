@@ -1,251 +1,166 @@
 package database
 
 import (
+	"database/sql"
+	"embed"
 	"fmt"
+	"io/fs"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/jmoiron/sqlx"
 	_ "github.com/mattn/go-sqlite3"
 )
 
-// New creates a new database connection
-func New(dbPath string) (*sqlx.DB, error) {
+//go:embed migrations/*.sql
+var embeddedMigrations embed.FS
+
+// DB bundles separate connection pools for reads and writes. SQLite allows a single writer,
+// so the writer pool is limited to one connection, while readers run concurrently in WAL mode
+// and are never blocked by long refresh transactions.
+type DB struct {
+	Writer *sqlx.DB
+	Reader *sqlx.DB
+
+	path               string
+	slowQueryThreshold time.Duration
+	logger             *slog.Logger
+}
+
+// Close closes both connection pools
+func (db *DB) Close() error {
+	readErr := db.Reader.Close()
+	if err := db.Writer.Close(); err != nil {
+		return err
+	}
+	return readErr
+}
+
+// New creates the read and write database connections. Every statement is timed and exported as metrics;
+// statements slower than slowQueryThreshold are logged (0 disables the slow query log).
+func New(dbPath string, maxReadConns int, slowQueryThreshold time.Duration, logger *slog.Logger) (*DB, error) {
 	// Ensure the directory exists
 	dir := filepath.Dir(dbPath)
 	if err := os.MkdirAll(dir, 0755); err != nil {
 		return nil, fmt.Errorf("failed to create database directory: %w", err)
 	}
 
-	// Open database connection
-	db, err := sqlx.Connect("sqlite3", dbPath)
+	if maxReadConns < 1 {
+		maxReadConns = 1
+	}
+
+	// The writer is opened first so WAL mode is enabled before readers connect
+	writer, err := open(dbPath+"?_journal_mode=WAL&_busy_timeout=5000&_txlock=immediate&_foreign_keys=1", slowQueryThreshold, logger)
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect to database: %w", err)
+		return nil, err
+	}
+	writer.SetMaxOpenConns(1) // SQLite supports a single writer
+	writer.SetMaxIdleConns(1)
+
+	reader, err := open(dbPath+"?_busy_timeout=5000&_query_only=true", slowQueryThreshold, logger)
+	if err != nil {
+		writer.Close()
+		return nil, err
 	}
+	reader.SetMaxOpenConns(maxReadConns)
+	reader.SetMaxIdleConns(maxReadConns)
 
-	// Configure connection pool
-	db.SetMaxOpenConns(1) // SQLite works better with single connection
-	db.SetMaxIdleConns(1)
+	return &DB{Writer: writer, Reader: reader, path: dbPath, slowQueryThreshold: slowQueryThreshold, logger: logger}, nil
+}
 
+// open opens a connection pool through the instrumented driver
+func open(dsn string, slowQueryThreshold time.Duration, logger *slog.Logger) (*sqlx.DB, error) {
+	connector := &instrumentedConnector{dsn: dsn, driver: newInstrumentedDriver(slowQueryThreshold, logger)}
+	db := sqlx.NewDb(sql.OpenDB(connector), "sqlite3")
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
 	return db, nil
 }
 
-// RunMigrations runs all database migrations
-func RunMigrations(db *sqlx.DB) error {
-	migrations := []string{
-		// Create schools table with all fields
-		`CREATE TABLE IF NOT EXISTS schools (
-			id INTEGER PRIMARY KEY AUTOINCREMENT,
-			school_number TEXT NOT NULL DEFAULT '',
-			name TEXT NOT NULL,
-			school_type TEXT NOT NULL DEFAULT '',
-			operator TEXT DEFAULT '',
-			school_category TEXT DEFAULT '',
-			district TEXT DEFAULT '',
-			neighborhood TEXT DEFAULT '',
-			postal_code TEXT DEFAULT '',
-			street TEXT DEFAULT '',
-			house_number TEXT DEFAULT '',
-			phone TEXT DEFAULT '',
-			fax TEXT DEFAULT '',
-			email TEXT DEFAULT '',
-			website TEXT DEFAULT '',
-			school_year TEXT DEFAULT '',
-			latitude REAL,
-			longitude REAL,
-			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
-		)`,
-		// Create indexes for schools
-		`CREATE INDEX IF NOT EXISTS idx_schools_school_number ON schools(school_number)`,
-		`CREATE INDEX IF NOT EXISTS idx_schools_school_type ON schools(school_type)`,
-		`CREATE INDEX IF NOT EXISTS idx_schools_district ON schools(district)`,
-		`CREATE INDEX IF NOT EXISTS idx_schools_created_at ON schools(created_at)`,
-
-		// Create construction_projects table
-		`CREATE TABLE IF NOT EXISTS construction_projects (
-			id INTEGER PRIMARY KEY AUTOINCREMENT,
-			project_id INTEGER NOT NULL UNIQUE,
-			school_number TEXT NOT NULL,
-			school_name TEXT NOT NULL,
-			district TEXT DEFAULT '',
-			school_type TEXT DEFAULT '',
-			construction_measure TEXT DEFAULT '',
-			description TEXT DEFAULT '',
-			built_school_places TEXT DEFAULT '',
-			places_after_construction TEXT DEFAULT '',
-			class_tracks_after_construction TEXT DEFAULT '',
-			handover_date TEXT DEFAULT '',
-			total_costs TEXT DEFAULT '',
-			street TEXT DEFAULT '',
-			postal_code TEXT DEFAULT '',
-			city TEXT DEFAULT '',
-			latitude REAL,
-			longitude REAL,
-			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
-		)`,
-		// Create indexes for construction_projects
-		`CREATE INDEX IF NOT EXISTS idx_construction_projects_project_id ON construction_projects(project_id)`,
-		`CREATE INDEX IF NOT EXISTS idx_construction_projects_school_number ON construction_projects(school_number)`,
-		`CREATE INDEX IF NOT EXISTS idx_construction_projects_district ON construction_projects(district)`,
-		`CREATE INDEX IF NOT EXISTS idx_construction_projects_created_at ON construction_projects(created_at)`,
-
-		// Create school_statistics table
-		`CREATE TABLE IF NOT EXISTS school_statistics (
-			id INTEGER PRIMARY KEY AUTOINCREMENT,
-			school_number TEXT,
-			school_name TEXT,
-			district TEXT,
-			school_type TEXT,
-			school_year TEXT,
-			students TEXT,
-			students_male TEXT,
-			students_female TEXT,
-			teachers TEXT,
-			teachers_male TEXT,
-			teachers_female TEXT,
-			classes TEXT,
-			metadata TEXT,
-			scraped_at DATETIME,
-			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-			UNIQUE(school_number, school_year)
-		)`,
-		// Create indexes for school_statistics
-		`CREATE INDEX IF NOT EXISTS idx_statistics_school_number ON school_statistics(school_number)`,
-		`CREATE INDEX IF NOT EXISTS idx_statistics_school_year ON school_statistics(school_year)`,
-		`CREATE INDEX IF NOT EXISTS idx_statistics_scraped_at ON school_statistics(scraped_at)`,
-
-		// Create school_details table for detailed school information
-		`CREATE TABLE IF NOT EXISTS school_details (
-			id INTEGER PRIMARY KEY AUTOINCREMENT,
-			school_number TEXT NOT NULL,
-			school_name TEXT NOT NULL,
-			languages TEXT DEFAULT '',
-			courses TEXT DEFAULT '',
-			offerings TEXT DEFAULT '',
-			available_after_4th_grade BOOLEAN DEFAULT 0,
-			additional_info TEXT DEFAULT '',
-			equipment TEXT DEFAULT '',
-			working_groups TEXT DEFAULT '',
-			partners TEXT DEFAULT '',
-			differentiation TEXT DEFAULT '',
-			lunch_info TEXT DEFAULT '',
-			dual_learning TEXT DEFAULT '',
-			citizenship_data TEXT DEFAULT '',
-			language_data TEXT DEFAULT '',
-			residence_data TEXT DEFAULT '',
-			absence_data TEXT DEFAULT '',
-			scraped_at DATETIME,
-			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-			UNIQUE(school_number)
-		)`,
-		// Create indexes for school_details
-		`CREATE INDEX IF NOT EXISTS idx_school_details_school_number ON school_details(school_number)`,
-		`CREATE INDEX IF NOT EXISTS idx_school_details_scraped_at ON school_details(scraped_at)`,
-		`CREATE INDEX IF NOT EXISTS idx_school_details_available_after_4th ON school_details(available_after_4th_grade)`,
-
-		// Create school_citizenship_stats table for normalized citizenship data
-		`CREATE TABLE IF NOT EXISTS school_citizenship_stats (
-			id INTEGER PRIMARY KEY AUTOINCREMENT,
-			school_number TEXT NOT NULL,
-			citizenship TEXT NOT NULL,
-			female_students INTEGER DEFAULT 0,
-			male_students INTEGER DEFAULT 0,
-			total INTEGER DEFAULT 0,
-			scraped_at DATETIME,
-			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-			UNIQUE(school_number, citizenship)
-		)`,
-		`CREATE INDEX IF NOT EXISTS idx_citizenship_school_number ON school_citizenship_stats(school_number)`,
-		`CREATE INDEX IF NOT EXISTS idx_citizenship_scraped_at ON school_citizenship_stats(scraped_at)`,
-
-		// Create school_language_stats table for normalized language data
-		`CREATE TABLE IF NOT EXISTS school_language_stats (
-			id INTEGER PRIMARY KEY AUTOINCREMENT,
-			school_number TEXT NOT NULL UNIQUE,
-			total_students INTEGER DEFAULT 0,
-			ndh_female_students INTEGER DEFAULT 0,
-			ndh_male_students INTEGER DEFAULT 0,
-			ndh_total INTEGER DEFAULT 0,
-			ndh_percentage REAL DEFAULT 0.0,
-			scraped_at DATETIME,
-			created_at DATETIME DEFAULT CURRENT_TIMESTAMP
-		)`,
-		`CREATE INDEX IF NOT EXISTS idx_language_school_number ON school_language_stats(school_number)`,
-		`CREATE INDEX IF NOT EXISTS idx_language_scraped_at ON school_language_stats(scraped_at)`,
-
-		// Create school_residence_stats table for normalized residence data
-		`CREATE TABLE IF NOT EXISTS school_residence_stats (
-			id INTEGER PRIMARY KEY AUTOINCREMENT,
-			school_number TEXT NOT NULL,
-			district TEXT NOT NULL,
-			student_count INTEGER DEFAULT 0,
-			scraped_at DATETIME,
-			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-			UNIQUE(school_number, district)
-		)`,
-		`CREATE INDEX IF NOT EXISTS idx_residence_school_number ON school_residence_stats(school_number)`,
-		`CREATE INDEX IF NOT EXISTS idx_residence_district ON school_residence_stats(district)`,
-		`CREATE INDEX IF NOT EXISTS idx_residence_scraped_at ON school_residence_stats(scraped_at)`,
-
-		// Create school_absence_stats table for normalized absence data
-		`CREATE TABLE IF NOT EXISTS school_absence_stats (
-			id INTEGER PRIMARY KEY AUTOINCREMENT,
-			school_number TEXT NOT NULL UNIQUE,
-			school_absence_rate REAL DEFAULT 0.0,
-			school_unexcused_rate REAL DEFAULT 0.0,
-			school_type_absence_rate REAL DEFAULT 0.0,
-			school_type_unexcused_rate REAL DEFAULT 0.0,
-			region_absence_rate REAL DEFAULT 0.0,
-			region_unexcused_rate REAL DEFAULT 0.0,
-			berlin_absence_rate REAL DEFAULT 0.0,
-			berlin_unexcused_rate REAL DEFAULT 0.0,
-			scraped_at DATETIME,
-			created_at DATETIME DEFAULT CURRENT_TIMESTAMP
-		)`,
-		`CREATE INDEX IF NOT EXISTS idx_absence_school_number ON school_absence_stats(school_number)`,
-		`CREATE INDEX IF NOT EXISTS idx_absence_scraped_at ON school_absence_stats(scraped_at)`,
+// RunMigrations runs the migrations compiled into the binary
+func RunMigrations(db *sqlx.DB, logger *slog.Logger) error {
+	return RunMigrationsFrom(db, "", logger)
+}
+
+// RunMigrationsFrom runs the *.sql migration files in name order, then the migrations that
+// need Go code. When dir is empty the files compiled into the binary are used; otherwise
+// they are read from dir (MIGRATIONS_DIR), which replaces them and must contain all of them.
+// Every statement must be idempotent, since migrations run on each start.
+func RunMigrationsFrom(db *sqlx.DB, dir string, logger *slog.Logger) error {
+	var fsys fs.FS
+	if dir == "" {
+		sub, err := fs.Sub(embeddedMigrations, "migrations")
+		if err != nil {
+			return fmt.Errorf("failed to open embedded migrations: %w", err)
+		}
+		fsys = sub
+	} else {
+		fsys = os.DirFS(dir)
+	}
+
+	files, err := fs.Glob(fsys, "*.sql")
+	if err != nil {
+		return fmt.Errorf("failed to list migrations: %w", err)
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("no migrations found in %q", dir)
 	}
 
-	for i, migration := range migrations {
-		if _, err := db.Exec(migration); err != nil {
-			return fmt.Errorf("migration %d failed: %w", i, err)
+	for _, file := range files {
+		content, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return fmt.Errorf("failed to read migration %s: %w", file, err)
+		}
+		for i, statement := range splitStatements(string(content)) {
+			if _, err := db.Exec(statement); err != nil {
+				// SQLite has no ADD COLUMN IF NOT EXISTS
+				if strings.Contains(err.Error(), "duplicate column name") {
+					continue
+				}
+				return fmt.Errorf("migration %s statement %d failed: %w", file, i+1, err)
+			}
 		}
 	}
 
-	// Run additional migrations for existing databases
-	if err := runAdditionalMigrations(db); err != nil {
-		return fmt.Errorf("additional migrations failed: %w", err)
+	// Link scraped data to schools (rebuilds tables created before foreign keys existed)
+	if err := migrateForeignKeys(db, logger.With(slog.String("component", "database"))); err != nil {
+		return fmt.Errorf("foreign key migration failed: %w", err)
+	}
+
+	// Log changes for the delta sync (after table rebuilds, which drop triggers)
+	if err := migrateSyncTriggers(db); err != nil {
+		return fmt.Errorf("sync trigger migration failed: %w", err)
+	}
+
+	if err := migrateSearchIndex(db); err != nil {
+		return fmt.Errorf("search index migration failed: %w", err)
 	}
 
 	return nil
 }
 
-// runAdditionalMigrations adds new columns to existing tables
-func runAdditionalMigrations(db *sqlx.DB) error {
-	// Add gender breakdown columns to school_statistics table if they don't exist
-	additionalMigrations := []string{
-		// Check and add students_male column
-		`ALTER TABLE school_statistics ADD COLUMN students_male TEXT`,
-		`ALTER TABLE school_statistics ADD COLUMN students_female TEXT`,
-		`ALTER TABLE school_statistics ADD COLUMN teachers_male TEXT`,
-		`ALTER TABLE school_statistics ADD COLUMN teachers_female TEXT`,
+// splitStatements splits a migration file into its statements. Lines starting with -- are
+// comments; statements end with a semicolon and cannot contain one themselves (triggers,
+// which do, are created in Go).
+func splitStatements(content string) []string {
+	var code strings.Builder
+	for _, line := range strings.Split(content, "\n") {
+		if !strings.HasPrefix(strings.TrimSpace(line), "--") {
+			code.WriteString(line)
+			code.WriteString("\n")
+		}
 	}
 
-	// Try to add each column, ignoring errors if column already exists
-	for _, migration := range additionalMigrations {
-		_, err := db.Exec(migration)
-		// Ignore "duplicate column" errors (SQLite error message)
-		if err != nil && err.Error() != "duplicate column name: students_male" &&
-			err.Error() != "duplicate column name: students_female" &&
-			err.Error() != "duplicate column name: teachers_male" &&
-			err.Error() != "duplicate column name: teachers_female" {
-			// Only return error if it's not a duplicate column error
-			continue
+	var statements []string
+	for _, statement := range strings.Split(code.String(), ";") {
+		if statement = strings.TrimSpace(statement); statement != "" {
+			statements = append(statements, statement)
 		}
 	}
-
-	return nil
+	return statements
 }
@@ -0,0 +1,182 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/mattn/go-sqlite3"
+)
+
+// foreignKeyClause links a table to its school. Deleting a school deletes its scraped data,
+// and renumbering a school follows through.
+const foreignKeyClause = `FOREIGN KEY (school_number) REFERENCES schools(school_number) ON DELETE CASCADE ON UPDATE CASCADE`
+
+// schoolChildTables reference schools.school_number. construction_projects is deliberately
+// not linked: projects without a matching school are served as standalone projects.
+var schoolChildTables = []string{
+	"school_details",
+	"school_statistics",
+	"school_citizenship_stats",
+	"school_language_stats",
+	"school_residence_stats",
+	"school_absence_stats",
+	"school_grade_structure",
+	"school_staffing_stats",
+	"school_teacher_qualifications",
+	"school_applications",
+	"school_exam_results",
+	"school_quality_indicators",
+	"school_corrections",
+	"school_overrides",
+	"school_summaries",
+	"school_external_ratings",
+}
+
+// SchoolChildTables returns the tables that reference schools.school_number
+func SchoolChildTables() []string {
+	return slices.Clone(schoolChildTables)
+}
+
+// migrateForeignKeys makes schools.school_number unique (keeping the newest row of each
+// duplicate) and rebuilds child tables created before foreign keys were declared, dropping
+// rows that reference no school.
+func migrateForeignKeys(db *sqlx.DB, logger *slog.Logger) error {
+	ctx := context.Background()
+
+	// PRAGMA foreign_keys cannot change inside a transaction and is per connection,
+	// so the whole migration runs on one pinned connection
+	conn, err := db.Connx(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get connection: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, `PRAGMA foreign_keys = OFF`); err != nil {
+		return fmt.Errorf("failed to disable foreign keys: %w", err)
+	}
+	defer conn.ExecContext(ctx, `PRAGMA foreign_keys = ON`)
+
+	tx, err := conn.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `
+		DELETE FROM schools
+		WHERE id NOT IN (SELECT MAX(id) FROM schools GROUP BY school_number)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to deduplicate schools: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n > 0 {
+		logger.Warn("deleted duplicate schools", slog.Int64("count", n))
+	}
+
+	if _, err := tx.ExecContext(ctx, `CREATE UNIQUE INDEX IF NOT EXISTS idx_schools_school_number_unique ON schools(school_number)`); err != nil {
+		return fmt.Errorf("failed to create unique school number index: %w", err)
+	}
+
+	for _, table := range schoolChildTables {
+		if err := addForeignKey(ctx, tx, table, logger); err != nil {
+			return fmt.Errorf("failed to add foreign key to %s: %w", table, err)
+		}
+	}
+
+	var violations []struct {
+		Table  string `db:"table"`
+		RowID  *int64 `db:"rowid"`
+		Parent string `db:"parent"`
+		FKID   int    `db:"fkid"`
+	}
+	if err := tx.SelectContext(ctx, &violations, `PRAGMA foreign_key_check`); err != nil {
+		return fmt.Errorf("failed to check foreign keys: %w", err)
+	}
+	if len(violations) > 0 {
+		return fmt.Errorf("foreign key check failed: %d violations, first in %s", len(violations), violations[0].Table)
+	}
+
+	return tx.Commit()
+}
+
+// addForeignKey rebuilds a table with the school foreign key, following SQLite's
+// procedure for schema changes ALTER TABLE cannot make. The current definition is
+// reused, so columns added by later migrations are kept.
+func addForeignKey(ctx context.Context, tx *sqlx.Tx, table string, logger *slog.Logger) error {
+	var fkCount int
+	if err := tx.GetContext(ctx, &fkCount, `SELECT COUNT(*) FROM pragma_foreign_key_list(?)`, table); err != nil {
+		return err
+	}
+	if fkCount > 0 {
+		return nil
+	}
+
+	var createSQL string
+	if err := tx.GetContext(ctx, &createSQL, `SELECT sql FROM sqlite_master WHERE type = 'table' AND name = ?`, table); err != nil {
+		return err
+	}
+
+	var indexSQL []string
+	if err := tx.SelectContext(ctx, &indexSQL, `SELECT sql FROM sqlite_master WHERE type = 'index' AND tbl_name = ? AND sql IS NOT NULL`, table); err != nil {
+		return err
+	}
+
+	end := strings.LastIndex(createSQL, ")")
+	if end < 0 {
+		return fmt.Errorf("unexpected table definition")
+	}
+	newTable := table + "_new"
+	newSQL := strings.Replace(createSQL[:end], table, newTable, 1) + ",\n\t\t\t" + foreignKeyClause + "\n\t\t)"
+
+	statements := []string{
+		`DROP TABLE IF EXISTS ` + newTable,
+		newSQL,
+		`INSERT INTO ` + newTable + ` SELECT * FROM ` + table + ` WHERE school_number IS NULL OR school_number IN (SELECT school_number FROM schools)`,
+	}
+	for _, stmt := range statements {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			return err
+		}
+	}
+
+	var total, kept int
+	if err := tx.GetContext(ctx, &total, `SELECT COUNT(*) FROM `+table); err != nil {
+		return err
+	}
+	if err := tx.GetContext(ctx, &kept, `SELECT COUNT(*) FROM `+newTable); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, `DROP TABLE `+table); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `ALTER TABLE `+newTable+` RENAME TO `+table); err != nil {
+		return err
+	}
+	for _, stmt := range indexSQL {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			return err
+		}
+	}
+
+	logger.Info("added foreign key",
+		slog.String("table", table),
+		slog.Int("orphans_deleted", total-kept),
+	)
+	return nil
+}
+
+// IsUniqueViolation reports whether err was caused by a UNIQUE or PRIMARY KEY constraint
+func IsUniqueViolation(err error) bool {
+	var sqliteErr sqlite3.Error
+	if !errors.As(err, &sqliteErr) {
+		return false
+	}
+	return sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique ||
+		sqliteErr.ExtendedCode == sqlite3.ErrConstraintPrimaryKey
+}
@@ -0,0 +1,29 @@
+package database
+
+import (
+	"schools-be/internal/utils"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// registerFunctions adds the Go functions statements can call to a new connection
+func registerFunctions(conn *sqlite3.SQLiteConn) error {
+	return conn.RegisterFunc("distance_km", distanceKm, true)
+}
+
+// distanceKm is distance_km(lat1, lon1, lat2, lon2): the haversine distance between two
+// points in kilometers, NULL when a coordinate is NULL or not a number
+func distanceKm(lat1, lon1, lat2, lon2 interface{}) interface{} {
+	var coordinates [4]float64
+	for i, v := range []interface{}{lat1, lon1, lat2, lon2} {
+		switch n := v.(type) {
+		case float64:
+			coordinates[i] = n
+		case int64:
+			coordinates[i] = float64(n)
+		default:
+			return nil
+		}
+	}
+	return utils.DistanceKm(coordinates[0], coordinates[1], coordinates[2], coordinates[3])
+}
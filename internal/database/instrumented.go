@@ -0,0 +1,218 @@
+package database
+
+import (
+	"context"
+	"database/sql/driver"
+	"log/slog"
+	"strings"
+	"time"
+	"unicode"
+
+	"schools-be/internal/metrics"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+var (
+	queryDuration = metrics.NewHistogramVec("schools_db_query_duration_seconds",
+		"Duration of SQLite statements", metrics.DefaultBuckets, "operation", "statement")
+	slowQueries = metrics.NewCounterVec("schools_db_slow_queries_total",
+		"Number of SQLite statements exceeding the slow query threshold", "operation", "statement")
+	queryErrors = metrics.NewCounterVec("schools_db_query_errors_total",
+		"Number of SQLite statements that returned an error", "operation", "statement")
+)
+
+// instrumentedDriver wraps the sqlite3 driver and times every statement
+type instrumentedDriver struct {
+	parent        driver.Driver
+	slowThreshold time.Duration
+	logger        *slog.Logger
+}
+
+func newInstrumentedDriver(slowThreshold time.Duration, logger *slog.Logger) *instrumentedDriver {
+	return &instrumentedDriver{
+		parent:        &sqlite3.SQLiteDriver{},
+		slowThreshold: slowThreshold,
+		logger:        logger.With(slog.String("component", "database")),
+	}
+}
+
+func (d *instrumentedDriver) Open(name string) (driver.Conn, error) {
+	conn, err := d.parent.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	if sqliteConn, ok := conn.(*sqlite3.SQLiteConn); ok {
+		if err := registerFunctions(sqliteConn); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	return &instrumentedConn{Conn: conn, driver: d}, nil
+}
+
+// observe records the duration of a statement and logs it if it exceeds the slow query threshold.
+// Parameters are never logged, only their count.
+func (d *instrumentedDriver) observe(operation, query string, argCount int, start time.Time, err error) {
+	duration := time.Since(start)
+	statement := statementType(query)
+
+	queryDuration.Observe(duration.Seconds(), operation, statement)
+	if err != nil && err != driver.ErrSkip {
+		queryErrors.Inc(operation, statement)
+	}
+
+	if d.slowThreshold > 0 && duration >= d.slowThreshold {
+		slowQueries.Inc(operation, statement)
+		d.logger.Warn("slow query",
+			slog.String("operation", operation),
+			slog.String("query", compactQuery(query)),
+			slog.Int("params", argCount),
+			slog.String("args", "[redacted]"),
+			slog.Duration("duration", duration),
+		)
+	}
+}
+
+// instrumentedConnector opens instrumented connections for a fixed DSN
+type instrumentedConnector struct {
+	dsn    string
+	driver *instrumentedDriver
+}
+
+func (c *instrumentedConnector) Connect(_ context.Context) (driver.Conn, error) {
+	return c.driver.Open(c.dsn)
+}
+
+func (c *instrumentedConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+type instrumentedConn struct {
+	driver.Conn
+	driver *instrumentedDriver
+}
+
+func (c *instrumentedConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	execer, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+
+	start := time.Now()
+	result, err := execer.ExecContext(ctx, query, args)
+	c.driver.observe("exec", query, len(args), start, err)
+	return result, err
+}
+
+func (c *instrumentedConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+
+	start := time.Now()
+	rows, err := queryer.QueryContext(ctx, query, args)
+	c.driver.observe("query", query, len(args), start, err)
+	return rows, err
+}
+
+func (c *instrumentedConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	var (
+		stmt driver.Stmt
+		err  error
+	)
+	if preparer, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		stmt, err = preparer.PrepareContext(ctx, query)
+	} else {
+		stmt, err = c.Conn.Prepare(query)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &instrumentedStmt{Stmt: stmt, query: query, driver: c.driver}, nil
+}
+
+func (c *instrumentedConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if beginner, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return beginner.BeginTx(ctx, opts)
+	}
+	return c.Conn.Begin()
+}
+
+func (c *instrumentedConn) Ping(ctx context.Context) error {
+	if pinger, ok := c.Conn.(driver.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
+
+type instrumentedStmt struct {
+	driver.Stmt
+	query  string
+	driver *instrumentedDriver
+}
+
+func (s *instrumentedStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	execer, ok := s.Stmt.(driver.StmtExecContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+
+	start := time.Now()
+	result, err := execer.ExecContext(ctx, args)
+	s.driver.observe("exec", s.query, len(args), start, err)
+	return result, err
+}
+
+func (s *instrumentedStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := s.Stmt.(driver.StmtQueryContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+
+	start := time.Now()
+	rows, err := queryer.QueryContext(ctx, args)
+	s.driver.observe("query", s.query, len(args), start, err)
+	return rows, err
+}
+
+// statementTypes are the metric labels of statements; other statements, including those of
+// sandbox queries starting with an unexpected token, are counted as "other"
+var statementTypes = map[string]string{
+	"select":  "select",
+	"insert":  "insert",
+	"replace": "insert",
+	"update":  "update",
+	"delete":  "delete",
+	"pragma":  "pragma",
+}
+
+// statementType returns the kind of statement after any leading comments, used as a
+// low-cardinality metric label
+func statementType(query string) string {
+	for {
+		query = strings.TrimSpace(query)
+		if rest, ok := strings.CutPrefix(query, "--"); ok {
+			_, query, _ = strings.Cut(rest, "\n")
+		} else if rest, ok := strings.CutPrefix(query, "/*"); ok {
+			_, query, _ = strings.Cut(rest, "*/")
+		} else {
+			break
+		}
+	}
+
+	end := strings.IndexFunc(query, func(r rune) bool { return !unicode.IsLetter(r) })
+	if end >= 0 {
+		query = query[:end]
+	}
+	if statement, ok := statementTypes[strings.ToLower(query)]; ok {
+		return statement
+	}
+	return "other"
+}
+
+// compactQuery collapses whitespace so multi-line queries fit in a single log attribute
+func compactQuery(query string) string {
+	return strings.Join(strings.Fields(query), " ")
+}
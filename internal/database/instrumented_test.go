@@ -0,0 +1,33 @@
+package database
+
+import "testing"
+
+func TestStatementType(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"SELECT * FROM schools", "select"},
+		{"\n\t  select id FROM schools", "select"},
+		{"INSERT OR REPLACE INTO schools (id) VALUES (?)", "insert"},
+		{"REPLACE INTO schools (id) VALUES (?)", "insert"},
+		{"UPDATE schools SET name = ?", "update"},
+		{"DELETE FROM schools", "delete"},
+		{"PRAGMA foreign_keys = ON", "pragma"},
+		{"/* label */ SELECT 1", "select"},
+		{"-- comment\nSELECT 1", "select"},
+		{"/* a */ -- b\n /* c */ DELETE FROM schools", "delete"},
+		{"SELECT(1)", "select"},
+		{"/* unterminated SELECT 1", "other"},
+		{"WITH x AS (SELECT 1) SELECT * FROM x", "other"},
+		{"CREATE TABLE t (id INTEGER)", "other"},
+		{"xyzzy_1234 junk", "other"},
+		{"", "other"},
+	}
+
+	for _, tt := range tests {
+		if got := statementType(tt.query); got != tt.want {
+			t.Errorf("statementType(%q) = %q, want %q", tt.query, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,304 @@
+-- Tables and indexes of the dataset, visitor data and internal bookkeeping. Every statement
+-- must be idempotent: migrations run on each start.
+
+-- Create schools table with all fields
+CREATE TABLE IF NOT EXISTS schools (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	school_number TEXT NOT NULL DEFAULT '',
+	name TEXT NOT NULL,
+	school_type TEXT NOT NULL DEFAULT '',
+	operator TEXT DEFAULT '',
+	school_category TEXT DEFAULT '',
+	district TEXT DEFAULT '',
+	neighborhood TEXT DEFAULT '',
+	postal_code TEXT DEFAULT '',
+	street TEXT DEFAULT '',
+	house_number TEXT DEFAULT '',
+	phone TEXT DEFAULT '',
+	fax TEXT DEFAULT '',
+	email TEXT DEFAULT '',
+	website TEXT DEFAULT '',
+	school_year TEXT DEFAULT '',
+	latitude REAL,
+	longitude REAL,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+-- Create indexes for schools
+CREATE INDEX IF NOT EXISTS idx_schools_school_number ON schools(school_number);
+CREATE INDEX IF NOT EXISTS idx_schools_school_type ON schools(school_type);
+CREATE INDEX IF NOT EXISTS idx_schools_district ON schools(district);
+CREATE INDEX IF NOT EXISTS idx_schools_created_at ON schools(created_at);
+
+-- Create construction_projects table
+CREATE TABLE IF NOT EXISTS construction_projects (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	project_id INTEGER NOT NULL UNIQUE,
+	school_number TEXT NOT NULL,
+	school_name TEXT NOT NULL,
+	district TEXT DEFAULT '',
+	school_type TEXT DEFAULT '',
+	construction_measure TEXT DEFAULT '',
+	description TEXT DEFAULT '',
+	built_school_places TEXT DEFAULT '',
+	places_after_construction TEXT DEFAULT '',
+	class_tracks_after_construction TEXT DEFAULT '',
+	handover_date TEXT DEFAULT '',
+	total_costs TEXT DEFAULT '',
+	street TEXT DEFAULT '',
+	postal_code TEXT DEFAULT '',
+	city TEXT DEFAULT '',
+	latitude REAL,
+	longitude REAL,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+-- Create indexes for construction_projects
+CREATE INDEX IF NOT EXISTS idx_construction_projects_project_id ON construction_projects(project_id);
+CREATE INDEX IF NOT EXISTS idx_construction_projects_school_number ON construction_projects(school_number);
+CREATE INDEX IF NOT EXISTS idx_construction_projects_district ON construction_projects(district);
+CREATE INDEX IF NOT EXISTS idx_construction_projects_created_at ON construction_projects(created_at);
+
+-- Create school_statistics table
+CREATE TABLE IF NOT EXISTS school_statistics (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	school_number TEXT,
+	school_name TEXT,
+	district TEXT,
+	school_type TEXT,
+	school_year TEXT,
+	students TEXT,
+	students_male TEXT,
+	students_female TEXT,
+	teachers TEXT,
+	teachers_male TEXT,
+	teachers_female TEXT,
+	classes TEXT,
+	metadata TEXT,
+	scraped_at DATETIME,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	UNIQUE(school_number, school_year),
+	FOREIGN KEY (school_number) REFERENCES schools(school_number) ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+-- Create school_exam_results table: Abitur results per school and year, small cohorts suppressed by the source
+CREATE TABLE IF NOT EXISTS school_exam_results (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	school_number TEXT NOT NULL,
+	school_year TEXT NOT NULL,
+	participants INTEGER,
+	passed INTEGER,
+	average_grade REAL,
+	suppressed BOOLEAN NOT NULL DEFAULT 0,
+	fetched_at DATETIME NOT NULL,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	UNIQUE(school_number, school_year),
+	FOREIGN KEY (school_number) REFERENCES schools(school_number) ON DELETE CASCADE ON UPDATE CASCADE
+);
+-- Create indexes for school_statistics
+CREATE INDEX IF NOT EXISTS idx_statistics_school_number ON school_statistics(school_number);
+CREATE INDEX IF NOT EXISTS idx_statistics_school_year ON school_statistics(school_year);
+CREATE INDEX IF NOT EXISTS idx_statistics_scraped_at ON school_statistics(scraped_at);
+
+-- Create school_details table for detailed school information
+CREATE TABLE IF NOT EXISTS school_details (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	school_number TEXT NOT NULL,
+	school_name TEXT NOT NULL,
+	languages TEXT DEFAULT '',
+	courses TEXT DEFAULT '',
+	offerings TEXT DEFAULT '',
+	available_after_4th_grade BOOLEAN DEFAULT 0,
+	additional_info TEXT DEFAULT '',
+	equipment TEXT DEFAULT '',
+	working_groups TEXT DEFAULT '',
+	partners TEXT DEFAULT '',
+	differentiation TEXT DEFAULT '',
+	lunch_info TEXT DEFAULT '',
+	dual_learning TEXT DEFAULT '',
+	citizenship_data TEXT DEFAULT '',
+	language_data TEXT DEFAULT '',
+	residence_data TEXT DEFAULT '',
+	absence_data TEXT DEFAULT '',
+	scraped_at DATETIME,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	UNIQUE(school_number),
+	FOREIGN KEY (school_number) REFERENCES schools(school_number) ON DELETE CASCADE ON UPDATE CASCADE
+);
+-- Create indexes for school_details
+CREATE INDEX IF NOT EXISTS idx_school_details_school_number ON school_details(school_number);
+CREATE INDEX IF NOT EXISTS idx_school_details_scraped_at ON school_details(scraped_at);
+CREATE INDEX IF NOT EXISTS idx_school_details_available_after_4th ON school_details(available_after_4th_grade);
+
+-- Create school_citizenship_stats table for normalized citizenship data
+CREATE TABLE IF NOT EXISTS school_citizenship_stats (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	school_number TEXT NOT NULL,
+	citizenship TEXT NOT NULL,
+	female_students INTEGER DEFAULT 0,
+	male_students INTEGER DEFAULT 0,
+	total INTEGER DEFAULT 0,
+	scraped_at DATETIME,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	UNIQUE(school_number, citizenship),
+	FOREIGN KEY (school_number) REFERENCES schools(school_number) ON DELETE CASCADE ON UPDATE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_citizenship_school_number ON school_citizenship_stats(school_number);
+CREATE INDEX IF NOT EXISTS idx_citizenship_scraped_at ON school_citizenship_stats(scraped_at);
+
+-- Create school_language_stats table for normalized language data
+CREATE TABLE IF NOT EXISTS school_language_stats (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	school_number TEXT NOT NULL UNIQUE,
+	total_students INTEGER DEFAULT 0,
+	ndh_female_students INTEGER DEFAULT 0,
+	ndh_male_students INTEGER DEFAULT 0,
+	ndh_total INTEGER DEFAULT 0,
+	ndh_percentage REAL DEFAULT 0.0,
+	scraped_at DATETIME,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (school_number) REFERENCES schools(school_number) ON DELETE CASCADE ON UPDATE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_language_school_number ON school_language_stats(school_number);
+CREATE INDEX IF NOT EXISTS idx_language_scraped_at ON school_language_stats(scraped_at);
+
+-- Create school_residence_stats table for normalized residence data
+CREATE TABLE IF NOT EXISTS school_residence_stats (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	school_number TEXT NOT NULL,
+	district TEXT NOT NULL,
+	student_count INTEGER DEFAULT 0,
+	scraped_at DATETIME,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	UNIQUE(school_number, district),
+	FOREIGN KEY (school_number) REFERENCES schools(school_number) ON DELETE CASCADE ON UPDATE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_residence_school_number ON school_residence_stats(school_number);
+CREATE INDEX IF NOT EXISTS idx_residence_district ON school_residence_stats(district);
+CREATE INDEX IF NOT EXISTS idx_residence_scraped_at ON school_residence_stats(scraped_at);
+
+-- Create school_absence_stats table for normalized absence data
+CREATE TABLE IF NOT EXISTS school_absence_stats (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	school_number TEXT NOT NULL UNIQUE,
+	school_absence_rate REAL DEFAULT 0.0,
+	school_unexcused_rate REAL DEFAULT 0.0,
+	school_type_absence_rate REAL DEFAULT 0.0,
+	school_type_unexcused_rate REAL DEFAULT 0.0,
+	region_absence_rate REAL DEFAULT 0.0,
+	region_unexcused_rate REAL DEFAULT 0.0,
+	berlin_absence_rate REAL DEFAULT 0.0,
+	berlin_unexcused_rate REAL DEFAULT 0.0,
+	scraped_at DATETIME,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (school_number) REFERENCES schools(school_number) ON DELETE CASCADE ON UPDATE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_absence_school_number ON school_absence_stats(school_number);
+CREATE INDEX IF NOT EXISTS idx_absence_scraped_at ON school_absence_stats(scraped_at);
+
+-- Create school_applications table: first-choice applications vs places per secondary school and year (Anmeldezahlen)
+CREATE TABLE IF NOT EXISTS school_applications (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	school_number TEXT NOT NULL,
+	school_year TEXT NOT NULL,
+	places INTEGER NOT NULL DEFAULT 0,
+	first_choice_applications INTEGER NOT NULL DEFAULT 0,
+	fetched_at DATETIME NOT NULL,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	UNIQUE(school_number, school_year),
+	FOREIGN KEY (school_number) REFERENCES schools(school_number) ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+-- Create enriched_schools_json table: denormalized enriched payload per school, rebuilt after each refresh
+CREATE TABLE IF NOT EXISTS enriched_schools_json (
+	school_id INTEGER PRIMARY KEY,
+	school_number TEXT NOT NULL DEFAULT '',
+	payload TEXT NOT NULL,
+	built_at DATETIME NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_enriched_schools_json_school_number ON enriched_schools_json(school_number);
+
+-- Composite indexes for filtered and sorted lookups (verified by cmd/indexcheck)
+CREATE INDEX IF NOT EXISTS idx_schools_district_school_type ON schools(district, school_type);
+CREATE INDEX IF NOT EXISTS idx_schools_school_type_name ON schools(school_type, name);
+CREATE INDEX IF NOT EXISTS idx_residence_school_number_count ON school_residence_stats(school_number, student_count DESC);
+CREATE INDEX IF NOT EXISTS idx_construction_projects_school_number_created_at ON construction_projects(school_number, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_schools_school_type_latitude ON schools(school_type, latitude);
+
+-- Create chat tables for the conversational school advisor
+CREATE TABLE IF NOT EXISTS chat_sessions (
+	id TEXT PRIMARY KEY,
+	school_id INTEGER,
+	created_at DATETIME NOT NULL,
+	updated_at DATETIME NOT NULL,
+	expires_at DATETIME NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_chat_sessions_expires_at ON chat_sessions(expires_at);
+CREATE TABLE IF NOT EXISTS chat_messages (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	session_id TEXT NOT NULL,
+	role TEXT NOT NULL,
+	content TEXT NOT NULL,
+	created_at DATETIME NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_chat_messages_session_id ON chat_messages(session_id, id);
+
+-- Create user location tables: anonymous home coordinates and their commute matrix results
+CREATE TABLE IF NOT EXISTS user_locations (
+	token TEXT PRIMARY KEY,
+	latitude REAL NOT NULL,
+	longitude REAL NOT NULL,
+	created_at DATETIME NOT NULL
+);
+CREATE TABLE IF NOT EXISTS commute_matrix_status (
+	location_token TEXT NOT NULL,
+	mode TEXT NOT NULL,
+	status TEXT NOT NULL,
+	error TEXT NOT NULL DEFAULT '',
+	updated_at DATETIME NOT NULL,
+	PRIMARY KEY (location_token, mode)
+);
+CREATE TABLE IF NOT EXISTS commute_times (
+	location_token TEXT NOT NULL,
+	mode TEXT NOT NULL,
+	school_id INTEGER NOT NULL,
+	duration_seconds REAL NOT NULL,
+	distance_meters REAL NOT NULL,
+	computed_at DATETIME NOT NULL,
+	PRIMARY KEY (location_token, mode, school_id)
+);
+
+-- Create travel time cache keyed by ~100m origin grid cell, so nearby origins share ORS results
+CREATE TABLE IF NOT EXISTS travel_time_cache (
+	cell TEXT NOT NULL,
+	school_id INTEGER NOT NULL,
+	mode TEXT NOT NULL,
+	duration_seconds REAL NOT NULL,
+	distance_meters REAL NOT NULL,
+	computed_at DATETIME NOT NULL,
+	PRIMARY KEY (cell, mode, school_id)
+);
+CREATE INDEX IF NOT EXISTS idx_travel_time_cache_computed_at ON travel_time_cache(computed_at);
+
+-- Create anonymous usage analytics rollups (hit counts per day, no PII)
+CREATE TABLE IF NOT EXISTS analytics_daily (
+	day TEXT NOT NULL,
+	kind TEXT NOT NULL,
+	key TEXT NOT NULL,
+	count INTEGER NOT NULL DEFAULT 0,
+	PRIMARY KEY (day, kind, key)
+);
+
+-- Create request and byte counts per API key and day, for partner monitoring and quotas
+CREATE TABLE IF NOT EXISTS api_usage_daily (
+	day TEXT NOT NULL,
+	subject TEXT NOT NULL,
+	requests INTEGER NOT NULL DEFAULT 0,
+	bytes INTEGER NOT NULL DEFAULT 0,
+	rejected INTEGER NOT NULL DEFAULT 0,
+	PRIMARY KEY (day, subject)
+);
+
+-- Missing coordinates used to be stored as 0,0; store them as NULL
+UPDATE schools SET latitude = NULL, longitude = NULL WHERE latitude = 0 AND longitude = 0;
@@ -0,0 +1,11 @@
+-- Columns added to existing tables. SQLite has no ADD COLUMN IF NOT EXISTS, so the runner
+-- skips these statements when the column already exists.
+
+-- Gender breakdown of school statistics
+ALTER TABLE school_statistics ADD COLUMN students_male TEXT;
+ALTER TABLE school_statistics ADD COLUMN students_female TEXT;
+ALTER TABLE school_statistics ADD COLUMN teachers_male TEXT;
+ALTER TABLE school_statistics ADD COLUMN teachers_female TEXT;
+
+ALTER TABLE school_details ADD COLUMN parser_version INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE schools ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
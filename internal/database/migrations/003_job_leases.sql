@@ -0,0 +1,7 @@
+-- Leases of scheduled jobs, so that of several instances sharing the database only one runs
+-- each job. expires_at is in Unix milliseconds; the holder renews it while the job runs.
+CREATE TABLE IF NOT EXISTS job_leases (
+	name TEXT PRIMARY KEY,
+	holder TEXT NOT NULL,
+	expires_at INTEGER NOT NULL
+);
@@ -0,0 +1,9 @@
+-- Jobs triggered on an instance that does not run the scheduler, waiting for a worker
+CREATE TABLE IF NOT EXISTS job_requests (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	job TEXT NOT NULL,
+	requested_at DATETIME NOT NULL,
+	claimed_by TEXT,
+	claimed_at DATETIME
+);
+CREATE INDEX IF NOT EXISTS idx_job_requests_unclaimed ON job_requests(id) WHERE claimed_by IS NULL;
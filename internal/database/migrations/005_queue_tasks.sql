@@ -0,0 +1,15 @@
+-- Tasks of the database job queue (QUEUE_DRIVER=database). Times in Unix milliseconds: a task
+-- is delivered from available_at on, and a claimed task is delivered again once locked_until
+-- passed without the consumer finishing it.
+CREATE TABLE IF NOT EXISTS queue_tasks (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	kind TEXT NOT NULL,
+	payload TEXT NOT NULL,
+	attempts INTEGER NOT NULL DEFAULT 0,
+	available_at INTEGER NOT NULL,
+	locked_by TEXT,
+	locked_until INTEGER,
+	last_error TEXT,
+	failed_at DATETIME
+);
+CREATE INDEX IF NOT EXISTS idx_queue_tasks_kind ON queue_tasks(kind, id) WHERE failed_at IS NULL;
@@ -0,0 +1,14 @@
+-- Data-quality log: checks a normalized statistics table failed when it was stored, replaced
+-- per school and dataset on every scrape or re-parse. excluded marks tables that were not
+-- stored because they are clearly broken.
+CREATE TABLE IF NOT EXISTS data_quality_issues (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	school_number TEXT NOT NULL,
+	dataset TEXT NOT NULL,
+	detail TEXT NOT NULL,
+	total INTEGER NOT NULL,
+	parts_sum INTEGER NOT NULL,
+	excluded BOOLEAN NOT NULL DEFAULT 0,
+	detected_at DATETIME NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_data_quality_issues_school ON data_quality_issues(school_number, dataset);
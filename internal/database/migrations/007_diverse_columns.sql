@@ -0,0 +1,6 @@
+-- "divers" counts of the m/w/d breakdowns in newer statistic tables. Rows stored before keep
+-- 0 (or '' for the text columns of school_statistics): their tables had no such category.
+ALTER TABLE school_statistics ADD COLUMN students_diverse TEXT NOT NULL DEFAULT '';
+ALTER TABLE school_statistics ADD COLUMN teachers_diverse TEXT NOT NULL DEFAULT '';
+ALTER TABLE school_citizenship_stats ADD COLUMN diverse_students INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE school_language_stats ADD COLUMN ndh_diverse_students INTEGER NOT NULL DEFAULT 0;
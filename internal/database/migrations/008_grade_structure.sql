@@ -0,0 +1,14 @@
+-- Classes (Züge) per grade and school year from the school pages, e.g. how many 7th grade
+-- classes a school opens
+CREATE TABLE IF NOT EXISTS school_grade_structure (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	school_number TEXT NOT NULL,
+	school_year TEXT NOT NULL,
+	grade INTEGER NOT NULL,
+	classes INTEGER NOT NULL,
+	scraped_at DATETIME,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	UNIQUE(school_number, school_year, grade),
+	FOREIGN KEY (school_number) REFERENCES schools(school_number) ON DELETE CASCADE ON UPDATE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_grade_structure_school ON school_grade_structure(school_number, school_year DESC, grade);
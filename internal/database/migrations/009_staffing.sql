@@ -0,0 +1,21 @@
+-- Teacher staffing from the personnel section of the school pages: the staffing coverage
+-- (Ausstattung in %, NULL when not published) and the teachers by qualification
+CREATE TABLE IF NOT EXISTS school_staffing_stats (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	school_number TEXT NOT NULL UNIQUE,
+	staffing_coverage REAL,
+	scraped_at DATETIME,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (school_number) REFERENCES schools(school_number) ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS school_teacher_qualifications (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	school_number TEXT NOT NULL,
+	qualification TEXT NOT NULL,
+	teachers INTEGER NOT NULL,
+	scraped_at DATETIME,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (school_number) REFERENCES schools(school_number) ON DELETE CASCADE ON UPDATE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_teacher_qualifications_school ON school_teacher_qualifications(school_number, teachers DESC, qualification);
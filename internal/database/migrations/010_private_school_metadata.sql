@@ -0,0 +1,8 @@
+-- Operator metadata of private schools from the school pages: the operator organization, its
+-- confession (empty for public and secular operators) and the fees, as published and as a
+-- monthly range in euros (NULL when not published)
+ALTER TABLE school_details ADD COLUMN operator_organization TEXT NOT NULL DEFAULT '';
+ALTER TABLE school_details ADD COLUMN confession TEXT NOT NULL DEFAULT '';
+ALTER TABLE school_details ADD COLUMN fee_info TEXT NOT NULL DEFAULT '';
+ALTER TABLE school_details ADD COLUMN fee_min_eur INTEGER;
+ALTER TABLE school_details ADD COLUMN fee_max_eur INTEGER;
@@ -0,0 +1,3 @@
+-- Special-needs support focus areas (Förderschwerpunkte) named in the offerings and notes of
+-- the school pages, as comma-separated codes such as "learning,autism"
+ALTER TABLE school_details ADD COLUMN support_focuses TEXT NOT NULL DEFAULT '';
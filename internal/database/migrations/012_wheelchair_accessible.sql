@@ -0,0 +1,3 @@
+-- Wheelchair accessibility stated in the equipment and notes of the school pages: yes, no, or
+-- unknown when the page does not mention it
+ALTER TABLE school_details ADD COLUMN wheelchair_accessible TEXT NOT NULL DEFAULT 'unknown';
@@ -0,0 +1,3 @@
+-- All-day schooling (Ganztag) form named in the offerings and notes of the school pages:
+-- bound, open or none
+ALTER TABLE school_details ADD COLUMN all_day_type TEXT NOT NULL DEFAULT 'none';
@@ -0,0 +1,6 @@
+-- Programs named in the offerings and notes of the school pages: Staatliche Europa-Schule
+-- Berlin (SESB) branches, the partner languages of bilingual branches as comma-separated
+-- codes such as "en,fr", and the religious education subjects such as "catholic,ethics"
+ALTER TABLE school_details ADD COLUMN europaschule BOOLEAN NOT NULL DEFAULT 0;
+ALTER TABLE school_details ADD COLUMN bilingual_languages TEXT NOT NULL DEFAULT '';
+ALTER TABLE school_details ADD COLUMN religious_education TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,16 @@
+-- Outcome of each completed scrape run: pages scraped and the pages that failed after all
+-- retries, by kind of failure
+CREATE TABLE IF NOT EXISTS scrape_runs (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	scraper TEXT NOT NULL,
+	started_at DATETIME NOT NULL,
+	finished_at DATETIME NOT NULL,
+	pages INTEGER NOT NULL DEFAULT 0,
+	failed INTEGER NOT NULL DEFAULT 0,
+	timeout_failures INTEGER NOT NULL DEFAULT 0,
+	element_missing_failures INTEGER NOT NULL DEFAULT 0,
+	navigation_failures INTEGER NOT NULL DEFAULT 0,
+	parse_failures INTEGER NOT NULL DEFAULT 0,
+	other_failures INTEGER NOT NULL DEFAULT 0
+);
+CREATE INDEX IF NOT EXISTS idx_scrape_runs_scraper_started ON scrape_runs(scraper, started_at DESC);
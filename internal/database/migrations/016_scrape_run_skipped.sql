@@ -0,0 +1,3 @@
+-- Schools a scrape run skipped when it ran out of its time budget
+ALTER TABLE scrape_runs ADD COLUMN skipped INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE scrape_runs ADD COLUMN skipped_pages TEXT NOT NULL DEFAULT '';
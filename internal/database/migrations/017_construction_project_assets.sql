@@ -0,0 +1,12 @@
+-- Documents and images linked from construction projects, such as PDF plans and photos.
+-- They are replaced together with their projects.
+CREATE TABLE IF NOT EXISTS construction_project_assets (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	project_id INTEGER NOT NULL,
+	kind TEXT NOT NULL,
+	url TEXT NOT NULL,
+	title TEXT NOT NULL DEFAULT '',
+	UNIQUE (project_id, url),
+	FOREIGN KEY (project_id) REFERENCES construction_projects(project_id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_construction_project_assets_project_id ON construction_project_assets(project_id);
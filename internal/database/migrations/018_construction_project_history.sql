@@ -0,0 +1,20 @@
+-- Every import of the construction projects with the projects it brought, so budgets can be
+-- compared with the previous import. Only the most recent imports are kept.
+CREATE TABLE IF NOT EXISTS construction_project_imports (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	imported_at DATETIME NOT NULL,
+	projects INTEGER NOT NULL DEFAULT 0
+);
+CREATE TABLE IF NOT EXISTS construction_project_history (
+	import_id INTEGER NOT NULL,
+	project_id INTEGER NOT NULL,
+	school_number TEXT NOT NULL DEFAULT '',
+	school_name TEXT NOT NULL DEFAULT '',
+	district TEXT NOT NULL DEFAULT '',
+	school_type TEXT NOT NULL DEFAULT '',
+	description TEXT NOT NULL DEFAULT '',
+	handover_date TEXT NOT NULL DEFAULT '',
+	total_costs TEXT NOT NULL DEFAULT '',
+	PRIMARY KEY (import_id, project_id),
+	FOREIGN KEY (import_id) REFERENCES construction_project_imports(id) ON DELETE CASCADE
+);
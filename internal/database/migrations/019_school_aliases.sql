@@ -0,0 +1,10 @@
+-- Former school numbers (BSN) of renumbered and merged schools, mapped to the current number.
+-- Chains are collapsed on save, so new_number is never itself an old number.
+CREATE TABLE IF NOT EXISTS school_aliases (
+	old_number TEXT PRIMARY KEY,
+	new_number TEXT NOT NULL,
+	source TEXT NOT NULL, -- mapping or heuristic
+	reason TEXT NOT NULL DEFAULT '',
+	created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_school_aliases_new_number ON school_aliases(new_number);
@@ -0,0 +1,15 @@
+-- Ratings of the Berlin school quality framework (Handlungsrahmen Schulqualität) from the
+-- school inspection section of the school pages, one row per indicator (Qualitätsmerkmal).
+-- Ratings run from A (strongly developed) to D (weakly developed).
+CREATE TABLE IF NOT EXISTS school_quality_indicators (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	school_number TEXT NOT NULL,
+	area TEXT NOT NULL DEFAULT '', -- Qualitätsbereich, empty when the table has no area rows
+	code TEXT NOT NULL DEFAULT '', -- e.g. 2.1, empty when the indicator is not numbered
+	indicator TEXT NOT NULL,
+	rating TEXT NOT NULL,
+	scraped_at DATETIME,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (school_number) REFERENCES schools(school_number) ON DELETE CASCADE ON UPDATE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_quality_indicators_school ON school_quality_indicators(school_number, id);
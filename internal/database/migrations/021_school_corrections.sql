@@ -0,0 +1,30 @@
+-- Fixes to school data suggested by users (wrong phone, dead website, ...), one field per
+-- correction. Pending corrections wait for an admin; accepted ones become overrides.
+CREATE TABLE IF NOT EXISTS school_corrections (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	school_number TEXT NOT NULL,
+	field TEXT NOT NULL,
+	current_value TEXT NOT NULL DEFAULT '', -- Value of the field when the correction was submitted
+	value TEXT NOT NULL DEFAULT '',
+	comment TEXT NOT NULL DEFAULT '',
+	status TEXT NOT NULL DEFAULT 'pending', -- pending, accepted or rejected
+	review_note TEXT NOT NULL DEFAULT '',
+	reviewed_by TEXT NOT NULL DEFAULT '', -- Credential of the reviewing admin, never the secret
+	submitted_at DATETIME NOT NULL,
+	reviewed_at DATETIME,
+	FOREIGN KEY (school_number) REFERENCES schools(school_number) ON DELETE CASCADE ON UPDATE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_school_corrections_status ON school_corrections(status, submitted_at);
+CREATE INDEX IF NOT EXISTS idx_school_corrections_school ON school_corrections(school_number);
+
+-- Accepted corrections, applied over the school list feed on every refresh so the next
+-- import does not bring the wrong value back. The latest accepted correction of a field wins.
+CREATE TABLE IF NOT EXISTS school_overrides (
+	school_number TEXT NOT NULL,
+	field TEXT NOT NULL,
+	value TEXT NOT NULL DEFAULT '',
+	correction_id INTEGER,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	PRIMARY KEY (school_number, field),
+	FOREIGN KEY (school_number) REFERENCES schools(school_number) ON DELETE CASCADE ON UPDATE CASCADE
+);
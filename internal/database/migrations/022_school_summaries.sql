@@ -0,0 +1,9 @@
+-- Last AI summary of each school that passed validation, kept for the full-text search.
+-- The search index itself (school_search) is created in Go, since it needs SQLite built
+-- with FTS5.
+CREATE TABLE IF NOT EXISTS school_summaries (
+	school_number TEXT PRIMARY KEY,
+	summary TEXT NOT NULL,
+	generated_at DATETIME NOT NULL,
+	FOREIGN KEY (school_number) REFERENCES schools(school_number) ON DELETE CASCADE ON UPDATE CASCADE
+);
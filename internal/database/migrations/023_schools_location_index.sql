@@ -0,0 +1,2 @@
+-- Bounding box prefilter of the nearby-schools query, which is not limited to one school type
+CREATE INDEX IF NOT EXISTS idx_schools_latitude_longitude ON schools(latitude, longitude);
@@ -0,0 +1,14 @@
+-- Rating and review count of each school on Google Places, looked up by name and address
+-- during the refresh and kept until EXTERNAL_RATINGS_TTL has passed. A row with an empty
+-- place_id records that no matching place was found, so the lookup waits as long.
+CREATE TABLE IF NOT EXISTS school_external_ratings (
+	school_number TEXT PRIMARY KEY,
+	source TEXT NOT NULL,
+	place_id TEXT NOT NULL DEFAULT '',
+	rating REAL, -- 1 to 5 stars, NULL for a place without reviews
+	review_count INTEGER NOT NULL DEFAULT 0,
+	url TEXT NOT NULL DEFAULT '',
+	fetched_at DATETIME NOT NULL,
+	FOREIGN KEY (school_number) REFERENCES schools(school_number) ON DELETE CASCADE ON UPDATE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_school_external_ratings_fetched_at ON school_external_ratings(fetched_at);
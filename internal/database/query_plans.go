@@ -0,0 +1,66 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// KeyQuery is a hot query whose plan must be served by an index
+type KeyQuery struct {
+	Name  string
+	Query string
+	Args  []interface{}
+}
+
+// KeyQueries are the lookups used per request by the enrichment path and filter endpoints
+var KeyQueries = []KeyQuery{
+	{"schools by number", `SELECT * FROM schools WHERE school_number = ?`, []interface{}{"01A01"}},
+	{"schools by type", `SELECT * FROM schools WHERE school_type = ? ORDER BY name`, []interface{}{"Gymnasium"}},
+	{"schools by district and type", `SELECT * FROM schools WHERE district = ? AND school_type = ?`, []interface{}{"Mitte", "Gymnasium"}},
+	{"details by school", `SELECT * FROM school_details WHERE school_number = ?`, []interface{}{"01A01"}},
+	{"citizenship by school", `SELECT * FROM school_citizenship_stats WHERE school_number = ? ORDER BY citizenship`, []interface{}{"01A01"}},
+	{"language by school", `SELECT * FROM school_language_stats WHERE school_number = ?`, []interface{}{"01A01"}},
+	{"residence by school", `SELECT * FROM school_residence_stats WHERE school_number = ? ORDER BY student_count DESC`, []interface{}{"01A01"}},
+	{"absence by school", `SELECT * FROM school_absence_stats WHERE school_number = ?`, []interface{}{"01A01"}},
+	{"grade structure by school", `SELECT * FROM school_grade_structure WHERE school_number = ? ORDER BY school_year DESC, grade`, []interface{}{"01A01"}},
+	{"staffing by school", `SELECT * FROM school_staffing_stats WHERE school_number = ?`, []interface{}{"01A01"}},
+	{"teacher qualifications by school", `SELECT * FROM school_teacher_qualifications WHERE school_number = ? ORDER BY teachers DESC, qualification`, []interface{}{"01A01"}},
+	{"statistics by school", `SELECT * FROM school_statistics WHERE school_number = ? ORDER BY school_year DESC`, []interface{}{"01A01"}},
+	{"schools of type near point", `SELECT * FROM schools WHERE school_type = ? AND latitude BETWEEN ? AND ? AND longitude BETWEEN ? AND ? AND id != ?`, []interface{}{"Gymnasium", 52.4, 52.6, 13.3, 13.5, 1}},
+	{"travel times by cell", `SELECT * FROM travel_time_cache WHERE cell = ? AND mode = ? AND computed_at >= ?`, []interface{}{"52.520,13.405", "walking", "2025-01-01"}},
+	{"construction by school", `SELECT * FROM construction_projects WHERE school_number = ? ORDER BY created_at DESC`, []interface{}{"01A01"}},
+}
+
+type queryPlanRow struct {
+	ID      int    `db:"id"`
+	Parent  int    `db:"parent"`
+	NotUsed int    `db:"notused"`
+	Detail  string `db:"detail"`
+}
+
+// CheckQueryPlans runs EXPLAIN QUERY PLAN for each query and reports the ones that
+// fall back to a full table scan or a temporary B-tree for sorting
+func CheckQueryPlans(db *sqlx.DB, queries []KeyQuery) ([]string, error) {
+	var violations []string
+
+	for _, q := range queries {
+		var plan []queryPlanRow
+		if err := db.Select(&plan, "EXPLAIN QUERY PLAN "+q.Query, q.Args...); err != nil {
+			return nil, fmt.Errorf("explain %q: %w", q.Name, err)
+		}
+
+		for _, step := range plan {
+			detail := step.Detail
+			if strings.HasPrefix(detail, "SCAN ") && !strings.Contains(detail, "USING") {
+				violations = append(violations, fmt.Sprintf("%s: full table scan (%s)", q.Name, detail))
+			}
+			if strings.HasPrefix(detail, "USE TEMP B-TREE") {
+				violations = append(violations, fmt.Sprintf("%s: unindexed sort (%s)", q.Name, detail))
+			}
+		}
+	}
+
+	return violations, nil
+}
@@ -0,0 +1,29 @@
+package database
+
+import "github.com/jmoiron/sqlx"
+
+// createSearchIndex is the full-text index of the school search: one row per school with the
+// school ID as rowid. unicode61 with remove_diacritics folds "Schüler" and "Schuler" together.
+const createSearchIndex = `
+	CREATE VIRTUAL TABLE IF NOT EXISTS school_search USING fts5(
+		name, offerings, working_groups, summary,
+		tokenize = 'unicode61 remove_diacritics 2'
+	)`
+
+// FullTextSearch reports whether SQLite was compiled with FTS5, which the go-sqlite3 driver
+// only includes when building with -tags sqlite_fts5
+func FullTextSearch(db *sqlx.DB) bool {
+	var enabled bool
+	err := db.Get(&enabled, `SELECT sqlite_compileoption_used('ENABLE_FTS5')`)
+	return err == nil && enabled
+}
+
+// migrateSearchIndex creates the search index when FTS5 is available; without it the search
+// endpoint is switched off and nothing is created
+func migrateSearchIndex(db *sqlx.DB) error {
+	if !FullTextSearch(db) {
+		return nil
+	}
+	_, err := db.Exec(createSearchIndex)
+	return err
+}
@@ -0,0 +1,43 @@
+package database
+
+import (
+	"context"
+	"fmt"
+)
+
+// Snapshot writes a consistent copy of the database to dest with VACUUM INTO and empties the
+// excluded tables in the copy. It runs on its own connection: readers are query-only, and
+// using the writer would block refreshes for the whole copy. dest must not exist or be empty.
+func (db *DB) Snapshot(ctx context.Context, dest string, excludeTables []string) error {
+	source, err := open(db.path+"?_busy_timeout=5000", db.slowQueryThreshold, db.logger)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+
+	// In WAL mode the copy is taken from one read transaction, so writes during it are not included
+	if _, err := source.ExecContext(ctx, `VACUUM INTO ?`, dest); err != nil {
+		return fmt.Errorf("failed to copy database: %w", err)
+	}
+
+	if len(excludeTables) == 0 {
+		return nil
+	}
+
+	snapshot, err := open(dest+"?_foreign_keys=0", db.slowQueryThreshold, db.logger)
+	if err != nil {
+		return err
+	}
+	defer snapshot.Close()
+
+	for _, table := range excludeTables {
+		if _, err := snapshot.ExecContext(ctx, `DELETE FROM `+table); err != nil {
+			return fmt.Errorf("failed to empty %s in snapshot: %w", table, err)
+		}
+	}
+	// Deleted rows stay in free pages until the file is rebuilt
+	if _, err := snapshot.ExecContext(ctx, `VACUUM`); err != nil {
+		return fmt.Errorf("failed to compact snapshot: %w", err)
+	}
+	return nil
+}
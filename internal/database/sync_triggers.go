@@ -0,0 +1,177 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// SyncTimestampFormat is how sync_changes stores times: UTC with milliseconds, so
+// timestamps compare correctly as text
+const SyncTimestampFormat = "2006-01-02T15:04:05.000Z"
+
+// syncNow is the SQL equivalent of SyncTimestampFormat for the current time
+const syncNow = `strftime('%Y-%m-%dT%H:%M:%fZ', 'now')`
+
+// syncedTable is a table whose changes are offered to offline clients through the delta sync
+type syncedTable struct {
+	entity string
+	table  string
+	key    []string // Columns identifying a record across refreshes
+	omit   []string // Columns the public DTOs do not expose
+}
+
+// syncedTables lists the public dataset. The normalized statistic tables are left out: they
+// are derived from school_details, which is synced without the scraped table blobs.
+var syncedTables = []syncedTable{
+	{entity: "schools", table: "schools", key: []string{"id"}, omit: []string{"fax"}},
+	{entity: "construction_projects", table: "construction_projects", key: []string{"project_id"}},
+	{entity: "construction_project_assets", table: "construction_project_assets", key: []string{"project_id", "url"}},
+	{entity: "statistics", table: "school_statistics", key: []string{"school_number", "school_year"}},
+	{entity: "school_details", table: "school_details", key: []string{"school_number"},
+		omit: []string{"citizenship_data", "language_data", "residence_data", "absence_data"}},
+	{entity: "applications", table: "school_applications", key: []string{"school_number", "school_year"}},
+	{entity: "exam_results", table: "school_exam_results", key: []string{"school_number", "school_year"}},
+}
+
+// syncIgnoredColumns are bookkeeping columns: rewriting a row with the same data changes
+// them, which must not count as a change
+var syncIgnoredColumns = map[string]bool{
+	"id":             true,
+	"version":        true,
+	"created_at":     true,
+	"updated_at":     true,
+	"scraped_at":     true,
+	"fetched_at":     true,
+	"parser_version": true,
+}
+
+// migrateSyncTriggers creates the change log for the delta sync and the triggers that fill it.
+// Triggers are recreated on every start, since table rebuilds drop them and their column
+// lists must follow columns added by later migrations. Existing rows are logged once.
+func migrateSyncTriggers(db *sqlx.DB) error {
+	ctx := context.Background()
+
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	statements := []string{
+		`CREATE TABLE IF NOT EXISTS sync_changes (
+			entity TEXT NOT NULL,
+			record_key TEXT NOT NULL,
+			deleted BOOLEAN NOT NULL DEFAULT 0,
+			content TEXT,
+			created_at TEXT NOT NULL,
+			updated_at TEXT NOT NULL,
+			deleted_at TEXT,
+			PRIMARY KEY (entity, record_key)
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_sync_changes_updated_at ON sync_changes(updated_at)`,
+		`CREATE INDEX IF NOT EXISTS idx_sync_changes_deleted_at ON sync_changes(deleted_at)`,
+	}
+	for _, stmt := range statements {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("failed to create sync change log: %w", err)
+		}
+	}
+
+	for _, t := range syncedTables {
+		if err := createSyncTriggers(ctx, tx, t); err != nil {
+			return fmt.Errorf("failed to create sync triggers for %s: %w", t.table, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// createSyncTriggers logs inserts and updates of a table as upserts with the record's
+// content, and deletes as deletions. updated_at only moves when the content changes or
+// the record comes back after a deletion, which also resets created_at.
+func createSyncTriggers(ctx context.Context, tx *sqlx.Tx, t syncedTable) error {
+	var columns []string
+	if err := tx.SelectContext(ctx, &columns, `SELECT name FROM pragma_table_info(?) ORDER BY cid`, t.table); err != nil {
+		return err
+	}
+
+	keyColumns := make(map[string]bool, len(t.key))
+	for _, c := range t.key {
+		keyColumns[c] = true
+	}
+	var fields []string
+	for _, c := range columns {
+		if slices.Contains(t.omit, c) {
+			continue
+		}
+		if keyColumns[c] || !syncIgnoredColumns[c] {
+			fields = append(fields, fmt.Sprintf(`'%s', %%[1]s.%s`, c, c))
+		}
+	}
+	content := `json_object(` + strings.Join(fields, ", ") + `)`
+
+	keyParts := make([]string, len(t.key))
+	for i, c := range t.key {
+		keyParts[i] = `%[1]s.` + c
+	}
+	key := strings.Join(keyParts, ` || '/' || `)
+
+	row := func(alias, expr string) string { return fmt.Sprintf(expr, alias) }
+	upsert := fmt.Sprintf(`
+			INSERT INTO sync_changes (entity, record_key, deleted, content, created_at, updated_at, deleted_at)
+			VALUES ('%s', %s, 0, %s, %s, %s, NULL)
+			ON CONFLICT(entity, record_key) DO UPDATE SET
+				created_at = CASE WHEN sync_changes.deleted = 1 THEN excluded.created_at ELSE sync_changes.created_at END,
+				updated_at = CASE
+					WHEN sync_changes.deleted = 0 AND sync_changes.content IS excluded.content THEN sync_changes.updated_at
+					ELSE excluded.updated_at
+				END,
+				deleted = 0,
+				content = excluded.content,
+				deleted_at = NULL;`, t.entity, row("NEW", key), row("NEW", content), syncNow, syncNow)
+	markDeleted := fmt.Sprintf(`
+			INSERT INTO sync_changes (entity, record_key, deleted, content, created_at, updated_at, deleted_at)
+			VALUES ('%s', %s, 1, NULL, %s, %s, %s)
+			ON CONFLICT(entity, record_key) DO UPDATE SET
+				deleted = 1,
+				deleted_at = excluded.deleted_at;`, t.entity, row("OLD", key), syncNow, syncNow, syncNow)
+	// A changed key (e.g. a renumbered school cascading to its data) removes the old record
+	markOldKeyDeleted := fmt.Sprintf(`
+			INSERT INTO sync_changes (entity, record_key, deleted, content, created_at, updated_at, deleted_at)
+			SELECT '%s', %s, 1, NULL, %s, %s, %s
+			WHERE %s IS NOT %s
+			ON CONFLICT(entity, record_key) DO UPDATE SET
+				deleted = 1,
+				deleted_at = excluded.deleted_at;`, t.entity, row("OLD", key), syncNow, syncNow, syncNow, row("OLD", key), row("NEW", key))
+
+	statements := []string{
+		`DROP TRIGGER IF EXISTS sync_` + t.table + `_insert`,
+		`DROP TRIGGER IF EXISTS sync_` + t.table + `_update`,
+		`DROP TRIGGER IF EXISTS sync_` + t.table + `_delete`,
+		`CREATE TRIGGER sync_` + t.table + `_insert AFTER INSERT ON ` + t.table + ` BEGIN` + upsert + `
+		END`,
+		`CREATE TRIGGER sync_` + t.table + `_update AFTER UPDATE ON ` + t.table + ` BEGIN` + markOldKeyDeleted + upsert + `
+		END`,
+		`CREATE TRIGGER sync_` + t.table + `_delete AFTER DELETE ON ` + t.table + ` BEGIN` + markDeleted + `
+		END`,
+		// Log rows stored before the change log existed; their time is when sync became available
+		fmt.Sprintf(`INSERT OR IGNORE INTO sync_changes (entity, record_key, deleted, content, created_at, updated_at)
+			SELECT '%s', %s, 0, %s, %s, %s FROM %s AS t`, t.entity, row("t", key), row("t", content), syncNow, syncNow, t.table),
+	}
+	// Remove omitted columns from records logged before they were omitted, so clients fetch
+	// the records again without them
+	for _, c := range t.omit {
+		statements = append(statements, fmt.Sprintf(`UPDATE sync_changes SET content = json_remove(content, '$.%s'), updated_at = %s
+			WHERE entity = '%s' AND deleted = 0 AND json_type(content, '$.%s') IS NOT NULL`, c, syncNow, t.entity, c))
+	}
+	for _, stmt := range statements {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
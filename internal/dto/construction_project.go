@@ -0,0 +1,122 @@
+package dto
+
+import (
+	"time"
+
+	"schools-be/internal/models"
+)
+
+// ConstructionProject is a school construction or renovation project
+type ConstructionProject struct {
+	ID                           int64     `json:"id"`
+	ProjectID                    int       `json:"project_id"`
+	SchoolNumber                 string    `json:"school_number"`
+	SchoolName                   string    `json:"school_name"`
+	District                     string    `json:"district"`
+	SchoolType                   string    `json:"school_type"`
+	ConstructionMeasure          string    `json:"construction_measure"`
+	Description                  string    `json:"description"`
+	BuiltSchoolPlaces            string    `json:"built_school_places"`
+	PlacesAfterConstruction      string    `json:"places_after_construction"`
+	ClassTracksAfterConstruction string    `json:"class_tracks_after_construction"`
+	HandoverDate                 string    `json:"handover_date"`
+	TotalCosts                   string    `json:"total_costs"`
+	Street                       string    `json:"street"`
+	PostalCode                   string    `json:"postal_code"`
+	City                         string    `json:"city"`
+	Latitude                     float64   `json:"latitude"`
+	Longitude                    float64   `json:"longitude"`
+	StartYear                    *int      `json:"start_year"` // Parsed from the description, nil when not published
+	EndYear                      *int      `json:"end_year"`   // Handover year, nil when not published
+	Status                       string    `json:"status"`     // planned, in_construction, completed or unknown
+	CreatedAt                    time.Time `json:"created_at"`
+	UpdatedAt                    time.Time `json:"updated_at"`
+
+	Assets []ConstructionProjectAsset `json:"assets,omitempty"` // Only on the construction project endpoints
+}
+
+// ConstructionProjectAsset is a document or image linked from a construction project
+type ConstructionProjectAsset struct {
+	Kind  string `json:"kind"`
+	URL   string `json:"url"`
+	Title string `json:"title"`
+}
+
+// NewConstructionProject maps a construction project row to its public representation
+func NewConstructionProject(p models.ConstructionProject) ConstructionProject {
+	timeline := p.Timeline(time.Now().Year())
+	return ConstructionProject{
+		ID:                           p.ID,
+		ProjectID:                    p.ProjectID,
+		SchoolNumber:                 p.SchoolNumber,
+		SchoolName:                   p.SchoolName,
+		District:                     p.District,
+		SchoolType:                   p.SchoolType,
+		ConstructionMeasure:          p.ConstructionMeasure,
+		Description:                  p.Description,
+		BuiltSchoolPlaces:            p.BuiltSchoolPlaces,
+		PlacesAfterConstruction:      p.PlacesAfterConstruction,
+		ClassTracksAfterConstruction: p.ClassTracksAfterConstruction,
+		HandoverDate:                 p.HandoverDate,
+		TotalCosts:                   p.TotalCosts,
+		Street:                       p.Street,
+		PostalCode:                   p.PostalCode,
+		City:                         p.City,
+		Latitude:                     p.Latitude,
+		Longitude:                    p.Longitude,
+		StartYear:                    timeline.StartYear,
+		EndYear:                      timeline.EndYear,
+		Status:                       timeline.Status,
+		CreatedAt:                    p.CreatedAt,
+		UpdatedAt:                    p.UpdatedAt,
+		Assets:                       newConstructionProjectAssets(p.Assets),
+	}
+}
+
+func newConstructionProjectAssets(assets []models.ConstructionProjectAsset) []ConstructionProjectAsset {
+	if len(assets) == 0 {
+		return nil
+	}
+	result := make([]ConstructionProjectAsset, len(assets))
+	for i, asset := range assets {
+		result[i] = ConstructionProjectAsset{Kind: asset.Kind, URL: asset.URL, Title: asset.Title}
+	}
+	return result
+}
+
+// NewConstructionProjects maps a list of construction projects
+func NewConstructionProjects(projects []models.ConstructionProject) []ConstructionProject {
+	result := make([]ConstructionProject, len(projects))
+	for i, project := range projects {
+		result[i] = NewConstructionProject(project)
+	}
+	return result
+}
+
+// ProjectTimelineEntry is one bar of the construction timeline: a project with its years
+type ProjectTimelineEntry struct {
+	ID                  int64  `json:"id"`
+	ProjectID           int    `json:"project_id"`
+	SchoolNumber        string `json:"school_number"`
+	SchoolName          string `json:"school_name"`
+	District            string `json:"district"`
+	ConstructionMeasure string `json:"construction_measure"`
+	StartYear           *int   `json:"start_year"`
+	EndYear             *int   `json:"end_year"`
+	Status              string `json:"status"`
+}
+
+// NewProjectTimelineEntry maps a construction project and its timeline to a timeline bar
+func NewProjectTimelineEntry(p models.ConstructionProject, timeline models.ProjectTimeline) ProjectTimelineEntry {
+	return ProjectTimelineEntry{
+		ID:                  p.ID,
+		ProjectID:           p.ProjectID,
+		SchoolNumber:        p.SchoolNumber,
+		SchoolName:          p.SchoolName,
+		District:            p.District,
+		ConstructionMeasure: p.ConstructionMeasure,
+		StartYear:           timeline.StartYear,
+		EndYear:             timeline.EndYear,
+		Status:              timeline.Status,
+	}
+}
@@ -0,0 +1,95 @@
+package dto
+
+import (
+	"testing"
+
+	"schools-be/internal/models"
+)
+
+func TestNewConstructionProject(t *testing.T) {
+	base := models.ConstructionProject{
+		ID:           3,
+		ProjectID:    4711,
+		SchoolNumber: "01Y02",
+		SchoolName:   "Lessing-Gymnasium",
+		TotalCosts:   "12,3 Mio. €",
+		Latitude:     52.52,
+		Longitude:    13.40,
+	}
+
+	tests := []struct {
+		name         string
+		handoverDate string
+		description  string
+		assets       []models.ConstructionProjectAsset
+		startYear    *int
+		endYear      *int
+		status       string
+		assetsJSON   bool
+	}{
+		{"no years", "", "Sanierung der Turnhalle", nil, nil, nil, models.ProjectStatusUnknown, false},
+		{"completed", "2010", "Baubeginn 2008", nil, ptr(2008), ptr(2010), models.ProjectStatusCompleted, false},
+		{"planned", "2098/2099", "Baubeginn 2097", nil, ptr(2097), ptr(2099), models.ProjectStatusPlanned, false},
+		{"with assets", "", "Bauzeit 2008 - 2010", []models.ConstructionProjectAsset{{ID: 1, ProjectID: 4711, Kind: models.AssetKindImage, URL: "https://example.de/a.jpg", Title: "Ansicht"}}, ptr(2008), ptr(2010), models.ProjectStatusCompleted, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			project := base
+			project.HandoverDate, project.Description, project.Assets = tt.handoverDate, tt.description, tt.assets
+
+			got := NewConstructionProject(project)
+			if got.ID != 3 || got.ProjectID != 4711 || got.SchoolNumber != "01Y02" || got.TotalCosts != base.TotalCosts || got.Latitude != 52.52 {
+				t.Errorf("NewConstructionProject() = %+v, fields not copied from %+v", got, project)
+			}
+			if !equalPtr(got.StartYear, tt.startYear) || !equalPtr(got.EndYear, tt.endYear) || got.Status != tt.status {
+				t.Errorf("timeline = %v-%v %s, want %v-%v %s", deref(got.StartYear), deref(got.EndYear), got.Status, deref(tt.startYear), deref(tt.endYear), tt.status)
+			}
+
+			fields := jsonFields(t, got)
+			checkFields(t, fields, []string{"project_id", "start_year", "end_year", "status"}, nil)
+			if tt.assetsJSON {
+				checkFields(t, fields, []string{"assets"}, nil)
+				asset := jsonFields(t, got.Assets[0])
+				checkFields(t, asset, []string{"kind", "url", "title"}, []string{"id", "project_id"})
+			} else {
+				checkFields(t, fields, nil, []string{"assets"})
+			}
+			if tt.startYear == nil && string(fields["start_year"]) != "null" {
+				t.Errorf("start_year = %s, want null", fields["start_year"])
+			}
+		})
+	}
+}
+
+func TestNewConstructionProjects(t *testing.T) {
+	if got := NewConstructionProjects(nil); got == nil || len(got) != 0 {
+		t.Errorf("NewConstructionProjects(nil) = %#v, want an empty list", got)
+	}
+
+	got := NewConstructionProjects([]models.ConstructionProject{{ProjectID: 1}, {ProjectID: 2}})
+	if len(got) != 2 || got[0].ProjectID != 1 || got[1].ProjectID != 2 {
+		t.Errorf("NewConstructionProjects() = %+v, want projects 1 and 2 in order", got)
+	}
+}
+
+func TestNewProjectTimelineEntry(t *testing.T) {
+	project := models.ConstructionProject{ID: 3, ProjectID: 4711, SchoolNumber: "01Y02", Description: "Baubeginn 2008", TotalCosts: "1 Mio. €"}
+	got := NewProjectTimelineEntry(project, project.Timeline(2009))
+
+	if got.ID != 3 || got.ProjectID != 4711 || deref(got.StartYear) != 2008 || got.EndYear != nil || got.Status != models.ProjectStatusInConstruction {
+		t.Errorf("NewProjectTimelineEntry() = %+v", got)
+	}
+	checkFields(t, jsonFields(t, got), []string{"start_year", "end_year", "status"}, []string{"description", "total_costs", "latitude"})
+}
+
+func equalPtr[T comparable](a, b *T) bool {
+	return a == nil && b == nil || a != nil && b != nil && *a == *b
+}
+
+func deref(year *int) any {
+	if year == nil {
+		return nil
+	}
+	return *year
+}
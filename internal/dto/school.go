@@ -0,0 +1,269 @@
+// Package dto defines the JSON shapes returned by the API. Handlers map database models
+// to these types, so tables can change without changing responses and vice versa.
+package dto
+
+import (
+	"time"
+
+	"schools-be/internal/i18n"
+	"schools-be/internal/models"
+)
+
+// School is the public representation of a school. The fax number is not exposed.
+type School struct {
+	ID             int64     `json:"id"`
+	SchoolNumber   string    `json:"school_number"`
+	Name           string    `json:"name"`
+	SchoolType     string    `json:"school_type"`
+	Operator       string    `json:"operator"`
+	SchoolCategory string    `json:"school_category"`
+	District       string    `json:"district"`
+	Neighborhood   string    `json:"neighborhood"`
+	PostalCode     string    `json:"postal_code"`
+	Street         string    `json:"street"`
+	HouseNumber    string    `json:"house_number"`
+	Phone          string    `json:"phone"`
+	Email          string    `json:"email"`
+	Website        string    `json:"website"`
+	SchoolYear     string    `json:"school_year"`
+	Latitude       *float64  `json:"latitude"`
+	Longitude      *float64  `json:"longitude"`
+	HasCoordinates bool      `json:"has_coordinates"`
+	Version        int       `json:"version"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// NewSchool maps a school row to its public representation
+func NewSchool(s models.School) School {
+	return School{
+		ID:             s.ID,
+		SchoolNumber:   s.SchoolNumber,
+		Name:           s.Name,
+		SchoolType:     s.SchoolType,
+		Operator:       s.Operator,
+		SchoolCategory: s.SchoolCategory,
+		District:       s.District,
+		Neighborhood:   s.Neighborhood,
+		PostalCode:     s.PostalCode,
+		Street:         s.Street,
+		HouseNumber:    s.HouseNumber,
+		Phone:          s.Phone,
+		Email:          s.Email,
+		Website:        s.Website,
+		SchoolYear:     s.SchoolYear,
+		Latitude:       s.Latitude,
+		Longitude:      s.Longitude,
+		HasCoordinates: s.HasCoordinates(),
+		Version:        s.Version,
+		CreatedAt:      s.CreatedAt,
+		UpdatedAt:      s.UpdatedAt,
+	}
+}
+
+// EnrichedSchool is a school with all related data
+type EnrichedSchool struct {
+	School                 School                `json:"school"`
+	Details                *SchoolDetail         `json:"details,omitempty"`
+	OperatorKind           string                `json:"operator_kind"` // public, confessional or private_secular
+	AllDayType             string                `json:"all_day_type"`  // Ganztag form: bound, open or none
+	CitizenshipStats       []CitizenshipStat     `json:"citizenship_stats,omitempty"`
+	LanguageStat           *LanguageStat         `json:"language_stat,omitempty"`
+	ResidenceStats         []ResidenceStat       `json:"residence_stats,omitempty"`
+	AbsenceStat            *AbsenceStat          `json:"absence_stat,omitempty"`
+	Statistics             []Statistic           `json:"statistics,omitempty"`
+	Applications           []Application         `json:"applications,omitempty"`
+	Oversubscription       *Application          `json:"oversubscription,omitempty"` // Latest school year with places
+	ConstructionProjects   []ConstructionProject `json:"construction_projects,omitempty"`
+	ConstructionInvestment float64               `json:"construction_investment,omitempty"`
+	ExternalRating         *ExternalRating       `json:"external_rating,omitempty"`
+	Labels                 *Labels               `json:"labels,omitempty"`
+	Commute                *Commute              `json:"commute,omitempty"`
+	Completeness           Completeness          `json:"completeness"`
+}
+
+// Completeness tells which enrichment sections of a school have data
+type Completeness struct {
+	Score   int      `json:"score"`   // Percentage of sections with data
+	Missing []string `json:"missing"` // details, coordinates, statistics, citizenship_stats, language_stat, residence_stats or absence_stat
+}
+
+// ExternalRating is the rating of a school on a third-party review site
+type ExternalRating struct {
+	Source      string    `json:"source"` // google_places
+	Rating      *float64  `json:"rating"` // 1 to 5 stars, null without reviews
+	ReviewCount int       `json:"review_count"`
+	URL         string    `json:"url,omitempty"`
+	FetchedAt   time.Time `json:"fetched_at"`
+}
+
+// Labels contains human-readable labels for the school's enum-like fields in the requested language
+type Labels struct {
+	Language       string `json:"language"`
+	SchoolType     string `json:"school_type"`
+	Operator       string `json:"operator"`
+	SchoolCategory string `json:"school_category"`
+}
+
+// newLabels looks up the labels of the school type, operator and category in lang
+func newLabels(s models.School, lang string) *Labels {
+	return &Labels{
+		Language:       lang,
+		SchoolType:     i18n.Label(lang, s.SchoolType),
+		Operator:       i18n.Label(lang, s.Operator),
+		SchoolCategory: i18n.Label(lang, s.SchoolCategory),
+	}
+}
+
+// Commute is the travel time from the caller's registered location
+type Commute struct {
+	Mode            string  `json:"mode"`
+	DurationMinutes int     `json:"duration_minutes"`
+	DistanceKm      float64 `json:"distance_km"`
+}
+
+// NewEnrichedSchool maps an enriched school to its public representation, with the labels of
+// its enum-like fields in lang (see i18n.ParseAcceptLanguage); an empty lang leaves them out
+func NewEnrichedSchool(s models.EnrichedSchool, lang string) EnrichedSchool {
+	enriched := EnrichedSchool{
+		School:                 NewSchool(s.School),
+		Details:                newSchoolDetail(s.Details),
+		OperatorKind:           s.OperatorKind(),
+		AllDayType:             s.AllDayType(),
+		CitizenshipStats:       mapSlice(s.CitizenshipStats, newCitizenshipStat),
+		ResidenceStats:         mapSlice(s.ResidenceStats, newResidenceStat),
+		Statistics:             mapSlice(s.Statistics, newStatistic),
+		Applications:           mapSlice(s.Applications, newApplication),
+		ConstructionProjects:   mapSlice(s.ConstructionProjects, NewConstructionProject),
+		ConstructionInvestment: s.ConstructionInvestment(),
+	}
+	completeness := s.ComputeCompleteness()
+	if s.Completeness != nil {
+		completeness = *s.Completeness
+	}
+	enriched.Completeness = Completeness{Score: completeness.Score, Missing: completeness.Missing}
+	for _, application := range enriched.Applications {
+		if application.OversubscriptionRatio != nil {
+			latest := application
+			enriched.Oversubscription = &latest
+			break
+		}
+	}
+	if s.LanguageStat != nil {
+		stat := newLanguageStat(*s.LanguageStat)
+		enriched.LanguageStat = &stat
+	}
+	if s.AbsenceStat != nil {
+		stat := newAbsenceStat(*s.AbsenceStat)
+		enriched.AbsenceStat = &stat
+	}
+	if s.ExternalRating != nil {
+		enriched.ExternalRating = &ExternalRating{
+			Source:      s.ExternalRating.Source,
+			Rating:      s.ExternalRating.Rating,
+			ReviewCount: s.ExternalRating.ReviewCount,
+			URL:         s.ExternalRating.URL,
+			FetchedAt:   s.ExternalRating.FetchedAt,
+		}
+	}
+	if lang != "" {
+		enriched.Labels = newLabels(s.School, lang)
+	}
+	if s.Commute != nil {
+		enriched.Commute = &Commute{
+			Mode:            s.Commute.Mode,
+			DurationMinutes: s.Commute.DurationMinutes,
+			DistanceKm:      s.Commute.DistanceKm,
+		}
+	}
+	return enriched
+}
+
+// NewEnrichedSchools maps a list of enriched schools with labels in lang
+func NewEnrichedSchools(schools []models.EnrichedSchool, lang string) []EnrichedSchool {
+	result := make([]EnrichedSchool, len(schools))
+	for i, school := range schools {
+		result[i] = NewEnrichedSchool(school, lang)
+	}
+	return result
+}
+
+// SimilarSchool is a nearby school of the same type with headline statistics
+type SimilarSchool struct {
+	School        School   `json:"school"`
+	DistanceKm    float64  `json:"distance_km"`
+	SchoolYear    string   `json:"school_year,omitempty"`
+	Students      string   `json:"students,omitempty"`
+	Teachers      string   `json:"teachers,omitempty"`
+	Classes       string   `json:"classes,omitempty"`
+	NDHPercentage *float64 `json:"ndh_percentage,omitempty"`
+}
+
+// NewSimilarSchools maps a list of similar schools
+func NewSimilarSchools(schools []models.SimilarSchool) []SimilarSchool {
+	result := make([]SimilarSchool, len(schools))
+	for i, s := range schools {
+		result[i] = SimilarSchool{
+			School:        NewSchool(s.School),
+			DistanceKm:    s.DistanceKm,
+			SchoolYear:    s.SchoolYear,
+			Students:      s.Students,
+			Teachers:      s.Teachers,
+			Classes:       s.Classes,
+			NDHPercentage: s.NDHPercentage,
+		}
+	}
+	return result
+}
+
+// NearbySchool is a school near a point with its distance
+type NearbySchool struct {
+	School     School  `json:"school"`
+	DistanceKm float64 `json:"distance_km"`
+}
+
+// NewNearbySchools maps a list of nearby schools, nearest first
+func NewNearbySchools(schools []models.NearbySchool) []NearbySchool {
+	result := make([]NearbySchool, len(schools))
+	for i, s := range schools {
+		result[i] = NearbySchool{School: NewSchool(s.School), DistanceKm: s.DistanceKm}
+	}
+	return result
+}
+
+// SchoolSearchResult is a school matching a full-text search, with the matching text
+type SchoolSearchResult struct {
+	School  School  `json:"school"`
+	Score   float64 `json:"score"`
+	Snippet string  `json:"snippet"`
+}
+
+// NewSchoolSearchResults maps a list of search results, best match first
+func NewSchoolSearchResults(results []models.SchoolSearchResult) []SchoolSearchResult {
+	mapped := make([]SchoolSearchResult, len(results))
+	for i, r := range results {
+		mapped[i] = SchoolSearchResult{School: NewSchool(r.School), Score: r.Score, Snippet: r.Snippet}
+	}
+	return mapped
+}
+
+// NewStatistics maps a list of school statistics
+func NewStatistics(statistics []models.SchoolStatistic) []Statistic {
+	result := make([]Statistic, len(statistics))
+	for i, statistic := range statistics {
+		result[i] = newStatistic(statistic)
+	}
+	return result
+}
+
+// mapSlice maps each element, keeping nil for empty input so omitempty still applies
+func mapSlice[T, U any](items []T, fn func(T) U) []U {
+	if len(items) == 0 {
+		return nil
+	}
+	result := make([]U, len(items))
+	for i, item := range items {
+		result[i] = fn(item)
+	}
+	return result
+}
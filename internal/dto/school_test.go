@@ -0,0 +1,275 @@
+package dto
+
+import (
+	"encoding/json"
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"schools-be/internal/models"
+)
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+// jsonFields marshals v and returns its top-level JSON object
+func jsonFields(t *testing.T, v any) map[string]json.RawMessage {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		t.Fatalf("unmarshal %s: %v", data, err)
+	}
+	return fields
+}
+
+// checkFields fails for every key in present missing from fields and every key in absent
+// found in fields
+func checkFields(t *testing.T, fields map[string]json.RawMessage, present, absent []string) {
+	t.Helper()
+	for _, key := range present {
+		if _, ok := fields[key]; !ok {
+			t.Errorf("%q missing from %v", key, slices.Sorted(maps.Keys(fields)))
+		}
+	}
+	for _, key := range absent {
+		if value, ok := fields[key]; ok {
+			t.Errorf("%q = %s, want it left out", key, value)
+		}
+	}
+}
+
+func TestNewSchool(t *testing.T) {
+	updated := time.Date(2025, 9, 1, 4, 0, 0, 0, time.UTC)
+	base := models.School{
+		ID:             7,
+		SchoolNumber:   "01Y02",
+		Name:           "Lessing-Gymnasium",
+		SchoolType:     "Gymnasium",
+		Operator:       "öffentlich",
+		SchoolCategory: "Allgemein bildende Schule",
+		District:       "Mitte",
+		Phone:          "030 1234567",
+		Fax:            "030 1234568",
+		Email:          "sekretariat@example.de",
+		Version:        3,
+		UpdatedAt:      updated,
+	}
+
+	tests := []struct {
+		name           string
+		latitude       *float64
+		longitude      *float64
+		hasCoordinates bool
+		wantJSON       map[string]string
+	}{
+		{"with coordinates", ptr(52.52), ptr(13.40), true, map[string]string{"latitude": "52.52", "longitude": "13.4", "has_coordinates": "true"}},
+		{"without coordinates", nil, nil, false, map[string]string{"latitude": "null", "longitude": "null", "has_coordinates": "false"}},
+		{"latitude only", ptr(52.52), nil, false, map[string]string{"latitude": "52.52", "longitude": "null", "has_coordinates": "false"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			school := base
+			school.Latitude, school.Longitude = tt.latitude, tt.longitude
+
+			got := NewSchool(school)
+			if got.ID != 7 || got.SchoolNumber != "01Y02" || got.Name != school.Name || got.Phone != school.Phone ||
+				got.Email != school.Email || got.Version != 3 || !got.UpdatedAt.Equal(updated) {
+				t.Errorf("NewSchool() = %+v, fields not copied from %+v", got, school)
+			}
+			if got.HasCoordinates != tt.hasCoordinates {
+				t.Errorf("HasCoordinates = %v, want %v", got.HasCoordinates, tt.hasCoordinates)
+			}
+
+			fields := jsonFields(t, got)
+			checkFields(t, fields, []string{"school_number", "school_type", "school_category", "house_number", "postal_code", "created_at", "updated_at"}, []string{"fax", "Fax", "SchoolNumber"})
+			for key, want := range tt.wantJSON {
+				if string(fields[key]) != want {
+					t.Errorf("%s = %s, want %s", key, fields[key], want)
+				}
+			}
+		})
+	}
+}
+
+func TestNewEnrichedSchool(t *testing.T) {
+	school := models.School{SchoolNumber: "01Y02", Name: "Lessing-Gymnasium", SchoolType: "Gymnasium", Operator: "privat", Latitude: ptr(52.52), Longitude: ptr(13.40)}
+	fetched := time.Date(2025, 10, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		in    models.EnrichedSchool
+		lang  string
+		check func(t *testing.T, got EnrichedSchool)
+		// JSON keys that must be present or left out
+		present []string
+		absent  []string
+	}{
+		{
+			name: "school only",
+			in:   models.EnrichedSchool{School: models.School{SchoolNumber: "01Y03", Operator: "öffentlich"}},
+			check: func(t *testing.T, got EnrichedSchool) {
+				if got.OperatorKind != models.OperatorKindPublic || got.AllDayType != models.AllDayNone {
+					t.Errorf("operator kind, all-day type = %q, %q, want public, none", got.OperatorKind, got.AllDayType)
+				}
+				if got.Completeness.Score != 0 || len(got.Completeness.Missing) != len(models.CompletenessSections) {
+					t.Errorf("completeness = %+v, want every section missing", got.Completeness)
+				}
+				if got.ConstructionInvestment != 0 {
+					t.Errorf("construction investment = %v, want 0", got.ConstructionInvestment)
+				}
+			},
+			present: []string{"school", "operator_kind", "all_day_type", "completeness"},
+			absent: []string{"details", "citizenship_stats", "language_stat", "residence_stats", "absence_stat", "statistics",
+				"applications", "oversubscription", "construction_projects", "construction_investment", "external_rating", "labels", "commute"},
+		},
+		{
+			name: "all sections",
+			in: models.EnrichedSchool{
+				School:           school,
+				Details:          &models.SchoolDetail{SchoolNumber: "01Y02", Confession: models.ConfessionCatholic, AllDayType: models.AllDayOpen, CitizenshipData: `{"headers":["Land"]}`, ParserVersion: 4},
+				CitizenshipStats: []models.SchoolCitizenshipStat{{Citizenship: "Deutschland", Total: 500}},
+				LanguageStat:     &models.SchoolLanguageStat{NDHTotal: 120, NDHPercentage: 24},
+				ResidenceStats:   []models.SchoolResidenceStat{{District: "Mitte", StudentCount: 300}},
+				AbsenceStat:      &models.SchoolAbsenceStat{SchoolAbsenceRate: ptr(5.5)},
+				Statistics:       []models.SchoolStatistic{{SchoolYear: "2024/25", Students: "500"}},
+				Applications: []models.SchoolApplication{
+					{SchoolYear: "2025/26", FirstChoiceApplications: 40},
+					{SchoolYear: "2024/25", Places: 50, FirstChoiceApplications: 75},
+				},
+				ConstructionProjects: []models.ConstructionProject{{ProjectID: 1, TotalCosts: "1,5 Mio. €"}},
+				ExternalRating:       &models.ExternalRating{Source: models.ExternalRatingSourceGooglePlaces, PlaceID: "place-1", Rating: ptr(4.5), ReviewCount: 12, FetchedAt: fetched},
+				Commute:              &models.CommuteTime{Mode: "cycling", DurationMinutes: 14, DistanceKm: 3.2},
+				Completeness:         &models.DataCompleteness{Score: 90, Missing: []string{models.SectionAbsence}},
+			},
+			lang: "en",
+			check: func(t *testing.T, got EnrichedSchool) {
+				if got.OperatorKind != models.OperatorKindConfessional || got.AllDayType != models.AllDayOpen {
+					t.Errorf("operator kind, all-day type = %q, %q, want confessional, open", got.OperatorKind, got.AllDayType)
+				}
+				if got.Oversubscription == nil || got.Oversubscription.SchoolYear != "2024/25" || *got.Oversubscription.OversubscriptionRatio != 1.5 {
+					t.Errorf("oversubscription = %+v, want 2024/25 with ratio 1.5", got.Oversubscription)
+				}
+				if got.Applications[0].OversubscriptionRatio != nil {
+					t.Errorf("ratio without places = %v, want nil", *got.Applications[0].OversubscriptionRatio)
+				}
+				if got.LanguageStat.NDHPercentage != 24 || *got.AbsenceStat.SchoolAbsenceRate != 5.5 || got.ResidenceStats[0].StudentCount != 300 {
+					t.Errorf("statistics not copied: %+v, %+v, %+v", got.LanguageStat, got.AbsenceStat, got.ResidenceStats)
+				}
+				if got.ConstructionInvestment != 1.5e6 {
+					t.Errorf("construction investment = %v, want 1.5e6", got.ConstructionInvestment)
+				}
+				if got.ExternalRating == nil || *got.ExternalRating.Rating != 4.5 || got.ExternalRating.ReviewCount != 12 || !got.ExternalRating.FetchedAt.Equal(fetched) {
+					t.Errorf("external rating = %+v", got.ExternalRating)
+				}
+				if got.Commute == nil || got.Commute.DurationMinutes != 14 {
+					t.Errorf("commute = %+v", got.Commute)
+				}
+				if got.Completeness.Score != 90 || !slices.Equal(got.Completeness.Missing, []string{models.SectionAbsence}) {
+					t.Errorf("completeness = %+v, want the stored one", got.Completeness)
+				}
+				want := Labels{Language: "en", SchoolType: "grammar school (Gymnasium)", Operator: "private"}
+				if got.Labels == nil || *got.Labels != want {
+					t.Errorf("labels = %+v, want %+v", got.Labels, want)
+				}
+
+				details := jsonFields(t, got.Details)
+				checkFields(t, details, []string{"school_number", "all_day_type", "support_focuses"},
+					[]string{"citizenship_data", "language_data", "residence_data", "absence_data", "parser_version", "tables"})
+				if string(details["support_focuses"]) != "[]" {
+					t.Errorf("support_focuses = %s, want []", details["support_focuses"])
+				}
+				rating := jsonFields(t, got.ExternalRating)
+				checkFields(t, rating, []string{"source", "rating", "review_count", "fetched_at"}, []string{"place_id", "school_number"})
+			},
+			present: []string{"details", "citizenship_stats", "language_stat", "residence_stats", "absence_stat", "statistics",
+				"applications", "oversubscription", "construction_projects", "construction_investment", "external_rating", "labels", "commute"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NewEnrichedSchool(tt.in, tt.lang)
+			tt.check(t, got)
+
+			fields := jsonFields(t, got)
+			checkFields(t, fields, tt.present, tt.absent)
+			checkFields(t, jsonFields(t, got.School), nil, []string{"fax"})
+		})
+	}
+}
+
+func TestNewEnrichedSchoolsLanguage(t *testing.T) {
+	schools := []models.EnrichedSchool{{School: models.School{SchoolType: "Gymnasium", Operator: "öffentlich"}}}
+
+	tests := []struct {
+		lang string
+		want *Labels
+	}{
+		{"", nil},
+		{"de", &Labels{Language: "de", SchoolType: "Gymnasium", Operator: "öffentlich"}},
+		{"en", &Labels{Language: "en", SchoolType: "grammar school (Gymnasium)", Operator: "public"}},
+	}
+
+	for _, tt := range tests {
+		t.Run("lang="+tt.lang, func(t *testing.T) {
+			got := NewEnrichedSchools(schools, tt.lang)
+			if len(got) != 1 {
+				t.Fatalf("len = %d, want 1", len(got))
+			}
+			switch {
+			case tt.want == nil && got[0].Labels != nil:
+				t.Errorf("labels = %+v, want none", got[0].Labels)
+			case tt.want != nil && (got[0].Labels == nil || *got[0].Labels != *tt.want):
+				t.Errorf("labels = %+v, want %+v", got[0].Labels, tt.want)
+			}
+		})
+	}
+}
+
+func TestSchoolListMappings(t *testing.T) {
+	school := models.School{SchoolNumber: "01Y02", Fax: "030 1234568"}
+
+	tests := []struct {
+		name    string
+		got     any
+		want    []string
+		notWant []string
+	}{
+		{"similar", NewSimilarSchools([]models.SimilarSchool{{School: school, DistanceKm: 1.2, Students: "500", NDHPercentage: ptr(24.0)}}), []string{`"distance_km":1.2`, `"students":"500"`, `"ndh_percentage":24`}, nil},
+		{"similar without statistics", NewSimilarSchools([]models.SimilarSchool{{School: school, DistanceKm: 1.2}}), []string{`"distance_km":1.2`}, []string{`"students"`, `"teachers"`, `"ndh_percentage"`}},
+		{"nearby", NewNearbySchools([]models.NearbySchool{{School: school, DistanceKm: 0.4}}), []string{`"school_number":"01Y02"`, `"distance_km":0.4`}, nil},
+		{"search", NewSchoolSearchResults([]models.SchoolSearchResult{{School: school, Score: 2.5, Snippet: "[Latein]"}}), []string{`"score":2.5,"snippet":"[Latein]"`}, nil},
+		{"statistics", NewStatistics([]models.SchoolStatistic{{SchoolNumber: "01Y02", SchoolYear: "2024/25", Students: "500"}}), []string{`"school_year":"2024/25"`, `"students":"500"`}, nil},
+		{"empty similar", NewSimilarSchools(nil), []string{`[]`}, nil},
+		{"empty nearby", NewNearbySchools(nil), []string{`[]`}, nil},
+		{"empty search", NewSchoolSearchResults(nil), []string{`[]`}, nil},
+		{"empty statistics", NewStatistics(nil), []string{`[]`}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := json.Marshal(tt.got)
+			if err != nil {
+				t.Fatalf("marshal: %v", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(string(data), want) {
+					t.Errorf("json = %s, want %s", data, want)
+				}
+			}
+			for _, notWant := range append(tt.notWant, `"fax"`, school.Fax) {
+				if strings.Contains(string(data), notWant) {
+					t.Errorf("json = %s, want no %s", data, notWant)
+				}
+			}
+		})
+	}
+}
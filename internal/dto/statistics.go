@@ -0,0 +1,456 @@
+package dto
+
+import (
+	"encoding/json"
+	"time"
+
+	"schools-be/internal/models"
+)
+
+// SchoolDetail contains the information scraped from the school's portrait page.
+// Scraper bookkeeping (parser version) and the stored *_data tables are not exposed; the
+// school details endpoints return the tables decoded in Tables.
+type SchoolDetail struct {
+	ID                     int64     `json:"id"`
+	SchoolNumber           string    `json:"school_number"`
+	SchoolName             string    `json:"school_name"`
+	Languages              string    `json:"languages"`
+	Courses                string    `json:"courses"`
+	Offerings              string    `json:"offerings"`
+	AvailableAfter4thGrade bool      `json:"available_after_4th_grade"`
+	AdditionalInfo         string    `json:"additional_info"`
+	Equipment              string    `json:"equipment"`
+	WorkingGroups          string    `json:"working_groups"`
+	Partners               string    `json:"partners"`
+	Differentiation        string    `json:"differentiation"`
+	LunchInfo              string    `json:"lunch_info"`
+	DualLearning           string    `json:"dual_learning"`
+	OperatorOrganization   string    `json:"operator_organization"` // Operator of a private school
+	Confession             string    `json:"confession"`            // catholic, protestant, christian, jewish or islamic; empty for none
+	FeeInfo                string    `json:"fee_info"`              // Fees as published
+	FeeMinEUR              *int      `json:"fee_min_eur"`           // Lowest monthly fee in euros, null when not published
+	FeeMaxEUR              *int      `json:"fee_max_eur"`           // Highest monthly fee in euros, null when not published
+	SupportFocuses         []string  `json:"support_focuses"`       // Special-needs support focus areas (Förderschwerpunkte)
+	WheelchairAccessible   string    `json:"wheelchair_accessible"` // yes, no or unknown
+	AllDayType             string    `json:"all_day_type"`          // Ganztag form named on the school page: bound, open or none
+	Europaschule           bool      `json:"europaschule"`          // Staatliche Europa-Schule Berlin (SESB)
+	BilingualLanguages     []string  `json:"bilingual_languages"`   // Partner languages of bilingual branches (ISO 639-1)
+	ReligiousEducation     []string  `json:"religious_education"`   // Religious and worldview education subjects
+	ScrapedAt              time.Time `json:"scraped_at"`
+	CreatedAt              time.Time `json:"created_at"`
+	UpdatedAt              time.Time `json:"updated_at"`
+
+	// Tables holds the decoded *_data tables; only set by the school details endpoints
+	Tables *StatisticTables `json:"tables,omitempty"`
+}
+
+// StatisticTables are the statistic tables scraped from the school's portrait page.
+// A table is null when the school page did not have it.
+type StatisticTables struct {
+	Citizenship *StatisticTable `json:"citizenship"`
+	Language    *StatisticTable `json:"language"`
+	Residence   *StatisticTable `json:"residence"`
+	Absence     *StatisticTable `json:"absence"`
+}
+
+// StatisticTable is a scraped HTML table as headers and rows
+type StatisticTable struct {
+	Headers []string          `json:"headers"`
+	Rows    [][]string        `json:"rows"`
+	Data    map[string]string `json:"data,omitempty"`
+}
+
+// NewSchoolDetailWithTables maps a school detail including its decoded statistic tables
+func NewSchoolDetailWithTables(d models.SchoolDetail) SchoolDetail {
+	detail := newSchoolDetail(&d)
+	detail.Tables = &StatisticTables{
+		Citizenship: decodeTable(d.CitizenshipData),
+		Language:    decodeTable(d.LanguageData),
+		Residence:   decodeTable(d.ResidenceData),
+		Absence:     decodeTable(d.AbsenceData),
+	}
+	return *detail
+}
+
+// NewSchoolDetailsWithTables maps a list of school details including their statistic tables
+func NewSchoolDetailsWithTables(details []models.SchoolDetail) []SchoolDetail {
+	result := make([]SchoolDetail, len(details))
+	for i, detail := range details {
+		result[i] = NewSchoolDetailWithTables(detail)
+	}
+	return result
+}
+
+// RawStatisticTables are the statistic tables of a school page exactly as the scraper stored
+// them, headers and cells unchanged, before any normalization into numbers. A table is null
+// when the school page did not have it.
+type RawStatisticTables struct {
+	SchoolNumber  string          `json:"school_number"`
+	ScrapedAt     time.Time       `json:"scraped_at"`
+	ParserVersion int             `json:"parser_version"` // Version of the parser that split the page into tables
+	Citizenship   json.RawMessage `json:"citizenship"`
+	Language      json.RawMessage `json:"language"`
+	Residence     json.RawMessage `json:"residence"`
+	Absence       json.RawMessage `json:"absence"`
+}
+
+// NewRawStatisticTables passes the stored *_data columns of a school detail through unchanged
+func NewRawStatisticTables(d models.SchoolDetail) RawStatisticTables {
+	return RawStatisticTables{
+		SchoolNumber:  d.SchoolNumber,
+		ScrapedAt:     d.ScrapedAt,
+		ParserVersion: d.ParserVersion,
+		Citizenship:   rawTable(d.CitizenshipData),
+		Language:      rawTable(d.LanguageData),
+		Residence:     rawTable(d.ResidenceData),
+		Absence:       rawTable(d.AbsenceData),
+	}
+}
+
+// rawTable returns a stored table as is, or null for a missing one
+func rawTable(data string) json.RawMessage {
+	if decodeTable(data) == nil || !json.Valid([]byte(data)) {
+		return json.RawMessage("null")
+	}
+	return json.RawMessage(data)
+}
+
+// decodeTable parses a stored table; the scraper stores "{}" (or nothing) for missing tables
+func decodeTable(data string) *StatisticTable {
+	var table StatisticTable
+	if err := json.Unmarshal([]byte(data), &table); err != nil {
+		return nil
+	}
+	if len(table.Headers) == 0 && len(table.Rows) == 0 && len(table.Data) == 0 {
+		return nil
+	}
+	return &table
+}
+
+func newSchoolDetail(d *models.SchoolDetail) *SchoolDetail {
+	if d == nil {
+		return nil
+	}
+	return &SchoolDetail{
+		ID:                     d.ID,
+		SchoolNumber:           d.SchoolNumber,
+		SchoolName:             d.SchoolName,
+		Languages:              d.Languages,
+		Courses:                d.Courses,
+		Offerings:              d.Offerings,
+		AvailableAfter4thGrade: d.AvailableAfter4thGrade,
+		AdditionalInfo:         d.AdditionalInfo,
+		Equipment:              d.Equipment,
+		WorkingGroups:          d.WorkingGroups,
+		Partners:               d.Partners,
+		Differentiation:        d.Differentiation,
+		LunchInfo:              d.LunchInfo,
+		DualLearning:           d.DualLearning,
+		OperatorOrganization:   d.OperatorOrganization,
+		Confession:             d.Confession,
+		FeeInfo:                d.FeeInfo,
+		FeeMinEUR:              d.FeeMinEUR,
+		FeeMaxEUR:              d.FeeMaxEUR,
+		SupportFocuses:         d.SupportFocusList(),
+		WheelchairAccessible:   d.WheelchairAccessible,
+		AllDayType:             d.AllDayType,
+		Europaschule:           d.Europaschule,
+		BilingualLanguages:     d.BilingualLanguageList(),
+		ReligiousEducation:     d.ReligiousEducationList(),
+		ScrapedAt:              d.ScrapedAt,
+		CreatedAt:              d.CreatedAt,
+		UpdatedAt:              d.UpdatedAt,
+	}
+}
+
+// CitizenshipStat is the number of students of one citizenship region
+type CitizenshipStat struct {
+	ID              int64     `json:"id"`
+	SchoolNumber    string    `json:"school_number"`
+	Citizenship     string    `json:"citizenship"`
+	FemaleStudents  int       `json:"female_students"`
+	MaleStudents    int       `json:"male_students"`
+	DiverseStudents int       `json:"diverse_students"`
+	Total           int       `json:"total"`
+	ScrapedAt       time.Time `json:"scraped_at"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+func newCitizenshipStat(s models.SchoolCitizenshipStat) CitizenshipStat {
+	return CitizenshipStat{
+		ID:              s.ID,
+		SchoolNumber:    s.SchoolNumber,
+		Citizenship:     s.Citizenship,
+		FemaleStudents:  s.FemaleStudents,
+		MaleStudents:    s.MaleStudents,
+		DiverseStudents: s.DiverseStudents,
+		Total:           s.Total,
+		ScrapedAt:       s.ScrapedAt,
+		CreatedAt:       s.CreatedAt,
+	}
+}
+
+// LanguageStat is the number of students with a non-German heritage language
+type LanguageStat struct {
+	ID                 int64     `json:"id"`
+	SchoolNumber       string    `json:"school_number"`
+	TotalStudents      int       `json:"total_students"`
+	NDHFemaleStudents  int       `json:"ndh_female_students"`
+	NDHMaleStudents    int       `json:"ndh_male_students"`
+	NDHDiverseStudents int       `json:"ndh_diverse_students"`
+	NDHTotal           int       `json:"ndh_total"`
+	NDHPercentage      float64   `json:"ndh_percentage"`
+	ScrapedAt          time.Time `json:"scraped_at"`
+	CreatedAt          time.Time `json:"created_at"`
+}
+
+func newLanguageStat(s models.SchoolLanguageStat) LanguageStat {
+	return LanguageStat{
+		ID:                 s.ID,
+		SchoolNumber:       s.SchoolNumber,
+		TotalStudents:      s.TotalStudents,
+		NDHFemaleStudents:  s.NDHFemaleStudents,
+		NDHMaleStudents:    s.NDHMaleStudents,
+		NDHDiverseStudents: s.NDHDiverseStudents,
+		NDHTotal:           s.NDHTotal,
+		NDHPercentage:      s.NDHPercentage,
+		ScrapedAt:          s.ScrapedAt,
+		CreatedAt:          s.CreatedAt,
+	}
+}
+
+// ResidenceStat is the number of students living in one district
+type ResidenceStat struct {
+	ID           int64     `json:"id"`
+	SchoolNumber string    `json:"school_number"`
+	District     string    `json:"district"`
+	StudentCount int       `json:"student_count"`
+	ScrapedAt    time.Time `json:"scraped_at"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+func newResidenceStat(s models.SchoolResidenceStat) ResidenceStat {
+	return ResidenceStat{
+		ID:           s.ID,
+		SchoolNumber: s.SchoolNumber,
+		District:     s.District,
+		StudentCount: s.StudentCount,
+		ScrapedAt:    s.ScrapedAt,
+		CreatedAt:    s.CreatedAt,
+	}
+}
+
+// GradeClasses is the number of classes (Züge) of a school in one grade and school year
+type GradeClasses struct {
+	SchoolYear string    `json:"school_year"`
+	Grade      int       `json:"grade"`
+	Classes    int       `json:"classes"`
+	ScrapedAt  time.Time `json:"scraped_at"`
+}
+
+// NewGradeStructure converts the classes per grade of a school
+func NewGradeStructure(stats []models.SchoolGradeClasses) []GradeClasses {
+	result := make([]GradeClasses, len(stats))
+	for i, s := range stats {
+		result[i] = GradeClasses{
+			SchoolYear: s.SchoolYear,
+			Grade:      s.Grade,
+			Classes:    s.Classes,
+			ScrapedAt:  s.ScrapedAt,
+		}
+	}
+	return result
+}
+
+// SchoolPrograms are the bilingual branches and religious education of a school
+type SchoolPrograms struct {
+	SchoolNumber       string   `json:"school_number"`
+	SchoolName         string   `json:"school_name"`
+	Europaschule       bool     `json:"europaschule"`
+	BilingualLanguages []string `json:"bilingual_languages"`
+	ReligiousEducation []string `json:"religious_education"`
+}
+
+// NewSchoolProgramsList converts the programs of several schools
+func NewSchoolProgramsList(details []models.SchoolDetail) []SchoolPrograms {
+	result := make([]SchoolPrograms, len(details))
+	for i, d := range details {
+		result[i] = SchoolPrograms{
+			SchoolNumber:       d.SchoolNumber,
+			SchoolName:         d.SchoolName,
+			Europaschule:       d.Europaschule,
+			BilingualLanguages: d.BilingualLanguageList(),
+			ReligiousEducation: d.ReligiousEducationList(),
+		}
+	}
+	return result
+}
+
+// Staffing is the teacher staffing of a school: its staffing coverage (Ausstattung), the
+// percentage of its entitled teaching hours that are covered, null when not published, and
+// its teachers by qualification
+type Staffing struct {
+	SchoolNumber     string                 `json:"school_number"`
+	StaffingCoverage *float64               `json:"staffing_coverage"`
+	Qualifications   []TeacherQualification `json:"qualifications"`
+	ScrapedAt        time.Time              `json:"scraped_at"`
+}
+
+// TeacherQualification is the number of teachers with one qualification
+type TeacherQualification struct {
+	Qualification string `json:"qualification"`
+	Teachers      int    `json:"teachers"`
+}
+
+// NewStaffing converts the staffing of a school
+func NewStaffing(s models.SchoolStaffingStat) Staffing {
+	staffing := Staffing{
+		SchoolNumber:     s.SchoolNumber,
+		StaffingCoverage: s.StaffingCoverage,
+		Qualifications:   make([]TeacherQualification, len(s.Qualifications)),
+		ScrapedAt:        s.ScrapedAt,
+	}
+	for i, q := range s.Qualifications {
+		staffing.Qualifications[i] = TeacherQualification{Qualification: q.Qualification, Teachers: q.Teachers}
+	}
+	return staffing
+}
+
+// NewStaffingList converts the staffing of several schools
+func NewStaffingList(stats []models.SchoolStaffingStat) []Staffing {
+	result := make([]Staffing, len(stats))
+	for i, s := range stats {
+		result[i] = NewStaffing(s)
+	}
+	return result
+}
+
+// QualityProfile is the quality framework rating of a school from its school inspection, one
+// rating per indicator from A (strongly developed) to D (weakly developed)
+type QualityProfile struct {
+	SchoolNumber string             `json:"school_number"`
+	Indicators   []QualityIndicator `json:"indicators"`
+	ScrapedAt    time.Time          `json:"scraped_at"`
+}
+
+// QualityIndicator is the rating of one indicator (Qualitätsmerkmal)
+type QualityIndicator struct {
+	Area      string `json:"area"`
+	Code      string `json:"code"`
+	Indicator string `json:"indicator"`
+	Rating    string `json:"rating"`
+}
+
+// NewQualityProfile converts the quality indicators of a school, which are scraped together
+func NewQualityProfile(schoolNumber string, indicators []models.SchoolQualityIndicator) QualityProfile {
+	profile := QualityProfile{
+		SchoolNumber: schoolNumber,
+		Indicators:   make([]QualityIndicator, len(indicators)),
+	}
+	for i, q := range indicators {
+		profile.Indicators[i] = QualityIndicator{Area: q.Area, Code: q.Code, Indicator: q.Indicator, Rating: q.Rating}
+		if q.ScrapedAt.After(profile.ScrapedAt) {
+			profile.ScrapedAt = q.ScrapedAt
+		}
+	}
+	return profile
+}
+
+// AbsenceStat compares the school's absence rates with its school type, region and Berlin; rates
+// the source did not publish are null
+type AbsenceStat struct {
+	ID                      int64     `json:"id"`
+	SchoolNumber            string    `json:"school_number"`
+	SchoolAbsenceRate       *float64  `json:"school_absence_rate"`
+	SchoolUnexcusedRate     *float64  `json:"school_unexcused_rate"`
+	SchoolTypeAbsenceRate   *float64  `json:"school_type_absence_rate"`
+	SchoolTypeUnexcusedRate *float64  `json:"school_type_unexcused_rate"`
+	RegionAbsenceRate       *float64  `json:"region_absence_rate"`
+	RegionUnexcusedRate     *float64  `json:"region_unexcused_rate"`
+	BerlinAbsenceRate       *float64  `json:"berlin_absence_rate"`
+	BerlinUnexcusedRate     *float64  `json:"berlin_unexcused_rate"`
+	ScrapedAt               time.Time `json:"scraped_at"`
+	CreatedAt               time.Time `json:"created_at"`
+}
+
+func newAbsenceStat(s models.SchoolAbsenceStat) AbsenceStat {
+	return AbsenceStat{
+		ID:                      s.ID,
+		SchoolNumber:            s.SchoolNumber,
+		SchoolAbsenceRate:       s.SchoolAbsenceRate,
+		SchoolUnexcusedRate:     s.SchoolUnexcusedRate,
+		SchoolTypeAbsenceRate:   s.SchoolTypeAbsenceRate,
+		SchoolTypeUnexcusedRate: s.SchoolTypeUnexcusedRate,
+		RegionAbsenceRate:       s.RegionAbsenceRate,
+		RegionUnexcusedRate:     s.RegionUnexcusedRate,
+		BerlinAbsenceRate:       s.BerlinAbsenceRate,
+		BerlinUnexcusedRate:     s.BerlinUnexcusedRate,
+		ScrapedAt:               s.ScrapedAt,
+		CreatedAt:               s.CreatedAt,
+	}
+}
+
+// Statistic contains the student, teacher and class counts of one school year
+type Statistic struct {
+	ID              int64     `json:"id"`
+	SchoolNumber    string    `json:"school_number"`
+	SchoolName      string    `json:"school_name"`
+	District        string    `json:"district"`
+	SchoolType      string    `json:"school_type"`
+	SchoolYear      string    `json:"school_year"`
+	Students        string    `json:"students"`
+	StudentsMale    string    `json:"students_male"`
+	StudentsFemale  string    `json:"students_female"`
+	StudentsDiverse string    `json:"students_diverse"`
+	Teachers        string    `json:"teachers"`
+	TeachersMale    string    `json:"teachers_male"`
+	TeachersFemale  string    `json:"teachers_female"`
+	TeachersDiverse string    `json:"teachers_diverse"`
+	Classes         string    `json:"classes"`
+	Metadata        string    `json:"metadata"`
+	ScrapedAt       time.Time `json:"scraped_at"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+func newStatistic(s models.SchoolStatistic) Statistic {
+	return Statistic{
+		ID:              s.ID,
+		SchoolNumber:    s.SchoolNumber,
+		SchoolName:      s.SchoolName,
+		District:        s.District,
+		SchoolType:      s.SchoolType,
+		SchoolYear:      s.SchoolYear,
+		Students:        s.Students,
+		StudentsMale:    s.StudentsMale,
+		StudentsFemale:  s.StudentsFemale,
+		StudentsDiverse: s.StudentsDiverse,
+		Teachers:        s.Teachers,
+		TeachersMale:    s.TeachersMale,
+		TeachersFemale:  s.TeachersFemale,
+		TeachersDiverse: s.TeachersDiverse,
+		Classes:         s.Classes,
+		Metadata:        s.Metadata,
+		ScrapedAt:       s.ScrapedAt,
+		CreatedAt:       s.CreatedAt,
+	}
+}
+
+// Application contains the first-choice applications against the places of one school year
+type Application struct {
+	SchoolYear              string   `json:"school_year"`
+	Places                  int      `json:"places"`
+	FirstChoiceApplications int      `json:"first_choice_applications"`
+	OversubscriptionRatio   *float64 `json:"oversubscription_ratio,omitempty"` // First choices per place
+}
+
+func newApplication(a models.SchoolApplication) Application {
+	application := Application{
+		SchoolYear:              a.SchoolYear,
+		Places:                  a.Places,
+		FirstChoiceApplications: a.FirstChoiceApplications,
+	}
+	if ratio, ok := a.OversubscriptionRatio(); ok {
+		application.OversubscriptionRatio = &ratio
+	}
+	return application
+}
@@ -0,0 +1,197 @@
+package dto
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"schools-be/internal/models"
+)
+
+func TestNewSchoolDetail(t *testing.T) {
+	scraped := time.Date(2025, 9, 1, 4, 0, 0, 0, time.UTC)
+	table := `{"headers":["Staatsangehörigkeit","Gesamt"],"rows":[["Deutschland","500"]]}`
+
+	tests := []struct {
+		name               string
+		in                 models.SchoolDetail
+		supportFocuses     []string
+		bilingualLanguages []string
+		citizenship        bool
+	}{
+		{
+			name:               "empty lists and tables",
+			in:                 models.SchoolDetail{SchoolNumber: "01Y02", CitizenshipData: "{}", ParserVersion: 4, ScrapedAt: scraped},
+			supportFocuses:     []string{},
+			bilingualLanguages: []string{},
+		},
+		{
+			name:               "lists and tables",
+			in:                 models.SchoolDetail{SchoolNumber: "01Y02", SupportFocuses: "learning,hearing", BilingualLanguages: "fr", FeeMinEUR: ptr(100), CitizenshipData: table, ParserVersion: 4, ScrapedAt: scraped},
+			supportFocuses:     []string{"learning", "hearing"},
+			bilingualLanguages: []string{"fr"},
+			citizenship:        true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if newSchoolDetail(nil) != nil {
+				t.Error("newSchoolDetail(nil) != nil")
+			}
+
+			got := *newSchoolDetail(&tt.in)
+			if got.SchoolNumber != "01Y02" || !got.ScrapedAt.Equal(scraped) || !equalPtr(got.FeeMinEUR, tt.in.FeeMinEUR) {
+				t.Errorf("newSchoolDetail() = %+v, fields not copied from %+v", got, tt.in)
+			}
+			if !slices.Equal(got.SupportFocuses, tt.supportFocuses) || !slices.Equal(got.BilingualLanguages, tt.bilingualLanguages) {
+				t.Errorf("lists = %q, %q, want %q, %q", got.SupportFocuses, got.BilingualLanguages, tt.supportFocuses, tt.bilingualLanguages)
+			}
+			internal := []string{"citizenship_data", "language_data", "residence_data", "absence_data", "parser_version"}
+			fields := jsonFields(t, got)
+			checkFields(t, fields, []string{"support_focuses", "bilingual_languages", "religious_education", "fee_min_eur"}, append(internal, "tables"))
+			if string(fields["support_focuses"]) == "null" {
+				t.Error("support_focuses = null, want a list")
+			}
+
+			withTables := NewSchoolDetailWithTables(tt.in)
+			checkFields(t, jsonFields(t, withTables), []string{"tables"}, internal)
+			tables := jsonFields(t, withTables.Tables)
+			checkFields(t, tables, []string{"citizenship", "language", "residence", "absence"}, nil)
+			if string(tables["language"]) != "null" {
+				t.Errorf("language table = %s, want null", tables["language"])
+			}
+			if (withTables.Tables.Citizenship != nil) != tt.citizenship {
+				t.Errorf("citizenship table = %+v, want present %v", withTables.Tables.Citizenship, tt.citizenship)
+			}
+		})
+	}
+}
+
+func TestNewRawStatisticTables(t *testing.T) {
+	tests := []struct {
+		data string
+		want string
+	}{
+		{`{"headers":["Bezirk"],"rows":[["Mitte"]]}`, `{"headers":["Bezirk"],"rows":[["Mitte"]]}`},
+		{"{}", "null"},
+		{"", "null"},
+		{"not json", "null"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.data, func(t *testing.T) {
+			got := NewRawStatisticTables(models.SchoolDetail{SchoolNumber: "01Y02", ResidenceData: tt.data, ParserVersion: 4})
+			if got.SchoolNumber != "01Y02" || got.ParserVersion != 4 {
+				t.Errorf("NewRawStatisticTables() = %+v", got)
+			}
+			if string(got.Residence) != tt.want || string(got.Citizenship) != "null" {
+				t.Errorf("residence, citizenship = %s, %s, want %s, null", got.Residence, got.Citizenship, tt.want)
+			}
+			checkFields(t, jsonFields(t, got), []string{"parser_version", "citizenship", "language", "residence", "absence"}, []string{"residence_data"})
+		})
+	}
+}
+
+func TestNewApplication(t *testing.T) {
+	tests := []struct {
+		name  string
+		in    models.SchoolApplication
+		ratio *float64
+	}{
+		{"oversubscribed", models.SchoolApplication{SchoolYear: "2024/25", Places: 50, FirstChoiceApplications: 75}, ptr(1.5)},
+		{"undersubscribed", models.SchoolApplication{SchoolYear: "2024/25", Places: 75, FirstChoiceApplications: 50}, ptr(0.67)},
+		{"without places", models.SchoolApplication{SchoolYear: "2024/25", FirstChoiceApplications: 50}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := newApplication(tt.in)
+			if got.SchoolYear != tt.in.SchoolYear || got.Places != tt.in.Places || got.FirstChoiceApplications != tt.in.FirstChoiceApplications {
+				t.Errorf("newApplication() = %+v, fields not copied from %+v", got, tt.in)
+			}
+			if !equalPtr(got.OversubscriptionRatio, tt.ratio) {
+				t.Errorf("ratio = %v, want %v", got.OversubscriptionRatio, tt.ratio)
+			}
+
+			fields := jsonFields(t, got)
+			checkFields(t, fields, nil, []string{"id", "school_number", "fetched_at"})
+			if _, ok := fields["oversubscription_ratio"]; ok != (tt.ratio != nil) {
+				t.Errorf("oversubscription_ratio in JSON = %v, want %v", ok, tt.ratio != nil)
+			}
+		})
+	}
+}
+
+func TestStatisticMappings(t *testing.T) {
+	scraped := time.Date(2025, 9, 1, 4, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		got     any
+		present []string
+		absent  []string
+	}{
+		{"citizenship", newCitizenshipStat(models.SchoolCitizenshipStat{Citizenship: "Afrika", FemaleStudents: 3, Total: 5}), []string{"citizenship", "female_students", "diverse_students", "total"}, nil},
+		{"language", newLanguageStat(models.SchoolLanguageStat{TotalStudents: 500, NDHTotal: 120, NDHPercentage: 24}), []string{"total_students", "ndh_total", "ndh_percentage"}, nil},
+		{"residence", newResidenceStat(models.SchoolResidenceStat{District: "Mitte", StudentCount: 300}), []string{"district", "student_count"}, nil},
+		{"absence", newAbsenceStat(models.SchoolAbsenceStat{SchoolAbsenceRate: ptr(5.5)}), []string{"school_absence_rate", "berlin_unexcused_rate"}, nil},
+		{"statistic", newStatistic(models.SchoolStatistic{SchoolYear: "2024/25", Students: "500"}), []string{"school_year", "students", "students_diverse", "metadata"}, nil},
+		{"grade classes", NewGradeStructure([]models.SchoolGradeClasses{{ID: 1, SchoolNumber: "01Y02", SchoolYear: "2024/25", Grade: 7, Classes: 4, ScrapedAt: scraped}})[0], []string{"school_year", "grade", "classes", "scraped_at"}, []string{"id", "school_number", "created_at"}},
+		{"programs", NewSchoolProgramsList([]models.SchoolDetail{{SchoolNumber: "01Y02", Europaschule: true, BilingualLanguages: "fr"}})[0], []string{"europaschule", "bilingual_languages", "religious_education"}, []string{"id", "parser_version"}},
+		{"staffing", NewStaffing(models.SchoolStaffingStat{ID: 1, SchoolNumber: "01Y02", Qualifications: []models.SchoolTeacherQualification{{ID: 2, Qualification: "Quereinsteigende", Teachers: 4}}}), []string{"staffing_coverage", "qualifications", "scraped_at"}, []string{"id", "created_at"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkFields(t, jsonFields(t, tt.got), tt.present, tt.absent)
+		})
+	}
+
+	absence := newAbsenceStat(models.SchoolAbsenceStat{SchoolAbsenceRate: ptr(5.5)})
+	fields := jsonFields(t, absence)
+	if string(fields["school_absence_rate"]) != "5.5" || string(fields["region_absence_rate"]) != "null" {
+		t.Errorf("absence rates = %s, %s, want 5.5, null", fields["school_absence_rate"], fields["region_absence_rate"])
+	}
+
+	staffing := NewStaffing(models.SchoolStaffingStat{SchoolNumber: "01Y02"})
+	if staffing.StaffingCoverage != nil || staffing.Qualifications == nil || len(staffing.Qualifications) != 0 {
+		t.Errorf("staffing without data = %+v, want null coverage and an empty list", staffing)
+	}
+	qualification := jsonFields(t, NewStaffing(models.SchoolStaffingStat{Qualifications: []models.SchoolTeacherQualification{{ID: 2, Teachers: 4}}}).Qualifications[0])
+	checkFields(t, qualification, []string{"qualification", "teachers"}, []string{"id", "school_number", "scraped_at"})
+}
+
+func TestNewQualityProfile(t *testing.T) {
+	older := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	newer := time.Date(2025, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		indicators []models.SchoolQualityIndicator
+		scrapedAt  time.Time
+	}{
+		{"no indicators", nil, time.Time{}},
+		{"latest scrape", []models.SchoolQualityIndicator{
+			{Code: "2.1", Indicator: "Unterrichtsgestaltung", Rating: "B", ScrapedAt: newer},
+			{Code: "1.1", Indicator: "Schulprogramm", Rating: "A", ScrapedAt: older},
+		}, newer},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NewQualityProfile("01Y02", tt.indicators)
+			if got.SchoolNumber != "01Y02" || !got.ScrapedAt.Equal(tt.scrapedAt) || len(got.Indicators) != len(tt.indicators) {
+				t.Errorf("NewQualityProfile() = %+v, want %d indicators scraped at %v", got, len(tt.indicators), tt.scrapedAt)
+			}
+			if string(jsonFields(t, got)["indicators"]) == "null" {
+				t.Error("indicators = null, want a list")
+			}
+			for i, indicator := range got.Indicators {
+				if indicator.Code != tt.indicators[i].Code || indicator.Rating != tt.indicators[i].Rating {
+					t.Errorf("indicator %d = %+v, want %+v", i, indicator, tt.indicators[i])
+				}
+			}
+		})
+	}
+}
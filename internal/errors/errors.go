@@ -14,8 +14,55 @@ var (
 	ErrConflict      = errors.New("conflict")
 	ErrInternal      = errors.New("internal error")
 	ErrDatabaseError = errors.New("database error")
+	ErrUnavailable   = errors.New("temporarily unavailable")
 )
 
+// ConflictError reports a value that is already taken by another resource
+type ConflictError struct {
+	Resource string
+	Field    string
+	Value    interface{}
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("%s with %s %v already exists", e.Resource, e.Field, e.Value)
+}
+
+func (e *ConflictError) Is(target error) bool {
+	return target == ErrConflict
+}
+
+// VersionConflictError reports an update based on an outdated version of a resource
+type VersionConflictError struct {
+	Resource string
+	ID       interface{}
+	Expected int
+	Current  int
+}
+
+func (e *VersionConflictError) Error() string {
+	return fmt.Sprintf("%s %v was modified: expected version %d, current version is %d", e.Resource, e.ID, e.Expected, e.Current)
+}
+
+func (e *VersionConflictError) Is(target error) bool {
+	return target == ErrConflict
+}
+
+// StateConflictError reports a change to a resource that is no longer in a state allowing it
+type StateConflictError struct {
+	Resource string
+	ID       interface{}
+	State    string
+}
+
+func (e *StateConflictError) Error() string {
+	return fmt.Sprintf("%s %v is already %s", e.Resource, e.ID, e.State)
+}
+
+func (e *StateConflictError) Is(target error) bool {
+	return target == ErrConflict
+}
+
 // NotFoundError wraps a not found error with additional context
 type NotFoundError struct {
 	Resource string
@@ -75,6 +122,21 @@ func NewValidationError(field, message string) error {
 	return &ValidationError{Field: field, Message: message}
 }
 
+// NewConflictError creates a new ConflictError
+func NewConflictError(resource, field string, value interface{}) error {
+	return &ConflictError{Resource: resource, Field: field, Value: value}
+}
+
+// NewVersionConflictError creates a new VersionConflictError
+func NewVersionConflictError(resource string, id interface{}, expected, current int) error {
+	return &VersionConflictError{Resource: resource, ID: id, Expected: expected, Current: current}
+}
+
+// NewStateConflictError creates a new StateConflictError
+func NewStateConflictError(resource string, id interface{}, state string) error {
+	return &StateConflictError{Resource: resource, ID: id, State: state}
+}
+
 // NewDatabaseError creates a new DatabaseError
 func NewDatabaseError(operation string, err error) error {
 	return &DatabaseError{Operation: operation, Err: err}
@@ -0,0 +1,98 @@
+package fetcher
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"schools-be/internal/models"
+)
+
+// Header names used for each column across the published years of the Anmeldezahlen CSV
+var applicationColumns = map[string][]string{
+	"school_number": {"bsn", "schulnummer", "schulnr"},
+	"school_year":   {"schuljahr"},
+	"places":        {"plätze", "plaetze", "aufnahmekapazität", "aufnahmekapazitaet", "kapazität", "kapazitaet"},
+	"first_choice":  {"erstwünsche", "erstwuensche", "erstwunsch", "anmeldungenerstwunsch", "anmeldungen"},
+}
+
+// ApplicationFetcher downloads the yearly first-choice application numbers of Berlin's
+// secondary schools (Anmeldezahlen) from a CSV file
+type ApplicationFetcher struct {
+	httpClient *http.Client
+	url        string
+	logger     *slog.Logger
+}
+
+func NewApplicationFetcher(url string, logger *slog.Logger) *ApplicationFetcher {
+	return &ApplicationFetcher{
+		httpClient: &http.Client{Timeout: 60 * time.Second},
+		url:        url,
+		logger:     logger.With(slog.String("component", "application_fetcher")),
+	}
+}
+
+// Enabled reports whether a dataset URL is configured
+func (f *ApplicationFetcher) Enabled() bool {
+	return f.url != ""
+}
+
+// FetchApplications downloads and parses the Anmeldezahlen CSV
+func (f *ApplicationFetcher) FetchApplications(ctx context.Context) ([]models.SchoolApplicationInput, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", f.url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	f.logger.InfoContext(ctx, "fetching application numbers")
+	resp, err := f.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch application numbers: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch application numbers: %d %s", resp.StatusCode, resp.Status)
+	}
+
+	applications, err := ParseApplicationsCSV(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	f.logger.InfoContext(ctx, "fetched application rows", slog.Int("rows", len(applications)))
+	return applications, nil
+}
+
+// ParseApplicationsCSV parses the Anmeldezahlen CSV. Rows without a school number,
+// school year or parseable counts are skipped.
+func ParseApplicationsCSV(r io.Reader) ([]models.SchoolApplicationInput, error) {
+	rows, err := readCSVTable(r, applicationColumns)
+	if err != nil {
+		return nil, fmt.Errorf("application numbers: %w", err)
+	}
+
+	var applications []models.SchoolApplicationInput
+	for _, row := range rows {
+		schoolNumber := strings.ToUpper(row["school_number"])
+		schoolYear := row["school_year"]
+		places, placesOK := parseWholeNumber(row["places"])
+		firstChoice, firstChoiceOK := parseWholeNumber(row["first_choice"])
+		if schoolNumber == "" || schoolYear == "" || !placesOK || !firstChoiceOK {
+			continue
+		}
+
+		applications = append(applications, models.SchoolApplicationInput{
+			SchoolNumber:            schoolNumber,
+			SchoolYear:              schoolYear,
+			Places:                  places,
+			FirstChoiceApplications: firstChoice,
+		})
+	}
+
+	return applications, nil
+}
@@ -0,0 +1,121 @@
+package fetcher
+
+import (
+	"net/url"
+	"path"
+	"strings"
+
+	"schools-be/internal/models"
+
+	"golang.org/x/net/html"
+)
+
+// constructionPagesURL is the base of relative links in project descriptions
+const constructionPagesURL = "https://www.berlin.de/sen/bildung/schule/bauen-und-sanieren/schulbaukarte/"
+
+// Link targets by file extension; links to other pages are not assets
+var assetKinds = map[string]string{
+	".pdf":  models.AssetKindDocument,
+	".doc":  models.AssetKindDocument,
+	".docx": models.AssetKindDocument,
+	".xls":  models.AssetKindDocument,
+	".xlsx": models.AssetKindDocument,
+	".jpg":  models.AssetKindImage,
+	".jpeg": models.AssetKindImage,
+	".png":  models.AssetKindImage,
+	".gif":  models.AssetKindImage,
+	".webp": models.AssetKindImage,
+}
+
+// projectAssets collects the documents and images a project description links or embeds,
+// such as PDF plans and photos of the site. Relative links are resolved against the
+// construction pages; each URL is returned once.
+func projectAssets(projectID int, description string) []models.ConstructionProjectAsset {
+	if !strings.Contains(description, "<") {
+		return nil
+	}
+	doc, err := html.Parse(strings.NewReader(description))
+	if err != nil {
+		return nil
+	}
+
+	var assets []models.ConstructionProjectAsset
+	seen := map[string]bool{}
+	add := func(link, title string, image bool) {
+		u, ok := assetURL(link)
+		if !ok || seen[u.String()] {
+			return
+		}
+		kind, ok := assetKinds[strings.ToLower(path.Ext(u.Path))]
+		if image {
+			kind, ok = models.AssetKindImage, true
+		}
+		if !ok {
+			return
+		}
+		seen[u.String()] = true
+		assets = append(assets, models.ConstructionProjectAsset{
+			ProjectID: projectID,
+			Kind:      kind,
+			URL:       u.String(),
+			Title:     strings.Join(strings.Fields(title), " "),
+		})
+	}
+
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			switch n.Data {
+			case "a":
+				add(attribute(n, "href"), textContent(n), false)
+			case "img":
+				add(attribute(n, "src"), attribute(n, "alt"), true)
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
+	return assets
+}
+
+// assetURL resolves a link against the construction pages; only web links are assets
+func assetURL(link string) (*url.URL, bool) {
+	base, _ := url.Parse(constructionPagesURL)
+	ref, err := url.Parse(strings.TrimSpace(link))
+	if err != nil || link == "" {
+		return nil, false
+	}
+	u := base.ResolveReference(ref)
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, false
+	}
+	u.Fragment = ""
+	return u, true
+}
+
+func attribute(n *html.Node, name string) string {
+	for _, attr := range n.Attr {
+		if attr.Key == name {
+			return attr.Val
+		}
+	}
+	return ""
+}
+
+func textContent(n *html.Node) string {
+	var sb strings.Builder
+	var collect func(*html.Node)
+	collect = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			sb.WriteString(n.Data)
+			sb.WriteString(" ")
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			collect(c)
+		}
+	}
+	collect(n)
+	return sb.String()
+}
@@ -0,0 +1,100 @@
+package fetcher
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// readCSVTable reads a published CSV whose columns are identified by header name rather than
+// position, since column order and spelling change between years. columns maps a field to
+// the accepted header names (compared after normalizeHeader). The delimiter (";" or ",")
+// is detected from the header line. Each row is returned keyed by field.
+func readCSVTable(r io.Reader, columns map[string][]string) ([]map[string]string, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read csv: %w", err)
+	}
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	if firstLine, _, _ := bytes.Cut(data, []byte("\n")); bytes.Count(firstLine, []byte(";")) > bytes.Count(firstLine, []byte(",")) {
+		reader.Comma = ';'
+	}
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read csv header: %w", err)
+	}
+	indexes := make(map[string]int)
+	for i, name := range header {
+		normalized := normalizeHeader(name)
+		for field, aliases := range columns {
+			if _, found := indexes[field]; found {
+				continue
+			}
+			for _, alias := range aliases {
+				if normalized == alias {
+					indexes[field] = i
+					break
+				}
+			}
+		}
+	}
+	var missing []string
+	for field := range columns {
+		if _, ok := indexes[field]; !ok {
+			missing = append(missing, field)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("missing columns: %s", strings.Join(missing, ", "))
+	}
+
+	var rows []map[string]string
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse csv: %w", err)
+		}
+
+		row := make(map[string]string, len(indexes))
+		for field, idx := range indexes {
+			if idx < len(record) {
+				row[field] = strings.TrimSpace(record[idx])
+			}
+		}
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+// normalizeHeader lowercases a header and drops spaces, punctuation and footnote marks
+func normalizeHeader(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, s)
+}
+
+// parseWholeNumber parses counts such as "120" or "1.024" with "." as thousands separator
+func parseWholeNumber(s string) (int, bool) {
+	s = strings.ReplaceAll(strings.ReplaceAll(s, ".", ""), " ", "")
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 0 {
+		return 0, false
+	}
+	return n, true
+}
@@ -0,0 +1,141 @@
+package fetcher
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"schools-be/internal/models"
+)
+
+// Header names used for each column across the published years of the Abitur results CSV
+var examResultColumns = map[string][]string{
+	"school_number": {"bsn", "schulnummer", "schulnr"},
+	"school_year":   {"schuljahr"},
+	"participants":  {"teilnehmer", "teilnehmende", "prüflinge", "prueflinge", "anzahlprüflinge", "anzahlprueflinge"},
+	"passed":        {"bestanden", "bestandene", "anzahlbestanden"},
+	"average_grade": {"durchschnittsnote", "notendurchschnitt", "durchschnitt", "note"},
+}
+
+// suppressedMarkers are the placeholders the source uses for figures withheld for small cohorts
+var suppressedMarkers = map[string]bool{"*": true, "x": true, "-": true, "–": true, ".": true, "/": true}
+
+// ExamResultFetcher downloads the yearly Abitur results of Berlin schools from a CSV file
+type ExamResultFetcher struct {
+	httpClient *http.Client
+	url        string
+	logger     *slog.Logger
+}
+
+func NewExamResultFetcher(url string, logger *slog.Logger) *ExamResultFetcher {
+	return &ExamResultFetcher{
+		httpClient: &http.Client{Timeout: 60 * time.Second},
+		url:        url,
+		logger:     logger.With(slog.String("component", "exam_result_fetcher")),
+	}
+}
+
+// Enabled reports whether a dataset URL is configured
+func (f *ExamResultFetcher) Enabled() bool {
+	return f.url != ""
+}
+
+// FetchExamResults downloads and parses the Abitur results CSV
+func (f *ExamResultFetcher) FetchExamResults(ctx context.Context) ([]models.ExamResultInput, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", f.url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	f.logger.InfoContext(ctx, "fetching exam results")
+	resp, err := f.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch exam results: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch exam results: %d %s", resp.StatusCode, resp.Status)
+	}
+
+	results, err := ParseExamResultsCSV(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	f.logger.InfoContext(ctx, "fetched exam result rows", slog.Int("rows", len(results)))
+	return results, nil
+}
+
+// ParseExamResultsCSV parses the Abitur results CSV. A row whose figures are all suppression
+// markers ("*", "x", "–", ...) or "<n" counts is kept as suppressed; rows without a school
+// number or school year, or with unreadable figures, are skipped.
+func ParseExamResultsCSV(r io.Reader) ([]models.ExamResultInput, error) {
+	rows, err := readCSVTable(r, examResultColumns)
+	if err != nil {
+		return nil, fmt.Errorf("exam results: %w", err)
+	}
+
+	var results []models.ExamResultInput
+	for _, row := range rows {
+		result := models.ExamResultInput{
+			SchoolNumber: strings.ToUpper(row["school_number"]),
+			SchoolYear:   row["school_year"],
+		}
+		if result.SchoolNumber == "" || result.SchoolYear == "" {
+			continue
+		}
+
+		valid := true
+		suppressed := 0
+		for _, field := range []string{"participants", "passed", "average_grade"} {
+			value := row[field]
+			if isSuppressed(value) {
+				suppressed++
+				continue
+			}
+			switch field {
+			case "participants", "passed":
+				n, ok := parseWholeNumber(value)
+				if !ok {
+					valid = false
+					break
+				}
+				if field == "participants" {
+					result.Participants = &n
+				} else {
+					result.Passed = &n
+				}
+			case "average_grade":
+				grade, err := strconv.ParseFloat(strings.Replace(value, ",", ".", 1), 64)
+				if err != nil || grade < 1 || grade > 6 {
+					valid = false
+					break
+				}
+				result.AverageGrade = &grade
+			}
+		}
+		if !valid {
+			continue
+		}
+		if suppressed > 0 {
+			// Partial figures of a suppressed cohort could identify students, so none are kept
+			result = models.ExamResultInput{SchoolNumber: result.SchoolNumber, SchoolYear: result.SchoolYear, Suppressed: true}
+		}
+
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// isSuppressed reports whether a cell holds a suppression marker instead of a figure
+func isSuppressed(value string) bool {
+	value = strings.ToLower(strings.TrimSpace(value))
+	return value == "" || suppressedMarkers[value] || strings.HasPrefix(value, "<")
+}
@@ -0,0 +1,86 @@
+package fetcher
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"io"
+	"os"
+
+	"schools-be/internal/models"
+)
+
+// schoolAliasesCSV is the maintained mapping of school numbers replaced after mergers and
+// reorganizations (old_number,new_number,reason). Add a line when the school register
+// renumbers a school that the address and name heuristics cannot match.
+//
+//go:embed school_aliases.csv
+var schoolAliasesCSV []byte
+
+var schoolAliasColumns = map[string][]string{
+	"old":    {"oldnumber", "alt", "altebsn"},
+	"new":    {"newnumber", "neu", "neuebsn"},
+	"reason": {"reason", "grund"},
+}
+
+// LoadSchoolAliases returns the maintained school number mapping, with the entries of the
+// CSV file at path (SCHOOL_ALIASES) replacing built-in ones for the same old number.
+// An empty path returns the built-in mapping.
+func LoadSchoolAliases(path string) ([]models.SchoolAlias, error) {
+	aliases, err := readSchoolAliases(bytes.NewReader(schoolAliasesCSV))
+	if err != nil {
+		return nil, fmt.Errorf("invalid built-in school aliases: %w", err)
+	}
+	if path == "" {
+		return aliases, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open school aliases: %w", err)
+	}
+	defer f.Close()
+
+	overrides, err := readSchoolAliases(f)
+	if err != nil {
+		return nil, fmt.Errorf("invalid school aliases %s: %w", path, err)
+	}
+
+	byOld := make(map[string]int, len(aliases))
+	for i, alias := range aliases {
+		byOld[alias.OldNumber] = i
+	}
+	for _, alias := range overrides {
+		if i, ok := byOld[alias.OldNumber]; ok {
+			aliases[i] = alias
+			continue
+		}
+		byOld[alias.OldNumber] = len(aliases)
+		aliases = append(aliases, alias)
+	}
+	return aliases, nil
+}
+
+func readSchoolAliases(r io.Reader) ([]models.SchoolAlias, error) {
+	rows, err := readCSVTable(r, schoolAliasColumns)
+	if err != nil {
+		return nil, err
+	}
+
+	aliases := make([]models.SchoolAlias, 0, len(rows))
+	for i, row := range rows {
+		if row["old"] == "" && row["new"] == "" {
+			continue
+		}
+		if row["old"] == "" || row["new"] == "" || row["old"] == row["new"] {
+			return nil, fmt.Errorf("line %d: needs two different school numbers", i+2)
+		}
+		aliases = append(aliases, models.SchoolAlias{
+			OldNumber: row["old"],
+			NewNumber: row["new"],
+			Source:    models.AliasSourceMapping,
+			Reason:    row["reason"],
+		})
+	}
+	return aliases, nil
+}
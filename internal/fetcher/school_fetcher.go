@@ -4,7 +4,7 @@ import (
 	"crypto/tls"
 	"encoding/json"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"net/url"
 	"os"
@@ -23,9 +23,10 @@ const (
 type SchoolFetcher struct {
 	httpClient *http.Client
 	typenames  string
+	logger     *slog.Logger
 }
 
-func NewSchoolFetcher() *SchoolFetcher {
+func NewSchoolFetcher(logger *slog.Logger) *SchoolFetcher {
 	typenames := os.Getenv("WFS_TYPENAMES")
 	if typenames == "" {
 		typenames = defaultTypenames
@@ -43,6 +44,7 @@ func NewSchoolFetcher() *SchoolFetcher {
 			Transport: transport,
 		},
 		typenames: typenames,
+		logger:    logger.With(slog.String("component", "school_fetcher")),
 	}
 }
 
@@ -77,7 +79,7 @@ type SchoolGeometry struct {
 type SchoolFeature struct {
 	Type         string           `json:"type"`          // Should be "Feature"
 	ID           string           `json:"id"`            // Feature ID
-	Geometry     SchoolGeometry   `json:"geometry"`      // Geometry data
+	Geometry     *SchoolGeometry  `json:"geometry"`      // Geometry data, nil when the school has no location
 	GeometryName string           `json:"geometry_name"` // Geometry field name
 	Properties   SchoolProperties `json:"properties"`    // School properties
 	BBox         [4]float64       `json:"bbox"`          // Bounding box
@@ -122,7 +124,7 @@ func (f *SchoolFetcher) FetchBerlinSchools() (*SchoolsGeoJSON, error) {
 	req.Header.Set("Accept", "application/json")
 
 	// Execute request
-	log.Println("Fetching schools from Berlin WFS service...")
+	f.logger.Info("fetching schools from Berlin WFS service")
 	resp, err := f.httpClient.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch schools: %w", err)
@@ -145,10 +147,23 @@ func (f *SchoolFetcher) FetchBerlinSchools() (*SchoolsGeoJSON, error) {
 		return nil, fmt.Errorf("invalid response format: missing features array")
 	}
 
-	log.Printf("Successfully fetched %d schools from WFS service", len(geoJSON.Features))
+	f.logger.Info("fetched schools from WFS service", slog.Int("schools", len(geoJSON.Features)))
 	return &geoJSON, nil
 }
 
+// Coordinates returns the feature's latitude and longitude, or nil when it has no location.
+// A 0,0 point is treated as missing: no Berlin school sits in the Gulf of Guinea.
+func (f SchoolFeature) Coordinates() (lat, lon *float64) {
+	if f.Geometry == nil {
+		return nil, nil
+	}
+	if f.Geometry.Coordinates[0] == 0 && f.Geometry.Coordinates[1] == 0 {
+		return nil, nil
+	}
+	latitude, longitude := f.Geometry.Coordinates[1], f.Geometry.Coordinates[0]
+	return &latitude, &longitude
+}
+
 // FetchSchools fetches schools from external sources and converts them to CreateSchoolInput
 func (f *SchoolFetcher) FetchSchools() ([]models.CreateSchoolInput, error) {
 	geoJSON, err := f.FetchBerlinSchools()
@@ -159,6 +174,7 @@ func (f *SchoolFetcher) FetchSchools() ([]models.CreateSchoolInput, error) {
 	schools := make([]models.CreateSchoolInput, 0, len(geoJSON.Features))
 	for _, feature := range geoJSON.Features {
 		props := feature.Properties
+		lat, lon := feature.Coordinates()
 
 		school := models.CreateSchoolInput{
 			SchoolNumber:   props.BSN,
@@ -176,13 +192,13 @@ func (f *SchoolFetcher) FetchSchools() ([]models.CreateSchoolInput, error) {
 			Email:          props.Email,
 			Website:        props.Internet,
 			SchoolYear:     props.Schuljahr,
-			Longitude:      feature.Geometry.Coordinates[0],
-			Latitude:       feature.Geometry.Coordinates[1],
+			Latitude:       lat,
+			Longitude:      lon,
 		}
 		schools = append(schools, school)
 	}
 
-	log.Printf("Converted %d schools to CreateSchoolInput", len(schools))
+	f.logger.Info("converted schools to CreateSchoolInput", slog.Int("schools", len(schools)))
 	return schools, nil
 }
 
@@ -211,6 +227,8 @@ type ConstructionProject struct {
 	Street                       string `json:"strasse"`                        // Street
 	PostalCode                   string `json:"plz"`                            // Postal code
 	City                         string `json:"ort"`                            // City
+
+	Assets []models.ConstructionProjectAsset `json:"-"` // Documents and images linked from the description
 }
 
 // ConstructionProjectsResponse represents the full response from the construction API
@@ -241,7 +259,7 @@ func (f *SchoolFetcher) FetchConstructionProjects() (*ConstructionProjectsRespon
 	req.Header.Set("Cache-Control", "no-cache")
 
 	// Execute request
-	log.Println("Fetching construction projects from Berlin API...")
+	f.logger.Info("fetching construction projects from Berlin API")
 	resp, err := f.httpClient.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch construction projects: %w", err)
@@ -264,6 +282,10 @@ func (f *SchoolFetcher) FetchConstructionProjects() (*ConstructionProjectsRespon
 		return nil, fmt.Errorf("invalid response format: missing index array")
 	}
 
-	log.Printf("Successfully fetched %d construction projects", len(data.Index))
+	for i := range data.Index {
+		data.Index[i].Assets = projectAssets(data.Index[i].ID, data.Index[i].Description)
+	}
+
+	f.logger.Info("fetched construction projects", slog.Int("projects", len(data.Index)))
 	return &data, nil
 }
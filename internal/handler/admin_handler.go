@@ -0,0 +1,261 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+
+	"schools-be/internal/analytics"
+	"schools-be/internal/config"
+	apperrors "schools-be/internal/errors"
+	"schools-be/internal/scheduler"
+	"schools-be/internal/service"
+	"schools-be/internal/usage"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type AdminHandler struct {
+	service   *service.AdminService
+	scheduler *scheduler.Scheduler
+	analytics *analytics.Collector
+	usage     *usage.Meter
+	live      *config.Live
+	templates *Templates
+	logger    *slog.Logger
+}
+
+func NewAdminHandler(service *service.AdminService, scheduler *scheduler.Scheduler, analytics *analytics.Collector, usage *usage.Meter, live *config.Live, templates *Templates, logger *slog.Logger) *AdminHandler {
+	return &AdminHandler{
+		service:   service,
+		scheduler: scheduler,
+		analytics: analytics,
+		usage:     usage,
+		live:      live,
+		templates: templates,
+		logger:    logger.With(slog.String("handler", "admin")),
+	}
+}
+
+// Dashboard renders the admin page with job status, data counts and failed scrapes
+func (h *AdminHandler) Dashboard(w http.ResponseWriter, r *http.Request) {
+	data, err := h.service.GetDataOverview(r.Context())
+	if err != nil {
+		h.logger.ErrorContext(r.Context(), "failed to load admin data overview", slog.String("error", err.Error()))
+		http.Error(w, "failed to load dashboard", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	err = h.templates.admin.Execute(w, map[string]interface{}{
+		"Jobs":   h.scheduler.Jobs(),
+		"Data":   data,
+		"Notice": r.URL.Query().Get("notice"),
+	})
+	if err != nil {
+		h.logger.ErrorContext(r.Context(), "failed to render admin dashboard", slog.String("error", err.Error()))
+	}
+}
+
+// TriggerJob starts a job from the dashboard and redirects back to it
+func (h *AdminHandler) TriggerJob(w http.ResponseWriter, r *http.Request) {
+	// The dashboard authenticates with browser-cached credentials, so reject cross-site form posts
+	if !sameOrigin(r) {
+		http.Error(w, "cross-origin request rejected", http.StatusForbidden)
+		return
+	}
+
+	name := chi.URLParam(r, "name")
+	notice := "Started " + name + "."
+	queued, err := h.scheduler.Trigger(name)
+	switch {
+	case errors.Is(err, scheduler.ErrJobRunning):
+		notice = name + " is already running."
+	case errors.Is(err, scheduler.ErrJobUnavailable):
+		notice = name + " cannot run on this instance."
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	case queued:
+		notice = "Queued " + name + " for a worker."
+	}
+
+	http.Redirect(w, r, "/admin?notice="+url.QueryEscape(notice), http.StatusSeeOther)
+}
+
+// sameOrigin reports whether a state-changing browser request comes from this host
+func sameOrigin(r *http.Request) bool {
+	if r.Header.Get("Sec-Fetch-Site") == "cross-site" {
+		return false
+	}
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	return err == nil && u.Host == r.Host
+}
+
+// GetAnalytics returns endpoint, filter and school view counts for the last days (default 7, at most 90)
+func (h *AdminHandler) GetAnalytics(w http.ResponseWriter, r *http.Request) {
+	if h.analytics == nil {
+		h.respondError(w, http.StatusServiceUnavailable, "analytics are not enabled")
+		return
+	}
+
+	days := 7
+	if v := r.URL.Query().Get("days"); v != "" {
+		var err error
+		days, err = strconv.Atoi(v)
+		if err != nil || days < 1 || days > 90 {
+			h.respondError(w, http.StatusBadRequest, "days must be between 1 and 90")
+			return
+		}
+	}
+
+	report, err := h.analytics.Report(r.Context(), days)
+	if err != nil {
+		h.logger.ErrorContext(r.Context(), "failed to build analytics report", slog.String("error", err.Error()))
+		h.respondError(w, http.StatusInternalServerError, "failed to retrieve analytics")
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, report)
+}
+
+// GetUsage returns request and byte counts per API key for the last days (default 7, at most 90)
+func (h *AdminHandler) GetUsage(w http.ResponseWriter, r *http.Request) {
+	days := 7
+	if v := r.URL.Query().Get("days"); v != "" {
+		var err error
+		days, err = strconv.Atoi(v)
+		if err != nil || days < 1 || days > 90 {
+			h.respondError(w, http.StatusBadRequest, "days must be between 1 and 90")
+			return
+		}
+	}
+
+	report, err := h.usage.Report(r.Context(), days)
+	if err != nil {
+		h.logger.ErrorContext(r.Context(), "failed to build usage report", slog.String("error", err.Error()))
+		h.respondError(w, http.StatusInternalServerError, "failed to retrieve usage")
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, report)
+}
+
+// VerifyConsistency returns the data consistency report; orphaned rows are deleted with fix=true
+func (h *AdminHandler) VerifyConsistency(w http.ResponseWriter, r *http.Request) {
+	fix := r.URL.Query().Get("fix") == "true"
+	if fix && r.Method != http.MethodPost {
+		h.respondError(w, http.StatusMethodNotAllowed, "fix=true requires POST")
+		return
+	}
+	// Admins may be signed in with browser Basic auth, and an empty body passes RequireJSON
+	if r.Method == http.MethodPost && !sameOrigin(r) {
+		h.respondError(w, http.StatusForbidden, "cross-origin request rejected")
+		return
+	}
+
+	report, err := h.service.VerifyConsistency(r.Context(), fix)
+	if err != nil {
+		h.logger.ErrorContext(r.Context(), "failed to verify data consistency", slog.String("error", err.Error()))
+		h.respondError(w, http.StatusInternalServerError, "failed to verify data consistency")
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, report)
+}
+
+// GetCompleteness returns the data completeness of every school as of the last refresh, least
+// complete first
+func (h *AdminHandler) GetCompleteness(w http.ResponseWriter, r *http.Request) {
+	report, err := h.service.CompletenessReport(r.Context())
+	if err != nil {
+		h.logger.ErrorContext(r.Context(), "failed to build completeness report", slog.String("error", err.Error()))
+		h.respondError(w, http.StatusInternalServerError, "failed to retrieve data completeness")
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, report)
+}
+
+// ExportDatabase streams a consistent snapshot of the SQLite database for offline analysis.
+// Chats, home locations and commute data of visitors are left out.
+func (h *AdminHandler) ExportDatabase(w http.ResponseWriter, r *http.Request) {
+	path, err := h.service.ExportDatabase(r.Context())
+	if err != nil {
+		if errors.Is(err, apperrors.ErrConflict) {
+			h.respondError(w, http.StatusConflict, "a database export is already running")
+			return
+		}
+		h.logger.ErrorContext(r.Context(), "failed to export database", slog.String("error", err.Error()))
+		h.respondError(w, http.StatusInternalServerError, "failed to export database")
+		return
+	}
+	defer os.Remove(path)
+
+	file, err := os.Open(path)
+	if err != nil {
+		h.logger.ErrorContext(r.Context(), "failed to open database snapshot", slog.String("error", err.Error()))
+		h.respondError(w, http.StatusInternalServerError, "failed to export database")
+		return
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		h.logger.ErrorContext(r.Context(), "failed to stat database snapshot", slog.String("error", err.Error()))
+		h.respondError(w, http.StatusInternalServerError, "failed to export database")
+		return
+	}
+
+	name := "schools-" + info.ModTime().UTC().Format("20060102-150405") + ".db"
+	w.Header().Set("Content-Type", "application/vnd.sqlite3")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+name+`"`)
+	w.Header().Set("Cache-Control", "no-store")
+	http.ServeContent(w, r, name, info.ModTime(), file)
+}
+
+// ReloadConfig re-reads the .env file and applies the log level, CORS origins, quotas and
+// disabled features without a restart. An invalid configuration is rejected with 400 and
+// the running configuration stays in effect.
+func (h *AdminHandler) ReloadConfig(w http.ResponseWriter, r *http.Request) {
+	if !sameOrigin(r) {
+		h.respondError(w, http.StatusForbidden, "cross-origin request rejected")
+		return
+	}
+
+	result, err := h.live.Reload()
+	if err != nil {
+		var cfgErr *config.Error
+		if errors.As(err, &cfgErr) {
+			h.respondJSON(w, http.StatusBadRequest, map[string]interface{}{"error": "invalid configuration", "problems": cfgErr.Problems})
+			return
+		}
+		h.logger.ErrorContext(r.Context(), "failed to reload config", slog.String("error", err.Error()))
+		h.respondError(w, http.StatusInternalServerError, "failed to reload config")
+		return
+	}
+
+	h.logger.InfoContext(r.Context(), "config reloaded", slog.Any("applied", result.Applied), slog.Any("restart_required", result.RestartRequired))
+	h.respondJSON(w, http.StatusOK, result)
+}
+
+func (h *AdminHandler) respondJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(data); err != nil {
+		h.logger.Error("failed to encode response", slog.String("error", err.Error()))
+	}
+}
+
+func (h *AdminHandler) respondError(w http.ResponseWriter, status int, message string) {
+	h.respondJSON(w, status, map[string]string{"error": message})
+}
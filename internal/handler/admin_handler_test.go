@@ -0,0 +1,46 @@
+package handler
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestAdminHandlerRejectsCrossOrigin checks that state-changing admin requests sent by another
+// site with the browser's cached credentials are rejected before anything runs
+func TestAdminHandlerRejectsCrossOrigin(t *testing.T) {
+	h := NewAdminHandler(nil, nil, nil, nil, nil, nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		target  string
+	}{
+		{"trigger job", h.TriggerJob, "/admin/jobs/refresh"},
+		{"verify with fix", h.VerifyConsistency, "/api/v1/admin/verify?fix=true"},
+		{"reload config", h.ReloadConfig, "/api/v1/admin/config/reload"},
+	}
+	headers := []map[string]string{
+		{"Origin": "https://evil.example"},
+		{"Sec-Fetch-Site": "cross-site"},
+	}
+
+	for _, tt := range tests {
+		for _, header := range headers {
+			t.Run(tt.name, func(t *testing.T) {
+				r := httptest.NewRequest(http.MethodPost, "https://schools.example"+tt.target, nil)
+				for k, v := range header {
+					r.Header.Set(k, v)
+				}
+				w := httptest.NewRecorder()
+				tt.handler(w, r)
+
+				if w.Code != http.StatusForbidden {
+					t.Errorf("%v: status = %d, want 403", header, w.Code)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+)
+
+// requestBaseURL returns the configured public origin of the API, or the one the request was
+// made to (honouring X-Forwarded-Proto and X-Forwarded-Host from a reverse proxy)
+func requestBaseURL(r *http.Request, configured string) string {
+	if configured != "" {
+		return configured
+	}
+
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	if proto := r.Header.Get("X-Forwarded-Proto"); proto == "http" || proto == "https" {
+		scheme = proto
+	}
+	host := r.Host
+	if forwarded := strings.TrimSpace(strings.Split(r.Header.Get("X-Forwarded-Host"), ",")[0]); forwarded != "" {
+		host = forwarded
+	}
+	return scheme + "://" + host
+}
@@ -0,0 +1,142 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+
+	apperrors "schools-be/internal/errors"
+	"schools-be/internal/service"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+)
+
+type ChatHandler struct {
+	service  *service.ChatService
+	validate *validator.Validate
+	logger   *slog.Logger
+}
+
+func NewChatHandler(service *service.ChatService, logger *slog.Logger) *ChatHandler {
+	return &ChatHandler{
+		service:  service,
+		validate: validator.New(),
+		logger:   logger.With(slog.String("handler", "chat")),
+	}
+}
+
+// StartSession creates a chat session, optionally scoped to one school
+func (h *ChatHandler) StartSession(w http.ResponseWriter, r *http.Request) {
+	if h.service == nil {
+		h.respondError(w, http.StatusServiceUnavailable, "AI service is not available")
+		return
+	}
+
+	var req service.StartChatRequest
+	// An empty body starts a general session
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		h.respondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	session, err := h.service.StartSession(r.Context(), req)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrNotFound) {
+			h.respondError(w, http.StatusNotFound, "school not found")
+			return
+		}
+		h.logger.ErrorContext(r.Context(), "failed to start chat session", slog.String("error", err.Error()))
+		h.respondError(w, http.StatusInternalServerError, "failed to start chat session")
+		return
+	}
+
+	h.respondJSON(w, http.StatusCreated, session)
+}
+
+// GetSession returns a session and its retained message history
+func (h *ChatHandler) GetSession(w http.ResponseWriter, r *http.Request) {
+	if h.service == nil {
+		h.respondError(w, http.StatusServiceUnavailable, "AI service is not available")
+		return
+	}
+
+	session, messages, err := h.service.GetSession(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		h.handleSessionError(w, r, "failed to get chat session", err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, map[string]interface{}{
+		"session":  session,
+		"messages": messages,
+	})
+}
+
+// SendMessage sends a user message and returns the advisor's reply
+func (h *ChatHandler) SendMessage(w http.ResponseWriter, r *http.Request) {
+	if h.service == nil {
+		h.respondError(w, http.StatusServiceUnavailable, "AI service is not available")
+		return
+	}
+
+	var req service.ChatMessageRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if err := h.validate.Struct(req); err != nil {
+		h.respondError(w, http.StatusBadRequest, "message is required and must be at most 2000 characters")
+		return
+	}
+
+	reply, err := h.service.SendMessage(r.Context(), chi.URLParam(r, "id"), req.Message)
+	if err != nil {
+		h.handleSessionError(w, r, "failed to send chat message", err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, reply)
+}
+
+// DeleteSession ends a session and deletes its history
+func (h *ChatHandler) DeleteSession(w http.ResponseWriter, r *http.Request) {
+	if h.service == nil {
+		h.respondError(w, http.StatusServiceUnavailable, "AI service is not available")
+		return
+	}
+
+	if err := h.service.DeleteSession(r.Context(), chi.URLParam(r, "id")); err != nil {
+		h.handleSessionError(w, r, "failed to delete chat session", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *ChatHandler) handleSessionError(w http.ResponseWriter, r *http.Request, message string, err error) {
+	if errors.Is(err, apperrors.ErrNotFound) {
+		h.respondError(w, http.StatusNotFound, "chat session not found or expired")
+		return
+	}
+	if externalUnavailable(w, err) {
+		h.respondError(w, http.StatusServiceUnavailable, "AI service is temporarily unavailable")
+		return
+	}
+	h.logger.ErrorContext(r.Context(), message, slog.String("error", err.Error()))
+	h.respondError(w, http.StatusInternalServerError, message)
+}
+
+func (h *ChatHandler) respondJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(data); err != nil {
+		h.logger.Error("failed to encode response", slog.String("error", err.Error()))
+	}
+}
+
+func (h *ChatHandler) respondError(w http.ResponseWriter, status int, message string) {
+	h.respondJSON(w, status, map[string]string{"error": message})
+}
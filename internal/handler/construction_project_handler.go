@@ -1,13 +1,19 @@
 package handler
 
 import (
+	"cmp"
 	"encoding/json"
 	"errors"
 	"log/slog"
 	"net/http"
+	"slices"
 	"strconv"
+	"strings"
+	"time"
 
+	"schools-be/internal/dto"
 	apperrors "schools-be/internal/errors"
+	"schools-be/internal/models"
 	"schools-be/internal/service"
 
 	"github.com/go-chi/chi/v5"
@@ -18,25 +24,116 @@ type ConstructionProjectHandler struct {
 	logger  *slog.Logger
 }
 
-func NewConstructionProjectHandler(service *service.ConstructionProjectService) *ConstructionProjectHandler {
+func NewConstructionProjectHandler(service *service.ConstructionProjectService, logger *slog.Logger) *ConstructionProjectHandler {
 	return &ConstructionProjectHandler{
 		service: service,
-		logger:  slog.Default(),
+		logger:  logger.With(slog.String("handler", "construction_project")),
 	}
 }
 
-// GetAll returns all construction projects
+// GetAll returns all construction projects, optionally filtered by status, district and years
 func (h *ConstructionProjectHandler) GetAll(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
+	filter, err := parseProjectTimelineFilter(r.URL.Query())
+	if err != nil {
+		h.respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
 	projects, err := h.service.GetAll(ctx)
 	if err != nil {
-		h.logger.Error("failed to get construction projects", slog.String("error", err.Error()))
+		h.logger.ErrorContext(r.Context(), "failed to get construction projects", slog.String("error", err.Error()))
 		h.respondError(w, http.StatusInternalServerError, "failed to retrieve construction projects")
 		return
 	}
 
-	h.respondJSON(w, http.StatusOK, projects)
+	h.respondJSON(w, http.StatusOK, dto.NewConstructionProjects(filter.apply(projects, time.Now().Year())))
+}
+
+// GetTimeline returns the projects with a known start or handover year as timeline bars,
+// earliest first, for a Gantt chart. It takes the filters of GetAll.
+func (h *ConstructionProjectHandler) GetTimeline(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	filter, err := parseProjectTimelineFilter(r.URL.Query())
+	if err != nil {
+		h.respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	projects, err := h.service.GetAll(ctx)
+	if err != nil {
+		h.logger.ErrorContext(r.Context(), "failed to get construction projects", slog.String("error", err.Error()))
+		h.respondError(w, http.StatusInternalServerError, "failed to retrieve construction projects")
+		return
+	}
+
+	currentYear := time.Now().Year()
+	entries := make([]dto.ProjectTimelineEntry, 0)
+	for _, project := range projects {
+		timeline := project.Timeline(currentYear)
+		if (timeline.StartYear == nil && timeline.EndYear == nil) || !filter.matches(project, timeline) {
+			continue
+		}
+		entries = append(entries, dto.NewProjectTimelineEntry(project, timeline))
+	}
+	slices.SortStableFunc(entries, func(a, b dto.ProjectTimelineEntry) int {
+		return cmp.Or(
+			cmp.Compare(firstYear(a), firstYear(b)),
+			cmp.Compare(lastYear(a), lastYear(b)),
+			strings.Compare(a.SchoolName, b.SchoolName),
+		)
+	})
+
+	h.respondJSON(w, http.StatusOK, entries)
+}
+
+// GetBudgetRollup returns the total costs of the construction projects per district (or per
+// school type with group_by=school_type), compared with the previous import. Query param year
+// limits it to the projects running in that year.
+func (h *ConstructionProjectHandler) GetBudgetRollup(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	groupBy := query.Get("group_by")
+	switch groupBy {
+	case "":
+		groupBy = models.BudgetGroupDistrict
+	case models.BudgetGroupDistrict, models.BudgetGroupSchoolType:
+	default:
+		h.respondError(w, http.StatusBadRequest, "group_by must be one of: district, school_type")
+		return
+	}
+
+	year, err := parseYearParam(query, "year")
+	if err != nil {
+		h.respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	rollup, err := h.service.BudgetRollup(r.Context(), groupBy, year)
+	if err != nil {
+		h.logger.ErrorContext(r.Context(), "failed to compute construction budget rollup", slog.String("error", err.Error()))
+		h.respondError(w, http.StatusInternalServerError, "failed to compute construction budget rollup")
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, rollup)
+}
+
+// firstYear and lastYear are the ends of a timeline bar; a bar with one year spans only that year
+func firstYear(e dto.ProjectTimelineEntry) int {
+	if e.StartYear != nil {
+		return *e.StartYear
+	}
+	return *e.EndYear
+}
+
+func lastYear(e dto.ProjectTimelineEntry) int {
+	if e.EndYear != nil {
+		return *e.EndYear
+	}
+	return *e.StartYear
 }
 
 // GetByID returns a single construction project by ID
@@ -56,7 +153,7 @@ func (h *ConstructionProjectHandler) GetByID(w http.ResponseWriter, r *http.Requ
 			h.respondError(w, http.StatusNotFound, "construction project not found")
 			return
 		}
-		h.logger.Error("failed to get construction project",
+		h.logger.ErrorContext(r.Context(), "failed to get construction project",
 			slog.Int64("id", id),
 			slog.String("error", err.Error()),
 		)
@@ -64,23 +161,31 @@ func (h *ConstructionProjectHandler) GetByID(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	h.respondJSON(w, http.StatusOK, project)
+	h.respondJSON(w, http.StatusOK, dto.NewConstructionProject(*project))
 }
 
 // GetStandalone returns valid construction projects that are not assigned to any existing school
-// Only includes orphaned projects with meaningful data (excludes meta entries and legends)
+// Only includes orphaned projects with meaningful data (excludes meta entries and legends).
+// It takes the filters of GetAll.
 func (h *ConstructionProjectHandler) GetStandalone(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
+	filter, err := parseProjectTimelineFilter(r.URL.Query())
+	if err != nil {
+		h.respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
 	projects, err := h.service.GetStandalone(ctx)
 	if err != nil {
-		h.logger.Error("failed to get standalone construction projects", slog.String("error", err.Error()))
+		h.logger.ErrorContext(r.Context(), "failed to get standalone construction projects", slog.String("error", err.Error()))
 		h.respondError(w, http.StatusInternalServerError, "failed to retrieve standalone construction projects")
 		return
 	}
 
-	h.logger.Info("retrieved standalone construction projects", slog.Int("count", len(projects)))
-	h.respondJSON(w, http.StatusOK, projects)
+	projects = filter.apply(projects, time.Now().Year())
+	h.logger.InfoContext(r.Context(), "retrieved standalone construction projects", slog.Int("count", len(projects)))
+	h.respondJSON(w, http.StatusOK, dto.NewConstructionProjects(projects))
 }
 
 // respondJSON sends a JSON response
@@ -0,0 +1,585 @@
+package handler_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"schools-be/internal/analytics"
+	"schools-be/internal/auth"
+	"schools-be/internal/cache"
+	"schools-be/internal/config"
+	"schools-be/internal/database"
+	"schools-be/internal/handler"
+	"schools-be/internal/models"
+	"schools-be/internal/queue"
+	"schools-be/internal/repository"
+	"schools-be/internal/scheduler"
+	"schools-be/internal/server"
+	"schools-be/internal/service"
+	"schools-be/internal/usage"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/go-chi/chi/v5"
+)
+
+// API keys of the contract server
+var contractKeys = map[auth.Role]string{
+	auth.RolePublic:  "contract-public-key",
+	auth.RolePartner: "contract-partner-key",
+	auth.RoleAdmin:   "contract-admin-key",
+}
+
+// contractLocation is the token of the seeded home location, whose walking commutes are computed
+const contractLocation = "9f86d081884c7d659a2feaa0c55ad015"
+
+// contractRequest is a request to the contract server, sent with the API key of role (public
+// when empty), and the status it must be answered with. When feature is unavailable on the
+// contract server, such as search without FTS5, the request must be answered with 503 instead.
+type contractRequest struct {
+	request string // Method and target, e.g. "GET /api/v1/schools?sort=name"
+	role    auth.Role
+	body    string
+	feature string
+	status  int
+}
+
+// contractRequests cover every documented operation with successful and error responses.
+// They are sent in order to one server, so requests changing data come after those reading it.
+var contractRequests = []contractRequest{
+	{"GET /health", "", "", "", http.StatusOK},
+	{"GET /openapi.json", "", "", "", http.StatusOK},
+	{"GET /metrics", auth.RoleAdmin, "", "", http.StatusOK},
+	{"GET /metrics", "", "", "", http.StatusForbidden},
+	{"GET /public/v1/districts", "", "", "", http.StatusOK},
+	{"GET /public/v1/freshness", "", "", "", http.StatusOK},
+	{"GET /public/v1/stats", "", "", "", http.StatusOK},
+	{"GET /admin", auth.RoleAdmin, "", "", http.StatusOK},
+	{"GET /admin", auth.RolePartner, "", "", http.StatusForbidden},
+	{"GET /api/v1/meta/examples", "", "", "", http.StatusOK},
+	{"GET /api/v1/schools", "", "", "", http.StatusOK},
+	{"GET /api/v1/schools?sort=name", "", "", "", http.StatusOK},
+	{"GET /api/v1/schools?sort=investment&has_construction=true", "", "", "", http.StatusOK},
+	{"GET /api/v1/schools?sort=distance", "", "", "", http.StatusBadRequest},
+	{"GET /api/v1/schools?has_construction=maybe", "", "", "", http.StatusBadRequest},
+	{"GET /api/v1/schools?operator_kind=public", "", "", "", http.StatusOK},
+	{"GET /api/v1/schools?operator_kind=church", "", "", "", http.StatusBadRequest},
+	{"GET /api/v1/schools?support_focus=autism", "", "", "", http.StatusOK},
+	{"GET /api/v1/schools?support_focus=all", "", "", "", http.StatusBadRequest},
+	{"GET /api/v1/schools?accessible=true", "", "", "", http.StatusOK},
+	{"GET /api/v1/schools?district=mitte&school_type=Gymnasium", "", "", "", http.StatusOK},
+	{"GET /api/v1/schools?accessible=maybe", "", "", "", http.StatusBadRequest},
+	{"GET /api/v1/schools?all_day=bound", "", "", "", http.StatusOK},
+	{"GET /api/v1/schools?all_day=half", "", "", "", http.StatusBadRequest},
+	{"GET /api/v1/schools?location=" + contractLocation + "&mode=walking", "", "", "", http.StatusOK},
+	{"GET /api/v1/schools/search?q=gymnasium", "", "", "search", http.StatusOK},
+	{"GET /api/v1/schools/search", "", "", "search", http.StatusBadRequest},
+	{"GET /api/v1/schools/1", "", "", "", http.StatusOK},
+	{"GET /api/v1/schools/999", "", "", "", http.StatusNotFound},
+	{"GET /api/v1/schools/abc", "", "", "", http.StatusBadRequest},
+	{"GET /api/v1/schools/nearby?lat=52.545&lng=13.37&radius_km=3", "", "", "", http.StatusOK},
+	{"GET /api/v1/schools/nearby?lat=91&lng=13.37", "", "", "", http.StatusBadRequest},
+	{"GET /api/v1/schools/1/summary", "", "", "", http.StatusServiceUnavailable},
+	{"GET /api/v1/schools/1/similar", "", "", "", http.StatusOK},
+	{"GET /api/v1/schools/1/similar?limit=50", "", "", "", http.StatusBadRequest},
+	{"GET /api/v1/schools/3/similar", "", "", "", http.StatusUnprocessableEntity},
+	{"GET /api/v1/schools/999/similar", "", "", "", http.StatusNotFound},
+	{"GET /api/v1/schools/1/profile.pdf", "", "", "", http.StatusServiceUnavailable},
+	{"GET /api/v1/schools/1/map.png", "", "", "", http.StatusOK},
+	{"GET /api/v1/schools/1/map.png?zoom=30", "", "", "", http.StatusBadRequest},
+	{"GET /api/v1/schools/3/map.png", "", "", "", http.StatusNotFound},
+	{"GET /api/v1/schools/1/preview", "", "", "", http.StatusOK},
+	{"GET /api/v1/schools/999/preview", "", "", "", http.StatusNotFound},
+	{"POST /api/v1/schools/1/ask", "", `{"question": "Welche Sprachen werden angeboten?"}`, "", http.StatusServiceUnavailable},
+	{"POST /api/v1/schools/1/routes", "", `{"start": [13.37, 52.545], "end": [13.3582, 52.5438], "modes": ["walking", "bicycle"]}`, "", http.StatusOK},
+	{"POST /api/v1/schools/1/routes", "", `{"start": [13.37, 52.545], "end": [13.3582, 52.5438], "modes": []}`, "", http.StatusBadRequest},
+	{"GET /api/v1/schools/01Y02/statistics", "", "", "", http.StatusOK},
+	{"GET /api/v1/schools/01Y02/staffing", "", "", "", http.StatusOK},
+	{"GET /api/v1/schools/07G14/staffing", "", "", "", http.StatusNotFound},
+	{"GET /api/v1/schools/01Y02/quality", "", "", "", http.StatusOK},
+	{"GET /api/v1/schools/07G14/quality", "", "", "", http.StatusNotFound},
+	{"GET /api/v1/schools/01Y02/exam-results", "", "", "", http.StatusOK},
+	{"GET /api/v1/schools/99X99/exam-results", "", "", "", http.StatusNotFound},
+	{"GET /api/v1/statistics", "", "", "", http.StatusOK},
+	{"GET /api/v1/statistics?school_year=2024/25", "", "", "", http.StatusOK},
+	{"GET /api/v1/statistics/summary", "", "", "", http.StatusOK},
+	{"GET /api/v1/statistics/staffing", "", "", "", http.StatusOK},
+	{"GET /api/v1/school-details", "", "", "", http.StatusOK},
+	{"GET /api/v1/school-details?available_after_4th_grade=true", "", "", "", http.StatusOK},
+	{"GET /api/v1/school-details?available_after_4th_grade=maybe", "", "", "", http.StatusBadRequest},
+	{"GET /api/v1/school-details/programs?language=en", "", "", "", http.StatusOK},
+	{"GET /api/v1/school-details/programs?religion=pastafarian", "", "", "", http.StatusBadRequest},
+	{"GET /api/v1/school-details/01Y02", "", "", "", http.StatusOK},
+	{"GET /api/v1/school-details/07G14", "", "", "", http.StatusNotFound},
+	{"GET /api/v1/school-details/01Y02/raw-tables", "", "", "", http.StatusOK},
+	{"GET /api/v1/school-details/07G14/raw-tables", "", "", "", http.StatusNotFound},
+	{"GET /api/v1/school-details/01Y02/grade-structure", "", "", "", http.StatusOK},
+	{"GET /api/v1/analytics/capacity-forecast", "", "", "", http.StatusOK},
+	{"GET /api/v1/analytics/capacity-forecast?district=Mitte", "", "", "", http.StatusOK},
+	{"GET /api/v1/sync", "", "", "", http.StatusOK},
+	{"GET /api/v1/sync?since=2025-01-01T00:00:00Z", "", "", "", http.StatusOK},
+	{"GET /api/v1/sync?since=yesterday", "", "", "", http.StatusBadRequest},
+	{"GET /api/v1/construction-projects", "", "", "", http.StatusOK},
+	{"GET /api/v1/construction-projects?status=in_construction&from=2025&to=2027", "", "", "", http.StatusOK},
+	{"GET /api/v1/construction-projects?status=built", "", "", "", http.StatusBadRequest},
+	{"GET /api/v1/construction-projects?from=2028&to=2026", "", "", "", http.StatusBadRequest},
+	{"GET /api/v1/construction-projects/standalone", "", "", "", http.StatusOK},
+	{"GET /api/v1/construction-projects/timeline", "", "", "", http.StatusOK},
+	{"GET /api/v1/construction-projects/timeline?district=Mitte&from=2026", "", "", "", http.StatusOK},
+	{"GET /api/v1/construction-projects/timeline?to=soon", "", "", "", http.StatusBadRequest},
+	{"GET /api/v1/construction-projects/budget-rollup", "", "", "", http.StatusOK},
+	{"GET /api/v1/construction-projects/budget-rollup?group_by=school_type&year=2026", "", "", "", http.StatusOK},
+	{"GET /api/v1/construction-projects/budget-rollup?group_by=operator", "", "", "", http.StatusBadRequest},
+	{"GET /api/v1/construction-projects/1", "", "", "", http.StatusOK},
+	{"GET /api/v1/construction-projects/999", "", "", "", http.StatusNotFound},
+	{"GET /api/v1/construction-projects/abc", "", "", "", http.StatusBadRequest},
+	{"GET /api/v1/locations/" + contractLocation, "", "", "", http.StatusOK},
+	{"GET /api/v1/locations/unknown", "", "", "", http.StatusNotFound},
+	{"POST /api/v1/chat/sessions", "", `{"school_id": 1}`, "", http.StatusServiceUnavailable},
+	{"GET /api/v1/chat/sessions/unknown", "", "", "", http.StatusServiceUnavailable},
+	{"POST /api/v1/chat/sessions/unknown/messages", "", `{"message": "Gibt es eine Mensa?"}`, "", http.StatusServiceUnavailable},
+	{"DELETE /api/v1/chat/sessions/unknown", "", "", "", http.StatusServiceUnavailable},
+	{"GET /api/v1/export/full.json.br", "", "", "", http.StatusOK},
+	{"POST /api/v1/export/links", "", `{"path": "/api/v1/export/full.json.br"}`, "", http.StatusOK},
+	{"POST /api/v1/export/links", "", `{"path": "/api/v1/admin/export/database"}`, "", http.StatusForbidden},
+	{"POST /api/v1/export/links", "", `{"path": "/api/v1/schools"}`, "", http.StatusBadRequest},
+	{"POST /api/v1/query", auth.RoleAdmin, `{"sql": "SELECT district, COUNT(*) AS schools FROM schools WHERE school_type = ? GROUP BY district", "params": ["Gymnasium"]}`, "", http.StatusOK},
+	{"POST /api/v1/query", auth.RoleAdmin, `{"sql": "DELETE FROM schools"}`, "", http.StatusBadRequest},
+	{"POST /api/v1/query", "", `{"sql": "SELECT 1"}`, "", http.StatusForbidden},
+	{"GET /api/v1/admin/analytics", auth.RoleAdmin, "", "", http.StatusOK},
+	{"GET /api/v1/admin/analytics?days=365", auth.RoleAdmin, "", "", http.StatusBadRequest},
+	{"GET /api/v1/admin/usage", auth.RoleAdmin, "", "", http.StatusOK},
+	{"GET /api/v1/admin/usage", auth.RolePartner, "", "", http.StatusForbidden},
+	{"GET /api/v1/admin/completeness", auth.RoleAdmin, "", "", http.StatusOK},
+	{"GET /api/v1/admin/verify", auth.RoleAdmin, "", "", http.StatusOK},
+	{"GET /api/v1/admin/verify?fix=true", auth.RoleAdmin, "", "", http.StatusMethodNotAllowed},
+	{"GET /api/v1/admin/export/database", auth.RoleAdmin, "", "", http.StatusOK},
+
+	// Requests changing data
+	{"POST /api/v1/locations", "", `{"latitude": 52.52, "longitude": 13.405, "modes": ["walking"]}`, "", http.StatusAccepted},
+	{"POST /api/v1/locations", "", `{"latitude": 152.52, "longitude": 13.405}`, "", http.StatusBadRequest},
+	{"POST /api/v1/schools/1/corrections", "", `{"field": "website", "value": "https://www.lessing-gymnasium.de", "comment": "Die Schule hat eine neue Website"}`, "", http.StatusAccepted},
+	{"POST /api/v1/schools/1/corrections", "", `{"field": "district", "value": "Pankow"}`, "", http.StatusBadRequest},
+	{"POST /api/v1/schools/999/corrections", "", `{"field": "phone", "value": "030 1234567"}`, "", http.StatusNotFound},
+	{"GET /api/v1/admin/corrections", auth.RoleAdmin, "", "", http.StatusOK},
+	{"GET /api/v1/admin/corrections?status=open", auth.RoleAdmin, "", "", http.StatusBadRequest},
+	{"POST /api/v1/admin/corrections/1/review", auth.RoleAdmin, `{"decision": "accept", "note": "Auf der Schulwebsite geprüft"}`, "", http.StatusOK},
+	{"POST /api/v1/admin/corrections/1/review", auth.RoleAdmin, `{"decision": "reject"}`, "", http.StatusConflict},
+	{"POST /api/v1/admin/corrections/999/review", auth.RoleAdmin, `{"decision": "reject"}`, "", http.StatusNotFound},
+	{"PATCH /api/v1/admin/schools/2", auth.RolePartner, `{"version": 1, "phone": "030 4550290"}`, "", http.StatusOK},
+	{"PATCH /api/v1/admin/schools/2", auth.RolePartner, `{"version": 1, "phone": "030 4550291"}`, "", http.StatusConflict},
+	{"PATCH /api/v1/admin/schools/2", auth.RolePartner, `{"phone": "030 4550291"}`, "", http.StatusPreconditionRequired},
+	{"PATCH /api/v1/admin/schools/999", auth.RolePartner, `{"version": 1, "phone": "030 4550291"}`, "", http.StatusNotFound},
+	{"PATCH /api/v1/admin/schools/2", "", `{"version": 2, "phone": "030 4550291"}`, "", http.StatusForbidden},
+	{"PATCH /api/v1/admin/schools:batch", auth.RolePartner, `[{"school_number": "07G14", "email": "sekretariat@finow-grundschule.de"}]`, "", http.StatusOK},
+	{"PATCH /api/v1/admin/schools:batch", auth.RolePartner, `[{"school_number": "99X99", "email": "info@example.com"}]`, "", http.StatusNotFound},
+	{"PATCH /api/v1/admin/schools:batch", auth.RolePartner, `{"school_number": "07G14"}`, "", http.StatusBadRequest},
+	{"POST /api/v1/admin/verify?fix=true", auth.RoleAdmin, "", "", http.StatusOK},
+	{"POST /api/v1/admin/config/reload", auth.RoleAdmin, "", "", http.StatusOK},
+	{"POST /admin/jobs/snapshot", auth.RoleAdmin, "", "", http.StatusSeeOther},
+	{"POST /admin/jobs/unknown", auth.RoleAdmin, "", "", http.StatusNotFound},
+}
+
+// newRequest builds the request with the API key of its role
+func (tt contractRequest) newRequest() *http.Request {
+	method, target, _ := strings.Cut(tt.request, " ")
+	req := httptest.NewRequest(method, target, strings.NewReader(tt.body))
+	if tt.body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	role := tt.role
+	if role == "" {
+		role = auth.RolePublic
+	}
+	req.Header.Set("X-API-Key", contractKeys[role])
+	return req
+}
+
+// TestContract sends every contract request to the server of cmd/api and validates the
+// status and body of the response against api/openapi.json. Requests that must succeed are
+// validated as well, so request bodies and parameters stay documented.
+func TestContract(t *testing.T) {
+	spec, err := loadOpenAPISpec()
+	if err != nil {
+		t.Fatalf("load spec: %v", err)
+	}
+	srv := newContractServer(t)
+
+	for _, tt := range contractRequests {
+		t.Run(tt.request, func(t *testing.T) {
+			req := tt.newRequest()
+			rec := httptest.NewRecorder()
+			srv.router.ServeHTTP(rec, req)
+
+			if want := srv.status(tt); rec.Code != want {
+				t.Fatalf("status = %d, want %d; body: %s", rec.Code, want, rec.Body.String())
+			}
+			if rec.Code < http.StatusMultipleChoices {
+				if err := spec.validateRequest(req, []byte(tt.body)); err != nil {
+					t.Errorf("request: %v", err)
+				}
+			}
+			if err := spec.validateResponse(req, rec.Code, rec.Header(), rec.Body.Bytes()); err != nil {
+				t.Errorf("response: %v", err)
+			}
+		})
+	}
+}
+
+// TestContractCoverage fails when the server has a route that is not documented, or a
+// documented operation has no request in contractRequests, so endpoints can neither be
+// added nor documented without being checked
+func TestContractCoverage(t *testing.T) {
+	spec, err := loadOpenAPISpec()
+	if err != nil {
+		t.Fatalf("load spec: %v", err)
+	}
+	srv := newContractServer(t)
+
+	for route, methods := range servedRoutes(t, srv.router) {
+		item := spec.doc.Paths.Value(route)
+		for _, method := range methods {
+			if item == nil || item.GetOperation(method) == nil {
+				t.Errorf("%s %s is served but not documented in api/openapi.json", method, route)
+			}
+		}
+	}
+
+	covered := make(map[*openapi3.Operation]bool)
+	for _, tt := range contractRequests {
+		if route, _, err := spec.operation(tt.newRequest()); err == nil {
+			covered[route.Operation] = true
+		}
+	}
+	for path, item := range spec.doc.Paths.Map() {
+		for method, operation := range item.Operations() {
+			if !covered[operation] {
+				t.Errorf("%s %s is documented but not covered by contractRequests", method, path)
+			}
+		}
+	}
+}
+
+// servedRoutes returns the methods of every route of a router by path template
+func servedRoutes(t *testing.T, router chi.Router) map[string][]string {
+	t.Helper()
+	routes := make(map[string][]string)
+	err := chi.Walk(router, func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		// Sub-routers serve their "/" route without the trailing slash as well
+		route = strings.TrimSuffix(route, "/")
+		routes[route] = append(routes[route], method)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("walk routes: %v", err)
+	}
+	for route, methods := range routes {
+		// Handlers mounted with Handle, such as /metrics, answer every method and are
+		// documented with GET
+		if slices.Contains(methods, http.MethodTrace) {
+			routes[route] = []string{http.MethodGet}
+		}
+	}
+	return routes
+}
+
+// contractServer is the server of cmd/api on a temporary database with the contract seed data
+type contractServer struct {
+	router chi.Router
+	live   *config.Live
+}
+
+// status returns the status a contract request must be answered with on this server
+func (s *contractServer) status(tt contractRequest) int {
+	if tt.feature != "" && !s.live.FeatureEnabled(tt.feature) {
+		return http.StatusServiceUnavailable
+	}
+	return tt.status
+}
+
+// newContractServer builds the server as cmd/api does, on a temporary database with two
+// Gymnasien close to each other, a school without coordinates, construction projects,
+// statistics and a home location. Map tiles and OpenRouteService are served by fakes. As
+// on an instance without a Gemini key or browser, AI summaries, chat and PDFs answer 503,
+// and so does search without FTS5.
+func newContractServer(t *testing.T) *contractServer {
+	t.Helper()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	dir := t.TempDir()
+
+	tiles := httptest.NewServer(http.HandlerFunc(serveTile))
+	t.Cleanup(tiles.Close)
+	ors := httptest.NewServer(http.HandlerFunc(serveRoutes))
+	t.Cleanup(ors.Close)
+
+	env := map[string]string{
+		"ENV":                       "test",
+		"DB_PATH":                   filepath.Join(dir, "contract.db"),
+		"EXPORT_DIR":                filepath.Join(dir, "exports"),
+		"CACHE_DIR":                 filepath.Join(dir, "cache"),
+		"MAP_CACHE_DIR":             filepath.Join(dir, "maps"),
+		"MAP_TILE_URL":              tiles.URL + "/{z}/{x}/{y}.png",
+		"OPENROUTESERVICE_BASE_URL": ors.URL,
+		"API_KEY":                   contractKeys[auth.RolePublic],
+		"PARTNER_API_KEYS":          contractKeys[auth.RolePartner],
+		"ADMIN_API_KEY":             contractKeys[auth.RoleAdmin],
+		"URL_SIGNING_KEY":           "contract-signing-key",
+		"PUBLIC_BASE_URL":           "https://schulen.example.org",
+		"SCHEDULER_ENABLED":         "false",
+		"ANALYTICS_ENABLED":         "true",
+	}
+	for name, value := range env {
+		t.Setenv(name, value)
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	live := config.NewLive(cfg)
+
+	db, err := database.New(cfg.DBPath, 2, time.Second, logger)
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := database.RunMigrations(db.Writer, logger); err != nil {
+		t.Fatalf("run migrations: %v", err)
+	}
+
+	schoolRepo := repository.NewSchoolRepository(db)
+	constructionRepo := repository.NewConstructionProjectRepository(db)
+	statisticRepo := repository.NewStatisticRepository(db)
+	schoolDetailRepo := repository.NewSchoolDetailRepository(db)
+	schoolStatsRepo := repository.NewSchoolStatisticsRepository(db)
+	applicationRepo := repository.NewSchoolApplicationRepository(db)
+	examResultRepo := repository.NewExamResultRepository(db)
+	locationRepo := repository.NewLocationRepository(db)
+	searchRepo := repository.NewSchoolSearchRepository(db)
+
+	schoolService := service.NewSchoolService(schoolRepo, constructionRepo, schoolDetailRepo, schoolStatsRepo, statisticRepo,
+		repository.NewEnrichedSchoolRepository(db), applicationRepo, nil, nil, searchRepo, nil, logger)
+	statisticService := service.NewStatisticService(statisticRepo, schoolStatsRepo, nil, logger)
+	jobQueue, err := queue.New(cfg.QueueDriver, repository.NewQueueRepository(db), queue.Options{
+		MaxAttempts: cfg.QueueMaxAttempts,
+		RetryDelay:  cfg.QueueRetryDelay,
+		Holder:      cfg.InstanceID,
+	}, logger)
+	if err != nil {
+		t.Fatalf("create job queue: %v", err)
+	}
+	schoolDetailService := service.NewSchoolDetailService(schoolDetailRepo, schoolStatsRepo, nil, jobQueue, nil, 0, logger)
+	examResultService := service.NewExamResultService(examResultRepo, schoolRepo, nil, logger)
+	exportService := service.NewExportService(schoolService, cfg.ExportDir, logger)
+	routesService := service.NewRoutesService(cfg, repository.NewTravelTimeRepository(db), logger)
+	locationService := service.NewLocationService(locationRepo, schoolService, routesService, logger)
+
+	seedContractData(t, db, schoolService, exportService)
+
+	templates, err := handler.LoadTemplates("")
+	if err != nil {
+		t.Fatalf("load templates: %v", err)
+	}
+	signer := auth.NewURLSigner(cfg.URLSigningKey)
+	collector := analytics.NewCollector(repository.NewAnalyticsRepository(db), cfg.AnalyticsFlushInterval, logger)
+	meter := usage.NewMeter(repository.NewUsageRepository(db), cfg.UsageFlushInterval, usage.Quota{}, logger)
+	sched := scheduler.New(cfg, schoolService, statisticService, nil, examResultService, nil, schoolDetailService, exportService, nil, routesService,
+		repository.NewLeaseRepository(db), repository.NewJobRequestRepository(db), nil, logger)
+
+	// Missing dependencies, with the reasons cmd/api gives
+	live.SetUnavailable("ai", "no GEMINI_API_KEY is configured")
+	live.SetUnavailable("chat", "no GEMINI_API_KEY is configured")
+	live.SetUnavailable("pdf", "Chrome or Chromium is not installed on this server")
+	if !searchRepo.Enabled() {
+		live.SetUnavailable("search", "SQLite was built without FTS5 (build with -tags sqlite_fts5)")
+	}
+
+	srv := server.New(cfg, live, logger, nil, signer, nil,
+		handler.NewSchoolHandler(schoolService, nil, routesService, locationService, service.NewPDFService(logger), service.NewMapService(cfg, logger), templates, logger),
+		handler.NewConstructionProjectHandler(service.NewConstructionProjectService(constructionRepo, logger), logger),
+		handler.NewExportHandler(exportService, signer, cfg.SignedURLTTL, cfg.PublicBaseURL, logger),
+		handler.NewChatHandler(nil, logger),
+		handler.NewLocationHandler(locationService, logger),
+		handler.NewStatisticHandler(statisticService, logger),
+		handler.NewSchoolDetailHandler(schoolDetailService, logger),
+		handler.NewForecastHandler(service.NewForecastService(statisticRepo, constructionRepo, logger), logger),
+		handler.NewExamResultHandler(examResultService, logger),
+		handler.NewPreviewHandler(service.NewPreviewService(schoolService, nil, cache.New("previews", cfg.PreviewCacheTTL), logger), cfg.PublicBaseURL, logger),
+		handler.NewSyncHandler(service.NewSyncService(repository.NewSyncRepository(db), logger), logger),
+		handler.NewQueryHandler(service.NewQueryService(repository.NewQueryRepository(db), cfg.QueryMaxRows, cfg.QueryTimeout, logger), logger),
+		handler.NewPublicHandler(service.NewPublicService(repository.NewPublicRepository(db), constructionRepo, cache.New("public", cfg.PublicCacheTTL), logger), cfg.PublicCacheTTL, logger),
+		handler.NewCorrectionHandler(service.NewCorrectionService(repository.NewSchoolCorrectionRepository(db), schoolService, logger), logger),
+		handler.NewAdminHandler(service.NewAdminService(repository.NewAdminRepository(db), schoolService, schoolDetailService, cfg.ExportDir, logger), sched, collector, meter, live, templates, logger),
+		collector, meter)
+	return &contractServer{router: srv.Router(), live: live}
+}
+
+// serveTile answers map tile requests with a plain 256×256 PNG
+func serveTile(w http.ResponseWriter, r *http.Request) {
+	tile := image.NewRGBA(image.Rect(0, 0, 256, 256))
+	draw.Draw(tile, tile.Bounds(), image.NewUniform(color.RGBA{R: 0xf2, G: 0xef, B: 0xe9, A: 0xff}), image.Point{}, draw.Src)
+	w.Header().Set("Content-Type", "image/png")
+	png.Encode(w, tile)
+}
+
+// serveRoutes answers OpenRouteService directions with a 13 minute, 1.2 km route and matrix
+// requests with 10 minutes and 1.5 km to the first destination, 2 minutes and 400 m more to
+// each further one
+func serveRoutes(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	switch {
+	case strings.HasPrefix(r.URL.Path, "/directions/"):
+		fmt.Fprint(w, `{"features": [{"properties": {"summary": {"duration": 780, "distance": 1240}}, "geometry": {"type": "LineString", "coordinates": [[13.37, 52.545], [13.3582, 52.5438]]}}]}`)
+	case strings.HasPrefix(r.URL.Path, "/matrix/"):
+		var req struct {
+			Destinations []int `json:"destinations"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		durations := make([]float64, len(req.Destinations))
+		distances := make([]float64, len(req.Destinations))
+		for i := range req.Destinations {
+			durations[i] = float64(600 + 120*i)
+			distances[i] = float64(1500 + 400*i)
+		}
+		json.NewEncoder(w).Encode(map[string][][]float64{"durations": {durations}, "distances": {distances}})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func seedContractData(t *testing.T, db *database.DB, schoolService *service.SchoolService, exportService *service.ExportService) {
+	t.Helper()
+	ctx := context.Background()
+	coords := func(v float64) *float64 { return &v }
+	number := func(v int) *int { return &v }
+	grade := func(v float64) *float64 { return &v }
+	scrapedAt := time.Date(2025, 8, 30, 3, 0, 0, 0, time.UTC)
+
+	schools := []models.CreateSchoolInput{
+		{SchoolNumber: "01Y02", Name: "Lessing-Gymnasium", SchoolType: "Gymnasium", Operator: "öffentlich", District: "Mitte",
+			PostalCode: "13353", Street: "Mettmannstraße", HouseNumber: "16", SchoolYear: "2025/26",
+			Latitude: coords(52.5438), Longitude: coords(13.3582)},
+		{SchoolNumber: "01Y03", Name: "Diesterweg-Gymnasium", SchoolType: "Gymnasium", Operator: "öffentlich", District: "Mitte",
+			PostalCode: "13357", Street: "Putbusser Straße", HouseNumber: "9", SchoolYear: "2025/26",
+			Latitude: coords(52.5489), Longitude: coords(13.3891)},
+		{SchoolNumber: "07G14", Name: "Finow-Grundschule", SchoolType: "Grundschule", District: "Tempelhof-Schöneberg"},
+	}
+	schoolRepo := repository.NewSchoolRepository(db)
+	for _, input := range schools {
+		if _, err := schoolRepo.Create(ctx, input); err != nil {
+			t.Fatalf("create school: %v", err)
+		}
+	}
+
+	projects := []models.CreateConstructionProjectInput{
+		{ProjectID: 1204, SchoolNumber: "01Y02", SchoolName: "Lessing-Gymnasium", District: "Mitte", SchoolType: "Gymnasium",
+			ConstructionMeasure: "Erweiterungsbau", Description: "Modularer Ergänzungsbau, Baubeginn 2025", HandoverDate: "2027/2028",
+			TotalCosts: "14.500.000 €", Street: "Mettmannstraße 16", PostalCode: "13353", City: "Berlin",
+			Assets: []models.ConstructionProjectAsset{
+				{Kind: models.AssetKindDocument, URL: "https://www.berlin.de/sen/bildung/schule/bauen-und-sanieren/schulbaukarte/1204-plan.pdf", Title: "Lageplan"},
+			}},
+		{ProjectID: 1305, SchoolName: "Neubau Grundschule Buch", District: "Pankow", SchoolType: "Grundschule",
+			ConstructionMeasure: "Neubau", Street: "Wiltbergstraße 1", PostalCode: "13125", City: "Berlin", Latitude: 52.6312, Longitude: 13.4985},
+	}
+	constructionRepo := repository.NewConstructionProjectRepository(db)
+	for _, input := range projects {
+		if _, err := constructionRepo.Create(ctx, input); err != nil {
+			t.Fatalf("create construction project: %v", err)
+		}
+	}
+
+	if _, err := repository.NewStatisticRepository(db).Create(ctx, models.StatisticData{
+		SchoolNumber: "01Y02", SchoolName: "Lessing-Gymnasium", District: "Mitte", SchoolType: "Gymnasium",
+		SchoolYear: "2024/25", Students: "864", Teachers: "68", Classes: "30", ScrapedAt: scrapedAt,
+	}); err != nil {
+		t.Fatalf("create statistic: %v", err)
+	}
+
+	if err := repository.NewSchoolDetailRepository(db).Create(ctx, &models.SchoolDetailData{
+		SchoolNumber: "01Y02", SchoolName: "Lessing-Gymnasium", SchoolURL: "https://www.bildung.berlin.de/Schulverzeichnis/Schulportrait.aspx?IDSchulzweig=10102",
+		Languages: "Englisch, Französisch, Latein", Offerings: "Bilingualer Unterricht (Englisch)", WorkingGroups: "Schach, Schülerzeitung, Big Band",
+		AvailableAfter4thGrade: true, WheelchairAccessible: "yes", AllDayType: models.AllDayOpen,
+		Europaschule: true, BilingualLanguages: []string{models.LanguageEnglish}, ReligiousEducation: []string{models.ReligiousEducationProtestant, models.ReligiousEducationEthics},
+		LanguageTable: &models.StatisticTable{
+			Headers: []string{"Schuljahr", "Schülerinnen und Schüler", "davon nichtdeutscher Herkunftssprache"},
+			Rows:    [][]string{{"2024/25", "864", "213"}},
+		},
+		ScrapedAt: scrapedAt, ParserVersion: 3,
+	}); err != nil {
+		t.Fatalf("create school detail: %v", err)
+	}
+
+	statsRepo := repository.NewSchoolStatisticsRepository(db)
+	if err := statsRepo.SaveStaffingStat(ctx, models.SchoolStaffingStat{
+		SchoolNumber: "01Y02", StaffingCoverage: grade(101.4), ScrapedAt: scrapedAt,
+		Qualifications: []models.SchoolTeacherQualification{
+			{SchoolNumber: "01Y02", Qualification: "Lehrkräfte mit Lehramtsbefähigung", Teachers: 61, ScrapedAt: scrapedAt},
+			{SchoolNumber: "01Y02", Qualification: "Quereinsteigende", Teachers: 7, ScrapedAt: scrapedAt},
+		},
+	}); err != nil {
+		t.Fatalf("save staffing: %v", err)
+	}
+	if err := statsRepo.SaveQualityIndicators(ctx, "01Y02", []models.SchoolQualityIndicator{
+		{SchoolNumber: "01Y02", Area: "Lehr- und Lernprozesse", Code: "2.1", Indicator: "Unterrichtsgestaltung", Rating: models.QualityRatingB, ScrapedAt: scrapedAt},
+		{SchoolNumber: "01Y02", Area: "Schulkultur", Code: "3.1", Indicator: "Schulklima", Rating: models.QualityRatingA, ScrapedAt: scrapedAt},
+	}); err != nil {
+		t.Fatalf("save quality indicators: %v", err)
+	}
+	if err := statsRepo.SaveGradeStructure(ctx, "01Y02", []models.SchoolGradeClasses{
+		{SchoolNumber: "01Y02", SchoolYear: "2024/25", Grade: 7, Classes: 5, ScrapedAt: scrapedAt},
+		{SchoolNumber: "01Y02", SchoolYear: "2024/25", Grade: 8, Classes: 4, ScrapedAt: scrapedAt},
+	}); err != nil {
+		t.Fatalf("save grade structure: %v", err)
+	}
+
+	if _, _, err := repository.NewSchoolApplicationRepository(db).Upsert(ctx, []models.SchoolApplicationInput{
+		{SchoolNumber: "01Y02", SchoolYear: "2025/26", Places: 140, FirstChoiceApplications: 187},
+	}); err != nil {
+		t.Fatalf("save applications: %v", err)
+	}
+	if _, _, err := repository.NewExamResultRepository(db).Upsert(ctx, []models.ExamResultInput{
+		{SchoolNumber: "01Y02", SchoolYear: "2023/24", Participants: number(98), Passed: number(96), AverageGrade: grade(2.31)},
+		{SchoolNumber: "01Y02", SchoolYear: "2024/25", Participants: number(104), Passed: number(101), AverageGrade: grade(2.24)},
+		{SchoolNumber: "01Y03", SchoolYear: "2024/25", Participants: number(87), Passed: number(83), AverageGrade: grade(2.52)},
+	}); err != nil {
+		t.Fatalf("save exam results: %v", err)
+	}
+
+	// A home location in Wedding whose walking commutes are computed
+	locationRepo := repository.NewLocationRepository(db)
+	if err := locationRepo.Create(ctx, &models.UserLocation{Token: contractLocation, Latitude: 52.545, Longitude: 13.37, CreatedAt: scrapedAt}); err != nil {
+		t.Fatalf("create location: %v", err)
+	}
+	if err := locationRepo.ReplaceCommuteTimes(ctx, contractLocation, "walking", []models.CommuteTime{
+		{SchoolID: 1, DurationSeconds: 840, DistanceMeters: 1120, ComputedAt: scrapedAt},
+		{SchoolID: 2, DurationSeconds: 1380, DistanceMeters: 1840, ComputedAt: scrapedAt},
+	}); err != nil {
+		t.Fatalf("save commute times: %v", err)
+	}
+	if err := locationRepo.SetStatus(ctx, contractLocation, "walking", models.CommuteStatusReady, ""); err != nil {
+		t.Fatalf("set commute status: %v", err)
+	}
+
+	// As after a refresh: the enriched snapshot, search index and full export are built
+	if err := schoolService.RebuildEnrichedSnapshot(ctx); err != nil {
+		t.Fatalf("build snapshot: %v", err)
+	}
+	if _, err := repository.NewSchoolSearchRepository(db).Rebuild(ctx); err != nil {
+		t.Fatalf("build search index: %v", err)
+	}
+	if err := exportService.BuildFullExport(ctx); err != nil {
+		t.Fatalf("build export: %v", err)
+	}
+}
@@ -0,0 +1,163 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"schools-be/internal/auth"
+	apperrors "schools-be/internal/errors"
+	"schools-be/internal/models"
+	"schools-be/internal/service"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+)
+
+// CorrectionHandler takes the fixes users suggest for school data and serves the review
+// queue to admins
+type CorrectionHandler struct {
+	service  *service.CorrectionService
+	validate *validator.Validate
+	logger   *slog.Logger
+}
+
+func NewCorrectionHandler(service *service.CorrectionService, logger *slog.Logger) *CorrectionHandler {
+	return &CorrectionHandler{
+		service:  service,
+		validate: validator.New(),
+		logger:   logger.With(slog.String("handler", "correction")),
+	}
+}
+
+// Submit queues a suggested fix of one field of a school, e.g.
+// {"field": "website", "value": "", "comment": "the site is gone"}, and answers 202 with
+// the pending correction. The value must pass the rules of the field as in school updates.
+func (h *CorrectionHandler) Submit(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		h.respondError(w, http.StatusBadRequest, "invalid school id")
+		return
+	}
+
+	var input models.CreateCorrectionInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		h.respondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	input.Value = strings.TrimSpace(input.Value)
+	if err := h.validate.Struct(input); err != nil {
+		h.respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	update, ok := models.CorrectionUpdate(input.Field, input.Value)
+	if !ok {
+		h.respondError(w, http.StatusBadRequest, "field must be one of "+strings.Join(models.CorrectableFields, ", "))
+		return
+	}
+	if err := h.validate.Struct(update); err != nil {
+		h.respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	correction, err := h.service.Submit(r.Context(), id, input)
+	if err != nil {
+		switch {
+		case errors.Is(err, apperrors.ErrNotFound):
+			h.respondError(w, http.StatusNotFound, "school not found")
+		case errors.Is(err, apperrors.ErrInvalidInput):
+			h.respondError(w, http.StatusBadRequest, err.Error())
+		default:
+			h.logger.ErrorContext(r.Context(), "failed to submit school correction",
+				slog.Int64("school_id", id),
+				slog.String("error", err.Error()),
+			)
+			h.respondError(w, http.StatusInternalServerError, "failed to submit correction")
+		}
+		return
+	}
+
+	h.respondJSON(w, http.StatusAccepted, correction)
+}
+
+// List returns the corrections with the status of ?status= (pending by default, all for
+// ?status=all), oldest first
+func (h *CorrectionHandler) List(w http.ResponseWriter, r *http.Request) {
+	status := r.URL.Query().Get("status")
+	switch status {
+	case "":
+		status = models.CorrectionStatusPending
+	case "all":
+		status = ""
+	}
+
+	corrections, err := h.service.List(r.Context(), status)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrInvalidInput) {
+			h.respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		h.logger.ErrorContext(r.Context(), "failed to list school corrections", slog.String("error", err.Error()))
+		h.respondError(w, http.StatusInternalServerError, "failed to list corrections")
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, corrections)
+}
+
+// Review accepts or rejects a pending correction: {"decision": "accept", "note": "..."}.
+// An accepted value is applied to the school and kept over later imports of the school list.
+func (h *CorrectionHandler) Review(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		h.respondError(w, http.StatusBadRequest, "invalid correction id")
+		return
+	}
+
+	var input models.ReviewCorrectionInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		h.respondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if err := h.validate.Struct(input); err != nil {
+		h.respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	principal, _ := auth.FromContext(r.Context())
+	correction, err := h.service.Review(r.Context(), id, input, principal.Subject)
+	if err != nil {
+		switch {
+		case errors.Is(err, apperrors.ErrNotFound):
+			h.respondError(w, http.StatusNotFound, "correction not found")
+		case errors.Is(err, apperrors.ErrConflict):
+			h.respondError(w, http.StatusConflict, err.Error())
+		default:
+			h.logger.ErrorContext(r.Context(), "failed to review school correction",
+				slog.Int64("id", id),
+				slog.String("error", err.Error()),
+			)
+			h.respondError(w, http.StatusInternalServerError, "failed to review correction")
+		}
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, correction)
+}
+
+// respondJSON sends a JSON response
+func (h *CorrectionHandler) respondJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(data); err != nil {
+		h.logger.Error("failed to encode response", slog.String("error", err.Error()))
+	}
+}
+
+// respondError sends an error JSON response
+func (h *CorrectionHandler) respondError(w http.ResponseWriter, status int, message string) {
+	h.respondJSON(w, status, map[string]string{"error": message})
+}
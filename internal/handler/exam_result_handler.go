@@ -0,0 +1,56 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	apperrors "schools-be/internal/errors"
+	"schools-be/internal/service"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type ExamResultHandler struct {
+	service *service.ExamResultService
+	logger  *slog.Logger
+}
+
+func NewExamResultHandler(service *service.ExamResultService, logger *slog.Logger) *ExamResultHandler {
+	return &ExamResultHandler{
+		service: service,
+		logger:  logger.With(slog.String("handler", "exam_result")),
+	}
+}
+
+// GetBySchool returns the Abitur results of one school by school number (BSN) as a year
+// series with Berlin and school type benchmarks
+func (h *ExamResultHandler) GetBySchool(w http.ResponseWriter, r *http.Request) {
+	series, err := h.service.GetSchoolExamResults(r.Context(), chi.URLParam(r, "bsn"))
+	if err != nil {
+		if errors.Is(err, apperrors.ErrNotFound) {
+			h.respondError(w, http.StatusNotFound, "school not found")
+			return
+		}
+		h.logger.ErrorContext(r.Context(), "failed to get exam results", slog.String("error", err.Error()))
+		h.respondError(w, http.StatusInternalServerError, "failed to retrieve exam results")
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, series)
+}
+
+// respondJSON sends a JSON response
+func (h *ExamResultHandler) respondJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(data); err != nil {
+		h.logger.Error("failed to encode response", slog.String("error", err.Error()))
+	}
+}
+
+// respondError sends an error JSON response
+func (h *ExamResultHandler) respondError(w http.ResponseWriter, status int, message string) {
+	h.respondJSON(w, status, map[string]string{"error": message})
+}
@@ -0,0 +1,169 @@
+package handler_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+
+	"schools-be/api"
+	"schools-be/internal/auth"
+)
+
+var updateExamples = flag.Bool("update-examples", false, "rewrite api/examples.json from the contract requests")
+
+const examplesPath = "../../api/examples.json"
+
+// exampleTimestamp replaces the timestamps of recorded responses, which depend on when the
+// seed data was created, so regenerating the bundle only changes what the endpoints changed
+const exampleTimestamp = `"2025-09-01T06:30:00Z"`
+
+var timestampPattern = regexp.MustCompile(`"\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(?:\.\d+)?(?:Z|[+-]\d{2}:\d{2})"`)
+
+// unrecordedPaths are left out of the bundle: the spec and the bundle itself
+var unrecordedPaths = map[string]bool{"/openapi.json": true, "/api/v1/meta/examples": true}
+
+// examplesBundle is the layout of api/examples.json
+type examplesBundle struct {
+	Description string    `json:"description"`
+	Examples    []example `json:"examples"`
+}
+
+type example struct {
+	OperationID string          `json:"operation_id"`
+	Method      string          `json:"method"`
+	Path        string          `json:"path"` // Documented path template
+	Request     string          `json:"request"`
+	RequestBody json.RawMessage `json:"request_body,omitempty"`
+	Status      int             `json:"status"`
+	ContentType string          `json:"content_type,omitempty"` // Set for responses that are not JSON, whose body is left out
+	Body        json.RawMessage `json:"body,omitempty"`
+}
+
+// newRequest rebuilds the recorded request
+func (ex example) newRequest() *http.Request {
+	req := httptest.NewRequest(ex.Method, ex.Request, bytes.NewReader(ex.RequestBody))
+	if len(ex.RequestBody) > 0 {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return req
+}
+
+// TestExamples checks that api/examples.json has an example of every public route of the
+// server, successful unless its feature is unavailable, and that every example matches the
+// spec. With -update-examples it first records the bundle again from the public
+// contractRequests against the seeded contract server.
+func TestExamples(t *testing.T) {
+	spec, err := loadOpenAPISpec()
+	if err != nil {
+		t.Fatalf("load spec: %v", err)
+	}
+	srv := newContractServer(t)
+
+	data := api.Examples
+	if *updateExamples {
+		data = recordExamples(t, spec, srv)
+		if err := os.WriteFile(examplesPath, data, 0o644); err != nil {
+			t.Fatalf("write examples: %v", err)
+		}
+	}
+
+	var bundle examplesBundle
+	if err := json.Unmarshal(data, &bundle); err != nil {
+		t.Fatalf("parse examples: %v", err)
+	}
+
+	succeeded := make(map[string]bool)
+	for _, ex := range bundle.Examples {
+		contentType := "application/json"
+		if ex.ContentType != "" {
+			contentType = ex.ContentType
+		}
+		req := ex.newRequest()
+		if err := spec.validateResponse(req, ex.Status, http.Header{"Content-Type": {contentType}}, ex.Body); err != nil {
+			t.Errorf("%s %s: %v", ex.Method, ex.Request, err)
+			continue
+		}
+		// Operations of features the contract server lacks, such as AI summaries, can only
+		// be shown failing with 503
+		if ex.Status < http.StatusMultipleChoices || ex.Status == http.StatusServiceUnavailable {
+			succeeded[ex.Method+" "+ex.Path] = true
+		}
+	}
+
+	for route, methods := range servedRoutes(t, srv.router) {
+		if unrecordedPaths[route] {
+			continue
+		}
+		item := spec.doc.Paths.Value(route)
+		for _, method := range methods {
+			// Routes of partners and admins are left out of the bundle
+			if item != nil {
+				if op := item.GetOperation(method); op != nil && op.Extensions["x-role"] != nil {
+					continue
+				}
+			}
+			if !succeeded[method+" "+route] {
+				t.Errorf("%s %s has no successful example; run go test -tags sqlite_fts5 ./internal/handler -run Examples -update-examples", method, route)
+			}
+		}
+	}
+}
+
+// recordExamples sends the public contract requests and returns the indented bundle
+func recordExamples(t *testing.T, spec *openAPISpec, srv *contractServer) []byte {
+	t.Helper()
+
+	bundle := examplesBundle{
+		Description: "Example responses of the documented endpoints, recorded from a small seed dataset. Timestamps are fixed; values are illustrative.",
+		Examples:    []example{},
+	}
+	for _, tt := range contractRequests {
+		if tt.role != "" && tt.role != auth.RolePublic {
+			continue
+		}
+		req := tt.newRequest()
+		rec := httptest.NewRecorder()
+		srv.router.ServeHTTP(rec, req)
+
+		route, _, err := spec.operation(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if unrecordedPaths[route.Path] {
+			continue
+		}
+		ex := example{
+			OperationID: route.Operation.OperationID,
+			Method:      req.Method,
+			Path:        route.Path,
+			Request:     req.URL.RequestURI(),
+			Status:      rec.Code,
+		}
+		if mediaType, _, _ := mime.ParseMediaType(rec.Header().Get("Content-Type")); mediaType != "application/json" {
+			ex.ContentType = mediaType
+		} else if rec.Body.Len() > 0 {
+			ex.Body = timestampPattern.ReplaceAll([]byte(strings.TrimSpace(rec.Body.String())), []byte(exampleTimestamp))
+		}
+		if tt.body != "" {
+			ex.RequestBody = json.RawMessage(tt.body)
+		}
+		bundle.Examples = append(bundle.Examples, ex)
+	}
+
+	// Query strings stay readable without HTML escaping of &
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(bundle); err != nil {
+		t.Fatalf("encode examples: %v", err)
+	}
+	return buf.Bytes()
+}
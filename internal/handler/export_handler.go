@@ -0,0 +1,116 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"os"
+	"time"
+
+	"schools-be/internal/auth"
+	"schools-be/internal/models"
+	"schools-be/internal/service"
+)
+
+// downloadLinkRoles lists the exports that signed links can be created for, with the role a
+// caller needs to create one. Links are signed with that role, not the caller's.
+var downloadLinkRoles = map[string]auth.Role{
+	"/api/v1/export/full.json.br":   auth.RolePublic,
+	"/api/v1/admin/export/database": auth.RoleAdmin,
+}
+
+type ExportHandler struct {
+	service       *service.ExportService
+	signer        *auth.URLSigner
+	linkTTL       time.Duration
+	publicBaseURL string
+	logger        *slog.Logger
+}
+
+// NewExportHandler creates the handler; signed download links are valid for linkTTL and
+// point to publicBaseURL (derived from the request when empty)
+func NewExportHandler(service *service.ExportService, signer *auth.URLSigner, linkTTL time.Duration, publicBaseURL string, logger *slog.Logger) *ExportHandler {
+	return &ExportHandler{
+		service:       service,
+		signer:        signer,
+		linkTTL:       linkTTL,
+		publicBaseURL: publicBaseURL,
+		logger:        logger.With(slog.String("handler", "export")),
+	}
+}
+
+// CreateDownloadLink returns a signed, expiring URL for an export, which the frontend can hand
+// to the browser without exposing its API key
+func (h *ExportHandler) CreateDownloadLink(w http.ResponseWriter, r *http.Request) {
+	var req models.DownloadLinkRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	role, ok := downloadLinkRoles[req.Path]
+	if !ok {
+		h.respondError(w, http.StatusBadRequest, "no download links for this path")
+		return
+	}
+	principal, _ := auth.FromContext(r.Context())
+	if !principal.Role.Allows(role) {
+		h.respondError(w, http.StatusForbidden, "requires the "+string(role)+" role")
+		return
+	}
+
+	expires := time.Now().Add(h.linkTTL).Truncate(time.Second)
+	query := h.signer.Sign(req.Path, role, expires)
+	h.logger.InfoContext(r.Context(), "download link created",
+		slog.String("path", req.Path),
+		slog.String("subject", principal.Subject),
+		slog.Time("expires_at", expires),
+	)
+
+	h.respondJSON(w, http.StatusOK, models.DownloadLink{
+		URL:       requestBaseURL(r, h.publicBaseURL) + req.Path + "?" + query.Encode(),
+		ExpiresAt: expires.UTC(),
+	})
+}
+
+// GetFullExport serves the precomputed Brotli-compressed dataset with all enriched schools.
+// The file is regenerated after each data refresh; Range and If-Modified-Since are supported.
+func (h *ExportHandler) GetFullExport(w http.ResponseWriter, r *http.Request) {
+	file, err := os.Open(h.service.FullExportPath())
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			h.respondError(w, http.StatusNotFound, "export not available yet")
+			return
+		}
+		h.logger.ErrorContext(r.Context(), "failed to open full export", slog.String("error", err.Error()))
+		h.respondError(w, http.StatusInternalServerError, "failed to read export")
+		return
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		h.logger.ErrorContext(r.Context(), "failed to stat full export", slog.String("error", err.Error()))
+		h.respondError(w, http.StatusInternalServerError, "failed to read export")
+		return
+	}
+
+	// Served as an opaque file: clients decompress it themselves (e.g. `brotli -d full.json.br`)
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Disposition", `attachment; filename="full.json.br"`)
+	http.ServeContent(w, r, "full.json.br", info.ModTime(), file)
+}
+
+func (h *ExportHandler) respondJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(data); err != nil {
+		h.logger.Error("failed to encode response", slog.String("error", err.Error()))
+	}
+}
+
+func (h *ExportHandler) respondError(w http.ResponseWriter, status int, message string) {
+	h.respondJSON(w, status, map[string]string{"error": message})
+}
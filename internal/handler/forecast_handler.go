@@ -0,0 +1,48 @@
+package handler
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"schools-be/internal/service"
+)
+
+type ForecastHandler struct {
+	service *service.ForecastService
+	logger  *slog.Logger
+}
+
+func NewForecastHandler(service *service.ForecastService, logger *slog.Logger) *ForecastHandler {
+	return &ForecastHandler{
+		service: service,
+		logger:  logger.With(slog.String("handler", "forecast")),
+	}
+}
+
+// GetCapacityForecast returns projected enrolment and capacity per school and per district
+// for the next school years. Query param district limits the forecast to one district.
+func (h *ForecastHandler) GetCapacityForecast(w http.ResponseWriter, r *http.Request) {
+	forecast, err := h.service.GetCapacityForecast(r.Context(), r.URL.Query().Get("district"))
+	if err != nil {
+		h.logger.ErrorContext(r.Context(), "failed to compute capacity forecast", slog.String("error", err.Error()))
+		h.respondError(w, http.StatusInternalServerError, "failed to compute capacity forecast")
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, forecast)
+}
+
+// respondJSON sends a JSON response
+func (h *ForecastHandler) respondJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(data); err != nil {
+		h.logger.Error("failed to encode response", slog.String("error", err.Error()))
+	}
+}
+
+// respondError sends an error JSON response
+func (h *ForecastHandler) respondError(w http.ResponseWriter, status int, message string) {
+	h.respondJSON(w, status, map[string]string{"error": message})
+}
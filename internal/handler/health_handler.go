@@ -12,9 +12,9 @@ type HealthHandler struct {
 	logger *slog.Logger
 }
 
-func NewHealthHandler() *HealthHandler {
+func NewHealthHandler(logger *slog.Logger) *HealthHandler {
 	return &HealthHandler{
-		logger: slog.Default(),
+		logger: logger.With(slog.String("handler", "health")),
 	}
 }
 
@@ -0,0 +1,27 @@
+package handler
+
+import (
+	"net/http"
+	"sort"
+
+	"schools-be/internal/i18n"
+	"schools-be/internal/models"
+)
+
+// requestLanguage resolves the response language from the Accept-Language header and
+// advertises it via Content-Language. Vary keeps shared caches from serving one language to
+// every client.
+func requestLanguage(w http.ResponseWriter, r *http.Request) string {
+	lang := i18n.ParseAcceptLanguage(r.Header.Get("Accept-Language"))
+	w.Header().Set("Content-Language", lang)
+	w.Header().Add("Vary", "Accept-Language")
+	return lang
+}
+
+// sortSchoolsByName orders schools alphabetically using the collation of the request language
+func sortSchoolsByName(schools []models.EnrichedSchool, lang string) {
+	collator := i18n.NewCollator(lang)
+	sort.SliceStable(schools, func(i, j int) bool {
+		return collator.CompareString(schools[i].School.Name, schools[j].School.Name) < 0
+	})
+}
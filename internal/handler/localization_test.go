@@ -0,0 +1,26 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestRequestLanguage checks that the resolved language is advertised and that responses
+// vary by Accept-Language for shared caches
+func TestRequestLanguage(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/api/v1/schools/enriched", nil)
+	r.Header.Set("Accept-Language", "en-GB,en;q=0.9,de;q=0.5")
+	w := httptest.NewRecorder()
+	w.Header().Set("Vary", "Origin")
+
+	if lang := requestLanguage(w, r); lang != "en" {
+		t.Errorf("language = %q, want en", lang)
+	}
+	if got := w.Header().Get("Content-Language"); got != "en" {
+		t.Errorf("Content-Language = %q, want en", got)
+	}
+	if got := w.Header().Values("Vary"); len(got) != 2 || got[1] != "Accept-Language" {
+		t.Errorf("Vary = %v, want Accept-Language next to Origin", got)
+	}
+}
@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	apperrors "schools-be/internal/errors"
+	"schools-be/internal/service"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+)
+
+type LocationHandler struct {
+	service  *service.LocationService
+	validate *validator.Validate
+	logger   *slog.Logger
+}
+
+func NewLocationHandler(service *service.LocationService, logger *slog.Logger) *LocationHandler {
+	return &LocationHandler{
+		service:  service,
+		validate: validator.New(),
+		logger:   logger.With(slog.String("handler", "location")),
+	}
+}
+
+// RegisterLocation stores a home coordinate under an anonymous token and starts
+// computing commute times to all schools in the background
+func (h *LocationHandler) RegisterLocation(w http.ResponseWriter, r *http.Request) {
+	var req service.RegisterLocationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if err := h.validate.Struct(req); err != nil {
+		h.respondError(w, http.StatusBadRequest, "latitude and longitude are required and must be valid coordinates")
+		return
+	}
+
+	location, err := h.service.RegisterLocation(r.Context(), req)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrInvalidInput) {
+			h.respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if errors.Is(err, apperrors.ErrUnavailable) {
+			w.Header().Set("Retry-After", "60")
+			h.respondError(w, http.StatusServiceUnavailable, "too many locations are waiting for commute times, retry later")
+			return
+		}
+		h.logger.ErrorContext(r.Context(), "failed to register location", slog.String("error", err.Error()))
+		h.respondError(w, http.StatusInternalServerError, "failed to register location")
+		return
+	}
+
+	h.respondJSON(w, http.StatusAccepted, location)
+}
+
+// GetLocation returns a registered location and the state of its commute computations
+func (h *LocationHandler) GetLocation(w http.ResponseWriter, r *http.Request) {
+	location, err := h.service.GetLocation(r.Context(), chi.URLParam(r, "token"))
+	if err != nil {
+		if errors.Is(err, apperrors.ErrNotFound) {
+			h.respondError(w, http.StatusNotFound, "location not found")
+			return
+		}
+		h.logger.ErrorContext(r.Context(), "failed to get location", slog.String("error", err.Error()))
+		h.respondError(w, http.StatusInternalServerError, "failed to retrieve location")
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, location)
+}
+
+func (h *LocationHandler) respondJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(data); err != nil {
+		h.logger.Error("failed to encode response", slog.String("error", err.Error()))
+	}
+}
+
+func (h *LocationHandler) respondError(w http.ResponseWriter, status int, message string) {
+	h.respondJSON(w, status, map[string]string{"error": message})
+}
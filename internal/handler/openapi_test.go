@@ -0,0 +1,100 @@
+package handler_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+
+	"schools-be/api"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
+)
+
+func init() {
+	// Binary and HTML responses are documented as strings; their bodies are not parsed
+	openapi3filter.RegisterBodyDecoder("application/pdf", openapi3filter.FileBodyDecoder)
+	openapi3filter.RegisterBodyDecoder("application/vnd.sqlite3", openapi3filter.FileBodyDecoder)
+	openapi3filter.RegisterBodyDecoder("image/png", openapi3filter.FileBodyDecoder)
+	openapi3filter.RegisterBodyDecoder("text/html", openapi3filter.PlainBodyDecoder)
+}
+
+// openAPISpec is api/openapi.json with a router finding the documented operation of a request
+type openAPISpec struct {
+	doc    *openapi3.T
+	router routers.Router
+}
+
+func loadOpenAPISpec() (*openAPISpec, error) {
+	loader := openapi3.NewLoader()
+	doc, err := loader.LoadFromData(api.Spec)
+	if err != nil {
+		return nil, fmt.Errorf("parse spec: %w", err)
+	}
+	if err := doc.Validate(loader.Context); err != nil {
+		return nil, fmt.Errorf("invalid spec: %w", err)
+	}
+
+	// Match requests by path alone, whatever host they are sent to
+	doc.Servers = nil
+	router, err := gorillamux.NewRouter(doc)
+	if err != nil {
+		return nil, fmt.Errorf("build router: %w", err)
+	}
+	return &openAPISpec{doc: doc, router: router}, nil
+}
+
+// operation returns the documented operation of a request and its path template
+func (s *openAPISpec) operation(req *http.Request) (*routers.Route, map[string]string, error) {
+	route, params, err := s.router.FindRoute(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s %s is not documented: %w", req.Method, req.URL.Path, err)
+	}
+	return route, params, nil
+}
+
+// validateRequest checks the parameters and body of a request against its operation
+func (s *openAPISpec) validateRequest(req *http.Request, body []byte) error {
+	route, params, err := s.operation(req)
+	if err != nil {
+		return err
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	return openapi3filter.ValidateRequest(context.Background(), &openapi3filter.RequestValidationInput{
+		Request:    req,
+		PathParams: params,
+		Route:      route,
+		Options: &openapi3filter.Options{
+			AuthenticationFunc: openapi3filter.NoopAuthenticationFunc,
+			MultiError:         true,
+		},
+	})
+}
+
+// validateResponse checks a response against the operation of its request. Undocumented
+// statuses and content types fail, as do bodies with undocumented properties, so a new
+// response field needs a spec change in the same commit.
+func (s *openAPISpec) validateResponse(req *http.Request, status int, header http.Header, body []byte) error {
+	route, params, err := s.operation(req)
+	if err != nil {
+		return err
+	}
+	return openapi3filter.ValidateResponse(context.Background(), &openapi3filter.ResponseValidationInput{
+		RequestValidationInput: &openapi3filter.RequestValidationInput{
+			Request:    req,
+			PathParams: params,
+			Route:      route,
+		},
+		Status: status,
+		Header: header,
+		Body:   io.NopCloser(bytes.NewReader(body)),
+		Options: &openapi3filter.Options{
+			IncludeResponseStatus: true,
+			MultiError:            true,
+		},
+	})
+}
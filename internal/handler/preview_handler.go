@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	apperrors "schools-be/internal/errors"
+	"schools-be/internal/service"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type PreviewHandler struct {
+	service       *service.PreviewService
+	publicBaseURL string
+	logger        *slog.Logger
+}
+
+// NewPreviewHandler creates the handler; publicBaseURL is the externally reachable origin of the
+// API, used for absolute image URLs. When empty it is derived from the request.
+func NewPreviewHandler(service *service.PreviewService, publicBaseURL string, logger *slog.Logger) *PreviewHandler {
+	return &PreviewHandler{
+		service:       service,
+		publicBaseURL: publicBaseURL,
+		logger:        logger.With(slog.String("handler", "preview")),
+	}
+}
+
+// GetSchoolPreview returns Open Graph metadata for a school (title, short description and
+// map thumbnail) so share links unfurl well in messaging apps
+func (h *PreviewHandler) GetSchoolPreview(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		h.respondError(w, http.StatusBadRequest, "invalid school id")
+		return
+	}
+
+	imageURL := fmt.Sprintf("%s/api/v1/schools/%d/map.png", h.baseURL(r), id)
+	preview, err := h.service.GetSchoolPreview(ctx, id, imageURL)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrNotFound) {
+			h.respondError(w, http.StatusNotFound, "school not found")
+			return
+		}
+		h.logger.ErrorContext(ctx, "failed to build school preview", slog.Int64("id", id), slog.String("error", err.Error()))
+		h.respondError(w, http.StatusInternalServerError, "failed to build school preview")
+		return
+	}
+
+	// Unfurlers fetch previews repeatedly; they only change when the school is updated
+	w.Header().Set("Cache-Control", "public, max-age=3600")
+	h.respondJSON(w, http.StatusOK, preview)
+}
+
+// baseURL returns the configured public origin, or the one the request was made to
+func (h *PreviewHandler) baseURL(r *http.Request) string {
+	return requestBaseURL(r, h.publicBaseURL)
+}
+
+// respondJSON sends a JSON response
+func (h *PreviewHandler) respondJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(data); err != nil {
+		h.logger.Error("failed to encode response", slog.String("error", err.Error()))
+	}
+}
+
+// respondError sends an error JSON response
+func (h *PreviewHandler) respondError(w http.ResponseWriter, status int, message string) {
+	h.respondJSON(w, status, map[string]string{"error": message})
+}
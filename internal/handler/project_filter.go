@@ -0,0 +1,95 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"schools-be/internal/models"
+)
+
+// projectTimelineFilter holds the timeline filters of the construction project lists; the zero
+// value keeps all projects
+type projectTimelineFilter struct {
+	status   string // status=<models.ProjectStatuses>
+	district string // district=<name>
+	from, to *int   // from=<year>&to=<year>: projects running during any of these years
+}
+
+// parseProjectTimelineFilter reads the filters from the query of a construction project list
+// request
+func parseProjectTimelineFilter(query url.Values) (projectTimelineFilter, error) {
+	var filter projectTimelineFilter
+
+	if v := query.Get("status"); v != "" {
+		if !slices.Contains(models.ProjectStatuses, v) {
+			return filter, fmt.Errorf("status must be one of: %s", strings.Join(models.ProjectStatuses, ", "))
+		}
+		filter.status = v
+	}
+
+	filter.district = query.Get("district")
+
+	var err error
+	if filter.from, err = parseYearParam(query, "from"); err != nil {
+		return filter, err
+	}
+	if filter.to, err = parseYearParam(query, "to"); err != nil {
+		return filter, err
+	}
+	if filter.from != nil && filter.to != nil && *filter.from > *filter.to {
+		return filter, errors.New("from must not be after to")
+	}
+
+	return filter, nil
+}
+
+// parseYearParam reads an optional year parameter
+func parseYearParam(query url.Values, param string) (*int, error) {
+	v := query.Get(param)
+	if v == "" {
+		return nil, nil
+	}
+	year, err := strconv.Atoi(v)
+	if err != nil || year < 1900 || year > 2100 {
+		return nil, errors.New(param + " must be a year such as 2026")
+	}
+	return &year, nil
+}
+
+// matches reports whether a project with its timeline passes all filters
+func (f projectTimelineFilter) matches(project models.ConstructionProject, timeline models.ProjectTimeline) bool {
+	if f.status != "" && timeline.Status != f.status {
+		return false
+	}
+	if f.district != "" && !strings.EqualFold(project.District, f.district) {
+		return false
+	}
+	if f.from != nil || f.to != nil {
+		from, to := 0, 9999
+		if f.from != nil {
+			from = *f.from
+		}
+		if f.to != nil {
+			to = *f.to
+		}
+		if !timeline.Overlaps(from, to) {
+			return false
+		}
+	}
+	return true
+}
+
+// apply keeps the projects that pass all filters as of currentYear, reusing the slice
+func (f projectTimelineFilter) apply(projects []models.ConstructionProject, currentYear int) []models.ConstructionProject {
+	filtered := projects[:0]
+	for _, project := range projects {
+		if f.matches(project, project.Timeline(currentYear)) {
+			filtered = append(filtered, project)
+		}
+	}
+	return filtered
+}
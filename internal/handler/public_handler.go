@@ -0,0 +1,85 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"schools-be/internal/service"
+)
+
+// PublicHandler serves the open data endpoints under /public/v1, which need no API key
+type PublicHandler struct {
+	service *service.PublicService
+	maxAge  time.Duration
+	logger  *slog.Logger
+}
+
+// NewPublicHandler creates the handler; maxAge is how long clients and proxies may cache a response
+func NewPublicHandler(service *service.PublicService, maxAge time.Duration, logger *slog.Logger) *PublicHandler {
+	return &PublicHandler{
+		service: service,
+		maxAge:  maxAge,
+		logger:  logger.With(slog.String("handler", "public")),
+	}
+}
+
+// GetDistricts returns the number of schools and students per district
+func (h *PublicHandler) GetDistricts(w http.ResponseWriter, r *http.Request) {
+	districts, err := h.service.GetDistricts(r.Context())
+	if err != nil {
+		h.logger.ErrorContext(r.Context(), "failed to count schools per district", slog.String("error", err.Error()))
+		h.respondError(w, http.StatusInternalServerError, "failed to count schools per district")
+		return
+	}
+
+	h.respondCached(w, districts)
+}
+
+// GetFreshness returns the record count and last refresh of each dataset
+func (h *PublicHandler) GetFreshness(w http.ResponseWriter, r *http.Request) {
+	freshness, err := h.service.GetFreshness(r.Context())
+	if err != nil {
+		h.logger.ErrorContext(r.Context(), "failed to get dataset freshness", slog.String("error", err.Error()))
+		h.respondError(w, http.StatusInternalServerError, "failed to get dataset freshness")
+		return
+	}
+
+	h.respondCached(w, freshness)
+}
+
+// GetStats returns city-wide totals of schools, students, teachers and construction projects
+func (h *PublicHandler) GetStats(w http.ResponseWriter, r *http.Request) {
+	stats, err := h.service.GetStats(r.Context())
+	if err != nil {
+		h.logger.ErrorContext(r.Context(), "failed to compute public stats", slog.String("error", err.Error()))
+		h.respondError(w, http.StatusInternalServerError, "failed to compute public stats")
+		return
+	}
+
+	h.respondCached(w, stats)
+}
+
+// respondCached sends a successful response that shared caches may keep for maxAge
+func (h *PublicHandler) respondCached(w http.ResponseWriter, data interface{}) {
+	if h.maxAge > 0 {
+		w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(h.maxAge.Seconds())))
+	}
+	h.respondJSON(w, http.StatusOK, data)
+}
+
+// respondJSON sends a JSON response
+func (h *PublicHandler) respondJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(data); err != nil {
+		h.logger.Error("failed to encode response", slog.String("error", err.Error()))
+	}
+}
+
+// respondError sends an error JSON response
+func (h *PublicHandler) respondError(w http.ResponseWriter, status int, message string) {
+	h.respondJSON(w, status, map[string]string{"error": message})
+}
@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	apperrors "schools-be/internal/errors"
+	"schools-be/internal/models"
+	"schools-be/internal/service"
+
+	"github.com/go-playground/validator/v10"
+)
+
+type QueryHandler struct {
+	service  *service.QueryService
+	validate *validator.Validate
+	logger   *slog.Logger
+}
+
+func NewQueryHandler(service *service.QueryService, logger *slog.Logger) *QueryHandler {
+	return &QueryHandler{
+		service:  service,
+		validate: validator.New(),
+		logger:   logger.With(slog.String("handler", "query")),
+	}
+}
+
+// RunQuery executes a read-only SQL query against the dataset, e.g.
+// {"sql": "SELECT district, COUNT(*) FROM schools WHERE school_type = ? GROUP BY district", "params": ["Gymnasium"]}
+func (h *QueryHandler) RunQuery(w http.ResponseWriter, r *http.Request) {
+	var req models.QueryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if err := h.validate.Struct(req); err != nil {
+		h.respondError(w, http.StatusBadRequest, "sql is required")
+		return
+	}
+
+	result, err := h.service.Run(r.Context(), req)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrInvalidInput) {
+			h.respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		h.logger.ErrorContext(r.Context(), "failed to run query", slog.String("error", err.Error()))
+		h.respondError(w, http.StatusInternalServerError, "failed to run query")
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, result)
+}
+
+// respondJSON sends a JSON response
+func (h *QueryHandler) respondJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(data); err != nil {
+		h.logger.Error("failed to encode response", slog.String("error", err.Error()))
+	}
+}
+
+// respondError sends an error JSON response
+func (h *QueryHandler) respondError(w http.ResponseWriter, status int, message string) {
+	h.respondJSON(w, status, map[string]string{"error": message})
+}
@@ -0,0 +1,157 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"schools-be/internal/dto"
+	apperrors "schools-be/internal/errors"
+	"schools-be/internal/models"
+	"schools-be/internal/service"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type SchoolDetailHandler struct {
+	service *service.SchoolDetailService
+	logger  *slog.Logger
+}
+
+func NewSchoolDetailHandler(service *service.SchoolDetailService, logger *slog.Logger) *SchoolDetailHandler {
+	return &SchoolDetailHandler{
+		service: service,
+		logger:  logger.With(slog.String("handler", "school_detail")),
+	}
+}
+
+// GetAll returns the scraped portrait data of all schools with decoded statistic tables.
+// Query param available_after_4th_grade=true|false filters on schools starting at grade 5.
+func (h *SchoolDetailHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var details []models.SchoolDetail
+	var err error
+	if v := r.URL.Query().Get("available_after_4th_grade"); v != "" {
+		available, parseErr := strconv.ParseBool(v)
+		if parseErr != nil {
+			h.respondError(w, http.StatusBadRequest, "available_after_4th_grade must be true or false")
+			return
+		}
+		details, err = h.service.GetByAvailableAfter4thGrade(ctx, available)
+	} else {
+		details, err = h.service.GetAll(ctx)
+	}
+	if err != nil {
+		h.logger.ErrorContext(r.Context(), "failed to get school details", slog.String("error", err.Error()))
+		h.respondError(w, http.StatusInternalServerError, "failed to retrieve school details")
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, dto.NewSchoolDetailsWithTables(details))
+}
+
+// GetBySchoolNumber returns the scraped portrait data of one school by school number (BSN)
+func (h *SchoolDetailHandler) GetBySchoolNumber(w http.ResponseWriter, r *http.Request) {
+	bsn := chi.URLParam(r, "bsn")
+
+	detail, err := h.service.GetBySchoolNumber(r.Context(), bsn)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrNotFound) {
+			h.respondError(w, http.StatusNotFound, "school details not found")
+			return
+		}
+		h.logger.ErrorContext(r.Context(), "failed to get school details",
+			slog.String("school_number", bsn),
+			slog.String("error", err.Error()),
+		)
+		h.respondError(w, http.StatusInternalServerError, "failed to retrieve school details")
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, dto.NewSchoolDetailWithTables(*detail))
+}
+
+// GetRawTables returns the statistic tables of one school as stored by the scraper, for
+// checking the normalized numbers against the original ones
+func (h *SchoolDetailHandler) GetRawTables(w http.ResponseWriter, r *http.Request) {
+	bsn := chi.URLParam(r, "bsn")
+
+	detail, err := h.service.GetBySchoolNumber(r.Context(), bsn)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrNotFound) {
+			h.respondError(w, http.StatusNotFound, "school details not found")
+			return
+		}
+		h.logger.ErrorContext(r.Context(), "failed to get school details",
+			slog.String("school_number", bsn),
+			slog.String("error", err.Error()),
+		)
+		h.respondError(w, http.StatusInternalServerError, "failed to retrieve school details")
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, dto.NewRawStatisticTables(*detail))
+}
+
+// GetGradeStructure returns how many classes a school has per grade and school year, e.g. how
+// many 7th grade classes it opens; an empty list when the page publishes none
+func (h *SchoolDetailHandler) GetGradeStructure(w http.ResponseWriter, r *http.Request) {
+	bsn := chi.URLParam(r, "bsn")
+
+	stats, err := h.service.GetGradeStructure(r.Context(), bsn)
+	if err != nil {
+		h.logger.ErrorContext(r.Context(), "failed to get grade structure",
+			slog.String("school_number", bsn),
+			slog.String("error", err.Error()),
+		)
+		h.respondError(w, http.StatusInternalServerError, "failed to retrieve grade structure")
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, dto.NewGradeStructure(stats))
+}
+
+// GetPrograms returns the schools with a bilingual branch (such as a Staatliche Europa-Schule)
+// or religious education. Query params language=<models.BilingualLanguages> and
+// religion=<models.ReligiousEducations> keep the schools offering them.
+func (h *SchoolDetailHandler) GetPrograms(w http.ResponseWriter, r *http.Request) {
+	language := r.URL.Query().Get("language")
+	if language != "" && !slices.Contains(models.BilingualLanguages, language) {
+		h.respondError(w, http.StatusBadRequest, fmt.Sprintf("language must be one of: %s", strings.Join(models.BilingualLanguages, ", ")))
+		return
+	}
+	religion := r.URL.Query().Get("religion")
+	if religion != "" && !slices.Contains(models.ReligiousEducations, religion) {
+		h.respondError(w, http.StatusBadRequest, fmt.Sprintf("religion must be one of: %s", strings.Join(models.ReligiousEducations, ", ")))
+		return
+	}
+
+	details, err := h.service.GetPrograms(r.Context(), language, religion)
+	if err != nil {
+		h.logger.ErrorContext(r.Context(), "failed to get school programs", slog.String("error", err.Error()))
+		h.respondError(w, http.StatusInternalServerError, "failed to retrieve school programs")
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, dto.NewSchoolProgramsList(details))
+}
+
+// respondJSON sends a JSON response
+func (h *SchoolDetailHandler) respondJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(data); err != nil {
+		h.logger.Error("failed to encode response", slog.String("error", err.Error()))
+	}
+}
+
+// respondError sends an error JSON response
+func (h *SchoolDetailHandler) respondError(w http.ResponseWriter, status int, message string) {
+	h.respondJSON(w, status, map[string]string{"error": message})
+}
@@ -0,0 +1,117 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"schools-be/internal/models"
+)
+
+// schoolListFilter holds the filters of the schools list; the zero value keeps all schools
+type schoolListFilter struct {
+	fields          models.SchoolFilter // district, school_type, operator, postal_code, neighborhood: applied in SQL
+	hasConstruction *bool               // has_construction=true|false: with or without construction projects
+	operatorKind    string              // operator_kind=public|confessional|private_secular
+	supportFocus    string              // support_focus=<models.SupportFocuses>: schools supporting the focus area
+	accessible      *bool               // accessible=true|false: wheelchair accessible or not; unknown schools match neither
+	allDayType      string              // all_day=bound|open|none
+}
+
+// parseSchoolListFilter reads the filters from the query of a schools list request
+func parseSchoolListFilter(query url.Values) (schoolListFilter, error) {
+	var filter schoolListFilter
+
+	filter.fields = models.SchoolFilter{
+		District:     strings.TrimSpace(query.Get("district")),
+		SchoolType:   strings.TrimSpace(query.Get("school_type")),
+		Operator:     strings.TrimSpace(query.Get("operator")),
+		PostalCode:   strings.TrimSpace(query.Get("postal_code")),
+		Neighborhood: strings.TrimSpace(query.Get("neighborhood")),
+	}
+	if filter.fields.SchoolType == "" {
+		filter.fields.SchoolType = strings.TrimSpace(query.Get("type")) // Older name of school_type
+	}
+
+	if v := query.Get("has_construction"); v != "" {
+		parsed, err := strconv.ParseBool(v)
+		if err != nil {
+			return filter, errors.New("has_construction must be true or false")
+		}
+		filter.hasConstruction = &parsed
+	}
+
+	switch v := query.Get("operator_kind"); v {
+	case "", models.OperatorKindPublic, models.OperatorKindConfessional, models.OperatorKindPrivateSecular:
+		filter.operatorKind = v
+	default:
+		return filter, errors.New("operator_kind must be one of: public, confessional, private_secular")
+	}
+
+	if v := query.Get("accessible"); v != "" {
+		parsed, err := strconv.ParseBool(v)
+		if err != nil {
+			return filter, errors.New("accessible must be true or false")
+		}
+		filter.accessible = &parsed
+	}
+
+	if v := query.Get("all_day"); v != "" {
+		if !slices.Contains(models.AllDayTypes, v) {
+			return filter, fmt.Errorf("all_day must be one of: %s", strings.Join(models.AllDayTypes, ", "))
+		}
+		filter.allDayType = v
+	}
+
+	if v := query.Get("support_focus"); v != "" {
+		if !slices.Contains(models.SupportFocuses, v) {
+			return filter, fmt.Errorf("support_focus must be one of: %s", strings.Join(models.SupportFocuses, ", "))
+		}
+		filter.supportFocus = v
+	}
+
+	return filter, nil
+}
+
+// matches reports whether a school passes all filters
+func (f schoolListFilter) matches(school models.EnrichedSchool) bool {
+	if !f.fields.Matches(school.School) {
+		return false
+	}
+	if f.hasConstruction != nil && school.HasConstruction() != *f.hasConstruction {
+		return false
+	}
+	if f.operatorKind != "" && school.OperatorKind() != f.operatorKind {
+		return false
+	}
+	if f.accessible != nil {
+		want := models.AccessibilityNo
+		if *f.accessible {
+			want = models.AccessibilityYes
+		}
+		if school.Details == nil || school.Details.WheelchairAccessible != want {
+			return false
+		}
+	}
+	if f.allDayType != "" && school.AllDayType() != f.allDayType {
+		return false
+	}
+	if f.supportFocus != "" && (school.Details == nil || !school.Details.HasSupportFocus(f.supportFocus)) {
+		return false
+	}
+	return true
+}
+
+// apply keeps the schools that pass all filters, reusing the slice
+func (f schoolListFilter) apply(schools []models.EnrichedSchool) []models.EnrichedSchool {
+	filtered := schools[:0]
+	for _, school := range schools {
+		if f.matches(school) {
+			filtered = append(filtered, school)
+		}
+	}
+	return filtered
+}
@@ -1,49 +1,229 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
+	"sort"
 	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
 
+	"schools-be/internal/dto"
 	apperrors "schools-be/internal/errors"
+	"schools-be/internal/models"
 	"schools-be/internal/service"
+	"schools-be/internal/utils"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-playground/validator/v10"
 )
 
 type SchoolHandler struct {
-	service       *service.SchoolService
-	aiService     *service.AIService
-	routesService *service.RoutesService
-	validate      *validator.Validate
-	logger        *slog.Logger
+	service         *service.SchoolService
+	aiService       *service.AIService
+	routesService   *service.RoutesService
+	locationService *service.LocationService
+	pdfService      *service.PDFService
+	mapService      *service.MapService
+	templates       *Templates
+	validate        *validator.Validate
+	logger          *slog.Logger
 }
 
-func NewSchoolHandler(service *service.SchoolService, aiService *service.AIService, routesService *service.RoutesService) *SchoolHandler {
+func NewSchoolHandler(service *service.SchoolService, aiService *service.AIService, routesService *service.RoutesService, locationService *service.LocationService, pdfService *service.PDFService, mapService *service.MapService, templates *Templates, logger *slog.Logger) *SchoolHandler {
 	return &SchoolHandler{
-		service:       service,
-		aiService:     aiService,
-		routesService: routesService,
-		validate:      validator.New(),
-		logger:        slog.Default(),
+		service:         service,
+		aiService:       aiService,
+		routesService:   routesService,
+		locationService: locationService,
+		pdfService:      pdfService,
+		mapService:      mapService,
+		templates:       templates,
+		validate:        validator.New(),
+		logger:          logger.With(slog.String("handler", "school")),
 	}
 }
 
-// GetSchoolsEnriched returns all schools with enriched data from all related tables
+// GetSchoolsEnriched returns all schools with enriched data from all related tables.
+// Served from the materialized snapshot when available, otherwise enriched live.
+// With sort=commute the schools are ordered by travel time from a registered location,
+// with sort=name alphabetically by name using the collation of the Accept-Language language,
+// with sort=investment by the total costs of their construction projects, highest first.
+// The filters of schoolListFilter combine with every sort.
 func (h *SchoolHandler) GetSchoolsEnriched(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
+	query := r.URL.Query()
 
-	schools, err := h.service.GetAllSchoolsEnriched(ctx)
+	filter, err := parseSchoolListFilter(query)
 	if err != nil {
-		h.logger.Error("failed to get enriched schools", slog.String("error", err.Error()))
+		h.respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	switch query.Get("sort") {
+	case "":
+	case "commute":
+		h.getSchoolsByCommute(w, r, filter)
+		return
+	case "name":
+		h.getSchoolsSorted(w, r, filter, sortSchoolsByName)
+		return
+	case "investment":
+		h.getSchoolsSorted(w, r, filter, sortSchoolsByInvestment)
+		return
+	default:
+		h.respondError(w, http.StatusBadRequest, "sort must be one of: name, commute, investment")
+		return
+	}
+
+	builtAt, err := h.service.GetEnrichedSnapshotBuiltAt(ctx)
+	if err != nil {
+		h.logger.WarnContext(r.Context(), "failed to read enriched snapshot state", slog.String("error", err.Error()))
+	} else if builtAt != nil {
+		h.streamSchoolsEnriched(w, r, *builtAt, filter)
+		return
+	}
+
+	schools, err := h.service.FindSchoolsEnriched(ctx, filter.fields)
+	if err != nil {
+		h.logger.ErrorContext(r.Context(), "failed to get enriched schools", slog.String("error", err.Error()))
+		h.respondError(w, http.StatusInternalServerError, "failed to retrieve enriched schools")
+		return
+	}
+	schools = filter.apply(schools)
+
+	h.respondJSON(w, http.StatusOK, dto.NewEnrichedSchools(schools, requestLanguage(w, r)))
+}
+
+// streamSchoolsEnriched writes the materialized snapshot as a JSON array, one school at a time
+func (h *SchoolHandler) streamSchoolsEnriched(w http.ResponseWriter, r *http.Request, builtAt time.Time, filter schoolListFilter) {
+	lang := requestLanguage(w, r)
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Last-Modified", builtAt.UTC().Format(http.TimeFormat))
+	w.WriteHeader(http.StatusOK)
+
+	first := true
+	w.Write([]byte("["))
+	err := h.service.StreamMatchingSnapshot(r.Context(), filter.fields, func(school models.EnrichedSchool) error {
+		if !filter.matches(school) {
+			return nil
+		}
+		data, err := json.Marshal(dto.NewEnrichedSchool(school, lang))
+		if err != nil {
+			return err
+		}
+		if !first {
+			w.Write([]byte(","))
+		}
+		first = false
+		_, err = w.Write(data)
+		return err
+	})
+	w.Write([]byte("]\n"))
+
+	if err != nil {
+		// Headers are already sent, so the error can only be logged
+		h.logger.ErrorContext(r.Context(), "failed to stream enriched schools", slog.String("error", err.Error()))
+	}
+}
+
+// getSchoolsSorted lists enriched schools in the order of sortFn. The snapshot is stored in
+// ID order, so it is read completely and sorted rather than streamed.
+func (h *SchoolHandler) getSchoolsSorted(w http.ResponseWriter, r *http.Request, filter schoolListFilter, sortFn func([]models.EnrichedSchool, string)) {
+	ctx := r.Context()
+
+	var schools []models.EnrichedSchool
+	builtAt, err := h.service.GetEnrichedSnapshotBuiltAt(ctx)
+	if err != nil {
+		h.logger.WarnContext(r.Context(), "failed to read enriched snapshot state", slog.String("error", err.Error()))
+	}
+	if err == nil && builtAt != nil {
+		err = h.service.StreamMatchingSnapshot(ctx, filter.fields, func(school models.EnrichedSchool) error {
+			schools = append(schools, school)
+			return nil
+		})
+	} else {
+		schools, err = h.service.FindSchoolsEnriched(ctx, filter.fields)
+	}
+	if err != nil {
+		h.logger.ErrorContext(r.Context(), "failed to get enriched schools", slog.String("error", err.Error()))
 		h.respondError(w, http.StatusInternalServerError, "failed to retrieve enriched schools")
 		return
 	}
+	schools = filter.apply(schools)
+
+	lang := requestLanguage(w, r)
+	sortFn(schools, lang)
+
+	if builtAt != nil {
+		w.Header().Set("Last-Modified", builtAt.UTC().Format(http.TimeFormat))
+	}
+	h.respondJSON(w, http.StatusOK, dto.NewEnrichedSchools(schools, lang))
+}
+
+// sortSchoolsByInvestment orders schools by the total costs of their construction projects,
+// highest first; schools with equal investment keep their order
+func sortSchoolsByInvestment(schools []models.EnrichedSchool, _ string) {
+	investments := make(map[int64]float64, len(schools))
+	for _, school := range schools {
+		investments[school.School.ID] = school.ConstructionInvestment()
+	}
+	sort.SliceStable(schools, func(i, j int) bool {
+		return investments[schools[i].School.ID] > investments[schools[j].School.ID]
+	})
+}
+
+// getSchoolsByCommute lists schools ordered by commute time from the location token
+// (location query parameter or X-Location-Token header) for the given mode.
+// Responds 202 with the computation status while the commute times are not ready yet.
+func (h *SchoolHandler) getSchoolsByCommute(w http.ResponseWriter, r *http.Request, filter schoolListFilter) {
+	query := r.URL.Query()
+
+	token := query.Get("location")
+	if token == "" {
+		token = r.Header.Get("X-Location-Token")
+	}
+	if token == "" {
+		h.respondError(w, http.StatusBadRequest, "location token is required for sort=commute")
+		return
+	}
+
+	mode := query.Get("mode")
+	if mode == "" {
+		mode = "walking"
+	}
+
+	schools, status, err := h.locationService.GetSchoolsByCommute(r.Context(), token, mode)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrNotFound) {
+			h.respondError(w, http.StatusNotFound, "location or travel mode not found")
+			return
+		}
+		if errors.Is(err, apperrors.ErrInvalidInput) {
+			h.respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		h.logger.ErrorContext(r.Context(), "failed to get schools by commute", slog.String("error", err.Error()))
+		h.respondError(w, http.StatusInternalServerError, "failed to retrieve schools by commute")
+		return
+	}
+
+	switch status.Status {
+	case models.CommuteStatusPending:
+		h.respondJSON(w, http.StatusAccepted, status)
+		return
+	case models.CommuteStatusFailed:
+		// Serve the list without commute times rather than failing the page
+		w.Header().Set("Warning", `199 - "commute times unavailable, schools are not sorted by commute"`)
+	}
+	schools = filter.apply(schools)
 
-	h.respondJSON(w, http.StatusOK, schools)
+	h.respondJSON(w, http.StatusOK, dto.NewEnrichedSchools(schools, requestLanguage(w, r)))
 }
 
 // GetSchoolEnriched returns a single enriched school by ID
@@ -63,7 +243,7 @@ func (h *SchoolHandler) GetSchoolEnriched(w http.ResponseWriter, r *http.Request
 			h.respondError(w, http.StatusNotFound, "school not found")
 			return
 		}
-		h.logger.Error("failed to get enriched school",
+		h.logger.ErrorContext(r.Context(), "failed to get enriched school",
 			slog.Int64("id", id),
 			slog.String("error", err.Error()),
 		)
@@ -71,7 +251,8 @@ func (h *SchoolHandler) GetSchoolEnriched(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	h.respondJSON(w, http.StatusOK, school)
+	w.Header().Set("ETag", schoolETag(school.School.Version))
+	h.respondJSON(w, http.StatusOK, dto.NewEnrichedSchool(*school, requestLanguage(w, r)))
 }
 
 // GetSchoolSummary generates an AI summary for a school
@@ -98,7 +279,7 @@ func (h *SchoolHandler) GetSchoolSummary(w http.ResponseWriter, r *http.Request)
 			h.respondError(w, http.StatusNotFound, "school not found")
 			return
 		}
-		h.logger.Error("failed to get enriched school",
+		h.logger.ErrorContext(r.Context(), "failed to get enriched school",
 			slog.Int64("id", id),
 			slog.String("error", err.Error()),
 		)
@@ -109,7 +290,11 @@ func (h *SchoolHandler) GetSchoolSummary(w http.ResponseWriter, r *http.Request)
 	// Generate summary using AI
 	summary, err := h.aiService.GenerateSchoolSummary(ctx, school)
 	if err != nil {
-		h.logger.Error("failed to generate school summary",
+		if externalUnavailable(w, err) {
+			h.respondError(w, http.StatusServiceUnavailable, "AI service is temporarily unavailable")
+			return
+		}
+		h.logger.ErrorContext(r.Context(), "failed to generate school summary",
 			slog.Int64("id", id),
 			slog.String("error", err.Error()),
 		)
@@ -119,11 +304,316 @@ func (h *SchoolHandler) GetSchoolSummary(w http.ResponseWriter, r *http.Request)
 
 	h.respondJSON(w, http.StatusOK, map[string]interface{}{
 		"success":    true,
-		"summary":    summary,
+		"summary":    summary.Text,
 		"schoolName": school.School.Name,
+		"flagged":    !summary.Validation.Valid,
+		"validation": summary.Validation,
+		"stale":      summary.Stale,
 	})
 }
 
+// GetNearbySchools returns the schools of any type within radius_km (default 2, at most 50)
+// of lat/lng, nearest first, each with its distance_km
+func (h *SchoolHandler) GetNearbySchools(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	lat, err := strconv.ParseFloat(query.Get("lat"), 64)
+	if err != nil || !(lat >= -90 && lat <= 90) {
+		h.respondError(w, http.StatusBadRequest, "lat must be a latitude between -90 and 90")
+		return
+	}
+	lng, err := strconv.ParseFloat(query.Get("lng"), 64)
+	if err != nil || !(lng >= -180 && lng <= 180) {
+		h.respondError(w, http.StatusBadRequest, "lng must be a longitude between -180 and 180")
+		return
+	}
+
+	radiusKm := 2.0
+	if v := query.Get("radius_km"); v != "" {
+		radiusKm, err = strconv.ParseFloat(v, 64)
+		if err != nil || !(radiusKm > 0 && radiusKm <= 50) {
+			h.respondError(w, http.StatusBadRequest, "radius_km must be greater than 0 and at most 50")
+			return
+		}
+	}
+
+	limit := 50
+	if v := query.Get("limit"); v != "" {
+		limit, err = strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > 200 {
+			h.respondError(w, http.StatusBadRequest, "limit must be between 1 and 200")
+			return
+		}
+	}
+
+	nearby, err := h.service.GetNearbySchools(r.Context(), lat, lng, radiusKm, limit)
+	if err != nil {
+		h.logger.ErrorContext(r.Context(), "failed to get nearby schools", slog.String("error", err.Error()))
+		h.respondError(w, http.StatusInternalServerError, "failed to retrieve nearby schools")
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, dto.NewNearbySchools(nearby))
+}
+
+// SearchSchools returns the schools whose name, offerings, working groups or AI summary
+// contain all words of ?q=, best match first, with the matching text as snippet
+func (h *SchoolHandler) SearchSchools(w http.ResponseWriter, r *http.Request) {
+	text := strings.TrimSpace(r.URL.Query().Get("q"))
+	if text == "" {
+		h.respondError(w, http.StatusBadRequest, "q is required")
+		return
+	}
+	if utf8.RuneCountInString(text) > 200 {
+		h.respondError(w, http.StatusBadRequest, "q must be at most 200 characters")
+		return
+	}
+
+	limit := 20
+	if v := r.URL.Query().Get("limit"); v != "" {
+		var err error
+		limit, err = strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > 100 {
+			h.respondError(w, http.StatusBadRequest, "limit must be between 1 and 100")
+			return
+		}
+	}
+
+	results, err := h.service.SearchSchools(r.Context(), text, limit)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrInvalidInput) {
+			h.respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		h.logger.ErrorContext(r.Context(), "failed to search schools",
+			slog.String("q", text),
+			slog.String("error", err.Error()),
+		)
+		h.respondError(w, http.StatusInternalServerError, "failed to search schools")
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, dto.NewSchoolSearchResults(results))
+}
+
+// GetSimilarSchools returns the nearest schools of the same type as alternatives for the detail page.
+// Query params: limit (default 5, max 20) and radius_km (default 10, max 50).
+func (h *SchoolHandler) GetSimilarSchools(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	idStr := chi.URLParam(r, "id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		h.respondError(w, http.StatusBadRequest, "invalid school id")
+		return
+	}
+
+	limit := 5
+	if v := r.URL.Query().Get("limit"); v != "" {
+		limit, err = strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > 20 {
+			h.respondError(w, http.StatusBadRequest, "limit must be between 1 and 20")
+			return
+		}
+	}
+
+	radiusKm := 10.0
+	if v := r.URL.Query().Get("radius_km"); v != "" {
+		radiusKm, err = strconv.ParseFloat(v, 64)
+		if err != nil || radiusKm <= 0 || radiusKm > 50 {
+			h.respondError(w, http.StatusBadRequest, "radius_km must be greater than 0 and at most 50")
+			return
+		}
+	}
+
+	similar, err := h.service.GetSimilarSchools(ctx, id, limit, radiusKm)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrNotFound) {
+			h.respondError(w, http.StatusNotFound, "school not found")
+			return
+		}
+		if errors.Is(err, apperrors.ErrInvalidInput) {
+			h.respondError(w, http.StatusUnprocessableEntity, "school has no coordinates")
+			return
+		}
+		h.logger.ErrorContext(r.Context(), "failed to get similar schools",
+			slog.Int64("id", id),
+			slog.String("error", err.Error()),
+		)
+		h.respondError(w, http.StatusInternalServerError, "failed to retrieve similar schools")
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, dto.NewSimilarSchools(similar))
+}
+
+// AskSchool answers a question about a single school from its enriched data, with citations
+func (h *SchoolHandler) AskSchool(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	idStr := chi.URLParam(r, "id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		h.respondError(w, http.StatusBadRequest, "invalid school id")
+		return
+	}
+
+	// Check if AI service is available
+	if h.aiService == nil {
+		h.respondError(w, http.StatusServiceUnavailable, "AI service is not available")
+		return
+	}
+
+	var req service.AskRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if err := h.validate.Struct(req); err != nil {
+		h.respondError(w, http.StatusBadRequest, "question is required and must be at most 500 characters")
+		return
+	}
+
+	school, err := h.service.GetSchoolByIDEnriched(ctx, id)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrNotFound) {
+			h.respondError(w, http.StatusNotFound, "school not found")
+			return
+		}
+		h.logger.ErrorContext(r.Context(), "failed to get enriched school",
+			slog.Int64("id", id),
+			slog.String("error", err.Error()),
+		)
+		h.respondError(w, http.StatusInternalServerError, "failed to retrieve school data")
+		return
+	}
+
+	answer, err := h.aiService.AnswerSchoolQuestion(ctx, school, req.Question)
+	if err != nil {
+		if externalUnavailable(w, err) {
+			h.respondError(w, http.StatusServiceUnavailable, "AI service is temporarily unavailable")
+			return
+		}
+		h.logger.ErrorContext(r.Context(), "failed to answer school question",
+			slog.Int64("id", id),
+			slog.String("error", err.Error()),
+		)
+		h.respondError(w, http.StatusInternalServerError, "failed to answer question")
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, answer)
+}
+
+// updateSchoolRequest is the body of UpdateSchool: the fields to change plus, unless an
+// If-Match header is sent, the version the edit is based on
+type updateSchoolRequest struct {
+	Version *int `json:"version,omitempty"`
+	models.UpdateSchoolInput
+}
+
+// UpdateSchool changes fields of a school. The edit must name the version it is based on
+// (If-Match: "<version>" from the ETag, or "version" in the body); 409 if the school
+// changed since, 428 if no version is given.
+func (h *SchoolHandler) UpdateSchool(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		h.respondError(w, http.StatusBadRequest, "invalid school id")
+		return
+	}
+
+	var req updateSchoolRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if err := h.validate.Struct(req); err != nil {
+		h.respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	version := 0
+	if ifMatch := r.Header.Get("If-Match"); ifMatch != "" {
+		version, err = parseSchoolETag(ifMatch)
+		if err != nil {
+			h.respondError(w, http.StatusBadRequest, "If-Match must be a school ETag such as \"3\"")
+			return
+		}
+	} else if req.Version != nil {
+		version = *req.Version
+	}
+	if version <= 0 {
+		h.respondError(w, http.StatusPreconditionRequired, "If-Match header or version field is required")
+		return
+	}
+
+	school, err := h.service.UpdateSchool(r.Context(), id, req.UpdateSchoolInput, version)
+	if err != nil {
+		switch {
+		case errors.Is(err, apperrors.ErrNotFound):
+			h.respondError(w, http.StatusNotFound, "school not found")
+		case errors.Is(err, apperrors.ErrConflict):
+			h.respondError(w, http.StatusConflict, err.Error())
+		case errors.Is(err, apperrors.ErrInvalidInput):
+			h.respondError(w, http.StatusBadRequest, err.Error())
+		default:
+			h.logger.ErrorContext(r.Context(), "failed to update school",
+				slog.Int64("id", id),
+				slog.String("error", err.Error()),
+			)
+			h.respondError(w, http.StatusInternalServerError, "failed to update school")
+		}
+		return
+	}
+
+	w.Header().Set("ETag", schoolETag(school.Version))
+	h.respondJSON(w, http.StatusOK, dto.NewSchool(*school))
+}
+
+// schoolETag formats a school version as a strong ETag
+func schoolETag(version int) string {
+	return strconv.Quote(strconv.Itoa(version))
+}
+
+// parseSchoolETag reads the version from an If-Match value ("3", W/"3" or 3)
+func parseSchoolETag(value string) (int, error) {
+	value = strings.TrimPrefix(strings.TrimSpace(value), "W/")
+	return strconv.Atoi(strings.Trim(value, `"`))
+}
+
+// BatchUpdateSchools applies a list of {school_number, fields...} corrections in one transaction,
+// e.g. [{"school_number": "01B01", "phone": "030 1234567"}]. Either all updates apply or none.
+// An update may carry a "version"; if that school changed since, the batch fails with 409.
+func (h *SchoolHandler) BatchUpdateSchools(w http.ResponseWriter, r *http.Request) {
+	var updates []models.SchoolBatchUpdate
+	if err := json.NewDecoder(r.Body).Decode(&updates); err != nil {
+		h.respondError(w, http.StatusBadRequest, "request body must be an array of school updates")
+		return
+	}
+	for i, update := range updates {
+		if err := h.validate.Struct(update); err != nil {
+			h.respondError(w, http.StatusBadRequest, fmt.Sprintf("invalid update at index %d: %s", i, err.Error()))
+			return
+		}
+	}
+
+	if err := h.service.BatchUpdateSchools(r.Context(), updates); err != nil {
+		switch {
+		case errors.Is(err, apperrors.ErrInvalidInput):
+			h.respondError(w, http.StatusBadRequest, err.Error())
+		case errors.Is(err, apperrors.ErrNotFound):
+			h.respondError(w, http.StatusNotFound, err.Error())
+		case errors.Is(err, apperrors.ErrConflict):
+			h.respondError(w, http.StatusConflict, err.Error())
+		default:
+			h.logger.ErrorContext(r.Context(), "failed to apply school batch update", slog.String("error", err.Error()))
+			h.respondError(w, http.StatusInternalServerError, "failed to update schools")
+		}
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, map[string]int{"updated": len(updates)})
+}
+
 // CalculateRoutes calculates travel times from a location to a school
 func (h *SchoolHandler) CalculateRoutes(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
@@ -160,9 +650,9 @@ func (h *SchoolHandler) CalculateRoutes(w http.ResponseWriter, r *http.Request)
 	}
 
 	// Calculate travel times
-	results, err := h.routesService.CalculateTravelTimes(ctx, req)
+	results, err := h.routesService.CalculateTravelTimes(ctx, h.routeSchoolID(ctx, id, req.End), req)
 	if err != nil {
-		h.logger.Error("failed to calculate travel times",
+		h.logger.ErrorContext(r.Context(), "failed to calculate travel times",
 			slog.Int64("schoolId", id),
 			slog.String("error", err.Error()),
 		)
@@ -170,9 +660,33 @@ func (h *SchoolHandler) CalculateRoutes(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	h.respondJSON(w, http.StatusOK, map[string]interface{}{
+	response := map[string]interface{}{
 		"results": results,
-	})
+	}
+	if !h.routesService.Available() {
+		response["warning"] = "routing service is temporarily unavailable, only cached travel times are included"
+	}
+	h.respondJSON(w, http.StatusOK, response)
+}
+
+// routeSchoolID returns the school ID when the route ends at that school, so the result
+// can be cached per school; routes to arbitrary coordinates return 0 and are not cached
+func (h *SchoolHandler) routeSchoolID(ctx context.Context, id int64, end [2]float64) int64 {
+	if id <= 0 {
+		return 0
+	}
+
+	school, err := h.service.GetSchoolByID(ctx, id)
+	if err != nil {
+		return 0
+	}
+
+	lat, lon, ok := school.Coordinates()
+	if !ok || utils.DistanceKm(lat, lon, end[1], end[0]) > 0.1 {
+		return 0
+	}
+
+	return id
 }
 
 // Helper functions for JSON responses
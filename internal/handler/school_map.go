@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	apperrors "schools-be/internal/errors"
+	"schools-be/internal/service"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// Default static map thumbnail, sized for link previews
+const (
+	defaultMapZoom   = 15
+	defaultMapWidth  = 600
+	defaultMapHeight = 315
+)
+
+// GetSchoolMap returns a static PNG map centred on the school with a marker.
+// Query params: zoom (10-18, default 15), width and height in pixels (default 600x315).
+func (h *SchoolHandler) GetSchoolMap(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		h.respondError(w, http.StatusBadRequest, "invalid school id")
+		return
+	}
+
+	opts := service.MapOptions{Zoom: defaultMapZoom, Width: defaultMapWidth, Height: defaultMapHeight}
+	query := r.URL.Query()
+	for param, target := range map[string]*int{"zoom": &opts.Zoom, "width": &opts.Width, "height": &opts.Height} {
+		if v := query.Get(param); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				h.respondError(w, http.StatusBadRequest, param+" must be a number")
+				return
+			}
+			*target = n
+		}
+	}
+	if err := opts.Validate(); err != nil {
+		h.respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	school, err := h.service.GetSchoolByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrNotFound) {
+			h.respondError(w, http.StatusNotFound, "school not found")
+			return
+		}
+		h.logger.ErrorContext(ctx, "failed to get school", slog.Int64("id", id), slog.String("error", err.Error()))
+		h.respondError(w, http.StatusInternalServerError, "failed to retrieve school")
+		return
+	}
+
+	lat, lon, ok := school.Coordinates()
+	if !ok {
+		h.respondError(w, http.StatusNotFound, "school has no known location")
+		return
+	}
+
+	img, err := h.mapService.RenderMap(ctx, lat, lon, opts)
+	if err != nil {
+		h.logger.ErrorContext(ctx, "failed to render school map", slog.Int64("id", id), slog.String("error", err.Error()))
+		h.respondError(w, http.StatusBadGateway, "failed to render map")
+		return
+	}
+
+	w.Header().Set("Content-Type", "image/png")
+	w.Header().Set("Cache-Control", "public, max-age=86400")
+	w.Header().Set("Content-Length", strconv.Itoa(len(img)))
+	w.WriteHeader(http.StatusOK)
+	w.Write(img)
+}
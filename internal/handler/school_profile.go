@@ -0,0 +1,107 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"schools-be/internal/dto"
+	apperrors "schools-be/internal/errors"
+	"schools-be/internal/service"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// schoolProfile is the data rendered into the printable school profile
+type schoolProfile struct {
+	Language       string
+	School         dto.EnrichedSchool
+	Summary        string
+	SummaryFlagged bool
+	Map            template.URL // PNG data URI, empty when the school has no location or the map failed
+	MapAttribution string
+	GeneratedAt    time.Time
+}
+
+// GetSchoolProfilePDF renders the enriched school data and, when the AI service is available,
+// its summary into a printable PDF with a location map. A failing summary or map is left out
+// rather than failing the PDF.
+func (h *SchoolHandler) GetSchoolProfilePDF(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		h.respondError(w, http.StatusBadRequest, "invalid school id")
+		return
+	}
+
+	school, err := h.service.GetSchoolByIDEnriched(ctx, id)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrNotFound) {
+			h.respondError(w, http.StatusNotFound, "school not found")
+			return
+		}
+		h.logger.ErrorContext(ctx, "failed to get enriched school",
+			slog.Int64("id", id),
+			slog.String("error", err.Error()),
+		)
+		h.respondError(w, http.StatusInternalServerError, "failed to retrieve school data")
+		return
+	}
+
+	profile := schoolProfile{GeneratedAt: time.Now()}
+	if h.aiService != nil {
+		summary, err := h.aiService.GenerateSchoolSummary(ctx, school)
+		if err != nil {
+			h.logger.WarnContext(ctx, "profile rendered without summary",
+				slog.Int64("id", id),
+				slog.String("error", err.Error()),
+			)
+		} else {
+			profile.Summary = summary.Text
+			profile.SummaryFlagged = !summary.Validation.Valid
+		}
+	}
+
+	if lat, lon, ok := school.School.Coordinates(); ok {
+		img, err := h.mapService.RenderMap(ctx, lat, lon, service.MapOptions{Zoom: defaultMapZoom, Width: 800, Height: 300})
+		if err != nil {
+			h.logger.WarnContext(ctx, "profile rendered without map",
+				slog.Int64("id", id),
+				slog.String("error", err.Error()),
+			)
+		} else {
+			profile.Map = template.URL("data:image/png;base64," + base64.StdEncoding.EncodeToString(img))
+			profile.MapAttribution = service.MapAttribution
+		}
+	}
+
+	profile.Language = requestLanguage(w, r)
+	profile.School = dto.NewEnrichedSchool(*school, profile.Language)
+
+	var html bytes.Buffer
+	if err := h.templates.profile.Execute(&html, profile); err != nil {
+		h.logger.ErrorContext(ctx, "failed to render school profile", slog.Int64("id", id), slog.String("error", err.Error()))
+		h.respondError(w, http.StatusInternalServerError, "failed to render profile")
+		return
+	}
+
+	pdf, err := h.pdfService.RenderHTML(ctx, html.String())
+	if err != nil {
+		h.logger.ErrorContext(ctx, "failed to print school profile", slog.Int64("id", id), slog.String("error", err.Error()))
+		h.respondError(w, http.StatusInternalServerError, "failed to render profile")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`inline; filename="school-%s.pdf"`, school.School.SchoolNumber))
+	w.Header().Set("Content-Length", strconv.Itoa(len(pdf)))
+	w.WriteHeader(http.StatusOK)
+	w.Write(pdf)
+}
@@ -0,0 +1,148 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"schools-be/internal/dto"
+	apperrors "schools-be/internal/errors"
+	"schools-be/internal/models"
+	"schools-be/internal/service"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type StatisticHandler struct {
+	service *service.StatisticService
+	logger  *slog.Logger
+}
+
+func NewStatisticHandler(service *service.StatisticService, logger *slog.Logger) *StatisticHandler {
+	return &StatisticHandler{
+		service: service,
+		logger:  logger.With(slog.String("handler", "statistic")),
+	}
+}
+
+// GetAll returns the scraped student, teacher and class counts of all schools.
+// Query params: school_year (exact), from_year and to_year (inclusive range), e.g. "2024/25".
+func (h *StatisticHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	h.respondStatistics(w, r, statisticFilter(r))
+}
+
+// GetBySchool returns the statistics of one school by school number (BSN), newest year first.
+// Accepts the same year filters as GetAll.
+func (h *StatisticHandler) GetBySchool(w http.ResponseWriter, r *http.Request) {
+	filter := statisticFilter(r)
+	filter.SchoolNumber = chi.URLParam(r, "bsn")
+	h.respondStatistics(w, r, filter)
+}
+
+// GetSummary returns the number of statistic rows per school year and the latest scrape time
+func (h *StatisticHandler) GetSummary(w http.ResponseWriter, r *http.Request) {
+	summary, err := h.service.GetStatisticsSummary(r.Context())
+	if err != nil {
+		h.logger.ErrorContext(r.Context(), "failed to get statistics summary", slog.String("error", err.Error()))
+		h.respondError(w, http.StatusInternalServerError, "failed to retrieve statistics summary")
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, summary)
+}
+
+// GetStaffing returns the staffing coverage (Ausstattung in %) and teachers by qualification of
+// all schools whose page has a personnel section
+func (h *StatisticHandler) GetStaffing(w http.ResponseWriter, r *http.Request) {
+	stats, err := h.service.GetAllStaffing(r.Context())
+	if err != nil {
+		h.logger.ErrorContext(r.Context(), "failed to get staffing", slog.String("error", err.Error()))
+		h.respondError(w, http.StatusInternalServerError, "failed to retrieve staffing")
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, dto.NewStaffingList(stats))
+}
+
+// GetStaffingBySchool returns the staffing of one school by school number (BSN); 404 when its
+// page has no personnel section
+func (h *StatisticHandler) GetStaffingBySchool(w http.ResponseWriter, r *http.Request) {
+	bsn := chi.URLParam(r, "bsn")
+
+	stat, err := h.service.GetStaffing(r.Context(), bsn)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrNotFound) {
+			h.respondError(w, http.StatusNotFound, "staffing not found")
+			return
+		}
+		h.logger.ErrorContext(r.Context(), "failed to get staffing",
+			slog.String("school_number", bsn),
+			slog.String("error", err.Error()),
+		)
+		h.respondError(w, http.StatusInternalServerError, "failed to retrieve staffing")
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, dto.NewStaffing(*stat))
+}
+
+// GetQualityBySchool returns the quality framework ratings of one school by school number (BSN);
+// 404 when its page publishes no quality profile
+func (h *StatisticHandler) GetQualityBySchool(w http.ResponseWriter, r *http.Request) {
+	bsn := chi.URLParam(r, "bsn")
+
+	indicators, err := h.service.GetQuality(r.Context(), bsn)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrNotFound) {
+			h.respondError(w, http.StatusNotFound, "quality profile not found")
+			return
+		}
+		h.logger.ErrorContext(r.Context(), "failed to get quality profile",
+			slog.String("school_number", bsn),
+			slog.String("error", err.Error()),
+		)
+		h.respondError(w, http.StatusInternalServerError, "failed to retrieve quality profile")
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, dto.NewQualityProfile(indicators[0].SchoolNumber, indicators))
+}
+
+func (h *StatisticHandler) respondStatistics(w http.ResponseWriter, r *http.Request, filter models.StatisticFilter) {
+	statistics, err := h.service.FindStatistics(r.Context(), filter)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrInvalidInput) {
+			h.respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		h.logger.ErrorContext(r.Context(), "failed to get statistics", slog.String("error", err.Error()))
+		h.respondError(w, http.StatusInternalServerError, "failed to retrieve statistics")
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, dto.NewStatistics(statistics))
+}
+
+func statisticFilter(r *http.Request) models.StatisticFilter {
+	query := r.URL.Query()
+	return models.StatisticFilter{
+		SchoolYear: query.Get("school_year"),
+		FromYear:   query.Get("from_year"),
+		ToYear:     query.Get("to_year"),
+	}
+}
+
+// respondJSON sends a JSON response
+func (h *StatisticHandler) respondJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(data); err != nil {
+		h.logger.Error("failed to encode response", slog.String("error", err.Error()))
+	}
+}
+
+// respondError sends an error JSON response
+func (h *StatisticHandler) respondError(w http.ResponseWriter, status int, message string) {
+	h.respondJSON(w, status, map[string]string{"error": message})
+}
@@ -0,0 +1,66 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	apperrors "schools-be/internal/errors"
+	"schools-be/internal/service"
+)
+
+type SyncHandler struct {
+	service *service.SyncService
+	logger  *slog.Logger
+}
+
+func NewSyncHandler(service *service.SyncService, logger *slog.Logger) *SyncHandler {
+	return &SyncHandler{
+		service: service,
+		logger:  logger.With(slog.String("handler", "sync")),
+	}
+}
+
+// GetChanges returns records created, updated and deleted since a checkpoint, so offline
+// clients can keep a copy of the dataset. Query param since is an RFC 3339 timestamp,
+// normally the checkpoint of the previous response; without it the full dataset is returned.
+func (h *SyncHandler) GetChanges(w http.ResponseWriter, r *http.Request) {
+	var since *time.Time
+	if v := r.URL.Query().Get("since"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			h.respondError(w, http.StatusBadRequest, "since must be an RFC 3339 timestamp")
+			return
+		}
+		since = &t
+	}
+
+	delta, err := h.service.GetChanges(r.Context(), since)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrInvalidInput) {
+			h.respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		h.logger.ErrorContext(r.Context(), "failed to get sync changes", slog.String("error", err.Error()))
+		h.respondError(w, http.StatusInternalServerError, "failed to retrieve changes")
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, delta)
+}
+
+// respondJSON sends a JSON response
+func (h *SyncHandler) respondJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(data); err != nil {
+		h.logger.Error("failed to encode response", slog.String("error", err.Error()))
+	}
+}
+
+// respondError sends an error JSON response
+func (h *SyncHandler) respondError(w http.ResponseWriter, status int, message string) {
+	h.respondJSON(w, status, map[string]string{"error": message})
+}
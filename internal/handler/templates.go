@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+//go:embed templates/*.html
+var embeddedTemplates embed.FS
+
+// Templates holds the server-rendered HTML pages: the admin dashboard and the printable
+// school profile
+type Templates struct {
+	admin   *template.Template
+	profile *template.Template
+}
+
+var templateFuncs = template.FuncMap{
+	"formatTime": func(v interface{}) string {
+		switch t := v.(type) {
+		case time.Time:
+			return t.Format("2006-01-02 15:04:05 MST")
+		case *time.Time:
+			if t != nil {
+				return t.Format("2006-01-02 15:04:05 MST")
+			}
+		}
+		return "–"
+	},
+	"ratio": func(v *float64) string {
+		if v == nil {
+			return "–"
+		}
+		return strconv.FormatFloat(*v, 'f', 2, 64)
+	},
+}
+
+// LoadTemplates parses admin.html and school_profile.html. A file found in dir (TEMPLATE_DIR)
+// replaces the one compiled into the binary, so pages can be restyled without recompiling;
+// with an empty dir or a file missing from it, the built-in template is used.
+func LoadTemplates(dir string) (*Templates, error) {
+	admin, err := parseTemplate(dir, "admin.html")
+	if err != nil {
+		return nil, err
+	}
+	profile, err := parseTemplate(dir, "school_profile.html")
+	if err != nil {
+		return nil, err
+	}
+	return &Templates{admin: admin, profile: profile}, nil
+}
+
+func parseTemplate(dir, name string) (*template.Template, error) {
+	content, err := fs.ReadFile(embeddedTemplates, "templates/"+name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read embedded template %s: %w", name, err)
+	}
+	if dir != "" {
+		override, err := os.ReadFile(filepath.Join(dir, name))
+		switch {
+		case err == nil:
+			content = override
+		case !errors.Is(err, fs.ErrNotExist):
+			return nil, fmt.Errorf("failed to read template %s: %w", name, err)
+		}
+	}
+
+	tmpl, err := template.New(name).Funcs(templateFuncs).Parse(string(content))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template %s: %w", name, err)
+	}
+	return tmpl, nil
+}
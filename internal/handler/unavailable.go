@@ -0,0 +1,20 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"schools-be/internal/breaker"
+)
+
+// externalUnavailable reports whether err comes from an external API whose circuit breaker
+// is open; if so it sets Retry-After, and the caller should answer 503 instead of 500
+func externalUnavailable(w http.ResponseWriter, err error) bool {
+	var open *breaker.OpenError
+	if !errors.As(err, &open) {
+		return false
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(int(open.RetryAfter.Seconds())+1))
+	return true
+}
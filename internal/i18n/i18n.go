@@ -0,0 +1,147 @@
+package i18n
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/text/collate"
+	"golang.org/x/text/language"
+)
+
+// Supported languages
+const (
+	German  = "de"
+	English = "en"
+)
+
+// DefaultLanguage is the language of the source data (Berlin open data is published in German)
+const DefaultLanguage = German
+
+// catalog maps a language to its translations, keyed by the lower-cased German source label
+var catalog = map[string]map[string]string{
+	English: {
+		// Operators (Traeger)
+		"öffentlich": "public",
+		"privat":     "private",
+
+		// School types (Schulart) and categories (Schultyp)
+		"grundschule":                "primary school",
+		"gymnasium":                  "grammar school (Gymnasium)",
+		"integrierte sekundarschule": "integrated secondary school",
+		"gemeinschaftsschule":        "community school",
+		"förderschule":               "special needs school",
+		"schule mit sonderpäd. förderschwerpunkt": "special needs school",
+		"berufsschule":              "vocational school",
+		"berufsfachschule":          "vocational college",
+		"berufliche schule":         "vocational school",
+		"berufliches gymnasium":     "vocational grammar school",
+		"fachoberschule":            "technical secondary school",
+		"fachschule":                "technical college",
+		"oberstufenzentrum":         "upper secondary vocational centre",
+		"kolleg":                    "college for adult education",
+		"abendgymnasium":            "evening grammar school",
+		"freie waldorfschule":       "Waldorf school",
+		"allgemein bildende schule": "general education school",
+		"berufsbildende schule":     "vocational education school",
+		"zweiter bildungsweg":       "second-chance education",
+	},
+}
+
+// Label returns the translation of a source label for the given language.
+// Unknown labels and the default language return the source label unchanged.
+func Label(lang, label string) string {
+	if lang == DefaultLanguage || label == "" {
+		return label
+	}
+
+	translations, ok := catalog[lang]
+	if !ok {
+		return label
+	}
+
+	if translated, ok := translations[strings.ToLower(strings.TrimSpace(label))]; ok {
+		return translated
+	}
+
+	return label
+}
+
+// IsSourceLabel reports whether a source label, such as a school type, has translations
+func IsSourceLabel(label string) bool {
+	_, ok := catalog[English][strings.ToLower(strings.TrimSpace(label))]
+	return ok
+}
+
+// IsSupported reports whether a language has a catalog (or is the source language)
+func IsSupported(lang string) bool {
+	if lang == DefaultLanguage {
+		return true
+	}
+	_, ok := catalog[lang]
+	return ok
+}
+
+// ParseAcceptLanguage picks the best supported language from an Accept-Language header,
+// honoring quality values (e.g. "en-US,en;q=0.9,de;q=0.8"). Falls back to DefaultLanguage.
+func ParseAcceptLanguage(header string) string {
+	if header == "" {
+		return DefaultLanguage
+	}
+
+	type candidate struct {
+		lang    string
+		quality float64
+	}
+
+	var candidates []candidate
+	for _, part := range strings.Split(header, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		tag := part
+		quality := 1.0
+		if idx := strings.Index(part, ";"); idx >= 0 {
+			tag = strings.TrimSpace(part[:idx])
+			params := strings.TrimSpace(part[idx+1:])
+			if strings.HasPrefix(params, "q=") {
+				if q, err := strconv.ParseFloat(strings.TrimPrefix(params, "q="), 64); err == nil {
+					quality = q
+				}
+			}
+		}
+
+		// Reduce region subtags ("en-US" -> "en")
+		if idx := strings.IndexAny(tag, "-_"); idx >= 0 {
+			tag = tag[:idx]
+		}
+		tag = strings.ToLower(tag)
+
+		if quality > 0 && IsSupported(tag) {
+			candidates = append(candidates, candidate{lang: tag, quality: quality})
+		}
+	}
+
+	if len(candidates) == 0 {
+		return DefaultLanguage
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].quality > candidates[j].quality
+	})
+
+	return candidates[0].lang
+}
+
+// NewCollator returns a case-insensitive collator for alphabetical sorting in the given
+// language, so umlauts sort with their base letter ("Ärztehaus" before "Berg") instead of
+// after "Z" as in byte order. Collators are not safe for concurrent use.
+func NewCollator(lang string) *collate.Collator {
+	tag, err := language.Parse(lang)
+	if err != nil || !IsSupported(lang) {
+		tag = language.German
+	}
+	return collate.New(tag, collate.IgnoreCase)
+}
@@ -0,0 +1,87 @@
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+	"sync/atomic"
+)
+
+// Log output formats (LOG_FORMAT)
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+)
+
+// Levels decides which records are written: everything from the application level up, and
+// additionally debug records of loggers selected by an attribute (LOG_DEBUG). A selector is
+// an attribute key, such as "scraper", or key=value, such as "scraper=details", matched
+// against the attributes a logger was created With. Both can change while the process runs.
+type Levels struct {
+	level slog.LevelVar
+	debug atomic.Pointer[[]string]
+}
+
+func NewLevels(level slog.Level, debug []string) *Levels {
+	l := &Levels{}
+	l.Set(level, debug)
+	return l
+}
+
+// Set replaces the application level and the debug selectors
+func (l *Levels) Set(level slog.Level, debug []string) {
+	l.level.Set(level)
+	l.debug.Store(&debug)
+}
+
+// selected reports whether a logger with attrs is selected for debug output
+func (l *Levels) selected(attrs []slog.Attr) bool {
+	for _, selector := range *l.debug.Load() {
+		key, value, hasValue := strings.Cut(selector, "=")
+		for _, a := range attrs {
+			if a.Key == key && (!hasValue || a.Value.String() == value) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// NewOutput returns the handler writing the log to w as JSON or, with FormatText, as
+// key=value text, filtered by levels
+func NewOutput(w io.Writer, format string, levels *Levels) slog.Handler {
+	// The writing handler accepts everything; levelHandler decides
+	opts := &slog.HandlerOptions{Level: slog.LevelDebug}
+	if format == FormatText {
+		return &levelHandler{next: slog.NewTextHandler(w, opts), levels: levels}
+	}
+	return &levelHandler{next: slog.NewJSONHandler(w, opts), levels: levels}
+}
+
+// levelHandler applies Levels; it remembers the attributes of its logger to match the debug
+// selectors
+type levelHandler struct {
+	next   slog.Handler
+	levels *Levels
+	attrs  []slog.Attr
+}
+
+func (h *levelHandler) Enabled(_ context.Context, level slog.Level) bool {
+	if level >= h.levels.level.Level() {
+		return true
+	}
+	return level >= slog.LevelDebug && len(h.attrs) > 0 && h.levels.selected(h.attrs)
+}
+
+func (h *levelHandler) Handle(ctx context.Context, record slog.Record) error {
+	return h.next.Handle(ctx, record)
+}
+
+func (h *levelHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &levelHandler{next: h.next.WithAttrs(attrs), levels: h.levels, attrs: append(h.attrs[:len(h.attrs):len(h.attrs)], attrs...)}
+}
+
+func (h *levelHandler) WithGroup(name string) slog.Handler {
+	return &levelHandler{next: h.next.WithGroup(name), levels: h.levels, attrs: h.attrs}
+}
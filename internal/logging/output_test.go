@@ -0,0 +1,65 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestOutputDebugSelectors(t *testing.T) {
+	tests := []struct {
+		name    string
+		level   slog.Level
+		debug   []string
+		logged  []string
+		dropped []string
+	}{
+		{"level only", slog.LevelInfo, nil, []string{"root info", "details info"}, []string{"root debug", "details debug", "statistics debug"}},
+		{"by key", slog.LevelInfo, []string{"scraper"}, []string{"details debug", "statistics debug"}, []string{"root debug"}},
+		{"by key and value", slog.LevelInfo, []string{"scraper=details"}, []string{"details debug"}, []string{"root debug", "statistics debug"}},
+		{"high level", slog.LevelError, []string{"scraper=details"}, []string{"details debug"}, []string{"root info"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			// Wrapped like the API server does, so attributes reach the output through the other handlers
+			root := slog.New(NewContextHandler(NewHandler(NewOutput(&buf, FormatText, NewLevels(tt.level, tt.debug)), NewRedactor([]string{RedactEmails}))))
+			details := root.With(slog.String("scraper", "details"))
+			statistics := root.With("scraper", "statistics").WithGroup("page")
+
+			root.Debug("root debug")
+			root.Info("root info")
+			details.Debug("details debug")
+			details.InfoContext(context.Background(), "details info")
+			statistics.Debug("statistics debug")
+
+			for _, msg := range tt.logged {
+				if !strings.Contains(buf.String(), `msg="`+msg+`"`) {
+					t.Errorf("%q not logged:\n%s", msg, buf.String())
+				}
+			}
+			for _, msg := range tt.dropped {
+				if strings.Contains(buf.String(), `msg="`+msg+`"`) {
+					t.Errorf("%q logged:\n%s", msg, buf.String())
+				}
+			}
+		})
+	}
+}
+
+func TestOutputLevelsChange(t *testing.T) {
+	var buf bytes.Buffer
+	levels := NewLevels(slog.LevelWarn, nil)
+	logger := slog.New(NewOutput(&buf, FormatJSON, levels)).With("scraper", "details")
+
+	logger.Info("before")
+	levels.Set(slog.LevelWarn, []string{"scraper"})
+	logger.Debug("after")
+
+	if strings.Contains(buf.String(), `"before"`) || !strings.Contains(buf.String(), `"msg":"after"`) {
+		t.Errorf("levels not applied to an existing logger:\n%s", buf.String())
+	}
+}
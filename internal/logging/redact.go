@@ -0,0 +1,183 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Redaction categories, enabled through LOG_REDACT
+const (
+	RedactAPIKeys     = "api_keys"
+	RedactEmails      = "emails"
+	RedactCoordinates = "coordinates"
+)
+
+const redacted = "[redacted]"
+
+var (
+	// Also URL-encoded, as in the query string "email=parent%40example.com"
+	emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+\-]+(?:@|%40)[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+
+	// Coordinates by name, as in the query string "lat=52.520008&lng=13.404954", JSON or
+	// "lat: 52.52"; other decimals such as durations or versions are left alone
+	coordinatePattern = regexp.MustCompile(`(?i)\b(lat|lng|lon|latitude|longitude)("?\s*[=:]\s*)-?\d+(?:\.\d+)?`)
+
+	// Query parameters and headers that carry credentials
+	apiKeyParamPattern = regexp.MustCompile(`(?i)\b(api_key|apikey|key|token|location|signature)=[^&\s"]+`)
+	bearerPattern      = regexp.MustCompile(`(?i)\bBearer\s+[A-Za-z0-9._\-]+`)
+)
+
+// Attribute keys whose values are always redacted for a category
+var sensitiveKeys = map[string]string{
+	"api_key":       RedactAPIKeys,
+	"apikey":        RedactAPIKeys,
+	"x-api-key":     RedactAPIKeys,
+	"x-admin-key":   RedactAPIKeys,
+	"authorization": RedactAPIKeys,
+	"token":         RedactAPIKeys,
+	"signature":     RedactAPIKeys,
+	"email":         RedactEmails,
+	"latitude":      RedactCoordinates,
+	"longitude":     RedactCoordinates,
+	"lat":           RedactCoordinates,
+	"lng":           RedactCoordinates,
+	"lon":           RedactCoordinates,
+	"start":         RedactCoordinates,
+	"end":           RedactCoordinates,
+	"coordinates":   RedactCoordinates,
+}
+
+// Redactor removes API keys, email addresses and coordinates from log output
+type Redactor struct {
+	categories map[string]bool
+
+	mu      sync.RWMutex
+	secrets []string
+}
+
+// NewRedactor creates a redactor for the given categories. Secrets are exact
+// values (configured API keys) that are replaced wherever they appear.
+func NewRedactor(categories []string, secrets ...string) *Redactor {
+	r := &Redactor{categories: make(map[string]bool, len(categories))}
+	for _, c := range categories {
+		r.categories[strings.ToLower(strings.TrimSpace(c))] = true
+	}
+	r.AddSecrets(secrets...)
+	return r
+}
+
+// AddSecrets redacts more exact values from here on, such as API keys added by a config
+// reload. Secrets already known are skipped.
+func (r *Redactor) AddSecrets(secrets ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, s := range secrets {
+		if s != "" && !slices.Contains(r.secrets, s) {
+			r.secrets = append(r.secrets, s)
+		}
+	}
+}
+
+// Enabled reports whether any redaction is configured
+func (r *Redactor) Enabled() bool {
+	return r != nil && len(r.categories) > 0
+}
+
+// RedactString replaces sensitive values inside free text such as URLs and error messages
+func (r *Redactor) RedactString(s string) string {
+	if !r.Enabled() {
+		return s
+	}
+
+	if r.categories[RedactAPIKeys] {
+		r.mu.RLock()
+		for _, secret := range r.secrets {
+			s = strings.ReplaceAll(s, secret, redacted)
+		}
+		r.mu.RUnlock()
+		s = apiKeyParamPattern.ReplaceAllString(s, "$1="+redacted)
+		s = bearerPattern.ReplaceAllString(s, "Bearer "+redacted)
+	}
+	if r.categories[RedactEmails] {
+		s = emailPattern.ReplaceAllString(s, redacted)
+	}
+	if r.categories[RedactCoordinates] {
+		s = coordinatePattern.ReplaceAllString(s, "${1}${2}"+redacted)
+	}
+
+	return s
+}
+
+// RedactAttr redacts an attribute by key, then by value, descending into groups
+func (r *Redactor) RedactAttr(a slog.Attr) slog.Attr {
+	if !r.Enabled() {
+		return a
+	}
+
+	if a.Value.Kind() == slog.KindGroup {
+		attrs := a.Value.Group()
+		redactedAttrs := make([]slog.Attr, len(attrs))
+		for i, attr := range attrs {
+			redactedAttrs[i] = r.RedactAttr(attr)
+		}
+		return slog.Attr{Key: a.Key, Value: slog.GroupValue(redactedAttrs...)}
+	}
+
+	if category, ok := sensitiveKeys[strings.ToLower(a.Key)]; ok && r.categories[category] {
+		return slog.String(a.Key, redacted)
+	}
+
+	switch a.Value.Kind() {
+	case slog.KindString:
+		return slog.String(a.Key, r.RedactString(a.Value.String()))
+	case slog.KindAny:
+		if err, ok := a.Value.Any().(error); ok {
+			return slog.String(a.Key, r.RedactString(err.Error()))
+		}
+	}
+
+	return a
+}
+
+// Handler is a slog.Handler that redacts the message and attributes of every record
+type Handler struct {
+	next     slog.Handler
+	redactor *Redactor
+}
+
+// NewHandler wraps a handler so that everything it writes passes through the redactor
+func NewHandler(next slog.Handler, redactor *Redactor) slog.Handler {
+	if !redactor.Enabled() {
+		return next
+	}
+	return &Handler{next: next, redactor: redactor}
+}
+
+func (h *Handler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *Handler) Handle(ctx context.Context, record slog.Record) error {
+	redactedRecord := slog.NewRecord(record.Time, record.Level, h.redactor.RedactString(record.Message), record.PC)
+	record.Attrs(func(a slog.Attr) bool {
+		redactedRecord.AddAttrs(h.redactor.RedactAttr(a))
+		return true
+	})
+	return h.next.Handle(ctx, redactedRecord)
+}
+
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	redactedAttrs := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		redactedAttrs[i] = h.redactor.RedactAttr(a)
+	}
+	return &Handler{next: h.next.WithAttrs(redactedAttrs), redactor: h.redactor}
+}
+
+func (h *Handler) WithGroup(name string) slog.Handler {
+	return &Handler{next: h.next.WithGroup(name), redactor: h.redactor}
+}
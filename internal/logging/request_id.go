@@ -0,0 +1,40 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+
+	"schools-be/internal/requestid"
+)
+
+// ContextHandler is a slog.Handler that adds the request ID from the context to every
+// record logged with a *Context method, so service and repository logs can be tied
+// to the HTTP request that caused them
+type ContextHandler struct {
+	next slog.Handler
+}
+
+// NewContextHandler wraps a handler so that records carry the request_id of their context
+func NewContextHandler(next slog.Handler) slog.Handler {
+	return &ContextHandler{next: next}
+}
+
+func (h *ContextHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *ContextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if id := requestid.FromContext(ctx); id != "" {
+		record = record.Clone()
+		record.AddAttrs(slog.String("request_id", id))
+	}
+	return h.next.Handle(ctx, record)
+}
+
+func (h *ContextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &ContextHandler{next: h.next.WithAttrs(attrs)}
+}
+
+func (h *ContextHandler) WithGroup(name string) slog.Handler {
+	return &ContextHandler{next: h.next.WithGroup(name)}
+}
@@ -0,0 +1,93 @@
+// Package metrics registers the application's Prometheus collectors. The constructors keep
+// label values positional, so call sites read like the metric definition.
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Registry holds the metric collectors served at /metrics
+type Registry struct {
+	registry *prometheus.Registry
+}
+
+// Default is the process-wide registry served at /metrics; it also exposes the Go runtime
+// and process metrics
+var Default = newRegistry()
+
+func newRegistry() *Registry {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(collectors.NewGoCollector(), collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+	return &Registry{registry: registry}
+}
+
+// Handler returns an http.Handler exposing the registry in the Prometheus exposition format
+func (r *Registry) Handler() http.Handler {
+	return promhttp.HandlerFor(r.registry, promhttp.HandlerOpts{})
+}
+
+// CounterVec is a monotonically increasing counter partitioned by label values
+type CounterVec struct {
+	vec *prometheus.CounterVec
+}
+
+// NewCounterVec creates a counter and registers it with the default registry
+func NewCounterVec(name, help string, labels ...string) *CounterVec {
+	vec := prometheus.NewCounterVec(prometheus.CounterOpts{Name: name, Help: help}, labels)
+	Default.registry.MustRegister(vec)
+	return &CounterVec{vec: vec}
+}
+
+// Inc increments the counter for the given label values by one
+func (c *CounterVec) Inc(labelValues ...string) {
+	c.vec.WithLabelValues(labelValues...).Inc()
+}
+
+// Add increments the counter for the given label values by delta
+func (c *CounterVec) Add(delta float64, labelValues ...string) {
+	c.vec.WithLabelValues(labelValues...).Add(delta)
+}
+
+// GaugeVec is a value that can go up and down, partitioned by label values
+type GaugeVec struct {
+	vec *prometheus.GaugeVec
+}
+
+// NewGaugeVec creates a gauge and registers it with the default registry
+func NewGaugeVec(name, help string, labels ...string) *GaugeVec {
+	vec := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: name, Help: help}, labels)
+	Default.registry.MustRegister(vec)
+	return &GaugeVec{vec: vec}
+}
+
+// Set sets the gauge for the given label values
+func (g *GaugeVec) Set(value float64, labelValues ...string) {
+	g.vec.WithLabelValues(labelValues...).Set(value)
+}
+
+// DefaultBuckets are histogram buckets (in seconds) suited for request and query latencies
+var DefaultBuckets = []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// HistogramVec tracks the distribution of observations partitioned by label values
+type HistogramVec struct {
+	vec *prometheus.HistogramVec
+}
+
+// NewHistogramVec creates a histogram and registers it with the default registry
+func NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	if len(buckets) == 0 {
+		buckets = DefaultBuckets
+	}
+	vec := prometheus.NewHistogramVec(prometheus.HistogramOpts{Name: name, Help: help, Buckets: buckets}, labels)
+	Default.registry.MustRegister(vec)
+	return &HistogramVec{vec: vec}
+}
+
+// Observe records a single observation for the given label values
+func (h *HistogramVec) Observe(value float64, labelValues ...string) {
+	h.vec.WithLabelValues(labelValues...).Observe(value)
+}
@@ -1,61 +1,224 @@
 package middleware
 
 import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
 	"strings"
+	"time"
 
+	"schools-be/internal/auth"
 	"schools-be/internal/config"
 )
 
-// APIKeyAuth is a middleware that validates API key authentication
-func APIKeyAuth(cfg *config.Config) func(next http.Handler) http.Handler {
+// Authenticate resolves the caller's role from the credentials of a request and stores it
+// in the request context; RequireRole enforces it per route group. Keys are accepted in the
+// X-API-Key and X-Admin-Key headers, as a Bearer token and as the HTTP Basic auth password
+// (for the browser dashboard), and map to roles:
+//
+//   - API_KEY and API_KEY_SECONDARY: public
+//   - PARTNER_API_KEYS: partner
+//   - ADMIN_API_KEY and ADMIN_API_KEY_SECONDARY: admin
+//
+// The secondary keys let clients switch to a new key without downtime; they are accepted
+// until their _UNTIL time. Keys are read per request, so a config reload applies them.
+//
+// Downloads of signed links (see auth.URLSigner) carry their role in the URL instead of a
+// key; a bad or expired signature is rejected. Signed links only allow GET and HEAD.
+//
+// When an identity provider is configured (verifier is not nil), Bearer tokens shaped like
+// a JWT are validated by it instead and get the role named in their role claim. Invalid or
+// expired tokens are always rejected.
+//
+// Requests with an unknown key are rejected and requests without a key are anonymous. When
+// no API_KEY is configured (development mode), both get the public role instead.
+func Authenticate(live *config.Live, verifier *auth.OIDCVerifier, signer *auth.URLSigner, logger *slog.Logger) func(next http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			// If API key is not configured, skip authentication (for development)
-			if cfg.APIKey == "" {
-				slog.Warn("API key authentication is disabled - no API_KEY configured")
-				next.ServeHTTP(w, r)
+			cfg := live.Config()
+			if signer != nil && auth.IsSigned(r.URL.Query()) {
+				p, err := signer.Verify(r.URL.Path, r.URL.Query(), time.Now())
+				if err != nil || (r.Method != http.MethodGet && r.Method != http.MethodHead) {
+					logger.Warn("invalid signed URL",
+						slog.String("path", r.URL.Path),
+						slog.String("method", r.Method),
+						slog.String("remote_addr", r.RemoteAddr),
+					)
+					respondError(w, http.StatusForbidden, "link is invalid or has expired")
+					return
+				}
+				next.ServeHTTP(w, r.WithContext(auth.WithPrincipal(r.Context(), p)))
 				return
 			}
 
-			// Get API key from header
-			apiKey := r.Header.Get("X-API-Key")
-			if apiKey == "" {
-				// Also check Authorization header with Bearer token format
-				authHeader := r.Header.Get("Authorization")
-				if strings.HasPrefix(authHeader, "Bearer ") {
-					apiKey = strings.TrimPrefix(authHeader, "Bearer ")
+			keys := requestKeys(r)
+			principal, ok := principalForKeys(cfg, keys, time.Now())
+
+			if token := bearerToken(r); verifier != nil && auth.LooksLikeJWT(token) {
+				p, err := verifier.Verify(r.Context(), token)
+				if err != nil {
+					if !errors.Is(err, auth.ErrInvalidToken) {
+						logger.Error("failed to verify token", slog.String("error", err.Error()))
+						respondError(w, http.StatusServiceUnavailable, "identity provider unavailable")
+						return
+					}
+					logger.Warn("invalid token",
+						slog.String("path", r.URL.Path),
+						slog.String("method", r.Method),
+						slog.String("remote_addr", r.RemoteAddr),
+						slog.String("error", err.Error()),
+					)
+					w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+					respondError(w, http.StatusUnauthorized, "invalid token")
+					return
+				}
+				if !ok || p.Role.Allows(principal.Role) {
+					principal, ok = p, true
 				}
 			}
 
-			// Validate API key
-			if apiKey == "" {
-				slog.Warn("missing API key",
+			switch {
+			case ok:
+				next.ServeHTTP(w, r.WithContext(auth.WithPrincipal(r.Context(), principal)))
+			case cfg.APIKey == "":
+				// Development mode: unknown or missing keys still read the dataset
+				logger.Warn("API key authentication is disabled - no API_KEY configured")
+				next.ServeHTTP(w, r.WithContext(auth.WithPrincipal(r.Context(), auth.Principal{Role: auth.RolePublic, Subject: "anonymous"})))
+			case len(keys) == 0:
+				next.ServeHTTP(w, r)
+			default:
+				logger.Warn("invalid API key",
 					slog.String("path", r.URL.Path),
 					slog.String("method", r.Method),
 					slog.String("remote_addr", r.RemoteAddr),
 				)
+				if basicPassword(r) != "" {
+					w.Header().Set("WWW-Authenticate", `Basic realm="admin", charset="UTF-8"`)
+				}
+				respondError(w, http.StatusUnauthorized, "invalid API key")
+			}
+		})
+	}
+}
+
+// RequireRole rejects requests whose caller lacks the role: 401 without credentials,
+// 403 with credentials of a lower role. Admin routes are disabled (403) when neither an
+// ADMIN_API_KEY nor an identity provider is configured.
+func RequireRole(live *config.Live, role auth.Role, logger *slog.Logger) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			cfg := live.Config()
+			if role == auth.RoleAdmin && cfg.AdminAPIKey == "" && !cfg.OIDCEnabled() {
+				respondError(w, http.StatusForbidden, "admin endpoints are disabled")
+				return
+			}
+
+			principal, ok := auth.FromContext(r.Context())
+			if !ok {
+				if role == auth.RoleAdmin {
+					// Lets browsers prompt for the key; any user name is accepted
+					w.Header().Set("WWW-Authenticate", `Basic realm="admin", charset="UTF-8"`)
+				}
 				respondError(w, http.StatusUnauthorized, "missing API key")
 				return
 			}
 
-			if apiKey != cfg.APIKey {
-				slog.Warn("invalid API key",
+			if !principal.Role.Allows(role) {
+				logger.Warn("insufficient role",
 					slog.String("path", r.URL.Path),
 					slog.String("method", r.Method),
-					slog.String("remote_addr", r.RemoteAddr),
+					slog.String("role", string(principal.Role)),
+					slog.String("required", string(role)),
 				)
-				respondError(w, http.StatusUnauthorized, "invalid API key")
+				if role == auth.RoleAdmin {
+					w.Header().Set("WWW-Authenticate", `Basic realm="admin", charset="UTF-8"`)
+				}
+				respondError(w, http.StatusForbidden, fmt.Sprintf("requires the %s role", role))
 				return
 			}
 
-			// API key is valid, proceed with request
 			next.ServeHTTP(w, r)
 		})
 	}
 }
 
+// requestKeys returns the keys sent with a request, in the order they are checked
+func requestKeys(r *http.Request) []string {
+	var keys []string
+	for _, key := range []string{
+		r.Header.Get("X-Admin-Key"),
+		r.Header.Get("X-API-Key"),
+		bearerToken(r),
+		basicPassword(r),
+	} {
+		if key != "" {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// principalForKeys returns the highest role any of the keys grants; ok is false when
+// none of them is known
+func principalForKeys(cfg *config.Config, keys []string, now time.Time) (auth.Principal, bool) {
+	var best auth.Principal
+	found := false
+	for _, key := range keys {
+		p, ok := principalForKey(cfg, key, now)
+		if ok && (!found || p.Role.Allows(best.Role)) {
+			best, found = p, true
+		}
+	}
+	return best, found
+}
+
+func principalForKey(cfg *config.Config, key string, now time.Time) (auth.Principal, bool) {
+	if keyMatches(key, cfg.AdminAPIKey) {
+		return auth.Principal{Role: auth.RoleAdmin, Subject: "admin_key"}, true
+	}
+	if secondaryKeyMatches(key, cfg.SecondaryAdminKey, cfg.SecondaryAdminKeyUntil, now) {
+		return auth.Principal{Role: auth.RoleAdmin, Subject: "admin_key_secondary"}, true
+	}
+	for i, partnerKey := range cfg.PartnerAPIKeys {
+		if keyMatches(key, partnerKey) {
+			return auth.Principal{Role: auth.RolePartner, Subject: fmt.Sprintf("partner_key:%d", i+1)}, true
+		}
+	}
+	if keyMatches(key, cfg.APIKey) {
+		return auth.Principal{Role: auth.RolePublic, Subject: "api_key"}, true
+	}
+	if secondaryKeyMatches(key, cfg.SecondaryAPIKey, cfg.SecondaryAPIKeyUntil, now) {
+		return auth.Principal{Role: auth.RolePublic, Subject: "api_key_secondary"}, true
+	}
+	return auth.Principal{}, false
+}
+
+// secondaryKeyMatches is keyMatches for a rotation key, which stops matching at until
+// unless until is zero
+func secondaryKeyMatches(key, configured string, until, now time.Time) bool {
+	return (until.IsZero() || now.Before(until)) && keyMatches(key, configured)
+}
+
+// keyMatches compares in constant time; an unconfigured key never matches
+func keyMatches(key, configured string) bool {
+	return configured != "" && subtle.ConstantTimeCompare([]byte(key), []byte(configured)) == 1
+}
+
+func bearerToken(r *http.Request) string {
+	authHeader := r.Header.Get("Authorization")
+	if strings.HasPrefix(authHeader, "Bearer ") {
+		return strings.TrimPrefix(authHeader, "Bearer ")
+	}
+	return ""
+}
+
+func basicPassword(r *http.Request) string {
+	_, password, _ := r.BasicAuth()
+	return password
+}
+
 func respondError(w http.ResponseWriter, status int, message string) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
@@ -0,0 +1,22 @@
+package middleware
+
+import (
+	"net/http"
+
+	"schools-be/internal/config"
+)
+
+// RequireFeature answers 503 with the reason while feature is listed in DISABLED_FEATURES or
+// unavailable for a missing dependency. The setting is checked per request, so a config
+// reload switches routes on and off.
+func RequireFeature(live *config.Live, feature string) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if enabled, reason := live.FeatureStatus(feature); !enabled {
+				respondError(w, http.StatusServiceUnavailable, reason)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+
+	"schools-be/internal/reporting"
+
+	"github.com/go-chi/chi/v5"
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
+)
+
+// Recoverer turns panics in handlers into 500 responses, logs them with their stack trace
+// and forwards them to the error tracker tagged with the request ID and route. Aborted
+// handlers (http.ErrAbortHandler) are passed on, as net/http expects.
+func Recoverer(reporter *reporting.Reporter, logger *slog.Logger) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer func() {
+				rec := recover()
+				if rec == nil {
+					return
+				}
+				if rec == http.ErrAbortHandler {
+					panic(rec)
+				}
+
+				route := ""
+				if rctx := chi.RouteContext(r.Context()); rctx != nil {
+					route = rctx.RoutePattern()
+				}
+				requestID := chimiddleware.GetReqID(r.Context())
+
+				reporter.CapturePanic(rec, map[string]string{
+					"request_id": requestID,
+					"route":      route,
+					"method":     r.Method,
+				})
+				logger.Error("panic recovered",
+					slog.String("panic", fmt.Sprint(rec)),
+					slog.String("method", r.Method),
+					slog.String("route", route),
+					slog.String("request_id", requestID),
+					slog.String("stack", string(debug.Stack())),
+				)
+
+				if r.Header.Get("Connection") != "Upgrade" {
+					respondError(w, http.StatusInternalServerError, "internal server error")
+				}
+			}()
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package middleware
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"schools-be/internal/reporting"
+
+	"github.com/go-chi/chi/v5"
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
+)
+
+// recoverRouter serves /schools/{id} with handler behind RequestID and Recoverer, reporting
+// to an error tracker whose received envelopes are returned by the second result
+func recoverRouter(t *testing.T, logs *bytes.Buffer, handler http.HandlerFunc) (http.Handler, func() []string) {
+	t.Helper()
+	var mu sync.Mutex
+	var envelopes []string
+	tracker := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		envelopes = append(envelopes, string(body))
+		mu.Unlock()
+	}))
+	t.Cleanup(tracker.Close)
+
+	logger := slog.New(slog.NewJSONHandler(logs, nil))
+	reporter, err := reporting.New(strings.Replace(tracker.URL, "http://", "http://key@", 1)+"/1", "test", logger)
+	if err != nil {
+		t.Fatalf("reporting.New() error = %v", err)
+	}
+
+	r := chi.NewRouter()
+	r.Use(chimiddleware.RequestID)
+	r.Use(Recoverer(reporter, logger))
+	r.Get("/schools/{id}", handler)
+	return r, func() []string {
+		reporter.Flush(5 * time.Second)
+		mu.Lock()
+		defer mu.Unlock()
+		return envelopes
+	}
+}
+
+func TestRecoverer(t *testing.T) {
+	var logs bytes.Buffer
+	router, envelopes := recoverRouter(t, &logs, func(w http.ResponseWriter, r *http.Request) {
+		panic("school not loaded")
+	})
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/schools/01Y02", nil))
+
+	if rec.Code != http.StatusInternalServerError || rec.Body.String() != `{"error":"internal server error"}` {
+		t.Errorf("response = %d %s, want 500 with an error body", rec.Code, rec.Body)
+	}
+	for _, want := range []string{`"msg":"panic recovered"`, `"panic":"school not loaded"`, `"route":"/schools/{id}"`, `"request_id":"`, "recoverer_test.go"} {
+		if !strings.Contains(logs.String(), want) {
+			t.Errorf("log does not contain %s:\n%s", want, logs.String())
+		}
+	}
+
+	sent := envelopes()
+	if len(sent) != 1 {
+		t.Fatalf("tracker received %d events, want 1", len(sent))
+	}
+	for _, want := range []string{`"value":"school not loaded"`, `"route":"/schools/{id}"`, `"method":"GET"`, `"request_id":"`} {
+		if !strings.Contains(sent[0], want) {
+			t.Errorf("event does not contain %s:\n%s", want, sent[0])
+		}
+	}
+}
+
+func TestRecovererUpgrade(t *testing.T) {
+	var logs bytes.Buffer
+	router, envelopes := recoverRouter(t, &logs, func(w http.ResponseWriter, r *http.Request) {
+		panic("connection hijacked")
+	})
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/schools/01Y02", nil)
+	req.Header.Set("Connection", "Upgrade")
+	router.ServeHTTP(rec, req)
+
+	if rec.Body.Len() != 0 || rec.Header().Get("Content-Type") != "" {
+		t.Errorf("response body = %q, want none on an upgraded connection", rec.Body)
+	}
+	if len(envelopes()) != 1 {
+		t.Error("panic on an upgraded connection not reported")
+	}
+}
+
+func TestRecovererAbortHandler(t *testing.T) {
+	var logs bytes.Buffer
+	router, envelopes := recoverRouter(t, &logs, func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	})
+
+	func() {
+		defer func() {
+			if rec := recover(); rec != http.ErrAbortHandler {
+				t.Errorf("recovered %v, want http.ErrAbortHandler passed on", rec)
+			}
+		}()
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/schools/01Y02", nil))
+	}()
+
+	if logs.Len() != 0 || len(envelopes()) != 0 {
+		t.Errorf("aborted handler logged or reported:\n%s", logs.String())
+	}
+}
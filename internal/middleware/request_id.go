@@ -0,0 +1,18 @@
+package middleware
+
+import (
+	"net/http"
+
+	"schools-be/internal/requestid"
+)
+
+// RequestIDHeader echoes the request ID assigned by chi's RequestID middleware in the
+// X-Request-ID response header, so clients can quote it when reporting a problem
+func RequestIDHeader(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if id := requestid.FromContext(r.Context()); id != "" {
+			w.Header().Set(requestid.Header, id)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,70 @@
+package models
+
+// TableCount is the number of rows in a table
+type TableCount struct {
+	Table string `json:"table"`
+	Rows  int    `json:"rows"`
+}
+
+// TableSchema describes a table for dataset exports
+type TableSchema struct {
+	Name    string
+	Columns []TableColumn
+}
+
+// TableColumn is a column with its declared SQLite type (e.g. "INTEGER", "TEXT", "DATETIME")
+type TableColumn struct {
+	Name string `db:"name"`
+	Type string `db:"type"`
+}
+
+// OrphanRows are rows of a table whose school number does not exist in schools
+type OrphanRows struct {
+	Table         string   `json:"table"`
+	Rows          int      `json:"rows"`
+	SchoolNumbers []string `json:"school_numbers"` // sample, at most 20
+	Fixable       bool     `json:"fixable"`        // construction projects without a school are valid standalone projects
+	Deleted       int64    `json:"deleted,omitempty"`
+}
+
+// StatMismatch is a row whose total does not equal the sum of its parts
+type StatMismatch struct {
+	Table        string `json:"table" db:"table_name"`
+	SchoolNumber string `json:"school_number" db:"school_number"`
+	Detail       string `json:"detail" db:"detail"`
+	Total        int    `json:"total" db:"total"`
+	Sum          int    `json:"sum" db:"parts_sum"`
+}
+
+// ConsistencyReport is the result of cross-checking the data tables
+type ConsistencyReport struct {
+	Orphans               []OrphanRows   `json:"orphans"`
+	SchoolsMissingDetails []string       `json:"schools_missing_details"`
+	TotalMismatches       []StatMismatch `json:"total_mismatches"`
+}
+
+// HasIssues reports whether any check found a problem that is not a valid standalone row
+func (r *ConsistencyReport) HasIssues() bool {
+	for _, o := range r.Orphans {
+		if o.Fixable && o.Rows > int(o.Deleted) {
+			return true
+		}
+	}
+	return len(r.SchoolsMissingDetails) > 0 || len(r.TotalMismatches) > 0
+}
+
+// SchoolCompleteness is the data completeness of one school
+type SchoolCompleteness struct {
+	SchoolNumber string   `json:"school_number"`
+	Name         string   `json:"name"`
+	SchoolType   string   `json:"school_type"`
+	Score        int      `json:"score"`
+	Missing      []string `json:"missing"`
+}
+
+// CompletenessReport lists the data completeness of all schools, least complete first
+type CompletenessReport struct {
+	AverageScore    float64              `json:"average_score"`
+	MissingSections map[string]int       `json:"missing_sections"` // Number of schools lacking each section
+	Schools         []SchoolCompleteness `json:"schools"`
+}
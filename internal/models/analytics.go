@@ -0,0 +1,16 @@
+package models
+
+// Analytics rollup kinds
+const (
+	AnalyticsKindEndpoint = "endpoint"
+	AnalyticsKindFilter   = "filter"
+	AnalyticsKindSchool   = "school"
+)
+
+// AnalyticsRollup is the hit count of one key on one day (UTC)
+type AnalyticsRollup struct {
+	Day   string `json:"day" db:"day"`
+	Kind  string `json:"kind" db:"kind"`
+	Key   string `json:"key" db:"key"`
+	Count int64  `json:"count" db:"count"`
+}
@@ -0,0 +1,44 @@
+package models
+
+import "time"
+
+// CapacityProjection is the projected enrolment and capacity for one school year
+type CapacityProjection struct {
+	SchoolYear        string  `json:"school_year"`
+	ProjectedStudents int     `json:"projected_students"`
+	Capacity          int     `json:"capacity"`
+	Utilization       float64 `json:"utilization"` // Projected students / capacity, 0 when capacity is unknown
+
+	// Construction projects handed over by the start of this school year
+	CompletedProjects int `json:"completed_projects,omitempty"`
+}
+
+// SchoolCapacityForecast projects one school's enrolment and capacity from its latest statistics
+type SchoolCapacityForecast struct {
+	SchoolNumber string               `json:"school_number"`
+	SchoolName   string               `json:"school_name"`
+	District     string               `json:"district"`
+	SchoolType   string               `json:"school_type"`
+	BaseYear     string               `json:"base_year"`
+	Students     int                  `json:"students"`
+	Classes      int                  `json:"classes"`
+	Capacity     int                  `json:"capacity"`
+	YearlyTrend  float64              `json:"yearly_trend"` // Average change in students per year
+	Projections  []CapacityProjection `json:"projections"`
+}
+
+// DistrictCapacityForecast sums the school forecasts of a district
+type DistrictCapacityForecast struct {
+	District    string               `json:"district"`
+	Schools     int                  `json:"schools"`
+	Projections []CapacityProjection `json:"projections"`
+}
+
+// CapacityForecast is the capacity outlook for planners, per school and per district
+type CapacityForecast struct {
+	GeneratedAt time.Time                  `json:"generated_at"`
+	Years       int                        `json:"years"`
+	ClassSize   int                        `json:"class_size"` // Students per class assumed when converting classes and tracks to places
+	Schools     []SchoolCapacityForecast   `json:"schools"`
+	Districts   []DistrictCapacityForecast `json:"districts"`
+}
@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// Chat message roles, matching the roles used by the generative model
+const (
+	ChatRoleUser  = "user"
+	ChatRoleModel = "model"
+)
+
+// ChatSession is a conversation with the school advisor, optionally scoped to one school
+type ChatSession struct {
+	ID        string    `json:"id" db:"id"`
+	SchoolID  *int64    `json:"school_id,omitempty" db:"school_id"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+	ExpiresAt time.Time `json:"expires_at" db:"expires_at"`
+}
+
+// ChatMessage is a single turn in a chat session
+type ChatMessage struct {
+	ID        int64     `json:"id" db:"id"`
+	SessionID string    `json:"session_id" db:"session_id"`
+	Role      string    `json:"role" db:"role"`
+	Content   string    `json:"content" db:"content"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
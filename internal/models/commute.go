@@ -0,0 +1,40 @@
+package models
+
+import "time"
+
+// Commute matrix computation states
+const (
+	CommuteStatusPending = "pending"
+	CommuteStatusReady   = "ready"
+	CommuteStatusFailed  = "failed"
+)
+
+// UserLocation is an anonymous home coordinate identified by an opaque token
+type UserLocation struct {
+	Token     string    `json:"token" db:"token"`
+	Latitude  float64   `json:"latitude" db:"latitude"`
+	Longitude float64   `json:"longitude" db:"longitude"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// CommuteMatrixStatus tracks the asynchronous commute computation for a location and mode
+type CommuteMatrixStatus struct {
+	LocationToken string    `json:"-" db:"location_token"`
+	Mode          string    `json:"mode" db:"mode"`
+	Status        string    `json:"status" db:"status"`
+	Error         string    `json:"error,omitempty" db:"error"`
+	UpdatedAt     time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// CommuteTime is the travel time from a user location to one school
+type CommuteTime struct {
+	LocationToken   string    `json:"-" db:"location_token"`
+	Mode            string    `json:"mode" db:"mode"`
+	SchoolID        int64     `json:"-" db:"school_id"`
+	DurationSeconds float64   `json:"-" db:"duration_seconds"`
+	DistanceMeters  float64   `json:"-" db:"distance_meters"`
+	ComputedAt      time.Time `json:"-" db:"computed_at"`
+
+	DurationMinutes int     `json:"duration_minutes" db:"-"`
+	DistanceKm      float64 `json:"distance_km" db:"-"`
+}
@@ -1,6 +1,10 @@
 package models
 
-import "time"
+import (
+	"strconv"
+	"strings"
+	"time"
+)
 
 // ConstructionProject represents a construction project from the Berlin API
 type ConstructionProject struct {
@@ -24,6 +28,103 @@ type ConstructionProject struct {
 	Longitude                    float64   `json:"longitude" db:"longitude"`
 	CreatedAt                    time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt                    time.Time `json:"updated_at" db:"updated_at"`
+
+	Assets []ConstructionProjectAsset `json:"assets,omitempty" db:"-"` // Loaded by the project endpoints only
+}
+
+// Construction project asset kinds
+const (
+	AssetKindDocument = "document"
+	AssetKindImage    = "image"
+)
+
+// ConstructionProjectAsset is a document or image linked from a construction project
+type ConstructionProjectAsset struct {
+	ID        int64  `json:"id" db:"id"`
+	ProjectID int    `json:"project_id" db:"project_id"`
+	Kind      string `json:"kind" db:"kind"` // AssetKindDocument or AssetKindImage
+	URL       string `json:"url" db:"url"`
+	Title     string `json:"title" db:"title"` // Link text or image description, may be empty
+}
+
+// ConstructionProjectImport is one import of the construction projects, kept in the project history
+type ConstructionProjectImport struct {
+	ID         int64     `json:"id" db:"id"`
+	ImportedAt time.Time `json:"imported_at" db:"imported_at"`
+	Projects   int       `json:"projects" db:"projects"`
+}
+
+// Budget rollup groupings
+const (
+	BudgetGroupDistrict   = "district"
+	BudgetGroupSchoolType = "school_type"
+)
+
+// BudgetRollup sums the total costs of the construction projects per group, compared with the
+// previous import. The Previous*, Delta and Added fields are nil without a previous import.
+type BudgetRollup struct {
+	GroupBy            string              `json:"group_by"`
+	Year               *int                `json:"year"`
+	ImportedAt         *time.Time          `json:"imported_at"`
+	PreviousImportedAt *time.Time          `json:"previous_imported_at"`
+	Groups             []BudgetRollupGroup `json:"groups"`
+	Total              BudgetRollupGroup   `json:"total"`
+}
+
+// BudgetRollupGroup is the investment in one group; projects without parseable costs count
+// as projects but add nothing
+type BudgetRollupGroup struct {
+	Key              string   `json:"key"`
+	Projects         int      `json:"projects"`
+	TotalEUR         float64  `json:"total_eur"`
+	PreviousTotalEUR *float64 `json:"previous_total_eur"`
+	DeltaEUR         *float64 `json:"delta_eur"` // TotalEUR minus PreviousTotalEUR
+	AddedEUR         *float64 `json:"added_eur"` // Costs of projects new since the previous import and cost increases of the others
+}
+
+// TotalCostsEUR parses the free-text total costs ("12.500.000 €", "ca. 35,6 Mio. €")
+// into euros. ok is false when the source gives no parseable amount.
+func (p ConstructionProject) TotalCostsEUR() (amount float64, ok bool) {
+	return parseEuroAmount(p.TotalCosts)
+}
+
+// parseEuroAmount reads a German formatted amount with an optional Tsd./Mio./Mrd. unit
+func parseEuroAmount(s string) (float64, bool) {
+	lower := strings.ToLower(s)
+	multiplier := 1.0
+	switch {
+	case strings.Contains(lower, "mrd"):
+		multiplier = 1e9
+	case strings.Contains(lower, "mio"):
+		multiplier = 1e6
+	case strings.Contains(lower, "tsd"):
+		multiplier = 1e3
+	}
+
+	// Take the first run of digits and separators
+	start := strings.IndexFunc(lower, func(r rune) bool { return r >= '0' && r <= '9' })
+	if start < 0 {
+		return 0, false
+	}
+	end := start
+	for end < len(lower) && (lower[end] >= '0' && lower[end] <= '9' || lower[end] == '.' || lower[end] == ',') {
+		end++
+	}
+	number := strings.TrimRight(lower[start:end], ".,")
+
+	// "." groups thousands and "," marks decimals; a single "." not followed by
+	// exactly three digits is read as a decimal point ("35.6 Mio.")
+	if strings.Count(number, ".") == 1 && !strings.Contains(number, ",") && len(number)-strings.Index(number, ".") != 4 {
+		number = strings.Replace(number, ".", ",", 1)
+	}
+	number = strings.ReplaceAll(number, ".", "")
+	number = strings.Replace(number, ",", ".", 1)
+
+	value, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return 0, false
+	}
+	return value * multiplier, true
 }
 
 // CreateConstructionProjectInput represents the input for creating a construction project
@@ -45,4 +146,6 @@ type CreateConstructionProjectInput struct {
 	City                         string  `json:"city"`
 	Latitude                     float64 `json:"latitude"`
 	Longitude                    float64 `json:"longitude"`
+
+	Assets []ConstructionProjectAsset `json:"assets"`
 }
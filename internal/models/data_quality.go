@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// DatasetCitizenship is the dataset of the citizenship table (school_citizenship_stats)
+const DatasetCitizenship = "citizenship"
+
+// DataQualityIssue is a row of a normalized table whose total does not equal the sum of its
+// parts, recorded when the table was stored
+type DataQualityIssue struct {
+	ID           int64     `json:"id" db:"id"`
+	SchoolNumber string    `json:"school_number" db:"school_number"`
+	Dataset      string    `json:"dataset" db:"dataset"`
+	Detail       string    `json:"detail" db:"detail"` // The row, e.g. "Türkei"
+	Total        int       `json:"total" db:"total"`
+	Sum          int       `json:"sum" db:"parts_sum"`
+	Excluded     bool      `json:"excluded" db:"excluded"` // The table was not stored, so the API leaves it out
+	DetectedAt   time.Time `json:"detected_at" db:"detected_at"`
+}
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// DownloadLinkRequest asks for a signed link to an export endpoint
+type DownloadLinkRequest struct {
+	Path string `json:"path" validate:"required"` // e.g. /api/v1/export/full.json.br
+}
+
+// DownloadLink is a time-limited URL that downloads an export without an API key
+type DownloadLink struct {
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
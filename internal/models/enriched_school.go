@@ -1,5 +1,7 @@
 package models
 
+import "strings"
+
 // EnrichedSchool contains a school with all related data from other tables
 type EnrichedSchool struct {
 	// Base school data
@@ -17,6 +19,119 @@ type EnrichedSchool struct {
 	// School statistics (students, teachers, classes by year)
 	Statistics []SchoolStatistic `json:"statistics,omitempty"`
 
+	// First-choice applications vs places per school year, newest first (secondary schools)
+	Applications []SchoolApplication `json:"applications,omitempty"`
+
 	// Construction projects related to this school
 	ConstructionProjects []ConstructionProject `json:"construction_projects,omitempty"`
+
+	// Rating on Google Places (set when external ratings are enabled and a place was found)
+	ExternalRating *ExternalRating `json:"external_rating,omitempty"`
+
+	// Commute from the caller's registered location (set per request when sorting by commute)
+	Commute *CommuteTime `json:"commute,omitempty"`
+
+	// Which enrichment sections have data (set when the school is enriched)
+	Completeness *DataCompleteness `json:"completeness,omitempty"`
+}
+
+// Enrichment sections counted by the data completeness score
+const (
+	SectionDetails     = "details"
+	SectionCoordinates = "coordinates"
+	SectionStatistics  = "statistics"
+	SectionCitizenship = "citizenship_stats"
+	SectionLanguage    = "language_stat"
+	SectionResidence   = "residence_stats"
+	SectionAbsence     = "absence_stat"
+)
+
+// CompletenessSections lists all sections counted by the completeness score
+var CompletenessSections = []string{
+	SectionDetails, SectionCoordinates, SectionStatistics, SectionCitizenship,
+	SectionLanguage, SectionResidence, SectionAbsence,
+}
+
+// DataCompleteness tells how many enrichment sections of a school have data
+type DataCompleteness struct {
+	Score   int      `json:"score"`   // Percentage of CompletenessSections with data
+	Missing []string `json:"missing"` // Sections without data
+}
+
+// ComputeCompleteness checks which enrichment sections of the school have data. Applications
+// and construction projects are left out: most schools rightly have none.
+func (s EnrichedSchool) ComputeCompleteness() DataCompleteness {
+	populated := map[string]bool{
+		SectionDetails:     s.Details != nil,
+		SectionCoordinates: s.School.HasCoordinates(),
+		SectionStatistics:  len(s.Statistics) > 0,
+		SectionCitizenship: len(s.CitizenshipStats) > 0,
+		SectionLanguage:    s.LanguageStat != nil,
+		SectionResidence:   len(s.ResidenceStats) > 0,
+		SectionAbsence:     s.AbsenceStat != nil,
+	}
+
+	completeness := DataCompleteness{Missing: []string{}}
+	for _, section := range CompletenessSections {
+		if !populated[section] {
+			completeness.Missing = append(completeness.Missing, section)
+		}
+	}
+	filled := len(CompletenessSections) - len(completeness.Missing)
+	completeness.Score = filled * 100 / len(CompletenessSections)
+	return completeness
+}
+
+// HasConstruction reports whether any construction project is planned or running at the school
+func (s EnrichedSchool) HasConstruction() bool {
+	return len(s.ConstructionProjects) > 0
+}
+
+// Kinds of school operators
+const (
+	OperatorKindPublic         = "public"
+	OperatorKindConfessional   = "confessional"
+	OperatorKindPrivateSecular = "private_secular"
+)
+
+// OperatorKind classifies the school's operator as public, confessional (a private school
+// whose operator has a confession) or private-secular
+func (s EnrichedSchool) OperatorKind() string {
+	if s.School.Operator == "" || s.School.Operator == "öffentlich" {
+		return OperatorKindPublic
+	}
+	if s.Details != nil && s.Details.Confession != "" {
+		return OperatorKindConfessional
+	}
+	return OperatorKindPrivateSecular
+}
+
+// AllDayType classifies the school's all-day schooling as AllDayBound, AllDayOpen or AllDayNone.
+// The form named on the school page wins; without one, a school type or category from the
+// school register naming Ganztag decides.
+func (s EnrichedSchool) AllDayType() string {
+	if s.Details != nil && s.Details.AllDayType != "" && s.Details.AllDayType != AllDayNone {
+		return s.Details.AllDayType
+	}
+	register := strings.ToLower(s.School.SchoolType + " " + s.School.SchoolCategory)
+	switch {
+	case strings.Contains(register, "gebunden") && !strings.Contains(register, "ungebunden"):
+		return AllDayBound
+	case strings.Contains(register, "ganztag"):
+		return AllDayOpen
+	default:
+		return AllDayNone
+	}
+}
+
+// ConstructionInvestment sums the total costs of the school's construction projects in euros.
+// Projects without a parseable amount are skipped.
+func (s EnrichedSchool) ConstructionInvestment() float64 {
+	var total float64
+	for _, project := range s.ConstructionProjects {
+		if amount, ok := project.TotalCostsEUR(); ok {
+			total += amount
+		}
+	}
+	return total
 }
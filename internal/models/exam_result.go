@@ -0,0 +1,79 @@
+package models
+
+import (
+	"math"
+	"time"
+)
+
+// ExamResult is a school's Abitur result for one school year. Berlin suppresses the
+// figures of small cohorts to protect students; such years are stored with Suppressed
+// set and no figures.
+type ExamResult struct {
+	ID           int64     `json:"id" db:"id"`
+	SchoolNumber string    `json:"school_number" db:"school_number"`
+	SchoolYear   string    `json:"school_year" db:"school_year"`
+	Participants *int      `json:"participants" db:"participants"`
+	Passed       *int      `json:"passed" db:"passed"`
+	AverageGrade *float64  `json:"average_grade" db:"average_grade"`
+	Suppressed   bool      `json:"suppressed" db:"suppressed"`
+	FetchedAt    time.Time `json:"fetched_at" db:"fetched_at"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+}
+
+// ExamResultInput is a parsed row of the exam results dataset
+type ExamResultInput struct {
+	SchoolNumber string
+	SchoolYear   string
+	Participants *int
+	Passed       *int
+	AverageGrade *float64
+	Suppressed   bool
+}
+
+// PassRate returns the share of participants who passed, or nil when unknown
+func (e ExamResult) PassRate() *float64 {
+	return passRate(e.Participants, e.Passed)
+}
+
+// ExamBenchmark aggregates the unsuppressed results of a group of schools for one school year.
+// The average grade is weighted by participants.
+type ExamBenchmark struct {
+	Schools      int      `json:"schools" db:"schools"`
+	Participants *int     `json:"participants" db:"participants"`
+	Passed       *int     `json:"passed" db:"passed"`
+	AverageGrade *float64 `json:"average_grade" db:"average_grade"`
+	PassRate     *float64 `json:"pass_rate"`
+}
+
+// ExamResultYear is one school year of a school's results next to its benchmarks
+type ExamResultYear struct {
+	SchoolYear   string         `json:"school_year"`
+	Suppressed   bool           `json:"suppressed"`
+	Participants *int           `json:"participants"`
+	Passed       *int           `json:"passed"`
+	AverageGrade *float64       `json:"average_grade"`
+	PassRate     *float64       `json:"pass_rate"`
+	Berlin       *ExamBenchmark `json:"berlin,omitempty"`
+	SchoolType   *ExamBenchmark `json:"school_type,omitempty"`
+}
+
+// ExamResultSeries is the year-over-year Abitur trajectory of a school, oldest year first
+type ExamResultSeries struct {
+	SchoolNumber string           `json:"school_number"`
+	SchoolType   string           `json:"school_type"`
+	Years        []ExamResultYear `json:"years"`
+}
+
+func passRate(participants, passed *int) *float64 {
+	if participants == nil || passed == nil || *participants <= 0 {
+		return nil
+	}
+	rate := math.Round(float64(*passed)/float64(*participants)*1000) / 1000
+	return &rate
+}
+
+// WithPassRate fills PassRate from the participant and passed counts
+func (b ExamBenchmark) WithPassRate() ExamBenchmark {
+	b.PassRate = passRate(b.Participants, b.Passed)
+	return b
+}
@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// ExternalRatingSourceGooglePlaces is the source of ratings looked up on Google Places
+const ExternalRatingSourceGooglePlaces = "google_places"
+
+// ExternalRating is the rating of a school on a third-party review site, as of FetchedAt
+type ExternalRating struct {
+	SchoolNumber string    `json:"-" db:"school_number"`
+	Source       string    `json:"source" db:"source"`
+	PlaceID      string    `json:"-" db:"place_id"`    // Empty when no matching place was found
+	Rating       *float64  `json:"rating" db:"rating"` // 1 to 5 stars, null without reviews
+	ReviewCount  int       `json:"review_count" db:"review_count"`
+	URL          string    `json:"url,omitempty" db:"url"` // Page of the place on the review site
+	FetchedAt    time.Time `json:"fetched_at" db:"fetched_at"`
+}
@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// JobRequest is a job triggered on an API instance without a scheduler, to be run by a worker
+type JobRequest struct {
+	ID          int64      `json:"id" db:"id"`
+	Job         string     `json:"job" db:"job"`
+	RequestedAt time.Time  `json:"requested_at" db:"requested_at"`
+	ClaimedBy   *string    `json:"claimed_by,omitempty" db:"claimed_by"` // INSTANCE_ID of the worker
+	ClaimedAt   *time.Time `json:"claimed_at,omitempty" db:"claimed_at"`
+}
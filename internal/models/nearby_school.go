@@ -0,0 +1,7 @@
+package models
+
+// NearbySchool is a school within a radius of a point, with its distance from the point
+type NearbySchool struct {
+	School     School  `json:"school"`
+	DistanceKm float64 `json:"distance_km"`
+}
@@ -0,0 +1,17 @@
+package models
+
+// SchoolPreview is the Open Graph metadata for a shared link to a school
+type SchoolPreview struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Image       string `json:"image,omitempty"` // Absolute URL of the map thumbnail, empty without a location
+	ImageWidth  int    `json:"image_width,omitempty"`
+	ImageHeight int    `json:"image_height,omitempty"`
+	ImageAlt    string `json:"image_alt,omitempty"`
+	SiteName    string `json:"site_name"`
+	Type        string `json:"type"`
+	Locale      string `json:"locale"`
+
+	// AI-written description, or one built from the school data when no summary is available
+	DescriptionSource string `json:"description_source"`
+}
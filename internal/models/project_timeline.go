@@ -0,0 +1,115 @@
+package models
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Construction project statuses
+const (
+	ProjectStatusPlanned        = "planned"
+	ProjectStatusInConstruction = "in_construction"
+	ProjectStatusCompleted      = "completed"
+	ProjectStatusUnknown        = "unknown"
+)
+
+// ProjectStatuses lists all construction project statuses
+var ProjectStatuses = []string{
+	ProjectStatusPlanned, ProjectStatusInConstruction, ProjectStatusCompleted, ProjectStatusUnknown,
+}
+
+// ProjectTimeline is the schedule of a construction project as far as the source gives it
+type ProjectTimeline struct {
+	StartYear *int   `json:"start_year"` // Year construction starts, nil when not published
+	EndYear   *int   `json:"end_year"`   // Year of the handover, nil when not published
+	Status    string `json:"status"`     // ProjectStatus* as of the current year
+}
+
+var (
+	timelineYear = `((?:19|20)\d{2})`
+	// "Baubeginn 2025", "Baustart: Sommer 2024", "Beginn der Bauarbeiten 2023"
+	startPattern = regexp.MustCompile(`(?i)(?:baubeginn|baustart|beginn der bauarbeiten|bauarbeiten beginnen)\D{0,20}?` + timelineYear)
+	// "Fertigstellung 2027", "Übergabe Ende 2026", "Inbetriebnahme 2028"
+	endPattern = regexp.MustCompile(`(?i)(?:fertigstellung|übergabe|inbetriebnahme)\D{0,20}?` + timelineYear)
+	// "Bauzeit 2024 - 2026", "von 2025 bis 2027"
+	rangePattern   = regexp.MustCompile(timelineYear + `\s*(?:-|–|bis)\s*` + timelineYear)
+	anyYearPattern = regexp.MustCompile(`\b` + timelineYear + `\b`)
+)
+
+// Phrases telling the state of a project when its years do not
+var (
+	completedPhrases      = []string{"wurde fertiggestellt", "ist fertiggestellt", "wurde abgeschlossen", "ist abgeschlossen", "wurde übergeben", "in betrieb genommen"}
+	inConstructionPhrases = []string{"im bau", "bauarbeiten laufen", "baumaßnahme läuft", "baubeginn erfolgte", "wird gebaut"}
+	plannedPhrases        = []string{"in planung", "planungsphase", "geplant", "wettbewerb"}
+)
+
+// Timeline reads the schedule of the project: the handover year from HandoverDate ("2027",
+// "2027/2028" gives 2028) or the description, and the start year from the description. The
+// status follows from the years as of currentYear and, where they say nothing, from the
+// description.
+func (p ConstructionProject) Timeline(currentYear int) ProjectTimeline {
+	description := strings.ToLower(p.Description)
+	var start, end int
+
+	if years := anyYearPattern.FindAllString(p.HandoverDate, -1); len(years) > 0 {
+		end, _ = strconv.Atoi(years[len(years)-1])
+	} else if m := endPattern.FindStringSubmatch(description); m != nil {
+		end, _ = strconv.Atoi(m[1])
+	}
+	if m := startPattern.FindStringSubmatch(description); m != nil {
+		start, _ = strconv.Atoi(m[1])
+	}
+	if m := rangePattern.FindStringSubmatch(description); m != nil {
+		if start == 0 {
+			start, _ = strconv.Atoi(m[1])
+		}
+		if end == 0 {
+			end, _ = strconv.Atoi(m[2])
+		}
+	}
+	if start != 0 && end != 0 && start > end {
+		start = 0
+	}
+
+	timeline := ProjectTimeline{Status: ProjectStatusUnknown}
+	if start != 0 {
+		timeline.StartYear = &start
+	}
+	if end != 0 {
+		timeline.EndYear = &end
+	}
+
+	switch {
+	case end != 0 && end < currentYear, containsPhrase(description, completedPhrases):
+		timeline.Status = ProjectStatusCompleted
+	case start != 0 && start <= currentYear, end == currentYear, containsPhrase(description, inConstructionPhrases):
+		timeline.Status = ProjectStatusInConstruction
+	case start != 0 || end != 0, containsPhrase(description, plannedPhrases):
+		timeline.Status = ProjectStatusPlanned
+	}
+	return timeline
+}
+
+// Overlaps reports whether the project runs during any of the years from to to (inclusive).
+// A timeline with one year runs in that year only; one without years overlaps no range.
+func (t ProjectTimeline) Overlaps(from, to int) bool {
+	switch {
+	case t.StartYear == nil && t.EndYear == nil:
+		return false
+	case t.StartYear == nil:
+		return *t.EndYear >= from && *t.EndYear <= to
+	case t.EndYear == nil:
+		return *t.StartYear >= from && *t.StartYear <= to
+	}
+	return *t.StartYear <= to && *t.EndYear >= from
+}
+
+func containsPhrase(text string, phrases []string) bool {
+	for _, phrase := range phrases {
+		if strings.Contains(text, phrase) {
+			return true
+		}
+	}
+	return false
+}
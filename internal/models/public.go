@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// DistrictCount is the number of schools in a district and the students they reported in the
+// latest school year of the statistics
+type DistrictCount struct {
+	District string `json:"district" db:"district"`
+	Schools  int    `json:"schools" db:"schools"`
+	Students int    `json:"students" db:"-"`
+}
+
+// DatasetFreshness tells how many records a dataset holds and when it was last refreshed.
+// UpdatedAt is nil while the dataset is empty.
+type DatasetFreshness struct {
+	Dataset   string     `json:"dataset"`
+	Records   int        `json:"records"`
+	UpdatedAt *time.Time `json:"updated_at"`
+}
+
+// PublicStats are the city-wide aggregates served without an API key. The student, teacher
+// and class totals are from SchoolYear, the latest school year of the statistics.
+type PublicStats struct {
+	Schools                   int     `json:"schools"`
+	Districts                 int     `json:"districts"`
+	SchoolYear                string  `json:"school_year"`
+	Students                  int     `json:"students"`
+	Teachers                  int     `json:"teachers"`
+	Classes                   int     `json:"classes"`
+	ConstructionProjects      int     `json:"construction_projects"`
+	ConstructionInvestmentEUR float64 `json:"construction_investment_eur"`
+}
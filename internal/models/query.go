@@ -0,0 +1,16 @@
+package models
+
+// QueryRequest is an ad-hoc read-only SQL query
+type QueryRequest struct {
+	SQL    string        `json:"sql" validate:"required"`
+	Params []interface{} `json:"params,omitempty"` // Values for ? placeholders
+}
+
+// QueryResult holds the rows of a query, cut off at the row limit
+type QueryResult struct {
+	Columns    []string        `json:"columns"`
+	Rows       [][]interface{} `json:"rows"`
+	RowCount   int             `json:"row_count"`
+	Truncated  bool            `json:"truncated"` // More rows matched than the limit allows
+	DurationMS int64           `json:"duration_ms"`
+}
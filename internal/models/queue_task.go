@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// QueueTask is a unit of work on the job queue, such as scraping one school page
+type QueueTask struct {
+	ID        int64      `json:"id" db:"id"`
+	Kind      string     `json:"kind" db:"kind"`
+	Payload   string     `json:"payload" db:"payload"`
+	Attempts  int        `json:"attempts" db:"attempts"` // Deliveries so far, including the current one
+	LastError *string    `json:"last_error,omitempty" db:"last_error"`
+	FailedAt  *time.Time `json:"failed_at,omitempty" db:"failed_at"` // Set once the task used up its attempts
+}
@@ -19,48 +19,129 @@ type School struct {
 	Email          string    `json:"email" db:"email"`                     // Email - Email address
 	Website        string    `json:"website" db:"website"`                 // Internet - Website URL
 	SchoolYear     string    `json:"school_year" db:"school_year"`         // Schuljahr - School year (e.g., "2025/26")
-	Latitude       float64   `json:"latitude" db:"latitude"`               // Geographic coordinate
-	Longitude      float64   `json:"longitude" db:"longitude"`             // Geographic coordinate
+	Latitude       *float64  `json:"latitude" db:"latitude"`               // Geographic coordinate, null when unknown
+	Longitude      *float64  `json:"longitude" db:"longitude"`             // Geographic coordinate, null when unknown
+	Version        int       `json:"version" db:"version"`                 // Incremented on every write, for optimistic concurrency
 	CreatedAt      time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt      time.Time `json:"updated_at" db:"updated_at"`
 }
 
+// Coordinates returns the school's position; ok is false when it has none
+func (s School) Coordinates() (lat, lon float64, ok bool) {
+	if s.Latitude == nil || s.Longitude == nil {
+		return 0, 0, false
+	}
+	return *s.Latitude, *s.Longitude, true
+}
+
+// HasCoordinates reports whether the school can be placed on a map
+func (s School) HasCoordinates() bool {
+	_, _, ok := s.Coordinates()
+	return ok
+}
+
 type CreateSchoolInput struct {
-	SchoolNumber   string  `json:"school_number" validate:"required,min=1,max=50"`
-	Name           string  `json:"name" validate:"required,min=1,max=300"`
-	SchoolType     string  `json:"school_type" validate:"required,min=1,max=100"`
-	Operator       string  `json:"operator" validate:"omitempty,max=100"`
-	SchoolCategory string  `json:"school_category" validate:"omitempty,max=100"`
-	District       string  `json:"district" validate:"omitempty,max=100"`
-	Neighborhood   string  `json:"neighborhood" validate:"omitempty,max=100"`
-	PostalCode     string  `json:"postal_code" validate:"omitempty,max=10"`
-	Street         string  `json:"street" validate:"omitempty,max=200"`
-	HouseNumber    string  `json:"house_number" validate:"omitempty,max=20"`
-	Phone          string  `json:"phone" validate:"omitempty,max=50"`
-	Fax            string  `json:"fax" validate:"omitempty,max=50"`
-	Email          string  `json:"email" validate:"omitempty,email,max=200"`
-	Website        string  `json:"website" validate:"omitempty,url,max=500"`
-	SchoolYear     string  `json:"school_year" validate:"omitempty,max=20"`
-	Latitude       float64 `json:"latitude" validate:"required,latitude"`
-	Longitude      float64 `json:"longitude" validate:"required,longitude"`
+	SchoolNumber   string   `json:"school_number" validate:"required,min=1,max=50"`
+	Name           string   `json:"name" validate:"required,min=1,max=300"`
+	SchoolType     string   `json:"school_type" validate:"required,min=1,max=100"`
+	Operator       string   `json:"operator" validate:"omitempty,max=100"`
+	SchoolCategory string   `json:"school_category" validate:"omitempty,max=100"`
+	District       string   `json:"district" validate:"omitempty,max=100"`
+	Neighborhood   string   `json:"neighborhood" validate:"omitempty,max=100"`
+	PostalCode     string   `json:"postal_code" validate:"omitempty,max=10"`
+	Street         string   `json:"street" validate:"omitempty,max=200"`
+	HouseNumber    string   `json:"house_number" validate:"omitempty,max=20"`
+	Phone          string   `json:"phone" validate:"omitempty,max=50"`
+	Fax            string   `json:"fax" validate:"omitempty,max=50"`
+	Email          string   `json:"email" validate:"omitempty,email,max=200"`
+	Website        string   `json:"website" validate:"omitempty,url,max=500"`
+	SchoolYear     string   `json:"school_year" validate:"omitempty,max=20"`
+	Latitude       *float64 `json:"latitude" validate:"omitempty,latitude"`
+	Longitude      *float64 `json:"longitude" validate:"omitempty,longitude"`
 }
 
+// UpdateSchoolInput changes the non-nil fields of a school; the db tags name the columns
 type UpdateSchoolInput struct {
-	SchoolNumber   *string  `json:"school_number,omitempty" validate:"omitempty,min=1,max=50"`
-	Name           *string  `json:"name,omitempty" validate:"omitempty,min=1,max=300"`
-	SchoolType     *string  `json:"school_type,omitempty" validate:"omitempty,min=1,max=100"`
-	Operator       *string  `json:"operator,omitempty" validate:"omitempty,max=100"`
-	SchoolCategory *string  `json:"school_category,omitempty" validate:"omitempty,max=100"`
-	District       *string  `json:"district,omitempty" validate:"omitempty,max=100"`
-	Neighborhood   *string  `json:"neighborhood,omitempty" validate:"omitempty,max=100"`
-	PostalCode     *string  `json:"postal_code,omitempty" validate:"omitempty,max=10"`
-	Street         *string  `json:"street,omitempty" validate:"omitempty,max=200"`
-	HouseNumber    *string  `json:"house_number,omitempty" validate:"omitempty,max=20"`
-	Phone          *string  `json:"phone,omitempty" validate:"omitempty,max=50"`
-	Fax            *string  `json:"fax,omitempty" validate:"omitempty,max=50"`
-	Email          *string  `json:"email,omitempty" validate:"omitempty,email,max=200"`
-	Website        *string  `json:"website,omitempty" validate:"omitempty,url,max=500"`
-	SchoolYear     *string  `json:"school_year,omitempty" validate:"omitempty,max=20"`
-	Latitude       *float64 `json:"latitude,omitempty" validate:"omitempty,latitude"`
-	Longitude      *float64 `json:"longitude,omitempty" validate:"omitempty,longitude"`
+	SchoolNumber   *string  `json:"school_number,omitempty" validate:"omitempty,min=1,max=50" db:"school_number"`
+	Name           *string  `json:"name,omitempty" validate:"omitempty,min=1,max=300" db:"name"`
+	SchoolType     *string  `json:"school_type,omitempty" validate:"omitempty,min=1,max=100" db:"school_type"`
+	Operator       *string  `json:"operator,omitempty" validate:"omitempty,max=100" db:"operator"`
+	SchoolCategory *string  `json:"school_category,omitempty" validate:"omitempty,max=100" db:"school_category"`
+	District       *string  `json:"district,omitempty" validate:"omitempty,max=100" db:"district"`
+	Neighborhood   *string  `json:"neighborhood,omitempty" validate:"omitempty,max=100" db:"neighborhood"`
+	PostalCode     *string  `json:"postal_code,omitempty" validate:"omitempty,max=10" db:"postal_code"`
+	Street         *string  `json:"street,omitempty" validate:"omitempty,max=200" db:"street"`
+	HouseNumber    *string  `json:"house_number,omitempty" validate:"omitempty,max=20" db:"house_number"`
+	Phone          *string  `json:"phone,omitempty" validate:"omitempty,max=50" db:"phone"`
+	Fax            *string  `json:"fax,omitempty" validate:"omitempty,max=50" db:"fax"`
+	Email          *string  `json:"email,omitempty" validate:"omitempty,email,max=200" db:"email"`
+	Website        *string  `json:"website,omitempty" validate:"omitempty,url,max=500" db:"website"`
+	SchoolYear     *string  `json:"school_year,omitempty" validate:"omitempty,max=20" db:"school_year"`
+	Latitude       *float64 `json:"latitude,omitempty" validate:"omitempty,latitude" db:"latitude"`
+	Longitude      *float64 `json:"longitude,omitempty" validate:"omitempty,longitude" db:"longitude"`
+}
+
+// SchoolBatchUpdate changes the given fields of the school identified by SchoolNumber.
+// The fields are flattened into the same object; the school number itself cannot be
+// changed in a batch (the outer school_number shadows the embedded one).
+type SchoolBatchUpdate struct {
+	SchoolNumber string `json:"school_number" validate:"required,max=50"`
+	Version      *int   `json:"version,omitempty"` // Optional: only apply if the school is still at this version
+	UpdateSchoolInput
+}
+
+// IsEmpty reports whether the update changes no fields
+func (u UpdateSchoolInput) IsEmpty() bool {
+	return u == UpdateSchoolInput{}
+}
+
+// SchoolFilter narrows the schools list to exact field values; ASCII letters compare
+// case-insensitively as in SQLite's NOCASE collation. Empty fields are ignored.
+type SchoolFilter struct {
+	District     string
+	SchoolType   string
+	Operator     string
+	PostalCode   string
+	Neighborhood string
+}
+
+// IsEmpty reports whether the filter keeps all schools
+func (f SchoolFilter) IsEmpty() bool {
+	return f == SchoolFilter{}
+}
+
+// Matches reports whether a school passes the filter, like the query of SchoolRepository.Find
+func (f SchoolFilter) Matches(school School) bool {
+	for _, field := range []struct{ want, got string }{
+		{f.District, school.District},
+		{f.SchoolType, school.SchoolType},
+		{f.Operator, school.Operator},
+		{f.PostalCode, school.PostalCode},
+		{f.Neighborhood, school.Neighborhood},
+	} {
+		if field.want != "" && !equalFoldASCII(field.want, field.got) {
+			return false
+		}
+	}
+	return true
+}
+
+// equalFoldASCII compares like NOCASE: "Neukölln" matches "NEUKöLLN" but not "NEUKÖLLN"
+func equalFoldASCII(a, b string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := 0; i < len(a); i++ {
+		ca, cb := a[i], b[i]
+		if 'A' <= ca && ca <= 'Z' {
+			ca += 'a' - 'A'
+		}
+		if 'A' <= cb && cb <= 'Z' {
+			cb += 'a' - 'A'
+		}
+		if ca != cb {
+			return false
+		}
+	}
+	return true
 }
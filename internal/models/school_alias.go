@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// Sources of a school alias
+const (
+	AliasSourceMapping   = "mapping"   // The maintained mapping file
+	AliasSourceHeuristic = "heuristic" // Detected when a school number disappeared from the feed
+)
+
+// SchoolAlias maps a former school number (BSN) of a renumbered or merged school to its
+// current number, so lookups and imports by the old number reach the current school
+type SchoolAlias struct {
+	OldNumber string    `json:"old_number" db:"old_number"`
+	NewNumber string    `json:"new_number" db:"new_number"`
+	Source    string    `json:"source" db:"source"`
+	Reason    string    `json:"reason" db:"reason"` // e.g. "Fusion 2024" or "same address"
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
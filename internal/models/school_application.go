@@ -0,0 +1,35 @@
+package models
+
+import (
+	"math"
+	"time"
+)
+
+// SchoolApplication is the number of first-choice applications against the places a
+// secondary school offered for one school year (Anmeldezahlen)
+type SchoolApplication struct {
+	ID                      int64     `json:"id" db:"id"`
+	SchoolNumber            string    `json:"school_number" db:"school_number"`
+	SchoolYear              string    `json:"school_year" db:"school_year"`
+	Places                  int       `json:"places" db:"places"`
+	FirstChoiceApplications int       `json:"first_choice_applications" db:"first_choice_applications"`
+	FetchedAt               time.Time `json:"fetched_at" db:"fetched_at"`
+	CreatedAt               time.Time `json:"created_at" db:"created_at"`
+}
+
+// SchoolApplicationInput is a parsed row of the Anmeldezahlen dataset
+type SchoolApplicationInput struct {
+	SchoolNumber            string
+	SchoolYear              string
+	Places                  int
+	FirstChoiceApplications int
+}
+
+// OversubscriptionRatio returns first-choice applications per place, rounded to two decimals.
+// Above 1 the school had more first choices than places. ok is false without places.
+func (a SchoolApplication) OversubscriptionRatio() (ratio float64, ok bool) {
+	if a.Places <= 0 {
+		return 0, false
+	}
+	return math.Round(float64(a.FirstChoiceApplications)/float64(a.Places)*100) / 100, true
+}
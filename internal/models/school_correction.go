@@ -0,0 +1,137 @@
+package models
+
+import "time"
+
+// Review states of a school correction
+const (
+	CorrectionStatusPending  = "pending"
+	CorrectionStatusAccepted = "accepted"
+	CorrectionStatusRejected = "rejected"
+)
+
+// Review decisions of an admin
+const (
+	CorrectionDecisionAccept = "accept"
+	CorrectionDecisionReject = "reject"
+)
+
+// CorrectableFields are the school fields users can suggest fixes for. Type, district and
+// the other classification fields come from the feed only.
+var CorrectableFields = []string{
+	"name", "street", "house_number", "postal_code", "neighborhood",
+	"phone", "fax", "email", "website",
+}
+
+// SchoolCorrection is a fix to one field of a school suggested by a user. Accepted
+// corrections are stored as overrides that survive the next import of the school list.
+type SchoolCorrection struct {
+	ID           int64      `json:"id" db:"id"`
+	SchoolNumber string     `json:"school_number" db:"school_number"`
+	Field        string     `json:"field" db:"field"`
+	CurrentValue string     `json:"current_value" db:"current_value"` // Value of the field when submitted
+	Value        string     `json:"value" db:"value"`                 // Suggested value; empty removes e.g. a dead website
+	Comment      string     `json:"comment" db:"comment"`
+	Status       string     `json:"status" db:"status"`
+	ReviewNote   string     `json:"review_note,omitempty" db:"review_note"`
+	ReviewedBy   string     `json:"reviewed_by,omitempty" db:"reviewed_by"` // Credential of the admin, e.g. "admin_key"
+	SubmittedAt  time.Time  `json:"submitted_at" db:"submitted_at"`
+	ReviewedAt   *time.Time `json:"reviewed_at,omitempty" db:"reviewed_at"`
+}
+
+type CreateCorrectionInput struct {
+	Field   string `json:"field" validate:"required"`
+	Value   string `json:"value"` // Checked against the rules of the field, see CorrectionUpdate
+	Comment string `json:"comment" validate:"max=1000"`
+}
+
+type ReviewCorrectionInput struct {
+	Decision string `json:"decision" validate:"required,oneof=accept reject"`
+	Note     string `json:"note" validate:"max=1000"`
+}
+
+// SchoolOverride is the value of an accepted correction, applied over the school list feed
+type SchoolOverride struct {
+	SchoolNumber string    `json:"school_number" db:"school_number"`
+	Field        string    `json:"field" db:"field"`
+	Value        string    `json:"value" db:"value"`
+	CorrectionID *int64    `json:"correction_id" db:"correction_id"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+}
+
+// correctableField returns the field of a school input, or nil if it cannot be corrected
+func (in *CreateSchoolInput) correctableField(field string) *string {
+	switch field {
+	case "name":
+		return &in.Name
+	case "street":
+		return &in.Street
+	case "house_number":
+		return &in.HouseNumber
+	case "postal_code":
+		return &in.PostalCode
+	case "neighborhood":
+		return &in.Neighborhood
+	case "phone":
+		return &in.Phone
+	case "fax":
+		return &in.Fax
+	case "email":
+		return &in.Email
+	case "website":
+		return &in.Website
+	}
+	return nil
+}
+
+// ApplyOverride sets the overridden field of a school from the feed; false for fields that
+// cannot be corrected
+func (in *CreateSchoolInput) ApplyOverride(field, value string) bool {
+	target := in.correctableField(field)
+	if target == nil {
+		return false
+	}
+	*target = value
+	return true
+}
+
+// CorrectionValue returns the current value of a correctable field of a school
+func (s School) CorrectionValue(field string) (string, bool) {
+	in := CreateSchoolInput{
+		Name: s.Name, Street: s.Street, HouseNumber: s.HouseNumber, PostalCode: s.PostalCode,
+		Neighborhood: s.Neighborhood, Phone: s.Phone, Fax: s.Fax, Email: s.Email, Website: s.Website,
+	}
+	target := in.correctableField(field)
+	if target == nil {
+		return "", false
+	}
+	return *target, true
+}
+
+// CorrectionUpdate returns the school update setting field to value; ok is false for fields
+// that cannot be corrected. The update carries the validation rules of the field.
+func CorrectionUpdate(field, value string) (UpdateSchoolInput, bool) {
+	var update UpdateSchoolInput
+	switch field {
+	case "name":
+		update.Name = &value
+	case "street":
+		update.Street = &value
+	case "house_number":
+		update.HouseNumber = &value
+	case "postal_code":
+		update.PostalCode = &value
+	case "neighborhood":
+		update.Neighborhood = &value
+	case "phone":
+		update.Phone = &value
+	case "fax":
+		update.Fax = &value
+	case "email":
+		update.Email = &value
+	case "website":
+		update.Website = &value
+	default:
+		return UpdateSchoolInput{}, false
+	}
+	return update, true
+}
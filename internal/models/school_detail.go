@@ -1,6 +1,10 @@
 package models
 
-import "time"
+import (
+	"slices"
+	"strings"
+	"time"
+)
 
 // SchoolDetail contains detailed information about a school scraped from the Berlin school directory
 type SchoolDetail struct {
@@ -18,15 +22,143 @@ type SchoolDetail struct {
 	Differentiation        string    `json:"differentiation" db:"differentiation"`                     // Differenzierung - Differentiation methods
 	LunchInfo              string    `json:"lunch_info" db:"lunch_info"`                               // Mittagessen - Lunch information
 	DualLearning           string    `json:"dual_learning" db:"dual_learning"`                         // Duales Lernen - Dual learning programs
+	OperatorOrganization   string    `json:"operator_organization" db:"operator_organization"`         // Träger - Organization running a private school
+	Confession             string    `json:"confession" db:"confession"`                               // Confession of the operator (ConfessionCatholic, ...), empty for none
+	FeeInfo                string    `json:"fee_info" db:"fee_info"`                                   // Schulgeld - Fees as published
+	FeeMinEUR              *int      `json:"fee_min_eur" db:"fee_min_eur"`                             // Lowest monthly fee in euros, nil when not published
+	FeeMaxEUR              *int      `json:"fee_max_eur" db:"fee_max_eur"`                             // Highest monthly fee in euros, nil when not published
+	SupportFocuses         string    `json:"support_focuses" db:"support_focuses"`                     // Förderschwerpunkte - Comma-separated SupportFocus codes
+	WheelchairAccessible   string    `json:"wheelchair_accessible" db:"wheelchair_accessible"`         // Barrierefrei - AccessibilityYes, AccessibilityNo or AccessibilityUnknown
+	AllDayType             string    `json:"all_day_type" db:"all_day_type"`                           // Ganztag - AllDayBound, AllDayOpen or AllDayNone
+	Europaschule           bool      `json:"europaschule" db:"europaschule"`                           // True for a Staatliche Europa-Schule Berlin (SESB)
+	BilingualLanguages     string    `json:"bilingual_languages" db:"bilingual_languages"`             // Comma-separated partner language codes of bilingual branches
+	ReligiousEducation     string    `json:"religious_education" db:"religious_education"`             // Comma-separated ReligiousEducation codes
 	CitizenshipData        string    `json:"citizenship_data" db:"citizenship_data"`                   // JSON: Staatsangehörigkeit statistics
 	LanguageData           string    `json:"language_data" db:"language_data"`                         // JSON: Nichtdeutsche Herkunftssprache statistics
 	ResidenceData          string    `json:"residence_data" db:"residence_data"`                       // JSON: Wohnorte statistics
 	AbsenceData            string    `json:"absence_data" db:"absence_data"`                           // JSON: Fehlzeiten statistics
+	ParserVersion          int       `json:"parser_version" db:"parser_version"`                       // Version of the parser that produced this record
 	ScrapedAt              time.Time `json:"scraped_at" db:"scraped_at"`                               // When this data was scraped
 	CreatedAt              time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt              time.Time `json:"updated_at" db:"updated_at"`
 }
 
+// Confessions of school operators
+const (
+	ConfessionCatholic   = "catholic"
+	ConfessionProtestant = "protestant"
+	ConfessionChristian  = "christian" // Ecumenical or free-church
+	ConfessionJewish     = "jewish"
+	ConfessionIslamic    = "islamic"
+)
+
+// Wheelchair accessibility of a school; unknown when its page does not mention it
+const (
+	AccessibilityYes     = "yes"
+	AccessibilityNo      = "no"
+	AccessibilityUnknown = "unknown"
+)
+
+// All-day schooling (Ganztag) forms
+const (
+	AllDayBound = "bound" // Gebundener Ganztag - Afternoon lessons compulsory for all or some classes
+	AllDayOpen  = "open"  // Offener Ganztag - Optional afternoon care
+	AllDayNone  = "none"
+)
+
+// AllDayTypes lists all all-day schooling forms
+var AllDayTypes = []string{AllDayBound, AllDayOpen, AllDayNone}
+
+// Partner languages of bilingual branches, as ISO 639-1 codes
+const (
+	LanguageEnglish    = "en"
+	LanguageFrench     = "fr"
+	LanguageSpanish    = "es"
+	LanguageItalian    = "it"
+	LanguagePortuguese = "pt"
+	LanguageRussian    = "ru"
+	LanguagePolish     = "pl"
+	LanguageTurkish    = "tr"
+	LanguageGreek      = "el"
+)
+
+// BilingualLanguages lists all partner languages
+var BilingualLanguages = []string{
+	LanguageEnglish, LanguageFrench, LanguageSpanish, LanguageItalian, LanguagePortuguese,
+	LanguageRussian, LanguagePolish, LanguageTurkish, LanguageGreek,
+}
+
+// Religious and worldview education subjects
+const (
+	ReligiousEducationCatholic   = "catholic"
+	ReligiousEducationProtestant = "protestant"
+	ReligiousEducationOrthodox   = "orthodox"
+	ReligiousEducationIslamic    = "islamic"
+	ReligiousEducationAlevi      = "alevi"
+	ReligiousEducationJewish     = "jewish"
+	ReligiousEducationBuddhist   = "buddhist"
+	ReligiousEducationHumanist   = "humanist" // Humanistische Lebenskunde
+	ReligiousEducationEthics     = "ethics"   // Ethik
+)
+
+// ReligiousEducations lists all religious education subjects
+var ReligiousEducations = []string{
+	ReligiousEducationCatholic, ReligiousEducationProtestant, ReligiousEducationOrthodox,
+	ReligiousEducationIslamic, ReligiousEducationAlevi, ReligiousEducationJewish,
+	ReligiousEducationBuddhist, ReligiousEducationHumanist, ReligiousEducationEthics,
+}
+
+// Special-needs support focus areas (sonderpädagogische Förderschwerpunkte)
+const (
+	SupportFocusLearning        = "learning"         // Lernen
+	SupportFocusSpeech          = "speech"           // Sprache
+	SupportFocusEmotionalSocial = "emotional_social" // Emotionale und soziale Entwicklung
+	SupportFocusIntellectual    = "intellectual"     // Geistige Entwicklung
+	SupportFocusPhysicalMotor   = "physical_motor"   // Körperliche und motorische Entwicklung
+	SupportFocusHearing         = "hearing"          // Hören und Kommunikation
+	SupportFocusVision          = "vision"           // Sehen
+	SupportFocusAutism          = "autism"           // Autismus
+)
+
+// SupportFocuses lists all support focus areas
+var SupportFocuses = []string{
+	SupportFocusLearning, SupportFocusSpeech, SupportFocusEmotionalSocial, SupportFocusIntellectual,
+	SupportFocusPhysicalMotor, SupportFocusHearing, SupportFocusVision, SupportFocusAutism,
+}
+
+// SupportFocusList returns the support focus areas of the school
+func (d SchoolDetail) SupportFocusList() []string {
+	return splitCodes(d.SupportFocuses)
+}
+
+// HasSupportFocus reports whether the school supports students with the focus area
+func (d SchoolDetail) HasSupportFocus(focus string) bool {
+	return slices.Contains(d.SupportFocusList(), focus)
+}
+
+// BilingualLanguageList returns the partner languages of the school's bilingual branches
+func (d SchoolDetail) BilingualLanguageList() []string {
+	return splitCodes(d.BilingualLanguages)
+}
+
+// ReligiousEducationList returns the religious education subjects of the school
+func (d SchoolDetail) ReligiousEducationList() []string {
+	return splitCodes(d.ReligiousEducation)
+}
+
+// HasPrograms reports whether the school has a bilingual branch or names religious education
+func (d SchoolDetail) HasPrograms() bool {
+	return d.Europaschule || d.BilingualLanguages != "" || d.ReligiousEducation != ""
+}
+
+// splitCodes splits a comma-separated column into its codes
+func splitCodes(codes string) []string {
+	if codes == "" {
+		return []string{}
+	}
+	return strings.Split(codes, ",")
+}
+
 // StatisticTable represents a generic statistics table with headers and rows
 type StatisticTable struct {
 	Headers []string          `json:"headers"`
@@ -50,9 +182,107 @@ type SchoolDetailData struct {
 	Differentiation        string          `json:"differentiation"`
 	LunchInfo              string          `json:"lunch_info"`
 	DualLearning           string          `json:"dual_learning"`
+	OperatorOrganization   string          `json:"operator_organization"`
+	Confession             string          `json:"confession"`
+	FeeInfo                string          `json:"fee_info"`
+	FeeMinEUR              *int            `json:"fee_min_eur,omitempty"`
+	FeeMaxEUR              *int            `json:"fee_max_eur,omitempty"`
+	SupportFocuses         []string        `json:"support_focuses,omitempty"`
+	WheelchairAccessible   string          `json:"wheelchair_accessible"`
+	AllDayType             string          `json:"all_day_type"`
+	Europaschule           bool            `json:"europaschule"`
+	BilingualLanguages     []string        `json:"bilingual_languages,omitempty"`
+	ReligiousEducation     []string        `json:"religious_education,omitempty"`
 	CitizenshipTable       *StatisticTable `json:"citizenship_table,omitempty"`
 	LanguageTable          *StatisticTable `json:"language_table,omitempty"`
 	ResidenceTable         *StatisticTable `json:"residence_table,omitempty"`
 	AbsenceTable           *StatisticTable `json:"absence_table,omitempty"`
+	GradeStructureTable    *StatisticTable `json:"grade_structure_table,omitempty"` // Classes per grade and school year
+	StaffingTable          *StatisticTable `json:"staffing_table,omitempty"`        // Teachers by qualification and staffing coverage
+	QualityTable           *StatisticTable `json:"quality_table,omitempty"`         // Quality framework ratings from the school inspection
 	ScrapedAt              time.Time       `json:"scraped_at"`
+	ParserVersion          int             `json:"parser_version"`
+	RawPage                *RawSchoolPage  `json:"raw_page,omitempty"` // Raw captures used to re-parse without re-scraping
+}
+
+// RawSchoolPage holds the raw values captured from a school page before parsing
+type RawSchoolPage struct {
+	SchoolNameWithNumber string            `json:"school_name_with_number"`
+	StatisticTablesHTML  map[string]string `json:"statistic_tables_html,omitempty"` // Tab title -> table outerHTML
+}
+
+// ScrapeFailure is a school page that could not be scraped
+type ScrapeFailure struct {
+	URL      string    `json:"url"`
+	Kind     string    `json:"kind"` // One of ScrapeFailureKinds
+	Error    string    `json:"error"`
+	FailedAt time.Time `json:"failed_at"`
+}
+
+// Kinds of scrape failures
+const (
+	ScrapeFailureTimeout        = "timeout"         // The page timeout ran out
+	ScrapeFailureNavigation     = "navigation"      // The page could not be loaded
+	ScrapeFailureElementMissing = "element_missing" // An element every school page has was not found
+	ScrapeFailureParse          = "parse"           // The page was loaded but its content could not be parsed
+	ScrapeFailureOther          = "other"
+)
+
+// ScrapeFailureKinds lists all kinds of scrape failures
+var ScrapeFailureKinds = []string{
+	ScrapeFailureTimeout, ScrapeFailureNavigation, ScrapeFailureElementMissing, ScrapeFailureParse, ScrapeFailureOther,
+}
+
+// ScrapeRun is the outcome of a completed scrape run: the pages that failed after all retries,
+// counted by kind of failure, and the pages skipped when the run ran out of its time budget
+type ScrapeRun struct {
+	ID             int64     `json:"id" db:"id"`
+	Scraper        string    `json:"scraper" db:"scraper"`
+	StartedAt      time.Time `json:"started_at" db:"started_at"`
+	FinishedAt     time.Time `json:"finished_at" db:"finished_at"`
+	Pages          int       `json:"pages" db:"pages"`
+	Failed         int       `json:"failed" db:"failed"`
+	Timeout        int       `json:"timeout" db:"timeout_failures"`
+	Navigation     int       `json:"navigation" db:"navigation_failures"`
+	ElementMissing int       `json:"element_missing" db:"element_missing_failures"`
+	Parse          int       `json:"parse" db:"parse_failures"`
+	Other          int       `json:"other" db:"other_failures"`
+	Skipped        int       `json:"skipped" db:"skipped"`
+	SkippedPages   string    `json:"skipped_pages" db:"skipped_pages"` // Newline-separated URLs of the skipped pages
+}
+
+// SkippedPageList returns the URLs of the pages the run skipped
+func (r ScrapeRun) SkippedPageList() []string {
+	if r.SkippedPages == "" {
+		return []string{}
+	}
+	return strings.Split(r.SkippedPages, "\n")
+}
+
+// AddFailure counts a failed page of kind
+func (r *ScrapeRun) AddFailure(kind string) {
+	r.Failed++
+	switch kind {
+	case ScrapeFailureTimeout:
+		r.Timeout++
+	case ScrapeFailureNavigation:
+		r.Navigation++
+	case ScrapeFailureElementMissing:
+		r.ElementMissing++
+	case ScrapeFailureParse:
+		r.Parse++
+	default:
+		r.Other++
+	}
+}
+
+// FailuresByKind returns the failed pages per kind
+func (r ScrapeRun) FailuresByKind() map[string]int {
+	return map[string]int{
+		ScrapeFailureTimeout:        r.Timeout,
+		ScrapeFailureNavigation:     r.Navigation,
+		ScrapeFailureElementMissing: r.ElementMissing,
+		ScrapeFailureParse:          r.Parse,
+		ScrapeFailureOther:          r.Other,
+	}
 }
@@ -0,0 +1,8 @@
+package models
+
+// SchoolSearchResult is a school matching a full-text search
+type SchoolSearchResult struct {
+	School  School  `json:"school"`
+	Score   float64 `json:"score"`   // Relevance (negated bm25), higher is better; only comparable within one search
+	Snippet string  `json:"snippet"` // Matching text with the search terms in **bold**
+}
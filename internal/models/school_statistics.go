@@ -4,27 +4,29 @@ import "time"
 
 // SchoolCitizenshipStat represents citizenship statistics for a school
 type SchoolCitizenshipStat struct {
-	ID             int64     `json:"id" db:"id"`
-	SchoolNumber   string    `json:"school_number" db:"school_number"`
-	Citizenship    string    `json:"citizenship" db:"citizenship"`         // e.g., "Europa (ohne Deutschland)", "Afrika"
-	FemaleStudents int       `json:"female_students" db:"female_students"` // Schülerinnen
-	MaleStudents   int       `json:"male_students" db:"male_students"`     // Schüler
-	Total          int       `json:"total" db:"total"`                     // Insgesamt
-	ScrapedAt      time.Time `json:"scraped_at" db:"scraped_at"`
-	CreatedAt      time.Time `json:"created_at" db:"created_at"`
+	ID              int64     `json:"id" db:"id"`
+	SchoolNumber    string    `json:"school_number" db:"school_number"`
+	Citizenship     string    `json:"citizenship" db:"citizenship"`           // e.g., "Europa (ohne Deutschland)", "Afrika"
+	FemaleStudents  int       `json:"female_students" db:"female_students"`   // Schülerinnen
+	MaleStudents    int       `json:"male_students" db:"male_students"`       // Schüler
+	DiverseStudents int       `json:"diverse_students" db:"diverse_students"` // divers, in tables with an m/w/d breakdown
+	Total           int       `json:"total" db:"total"`                       // Insgesamt
+	ScrapedAt       time.Time `json:"scraped_at" db:"scraped_at"`
+	CreatedAt       time.Time `json:"created_at" db:"created_at"`
 }
 
 // SchoolLanguageStat represents non-German heritage language statistics for a school
 type SchoolLanguageStat struct {
-	ID                int64     `json:"id" db:"id"`
-	SchoolNumber      string    `json:"school_number" db:"school_number"`
-	TotalStudents     int       `json:"total_students" db:"total_students"`           // Total students
-	NDHFemaleStudents int       `json:"ndh_female_students" db:"ndh_female_students"` // Non-German heritage female
-	NDHMaleStudents   int       `json:"ndh_male_students" db:"ndh_male_students"`     // Non-German heritage male
-	NDHTotal          int       `json:"ndh_total" db:"ndh_total"`                     // Non-German heritage total
-	NDHPercentage     float64   `json:"ndh_percentage" db:"ndh_percentage"`           // Percentage
-	ScrapedAt         time.Time `json:"scraped_at" db:"scraped_at"`
-	CreatedAt         time.Time `json:"created_at" db:"created_at"`
+	ID                 int64     `json:"id" db:"id"`
+	SchoolNumber       string    `json:"school_number" db:"school_number"`
+	TotalStudents      int       `json:"total_students" db:"total_students"`             // Total students
+	NDHFemaleStudents  int       `json:"ndh_female_students" db:"ndh_female_students"`   // Non-German heritage female
+	NDHMaleStudents    int       `json:"ndh_male_students" db:"ndh_male_students"`       // Non-German heritage male
+	NDHDiverseStudents int       `json:"ndh_diverse_students" db:"ndh_diverse_students"` // Non-German heritage diverse (m/w/d tables)
+	NDHTotal           int       `json:"ndh_total" db:"ndh_total"`                       // Non-German heritage total
+	NDHPercentage      float64   `json:"ndh_percentage" db:"ndh_percentage"`             // Percentage
+	ScrapedAt          time.Time `json:"scraped_at" db:"scraped_at"`
+	CreatedAt          time.Time `json:"created_at" db:"created_at"`
 }
 
 // SchoolResidenceStat represents student residence statistics for a school
@@ -37,18 +39,74 @@ type SchoolResidenceStat struct {
 	CreatedAt    time.Time `json:"created_at" db:"created_at"`
 }
 
-// SchoolAbsenceStat represents absence statistics for a school
+// SchoolGradeClasses is the number of classes (Züge) a school has in one grade in a school year
+type SchoolGradeClasses struct {
+	ID           int64     `json:"id" db:"id"`
+	SchoolNumber string    `json:"school_number" db:"school_number"`
+	SchoolYear   string    `json:"school_year" db:"school_year"` // e.g. "2024/25"
+	Grade        int       `json:"grade" db:"grade"`             // Jahrgangsstufe, 1 to 13
+	Classes      int       `json:"classes" db:"classes"`
+	ScrapedAt    time.Time `json:"scraped_at" db:"scraped_at"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+}
+
+// SchoolStaffingStat is the teacher staffing of a school from the personnel section of its page.
+// StaffingCoverage (Ausstattung) is the percentage of the teaching hours the school is entitled
+// to that its teachers cover; nil when the page does not publish it.
+type SchoolStaffingStat struct {
+	ID               int64     `json:"id" db:"id"`
+	SchoolNumber     string    `json:"school_number" db:"school_number"`
+	StaffingCoverage *float64  `json:"staffing_coverage" db:"staffing_coverage"` // Ausstattung in %
+	ScrapedAt        time.Time `json:"scraped_at" db:"scraped_at"`
+	CreatedAt        time.Time `json:"created_at" db:"created_at"`
+
+	Qualifications []SchoolTeacherQualification `json:"qualifications" db:"-"`
+}
+
+// SchoolTeacherQualification is the number of teachers of a school with one qualification
+type SchoolTeacherQualification struct {
+	ID            int64     `json:"id" db:"id"`
+	SchoolNumber  string    `json:"school_number" db:"school_number"`
+	Qualification string    `json:"qualification" db:"qualification"` // e.g. "Lehrkräfte mit Lehramtsbefähigung", "Quereinsteigende"
+	Teachers      int       `json:"teachers" db:"teachers"`
+	ScrapedAt     time.Time `json:"scraped_at" db:"scraped_at"`
+	CreatedAt     time.Time `json:"created_at" db:"created_at"`
+}
+
+// Ratings of the school quality framework, from strongly to weakly developed
+const (
+	QualityRatingA = "A" // stark ausgeprägt
+	QualityRatingB = "B" // eher stark ausgeprägt
+	QualityRatingC = "C" // eher schwach ausgeprägt
+	QualityRatingD = "D" // schwach ausgeprägt
+)
+
+// SchoolQualityIndicator is the rating of one indicator (Qualitätsmerkmal) of the Berlin school
+// quality framework, from the school inspection section of the school page
+type SchoolQualityIndicator struct {
+	ID           int64     `json:"id" db:"id"`
+	SchoolNumber string    `json:"school_number" db:"school_number"`
+	Area         string    `json:"area" db:"area"`           // Qualitätsbereich, e.g. "Lehr- und Lernprozesse"; empty when not published
+	Code         string    `json:"code" db:"code"`           // e.g. "2.1"; empty when the indicator is not numbered
+	Indicator    string    `json:"indicator" db:"indicator"` // e.g. "Unterrichtsgestaltung"
+	Rating       string    `json:"rating" db:"rating"`       // QualityRatingA to QualityRatingD
+	ScrapedAt    time.Time `json:"scraped_at" db:"scraped_at"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+}
+
+// SchoolAbsenceStat represents absence statistics for a school. Rates are percentages; a rate is
+// nil when its row is missing from the published table, so 0% stays distinct from "not reported".
 type SchoolAbsenceStat struct {
 	ID                      int64     `json:"id" db:"id"`
 	SchoolNumber            string    `json:"school_number" db:"school_number"`
-	SchoolAbsenceRate       float64   `json:"school_absence_rate" db:"school_absence_rate"`               // der Schule
-	SchoolUnexcusedRate     float64   `json:"school_unexcused_rate" db:"school_unexcused_rate"`           // der Schule unentschuldigt
-	SchoolTypeAbsenceRate   float64   `json:"school_type_absence_rate" db:"school_type_absence_rate"`     // der Schulart
-	SchoolTypeUnexcusedRate float64   `json:"school_type_unexcused_rate" db:"school_type_unexcused_rate"` // der Schulart unentschuldigt
-	RegionAbsenceRate       float64   `json:"region_absence_rate" db:"region_absence_rate"`               // der Region
-	RegionUnexcusedRate     float64   `json:"region_unexcused_rate" db:"region_unexcused_rate"`           // der Region unentschuldigt
-	BerlinAbsenceRate       float64   `json:"berlin_absence_rate" db:"berlin_absence_rate"`               // in Berlin
-	BerlinUnexcusedRate     float64   `json:"berlin_unexcused_rate" db:"berlin_unexcused_rate"`           // in Berlin unentschuldigt
+	SchoolAbsenceRate       *float64  `json:"school_absence_rate" db:"school_absence_rate"`               // der Schule
+	SchoolUnexcusedRate     *float64  `json:"school_unexcused_rate" db:"school_unexcused_rate"`           // der Schule unentschuldigt
+	SchoolTypeAbsenceRate   *float64  `json:"school_type_absence_rate" db:"school_type_absence_rate"`     // der Schulart
+	SchoolTypeUnexcusedRate *float64  `json:"school_type_unexcused_rate" db:"school_type_unexcused_rate"` // der Schulart unentschuldigt
+	RegionAbsenceRate       *float64  `json:"region_absence_rate" db:"region_absence_rate"`               // der Region
+	RegionUnexcusedRate     *float64  `json:"region_unexcused_rate" db:"region_unexcused_rate"`           // der Region unentschuldigt
+	BerlinAbsenceRate       *float64  `json:"berlin_absence_rate" db:"berlin_absence_rate"`               // in Berlin
+	BerlinUnexcusedRate     *float64  `json:"berlin_unexcused_rate" db:"berlin_unexcused_rate"`           // in Berlin unentschuldigt
 	ScrapedAt               time.Time `json:"scraped_at" db:"scraped_at"`
 	CreatedAt               time.Time `json:"created_at" db:"created_at"`
 }
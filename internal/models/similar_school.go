@@ -0,0 +1,12 @@
+package models
+
+// SimilarSchool is a nearby school of the same type with headline stats for the detail page
+type SimilarSchool struct {
+	School        School   `json:"school"`
+	DistanceKm    float64  `json:"distance_km"`
+	SchoolYear    string   `json:"school_year,omitempty"` // School year of the headline statistics
+	Students      string   `json:"students,omitempty"`
+	Teachers      string   `json:"teachers,omitempty"`
+	Classes       string   `json:"classes,omitempty"`
+	NDHPercentage *float64 `json:"ndh_percentage,omitempty"` // Share of students with non-German heritage language
+}
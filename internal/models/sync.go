@@ -0,0 +1,38 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// SyncChange is one record in the sync change log, with its content as stored
+type SyncChange struct {
+	Entity    string  `db:"entity"`
+	Key       string  `db:"record_key"`
+	Deleted   bool    `db:"deleted"`
+	Content   *string `db:"content"` // JSON object of the record, NULL once deleted
+	CreatedAt string  `db:"created_at"`
+	UpdatedAt string  `db:"updated_at"`
+}
+
+// SyncDelta is what changed in the dataset since a client's last checkpoint, per entity
+type SyncDelta struct {
+	Since      *time.Time                    `json:"since,omitempty"`
+	Checkpoint time.Time                     `json:"checkpoint"` // Pass as since on the next sync
+	Full       bool                          `json:"full"`       // No since given: every record is listed as created
+	Entities   map[string]*SyncEntityChanges `json:"entities"`
+}
+
+// SyncEntityChanges lists records of one entity. Created and updated records carry their
+// full content; deleted records only their key.
+type SyncEntityChanges struct {
+	Created []SyncRecord `json:"created"`
+	Updated []SyncRecord `json:"updated"`
+	Deleted []string     `json:"deleted"`
+}
+
+// SyncRecord is a created or updated record
+type SyncRecord struct {
+	Key  string          `json:"key"`
+	Data json.RawMessage `json:"data"`
+}
@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// CachedTravelTime is an ORS result from an origin grid cell to a school
+type CachedTravelTime struct {
+	Cell            string    `db:"cell"`
+	SchoolID        int64     `db:"school_id"`
+	Mode            string    `db:"mode"`
+	DurationSeconds float64   `db:"duration_seconds"`
+	DistanceMeters  float64   `db:"distance_meters"`
+	ComputedAt      time.Time `db:"computed_at"`
+}
@@ -0,0 +1,11 @@
+package models
+
+// UsageRollup is the traffic of one API key on one day (UTC). Subject identifies the key
+// (e.g. "partner_key:2"), never its value.
+type UsageRollup struct {
+	Day      string `json:"day" db:"day"`
+	Subject  string `json:"subject" db:"subject"`
+	Requests int64  `json:"requests" db:"requests"`
+	Bytes    int64  `json:"bytes" db:"bytes"`       // Response body bytes
+	Rejected int64  `json:"rejected" db:"rejected"` // Requests refused because the quota was used up
+}
@@ -0,0 +1,180 @@
+package prompts
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+	"text/template"
+
+	"schools-be/internal/models"
+)
+
+// Names of the built-in prompt templates
+const (
+	SchoolSummaryTemplate  = "school_summary"
+	SchoolQuestionTemplate = "school_question"
+	ChatAdvisorTemplate    = "chat_advisor"
+)
+
+const templateExt = ".tmpl"
+
+//go:embed templates/*.tmpl
+var embedded embed.FS
+
+// Set holds the parsed prompt templates, keyed by file name without extension
+type Set struct {
+	templates map[string]*template.Template
+}
+
+// Load parses all *.tmpl prompt templates. When dir is empty the templates compiled into the
+// binary are used; otherwise they are read from dir, so prompts can be edited without recompiling.
+func Load(dir string) (*Set, error) {
+	var fsys fs.FS
+	if dir == "" {
+		sub, err := fs.Sub(embedded, "templates")
+		if err != nil {
+			return nil, fmt.Errorf("failed to open embedded templates: %w", err)
+		}
+		fsys = sub
+	} else {
+		fsys = os.DirFS(dir)
+	}
+
+	files, err := fs.Glob(fsys, "*"+templateExt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list prompt templates: %w", err)
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no prompt templates found in %q", dir)
+	}
+
+	set := &Set{templates: make(map[string]*template.Template, len(files))}
+	for _, file := range files {
+		content, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read prompt template %s: %w", file, err)
+		}
+
+		name := strings.TrimSuffix(path.Base(file), templateExt)
+		tmpl, err := template.New(name).Funcs(funcs).Option("missingkey=error").Parse(string(content))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse prompt template %s: %w", file, err)
+		}
+		set.templates[name] = tmpl
+	}
+
+	return set, nil
+}
+
+// Has reports whether a template with the given name was loaded
+func (s *Set) Has(name string) bool {
+	_, ok := s.templates[name]
+	return ok
+}
+
+// Render executes the named template with data
+func (s *Set) Render(name string, data any) (string, error) {
+	tmpl, ok := s.templates[name]
+	if !ok {
+		return "", fmt.Errorf("prompt template %q not found", name)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to render prompt template %q: %w", name, err)
+	}
+	return buf.String(), nil
+}
+
+var funcs = template.FuncMap{
+	"orNA": func(s string) string {
+		if s == "" {
+			return "N/A"
+		}
+		return s
+	},
+	"yesNo": func(b bool) string {
+		if b {
+			return "Yes"
+		}
+		return "No"
+	},
+	"percent": func(v *float64) string {
+		if v == nil {
+			return "not reported"
+		}
+		return fmt.Sprintf("%.1f%%", *v)
+	},
+}
+
+// SchoolData is the data passed to school prompt templates
+type SchoolData struct {
+	School             models.School
+	Details            *models.SchoolDetail
+	LatestStatistic    *models.SchoolStatistic
+	LanguageStat       *models.SchoolLanguageStat
+	CitizenshipTotal   *models.SchoolCitizenshipStat  // The "Insgesamt" row, if present
+	CitizenshipRegions []models.SchoolCitizenshipStat // Up to 5 regions, excluding the total row
+	TopResidences      []models.SchoolResidenceStat   // Up to 5 districts where students live
+	AbsenceStat        *models.SchoolAbsenceStat
+}
+
+// NewSchoolData prepares the template data for an enriched school
+func NewSchoolData(data *models.EnrichedSchool) SchoolData {
+	d := SchoolData{
+		School:       data.School,
+		Details:      data.Details,
+		LanguageStat: data.LanguageStat,
+		AbsenceStat:  data.AbsenceStat,
+	}
+
+	if len(data.Statistics) > 0 {
+		d.LatestStatistic = &data.Statistics[0]
+	}
+
+	for i := range data.CitizenshipStats {
+		if isCitizenshipTotal(data.CitizenshipStats[i].Citizenship) {
+			d.CitizenshipTotal = &data.CitizenshipStats[i]
+			break
+		}
+	}
+	for _, stat := range data.CitizenshipStats {
+		if !isCitizenshipTotal(stat.Citizenship) && len(d.CitizenshipRegions) < 5 {
+			d.CitizenshipRegions = append(d.CitizenshipRegions, stat)
+		}
+	}
+
+	d.TopResidences = data.ResidenceStats
+	if len(d.TopResidences) > 5 {
+		d.TopResidences = d.TopResidences[:5]
+	}
+
+	return d
+}
+
+func isCitizenshipTotal(citizenship string) bool {
+	return citizenship == "Insgesamt" || citizenship == "insgesamt"
+}
+
+// Field is a single flattened field of a school record, e.g. "details.lunch_info"
+type Field struct {
+	Path  string
+	Value string
+}
+
+// QuestionData is the data passed to the school question template
+type QuestionData struct {
+	SchoolName string
+	Question   string
+	Fields     []Field
+}
+
+// ChatData is the data passed to the chat advisor template; SchoolName is empty for general sessions
+type ChatData struct {
+	SchoolName string
+	Fields     []Field
+}
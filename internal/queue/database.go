@@ -0,0 +1,105 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"schools-be/internal/models"
+	"schools-be/internal/repository"
+)
+
+// Database is the queue in the shared database. All instances consume from it, and a task
+// claimed by a consumer that died is delivered again after the visibility timeout, so retries
+// do not depend on any process staying up.
+type Database struct {
+	repo   *repository.QueueRepository
+	opts   Options
+	logger *slog.Logger
+}
+
+func NewDatabase(repo *repository.QueueRepository, opts Options, logger *slog.Logger) *Database {
+	return &Database{
+		repo:   repo,
+		opts:   opts.withDefaults(),
+		logger: logger.With(slog.String("component", "queue")),
+	}
+}
+
+func (q *Database) Enqueue(ctx context.Context, kind string, payloads ...string) error {
+	return q.repo.Add(ctx, kind, payloads)
+}
+
+func (q *Database) Consume(ctx context.Context, kind string, handle Handler) {
+	for ctx.Err() == nil {
+		task, err := q.repo.Claim(ctx, kind, q.opts.Holder, q.opts.VisibilityTimeout)
+		if err != nil {
+			if ctx.Err() == nil {
+				q.logger.Error("failed to claim queue task", slog.String("kind", kind), slog.String("error", err.Error()))
+			}
+			sleep(ctx, q.opts.PollInterval)
+			continue
+		}
+		if task == nil {
+			sleep(ctx, q.opts.PollInterval)
+			continue
+		}
+
+		if task.Attempts > q.opts.MaxAttempts {
+			// Its consumers kept dying before finishing it
+			q.finish(task, fmt.Errorf("abandoned by its consumer %d times", task.Attempts-1))
+			continue
+		}
+		q.finish(task, handle(ctx, *task))
+	}
+}
+
+// finish records the outcome of a delivery; it outlives the consumer's context so a task
+// interrupted by shutdown is released for another consumer
+func (q *Database) finish(task *models.QueueTask, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var recordErr error
+	switch {
+	case err == nil:
+		recordErr = q.repo.Complete(ctx, task.ID, q.opts.Holder)
+		queueTasks.Inc(task.Kind, "done")
+	case task.Attempts >= q.opts.MaxAttempts:
+		recordErr = q.repo.Fail(ctx, task.ID, q.opts.Holder, err.Error())
+		queueTasks.Inc(task.Kind, "failed")
+	default:
+		recordErr = q.repo.Retry(ctx, task.ID, q.opts.Holder, time.Now().Add(q.opts.RetryDelay), err.Error())
+		queueTasks.Inc(task.Kind, "retried")
+	}
+
+	if err != nil {
+		q.logger.Warn("queue task failed",
+			slog.String("kind", task.Kind),
+			slog.String("payload", task.Payload),
+			slog.Int("attempt", task.Attempts),
+			slog.String("error", err.Error()),
+		)
+	}
+	if recordErr != nil {
+		// The task is delivered again once its visibility timeout passed
+		q.logger.Error("failed to record queue task outcome", slog.Int64("task_id", task.ID), slog.String("error", recordErr.Error()))
+	}
+}
+
+func (q *Database) Pending(ctx context.Context, kind string) (int, error) {
+	return q.repo.CountPending(ctx, kind)
+}
+
+func (q *Database) Failed(ctx context.Context, kind string) ([]models.QueueTask, error) {
+	return q.repo.GetFailed(ctx, kind)
+}
+
+func (q *Database) Drop(ctx context.Context, kind string) ([]string, error) {
+	return q.repo.DeletePending(ctx, kind)
+}
+
+func (q *Database) Clear(ctx context.Context, kind string) error {
+	return q.repo.DeleteKind(ctx, kind)
+}
@@ -0,0 +1,170 @@
+package queue
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+
+	"schools-be/internal/models"
+)
+
+// Memory is the in-process queue. Its tasks are lost when the process ends.
+type Memory struct {
+	opts   Options
+	logger *slog.Logger
+
+	mu     sync.Mutex
+	nextID int64
+	tasks  map[string][]*memoryTask
+	wake   chan struct{} // Signalled when tasks are added
+}
+
+type memoryTask struct {
+	task        models.QueueTask
+	availableAt time.Time
+	inFlight    bool
+}
+
+func NewMemory(opts Options, logger *slog.Logger) *Memory {
+	return &Memory{
+		opts:   opts.withDefaults(),
+		logger: logger.With(slog.String("component", "queue")),
+		tasks:  make(map[string][]*memoryTask),
+		wake:   make(chan struct{}, 1),
+	}
+}
+
+func (q *Memory) Enqueue(_ context.Context, kind string, payloads ...string) error {
+	q.mu.Lock()
+	now := time.Now()
+	for _, payload := range payloads {
+		q.nextID++
+		q.tasks[kind] = append(q.tasks[kind], &memoryTask{
+			task:        models.QueueTask{ID: q.nextID, Kind: kind, Payload: payload},
+			availableAt: now,
+		})
+	}
+	q.mu.Unlock()
+
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+func (q *Memory) Consume(ctx context.Context, kind string, handle Handler) {
+	for ctx.Err() == nil {
+		t := q.claim(kind)
+		if t == nil {
+			select {
+			case <-ctx.Done():
+			case <-q.wake:
+			case <-time.After(q.opts.PollInterval):
+			}
+			continue
+		}
+
+		err := handle(ctx, t.task)
+
+		q.mu.Lock()
+		t.inFlight = false
+		switch {
+		case err == nil:
+			q.remove(kind, t)
+			queueTasks.Inc(kind, "done")
+		case t.task.Attempts >= q.opts.MaxAttempts:
+			message, now := err.Error(), time.Now()
+			t.task.LastError, t.task.FailedAt = &message, &now
+			queueTasks.Inc(kind, "failed")
+		default:
+			message := err.Error()
+			t.task.LastError = &message
+			t.availableAt = time.Now().Add(q.opts.RetryDelay)
+			queueTasks.Inc(kind, "retried")
+		}
+		q.mu.Unlock()
+
+		if err != nil {
+			q.logger.Warn("queue task failed",
+				slog.String("kind", kind),
+				slog.String("payload", t.task.Payload),
+				slog.Int("attempt", t.task.Attempts),
+				slog.String("error", err.Error()),
+			)
+		}
+	}
+}
+
+// claim marks the oldest available task of kind as in flight
+func (q *Memory) claim(kind string) *memoryTask {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	now := time.Now()
+	for _, t := range q.tasks[kind] {
+		if !t.inFlight && t.task.FailedAt == nil && !t.availableAt.After(now) {
+			t.inFlight = true
+			t.task.Attempts++
+			return t
+		}
+	}
+	return nil
+}
+
+// remove deletes t from kind; q.mu must be held
+func (q *Memory) remove(kind string, t *memoryTask) {
+	q.tasks[kind] = slices.DeleteFunc(q.tasks[kind], func(other *memoryTask) bool { return other == t })
+}
+
+func (q *Memory) Pending(_ context.Context, kind string) (int, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	count := 0
+	for _, t := range q.tasks[kind] {
+		if t.task.FailedAt == nil {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (q *Memory) Failed(_ context.Context, kind string) ([]models.QueueTask, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var failed []models.QueueTask
+	for _, t := range q.tasks[kind] {
+		if t.task.FailedAt != nil {
+			failed = append(failed, t.task)
+		}
+	}
+	return failed, nil
+}
+
+func (q *Memory) Drop(_ context.Context, kind string) ([]string, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var dropped []string
+	q.tasks[kind] = slices.DeleteFunc(q.tasks[kind], func(t *memoryTask) bool {
+		if t.inFlight || t.task.FailedAt != nil {
+			return false
+		}
+		dropped = append(dropped, t.task.Payload)
+		return true
+	})
+	return dropped, nil
+}
+
+func (q *Memory) Clear(_ context.Context, kind string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	// A task in flight finishes normally and is then no longer found
+	delete(q.tasks, kind)
+	return nil
+}
@@ -0,0 +1,90 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"schools-be/internal/metrics"
+	"schools-be/internal/models"
+	"schools-be/internal/repository"
+)
+
+// Queue drivers (QUEUE_DRIVER)
+const (
+	DriverMemory   = "memory"
+	DriverDatabase = "database"
+)
+
+var queueTasks = metrics.NewCounterVec("schools_queue_tasks_total",
+	"Number of queue task deliveries by outcome (done, retried, failed)", "kind", "outcome")
+
+// Handler processes a task. An error makes the queue deliver the task again later, until it
+// used up its attempts.
+type Handler func(ctx context.Context, task models.QueueTask) error
+
+// Queue sits between the jobs producing work, such as the details scrape listing all school
+// pages, and the consumers doing it. Drivers beyond the in-process and database ones, such as
+// NATS or Redis, implement this interface.
+type Queue interface {
+	// Enqueue adds one task of kind per payload
+	Enqueue(ctx context.Context, kind string, payloads ...string) error
+	// Consume runs handle for the tasks of kind, one at a time, until ctx is done
+	Consume(ctx context.Context, kind string, handle Handler)
+	// Pending counts the tasks of kind that are neither done nor failed
+	Pending(ctx context.Context, kind string) (int, error)
+	// Failed returns the tasks of kind that used up their attempts
+	Failed(ctx context.Context, kind string) ([]models.QueueTask, error)
+	// Drop removes the pending tasks of kind that are not in flight and returns their payloads;
+	// tasks in flight finish, failed tasks are kept
+	Drop(ctx context.Context, kind string) ([]string, error)
+	// Clear drops all tasks of kind, pending and failed
+	Clear(ctx context.Context, kind string) error
+}
+
+// Options control deliveries
+type Options struct {
+	MaxAttempts int           // Deliveries of a task before it is failed
+	RetryDelay  time.Duration // Wait before a failed task is delivered again
+
+	// Database driver only
+	Holder            string        // Name of this consumer (INSTANCE_ID)
+	VisibilityTimeout time.Duration // A task not finished this long after its delivery, because its consumer died, is delivered again
+	PollInterval      time.Duration // How often an idle consumer checks for new tasks
+}
+
+func (o Options) withDefaults() Options {
+	if o.MaxAttempts <= 0 {
+		o.MaxAttempts = 3
+	}
+	if o.VisibilityTimeout <= 0 {
+		o.VisibilityTimeout = 15 * time.Minute
+	}
+	if o.PollInterval <= 0 {
+		o.PollInterval = 2 * time.Second
+	}
+	return o
+}
+
+// New returns the queue of driver: in-process, so tasks are lost on restart, or in the
+// database, shared by all instances and kept across restarts
+func New(driver string, repo *repository.QueueRepository, opts Options, logger *slog.Logger) (Queue, error) {
+	switch driver {
+	case DriverMemory:
+		return NewMemory(opts, logger), nil
+	case DriverDatabase:
+		return NewDatabase(repo, opts, logger), nil
+	}
+	return nil, fmt.Errorf("unknown queue driver: %s", driver)
+}
+
+// sleep waits for d unless ctx is done first
+func sleep(ctx context.Context, d time.Duration) {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+	case <-timer.C:
+	}
+}
@@ -0,0 +1,178 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"schools-be/internal/database"
+	"schools-be/internal/models"
+	"schools-be/internal/repository"
+)
+
+var testLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+func newTestQueues(t *testing.T, opts Options) map[string]Queue {
+	t.Helper()
+	db, err := database.New(filepath.Join(t.TempDir(), "test.db"), 2, time.Second, testLogger)
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := database.RunMigrations(db.Writer, testLogger); err != nil {
+		t.Fatalf("run migrations: %v", err)
+	}
+
+	return map[string]Queue{
+		DriverMemory:   NewMemory(opts, testLogger),
+		DriverDatabase: NewDatabase(repository.NewQueueRepository(db), opts, testLogger),
+	}
+}
+
+// waitDrained waits until kind has no pending tasks
+func waitDrained(t *testing.T, q Queue, kind string) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		pending, err := q.Pending(context.Background(), kind)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if pending == 0 {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%d tasks still pending", pending)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestQueueRetriesAndFails(t *testing.T) {
+	opts := Options{MaxAttempts: 2, Holder: "test", PollInterval: 10 * time.Millisecond}
+	for driver, q := range newTestQueues(t, opts) {
+		t.Run(driver, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			if err := q.Enqueue(ctx, "page", "ok", "flaky", "broken"); err != nil {
+				t.Fatal(err)
+			}
+
+			var mu sync.Mutex
+			attempts := map[string]int{}
+			done := map[string]bool{}
+			go q.Consume(ctx, "page", func(_ context.Context, task models.QueueTask) error {
+				mu.Lock()
+				defer mu.Unlock()
+				attempts[task.Payload]++
+				if attempts[task.Payload] != task.Attempts {
+					t.Errorf("%s: task reports attempt %d, handler saw %d", task.Payload, task.Attempts, attempts[task.Payload])
+				}
+				switch {
+				case task.Payload == "broken", task.Payload == "flaky" && task.Attempts == 1:
+					return errors.New("scrape failed")
+				}
+				done[task.Payload] = true
+				return nil
+			})
+			waitDrained(t, q, "page")
+
+			mu.Lock()
+			if !done["ok"] || !done["flaky"] || done["broken"] || attempts["broken"] != 2 {
+				t.Errorf("done %v after attempts %v", done, attempts)
+			}
+			mu.Unlock()
+
+			failed, err := q.Failed(ctx, "page")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(failed) != 1 || failed[0].Payload != "broken" || failed[0].FailedAt == nil ||
+				failed[0].LastError == nil || *failed[0].LastError != "scrape failed" {
+				t.Errorf("failed tasks %+v", failed)
+			}
+
+			if err := q.Clear(ctx, "page"); err != nil {
+				t.Fatal(err)
+			}
+			if failed, _ := q.Failed(ctx, "page"); len(failed) != 0 {
+				t.Errorf("failed tasks left after Clear: %+v", failed)
+			}
+		})
+	}
+}
+
+func TestDatabaseQueueRedeliversAbandonedTasks(t *testing.T) {
+	opts := Options{MaxAttempts: 3, Holder: "second", PollInterval: 10 * time.Millisecond}
+	q := newTestQueues(t, opts)[DriverDatabase].(*Database)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if err := q.Enqueue(ctx, "page", "school"); err != nil {
+		t.Fatal(err)
+	}
+	// A consumer that claims the task and dies without finishing it
+	if task, err := q.repo.Claim(ctx, "page", "first", time.Millisecond); err != nil || task == nil {
+		t.Fatalf("claim: %v, %v", task, err)
+	}
+	time.Sleep(5 * time.Millisecond)
+
+	delivered := make(chan models.QueueTask, 1)
+	go q.Consume(ctx, "page", func(_ context.Context, task models.QueueTask) error {
+		delivered <- task
+		return nil
+	})
+	select {
+	case task := <-delivered:
+		if task.Attempts != 2 {
+			t.Errorf("redelivered task has attempt %d, want 2", task.Attempts)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("abandoned task not redelivered")
+	}
+	waitDrained(t, q, "page")
+}
+
+func TestQueueDropKeepsTasksInFlight(t *testing.T) {
+	opts := Options{MaxAttempts: 2, Holder: "test", PollInterval: 10 * time.Millisecond}
+	for driver, q := range newTestQueues(t, opts) {
+		t.Run(driver, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			if err := q.Enqueue(ctx, "page", "first", "second", "third"); err != nil {
+				t.Fatal(err)
+			}
+
+			started, release := make(chan string, 3), make(chan struct{})
+			go q.Consume(ctx, "page", func(_ context.Context, task models.QueueTask) error {
+				started <- task.Payload
+				<-release
+				return nil
+			})
+			if payload := <-started; payload != "first" {
+				t.Fatalf("first delivered task %q, want first", payload)
+			}
+
+			dropped, err := q.Drop(ctx, "page")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(dropped) != 2 || dropped[0] != "second" || dropped[1] != "third" {
+				t.Errorf("dropped %v, want [second third]", dropped)
+			}
+
+			close(release)
+			waitDrained(t, q, "page")
+			if len(started) != 0 {
+				t.Errorf("dropped task %q delivered", <-started)
+			}
+		})
+	}
+}
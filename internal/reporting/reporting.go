@@ -0,0 +1,110 @@
+// Package reporting forwards panics to a Sentry-compatible error tracker (Sentry, GlitchTip,
+// ...), with their stack trace and tags such as the request ID, route or job. No request
+// bodies, query strings or headers are sent.
+package reporting
+
+import (
+	"fmt"
+	"log/slog"
+	"runtime"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// modulePrefix marks frames of this application as in-app in the error tracker
+const modulePrefix = "schools-be/"
+
+// Reporter sends events to a Sentry project through its own sentry-go client, leaving the
+// SDK's global hub untouched. A nil Reporter discards events, so callers need not check
+// whether reporting is configured.
+type Reporter struct {
+	client *sentry.Client
+	logger *slog.Logger
+}
+
+// New creates a reporter for a DSN of the form https://<key>@<host>/<project>; self-hosted
+// trackers may live under a path prefix, https://<key>@<host>/<prefix>/<project>. An empty
+// DSN disables reporting and returns nil.
+func New(dsn, environment string, logger *slog.Logger) (*Reporter, error) {
+	if dsn == "" {
+		return nil, nil
+	}
+
+	client, err := sentry.NewClient(sentry.ClientOptions{
+		Dsn:            dsn,
+		Environment:    environment,
+		DisableMetrics: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid error reporting DSN: %w", err)
+	}
+	return &Reporter{
+		client: client,
+		logger: logger.With(slog.String("component", "reporting")),
+	}, nil
+}
+
+// CapturePanic reports a recovered panic in the background. It must be called from the
+// deferred function that recovered, so the stack of the panicking goroutine is still intact.
+func (r *Reporter) CapturePanic(recovered interface{}, tags map[string]string) {
+	if r == nil {
+		return
+	}
+
+	event := sentry.NewEvent()
+	event.Level = sentry.LevelFatal
+	event.Logger = "panic"
+	event.Exception = []sentry.Exception{{
+		Type:       fmt.Sprintf("panic (%T)", recovered),
+		Value:      fmt.Sprint(recovered),
+		Stacktrace: &sentry.Stacktrace{Frames: panicFrames()},
+		Mechanism:  &sentry.Mechanism{Type: "recover", Handled: sentry.Pointer(false)},
+	}}
+
+	scope := sentry.NewScope()
+	scope.SetTags(tags)
+	r.client.CaptureEvent(event, nil, scope)
+}
+
+// Flush waits until pending events are sent or the timeout has passed
+func (r *Reporter) Flush(timeout time.Duration) {
+	if r == nil {
+		return
+	}
+
+	if !r.client.Flush(timeout) {
+		r.logger.Warn("timed out sending error reports")
+	}
+}
+
+// panicFrames returns the stack of the panicking goroutine below runtime.gopanic, oldest
+// call first as the error tracker expects. sentry.NewStacktrace would start at the deferred
+// function that recovered instead. Runtime frames, such as the map assignment that raised a
+// runtime error, are left out so the stack ends at the panicking function.
+func panicFrames() []sentry.Frame {
+	pcs := make([]uintptr, 64)
+	n := runtime.Callers(1, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+
+	var stack []sentry.Frame
+	panicking := false
+	for {
+		frame, more := frames.Next()
+		if panicking && !strings.HasPrefix(frame.Function, "runtime.") {
+			f := sentry.NewFrame(frame)
+			f.InApp = strings.HasPrefix(f.Module, modulePrefix)
+			stack = append(stack, f)
+		} else if frame.Function == "runtime.gopanic" {
+			panicking = true
+		}
+		if !more {
+			break
+		}
+	}
+
+	slices.Reverse(stack)
+	return stack
+}
@@ -0,0 +1,265 @@
+package reporting
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// tracker is an error tracker recording the envelopes it receives
+type tracker struct {
+	server *httptest.Server
+
+	mu       sync.Mutex
+	requests []trackerRequest
+}
+
+type trackerRequest struct {
+	path string
+	auth string
+	body []byte
+}
+
+func newTracker(t *testing.T) *tracker {
+	t.Helper()
+	tr := &tracker{}
+	tr.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		tr.mu.Lock()
+		tr.requests = append(tr.requests, trackerRequest{path: r.URL.Path, auth: r.Header.Get("X-Sentry-Auth"), body: body})
+		tr.mu.Unlock()
+		w.Write([]byte(`{}`))
+	}))
+	t.Cleanup(tr.server.Close)
+	return tr
+}
+
+// dsn returns a DSN of the tracker with the given path, e.g. "/42"
+func (tr *tracker) dsn(path string) string {
+	return strings.Replace(tr.server.URL, "http://", "http://public-key@", 1) + path
+}
+
+func (tr *tracker) received() []trackerRequest {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	return append([]trackerRequest(nil), tr.requests...)
+}
+
+func testLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		dsn     string
+		wantErr bool
+	}{
+		{"project", "https://key@sentry.example.com/42", false},
+		{"path prefix", "https://key@example.com/sentry/42", false},
+		{"without key", "https://sentry.example.com/42", true},
+		{"without project", "https://key@sentry.example.com/", true},
+		{"unknown scheme", "ftp://key@sentry.example.com/42", true},
+		{"not a URL", "://", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reporter, err := New(tt.dsn, "test", testLogger())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("New(%q) error = %v, wantErr %v", tt.dsn, err, tt.wantErr)
+			}
+			if !tt.wantErr && reporter == nil {
+				t.Errorf("New(%q) = nil, want a reporter", tt.dsn)
+			}
+		})
+	}
+
+	reporter, err := New("", "test", testLogger())
+	if reporter != nil || err != nil {
+		t.Errorf("New(\"\") = %v, %v, want a nil reporter", reporter, err)
+	}
+}
+
+func TestNilReporter(t *testing.T) {
+	var reporter *Reporter
+	reporter.CapturePanic("boom", nil)
+	reporter.Flush(time.Second)
+}
+
+// capture panics in panicking and reports it, as a deferred recover does
+func capture(reporter *Reporter, tags map[string]string) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			reporter.CapturePanic(rec, tags)
+		}
+	}()
+	panicking()
+}
+
+func panicking() {
+	var m map[string]int
+	m["school"] = 1
+}
+
+func TestCapturePanic(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     string
+		endpoint string
+	}{
+		{"project", "/42", "/api/42/envelope/"},
+		{"path prefix", "/sentry/42", "/sentry/api/42/envelope/"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr := newTracker(t)
+			reporter, err := New(tr.dsn(tt.path), "test", testLogger())
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+
+			capture(reporter, map[string]string{"route": "/api/v1/schools/{id}", "request_id": "req-1"})
+			reporter.Flush(5 * time.Second)
+
+			requests := tr.received()
+			if len(requests) != 1 {
+				t.Fatalf("tracker received %d requests, want 1", len(requests))
+			}
+			req := requests[0]
+			if req.path != tt.endpoint {
+				t.Errorf("path = %q, want %q", req.path, tt.endpoint)
+			}
+			if !strings.Contains(req.auth, "sentry_key=public-key") {
+				t.Errorf("X-Sentry-Auth = %q, want the DSN's key", req.auth)
+			}
+
+			envelope, item, event := parseEnvelope(t, req.body)
+			if envelope.EventID == "" || envelope.EventID != event.EventID || envelope.DSN != tr.dsn(tt.path) {
+				t.Errorf("envelope header = %+v, want the event ID %q and DSN", envelope, event.EventID)
+			}
+			if item.Type != "event" {
+				t.Errorf("item type = %q, want event", item.Type)
+			}
+			checkEvent(t, event)
+		})
+	}
+}
+
+type envelopeHeader struct {
+	EventID string `json:"event_id"`
+	DSN     string `json:"dsn"`
+}
+
+type itemHeader struct {
+	Type   string `json:"type"`
+	Length int    `json:"length"`
+}
+
+type event struct {
+	EventID     string            `json:"event_id"`
+	Level       string            `json:"level"`
+	Environment string            `json:"environment"`
+	Tags        map[string]string `json:"tags"`
+	Request     json.RawMessage   `json:"request"`
+	Exception   []struct {
+		Type      string `json:"type"`
+		Value     string `json:"value"`
+		Mechanism struct {
+			Type    string `json:"type"`
+			Handled *bool  `json:"handled"`
+		} `json:"mechanism"`
+		Stacktrace struct {
+			Frames []struct {
+				Function string `json:"function"`
+				Module   string `json:"module"`
+				InApp    bool   `json:"in_app"`
+			} `json:"frames"`
+		} `json:"stacktrace"`
+	} `json:"exception"`
+}
+
+// parseEnvelope splits an envelope into its header, the header of its single item and the
+// item, an event
+func parseEnvelope(t *testing.T, body []byte) (envelopeHeader, itemHeader, event) {
+	t.Helper()
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	scanner.Buffer(nil, 1<<20)
+	var lines [][]byte
+	for scanner.Scan() {
+		lines = append(lines, bytes.Clone(scanner.Bytes()))
+	}
+	if len(lines) != 3 {
+		t.Fatalf("envelope has %d lines, want 3:\n%s", len(lines), body)
+	}
+
+	var envelope envelopeHeader
+	var item itemHeader
+	var ev event
+	for i, v := range []any{&envelope, &item, &ev} {
+		if err := json.Unmarshal(lines[i], v); err != nil {
+			t.Fatalf("envelope line %d: %v", i+1, err)
+		}
+	}
+	if item.Length != 0 && item.Length != len(lines[2]) {
+		t.Errorf("item length = %d, want %d", item.Length, len(lines[2]))
+	}
+	return envelope, item, ev
+}
+
+func checkEvent(t *testing.T, ev event) {
+	t.Helper()
+	if ev.Level != "fatal" || ev.Environment != "test" {
+		t.Errorf("level, environment = %q, %q, want fatal, test", ev.Level, ev.Environment)
+	}
+	if ev.Tags["route"] != "/api/v1/schools/{id}" || ev.Tags["request_id"] != "req-1" {
+		t.Errorf("tags = %v, want the route and request ID", ev.Tags)
+	}
+	if ev.Request != nil {
+		t.Errorf("request = %s, want none", ev.Request)
+	}
+	if len(ev.Exception) != 1 {
+		t.Fatalf("exceptions = %d, want 1", len(ev.Exception))
+	}
+
+	exception := ev.Exception[0]
+	if exception.Type != "panic (runtime.plainError)" {
+		t.Errorf("type = %q, want panic (runtime.plainError)", exception.Type)
+	}
+	if exception.Value != "assignment to entry in nil map" {
+		t.Errorf("value = %q, want the panic message", exception.Value)
+	}
+	if exception.Mechanism.Type != "recover" || exception.Mechanism.Handled == nil || *exception.Mechanism.Handled {
+		t.Errorf("mechanism = %+v, want an unhandled recover", exception.Mechanism)
+	}
+
+	// Frames are oldest first, so the last frame is the one that panicked
+	frames := exception.Stacktrace.Frames
+	if len(frames) < 2 {
+		t.Fatalf("frames = %+v, want the stack up to the panic", frames)
+	}
+	top := frames[len(frames)-1]
+	if top.Function != "panicking" || top.Module != "schools-be/internal/reporting" || !top.InApp {
+		t.Errorf("top frame = %+v, want the in-app function panicking", top)
+	}
+	if caller := frames[len(frames)-2]; caller.Function != "capture" {
+		t.Errorf("caller frame = %+v, want capture", caller)
+	}
+	for _, frame := range frames {
+		if frame.Module == "runtime" || strings.HasPrefix(frame.Module, "github.com/getsentry/") {
+			t.Errorf("frame %+v of the runtime or SDK reported", frame)
+		}
+		if frame.Module == "testing" && frame.InApp {
+			t.Errorf("frame %+v outside this module marked in-app", frame)
+		}
+	}
+}
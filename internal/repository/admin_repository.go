@@ -0,0 +1,280 @@
+package repository
+
+import (
+	"context"
+	"strings"
+
+	"schools-be/internal/database"
+	"schools-be/internal/errors"
+	"schools-be/internal/models"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// dataTables are the tables holding fetched and scraped data, in display order
+var dataTables = []string{
+	"schools",
+	"school_aliases",
+	"school_overrides",
+	"construction_projects",
+	"construction_project_assets",
+	"school_statistics",
+	"school_details",
+	"school_citizenship_stats",
+	"school_language_stats",
+	"school_residence_stats",
+	"school_absence_stats",
+	"school_grade_structure",
+	"school_staffing_stats",
+	"school_teacher_qualifications",
+	"school_quality_indicators",
+	"school_applications",
+	"school_exam_results",
+	"enriched_schools_json",
+}
+
+// schoolNumberTables are the tables linked to schools by school_number. Construction
+// projects without a school are kept: they are served as standalone projects.
+var schoolNumberTables = []struct {
+	table   string
+	fixable bool
+}{
+	{"school_details", true},
+	{"school_statistics", true},
+	{"school_citizenship_stats", true},
+	{"school_language_stats", true},
+	{"school_residence_stats", true},
+	{"school_absence_stats", true},
+	{"school_grade_structure", true},
+	{"school_staffing_stats", true},
+	{"school_teacher_qualifications", true},
+	{"school_quality_indicators", true},
+	{"construction_projects", false},
+}
+
+// visitorTables hold data of API users (chats, home locations and the commute times derived
+// from them, suggested corrections), which database snapshots leave out
+var visitorTables = []string{
+	"school_corrections",
+	"chat_messages",
+	"chat_sessions",
+	"user_locations",
+	"commute_matrix_status",
+	"commute_times",
+	"travel_time_cache",
+}
+
+// internalTables are bookkeeping, not part of the dataset
+var internalTables = []string{
+	"data_quality_issues",
+	"scrape_runs",
+	"construction_project_imports",
+	"construction_project_history",
+	"sync_changes",
+	"enriched_schools_json",
+	"analytics_daily",
+	"api_usage_daily",
+	"school_summaries",
+	"school_external_ratings", // Third-party data, served only as external_rating
+	"queue_tasks",
+	"job_requests",
+	"job_leases",
+	// The full-text search index and the shadow tables FTS5 keeps its data in
+	"school_search",
+	"school_search_data",
+	"school_search_idx",
+	"school_search_content",
+	"school_search_docsize",
+	"school_search_config",
+}
+
+type AdminRepository struct {
+	db     *database.DB
+	writer *sqlx.DB
+	reader *sqlx.DB
+}
+
+func NewAdminRepository(db *database.DB) *AdminRepository {
+	return &AdminRepository{db: db, writer: db.Writer, reader: db.Reader}
+}
+
+// DatasetTables returns the tables of the public dataset with their columns, leaving out
+// visitor data and internal bookkeeping
+func (r *AdminRepository) DatasetTables(ctx context.Context) ([]models.TableSchema, error) {
+	var names []string
+	query := `SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%' ORDER BY name`
+	if err := r.reader.SelectContext(ctx, &names, query); err != nil {
+		return nil, errors.NewDatabaseError("list tables", err)
+	}
+
+	excluded := make(map[string]bool, len(visitorTables)+len(internalTables))
+	for _, table := range append(visitorTables, internalTables...) {
+		excluded[table] = true
+	}
+
+	var tables []models.TableSchema
+	for _, name := range names {
+		if excluded[name] {
+			continue
+		}
+		var columns []models.TableColumn
+		if err := r.reader.SelectContext(ctx, &columns, `SELECT name, type FROM pragma_table_info(?) ORDER BY cid`, name); err != nil {
+			return nil, errors.NewDatabaseError("describe "+name, err)
+		}
+		tables = append(tables, models.TableSchema{Name: name, Columns: columns})
+	}
+	return tables, nil
+}
+
+// StreamTable calls fn with every row of a table, values in column order. The table name
+// must come from DatasetTables.
+func (r *AdminRepository) StreamTable(ctx context.Context, table models.TableSchema, fn func(values []interface{}) error) error {
+	columns := make([]string, len(table.Columns))
+	for i, c := range table.Columns {
+		columns[i] = `"` + c.Name + `"`
+	}
+
+	rows, err := r.reader.QueryxContext(ctx, `SELECT `+strings.Join(columns, ", ")+` FROM "`+table.Name+`" ORDER BY rowid`)
+	if err != nil {
+		return errors.NewDatabaseError("read "+table.Name, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		values, err := rows.SliceScan()
+		if err != nil {
+			return errors.NewDatabaseError("scan "+table.Name, err)
+		}
+		if err := fn(values); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return errors.NewDatabaseError("read "+table.Name, err)
+	}
+	return nil
+}
+
+// SnapshotDatabase writes a consistent copy of the database without visitor data to dest
+func (r *AdminRepository) SnapshotDatabase(ctx context.Context, dest string) error {
+	if err := r.db.Snapshot(ctx, dest, visitorTables); err != nil {
+		return errors.NewDatabaseError("snapshot database", err)
+	}
+	return nil
+}
+
+// TableCounts returns the row count of each data table
+func (r *AdminRepository) TableCounts(ctx context.Context) ([]models.TableCount, error) {
+	counts := make([]models.TableCount, 0, len(dataTables))
+	for _, table := range dataTables {
+		var count int
+		// Table names come from the fixed list above, never from input
+		if err := r.reader.QueryRowContext(ctx, `SELECT COUNT(*) FROM `+table).Scan(&count); err != nil {
+			return nil, errors.NewDatabaseError("count "+table, err)
+		}
+		counts = append(counts, models.TableCount{Table: table, Rows: count})
+	}
+
+	return counts, nil
+}
+
+// FindOrphans returns, per linked table, the rows whose school number is missing from schools
+func (r *AdminRepository) FindOrphans(ctx context.Context) ([]models.OrphanRows, error) {
+	orphans := []models.OrphanRows{}
+	for _, t := range schoolNumberTables {
+		orphan := models.OrphanRows{Table: t.table, Fixable: t.fixable, SchoolNumbers: []string{}}
+
+		where := `WHERE TRIM(school_number) != '' AND school_number NOT IN (SELECT school_number FROM schools)`
+		if err := r.reader.QueryRowContext(ctx, `SELECT COUNT(*) FROM `+t.table+` `+where).Scan(&orphan.Rows); err != nil {
+			return nil, errors.NewDatabaseError("count orphans in "+t.table, err)
+		}
+		if orphan.Rows == 0 {
+			continue
+		}
+
+		err := r.reader.SelectContext(ctx, &orphan.SchoolNumbers,
+			`SELECT DISTINCT school_number FROM `+t.table+` `+where+` ORDER BY school_number LIMIT 20`)
+		if err != nil {
+			return nil, errors.NewDatabaseError("list orphans in "+t.table, err)
+		}
+
+		orphans = append(orphans, orphan)
+	}
+
+	return orphans, nil
+}
+
+// DeleteOrphans deletes the rows of a fixable linked table whose school number is missing from schools
+func (r *AdminRepository) DeleteOrphans(ctx context.Context, table string) (int64, error) {
+	for _, t := range schoolNumberTables {
+		if t.table != table || !t.fixable {
+			continue
+		}
+
+		result, err := r.writer.ExecContext(ctx,
+			`DELETE FROM `+t.table+` WHERE TRIM(school_number) != '' AND school_number NOT IN (SELECT school_number FROM schools)`)
+		if err != nil {
+			return 0, errors.NewDatabaseError("delete orphans in "+t.table, err)
+		}
+		return result.RowsAffected()
+	}
+
+	return 0, errors.NewValidationError("table", "orphans of "+table+" cannot be deleted")
+}
+
+// FindSchoolsMissingDetails returns the school numbers of schools without a details row
+func (r *AdminRepository) FindSchoolsMissingDetails(ctx context.Context) ([]string, error) {
+	numbers := []string{}
+	query := `
+		SELECT s.school_number
+		FROM schools s
+		LEFT JOIN school_details d ON d.school_number = s.school_number
+		WHERE d.id IS NULL
+		ORDER BY s.school_number
+	`
+
+	if err := r.reader.SelectContext(ctx, &numbers, query); err != nil {
+		return nil, errors.NewDatabaseError("find schools missing details", err)
+	}
+
+	return numbers, nil
+}
+
+// FindTotalMismatches returns rows whose total differs from the sum of the female, male and diverse counts.
+// Statistics stored as text are only compared when all three values are plain numbers.
+func (r *AdminRepository) FindTotalMismatches(ctx context.Context) ([]models.StatMismatch, error) {
+	mismatches := []models.StatMismatch{}
+	query := `
+		SELECT 'school_citizenship_stats' AS table_name, school_number, citizenship AS detail,
+			total, female_students + male_students + diverse_students AS parts_sum
+		FROM school_citizenship_stats
+		WHERE total != female_students + male_students + diverse_students
+
+		UNION ALL
+
+		SELECT 'school_language_stats', school_number, 'ndh_total',
+			ndh_total, ndh_female_students + ndh_male_students + ndh_diverse_students
+		FROM school_language_stats
+		WHERE ndh_total != ndh_female_students + ndh_male_students + ndh_diverse_students
+
+		UNION ALL
+
+		SELECT 'school_statistics', school_number, school_year || ' students',
+			CAST(students AS INTEGER),
+			CAST(students_male AS INTEGER) + CAST(students_female AS INTEGER) + CAST(students_diverse AS INTEGER)
+		FROM school_statistics
+		WHERE students GLOB '[0-9]*' AND NOT students GLOB '*[^0-9]*'
+		  AND students_male GLOB '[0-9]*' AND NOT students_male GLOB '*[^0-9]*'
+		  AND students_female GLOB '[0-9]*' AND NOT students_female GLOB '*[^0-9]*'
+		  AND NOT students_diverse GLOB '*[^0-9]*'
+		  AND CAST(students AS INTEGER) != CAST(students_male AS INTEGER) + CAST(students_female AS INTEGER) + CAST(students_diverse AS INTEGER)
+
+		ORDER BY table_name, school_number
+	`
+
+	if err := r.reader.SelectContext(ctx, &mismatches, query); err != nil {
+		return nil, errors.NewDatabaseError("find total mismatches", err)
+	}
+
+	return mismatches, nil
+}
@@ -0,0 +1,28 @@
+package repository
+
+import (
+	"slices"
+	"testing"
+)
+
+// TestDataTablesMatchSchema checks that every table of the migrated schema is listed as data,
+// visitor or internal table, so new tables show up in the admin overview and snapshots
+func TestDataTablesMatchSchema(t *testing.T) {
+	db := newTestDB(t)
+
+	var tables []string
+	if err := db.Writer.Select(&tables, `SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%'`); err != nil {
+		t.Fatalf("list tables: %v", err)
+	}
+
+	for _, table := range tables {
+		if !slices.Contains(dataTables, table) && !slices.Contains(visitorTables, table) && !slices.Contains(internalTables, table) {
+			t.Errorf("table %s is neither in dataTables, visitorTables nor internalTables", table)
+		}
+	}
+	for _, table := range dataTables {
+		if !slices.Contains(tables, table) {
+			t.Errorf("dataTables lists %s, which the migrations do not create", table)
+		}
+	}
+}
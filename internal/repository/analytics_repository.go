@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"context"
+
+	"schools-be/internal/database"
+	"schools-be/internal/errors"
+	"schools-be/internal/models"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type AnalyticsRepository struct {
+	writer *sqlx.DB
+	reader *sqlx.DB
+}
+
+func NewAnalyticsRepository(db *database.DB) *AnalyticsRepository {
+	return &AnalyticsRepository{writer: db.Writer, reader: db.Reader}
+}
+
+// AddCounts adds hit counts to the daily rollups
+func (r *AnalyticsRepository) AddCounts(ctx context.Context, rollups []models.AnalyticsRollup) error {
+	if len(rollups) == 0 {
+		return nil
+	}
+
+	tx, err := r.writer.BeginTxx(ctx, nil)
+	if err != nil {
+		return errors.NewDatabaseError("begin transaction", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PreparexContext(ctx, `
+		INSERT INTO analytics_daily (day, kind, key, count)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(day, kind, key) DO UPDATE SET count = count + excluded.count
+	`)
+	if err != nil {
+		return errors.NewDatabaseError("prepare statement", err)
+	}
+	defer stmt.Close()
+
+	for _, rollup := range rollups {
+		if _, err := stmt.ExecContext(ctx, rollup.Day, rollup.Kind, rollup.Key, rollup.Count); err != nil {
+			return errors.NewDatabaseError("add analytics count", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.NewDatabaseError("commit transaction", err)
+	}
+
+	return nil
+}
+
+// GetSchoolViews returns the views of each school since a day (inclusive, YYYY-MM-DD), keyed
+// by school number; schools without views are left out
+func (r *AnalyticsRepository) GetSchoolViews(ctx context.Context, from string) (map[string]int64, error) {
+	var rows []struct {
+		SchoolNumber string `db:"school_number"`
+		Views        int64  `db:"views"`
+	}
+	query := `
+		SELECT s.school_number, SUM(a.count) AS views
+		FROM analytics_daily a
+		JOIN schools s ON s.id = CAST(a.key AS INTEGER)
+		WHERE a.kind = ? AND a.day >= ? AND s.school_number != ''
+		GROUP BY s.school_number
+	`
+
+	if err := r.reader.SelectContext(ctx, &rows, query, models.AnalyticsKindSchool, from); err != nil {
+		return nil, errors.NewDatabaseError("get school views", err)
+	}
+
+	views := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		views[row.SchoolNumber] = row.Views
+	}
+	return views, nil
+}
+
+// GetRange returns the daily rollups between two days (inclusive, YYYY-MM-DD)
+func (r *AnalyticsRepository) GetRange(ctx context.Context, from, to string) ([]models.AnalyticsRollup, error) {
+	var rollups []models.AnalyticsRollup
+	query := `SELECT * FROM analytics_daily WHERE day BETWEEN ? AND ? ORDER BY day, kind, count DESC`
+
+	err := r.reader.SelectContext(ctx, &rollups, query, from, to)
+	if err != nil {
+		return nil, errors.NewDatabaseError("get analytics rollups", err)
+	}
+
+	return rollups, nil
+}
@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"context"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// maxBatchParams caps the bound parameters of one multi-row statement at SQLite's
+// historical SQLITE_MAX_VARIABLE_NUMBER, which also holds for builds against an older
+// system libsqlite3
+const maxBatchParams = 999
+
+// insertBatches inserts rows with multi-row VALUES statements, as many rows per statement as
+// the parameter limit allows. insert is the statement up to VALUES, e.g.
+// "INSERT INTO t (a, b)", and suffix is appended after the values, e.g. an ON CONFLICT clause.
+// All rows must have the same number of values. It returns the number of affected rows.
+// Run it in a transaction: on error, earlier batches have already been written.
+func insertBatches(ctx context.Context, db sqlx.ExecerContext, operation, insert, suffix string, rows [][]interface{}) (int64, error) {
+	if len(rows) == 0 {
+		return 0, nil
+	}
+
+	columns := len(rows[0])
+	perBatch := maxBatchParams / columns
+	placeholders := "(?" + strings.Repeat(", ?", columns-1) + ")"
+
+	var affected int64
+	for start := 0; start < len(rows); start += perBatch {
+		end := min(start+perBatch, len(rows))
+		batch := rows[start:end]
+
+		values := strings.Repeat(placeholders+", ", len(batch)-1) + placeholders
+		args := make([]interface{}, 0, len(batch)*columns)
+		for _, row := range batch {
+			args = append(args, row...)
+		}
+
+		n, err := execQuery(ctx, db, operation, insert+" VALUES "+values+" "+suffix, args...)
+		if err != nil {
+			return affected, err
+		}
+		affected += n
+	}
+
+	return affected, nil
+}
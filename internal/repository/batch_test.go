@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"schools-be/internal/models"
+)
+
+// TestSyncBatches syncs more schools than fit into one statement and checks that every
+// school is stored, that IDs survive a second sync and that missing schools are deleted
+func TestSyncBatches(t *testing.T) {
+	repo := NewSchoolRepository(newTestDB(t))
+	ctx := context.Background()
+
+	r := rand.New(rand.NewSource(1))
+	inputs := make([]models.CreateSchoolInput, 900)
+	for i := range inputs {
+		inputs[i] = randomCreateSchoolInput(r, fmt.Sprintf("%02dY%03d", i%12, i))
+	}
+
+	stored, deleted, err := repo.Sync(ctx, inputs)
+	if err != nil || stored != len(inputs) || deleted != 0 {
+		t.Fatalf("Sync = %d, %d, %v", stored, deleted, err)
+	}
+	for _, input := range inputs {
+		school, err := repo.GetBySchoolNumber(ctx, input.SchoolNumber)
+		if err != nil {
+			t.Fatalf("get %s: %v", input.SchoolNumber, err)
+		}
+		if diffs := schoolFieldDiffs(*school, schoolFromInput(input)); len(diffs) > 0 {
+			t.Fatalf("school %s: %v", input.SchoolNumber, diffs)
+		}
+	}
+
+	before, err := repo.GetBySchoolNumber(ctx, inputs[500].SchoolNumber)
+	if err != nil {
+		t.Fatal(err)
+	}
+	inputs[500].Name = "Umbenannte Schule"
+	if _, deleted, err := repo.Sync(ctx, inputs[:800]); err != nil || deleted != 100 {
+		t.Fatalf("second Sync: deleted %d, %v; want 100 deleted", deleted, err)
+	}
+	after, err := repo.GetBySchoolNumber(ctx, inputs[500].SchoolNumber)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if after.ID != before.ID || after.Name != "Umbenannte Schule" || after.Version != before.Version+1 {
+		t.Errorf("after second sync: id %d → %d, name %q, version %d → %d", before.ID, after.ID, after.Name, before.Version, after.Version)
+	}
+}
+
+func TestReplaceAllConstructionProjects(t *testing.T) {
+	repo := NewConstructionProjectRepository(newTestDB(t))
+	ctx := context.Background()
+
+	inputs := make([]models.CreateConstructionProjectInput, 1000)
+	for i := range inputs {
+		inputs[i] = models.CreateConstructionProjectInput{
+			ProjectID: i + 1, SchoolNumber: fmt.Sprintf("%02dG%02d", i%12, i%100), SchoolName: fmt.Sprintf("Schule %d", i),
+			ConstructionMeasure: "Sanierung", TotalCosts: "1.000.000 €", City: "Berlin", Latitude: 52.5, Longitude: 13.4,
+		}
+	}
+
+	for _, set := range [][]models.CreateConstructionProjectInput{inputs, inputs[:10]} {
+		n, err := repo.ReplaceAll(ctx, set)
+		if err != nil || n != len(set) {
+			t.Fatalf("ReplaceAll = %d, %v", n, err)
+		}
+		projects, err := repo.GetAll(ctx)
+		if err != nil || len(projects) != len(set) {
+			t.Fatalf("GetAll = %d projects, %v; want %d", len(projects), err, len(set))
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"schools-be/internal/database"
+	"schools-be/internal/errors"
+	"schools-be/internal/models"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type ChatRepository struct {
+	writer *sqlx.DB
+	reader *sqlx.DB
+}
+
+func NewChatRepository(db *database.DB) *ChatRepository {
+	return &ChatRepository{writer: db.Writer, reader: db.Reader}
+}
+
+// CreateSession stores a new chat session
+func (r *ChatRepository) CreateSession(ctx context.Context, session *models.ChatSession) error {
+	query := `
+		INSERT INTO chat_sessions (id, school_id, created_at, updated_at, expires_at)
+		VALUES (?, ?, ?, ?, ?)
+	`
+
+	_, err := r.writer.ExecContext(ctx, query, session.ID, session.SchoolID, session.CreatedAt, session.UpdatedAt, session.ExpiresAt)
+	if err != nil {
+		return errors.NewDatabaseError("create chat session", err)
+	}
+
+	return nil
+}
+
+// GetSession returns a session that has not expired yet
+func (r *ChatRepository) GetSession(ctx context.Context, id string, now time.Time) (*models.ChatSession, error) {
+	var session models.ChatSession
+	query := `SELECT * FROM chat_sessions WHERE id = ? AND expires_at > ?`
+
+	err := r.reader.GetContext(ctx, &session, query, id, now)
+	if err == sql.ErrNoRows {
+		return nil, errors.NewNotFoundError("chat session", id)
+	}
+	if err != nil {
+		return nil, errors.NewDatabaseError("get chat session", err)
+	}
+
+	return &session, nil
+}
+
+// TouchSession extends the session's expiry after activity
+func (r *ChatRepository) TouchSession(ctx context.Context, id string, now, expiresAt time.Time) error {
+	query := `UPDATE chat_sessions SET updated_at = ?, expires_at = ? WHERE id = ?`
+
+	_, err := r.writer.ExecContext(ctx, query, now, expiresAt, id)
+	if err != nil {
+		return errors.NewDatabaseError("touch chat session", err)
+	}
+
+	return nil
+}
+
+// AddMessage appends a message to a session
+func (r *ChatRepository) AddMessage(ctx context.Context, message *models.ChatMessage) error {
+	query := `
+		INSERT INTO chat_messages (session_id, role, content, created_at)
+		VALUES (?, ?, ?, ?)
+	`
+
+	result, err := r.writer.ExecContext(ctx, query, message.SessionID, message.Role, message.Content, message.CreatedAt)
+	if err != nil {
+		return errors.NewDatabaseError("add chat message", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return errors.NewDatabaseError("get last insert id", err)
+	}
+	message.ID = id
+
+	return nil
+}
+
+// GetRecentMessages returns the last limit messages of a session in chronological order
+func (r *ChatRepository) GetRecentMessages(ctx context.Context, sessionID string, limit int) ([]models.ChatMessage, error) {
+	var messages []models.ChatMessage
+	query := `
+		SELECT * FROM (
+			SELECT * FROM chat_messages WHERE session_id = ? ORDER BY id DESC LIMIT ?
+		) ORDER BY id
+	`
+
+	err := r.reader.SelectContext(ctx, &messages, query, sessionID, limit)
+	if err != nil {
+		return nil, errors.NewDatabaseError("get chat messages", err)
+	}
+
+	return messages, nil
+}
+
+// TrimMessages deletes all but the newest keep messages of a session
+func (r *ChatRepository) TrimMessages(ctx context.Context, sessionID string, keep int) error {
+	query := `
+		DELETE FROM chat_messages
+		WHERE session_id = ? AND id NOT IN (
+			SELECT id FROM chat_messages WHERE session_id = ? ORDER BY id DESC LIMIT ?
+		)
+	`
+
+	_, err := r.writer.ExecContext(ctx, query, sessionID, sessionID, keep)
+	if err != nil {
+		return errors.NewDatabaseError("trim chat messages", err)
+	}
+
+	return nil
+}
+
+// DeleteSession deletes a session and its messages
+func (r *ChatRepository) DeleteSession(ctx context.Context, id string) error {
+	tx, err := r.writer.BeginTxx(ctx, nil)
+	if err != nil {
+		return errors.NewDatabaseError("begin transaction", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM chat_messages WHERE session_id = ?`, id); err != nil {
+		return errors.NewDatabaseError("delete chat messages", err)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM chat_sessions WHERE id = ?`, id); err != nil {
+		return errors.NewDatabaseError("delete chat session", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.NewDatabaseError("commit transaction", err)
+	}
+
+	return nil
+}
+
+// DeleteExpired removes sessions (and their messages) that expired before now
+func (r *ChatRepository) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	tx, err := r.writer.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, errors.NewDatabaseError("begin transaction", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		DELETE FROM chat_messages
+		WHERE session_id IN (SELECT id FROM chat_sessions WHERE expires_at <= ?)
+	`, now)
+	if err != nil {
+		return 0, errors.NewDatabaseError("delete expired chat messages", err)
+	}
+
+	result, err := tx.ExecContext(ctx, `DELETE FROM chat_sessions WHERE expires_at <= ?`, now)
+	if err != nil {
+		return 0, errors.NewDatabaseError("delete expired chat sessions", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, errors.NewDatabaseError("commit transaction", err)
+	}
+
+	deleted, _ := result.RowsAffected()
+	return deleted, nil
+}
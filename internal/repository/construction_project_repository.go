@@ -4,6 +4,7 @@ import (
 	"context"
 	"time"
 
+	"schools-be/internal/database"
 	"schools-be/internal/errors"
 	"schools-be/internal/models"
 
@@ -11,30 +12,57 @@ import (
 )
 
 type ConstructionProjectRepository struct {
-	db *sqlx.DB
+	writer *sqlx.DB
+	reader *sqlx.DB
 }
 
-func NewConstructionProjectRepository(db *sqlx.DB) *ConstructionProjectRepository {
-	return &ConstructionProjectRepository{db: db}
+func NewConstructionProjectRepository(db *database.DB) *ConstructionProjectRepository {
+	return &ConstructionProjectRepository{writer: db.Writer, reader: db.Reader}
 }
 
-// Create creates a new construction project
-func (r *ConstructionProjectRepository) Create(ctx context.Context, input models.CreateConstructionProjectInput) (*models.ConstructionProject, error) {
-	query := `
-		INSERT INTO construction_projects (
-			project_id, school_number, school_name, district, school_type,
-			construction_measure, description, built_school_places, places_after_construction,
-			class_tracks_after_construction, handover_date, total_costs, street,
-			postal_code, city, latitude, longitude, created_at, updated_at
-		)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
-	`
-	now := time.Now()
-	result, err := r.db.ExecContext(ctx, query,
+const insertConstructionProject = `
+	INSERT INTO construction_projects (
+		project_id, school_number, school_name, district, school_type,
+		construction_measure, description, built_school_places, places_after_construction,
+		class_tracks_after_construction, handover_date, total_costs, street,
+		postal_code, city, latitude, longitude, created_at, updated_at
+	)`
+
+// constructionProjectRow returns the values of a project in the column order of
+// insertConstructionProject
+func constructionProjectRow(input models.CreateConstructionProjectInput, now time.Time) []interface{} {
+	return []interface{}{
 		input.ProjectID, input.SchoolNumber, input.SchoolName, input.District, input.SchoolType,
 		input.ConstructionMeasure, input.Description, input.BuiltSchoolPlaces, input.PlacesAfterConstruction,
 		input.ClassTracksAfterConstruction, input.HandoverDate, input.TotalCosts, input.Street,
-		input.PostalCode, input.City, input.Latitude, input.Longitude, now, now)
+		input.PostalCode, input.City, input.Latitude, input.Longitude, now, now,
+	}
+}
+
+const insertConstructionProjectAsset = `INSERT INTO construction_project_assets (project_id, kind, url, title)`
+
+// constructionProjectAssetRows returns the values of the assets of projects in the column
+// order of insertConstructionProjectAsset
+func constructionProjectAssetRows(inputs ...models.CreateConstructionProjectInput) [][]interface{} {
+	var rows [][]interface{}
+	for _, input := range inputs {
+		for _, asset := range input.Assets {
+			rows = append(rows, []interface{}{input.ProjectID, asset.Kind, asset.URL, asset.Title})
+		}
+	}
+	return rows
+}
+
+// Create creates a new construction project with its assets
+func (r *ConstructionProjectRepository) Create(ctx context.Context, input models.CreateConstructionProjectInput) (*models.ConstructionProject, error) {
+	tx, err := r.writer.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, errors.NewDatabaseError("begin transaction", err)
+	}
+	defer tx.Rollback()
+
+	query := insertConstructionProject + ` VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	result, err := tx.ExecContext(ctx, query, constructionProjectRow(input, time.Now())...)
 	if err != nil {
 		return nil, errors.NewDatabaseError("create construction project", err)
 	}
@@ -44,53 +72,133 @@ func (r *ConstructionProjectRepository) Create(ctx context.Context, input models
 		return nil, errors.NewDatabaseError("get last insert id", err)
 	}
 
+	if _, err := insertBatches(ctx, tx, "insert construction project assets", insertConstructionProjectAsset, "ON CONFLICT DO NOTHING", constructionProjectAssetRows(input)); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, errors.NewDatabaseError("commit construction project", err)
+	}
+
 	return r.GetByID(ctx, id)
 }
 
-// GetByID retrieves a construction project by ID
-func (r *ConstructionProjectRepository) GetByID(ctx context.Context, id int64) (*models.ConstructionProject, error) {
-	var project models.ConstructionProject
-	query := `SELECT * FROM construction_projects WHERE id = ?`
+// constructionImportsKept is how many imports the project history keeps
+const constructionImportsKept = 20
 
-	err := r.db.GetContext(ctx, &project, query, id)
+// ReplaceAll replaces all construction projects and their assets with the given ones in one
+// transaction, using batched inserts. Readers see either the old or the new set. The import
+// is recorded in the project history.
+func (r *ConstructionProjectRepository) ReplaceAll(ctx context.Context, inputs []models.CreateConstructionProjectInput) (int, error) {
+	tx, err := r.writer.BeginTxx(ctx, nil)
 	if err != nil {
-		return nil, errors.NewDatabaseError("get construction project by id", err)
+		return 0, errors.NewDatabaseError("begin transaction", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := execQuery(ctx, tx, "delete all construction projects", `DELETE FROM construction_projects`); err != nil {
+		return 0, err
 	}
 
-	return &project, nil
+	now := time.Now()
+	rows := make([][]interface{}, 0, len(inputs))
+	for _, input := range inputs {
+		rows = append(rows, constructionProjectRow(input, now))
+	}
+	if _, err := insertBatches(ctx, tx, "insert construction projects", insertConstructionProject, "", rows); err != nil {
+		return 0, err
+	}
+	// The assets of the old projects were deleted with them
+	if _, err := insertBatches(ctx, tx, "insert construction project assets", insertConstructionProjectAsset, "ON CONFLICT DO NOTHING", constructionProjectAssetRows(inputs...)); err != nil {
+		return 0, err
+	}
+	if err := recordConstructionImport(ctx, tx, now, len(inputs)); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, errors.NewDatabaseError("commit construction projects", err)
+	}
+
+	return len(inputs), nil
+}
+
+// recordConstructionImport copies the imported projects into the project history and drops
+// the imports beyond constructionImportsKept; their history goes with them
+func recordConstructionImport(ctx context.Context, tx *sqlx.Tx, importedAt time.Time, projects int) error {
+	result, err := tx.ExecContext(ctx, `INSERT INTO construction_project_imports (imported_at, projects) VALUES (?, ?)`, importedAt, projects)
+	if err != nil {
+		return errors.NewDatabaseError("record construction project import", err)
+	}
+	importID, err := result.LastInsertId()
+	if err != nil {
+		return errors.NewDatabaseError("get last insert id", err)
+	}
+
+	query := `
+		INSERT INTO construction_project_history (
+			import_id, project_id, school_number, school_name, district, school_type,
+			description, handover_date, total_costs
+		)
+		SELECT ?, project_id, school_number, school_name, COALESCE(district, ''), COALESCE(school_type, ''),
+			COALESCE(description, ''), COALESCE(handover_date, ''), COALESCE(total_costs, '')
+		FROM construction_projects
+	`
+	if _, err := execQuery(ctx, tx, "record construction project history", query, importID); err != nil {
+		return err
+	}
+
+	query = `DELETE FROM construction_project_imports WHERE id NOT IN (
+		SELECT id FROM construction_project_imports ORDER BY id DESC LIMIT ?
+	)`
+	_, err = execQuery(ctx, tx, "prune construction project imports", query, constructionImportsKept)
+	return err
+}
+
+// GetImports retrieves the most recent imports of the construction projects, newest first
+func (r *ConstructionProjectRepository) GetImports(ctx context.Context, limit int) ([]models.ConstructionProjectImport, error) {
+	query := `SELECT * FROM construction_project_imports ORDER BY id DESC LIMIT ?`
+
+	return getList[models.ConstructionProjectImport](ctx, r.reader, "get construction project imports", query, limit)
+}
+
+// GetHistory retrieves the projects of an import as they were imported; only the columns the
+// history keeps are set
+func (r *ConstructionProjectRepository) GetHistory(ctx context.Context, importID int64) ([]models.ConstructionProject, error) {
+	query := `
+		SELECT project_id, school_number, school_name, district, school_type, description, handover_date, total_costs
+		FROM construction_project_history
+		WHERE import_id = ?
+	`
+
+	return getList[models.ConstructionProject](ctx, r.reader, "get construction project history", query, importID)
+}
+
+// GetByID retrieves a construction project by ID
+func (r *ConstructionProjectRepository) GetByID(ctx context.Context, id int64) (*models.ConstructionProject, error) {
+	query := `SELECT * FROM construction_projects WHERE id = ?`
+
+	return getOne[models.ConstructionProject](ctx, r.reader, "construction project", id, "get construction project by id", query, id)
 }
 
 // GetAll retrieves all construction projects
 func (r *ConstructionProjectRepository) GetAll(ctx context.Context) ([]models.ConstructionProject, error) {
-	var projects []models.ConstructionProject
 	query := `SELECT * FROM construction_projects ORDER BY created_at DESC`
 
-	err := r.db.SelectContext(ctx, &projects, query)
-	if err != nil {
-		return nil, errors.NewDatabaseError("get all construction projects", err)
-	}
-
-	return projects, nil
+	return getList[models.ConstructionProject](ctx, r.reader, "get all construction projects", query)
 }
 
 // GetBySchoolNumber retrieves construction projects for a specific school
 func (r *ConstructionProjectRepository) GetBySchoolNumber(ctx context.Context, schoolNumber string) ([]models.ConstructionProject, error) {
-	var projects []models.ConstructionProject
-	query := `SELECT * FROM construction_projects WHERE school_number = ? ORDER BY created_at DESC`
+	query := `SELECT * FROM construction_projects WHERE school_number = ` + currentSchoolNumber + ` ORDER BY created_at DESC`
 
-	err := r.db.SelectContext(ctx, &projects, query, schoolNumber)
-	if err != nil {
-		return nil, errors.NewDatabaseError("get construction projects by school number", err)
-	}
-
-	return projects, nil
+	return getList[models.ConstructionProject](ctx, r.reader, "get construction projects by school number", query, schoolNumber, schoolNumber)
 }
 
 // GetStandalone retrieves construction projects that are not assigned to any existing school
 // This includes only valid orphaned projects (school_number doesn't exist in schools table)
 // Excludes meta entries, legends, and projects with no meaningful data
 func (r *ConstructionProjectRepository) GetStandalone(ctx context.Context) ([]models.ConstructionProject, error) {
-	var projects []models.ConstructionProject
 	query := `
 		SELECT cp.* 
 		FROM construction_projects cp 
@@ -103,20 +211,36 @@ func (r *ConstructionProjectRepository) GetStandalone(ctx context.Context) ([]mo
 		ORDER BY cp.created_at DESC
 	`
 
-	err := r.db.SelectContext(ctx, &projects, query)
+	return getList[models.ConstructionProject](ctx, r.reader, "get standalone construction projects", query)
+}
+
+// GetAllAssets retrieves the assets of all construction projects, grouped by project_id
+func (r *ConstructionProjectRepository) GetAllAssets(ctx context.Context) (map[int][]models.ConstructionProjectAsset, error) {
+	query := `SELECT * FROM construction_project_assets ORDER BY project_id, id`
+
+	assets, err := getList[models.ConstructionProjectAsset](ctx, r.reader, "get construction project assets", query)
 	if err != nil {
-		return nil, errors.NewDatabaseError("get standalone construction projects", err)
+		return nil, err
 	}
 
-	return projects, nil
+	byProject := make(map[int][]models.ConstructionProjectAsset)
+	for _, asset := range assets {
+		byProject[asset.ProjectID] = append(byProject[asset.ProjectID], asset)
+	}
+	return byProject, nil
+}
+
+// GetAssetsByProjectID retrieves the assets of one construction project (by project_id)
+func (r *ConstructionProjectRepository) GetAssetsByProjectID(ctx context.Context, projectID int) ([]models.ConstructionProjectAsset, error) {
+	query := `SELECT * FROM construction_project_assets WHERE project_id = ? ORDER BY id`
+
+	return getList[models.ConstructionProjectAsset](ctx, r.reader, "get construction project assets by project id", query, projectID)
 }
 
 // DeleteAll deletes all construction projects
 func (r *ConstructionProjectRepository) DeleteAll(ctx context.Context) error {
 	query := `DELETE FROM construction_projects`
-	_, err := r.db.ExecContext(ctx, query)
-	if err != nil {
-		return errors.NewDatabaseError("delete all construction projects", err)
-	}
-	return nil
+
+	_, err := execQuery(ctx, r.writer, "delete all construction projects", query)
+	return err
 }
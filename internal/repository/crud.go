@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"schools-be/internal/errors"
+	"schools-be/internal/timeouts"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// The helpers below wrap the query patterns every repository repeats: reading one row,
+// reading a list, writing and counting, each with the repository's error types. They take
+// sqlx.QueryerContext/ExecerContext so they work on the reader, the writer and transactions.
+// Every statement is limited to timeouts.DB.
+
+// getOne reads the single row of a query into a T. No row is reported as a NotFoundError
+// for resource and key, any other failure as a DatabaseError for operation.
+func getOne[T any](ctx context.Context, db sqlx.QueryerContext, resource string, key interface{}, operation, query string, args ...interface{}) (*T, error) {
+	ctx, cancel := timeouts.With(ctx, operation, timeouts.DB)
+	defer cancel()
+
+	var item T
+	err := sqlx.GetContext(ctx, db, &item, query, args...)
+	if err == sql.ErrNoRows {
+		return nil, errors.NewNotFoundError(resource, key)
+	}
+	if err != nil {
+		return nil, dbError(ctx, operation, err)
+	}
+
+	return &item, nil
+}
+
+// getList reads all rows of a query into a slice of T; no rows is an empty (nil) slice
+func getList[T any](ctx context.Context, db sqlx.QueryerContext, operation, query string, args ...interface{}) ([]T, error) {
+	ctx, cancel := timeouts.With(ctx, operation, timeouts.DB)
+	defer cancel()
+
+	var items []T
+	if err := sqlx.SelectContext(ctx, db, &items, query, args...); err != nil {
+		return nil, dbError(ctx, operation, err)
+	}
+
+	return items, nil
+}
+
+// execQuery runs a write and returns the number of affected rows
+func execQuery(ctx context.Context, db sqlx.ExecerContext, operation, query string, args ...interface{}) (int64, error) {
+	ctx, cancel := timeouts.With(ctx, operation, timeouts.DB)
+	defer cancel()
+
+	result, err := db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, dbError(ctx, operation, err)
+	}
+
+	n, _ := result.RowsAffected()
+	return n, nil
+}
+
+// countRows runs a COUNT query
+func countRows(ctx context.Context, db sqlx.QueryerContext, operation, query string, args ...interface{}) (int, error) {
+	ctx, cancel := timeouts.With(ctx, operation, timeouts.DB)
+	defer cancel()
+
+	var count int
+	if err := sqlx.GetContext(ctx, db, &count, query, args...); err != nil {
+		return 0, dbError(ctx, operation, err)
+	}
+
+	return count, nil
+}
+
+// dbError wraps a failed statement as a DatabaseError. A statement that ran out of time
+// reports which timeout ended it rather than a bare "context deadline exceeded".
+func dbError(ctx context.Context, operation string, err error) error {
+	if ctx.Err() != nil {
+		err = context.Cause(ctx)
+	}
+	return errors.NewDatabaseError(operation, err)
+}
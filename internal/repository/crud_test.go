@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"context"
+	stderrors "errors"
+	"testing"
+
+	"schools-be/internal/errors"
+	"schools-be/internal/models"
+)
+
+func TestCRUDHelpers(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+
+	if _, err := execQuery(ctx, db.Writer, "insert", `INSERT INTO schools (school_number, name, school_type) VALUES (?, ?, ?), (?, ?, ?)`,
+		"01Y02", "Lessing-Gymnasium", "Gymnasium", "07G14", "Finow-Grundschule", "Grundschule"); err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+
+	school, err := getOne[models.School](ctx, db.Reader, "school", "01Y02", "get school", `SELECT * FROM schools WHERE school_number = ?`, "01Y02")
+	if err != nil || school.Name != "Lessing-Gymnasium" {
+		t.Errorf("getOne = %+v, %v", school, err)
+	}
+
+	_, err = getOne[models.School](ctx, db.Reader, "school", "99X99", "get school", `SELECT * FROM schools WHERE school_number = ?`, "99X99")
+	if !stderrors.Is(err, errors.ErrNotFound) {
+		t.Errorf("getOne of a missing row: got %v, want a not found error", err)
+	}
+
+	_, err = getList[models.School](ctx, db.Reader, "list schools", `SELECT * FROM no_such_table`)
+	if !stderrors.Is(err, errors.ErrDatabaseError) {
+		t.Errorf("getList of a bad query: got %v, want a database error", err)
+	}
+
+	schools, err := getList[models.School](ctx, db.Reader, "list schools", `SELECT * FROM schools WHERE school_type = ?`, "Kita")
+	if err != nil || len(schools) != 0 {
+		t.Errorf("getList without rows = %v, %v", schools, err)
+	}
+
+	n, err := execQuery(ctx, db.Writer, "delete", `DELETE FROM schools WHERE school_type = ?`, "Grundschule")
+	if err != nil || n != 1 {
+		t.Errorf("execQuery = %d, %v, want 1 affected row", n, err)
+	}
+
+	if count, err := countRows(ctx, db.Reader, "count", `SELECT COUNT(*) FROM schools`); err != nil || count != 1 {
+		t.Errorf("countRows = %d, %v, want 1", count, err)
+	}
+}
+
+// TestConstructionProjectNotFound guards the 404 of /construction-projects/{id}, which
+// depends on the repository reporting a missing project as not found
+func TestConstructionProjectNotFound(t *testing.T) {
+	repo := NewConstructionProjectRepository(newTestDB(t))
+
+	if _, err := repo.GetByID(context.Background(), 999); !stderrors.Is(err, errors.ErrNotFound) {
+		t.Errorf("GetByID of a missing project: got %v, want a not found error", err)
+	}
+}
@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"schools-be/internal/database"
+	"schools-be/internal/errors"
+	"schools-be/internal/models"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// EnrichedSchoolSnapshot is a pre-serialized enriched school payload
+type EnrichedSchoolSnapshot struct {
+	SchoolID     int64     `db:"school_id"`
+	SchoolNumber string    `db:"school_number"`
+	Payload      string    `db:"payload"`
+	BuiltAt      time.Time `db:"built_at"`
+}
+
+// EnrichedSchoolRepository stores the materialized enriched_schools_json table
+type EnrichedSchoolRepository struct {
+	writer *sqlx.DB
+	reader *sqlx.DB
+}
+
+func NewEnrichedSchoolRepository(db *database.DB) *EnrichedSchoolRepository {
+	return &EnrichedSchoolRepository{writer: db.Writer, reader: db.Reader}
+}
+
+// ReplaceAll atomically replaces the whole snapshot table
+func (r *EnrichedSchoolRepository) ReplaceAll(ctx context.Context, snapshots []EnrichedSchoolSnapshot) error {
+	tx, err := r.writer.BeginTxx(ctx, nil)
+	if err != nil {
+		return errors.NewDatabaseError("begin transaction", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := execQuery(ctx, tx, "clear enriched schools snapshot", `DELETE FROM enriched_schools_json`); err != nil {
+		return err
+	}
+
+	rows := make([][]interface{}, 0, len(snapshots))
+	for _, snapshot := range snapshots {
+		rows = append(rows, []interface{}{snapshot.SchoolID, snapshot.SchoolNumber, snapshot.Payload, snapshot.BuiltAt})
+	}
+	if _, err := insertBatches(ctx, tx, "insert enriched school snapshots",
+		`INSERT INTO enriched_schools_json (school_id, school_number, payload, built_at)`, "", rows); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.NewDatabaseError("commit transaction", err)
+	}
+
+	return nil
+}
+
+// Stream calls fn for the snapshot payload of every school matching the filter, in school
+// order, without loading the whole table into memory
+func (r *EnrichedSchoolRepository) Stream(ctx context.Context, filter models.SchoolFilter, fn func(payload []byte) error) error {
+	query := `SELECT payload FROM enriched_schools_json ORDER BY school_id`
+	var args []interface{}
+	if !filter.IsEmpty() {
+		var where string
+		where, args = schoolFilterWhere(filter, "s.")
+		query = `SELECT e.payload FROM enriched_schools_json e
+		         JOIN schools s ON s.id = e.school_id
+		         WHERE ` + where + ` ORDER BY e.school_id`
+	}
+
+	rows, err := r.reader.QueryxContext(ctx, query, args...)
+	if err != nil {
+		return errors.NewDatabaseError("stream enriched schools", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var payload []byte
+		if err := rows.Scan(&payload); err != nil {
+			return errors.NewDatabaseError("scan enriched school", err)
+		}
+		if err := fn(payload); err != nil {
+			return err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return errors.NewDatabaseError("stream enriched schools", err)
+	}
+
+	return nil
+}
+
+// GetBuiltAt returns when the snapshot was last rebuilt, or nil if it was never built
+func (r *EnrichedSchoolRepository) GetBuiltAt(ctx context.Context) (*time.Time, error) {
+	var builtAt time.Time
+	// Select the column itself (not MAX) so the driver decodes it as a DATETIME
+	query := `SELECT built_at FROM enriched_schools_json ORDER BY built_at DESC LIMIT 1`
+
+	err := r.reader.GetContext(ctx, &builtAt, query)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.NewDatabaseError("get enriched snapshot built at", err)
+	}
+
+	return &builtAt, nil
+}
@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"schools-be/internal/database"
+	"schools-be/internal/errors"
+	"schools-be/internal/models"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type ExamResultRepository struct {
+	writer *sqlx.DB
+	reader *sqlx.DB
+}
+
+func NewExamResultRepository(db *database.DB) *ExamResultRepository {
+	return &ExamResultRepository{writer: db.Writer, reader: db.Reader}
+}
+
+// Upsert stores exam results in one transaction, replacing existing rows of the same school
+// and year. Rows under a replaced school number are stored under the current one; rows for
+// school numbers not in the schools table are skipped and counted in the second return value.
+func (r *ExamResultRepository) Upsert(ctx context.Context, results []models.ExamResultInput) (saved int, skipped int, err error) {
+	tx, err := r.writer.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, 0, errors.NewDatabaseError("begin transaction", err)
+	}
+	defer tx.Rollback()
+
+	query := `INSERT INTO school_exam_results (school_number, school_year, participants, passed, average_grade, suppressed, fetched_at)
+	          SELECT ` + currentSchoolNumber + `, ?, ?, ?, ?, ?, ?
+	          WHERE EXISTS (SELECT 1 FROM schools WHERE school_number = ` + currentSchoolNumber + `)
+	          ON CONFLICT(school_number, school_year) DO UPDATE SET
+	              participants = excluded.participants,
+	              passed = excluded.passed,
+	              average_grade = excluded.average_grade,
+	              suppressed = excluded.suppressed,
+	              fetched_at = excluded.fetched_at`
+
+	now := time.Now()
+	for _, result := range results {
+		n, err := execQuery(ctx, tx, "upsert exam result", query,
+			result.SchoolNumber, result.SchoolNumber, result.SchoolYear, result.Participants, result.Passed,
+			result.AverageGrade, result.Suppressed, now, result.SchoolNumber, result.SchoolNumber)
+		if err != nil {
+			return 0, 0, err
+		}
+		if n == 0 {
+			skipped++
+			continue
+		}
+		saved++
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, 0, errors.NewDatabaseError("commit transaction", err)
+	}
+	return saved, skipped, nil
+}
+
+// GetBySchoolNumber retrieves the exam results of a school, oldest school year first
+func (r *ExamResultRepository) GetBySchoolNumber(ctx context.Context, schoolNumber string) ([]models.ExamResult, error) {
+	query := `SELECT * FROM school_exam_results WHERE school_number = ` + currentSchoolNumber + ` ORDER BY school_year`
+
+	return getList[models.ExamResult](ctx, r.reader, "get exam results", query, schoolNumber, schoolNumber)
+}
+
+// GetBenchmarks aggregates the unsuppressed results per school year, for all of Berlin
+// or, with a school type, for the schools of that type
+func (r *ExamResultRepository) GetBenchmarks(ctx context.Context, schoolType string) (map[string]models.ExamBenchmark, error) {
+	var rows []struct {
+		SchoolYear string `db:"school_year"`
+		models.ExamBenchmark
+	}
+	query := `
+		SELECT e.school_year,
+		       COUNT(*) AS schools,
+		       SUM(e.participants) AS participants,
+		       SUM(e.passed) AS passed,
+		       ROUND(SUM(e.average_grade * e.participants) / SUM(CASE WHEN e.average_grade IS NOT NULL THEN e.participants END), 2) AS average_grade
+		FROM school_exam_results e
+		JOIN schools s ON s.school_number = e.school_number
+		WHERE e.suppressed = 0 AND (? = '' OR s.school_type = ?)
+		GROUP BY e.school_year`
+
+	if err := r.reader.SelectContext(ctx, &rows, query, schoolType, schoolType); err != nil {
+		return nil, errors.NewDatabaseError("get exam benchmarks", err)
+	}
+
+	benchmarks := make(map[string]models.ExamBenchmark, len(rows))
+	for _, row := range rows {
+		benchmarks[row.SchoolYear] = row.ExamBenchmark.WithPassRate()
+	}
+	return benchmarks, nil
+}
@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"schools-be/internal/database"
+	"schools-be/internal/models"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// ExternalRatingRepository caches the ratings of schools on third-party review sites
+// (school_external_ratings)
+type ExternalRatingRepository struct {
+	writer *sqlx.DB
+	reader *sqlx.DB
+}
+
+func NewExternalRatingRepository(db *database.DB) *ExternalRatingRepository {
+	return &ExternalRatingRepository{writer: db.Writer, reader: db.Reader}
+}
+
+// GetBySchoolNumber returns the rating of a school; a school without one, or whose lookup
+// found no place, is a NotFoundError
+func (r *ExternalRatingRepository) GetBySchoolNumber(ctx context.Context, schoolNumber string) (*models.ExternalRating, error) {
+	query := `SELECT * FROM school_external_ratings WHERE school_number = ` + currentSchoolNumber + ` AND place_id != ''`
+	return getOne[models.ExternalRating](ctx, r.reader, "external rating", schoolNumber, "get external rating", query, schoolNumber, schoolNumber)
+}
+
+// GetDue returns the schools whose rating was not looked up since notBefore: schools never
+// looked up first, then the oldest lookups
+func (r *ExternalRatingRepository) GetDue(ctx context.Context, notBefore time.Time) ([]models.School, error) {
+	query := `
+		SELECT s.* FROM schools s
+		LEFT JOIN school_external_ratings r ON r.school_number = s.school_number
+		WHERE r.fetched_at IS NULL OR r.fetched_at < ?
+		ORDER BY r.fetched_at IS NOT NULL, r.fetched_at, s.id`
+	return getList[models.School](ctx, r.reader, "get schools due for external rating", query, notBefore)
+}
+
+// Upsert stores the result of a lookup, replacing the previous one of the school
+func (r *ExternalRatingRepository) Upsert(ctx context.Context, rating models.ExternalRating) error {
+	_, err := execQuery(ctx, r.writer, "save external rating", `
+		INSERT INTO school_external_ratings (school_number, source, place_id, rating, review_count, url, fetched_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(school_number) DO UPDATE SET
+			source = excluded.source,
+			place_id = excluded.place_id,
+			rating = excluded.rating,
+			review_count = excluded.review_count,
+			url = excluded.url,
+			fetched_at = excluded.fetched_at
+	`, rating.SchoolNumber, rating.Source, rating.PlaceID, rating.Rating, rating.ReviewCount, rating.URL, rating.FetchedAt)
+	return err
+}
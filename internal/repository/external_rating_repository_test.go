@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"context"
+	stderrors "errors"
+	"strings"
+	"testing"
+	"time"
+
+	"schools-be/internal/errors"
+	"schools-be/internal/models"
+)
+
+// TestExternalRatingDue checks that only missing and expired ratings are looked up again,
+// never looked up first, and that a lookup without a place is not served
+func TestExternalRatingDue(t *testing.T) {
+	db := newTestDB(t)
+	ratings := NewExternalRatingRepository(db)
+	ctx := context.Background()
+
+	_, _, err := NewSchoolRepository(db).Sync(ctx, []models.CreateSchoolInput{
+		{SchoolNumber: "01R01", Name: "Fresh"},
+		{SchoolNumber: "01R02", Name: "Expired"},
+		{SchoolNumber: "01R03", Name: "New"},
+	})
+	if err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+
+	stars := 4.5
+	now := time.Now()
+	for _, rating := range []models.ExternalRating{
+		{SchoolNumber: "01R01", Source: models.ExternalRatingSourceGooglePlaces, PlaceID: "place-1", Rating: &stars, ReviewCount: 12, FetchedAt: now},
+		{SchoolNumber: "01R02", Source: models.ExternalRatingSourceGooglePlaces, FetchedAt: now.Add(-8 * 24 * time.Hour)},
+	} {
+		if err := ratings.Upsert(ctx, rating); err != nil {
+			t.Fatalf("upsert %s: %v", rating.SchoolNumber, err)
+		}
+	}
+
+	due, err := ratings.GetDue(ctx, now.Add(-7*24*time.Hour))
+	if err != nil {
+		t.Fatalf("get due: %v", err)
+	}
+	var got []string
+	for _, school := range due {
+		got = append(got, school.SchoolNumber)
+	}
+	if strings.Join(got, ",") != "01R03,01R02" {
+		t.Errorf("due = %v, want 01R03,01R02", got)
+	}
+
+	rating, err := ratings.GetBySchoolNumber(ctx, "01R01")
+	if err != nil {
+		t.Fatalf("get rating: %v", err)
+	}
+	if rating.Rating == nil || *rating.Rating != stars || rating.ReviewCount != 12 {
+		t.Errorf("rating = %+v, want 4.5 stars from 12 reviews", rating)
+	}
+	if _, err := ratings.GetBySchoolNumber(ctx, "01R02"); !stderrors.Is(err, errors.ErrNotFound) {
+		t.Errorf("rating without place error = %v, want not found", err)
+	}
+}
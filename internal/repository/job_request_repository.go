@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"time"
+
+	"schools-be/internal/database"
+	"schools-be/internal/errors"
+	"schools-be/internal/models"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// JobRequestRepository queues manually triggered jobs for the instances running the scheduler
+type JobRequestRepository struct {
+	writer *sqlx.DB
+	reader *sqlx.DB
+}
+
+func NewJobRequestRepository(db *database.DB) *JobRequestRepository {
+	return &JobRequestRepository{writer: db.Writer, reader: db.Reader}
+}
+
+// Create queues a run of job
+func (r *JobRequestRepository) Create(ctx context.Context, job string) error {
+	query := `INSERT INTO job_requests (job, requested_at) VALUES (?, ?)`
+	if _, err := r.writer.ExecContext(ctx, query, job, time.Now().UTC()); err != nil {
+		return errors.NewDatabaseError("create job request", err)
+	}
+	return nil
+}
+
+// Claim assigns all unclaimed requests to holder and returns them, oldest first. Each
+// request is claimed by exactly one instance.
+func (r *JobRequestRepository) Claim(ctx context.Context, holder string) ([]models.JobRequest, error) {
+	var requests []models.JobRequest
+	query := `UPDATE job_requests SET claimed_by = ?, claimed_at = ?
+	          WHERE claimed_by IS NULL
+	          RETURNING id, job, requested_at, claimed_by, claimed_at`
+
+	if err := r.writer.SelectContext(ctx, &requests, query, holder, time.Now().UTC()); err != nil {
+		return nil, errors.NewDatabaseError("claim job requests", err)
+	}
+	// RETURNING does not keep the table order
+	slices.SortFunc(requests, func(a, b models.JobRequest) int { return cmp.Compare(a.ID, b.ID) })
+	return requests, nil
+}
+
+// GetPending returns the requests no worker has claimed yet, oldest first
+func (r *JobRequestRepository) GetPending(ctx context.Context) ([]models.JobRequest, error) {
+	var requests []models.JobRequest
+	query := `SELECT * FROM job_requests WHERE claimed_by IS NULL ORDER BY id`
+
+	if err := r.reader.SelectContext(ctx, &requests, query); err != nil {
+		return nil, errors.NewDatabaseError("get pending job requests", err)
+	}
+	return requests, nil
+}
@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"context"
+	"testing"
+)
+
+func TestJobRequestRepositoryClaim(t *testing.T) {
+	ctx := context.Background()
+	repo := NewJobRequestRepository(newTestDB(t))
+
+	for _, job := range []string{"refresh", "details"} {
+		if err := repo.Create(ctx, job); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	claimed, err := repo.Claim(ctx, "worker-a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(claimed) != 2 || claimed[0].Job != "refresh" || claimed[1].Job != "details" {
+		t.Fatalf("claimed %+v, want refresh and details in order", claimed)
+	}
+	if claimed[0].ClaimedBy == nil || *claimed[0].ClaimedBy != "worker-a" || claimed[0].ClaimedAt == nil {
+		t.Errorf("claim not recorded: %+v", claimed[0])
+	}
+
+	// Claimed requests are not handed out again
+	again, err := repo.Claim(ctx, "worker-b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pending, err := repo.GetPending(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(again) != 0 || len(pending) != 0 {
+		t.Errorf("claimed again %+v, pending %+v", again, pending)
+	}
+}
@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"schools-be/internal/database"
+	"schools-be/internal/errors"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// LeaseRepository stores named leases, held by one instance at a time until they expire
+type LeaseRepository struct {
+	writer *sqlx.DB
+	reader *sqlx.DB
+}
+
+func NewLeaseRepository(db *database.DB) *LeaseRepository {
+	return &LeaseRepository{writer: db.Writer, reader: db.Reader}
+}
+
+// Acquire takes the lease name for holder until ttl from now. It fails, returning false, while
+// another holder has an unexpired lease; a lease already held by holder is extended.
+func (r *LeaseRepository) Acquire(ctx context.Context, name, holder string, ttl time.Duration) (bool, error) {
+	now := time.Now()
+	query := `INSERT INTO job_leases (name, holder, expires_at) VALUES (?, ?, ?)
+	          ON CONFLICT(name) DO UPDATE SET holder = excluded.holder, expires_at = excluded.expires_at
+	          WHERE job_leases.holder = excluded.holder OR job_leases.expires_at <= ?`
+
+	result, err := r.writer.ExecContext(ctx, query, name, holder, now.Add(ttl).UnixMilli(), now.UnixMilli())
+	if err != nil {
+		return false, errors.NewDatabaseError("acquire lease", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, errors.NewDatabaseError("acquire lease", err)
+	}
+	return affected > 0, nil
+}
+
+// Release gives up the lease name if holder still holds it
+func (r *LeaseRepository) Release(ctx context.Context, name, holder string) error {
+	if _, err := r.writer.ExecContext(ctx, `DELETE FROM job_leases WHERE name = ? AND holder = ?`, name, holder); err != nil {
+		return errors.NewDatabaseError("release lease", err)
+	}
+	return nil
+}
+
+// GetHolder returns the holder of the unexpired lease name, or "" when it is free
+func (r *LeaseRepository) GetHolder(ctx context.Context, name string) (string, error) {
+	var holder string
+	query := `SELECT holder FROM job_leases WHERE name = ? AND expires_at > ?`
+	if err := r.reader.GetContext(ctx, &holder, query, name, time.Now().UnixMilli()); err != nil {
+		if err == sql.ErrNoRows {
+			return "", nil
+		}
+		return "", errors.NewDatabaseError("get lease holder", err)
+	}
+	return holder, nil
+}
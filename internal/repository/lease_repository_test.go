@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestLeaseRepositoryAcquire(t *testing.T) {
+	ctx := context.Background()
+	repo := NewLeaseRepository(newTestDB(t))
+
+	acquire := func(holder string, ttl time.Duration, want bool) {
+		t.Helper()
+		got, err := repo.Acquire(ctx, "refresh", holder, ttl)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Fatalf("Acquire(%s) = %v, want %v", holder, got, want)
+		}
+	}
+	holder := func(want string) {
+		t.Helper()
+		got, err := repo.GetHolder(ctx, "refresh")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Fatalf("holder = %q, want %q", got, want)
+		}
+	}
+
+	holder("")
+	acquire("a", time.Minute, true)
+	acquire("b", time.Minute, false)
+	acquire("a", time.Minute, true) // renewal
+	holder("a")
+
+	// Releasing someone else's lease does nothing
+	if err := repo.Release(ctx, "refresh", "b"); err != nil {
+		t.Fatal(err)
+	}
+	holder("a")
+	if err := repo.Release(ctx, "refresh", "a"); err != nil {
+		t.Fatal(err)
+	}
+	acquire("b", time.Minute, true)
+
+	// An expired lease can be taken over
+	acquire("b", -time.Second, true)
+	holder("")
+	acquire("a", time.Minute, true)
+	holder("a")
+}
@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"schools-be/internal/database"
+	"schools-be/internal/errors"
+	"schools-be/internal/models"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type LocationRepository struct {
+	writer *sqlx.DB
+	reader *sqlx.DB
+}
+
+func NewLocationRepository(db *database.DB) *LocationRepository {
+	return &LocationRepository{writer: db.Writer, reader: db.Reader}
+}
+
+// Create stores a new user location
+func (r *LocationRepository) Create(ctx context.Context, location *models.UserLocation) error {
+	query := `INSERT INTO user_locations (token, latitude, longitude, created_at) VALUES (?, ?, ?, ?)`
+
+	_, err := r.writer.ExecContext(ctx, query, location.Token, location.Latitude, location.Longitude, location.CreatedAt)
+	if err != nil {
+		return errors.NewDatabaseError("create user location", err)
+	}
+
+	return nil
+}
+
+// GetByToken returns a user location by its token
+func (r *LocationRepository) GetByToken(ctx context.Context, token string) (*models.UserLocation, error) {
+	var location models.UserLocation
+	query := `SELECT * FROM user_locations WHERE token = ?`
+
+	err := r.reader.GetContext(ctx, &location, query, token)
+	if err == sql.ErrNoRows {
+		return nil, errors.NewNotFoundError("location", token)
+	}
+	if err != nil {
+		return nil, errors.NewDatabaseError("get user location", err)
+	}
+
+	return &location, nil
+}
+
+// SetStatus records the state of the commute computation for a location and mode
+func (r *LocationRepository) SetStatus(ctx context.Context, token, mode, status, errMsg string) error {
+	query := `
+		INSERT INTO commute_matrix_status (location_token, mode, status, error, updated_at)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(location_token, mode) DO UPDATE SET
+			status = excluded.status,
+			error = excluded.error,
+			updated_at = excluded.updated_at
+	`
+
+	_, err := r.writer.ExecContext(ctx, query, token, mode, status, errMsg, time.Now())
+	if err != nil {
+		return errors.NewDatabaseError("set commute status", err)
+	}
+
+	return nil
+}
+
+// GetStatuses returns the commute computation state of every mode for a location
+func (r *LocationRepository) GetStatuses(ctx context.Context, token string) ([]models.CommuteMatrixStatus, error) {
+	var statuses []models.CommuteMatrixStatus
+	query := `SELECT * FROM commute_matrix_status WHERE location_token = ? ORDER BY mode`
+
+	err := r.reader.SelectContext(ctx, &statuses, query, token)
+	if err != nil {
+		return nil, errors.NewDatabaseError("get commute statuses", err)
+	}
+
+	return statuses, nil
+}
+
+// ReplaceCommuteTimes stores the commute matrix for a location and mode, replacing earlier results
+func (r *LocationRepository) ReplaceCommuteTimes(ctx context.Context, token, mode string, times []models.CommuteTime) error {
+	tx, err := r.writer.BeginTxx(ctx, nil)
+	if err != nil {
+		return errors.NewDatabaseError("begin transaction", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM commute_times WHERE location_token = ? AND mode = ?`, token, mode); err != nil {
+		return errors.NewDatabaseError("clear commute times", err)
+	}
+
+	stmt, err := tx.PreparexContext(ctx, `
+		INSERT INTO commute_times (location_token, mode, school_id, duration_seconds, distance_meters, computed_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return errors.NewDatabaseError("prepare statement", err)
+	}
+	defer stmt.Close()
+
+	for _, t := range times {
+		if _, err := stmt.ExecContext(ctx, token, mode, t.SchoolID, t.DurationSeconds, t.DistanceMeters, t.ComputedAt); err != nil {
+			return errors.NewDatabaseError("insert commute time", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.NewDatabaseError("commit transaction", err)
+	}
+
+	return nil
+}
+
+// GetCommuteTimes returns the commute times for a location and mode, fastest first
+func (r *LocationRepository) GetCommuteTimes(ctx context.Context, token, mode string) ([]models.CommuteTime, error) {
+	var times []models.CommuteTime
+	query := `
+		SELECT location_token, mode, school_id, duration_seconds, distance_meters, computed_at
+		FROM commute_times
+		WHERE location_token = ? AND mode = ?
+		ORDER BY duration_seconds
+	`
+
+	err := r.reader.SelectContext(ctx, &times, query, token, mode)
+	if err != nil {
+		return nil, errors.NewDatabaseError("get commute times", err)
+	}
+
+	return times, nil
+}
@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"schools-be/internal/database"
+	"schools-be/internal/errors"
+	"schools-be/internal/models"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// publicDatasets are the datasets whose freshness the open data endpoints report, with the
+// column telling when a record was last refreshed
+var publicDatasets = []struct {
+	name, table, column string
+}{
+	{"schools", "schools", "updated_at"},
+	{"construction_projects", "construction_projects", "updated_at"},
+	{"statistics", "school_statistics", "scraped_at"},
+	{"school_details", "school_details", "scraped_at"},
+	{"applications", "school_applications", "fetched_at"},
+	{"exam_results", "school_exam_results", "fetched_at"},
+}
+
+// PublicRepository reads the aggregates served without an API key
+type PublicRepository struct {
+	reader *sqlx.DB
+}
+
+func NewPublicRepository(db *database.DB) *PublicRepository {
+	return &PublicRepository{reader: db.Reader}
+}
+
+// GetDistrictSchoolCounts counts the schools per district, by district name
+func (r *PublicRepository) GetDistrictSchoolCounts(ctx context.Context) ([]models.DistrictCount, error) {
+	query := `
+		SELECT district, COUNT(*) AS schools
+		FROM schools
+		WHERE district != ''
+		GROUP BY district
+		ORDER BY district
+	`
+
+	return getList[models.DistrictCount](ctx, r.reader, "get district school counts", query)
+}
+
+// CountSchools counts all schools, with or without a district
+func (r *PublicRepository) CountSchools(ctx context.Context) (int, error) {
+	return countRows(ctx, r.reader, "count schools", `SELECT COUNT(*) FROM schools`)
+}
+
+// GetLatestYearStatistics retrieves the statistics rows of the most recent school year
+func (r *PublicRepository) GetLatestYearStatistics(ctx context.Context) ([]models.SchoolStatistic, error) {
+	query := `SELECT * FROM school_statistics WHERE school_year = (SELECT MAX(school_year) FROM school_statistics)`
+
+	return getList[models.SchoolStatistic](ctx, r.reader, "get latest year statistics", query)
+}
+
+// GetFreshness returns the record count and the last refresh of each public dataset
+func (r *PublicRepository) GetFreshness(ctx context.Context) ([]models.DatasetFreshness, error) {
+	freshness := make([]models.DatasetFreshness, 0, len(publicDatasets))
+	for _, dataset := range publicDatasets {
+		records, err := countRows(ctx, r.reader, "count "+dataset.name, `SELECT COUNT(*) FROM `+dataset.table)
+		if err != nil {
+			return nil, err
+		}
+
+		// MAX() loses the column type, so the newest value is read by ordering instead
+		entry := models.DatasetFreshness{Dataset: dataset.name, Records: records}
+		var updatedAt time.Time
+		query := `SELECT ` + dataset.column + ` FROM ` + dataset.table + ` WHERE ` + dataset.column + ` IS NOT NULL ORDER BY ` + dataset.column + ` DESC LIMIT 1`
+		switch err := r.reader.QueryRowContext(ctx, query).Scan(&updatedAt); err {
+		case nil:
+			entry.UpdatedAt = &updatedAt
+		case sql.ErrNoRows:
+		default:
+			return nil, errors.NewDatabaseError("get "+dataset.name+" freshness", err)
+		}
+		freshness = append(freshness, entry)
+	}
+	return freshness, nil
+}
@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"context"
+
+	"schools-be/internal/database"
+	"schools-be/internal/errors"
+	"schools-be/internal/models"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// QueryRepository runs ad-hoc read-only queries on the reader pool, whose connections are
+// query-only, so writes fail even if a statement gets past validation
+type QueryRepository struct {
+	reader *sqlx.DB
+}
+
+func NewQueryRepository(db *database.DB) *QueryRepository {
+	return &QueryRepository{reader: db.Reader}
+}
+
+// CheckPlan compiles the query with EXPLAIN and rejects it if the program would open a table
+// for writing, or read a table (or its indexes) outside the dataset. Visitor data and
+// internal tables are not readable; the schema table is, so users can list tables.
+func (r *QueryRepository) CheckPlan(ctx context.Context, query string, params []interface{}) error {
+	allowed := make(map[int64]bool)
+	excluded := make(map[string]bool, len(visitorTables)+len(internalTables))
+	for _, table := range visitorTables {
+		excluded[table] = true
+	}
+	for _, table := range internalTables {
+		excluded[table] = true
+	}
+
+	var objects []struct {
+		Table    string `db:"tbl_name"`
+		RootPage int64  `db:"rootpage"`
+	}
+	if err := r.reader.SelectContext(ctx, &objects, `SELECT tbl_name, rootpage FROM sqlite_master WHERE rootpage > 0`); err != nil {
+		return errors.NewDatabaseError("read schema", err)
+	}
+	allowed[1] = true // sqlite_master
+	for _, o := range objects {
+		if !excluded[o.Table] {
+			allowed[o.RootPage] = true
+		}
+	}
+
+	rows, err := r.reader.QueryxContext(ctx, `EXPLAIN `+query, params...)
+	if err != nil {
+		return errors.NewValidationError("sql", err.Error())
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var op struct {
+			Addr    int64       `db:"addr"`
+			Opcode  string      `db:"opcode"`
+			P1      int64       `db:"p1"`
+			P2      int64       `db:"p2"`
+			P3      int64       `db:"p3"`
+			P4      interface{} `db:"p4"`
+			P5      int64       `db:"p5"`
+			Comment interface{} `db:"comment"`
+		}
+		if err := rows.StructScan(&op); err != nil {
+			return errors.NewDatabaseError("read query plan", err)
+		}
+		switch op.Opcode {
+		case "OpenWrite":
+			return errors.NewValidationError("sql", "only read-only queries are allowed")
+		case "OpenRead":
+			// P3 is the database: 0 is main; temp and attached databases are not allowed
+			if op.P3 != 0 || !allowed[op.P2] {
+				return errors.NewValidationError("sql", "query reads a table that is not part of the dataset")
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return errors.NewValidationError("sql", err.Error())
+	}
+	return nil
+}
+
+// Run executes a query and returns at most maxRows rows; Truncated reports whether there
+// were more. Byte values are returned as strings.
+func (r *QueryRepository) Run(ctx context.Context, query string, params []interface{}, maxRows int) (*models.QueryResult, error) {
+	rows, err := r.reader.QueryxContext(ctx, query, params...)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, errors.NewValidationError("sql", err.Error())
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, errors.NewDatabaseError("read query columns", err)
+	}
+
+	result := &models.QueryResult{Columns: columns, Rows: [][]interface{}{}}
+	for rows.Next() {
+		if len(result.Rows) == maxRows {
+			result.Truncated = true
+			break
+		}
+		values, err := rows.SliceScan()
+		if err != nil {
+			return nil, errors.NewDatabaseError("scan query row", err)
+		}
+		for i, v := range values {
+			if b, ok := v.([]byte); ok {
+				values[i] = string(b)
+			}
+		}
+		result.Rows = append(result.Rows, values)
+	}
+	if err := rows.Err(); err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, errors.NewValidationError("sql", err.Error())
+	}
+
+	result.RowCount = len(result.Rows)
+	return result, nil
+}
@@ -0,0 +1,156 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"schools-be/internal/database"
+	"schools-be/internal/errors"
+	"schools-be/internal/models"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// QueueRepository stores the tasks of the database job queue
+type QueueRepository struct {
+	writer *sqlx.DB
+	reader *sqlx.DB
+}
+
+func NewQueueRepository(db *database.DB) *QueueRepository {
+	return &QueueRepository{writer: db.Writer, reader: db.Reader}
+}
+
+const queueTaskColumns = `id, kind, payload, attempts, last_error, failed_at`
+
+// Add queues one task of kind per payload
+func (r *QueueRepository) Add(ctx context.Context, kind string, payloads []string) error {
+	tx, err := r.writer.BeginTxx(ctx, nil)
+	if err != nil {
+		return errors.NewDatabaseError("begin transaction", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PreparexContext(ctx, `INSERT INTO queue_tasks (kind, payload, available_at) VALUES (?, ?, ?)`)
+	if err != nil {
+		return errors.NewDatabaseError("prepare statement", err)
+	}
+	defer stmt.Close()
+
+	now := time.Now().UnixMilli()
+	for _, payload := range payloads {
+		if _, err := stmt.ExecContext(ctx, kind, payload, now); err != nil {
+			return errors.NewDatabaseError("add queue task", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.NewDatabaseError("commit transaction", err)
+	}
+	return nil
+}
+
+// Claim hands the oldest available task of kind to holder until visibility from now, counting
+// the delivery in Attempts. It returns nil when no task is available.
+func (r *QueueRepository) Claim(ctx context.Context, kind, holder string, visibility time.Duration) (*models.QueueTask, error) {
+	now := time.Now()
+	query := `UPDATE queue_tasks SET locked_by = ?, locked_until = ?, attempts = attempts + 1
+	          WHERE id = (
+	              SELECT id FROM queue_tasks
+	              WHERE kind = ? AND failed_at IS NULL AND available_at <= ?
+	                AND (locked_until IS NULL OR locked_until <= ?)
+	              ORDER BY id LIMIT 1
+	          )
+	          RETURNING ` + queueTaskColumns
+
+	var task models.QueueTask
+	err := r.writer.GetContext(ctx, &task, query, holder, now.Add(visibility).UnixMilli(), kind, now.UnixMilli(), now.UnixMilli())
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.NewDatabaseError("claim queue task", err)
+	}
+	return &task, nil
+}
+
+// Complete removes a task holder finished
+func (r *QueueRepository) Complete(ctx context.Context, id int64, holder string) error {
+	if _, err := r.writer.ExecContext(ctx, `DELETE FROM queue_tasks WHERE id = ? AND locked_by = ?`, id, holder); err != nil {
+		return errors.NewDatabaseError("complete queue task", err)
+	}
+	return nil
+}
+
+// Retry releases a task holder failed on, to be delivered again from availableAt
+func (r *QueueRepository) Retry(ctx context.Context, id int64, holder string, availableAt time.Time, lastError string) error {
+	query := `UPDATE queue_tasks SET locked_by = NULL, locked_until = NULL, available_at = ?, last_error = ?
+	          WHERE id = ? AND locked_by = ?`
+	if _, err := r.writer.ExecContext(ctx, query, availableAt.UnixMilli(), lastError, id, holder); err != nil {
+		return errors.NewDatabaseError("retry queue task", err)
+	}
+	return nil
+}
+
+// Fail marks a task as failed for good; it stays for inspection until the kind is cleared
+func (r *QueueRepository) Fail(ctx context.Context, id int64, holder, lastError string) error {
+	query := `UPDATE queue_tasks SET locked_by = NULL, locked_until = NULL, last_error = ?, failed_at = ?
+	          WHERE id = ? AND locked_by = ?`
+	if _, err := r.writer.ExecContext(ctx, query, lastError, time.Now().UTC(), id, holder); err != nil {
+		return errors.NewDatabaseError("fail queue task", err)
+	}
+	return nil
+}
+
+// CountPending counts the tasks of kind that are neither done nor failed
+func (r *QueueRepository) CountPending(ctx context.Context, kind string) (int, error) {
+	var count int
+	if err := r.reader.GetContext(ctx, &count, `SELECT COUNT(*) FROM queue_tasks WHERE kind = ? AND failed_at IS NULL`, kind); err != nil {
+		return 0, errors.NewDatabaseError("count queue tasks", err)
+	}
+	return count, nil
+}
+
+// GetFailed returns the failed tasks of kind, oldest first
+func (r *QueueRepository) GetFailed(ctx context.Context, kind string) ([]models.QueueTask, error) {
+	var tasks []models.QueueTask
+	query := `SELECT ` + queueTaskColumns + ` FROM queue_tasks WHERE kind = ? AND failed_at IS NOT NULL ORDER BY id`
+	if err := r.reader.SelectContext(ctx, &tasks, query, kind); err != nil {
+		return nil, errors.NewDatabaseError("get failed queue tasks", err)
+	}
+	return tasks, nil
+}
+
+// DeletePending removes the tasks of kind that are neither failed nor claimed by a live holder
+// and returns their payloads in queue order
+func (r *QueueRepository) DeletePending(ctx context.Context, kind string) ([]string, error) {
+	tx, err := r.writer.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, errors.NewDatabaseError("begin transaction", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now().UnixMilli()
+	where := `WHERE kind = ? AND failed_at IS NULL AND (locked_until IS NULL OR locked_until <= ?)`
+	var payloads []string
+	if err := tx.SelectContext(ctx, &payloads, `SELECT payload FROM queue_tasks `+where+` ORDER BY id`, kind, now); err != nil {
+		return nil, errors.NewDatabaseError("get pending queue tasks", err)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM queue_tasks `+where, kind, now); err != nil {
+		return nil, errors.NewDatabaseError("delete pending queue tasks", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, errors.NewDatabaseError("commit transaction", err)
+	}
+	return payloads, nil
+}
+
+// DeleteKind removes all tasks of kind, pending and failed
+func (r *QueueRepository) DeleteKind(ctx context.Context, kind string) error {
+	if _, err := r.writer.ExecContext(ctx, `DELETE FROM queue_tasks WHERE kind = ?`, kind); err != nil {
+		return errors.NewDatabaseError("delete queue tasks", err)
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+package repository
+
+import (
+	"context"
+
+	"schools-be/internal/database"
+	"schools-be/internal/errors"
+	"schools-be/internal/models"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// currentSchoolNumber is a school number parameter resolved through school_aliases, so
+// lookups and imports by the former number of a renumbered or merged school reach the
+// current one. It takes the number twice: "school_number = " + currentSchoolNumber.
+const currentSchoolNumber = `COALESCE((SELECT new_number FROM school_aliases WHERE old_number = ?), ?)`
+
+// maxAliasChain bounds how many renumberings of one school are collapsed
+const maxAliasChain = 10
+
+// SchoolAliasRepository stores the former school numbers of renumbered and merged schools
+type SchoolAliasRepository struct {
+	writer *sqlx.DB
+	reader *sqlx.DB
+}
+
+func NewSchoolAliasRepository(db *database.DB) *SchoolAliasRepository {
+	return &SchoolAliasRepository{writer: db.Writer, reader: db.Reader}
+}
+
+// Save stores aliases in one transaction and returns how many were added or changed.
+// Aliases of the maintained mapping replace existing ones; heuristic aliases only add
+// numbers not mapped yet, and a later alias replaces one the other way round. Chains such
+// as A→B, B→C are collapsed to A→C, B→C.
+func (r *SchoolAliasRepository) Save(ctx context.Context, aliases []models.SchoolAlias) (int, error) {
+	tx, err := r.writer.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, errors.NewDatabaseError("begin transaction", err)
+	}
+	defer tx.Rollback()
+
+	insert := `INSERT INTO school_aliases (old_number, new_number, source, reason) VALUES (?, ?, ?, ?)`
+	saved := 0
+	for _, alias := range aliases {
+		if alias.OldNumber == "" || alias.NewNumber == "" || alias.OldNumber == alias.NewNumber {
+			continue
+		}
+		query := insert + ` ON CONFLICT(old_number) DO NOTHING`
+		if alias.Source == models.AliasSourceMapping {
+			query = insert + ` ON CONFLICT(old_number) DO UPDATE SET
+			      new_number = excluded.new_number, source = excluded.source, reason = excluded.reason
+			      WHERE new_number != excluded.new_number OR source != excluded.source OR reason != excluded.reason`
+		}
+		n, err := execQuery(ctx, tx, "save school alias", query, alias.OldNumber, alias.NewNumber, alias.Source, alias.Reason)
+		if err != nil {
+			return 0, err
+		}
+		if n == 0 {
+			continue
+		}
+		saved++
+		// The new number is current again, so an alias the other way round is outdated
+		if _, err := execQuery(ctx, tx, "delete reverse school alias",
+			`DELETE FROM school_aliases WHERE old_number = ? AND new_number = ?`, alias.NewNumber, alias.OldNumber); err != nil {
+			return 0, err
+		}
+	}
+
+	for i := 0; i < maxAliasChain; i++ {
+		n, err := execQuery(ctx, tx, "collapse school aliases", `
+			UPDATE school_aliases
+			SET new_number = (SELECT next.new_number FROM school_aliases next WHERE next.old_number = school_aliases.new_number)
+			WHERE new_number IN (SELECT old_number FROM school_aliases)
+		`)
+		if err != nil {
+			return 0, err
+		}
+		if n == 0 {
+			break
+		}
+	}
+	// Longer loops collapse onto themselves and say nothing about the current number
+	if _, err := execQuery(ctx, tx, "delete looping school aliases", `DELETE FROM school_aliases WHERE old_number = new_number`); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, errors.NewDatabaseError("commit transaction", err)
+	}
+	return saved, nil
+}
+
+// GetAll returns all aliases by old number
+func (r *SchoolAliasRepository) GetAll(ctx context.Context) ([]models.SchoolAlias, error) {
+	query := `SELECT * FROM school_aliases ORDER BY old_number`
+
+	return getList[models.SchoolAlias](ctx, r.reader, "get school aliases", query)
+}
+
+// GetByNewNumber returns the former numbers of a school
+func (r *SchoolAliasRepository) GetByNewNumber(ctx context.Context, schoolNumber string) ([]models.SchoolAlias, error) {
+	query := `SELECT * FROM school_aliases WHERE new_number = ? ORDER BY old_number`
+
+	return getList[models.SchoolAlias](ctx, r.reader, "get school aliases", query, schoolNumber)
+}
+
+// applyAliases renumbers the schools that the feed lists under a new number before their
+// old number is deleted, so their scraped data follows through the foreign keys. A school
+// merged into one that already exists hands over the rows the other does not have yet.
+func applyAliases(ctx context.Context, tx *sqlx.Tx, feedNumbersJSON string) (int, error) {
+	aliases, err := getList[models.SchoolAlias](ctx, tx, "get school aliases to apply", `
+		SELECT a.* FROM school_aliases a
+		JOIN schools s ON s.school_number = a.old_number
+		WHERE a.old_number NOT IN (SELECT value FROM json_each(?))
+		  AND a.new_number IN (SELECT value FROM json_each(?))
+		ORDER BY a.old_number
+	`, feedNumbersJSON, feedNumbersJSON)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, alias := range aliases {
+		renumbered, err := execQuery(ctx, tx, "renumber school", `
+			UPDATE schools SET school_number = ?
+			WHERE school_number = ? AND NOT EXISTS (SELECT 1 FROM schools WHERE school_number = ?)
+		`, alias.NewNumber, alias.OldNumber, alias.NewNumber)
+		if err != nil {
+			return 0, err
+		}
+		if renumbered > 0 {
+			continue
+		}
+
+		// Table names come from the fixed list, never from input
+		for _, table := range database.SchoolChildTables() {
+			if _, err := execQuery(ctx, tx, "merge "+table, `UPDATE OR IGNORE `+table+` SET school_number = ? WHERE school_number = ?`, alias.NewNumber, alias.OldNumber); err != nil {
+				return 0, err
+			}
+		}
+	}
+	return len(aliases), nil
+}
+
+// aliasMap returns the current number by old number, for imports that write many rows at once
+func aliasMap(ctx context.Context, db sqlx.QueryerContext) (map[string]string, error) {
+	aliases, err := getList[models.SchoolAlias](ctx, db, "get school aliases", `SELECT * FROM school_aliases`)
+	if err != nil {
+		return nil, err
+	}
+	current := make(map[string]string, len(aliases))
+	for _, alias := range aliases {
+		current[alias.OldNumber] = alias.NewNumber
+	}
+	return current, nil
+}
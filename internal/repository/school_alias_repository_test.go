@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"schools-be/internal/models"
+)
+
+// TestSchoolAliasChains checks that saved aliases point at the newest number and that the
+// maintained mapping wins over heuristics and of two opposite aliases the later one
+func TestSchoolAliasChains(t *testing.T) {
+	repo := NewSchoolAliasRepository(newTestDB(t))
+	ctx := context.Background()
+
+	_, err := repo.Save(ctx, []models.SchoolAlias{
+		{OldNumber: "01A01", NewNumber: "01B01", Source: models.AliasSourceHeuristic},
+		{OldNumber: "01B01", NewNumber: "01C01", Source: models.AliasSourceMapping},
+		{OldNumber: "02A01", NewNumber: "02B01", Source: models.AliasSourceMapping},
+		{OldNumber: "02A01", NewNumber: "02C01", Source: models.AliasSourceHeuristic},
+		{OldNumber: "03A01", NewNumber: "03B01", Source: models.AliasSourceMapping},
+		{OldNumber: "03B01", NewNumber: "03A01", Source: models.AliasSourceMapping},
+	})
+	if err != nil {
+		t.Fatalf("save: %v", err)
+	}
+
+	aliases, err := repo.GetAll(ctx)
+	if err != nil {
+		t.Fatalf("get all: %v", err)
+	}
+	got := map[string]string{}
+	for _, alias := range aliases {
+		got[alias.OldNumber] = alias.NewNumber
+	}
+	want := map[string]string{"01A01": "01C01", "01B01": "01C01", "02A01": "02B01", "03B01": "03A01"}
+	if len(got) != len(want) {
+		t.Fatalf("aliases = %v, want %v", got, want)
+	}
+	for old, current := range want {
+		if got[old] != current {
+			t.Errorf("alias of %s = %q, want %q", old, got[old], current)
+		}
+	}
+}
+
+// TestSchoolAliasSync checks that a renumbered school keeps its ID and data, and that
+// lookups and imports by the old number reach it
+func TestSchoolAliasSync(t *testing.T) {
+	db := newTestDB(t)
+	schools := NewSchoolRepository(db)
+	aliases := NewSchoolAliasRepository(db)
+	exams := NewExamResultRepository(db)
+	ctx := context.Background()
+
+	if _, _, err := schools.Sync(ctx, []models.CreateSchoolInput{{SchoolNumber: "01Y01", Name: "Alte Schule"}}); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	before, err := schools.GetBySchoolNumber(ctx, "01Y01")
+	if err != nil {
+		t.Fatalf("get school: %v", err)
+	}
+	if _, _, err := exams.Upsert(ctx, []models.ExamResultInput{{SchoolNumber: "01Y01", SchoolYear: "2023/24"}}); err != nil {
+		t.Fatalf("upsert exam result: %v", err)
+	}
+
+	if _, err := aliases.Save(ctx, []models.SchoolAlias{{OldNumber: "01Y01", NewNumber: "01Y02", Source: models.AliasSourceMapping}}); err != nil {
+		t.Fatalf("save alias: %v", err)
+	}
+	_, deleted, err := schools.Sync(ctx, []models.CreateSchoolInput{{SchoolNumber: "01Y02", Name: "Neue Schule"}})
+	if err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	if deleted != 0 {
+		t.Errorf("deleted = %d, want 0", deleted)
+	}
+
+	after, err := schools.GetBySchoolNumber(ctx, "01Y01")
+	if err != nil {
+		t.Fatalf("get school by old number: %v", err)
+	}
+	if after.ID != before.ID || after.SchoolNumber != "01Y02" || after.Name != "Neue Schule" {
+		t.Errorf("school by old number = #%d %s %q, want #%d 01Y02 %q", after.ID, after.SchoolNumber, after.Name, before.ID, "Neue Schule")
+	}
+
+	if saved, _, err := exams.Upsert(ctx, []models.ExamResultInput{{SchoolNumber: "01Y01", SchoolYear: "2024/25"}}); err != nil || saved != 1 {
+		t.Fatalf("upsert exam result by old number: saved %d, %v", saved, err)
+	}
+	results, err := exams.GetBySchoolNumber(ctx, "01Y02")
+	if err != nil {
+		t.Fatalf("get exam results: %v", err)
+	}
+	if len(results) != 2 {
+		t.Errorf("exam results = %d, want both years under the new number", len(results))
+	}
+}
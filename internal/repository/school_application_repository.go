@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"schools-be/internal/database"
+	"schools-be/internal/errors"
+	"schools-be/internal/models"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type SchoolApplicationRepository struct {
+	writer *sqlx.DB
+	reader *sqlx.DB
+}
+
+func NewSchoolApplicationRepository(db *database.DB) *SchoolApplicationRepository {
+	return &SchoolApplicationRepository{writer: db.Writer, reader: db.Reader}
+}
+
+// Upsert stores application numbers in one transaction, replacing existing rows of the
+// same school and year. Rows under a replaced school number are stored under the current
+// one; rows for school numbers not in the schools table are skipped and counted in the
+// second return value.
+func (r *SchoolApplicationRepository) Upsert(ctx context.Context, applications []models.SchoolApplicationInput) (saved int, skipped int, err error) {
+	tx, err := r.writer.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, 0, errors.NewDatabaseError("begin transaction", err)
+	}
+	defer tx.Rollback()
+
+	query := `INSERT INTO school_applications (school_number, school_year, places, first_choice_applications, fetched_at)
+	          SELECT ` + currentSchoolNumber + `, ?, ?, ?, ?
+	          WHERE EXISTS (SELECT 1 FROM schools WHERE school_number = ` + currentSchoolNumber + `)
+	          ON CONFLICT(school_number, school_year) DO UPDATE SET
+	              places = excluded.places,
+	              first_choice_applications = excluded.first_choice_applications,
+	              fetched_at = excluded.fetched_at`
+
+	now := time.Now()
+	for _, application := range applications {
+		n, err := execQuery(ctx, tx, "upsert school application", query,
+			application.SchoolNumber, application.SchoolNumber, application.SchoolYear, application.Places,
+			application.FirstChoiceApplications, now, application.SchoolNumber, application.SchoolNumber)
+		if err != nil {
+			return 0, 0, err
+		}
+		if n == 0 {
+			skipped++
+			continue
+		}
+		saved++
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, 0, errors.NewDatabaseError("commit transaction", err)
+	}
+	return saved, skipped, nil
+}
+
+// GetBySchoolNumber retrieves the application numbers of a school, newest school year first
+func (r *SchoolApplicationRepository) GetBySchoolNumber(ctx context.Context, schoolNumber string) ([]models.SchoolApplication, error) {
+	var applications []models.SchoolApplication
+	query := `SELECT * FROM school_applications WHERE school_number = ` + currentSchoolNumber + ` ORDER BY school_year DESC`
+
+	err := r.reader.SelectContext(ctx, &applications, query, schoolNumber, schoolNumber)
+	if err != nil {
+		return nil, errors.NewDatabaseError("get school applications", err)
+	}
+
+	return applications, nil
+}
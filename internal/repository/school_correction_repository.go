@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"schools-be/internal/database"
+	"schools-be/internal/errors"
+	"schools-be/internal/models"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// SchoolCorrectionRepository stores the corrections suggested by users and the overrides
+// of the accepted ones
+type SchoolCorrectionRepository struct {
+	writer *sqlx.DB
+	reader *sqlx.DB
+}
+
+func NewSchoolCorrectionRepository(db *database.DB) *SchoolCorrectionRepository {
+	return &SchoolCorrectionRepository{writer: db.Writer, reader: db.Reader}
+}
+
+// Create stores a pending correction. Submitting the same fix again while it is pending
+// returns the stored correction instead of queueing a duplicate.
+func (r *SchoolCorrectionRepository) Create(ctx context.Context, c models.SchoolCorrection) (*models.SchoolCorrection, error) {
+	existing, err := getList[models.SchoolCorrection](ctx, r.writer, "get pending school correction", `
+		SELECT * FROM school_corrections
+		WHERE school_number = ? AND field = ? AND value = ? AND status = ?
+		ORDER BY id LIMIT 1
+	`, c.SchoolNumber, c.Field, c.Value, models.CorrectionStatusPending)
+	if err != nil {
+		return nil, err
+	}
+	if len(existing) > 0 {
+		return &existing[0], nil
+	}
+
+	var id int64
+	err = r.writer.GetContext(ctx, &id, `
+		INSERT INTO school_corrections (school_number, field, current_value, value, comment, status, submitted_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		RETURNING id
+	`, c.SchoolNumber, c.Field, c.CurrentValue, c.Value, c.Comment, models.CorrectionStatusPending, time.Now())
+	if err != nil {
+		return nil, errors.NewDatabaseError("create school correction", err)
+	}
+
+	return r.GetByID(ctx, id)
+}
+
+func (r *SchoolCorrectionRepository) GetByID(ctx context.Context, id int64) (*models.SchoolCorrection, error) {
+	query := `SELECT * FROM school_corrections WHERE id = ?`
+
+	return getOne[models.SchoolCorrection](ctx, r.reader, "school correction", id, "get school correction", query, id)
+}
+
+// List returns the corrections with a status, oldest first so the review queue is worked
+// off in order; an empty status lists all
+func (r *SchoolCorrectionRepository) List(ctx context.Context, status string) ([]models.SchoolCorrection, error) {
+	query := `SELECT * FROM school_corrections WHERE ? = '' OR status = ? ORDER BY submitted_at, id`
+
+	return getList[models.SchoolCorrection](ctx, r.reader, "list school corrections", query, status, status)
+}
+
+// Review marks a pending correction as accepted or rejected. Accepting stores the value as
+// override of the field in the same transaction; the school row itself is changed by the
+// caller. A correction that was already reviewed is a conflict.
+func (r *SchoolCorrectionRepository) Review(ctx context.Context, id int64, status, note, reviewer string) (*models.SchoolCorrection, error) {
+	tx, err := r.writer.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, errors.NewDatabaseError("begin transaction", err)
+	}
+	defer tx.Rollback()
+
+	n, err := execQuery(ctx, tx, "review school correction", `
+		UPDATE school_corrections SET status = ?, review_note = ?, reviewed_by = ?, reviewed_at = ?
+		WHERE id = ? AND status = ?
+	`, status, note, reviewer, time.Now(), id, models.CorrectionStatusPending)
+	if err != nil {
+		return nil, err
+	}
+
+	correction, err := getOne[models.SchoolCorrection](ctx, tx, "school correction", id, "get school correction", `SELECT * FROM school_corrections WHERE id = ?`, id)
+	if err != nil {
+		return nil, err
+	}
+	if n == 0 {
+		return nil, errors.NewStateConflictError("school correction", id, correction.Status)
+	}
+
+	if status == models.CorrectionStatusAccepted {
+		if _, err := execQuery(ctx, tx, "save school override", `
+			INSERT INTO school_overrides (school_number, field, value, correction_id, created_at)
+			VALUES (?, ?, ?, ?, ?)
+			ON CONFLICT(school_number, field) DO UPDATE SET
+				value = excluded.value, correction_id = excluded.correction_id, created_at = excluded.created_at
+		`, correction.SchoolNumber, correction.Field, correction.Value, correction.ID, time.Now()); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, errors.NewDatabaseError("commit transaction", err)
+	}
+	return correction, nil
+}
+
+// overridesByNumber returns the overridden fields by school number, for the school import
+func overridesByNumber(ctx context.Context, db sqlx.QueryerContext) (map[string][]models.SchoolOverride, error) {
+	overrides, err := getList[models.SchoolOverride](ctx, db, "get school overrides", `SELECT * FROM school_overrides`)
+	if err != nil {
+		return nil, err
+	}
+	byNumber := make(map[string][]models.SchoolOverride)
+	for _, override := range overrides {
+		byNumber[override.SchoolNumber] = append(byNumber[override.SchoolNumber], override)
+	}
+	return byNumber, nil
+}
@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"context"
+	stderrors "errors"
+	"testing"
+
+	"schools-be/internal/errors"
+	"schools-be/internal/models"
+)
+
+// TestSchoolCorrectionReview checks that a correction is reviewed once and that an accepted
+// value survives the next import of the school list
+func TestSchoolCorrectionReview(t *testing.T) {
+	db := newTestDB(t)
+	schools := NewSchoolRepository(db)
+	corrections := NewSchoolCorrectionRepository(db)
+	ctx := context.Background()
+
+	feed := []models.CreateSchoolInput{{SchoolNumber: "01Z01", Name: "Schule", Website: "http://dead.example"}}
+	if _, _, err := schools.Sync(ctx, feed); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+
+	submitted := models.SchoolCorrection{SchoolNumber: "01Z01", Field: "website", CurrentValue: "http://dead.example", Comment: "gone"}
+	first, err := corrections.Create(ctx, submitted)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	again, err := corrections.Create(ctx, submitted)
+	if err != nil {
+		t.Fatalf("create again: %v", err)
+	}
+	if again.ID != first.ID {
+		t.Errorf("duplicate correction got id %d, want %d", again.ID, first.ID)
+	}
+
+	reviewed, err := corrections.Review(ctx, first.ID, models.CorrectionStatusAccepted, "checked", "admin_key")
+	if err != nil {
+		t.Fatalf("review: %v", err)
+	}
+	if reviewed.Status != models.CorrectionStatusAccepted || reviewed.ReviewedBy != "admin_key" || reviewed.ReviewedAt == nil {
+		t.Errorf("reviewed = %+v, want accepted by admin_key", reviewed)
+	}
+	if _, err := corrections.Review(ctx, first.ID, models.CorrectionStatusRejected, "", "admin_key"); !stderrors.Is(err, errors.ErrConflict) {
+		t.Errorf("second review error = %v, want conflict", err)
+	}
+
+	pending, err := corrections.List(ctx, models.CorrectionStatusPending)
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(pending) != 0 {
+		t.Errorf("pending = %d corrections, want 0", len(pending))
+	}
+
+	if _, _, err := schools.Sync(ctx, feed); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	school, err := schools.GetBySchoolNumber(ctx, "01Z01")
+	if err != nil {
+		t.Fatalf("get school: %v", err)
+	}
+	if school.Website != "" {
+		t.Errorf("website after refresh = %q, want the accepted correction %q", school.Website, "")
+	}
+}
@@ -2,22 +2,23 @@ package repository
 
 import (
 	"context"
-	"database/sql"
 	"encoding/json"
+	"strings"
 	"time"
 
-	"schools-be/internal/errors"
+	"schools-be/internal/database"
 	"schools-be/internal/models"
 
 	"github.com/jmoiron/sqlx"
 )
 
 type SchoolDetailRepository struct {
-	db *sqlx.DB
+	writer *sqlx.DB
+	reader *sqlx.DB
 }
 
-func NewSchoolDetailRepository(db *sqlx.DB) *SchoolDetailRepository {
-	return &SchoolDetailRepository{db: db}
+func NewSchoolDetailRepository(db *database.DB) *SchoolDetailRepository {
+	return &SchoolDetailRepository{writer: db.Writer, reader: db.Reader}
 }
 
 // Create creates a new school detail record
@@ -27,10 +28,12 @@ func (r *SchoolDetailRepository) Create(ctx context.Context, detail *models.Scho
 			school_number, school_name, languages, courses, offerings,
 			available_after_4th_grade, additional_info,
 			equipment, working_groups, partners, differentiation, lunch_info, dual_learning,
+			operator_organization, confession, fee_info, fee_min_eur, fee_max_eur, support_focuses, wheelchair_accessible, all_day_type,
+			europaschule, bilingual_languages, religious_education,
 			citizenship_data, language_data, residence_data, absence_data,
-			scraped_at, created_at, updated_at
+			parser_version, scraped_at, created_at, updated_at
 		)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
 	now := time.Now()
@@ -41,7 +44,7 @@ func (r *SchoolDetailRepository) Create(ctx context.Context, detail *models.Scho
 	residenceJSON, _ := r.tableToJSON(detail.ResidenceTable)
 	absenceJSON, _ := r.tableToJSON(detail.AbsenceTable)
 
-	_, err := r.db.ExecContext(ctx, query,
+	_, err := execQuery(ctx, r.writer, "create school detail", query,
 		detail.SchoolNumber,
 		detail.SchoolName,
 		detail.Languages,
@@ -55,20 +58,28 @@ func (r *SchoolDetailRepository) Create(ctx context.Context, detail *models.Scho
 		detail.Differentiation,
 		detail.LunchInfo,
 		detail.DualLearning,
+		detail.OperatorOrganization,
+		detail.Confession,
+		detail.FeeInfo,
+		detail.FeeMinEUR,
+		detail.FeeMaxEUR,
+		strings.Join(detail.SupportFocuses, ","),
+		detail.WheelchairAccessible,
+		detail.AllDayType,
+		detail.Europaschule,
+		strings.Join(detail.BilingualLanguages, ","),
+		strings.Join(detail.ReligiousEducation, ","),
 		citizenshipJSON,
 		languageJSON,
 		residenceJSON,
 		absenceJSON,
+		detail.ParserVersion,
 		detail.ScrapedAt,
 		now,
 		now,
 	)
 
-	if err != nil {
-		return errors.NewDatabaseError("create school detail", err)
-	}
-
-	return nil
+	return err
 }
 
 // Upsert inserts or updates a school detail record
@@ -78,10 +89,12 @@ func (r *SchoolDetailRepository) Upsert(ctx context.Context, detail *models.Scho
 			school_number, school_name, languages, courses, offerings,
 			available_after_4th_grade, additional_info,
 			equipment, working_groups, partners, differentiation, lunch_info, dual_learning,
+			operator_organization, confession, fee_info, fee_min_eur, fee_max_eur, support_focuses, wheelchair_accessible, all_day_type,
+			europaschule, bilingual_languages, religious_education,
 			citizenship_data, language_data, residence_data, absence_data,
-			scraped_at, created_at, updated_at
+			parser_version, scraped_at, created_at, updated_at
 		)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 		ON CONFLICT(school_number) DO UPDATE SET
 			school_name = excluded.school_name,
 			languages = excluded.languages,
@@ -95,10 +108,22 @@ func (r *SchoolDetailRepository) Upsert(ctx context.Context, detail *models.Scho
 			differentiation = excluded.differentiation,
 			lunch_info = excluded.lunch_info,
 			dual_learning = excluded.dual_learning,
+			operator_organization = excluded.operator_organization,
+			confession = excluded.confession,
+			fee_info = excluded.fee_info,
+			fee_min_eur = excluded.fee_min_eur,
+			fee_max_eur = excluded.fee_max_eur,
+			support_focuses = excluded.support_focuses,
+			wheelchair_accessible = excluded.wheelchair_accessible,
+			all_day_type = excluded.all_day_type,
+			europaschule = excluded.europaschule,
+			bilingual_languages = excluded.bilingual_languages,
+			religious_education = excluded.religious_education,
 			citizenship_data = excluded.citizenship_data,
 			language_data = excluded.language_data,
 			residence_data = excluded.residence_data,
 			absence_data = excluded.absence_data,
+			parser_version = excluded.parser_version,
 			scraped_at = excluded.scraped_at,
 			updated_at = excluded.updated_at
 	`
@@ -111,7 +136,7 @@ func (r *SchoolDetailRepository) Upsert(ctx context.Context, detail *models.Scho
 	residenceJSON, _ := r.tableToJSON(detail.ResidenceTable)
 	absenceJSON, _ := r.tableToJSON(detail.AbsenceTable)
 
-	_, err := r.db.ExecContext(ctx, query,
+	_, err := execQuery(ctx, r.writer, "upsert school detail", query,
 		detail.SchoolNumber,
 		detail.SchoolName,
 		detail.Languages,
@@ -125,99 +150,112 @@ func (r *SchoolDetailRepository) Upsert(ctx context.Context, detail *models.Scho
 		detail.Differentiation,
 		detail.LunchInfo,
 		detail.DualLearning,
+		detail.OperatorOrganization,
+		detail.Confession,
+		detail.FeeInfo,
+		detail.FeeMinEUR,
+		detail.FeeMaxEUR,
+		strings.Join(detail.SupportFocuses, ","),
+		detail.WheelchairAccessible,
+		detail.AllDayType,
+		detail.Europaschule,
+		strings.Join(detail.BilingualLanguages, ","),
+		strings.Join(detail.ReligiousEducation, ","),
 		citizenshipJSON,
 		languageJSON,
 		residenceJSON,
 		absenceJSON,
+		detail.ParserVersion,
 		detail.ScrapedAt,
 		now,
 		now,
 	)
 
-	if err != nil {
-		return errors.NewDatabaseError("upsert school detail", err)
-	}
-
-	return nil
+	return err
 }
 
 // GetBySchoolNumber retrieves school details by school number
 func (r *SchoolDetailRepository) GetBySchoolNumber(ctx context.Context, schoolNumber string) (*models.SchoolDetail, error) {
-	var detail models.SchoolDetail
-	query := `SELECT * FROM school_details WHERE school_number = ?`
-
-	err := r.db.GetContext(ctx, &detail, query, schoolNumber)
-	if err == sql.ErrNoRows {
-		return nil, errors.NewNotFoundError("school detail", schoolNumber)
-	}
-	if err != nil {
-		return nil, errors.NewDatabaseError("get school detail by number", err)
-	}
+	query := `SELECT * FROM school_details WHERE school_number = ` + currentSchoolNumber
 
-	return &detail, nil
+	return getOne[models.SchoolDetail](ctx, r.reader, "school detail", schoolNumber, "get school detail by number", query, schoolNumber, schoolNumber)
 }
 
 // GetAll retrieves all school details
 func (r *SchoolDetailRepository) GetAll(ctx context.Context) ([]models.SchoolDetail, error) {
-	var details []models.SchoolDetail
 	query := `SELECT * FROM school_details ORDER BY school_name`
 
-	err := r.db.SelectContext(ctx, &details, query)
-	if err != nil {
-		return nil, errors.NewDatabaseError("get all school details", err)
-	}
-
-	return details, nil
+	return getList[models.SchoolDetail](ctx, r.reader, "get all school details", query)
 }
 
 // GetAvailableAfter4thGrade retrieves schools available after 4th grade
 func (r *SchoolDetailRepository) GetAvailableAfter4thGrade(ctx context.Context) ([]models.SchoolDetail, error) {
-	var details []models.SchoolDetail
-	query := `SELECT * FROM school_details WHERE available_after_4th_grade = 1 ORDER BY school_name`
+	return r.GetByAvailableAfter4thGrade(ctx, true)
+}
 
-	err := r.db.SelectContext(ctx, &details, query)
-	if err != nil {
-		return nil, errors.NewDatabaseError("get schools available after 4th grade", err)
-	}
+// GetByAvailableAfter4thGrade retrieves schools that do (or do not) start after 4th grade
+func (r *SchoolDetailRepository) GetByAvailableAfter4thGrade(ctx context.Context, available bool) ([]models.SchoolDetail, error) {
+	query := `SELECT * FROM school_details WHERE available_after_4th_grade = ? ORDER BY school_name`
+
+	return getList[models.SchoolDetail](ctx, r.reader, "get schools by availability after 4th grade", query, available)
+}
+
+// GetWithPrograms retrieves the schools with a bilingual branch or religious education
+func (r *SchoolDetailRepository) GetWithPrograms(ctx context.Context) ([]models.SchoolDetail, error) {
+	query := `
+		SELECT * FROM school_details
+		WHERE europaschule = 1 OR bilingual_languages != '' OR religious_education != ''
+		ORDER BY school_name
+	`
+
+	return getList[models.SchoolDetail](ctx, r.reader, "get school details with programs", query)
+}
+
+// SaveScrapeRun records the outcome of a completed scrape run
+func (r *SchoolDetailRepository) SaveScrapeRun(ctx context.Context, run models.ScrapeRun) error {
+	query := `
+		INSERT INTO scrape_runs (
+			scraper, started_at, finished_at, pages, failed, timeout_failures, navigation_failures,
+			element_missing_failures, parse_failures, other_failures, skipped, skipped_pages
+		)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	_, err := execQuery(ctx, r.writer, "save scrape run", query,
+		run.Scraper, run.StartedAt, run.FinishedAt, run.Pages, run.Failed, run.Timeout, run.Navigation,
+		run.ElementMissing, run.Parse, run.Other, run.Skipped, run.SkippedPages,
+	)
+	return err
+}
 
-	return details, nil
+// GetScrapeRuns retrieves the most recent runs of a scraper, newest first
+func (r *SchoolDetailRepository) GetScrapeRuns(ctx context.Context, scraper string, limit int) ([]models.ScrapeRun, error) {
+	query := `SELECT * FROM scrape_runs WHERE scraper = ? ORDER BY started_at DESC LIMIT ?`
+
+	return getList[models.ScrapeRun](ctx, r.reader, "get scrape runs", query, scraper, limit)
 }
 
 // Delete deletes a school detail by school number
 func (r *SchoolDetailRepository) Delete(ctx context.Context, schoolNumber string) error {
 	query := `DELETE FROM school_details WHERE school_number = ?`
 
-	_, err := r.db.ExecContext(ctx, query, schoolNumber)
-	if err != nil {
-		return errors.NewDatabaseError("delete school detail", err)
-	}
-
-	return nil
+	_, err := execQuery(ctx, r.writer, "delete school detail", query, schoolNumber)
+	return err
 }
 
 // DeleteAll deletes all school details
 func (r *SchoolDetailRepository) DeleteAll(ctx context.Context) error {
 	query := `DELETE FROM school_details`
 
-	_, err := r.db.ExecContext(ctx, query)
-	if err != nil {
-		return errors.NewDatabaseError("delete all school details", err)
-	}
-
-	return nil
+	_, err := execQuery(ctx, r.writer, "delete all school details", query)
+	return err
 }
 
 // GetCount returns the count of school details
 func (r *SchoolDetailRepository) GetCount(ctx context.Context) (int, error) {
-	var count int
 	query := `SELECT COUNT(*) FROM school_details`
 
-	err := r.db.GetContext(ctx, &count, query)
-	if err != nil {
-		return 0, errors.NewDatabaseError("get school details count", err)
-	}
-
-	return count, nil
+	return countRows(ctx, r.reader, "get school details count", query)
 }
 
 // tableToJSON converts a StatisticTable to JSON string
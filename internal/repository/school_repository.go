@@ -3,193 +3,368 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"time"
 
+	"schools-be/internal/cache"
+	"schools-be/internal/database"
 	"schools-be/internal/errors"
 	"schools-be/internal/models"
 
 	"github.com/jmoiron/sqlx"
 )
 
+// insertSchool and onSchoolConflict make up the school upsert: a school whose school
+// number already exists overwrites that row, so each school number is stored once and
+// keeps its ID
+const insertSchool = `
+	INSERT INTO schools (
+		school_number, name, school_type, operator, school_category,
+		district, neighborhood, postal_code, street, house_number,
+		phone, fax, email, website, school_year,
+		latitude, longitude, created_at, updated_at
+	)`
+
+const onSchoolConflict = `
+	ON CONFLICT(school_number) DO UPDATE SET
+		name = excluded.name,
+		school_type = excluded.school_type,
+		operator = excluded.operator,
+		school_category = excluded.school_category,
+		district = excluded.district,
+		neighborhood = excluded.neighborhood,
+		postal_code = excluded.postal_code,
+		street = excluded.street,
+		house_number = excluded.house_number,
+		phone = excluded.phone,
+		fax = excluded.fax,
+		email = excluded.email,
+		website = excluded.website,
+		school_year = excluded.school_year,
+		latitude = excluded.latitude,
+		longitude = excluded.longitude,
+		updated_at = excluded.updated_at,
+		version = schools.version + 1
+`
+
+// schoolRow returns the values of a school in the column order of insertSchool
+func schoolRow(input models.CreateSchoolInput, now time.Time) []interface{} {
+	return []interface{}{
+		input.SchoolNumber, input.Name, input.SchoolType, input.Operator, input.SchoolCategory,
+		input.District, input.Neighborhood, input.PostalCode, input.Street, input.HouseNumber,
+		input.Phone, input.Fax, input.Email, input.Website, input.SchoolYear,
+		input.Latitude, input.Longitude, now, now,
+	}
+}
+
 type SchoolRepository struct {
-	db *sqlx.DB
+	writer *sqlx.DB
+	reader *sqlx.DB
+	cache  *cache.Cache
+}
+
+func NewSchoolRepository(db *database.DB) *SchoolRepository {
+	return &SchoolRepository{writer: db.Writer, reader: db.Reader}
 }
 
-func NewSchoolRepository(db *sqlx.DB) *SchoolRepository {
-	return &SchoolRepository{db: db}
+// WithCache enables read-through caching of list queries (invalidated on every write)
+func (r *SchoolRepository) WithCache(c *cache.Cache) *SchoolRepository {
+	r.cache = c
+	return r
 }
 
 func (r *SchoolRepository) GetAll(ctx context.Context) ([]models.School, error) {
-	var schools []models.School
-	query := `SELECT * FROM schools ORDER BY created_at DESC`
+	schools, err := cache.GetOrLoad(r.cache, "all", func() ([]models.School, error) {
+		query := `SELECT * FROM schools ORDER BY created_at DESC`
 
-	err := r.db.SelectContext(ctx, &schools, query)
+		return getList[models.School](ctx, r.reader, "get all schools", query)
+	})
 	if err != nil {
-		return nil, errors.NewDatabaseError("get all schools", err)
+		return nil, err
 	}
 
-	return schools, nil
+	// Return a copy so callers can't mutate the cached slice
+	return append([]models.School(nil), schools...), nil
+}
+
+// Find returns the schools matching the filter in the order of GetAll; an empty filter is
+// GetAll and served from its cache
+func (r *SchoolRepository) Find(ctx context.Context, filter models.SchoolFilter) ([]models.School, error) {
+	if filter.IsEmpty() {
+		return r.GetAll(ctx)
+	}
+
+	where, args := schoolFilterWhere(filter, "")
+	query := `SELECT * FROM schools WHERE ` + where + ` ORDER BY created_at DESC`
+
+	return getList[models.School](ctx, r.reader, "find schools", query, args...)
+}
+
+// schoolFilterWhere builds the condition of a school filter on the schools table, whose
+// columns are prefixed with alias (e.g. "s."). Values are always bound as parameters.
+func schoolFilterWhere(filter models.SchoolFilter, alias string) (string, []interface{}) {
+	where := `1 = 1`
+	var args []interface{}
+	for _, field := range []struct{ column, value string }{
+		{"district", filter.District},
+		{"school_type", filter.SchoolType},
+		{"operator", filter.Operator},
+		{"postal_code", filter.PostalCode},
+		{"neighborhood", filter.Neighborhood},
+	} {
+		if field.value == "" {
+			continue
+		}
+		where += ` AND ` + alias + field.column + ` = ? COLLATE NOCASE`
+		args = append(args, field.value)
+	}
+	return where, args
 }
 
 func (r *SchoolRepository) GetByID(ctx context.Context, id int64) (*models.School, error) {
-	var school models.School
 	query := `SELECT * FROM schools WHERE id = ?`
 
-	err := r.db.GetContext(ctx, &school, query, id)
-	if err == sql.ErrNoRows {
-		return nil, errors.NewNotFoundError("school", id)
-	}
-	if err != nil {
-		return nil, errors.NewDatabaseError("get school by id", err)
-	}
+	return getOne[models.School](ctx, r.reader, "school", id, "get school by id", query, id)
+}
+
+// GetBySchoolNumber returns the school with the given school number (BSN)
+func (r *SchoolRepository) GetBySchoolNumber(ctx context.Context, schoolNumber string) (*models.School, error) {
+	query := `SELECT * FROM schools WHERE school_number = ` + currentSchoolNumber
 
-	return &school, nil
+	return getOne[models.School](ctx, r.reader, "school", schoolNumber, "get school by school number", query, schoolNumber, schoolNumber)
 }
 
 func (r *SchoolRepository) GetByType(ctx context.Context, schoolType string) ([]models.School, error) {
-	var schools []models.School
-	query := `SELECT * FROM schools WHERE school_type = ? ORDER BY name`
+	schools, err := cache.GetOrLoad(r.cache, "type:"+schoolType, func() ([]models.School, error) {
+		query := `SELECT * FROM schools WHERE school_type = ? ORDER BY name`
 
-	err := r.db.SelectContext(ctx, &schools, query, schoolType)
+		return getList[models.School](ctx, r.reader, "get schools by type", query, schoolType)
+	})
 	if err != nil {
-		return nil, errors.NewDatabaseError("get schools by type", err)
+		return nil, err
 	}
 
-	return schools, nil
+	return append([]models.School(nil), schools...), nil
 }
 
-func (r *SchoolRepository) Create(ctx context.Context, input models.CreateSchoolInput) (*models.School, error) {
+// GetByTypeWithinBox returns schools of a type whose coordinates fall inside the given box, excluding one school
+func (r *SchoolRepository) GetByTypeWithinBox(ctx context.Context, schoolType string, excludeID int64, minLat, maxLat, minLon, maxLon float64) ([]models.School, error) {
 	query := `
-		INSERT INTO schools (
-			school_number, name, school_type, operator, school_category,
-			district, neighborhood, postal_code, street, house_number,
-			phone, fax, email, website, school_year,
-			latitude, longitude, created_at, updated_at
-		)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		SELECT * FROM schools
+		WHERE school_type = ? AND latitude BETWEEN ? AND ? AND longitude BETWEEN ? AND ? AND id != ?
 	`
 
-	now := time.Now()
-	result, err := r.db.ExecContext(ctx, query,
-		input.SchoolNumber, input.Name, input.SchoolType, input.Operator, input.SchoolCategory,
-		input.District, input.Neighborhood, input.PostalCode, input.Street, input.HouseNumber,
-		input.Phone, input.Fax, input.Email, input.Website, input.SchoolYear,
-		input.Latitude, input.Longitude, now, now)
+	return getList[models.School](ctx, r.reader, "get schools by type within box", query, schoolType, minLat, maxLat, minLon, maxLon, excludeID)
+}
+
+// nearbyRow is a school with its distance from the searched point
+type nearbyRow struct {
+	models.School
+	DistanceKm float64 `db:"distance_km"`
+}
+
+// FindNearby returns up to limit schools within radiusKm of a point, nearest first. The
+// bounding box of the circle narrows the candidates by index before the haversine distance
+// (distance_km, see database.registerFunctions) is computed for each.
+func (r *SchoolRepository) FindNearby(ctx context.Context, lat, lon, radiusKm, minLat, maxLat, minLon, maxLon float64, limit int) ([]models.NearbySchool, error) {
+	query := `
+		SELECT * FROM (
+			SELECT *, distance_km(?, ?, latitude, longitude) AS distance_km
+			FROM schools
+			WHERE latitude BETWEEN ? AND ? AND longitude BETWEEN ? AND ?
+		)
+		WHERE distance_km <= ?
+		ORDER BY distance_km, id
+		LIMIT ?`
+
+	rows, err := getList[nearbyRow](ctx, r.reader, "find nearby schools", query, lat, lon, minLat, maxLat, minLon, maxLon, radiusKm, limit)
 	if err != nil {
-		return nil, errors.NewDatabaseError("create school", err)
+		return nil, err
 	}
 
-	id, err := result.LastInsertId()
+	nearby := make([]models.NearbySchool, len(rows))
+	for i, row := range rows {
+		nearby[i] = models.NearbySchool{School: row.School, DistanceKm: row.DistanceKm}
+	}
+	return nearby, nil
+}
+
+// Create stores a school, updating the existing row if the school number is already known
+func (r *SchoolRepository) Create(ctx context.Context, input models.CreateSchoolInput) (*models.School, error) {
+	now := time.Now()
+	var id int64
+	query := insertSchool + ` VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)` + onSchoolConflict + ` RETURNING id`
+	err := r.writer.GetContext(ctx, &id, query, schoolRow(input, now)...)
 	if err != nil {
-		return nil, errors.NewDatabaseError("get last insert id", err)
+		return nil, errors.NewDatabaseError("create school", err)
 	}
+	r.cache.Invalidate()
 
 	return r.GetByID(ctx, id)
 }
 
-func (r *SchoolRepository) Update(ctx context.Context, id int64, input models.UpdateSchoolInput) (*models.School, error) {
-	// Build dynamic update query
-	query := `UPDATE schools SET updated_at = ?`
-	args := []interface{}{time.Now()}
-
-	if input.SchoolNumber != nil {
-		query += `, school_number = ?`
-		args = append(args, *input.SchoolNumber)
-	}
-	if input.Name != nil {
-		query += `, name = ?`
-		args = append(args, *input.Name)
-	}
-	if input.SchoolType != nil {
-		query += `, school_type = ?`
-		args = append(args, *input.SchoolType)
+// Update changes the non-nil fields of a school. With expectedVersion > 0 the update only
+// applies if the school is still at that version; otherwise a version conflict is returned.
+func (r *SchoolRepository) Update(ctx context.Context, id int64, input models.UpdateSchoolInput, expectedVersion int) (*models.School, error) {
+	set, args := updateSet(input)
+	query := `UPDATE schools SET ` + set + ` WHERE id = ?`
+	args = append(args, id)
+	if expectedVersion > 0 {
+		query += ` AND version = ?`
+		args = append(args, expectedVersion)
 	}
-	if input.Operator != nil {
-		query += `, operator = ?`
-		args = append(args, *input.Operator)
+
+	result, err := r.writer.ExecContext(ctx, query, args...)
+	if err != nil {
+		if input.SchoolNumber != nil && database.IsUniqueViolation(err) {
+			return nil, errors.NewConflictError("school", "school_number", *input.SchoolNumber)
+		}
+		return nil, errors.NewDatabaseError("update school", err)
 	}
-	if input.SchoolCategory != nil {
-		query += `, school_category = ?`
-		args = append(args, *input.SchoolCategory)
+	r.cache.Invalidate()
+
+	if n, _ := result.RowsAffected(); n == 0 {
+		current, err := r.GetByID(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		return nil, errors.NewVersionConflictError("school", id, expectedVersion, current.Version)
 	}
-	if input.District != nil {
-		query += `, district = ?`
-		args = append(args, *input.District)
+
+	return r.GetByID(ctx, id)
+}
+
+// BatchUpdate applies updates to schools identified by school number in one transaction.
+// If any school is unknown, or not at the version given with its update, nothing is changed
+// and a not found or version conflict error is returned.
+func (r *SchoolRepository) BatchUpdate(ctx context.Context, updates []models.SchoolBatchUpdate) error {
+	tx, err := r.writer.BeginTxx(ctx, nil)
+	if err != nil {
+		return errors.NewDatabaseError("begin transaction", err)
 	}
-	if input.Neighborhood != nil {
-		query += `, neighborhood = ?`
-		args = append(args, *input.Neighborhood)
+	defer tx.Rollback()
+
+	for _, update := range updates {
+		set, args := updateSet(update.UpdateSchoolInput)
+		query := `UPDATE schools SET ` + set + ` WHERE school_number = ?`
+		args = append(args, update.SchoolNumber)
+		if update.Version != nil {
+			query += ` AND version = ?`
+			args = append(args, *update.Version)
+		}
+
+		result, err := tx.ExecContext(ctx, query, args...)
+		if err != nil {
+			return errors.NewDatabaseError("update school "+update.SchoolNumber, err)
+		}
+		if n, _ := result.RowsAffected(); n > 0 {
+			continue
+		}
+
+		var current int
+		err = tx.GetContext(ctx, &current, `SELECT version FROM schools WHERE school_number = ?`, update.SchoolNumber)
+		if err == sql.ErrNoRows {
+			return errors.NewNotFoundError("school", update.SchoolNumber)
+		}
+		if err != nil {
+			return errors.NewDatabaseError("get school version", err)
+		}
+		return errors.NewVersionConflictError("school", update.SchoolNumber, *update.Version, current)
 	}
-	if input.PostalCode != nil {
-		query += `, postal_code = ?`
-		args = append(args, *input.PostalCode)
+
+	if err := tx.Commit(); err != nil {
+		return errors.NewDatabaseError("commit school batch update", err)
 	}
-	if input.Street != nil {
-		query += `, street = ?`
-		args = append(args, *input.Street)
+	r.cache.Invalidate()
+
+	return nil
+}
+
+func (r *SchoolRepository) Delete(ctx context.Context, id int64) error {
+	query := `DELETE FROM schools WHERE id = ?`
+
+	if _, err := execQuery(ctx, r.writer, "delete school", query, id); err != nil {
+		return err
 	}
-	if input.HouseNumber != nil {
-		query += `, house_number = ?`
-		args = append(args, *input.HouseNumber)
+	r.cache.Invalidate()
+
+	return nil
+}
+
+// Sync makes the schools table match the given feed in one transaction: schools are
+// inserted or updated by school number, keeping their IDs, and schools missing from the
+// feed are deleted together with their scraped data (via ON DELETE CASCADE). A school the
+// feed lists under a new number (see school_aliases) is renumbered and keeps its data, and
+// accepted corrections (school_overrides) replace the values of the feed. It returns the
+// number of stored and deleted schools.
+func (r *SchoolRepository) Sync(ctx context.Context, inputs []models.CreateSchoolInput) (int, int64, error) {
+	tx, err := r.writer.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, 0, errors.NewDatabaseError("begin transaction", err)
 	}
-	if input.Phone != nil {
-		query += `, phone = ?`
-		args = append(args, *input.Phone)
+	defer tx.Rollback()
+
+	numbers := make([]string, 0, len(inputs))
+	for _, input := range inputs {
+		numbers = append(numbers, input.SchoolNumber)
 	}
-	if input.Fax != nil {
-		query += `, fax = ?`
-		args = append(args, *input.Fax)
+	numbersJSON, err := json.Marshal(numbers)
+	if err != nil {
+		return 0, 0, errors.NewDatabaseError("encode school numbers", err)
 	}
-	if input.Email != nil {
-		query += `, email = ?`
-		args = append(args, *input.Email)
+
+	// Move renumbered schools to their new number first, the delete below would drop their data
+	if _, err := applyAliases(ctx, tx, string(numbersJSON)); err != nil {
+		return 0, 0, err
 	}
-	if input.Website != nil {
-		query += `, website = ?`
-		args = append(args, *input.Website)
+
+	// Accepted corrections win over the feed, which would otherwise bring the old value back
+	overrides, err := overridesByNumber(ctx, tx)
+	if err != nil {
+		return 0, 0, err
 	}
-	if input.SchoolYear != nil {
-		query += `, school_year = ?`
-		args = append(args, *input.SchoolYear)
+	now := time.Now()
+	rows := make([][]interface{}, 0, len(inputs))
+	for _, input := range inputs {
+		for _, override := range overrides[input.SchoolNumber] {
+			input.ApplyOverride(override.Field, override.Value)
+		}
+		rows = append(rows, schoolRow(input, now))
 	}
-	if input.Latitude != nil {
-		query += `, latitude = ?`
-		args = append(args, *input.Latitude)
+	if _, err := insertBatches(ctx, tx, "store schools", insertSchool, onSchoolConflict, rows); err != nil {
+		return 0, 0, err
 	}
-	if input.Longitude != nil {
-		query += `, longitude = ?`
-		args = append(args, *input.Longitude)
+	// A number back in the feed belongs to a school again and must not redirect lookups
+	if _, err := execQuery(ctx, tx, "delete current school aliases", `
+		DELETE FROM school_aliases
+		WHERE old_number IN (SELECT value FROM json_each(?))
+	`, string(numbersJSON)); err != nil {
+		return 0, 0, err
 	}
 
-	query += ` WHERE id = ?`
-	args = append(args, id)
-
-	_, err := r.db.ExecContext(ctx, query, args...)
+	deleted, err := execQuery(ctx, tx, "delete removed schools", `
+		DELETE FROM schools
+		WHERE school_number NOT IN (SELECT value FROM json_each(?))
+	`, string(numbersJSON))
 	if err != nil {
-		return nil, errors.NewDatabaseError("update school", err)
+		return 0, 0, err
 	}
 
-	return r.GetByID(ctx, id)
-}
-
-func (r *SchoolRepository) Delete(ctx context.Context, id int64) error {
-	query := `DELETE FROM schools WHERE id = ?`
-
-	_, err := r.db.ExecContext(ctx, query, id)
-	if err != nil {
-		return errors.NewDatabaseError("delete school", err)
+	if err := tx.Commit(); err != nil {
+		return 0, 0, errors.NewDatabaseError("commit school sync", err)
 	}
+	r.cache.Invalidate()
 
-	return nil
+	return len(inputs), deleted, nil
 }
 
 func (r *SchoolRepository) DeleteAll(ctx context.Context) error {
 	query := `DELETE FROM schools`
 
-	_, err := r.db.ExecContext(ctx, query)
-	if err != nil {
-		return errors.NewDatabaseError("delete all schools", err)
+	if _, err := execQuery(ctx, r.writer, "delete all schools", query); err != nil {
+		return err
 	}
+	r.cache.Invalidate()
 
 	return nil
 }
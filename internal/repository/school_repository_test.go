@@ -0,0 +1,413 @@
+package repository
+
+import (
+	"context"
+	stderrors "errors"
+	"io"
+	"log/slog"
+	"math"
+	"math/rand"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"testing/quick"
+	"time"
+
+	"schools-be/internal/database"
+	"schools-be/internal/errors"
+	"schools-be/internal/models"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// roundTripCount is the number of random schools per property; raise it with -quickchecks
+// (testing/quick's flag) when changing the UPDATE builder
+const roundTripCount = 200
+
+var testLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+func newTestDB(t *testing.T) *database.DB {
+	t.Helper()
+	db, err := database.New(filepath.Join(t.TempDir(), "test.db"), 2, time.Second, testLogger)
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := database.RunMigrations(db.Writer, testLogger); err != nil {
+		t.Fatalf("run migrations: %v", err)
+	}
+	return db
+}
+
+// Fragments random strings are built from: umlauts and ß, other scripts, combining marks,
+// emoji, quotes and SQL-looking text, so mapping and escaping bugs surface
+var textFragments = []string{
+	"a", "Z", "0", " ", "-", "ä", "Ö", "ß", "é", "ł", "ç", "İ", "Ж", "λ", "中", "学", "ש", "ع",
+	"é", "​", "🏫", "👩🏽‍🏫", "'", `"`, "\\", "%", "_", ";", "--", "' OR 1=1 --", "\t", "\n",
+}
+
+// Coordinates at and next to the valid bounds, signed zeros and subnormals
+var edgeCoordinates = []float64{
+	0, math.Copysign(0, -1), 5e-324, -5e-324, 1e-9, 52.520008, 13.404954,
+	90, -90, math.Nextafter(90, 0), math.Nextafter(-90, 0),
+	180, -180, math.Nextafter(180, 0), math.Nextafter(-180, 0),
+}
+
+func randomText(r *rand.Rand, maxRunes int) string {
+	var b strings.Builder
+	runes := 0
+	for n := r.Intn(8); n > 0; n-- {
+		fragment := textFragments[r.Intn(len(textFragments))]
+		if runes+len([]rune(fragment)) > maxRunes {
+			break
+		}
+		runes += len([]rune(fragment))
+		b.WriteString(fragment)
+	}
+	return b.String()
+}
+
+// randomRequiredText returns a non-empty string of at most maxRunes runes
+func randomRequiredText(r *rand.Rand, maxRunes int) string {
+	if s := randomText(r, maxRunes-1); s != "" {
+		return s
+	}
+	return "x"
+}
+
+func randomCoordinate(r *rand.Rand, limit float64) *float64 {
+	var v float64
+	switch r.Intn(3) {
+	case 0:
+		return nil
+	case 1:
+		v = edgeCoordinates[r.Intn(len(edgeCoordinates))]
+		if math.Abs(v) > limit {
+			v = math.Copysign(limit, v)
+		}
+	default:
+		v = (r.Float64()*2 - 1) * limit
+	}
+	return &v
+}
+
+func randomEmail(r *rand.Rand) string {
+	if r.Intn(3) == 0 {
+		return ""
+	}
+	return "sekretariat" + strconv.Itoa(r.Intn(1000)) + "@schule-" + strconv.Itoa(r.Intn(1000)) + ".berlin.de"
+}
+
+func randomWebsite(r *rand.Rand) string {
+	if r.Intn(3) == 0 {
+		return ""
+	}
+	return "https://www.schule-" + strconv.Itoa(r.Intn(1000)) + ".de/?q=ä&p=" + strconv.Itoa(r.Intn(10))
+}
+
+// randomCreateSchoolInput returns a valid input; schoolNumber keeps school numbers unique
+func randomCreateSchoolInput(r *rand.Rand, schoolNumber string) models.CreateSchoolInput {
+	return models.CreateSchoolInput{
+		SchoolNumber:   schoolNumber,
+		Name:           randomRequiredText(r, 300),
+		SchoolType:     randomRequiredText(r, 100),
+		Operator:       randomText(r, 100),
+		SchoolCategory: randomText(r, 100),
+		District:       randomText(r, 100),
+		Neighborhood:   randomText(r, 100),
+		PostalCode:     randomText(r, 10),
+		Street:         randomText(r, 200),
+		HouseNumber:    randomText(r, 20),
+		Phone:          randomText(r, 50),
+		Fax:            randomText(r, 50),
+		Email:          randomEmail(r),
+		Website:        randomWebsite(r),
+		SchoolYear:     randomText(r, 20),
+		Latitude:       randomCoordinate(r, 90),
+		Longitude:      randomCoordinate(r, 180),
+	}
+}
+
+// randomUpdateSchoolInput sets a random subset of fields; the school number changes rarely
+// and to schoolNumber, which must be unused
+func randomUpdateSchoolInput(r *rand.Rand, schoolNumber string) models.UpdateSchoolInput {
+	var input models.UpdateSchoolInput
+	maybe := func(value string) *string {
+		if r.Intn(2) == 0 {
+			return nil
+		}
+		return &value
+	}
+	if r.Intn(5) == 0 {
+		input.SchoolNumber = &schoolNumber
+	}
+	input.Name = maybe(randomRequiredText(r, 300))
+	input.SchoolType = maybe(randomRequiredText(r, 100))
+	input.Operator = maybe(randomText(r, 100))
+	input.SchoolCategory = maybe(randomText(r, 100))
+	input.District = maybe(randomText(r, 100))
+	input.Neighborhood = maybe(randomText(r, 100))
+	input.PostalCode = maybe(randomText(r, 10))
+	input.Street = maybe(randomText(r, 200))
+	input.HouseNumber = maybe(randomText(r, 20))
+	input.Phone = maybe(randomText(r, 50))
+	input.Fax = maybe(randomText(r, 50))
+	if email := randomEmail(r); email != "" {
+		input.Email = maybe(email)
+	}
+	if website := randomWebsite(r); website != "" {
+		input.Website = maybe(website)
+	}
+	input.SchoolYear = maybe(randomText(r, 20))
+	input.Latitude = randomCoordinate(r, 90)
+	input.Longitude = randomCoordinate(r, 180)
+	return input
+}
+
+// applyUpdate returns the school as expected after the update
+func applyUpdate(school models.School, input models.UpdateSchoolInput) models.School {
+	set := func(field *string, value *string) {
+		if value != nil {
+			*field = *value
+		}
+	}
+	set(&school.SchoolNumber, input.SchoolNumber)
+	set(&school.Name, input.Name)
+	set(&school.SchoolType, input.SchoolType)
+	set(&school.Operator, input.Operator)
+	set(&school.SchoolCategory, input.SchoolCategory)
+	set(&school.District, input.District)
+	set(&school.Neighborhood, input.Neighborhood)
+	set(&school.PostalCode, input.PostalCode)
+	set(&school.Street, input.Street)
+	set(&school.HouseNumber, input.HouseNumber)
+	set(&school.Phone, input.Phone)
+	set(&school.Fax, input.Fax)
+	set(&school.Email, input.Email)
+	set(&school.Website, input.Website)
+	set(&school.SchoolYear, input.SchoolYear)
+	if input.Latitude != nil {
+		school.Latitude = input.Latitude
+	}
+	if input.Longitude != nil {
+		school.Longitude = input.Longitude
+	}
+	school.Version++
+	return school
+}
+
+// schoolFieldDiffs lists the stored fields of got that differ from want
+func schoolFieldDiffs(got, want models.School) []string {
+	var diffs []string
+	check := func(field, got, want string) {
+		if got != want {
+			diffs = append(diffs, field+": got "+strconv.Quote(got)+", want "+strconv.Quote(want))
+		}
+	}
+	checkCoordinate := func(field string, got, want *float64) {
+		switch {
+		case got == nil && want == nil:
+		case got == nil || want == nil:
+			diffs = append(diffs, field+": got "+formatCoordinate(got)+", want "+formatCoordinate(want))
+		case *got != *want:
+			diffs = append(diffs, field+": got "+formatCoordinate(got)+", want "+formatCoordinate(want))
+		}
+	}
+	check("school_number", got.SchoolNumber, want.SchoolNumber)
+	check("name", got.Name, want.Name)
+	check("school_type", got.SchoolType, want.SchoolType)
+	check("operator", got.Operator, want.Operator)
+	check("school_category", got.SchoolCategory, want.SchoolCategory)
+	check("district", got.District, want.District)
+	check("neighborhood", got.Neighborhood, want.Neighborhood)
+	check("postal_code", got.PostalCode, want.PostalCode)
+	check("street", got.Street, want.Street)
+	check("house_number", got.HouseNumber, want.HouseNumber)
+	check("phone", got.Phone, want.Phone)
+	check("fax", got.Fax, want.Fax)
+	check("email", got.Email, want.Email)
+	check("website", got.Website, want.Website)
+	check("school_year", got.SchoolYear, want.SchoolYear)
+	checkCoordinate("latitude", got.Latitude, want.Latitude)
+	checkCoordinate("longitude", got.Longitude, want.Longitude)
+	if got.Version != want.Version {
+		diffs = append(diffs, "version: got "+strconv.Itoa(got.Version)+", want "+strconv.Itoa(want.Version))
+	}
+	return diffs
+}
+
+func formatCoordinate(v *float64) string {
+	if v == nil {
+		return "null"
+	}
+	return strconv.FormatFloat(*v, 'g', -1, 64)
+}
+
+func schoolFromInput(input models.CreateSchoolInput) models.School {
+	return models.School{
+		SchoolNumber: input.SchoolNumber, Name: input.Name, SchoolType: input.SchoolType,
+		Operator: input.Operator, SchoolCategory: input.SchoolCategory, District: input.District,
+		Neighborhood: input.Neighborhood, PostalCode: input.PostalCode, Street: input.Street,
+		HouseNumber: input.HouseNumber, Phone: input.Phone, Fax: input.Fax, Email: input.Email,
+		Website: input.Website, SchoolYear: input.SchoolYear, Latitude: input.Latitude,
+		Longitude: input.Longitude, Version: 1,
+	}
+}
+
+// TestSchoolRoundTrip checks that any valid school survives Create → GetByID → Update →
+// GetByID with every field intact: created fields as given, updated fields changed and all
+// other fields untouched
+func TestSchoolRoundTrip(t *testing.T) {
+	repo := NewSchoolRepository(newTestDB(t))
+	validate := validator.New()
+	ctx := context.Background()
+	n := 0
+
+	property := func(seed int64) bool {
+		r := rand.New(rand.NewSource(seed))
+		n++
+		input := randomCreateSchoolInput(r, "RT"+strconv.Itoa(n))
+		if err := validate.Struct(input); err != nil {
+			t.Fatalf("seed %d: generated invalid input: %v", seed, err)
+		}
+
+		created, err := repo.Create(ctx, input)
+		if err != nil {
+			t.Errorf("seed %d: create: %v", seed, err)
+			return false
+		}
+		stored, err := repo.GetByID(ctx, created.ID)
+		if err != nil {
+			t.Errorf("seed %d: get: %v", seed, err)
+			return false
+		}
+		if diffs := schoolFieldDiffs(*stored, schoolFromInput(input)); len(diffs) > 0 {
+			t.Errorf("seed %d: after create: %s", seed, strings.Join(diffs, "; "))
+			return false
+		}
+
+		update := randomUpdateSchoolInput(r, "RT"+strconv.Itoa(n)+"-renamed")
+		if err := validate.Struct(update); err != nil {
+			t.Fatalf("seed %d: generated invalid update: %v", seed, err)
+		}
+		if _, err := repo.Update(ctx, created.ID, update, stored.Version); err != nil {
+			t.Errorf("seed %d: update: %v", seed, err)
+			return false
+		}
+		updated, err := repo.GetByID(ctx, created.ID)
+		if err != nil {
+			t.Errorf("seed %d: get after update: %v", seed, err)
+			return false
+		}
+		if diffs := schoolFieldDiffs(*updated, applyUpdate(*stored, update)); len(diffs) > 0 {
+			t.Errorf("seed %d: after update: %s", seed, strings.Join(diffs, "; "))
+			return false
+		}
+
+		// The version the update was based on is stale now
+		var conflict *errors.VersionConflictError
+		if _, err := repo.Update(ctx, created.ID, update, stored.Version); !stderrors.As(err, &conflict) {
+			t.Errorf("seed %d: update with stale version: got %v, want a version conflict", seed, err)
+			return false
+		}
+		return true
+	}
+
+	if err := quick.Check(property, &quick.Config{MaxCount: roundTripCount}); err != nil {
+		t.Error(err)
+	}
+}
+
+// TestSchoolEmptyUpdate checks that an update without fields only bumps the version
+func TestSchoolEmptyUpdate(t *testing.T) {
+	repo := NewSchoolRepository(newTestDB(t))
+	ctx := context.Background()
+
+	input := randomCreateSchoolInput(rand.New(rand.NewSource(1)), "EMPTY")
+	created, err := repo.Create(ctx, input)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	updated, err := repo.Update(ctx, created.ID, models.UpdateSchoolInput{}, created.Version)
+	if err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	want := schoolFromInput(input)
+	want.Version = 2
+	if diffs := schoolFieldDiffs(*updated, want); len(diffs) > 0 {
+		t.Error(strings.Join(diffs, "; "))
+	}
+}
+
+// TestSchoolFind checks that filter fields combine, ignore ASCII case and are bound as values
+func TestSchoolFind(t *testing.T) {
+	repo := NewSchoolRepository(newTestDB(t))
+	ctx := context.Background()
+
+	inputs := []models.CreateSchoolInput{
+		{SchoolNumber: "01F01", Name: "A", SchoolType: "Gymnasium", District: "Mitte", PostalCode: "10115"},
+		{SchoolNumber: "01F02", Name: "B", SchoolType: "Grundschule", District: "Mitte", PostalCode: "10115"},
+		{SchoolNumber: "08F01", Name: "C", SchoolType: "Gymnasium", District: "Neukölln", PostalCode: "12043"},
+	}
+	if _, _, err := repo.Sync(ctx, inputs); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+
+	for _, tc := range []struct {
+		filter models.SchoolFilter
+		want   int
+	}{
+		{models.SchoolFilter{}, 3},
+		{models.SchoolFilter{District: "mitte"}, 2},
+		{models.SchoolFilter{District: "MITTE", SchoolType: "gymnasium"}, 1},
+		{models.SchoolFilter{District: "Neukölln", PostalCode: "12043"}, 1},
+		{models.SchoolFilter{District: "Mitte' OR '1'='1"}, 0},
+	} {
+		schools, err := repo.Find(ctx, tc.filter)
+		if err != nil {
+			t.Fatalf("find %+v: %v", tc.filter, err)
+		}
+		if len(schools) != tc.want {
+			t.Errorf("find %+v = %d schools, want %d", tc.filter, len(schools), tc.want)
+		}
+		for _, school := range schools {
+			if !tc.filter.Matches(school) {
+				t.Errorf("find %+v returned %s, which Matches rejects", tc.filter, school.SchoolNumber)
+			}
+		}
+	}
+}
+
+// TestSchoolFindNearby checks the radius cut, the order by distance and that schools without
+// coordinates are left out
+func TestSchoolFindNearby(t *testing.T) {
+	repo := NewSchoolRepository(newTestDB(t))
+	ctx := context.Background()
+
+	coord := func(v float64) *float64 { return &v }
+	inputs := []models.CreateSchoolInput{
+		{SchoolNumber: "01N01", Name: "Far", Latitude: coord(52.530), Longitude: coord(13.400)},
+		{SchoolNumber: "01N02", Name: "Near", Latitude: coord(52.521), Longitude: coord(13.400)},
+		{SchoolNumber: "01N03", Name: "Outside", Latitude: coord(52.600), Longitude: coord(13.400)},
+		{SchoolNumber: "01N04", Name: "Unknown"},
+	}
+	if _, _, err := repo.Sync(ctx, inputs); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+
+	nearby, err := repo.FindNearby(ctx, 52.52, 13.40, 2, 52.40, 52.65, 13.30, 13.50, 10)
+	if err != nil {
+		t.Fatalf("find nearby: %v", err)
+	}
+	var got []string
+	for _, school := range nearby {
+		got = append(got, school.School.SchoolNumber)
+	}
+	if strings.Join(got, ",") != "01N02,01N01" {
+		t.Fatalf("nearby = %v, want 01N02,01N01", got)
+	}
+	if d := nearby[0].DistanceKm; d < 0.1 || d > 0.12 {
+		t.Errorf("distance of 01N02 = %f km, want about 0.11", d)
+	}
+}
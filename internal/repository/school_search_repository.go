@@ -0,0 +1,132 @@
+package repository
+
+import (
+	"context"
+	"strings"
+	"time"
+	"unicode"
+
+	"schools-be/internal/database"
+	"schools-be/internal/errors"
+	"schools-be/internal/models"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// maxSearchTerms bounds the terms of one search query
+const maxSearchTerms = 10
+
+// searchRank weighs the columns of school_search for bm25: a term in the name counts most,
+// one only in the AI summary least
+const searchRank = `bm25(school_search, 10.0, 3.0, 3.0, 1.0)`
+
+// SchoolSearchRepository maintains the full-text index of school names, offerings, working
+// groups and AI summaries (school_search, FTS5) and searches it
+type SchoolSearchRepository struct {
+	writer  *sqlx.DB
+	reader  *sqlx.DB
+	enabled bool
+}
+
+func NewSchoolSearchRepository(db *database.DB) *SchoolSearchRepository {
+	return &SchoolSearchRepository{writer: db.Writer, reader: db.Reader, enabled: database.FullTextSearch(db.Writer)}
+}
+
+// Enabled reports whether SQLite was built with FTS5; without it there is no index
+func (r *SchoolSearchRepository) Enabled() bool {
+	return r.enabled
+}
+
+// SaveSummary stores the AI summary of a school for the next rebuild of the index
+func (r *SchoolSearchRepository) SaveSummary(ctx context.Context, schoolNumber, summary string) error {
+	_, err := execQuery(ctx, r.writer, "save school summary", `
+		INSERT INTO school_summaries (school_number, summary, generated_at) VALUES (?, ?, ?)
+		ON CONFLICT(school_number) DO UPDATE SET summary = excluded.summary, generated_at = excluded.generated_at
+	`, schoolNumber, summary, time.Now())
+	return err
+}
+
+// Rebuild replaces the index with the current schools, details and summaries in one
+// transaction and returns the number of indexed schools. Without FTS5 it does nothing.
+func (r *SchoolSearchRepository) Rebuild(ctx context.Context) (int64, error) {
+	if !r.enabled {
+		return 0, nil
+	}
+
+	tx, err := r.writer.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, errors.NewDatabaseError("begin transaction", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := execQuery(ctx, tx, "clear search index", `DELETE FROM school_search`); err != nil {
+		return 0, err
+	}
+	indexed, err := execQuery(ctx, tx, "build search index", `
+		INSERT INTO school_search (rowid, name, offerings, working_groups, summary)
+		SELECT s.id, s.name, COALESCE(d.offerings, ''), COALESCE(d.working_groups, ''), COALESCE(m.summary, '')
+		FROM schools s
+		LEFT JOIN school_details d ON d.school_number = s.school_number
+		LEFT JOIN school_summaries m ON m.school_number = s.school_number
+	`)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, errors.NewDatabaseError("commit search index", err)
+	}
+	return indexed, nil
+}
+
+// searchRow is a school with the rank and snippet of its match
+type searchRow struct {
+	models.School
+	Rank    float64 `db:"search_rank"`
+	Snippet string  `db:"search_snippet"`
+}
+
+// Search returns the schools matching all words of text, best match first. Words match as
+// prefixes ("montess" finds Montessori) regardless of case and diacritics.
+func (r *SchoolSearchRepository) Search(ctx context.Context, text string, limit int) ([]models.SchoolSearchResult, error) {
+	match := matchExpression(text)
+	if match == "" {
+		return nil, errors.NewValidationError("q", "must contain a letter or digit")
+	}
+
+	query := `
+		SELECT s.*, ` + searchRank + ` AS search_rank,
+		       snippet(school_search, -1, '**', '**', '…', 12) AS search_snippet
+		FROM school_search
+		JOIN schools s ON s.id = school_search.rowid
+		WHERE school_search MATCH ?
+		ORDER BY search_rank
+		LIMIT ?`
+	rows, err := getList[searchRow](ctx, r.reader, "search schools", query, match, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]models.SchoolSearchResult, len(rows))
+	for i, row := range rows {
+		results[i] = models.SchoolSearchResult{School: row.School, Score: -row.Rank, Snippet: row.Snippet}
+	}
+	return results, nil
+}
+
+// matchExpression turns free text into an FTS5 query matching all of its words as prefixes.
+// Each word is quoted, so operators and column filters in the input are searched as text.
+func matchExpression(text string) string {
+	words := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	if len(words) > maxSearchTerms {
+		words = words[:maxSearchTerms]
+	}
+
+	terms := make([]string, len(words))
+	for i, word := range words {
+		terms[i] = `"` + word + `"*`
+	}
+	return strings.Join(terms, " ")
+}
@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"schools-be/internal/models"
+)
+
+func TestMatchExpression(t *testing.T) {
+	for _, tc := range []struct{ text, want string }{
+		{"montessori", `"montessori"*`},
+		{"  Schüler-Zeitung ", `"Schüler"* "Zeitung"*`},
+		{`name:foo OR "bar`, `"name"* "foo"* "OR"* "bar"*`},
+		{"-- ' *", ""},
+		{strings.Repeat("a ", maxSearchTerms+5), strings.TrimSpace(strings.Repeat(`"a"* `, maxSearchTerms))},
+	} {
+		if got := matchExpression(tc.text); got != tc.want {
+			t.Errorf("matchExpression(%q) = %q, want %q", tc.text, got, tc.want)
+		}
+	}
+}
+
+// TestSchoolSearch checks ranking across the indexed columns; it needs SQLite with FTS5
+// (go test -tags sqlite_fts5)
+func TestSchoolSearch(t *testing.T) {
+	db := newTestDB(t)
+	search := NewSchoolSearchRepository(db)
+	if !search.Enabled() {
+		t.Skip("SQLite built without FTS5")
+	}
+	ctx := context.Background()
+
+	_, _, err := NewSchoolRepository(db).Sync(ctx, []models.CreateSchoolInput{
+		{SchoolNumber: "01A01", Name: "Montessori-Schule Mitte"},
+		{SchoolNumber: "01A02", Name: "Grundschule am Park"},
+		{SchoolNumber: "01A03", Name: "Gymnasium Nord"},
+	})
+	if err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	if _, err := db.Writer.Exec(`INSERT INTO school_details (school_number, school_name, working_groups) VALUES ('01A02', 'Grundschule am Park', 'Schülerzeitung, Montessori-Werkstatt')`); err != nil {
+		t.Fatalf("insert details: %v", err)
+	}
+	if err := search.SaveSummary(ctx, "01A03", "Ein Gymnasium mit Musikprofil."); err != nil {
+		t.Fatalf("save summary: %v", err)
+	}
+	if n, err := search.Rebuild(ctx); err != nil || n != 3 {
+		t.Fatalf("rebuild = %d, %v; want 3 schools", n, err)
+	}
+
+	results, err := search.Search(ctx, "montessori", 10)
+	if err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	var got []string
+	for _, r := range results {
+		got = append(got, r.School.SchoolNumber)
+	}
+	if strings.Join(got, ",") != "01A01,01A02" {
+		t.Errorf("montessori found %v, want the name match before the working group match", got)
+	}
+	if len(results) > 1 && !strings.Contains(results[1].Snippet, "**Montessori**") {
+		t.Errorf("snippet = %q, want the match in bold", results[1].Snippet)
+	}
+
+	for _, text := range []string{"schulerzeit", "musik"} {
+		results, err := search.Search(ctx, text, 10)
+		if err != nil {
+			t.Fatalf("search %q: %v", text, err)
+		}
+		if len(results) != 1 {
+			t.Errorf("search %q found %d schools, want 1", text, len(results))
+		}
+	}
+}
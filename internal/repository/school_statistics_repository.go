@@ -4,18 +4,19 @@ import (
 	"context"
 	"time"
 
-	"schools-be/internal/errors"
+	"schools-be/internal/database"
 	"schools-be/internal/models"
 
 	"github.com/jmoiron/sqlx"
 )
 
 type SchoolStatisticsRepository struct {
-	db *sqlx.DB
+	writer *sqlx.DB
+	reader *sqlx.DB
 }
 
-func NewSchoolStatisticsRepository(db *sqlx.DB) *SchoolStatisticsRepository {
-	return &SchoolStatisticsRepository{db: db}
+func NewSchoolStatisticsRepository(db *database.DB) *SchoolStatisticsRepository {
+	return &SchoolStatisticsRepository{writer: db.Writer, reader: db.Reader}
 }
 
 // SaveCitizenshipStats saves citizenship statistics (replaces existing data for the school)
@@ -26,42 +27,67 @@ func (r *SchoolStatisticsRepository) SaveCitizenshipStats(ctx context.Context, s
 
 	// Delete existing stats for this school
 	schoolNumber := stats[0].SchoolNumber
-	_, err := r.db.ExecContext(ctx, `DELETE FROM school_citizenship_stats WHERE school_number = ?`, schoolNumber)
+	_, err := execQuery(ctx, r.writer, "delete old citizenship stats", `DELETE FROM school_citizenship_stats WHERE school_number = ?`, schoolNumber)
 	if err != nil {
-		return errors.NewDatabaseError("delete old citizenship stats", err)
+		return err
 	}
 
 	// Insert new stats
-	query := `INSERT INTO school_citizenship_stats (school_number, citizenship, female_students, male_students, total, scraped_at, created_at) 
-	          VALUES (?, ?, ?, ?, ?, ?, ?)`
-
+	now := time.Now()
+	rows := make([][]interface{}, 0, len(stats))
 	for _, stat := range stats {
-		_, err := r.db.ExecContext(ctx, query,
-			stat.SchoolNumber, stat.Citizenship, stat.FemaleStudents, stat.MaleStudents, stat.Total,
-			stat.ScrapedAt, time.Now())
-		if err != nil {
-			return errors.NewDatabaseError("insert citizenship stat", err)
-		}
+		rows = append(rows, []interface{}{
+			stat.SchoolNumber, stat.Citizenship, stat.FemaleStudents, stat.MaleStudents, stat.DiverseStudents, stat.Total, stat.ScrapedAt, now,
+		})
 	}
+	_, err = insertBatches(ctx, r.writer, "insert citizenship stats",
+		`INSERT INTO school_citizenship_stats (school_number, citizenship, female_students, male_students, diverse_students, total, scraped_at, created_at)`, "", rows)
+	return err
+}
 
-	return nil
+// DeleteCitizenshipStats deletes the citizenship statistics of a school
+func (r *SchoolStatisticsRepository) DeleteCitizenshipStats(ctx context.Context, schoolNumber string) error {
+	_, err := execQuery(ctx, r.writer, "delete citizenship stats", `DELETE FROM school_citizenship_stats WHERE school_number = ?`, schoolNumber)
+	return err
+}
+
+// SaveDataQualityIssues replaces the data-quality issues recorded for a dataset of a school
+func (r *SchoolStatisticsRepository) SaveDataQualityIssues(ctx context.Context, schoolNumber, dataset string, issues []models.DataQualityIssue) error {
+	_, err := execQuery(ctx, r.writer, "delete old data quality issues",
+		`DELETE FROM data_quality_issues WHERE school_number = ? AND dataset = ?`, schoolNumber, dataset)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	rows := make([][]interface{}, 0, len(issues))
+	for _, issue := range issues {
+		rows = append(rows, []interface{}{
+			schoolNumber, dataset, issue.Detail, issue.Total, issue.Sum, issue.Excluded, now,
+		})
+	}
+	_, err = insertBatches(ctx, r.writer, "insert data quality issues",
+		`INSERT INTO data_quality_issues (school_number, dataset, detail, total, parts_sum, excluded, detected_at)`, "", rows)
+	return err
+}
+
+// GetDataQualityIssues retrieves the data-quality log, excluded tables first
+func (r *SchoolStatisticsRepository) GetDataQualityIssues(ctx context.Context) ([]models.DataQualityIssue, error) {
+	query := `SELECT * FROM data_quality_issues ORDER BY excluded DESC, school_number, dataset, id`
+
+	return getList[models.DataQualityIssue](ctx, r.reader, "get data quality issues", query)
 }
 
 // SaveLanguageStat saves language statistics (replaces existing data for the school)
 func (r *SchoolStatisticsRepository) SaveLanguageStat(ctx context.Context, stat models.SchoolLanguageStat) error {
 	query := `INSERT OR REPLACE INTO school_language_stats 
-	          (school_number, total_students, ndh_female_students, ndh_male_students, ndh_total, ndh_percentage, scraped_at, created_at) 
-	          VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
+	          (school_number, total_students, ndh_female_students, ndh_male_students, ndh_diverse_students, ndh_total, ndh_percentage, scraped_at, created_at) 
+	          VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
 
-	_, err := r.db.ExecContext(ctx, query,
-		stat.SchoolNumber, stat.TotalStudents, stat.NDHFemaleStudents, stat.NDHMaleStudents,
+	_, err := execQuery(ctx, r.writer, "save language stat", query,
+		stat.SchoolNumber, stat.TotalStudents, stat.NDHFemaleStudents, stat.NDHMaleStudents, stat.NDHDiverseStudents,
 		stat.NDHTotal, stat.NDHPercentage, stat.ScrapedAt, time.Now())
-
-	if err != nil {
-		return errors.NewDatabaseError("save language stat", err)
-	}
-
-	return nil
+	return err
 }
 
 // SaveResidenceStats saves residence statistics (replaces existing data for the school)
@@ -72,24 +98,20 @@ func (r *SchoolStatisticsRepository) SaveResidenceStats(ctx context.Context, sta
 
 	// Delete existing stats for this school
 	schoolNumber := stats[0].SchoolNumber
-	_, err := r.db.ExecContext(ctx, `DELETE FROM school_residence_stats WHERE school_number = ?`, schoolNumber)
+	_, err := execQuery(ctx, r.writer, "delete old residence stats", `DELETE FROM school_residence_stats WHERE school_number = ?`, schoolNumber)
 	if err != nil {
-		return errors.NewDatabaseError("delete old residence stats", err)
+		return err
 	}
 
 	// Insert new stats
-	query := `INSERT INTO school_residence_stats (school_number, district, student_count, scraped_at, created_at) 
-	          VALUES (?, ?, ?, ?, ?)`
-
+	now := time.Now()
+	rows := make([][]interface{}, 0, len(stats))
 	for _, stat := range stats {
-		_, err := r.db.ExecContext(ctx, query,
-			stat.SchoolNumber, stat.District, stat.StudentCount, stat.ScrapedAt, time.Now())
-		if err != nil {
-			return errors.NewDatabaseError("insert residence stat", err)
-		}
+		rows = append(rows, []interface{}{stat.SchoolNumber, stat.District, stat.StudentCount, stat.ScrapedAt, now})
 	}
-
-	return nil
+	_, err = insertBatches(ctx, r.writer, "insert residence stats",
+		`INSERT INTO school_residence_stats (school_number, district, student_count, scraped_at, created_at)`, "", rows)
+	return err
 }
 
 // SaveAbsenceStat saves absence statistics (replaces existing data for the school)
@@ -99,68 +121,155 @@ func (r *SchoolStatisticsRepository) SaveAbsenceStat(ctx context.Context, stat m
 	           region_absence_rate, region_unexcused_rate, berlin_absence_rate, berlin_unexcused_rate, scraped_at, created_at) 
 	          VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
 
-	_, err := r.db.ExecContext(ctx, query,
+	_, err := execQuery(ctx, r.writer, "save absence stat", query,
 		stat.SchoolNumber, stat.SchoolAbsenceRate, stat.SchoolUnexcusedRate,
 		stat.SchoolTypeAbsenceRate, stat.SchoolTypeUnexcusedRate,
 		stat.RegionAbsenceRate, stat.RegionUnexcusedRate,
 		stat.BerlinAbsenceRate, stat.BerlinUnexcusedRate,
 		stat.ScrapedAt, time.Now())
+	return err
+}
+
+// GetCitizenshipStats retrieves citizenship statistics for a school
+func (r *SchoolStatisticsRepository) GetCitizenshipStats(ctx context.Context, schoolNumber string) ([]models.SchoolCitizenshipStat, error) {
+	query := `SELECT * FROM school_citizenship_stats WHERE school_number = ` + currentSchoolNumber + ` ORDER BY citizenship`
+
+	return getList[models.SchoolCitizenshipStat](ctx, r.reader, "get citizenship stats", query, schoolNumber, schoolNumber)
+}
+
+// GetLanguageStat retrieves language statistics for a school
+func (r *SchoolStatisticsRepository) GetLanguageStat(ctx context.Context, schoolNumber string) (*models.SchoolLanguageStat, error) {
+	query := `SELECT * FROM school_language_stats WHERE school_number = ` + currentSchoolNumber
+
+	return getOne[models.SchoolLanguageStat](ctx, r.reader, "language stat", schoolNumber, "get language stat", query, schoolNumber, schoolNumber)
+}
+
+// GetResidenceStats retrieves residence statistics for a school
+func (r *SchoolStatisticsRepository) GetResidenceStats(ctx context.Context, schoolNumber string) ([]models.SchoolResidenceStat, error) {
+	query := `SELECT * FROM school_residence_stats WHERE school_number = ` + currentSchoolNumber + ` ORDER BY student_count DESC`
+
+	return getList[models.SchoolResidenceStat](ctx, r.reader, "get residence stats", query, schoolNumber, schoolNumber)
+}
 
+// SaveGradeStructure saves the classes per grade of a school (replaces existing data for the school)
+func (r *SchoolStatisticsRepository) SaveGradeStructure(ctx context.Context, schoolNumber string, stats []models.SchoolGradeClasses) error {
+	_, err := execQuery(ctx, r.writer, "delete old grade structure", `DELETE FROM school_grade_structure WHERE school_number = ?`, schoolNumber)
 	if err != nil {
-		return errors.NewDatabaseError("save absence stat", err)
+		return err
 	}
 
-	return nil
+	now := time.Now()
+	rows := make([][]interface{}, 0, len(stats))
+	for _, stat := range stats {
+		rows = append(rows, []interface{}{schoolNumber, stat.SchoolYear, stat.Grade, stat.Classes, stat.ScrapedAt, now})
+	}
+	_, err = insertBatches(ctx, r.writer, "insert grade structure",
+		`INSERT INTO school_grade_structure (school_number, school_year, grade, classes, scraped_at, created_at)`, "", rows)
+	return err
 }
 
-// GetCitizenshipStats retrieves citizenship statistics for a school
-func (r *SchoolStatisticsRepository) GetCitizenshipStats(ctx context.Context, schoolNumber string) ([]models.SchoolCitizenshipStat, error) {
-	var stats []models.SchoolCitizenshipStat
-	query := `SELECT * FROM school_citizenship_stats WHERE school_number = ? ORDER BY citizenship`
+// GetGradeStructure retrieves the classes per grade of a school, newest school year first
+func (r *SchoolStatisticsRepository) GetGradeStructure(ctx context.Context, schoolNumber string) ([]models.SchoolGradeClasses, error) {
+	query := `SELECT * FROM school_grade_structure WHERE school_number = ` + currentSchoolNumber + ` ORDER BY school_year DESC, grade`
+
+	return getList[models.SchoolGradeClasses](ctx, r.reader, "get grade structure", query, schoolNumber, schoolNumber)
+}
+
+// SaveStaffingStat saves the staffing coverage and teachers by qualification of a school
+// (replaces existing data for the school)
+func (r *SchoolStatisticsRepository) SaveStaffingStat(ctx context.Context, stat models.SchoolStaffingStat) error {
+	now := time.Now()
+	query := `INSERT OR REPLACE INTO school_staffing_stats (school_number, staffing_coverage, scraped_at, created_at) VALUES (?, ?, ?, ?)`
+	if _, err := execQuery(ctx, r.writer, "save staffing stat", query, stat.SchoolNumber, stat.StaffingCoverage, stat.ScrapedAt, now); err != nil {
+		return err
+	}
 
-	err := r.db.SelectContext(ctx, &stats, query, schoolNumber)
+	_, err := execQuery(ctx, r.writer, "delete old teacher qualifications", `DELETE FROM school_teacher_qualifications WHERE school_number = ?`, stat.SchoolNumber)
 	if err != nil {
-		return nil, errors.NewDatabaseError("get citizenship stats", err)
+		return err
 	}
 
-	return stats, nil
+	rows := make([][]interface{}, 0, len(stat.Qualifications))
+	for _, q := range stat.Qualifications {
+		rows = append(rows, []interface{}{stat.SchoolNumber, q.Qualification, q.Teachers, q.ScrapedAt, now})
+	}
+	_, err = insertBatches(ctx, r.writer, "insert teacher qualifications",
+		`INSERT INTO school_teacher_qualifications (school_number, qualification, teachers, scraped_at, created_at)`, "", rows)
+	return err
 }
 
-// GetLanguageStat retrieves language statistics for a school
-func (r *SchoolStatisticsRepository) GetLanguageStat(ctx context.Context, schoolNumber string) (*models.SchoolLanguageStat, error) {
-	var stat models.SchoolLanguageStat
-	query := `SELECT * FROM school_language_stats WHERE school_number = ?`
+// GetStaffingStat retrieves the staffing of a school with its teachers by qualification, most
+// teachers first
+func (r *SchoolStatisticsRepository) GetStaffingStat(ctx context.Context, schoolNumber string) (*models.SchoolStaffingStat, error) {
+	query := `SELECT * FROM school_staffing_stats WHERE school_number = ` + currentSchoolNumber
 
-	err := r.db.GetContext(ctx, &stat, query, schoolNumber)
+	stat, err := getOne[models.SchoolStaffingStat](ctx, r.reader, "staffing stat", schoolNumber, "get staffing stat", query, schoolNumber, schoolNumber)
 	if err != nil {
-		return nil, errors.NewDatabaseError("get language stat", err)
+		return nil, err
 	}
 
-	return &stat, nil
+	stat.Qualifications, err = getList[models.SchoolTeacherQualification](ctx, r.reader, "get teacher qualifications",
+		`SELECT * FROM school_teacher_qualifications WHERE school_number = ? ORDER BY teachers DESC, qualification`, stat.SchoolNumber)
+	if err != nil {
+		return nil, err
+	}
+	return stat, nil
 }
 
-// GetResidenceStats retrieves residence statistics for a school
-func (r *SchoolStatisticsRepository) GetResidenceStats(ctx context.Context, schoolNumber string) ([]models.SchoolResidenceStat, error) {
-	var stats []models.SchoolResidenceStat
-	query := `SELECT * FROM school_residence_stats WHERE school_number = ? ORDER BY student_count DESC`
+// GetAllStaffingStats retrieves the staffing of all schools with their teachers by qualification,
+// ordered by school number
+func (r *SchoolStatisticsRepository) GetAllStaffingStats(ctx context.Context) ([]models.SchoolStaffingStat, error) {
+	stats, err := getList[models.SchoolStaffingStat](ctx, r.reader, "get staffing stats",
+		`SELECT * FROM school_staffing_stats ORDER BY school_number`)
+	if err != nil {
+		return nil, err
+	}
 
-	err := r.db.SelectContext(ctx, &stats, query, schoolNumber)
+	qualifications, err := getList[models.SchoolTeacherQualification](ctx, r.reader, "get teacher qualifications",
+		`SELECT * FROM school_teacher_qualifications ORDER BY school_number, teachers DESC, qualification`)
 	if err != nil {
-		return nil, errors.NewDatabaseError("get residence stats", err)
+		return nil, err
 	}
 
+	bySchool := make(map[string][]models.SchoolTeacherQualification)
+	for _, q := range qualifications {
+		bySchool[q.SchoolNumber] = append(bySchool[q.SchoolNumber], q)
+	}
+	for i := range stats {
+		stats[i].Qualifications = bySchool[stats[i].SchoolNumber]
+	}
 	return stats, nil
 }
 
-// GetAbsenceStat retrieves absence statistics for a school
-func (r *SchoolStatisticsRepository) GetAbsenceStat(ctx context.Context, schoolNumber string) (*models.SchoolAbsenceStat, error) {
-	var stat models.SchoolAbsenceStat
-	query := `SELECT * FROM school_absence_stats WHERE school_number = ?`
-
-	err := r.db.GetContext(ctx, &stat, query, schoolNumber)
+// SaveQualityIndicators saves the quality framework ratings of a school (replaces existing data
+// for the school)
+func (r *SchoolStatisticsRepository) SaveQualityIndicators(ctx context.Context, schoolNumber string, indicators []models.SchoolQualityIndicator) error {
+	_, err := execQuery(ctx, r.writer, "delete old quality indicators", `DELETE FROM school_quality_indicators WHERE school_number = ?`, schoolNumber)
 	if err != nil {
-		return nil, errors.NewDatabaseError("get absence stat", err)
+		return err
+	}
+
+	now := time.Now()
+	rows := make([][]interface{}, 0, len(indicators))
+	for _, indicator := range indicators {
+		rows = append(rows, []interface{}{schoolNumber, indicator.Area, indicator.Code, indicator.Indicator, indicator.Rating, indicator.ScrapedAt, now})
 	}
+	_, err = insertBatches(ctx, r.writer, "insert quality indicators",
+		`INSERT INTO school_quality_indicators (school_number, area, code, indicator, rating, scraped_at, created_at)`, "", rows)
+	return err
+}
+
+// GetQualityIndicators retrieves the quality framework ratings of a school in the order of its
+// quality profile
+func (r *SchoolStatisticsRepository) GetQualityIndicators(ctx context.Context, schoolNumber string) ([]models.SchoolQualityIndicator, error) {
+	query := `SELECT * FROM school_quality_indicators WHERE school_number = ` + currentSchoolNumber + ` ORDER BY id`
+
+	return getList[models.SchoolQualityIndicator](ctx, r.reader, "get quality indicators", query, schoolNumber, schoolNumber)
+}
+
+// GetAbsenceStat retrieves absence statistics for a school
+func (r *SchoolStatisticsRepository) GetAbsenceStat(ctx context.Context, schoolNumber string) (*models.SchoolAbsenceStat, error) {
+	query := `SELECT * FROM school_absence_stats WHERE school_number = ` + currentSchoolNumber
 
-	return &stat, nil
+	return getOne[models.SchoolAbsenceStat](ctx, r.reader, "absence stat", schoolNumber, "get absence stat", query, schoolNumber, schoolNumber)
 }
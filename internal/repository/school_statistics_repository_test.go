@@ -0,0 +1,146 @@
+package repository
+
+import (
+	"context"
+	"math/rand"
+	"testing"
+
+	"schools-be/internal/models"
+)
+
+func TestSaveDataQualityIssues(t *testing.T) {
+	ctx := context.Background()
+	repo := NewSchoolStatisticsRepository(newTestDB(t))
+
+	save := func(schoolNumber string, issues ...models.DataQualityIssue) {
+		t.Helper()
+		if err := repo.SaveDataQualityIssues(ctx, schoolNumber, models.DatasetCitizenship, issues); err != nil {
+			t.Fatal(err)
+		}
+	}
+	details := func(want ...string) {
+		t.Helper()
+		issues, err := repo.GetDataQualityIssues(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, issue := range issues {
+			got = append(got, issue.SchoolNumber+" "+issue.Detail)
+		}
+		if len(got) != len(want) {
+			t.Fatalf("issues = %q, want %q", got, want)
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Fatalf("issues = %q, want %q", got, want)
+			}
+		}
+	}
+
+	save("01Y02", models.DataQualityIssue{Detail: "Türkei", Total: 10, Sum: 9})
+	save("02K03", models.DataQualityIssue{Detail: "Insgesamt vs. categories", Total: 864, Sum: 812, Excluded: true})
+	details("02K03 Insgesamt vs. categories", "01Y02 Türkei") // Excluded tables first
+
+	// A new scrape replaces the issues of the school
+	save("01Y02", models.DataQualityIssue{Detail: "Polen", Total: 4, Sum: 5})
+	details("02K03 Insgesamt vs. categories", "01Y02 Polen")
+	save("02K03")
+	details("01Y02 Polen")
+}
+
+func TestDiverseStudentsRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	if _, err := NewSchoolRepository(db).Create(ctx, randomCreateSchoolInput(rand.New(rand.NewSource(1)), "01Y02")); err != nil {
+		t.Fatal(err)
+	}
+	repo := NewSchoolStatisticsRepository(db)
+
+	err := repo.SaveCitizenshipStats(ctx, []models.SchoolCitizenshipStat{
+		{SchoolNumber: "01Y02", Citizenship: "Türkei", FemaleStudents: 21, MaleStudents: 18, DiverseStudents: 1, Total: 40},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	citizenship, err := repo.GetCitizenshipStats(ctx, "01Y02")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(citizenship) != 1 || citizenship[0].DiverseStudents != 1 {
+		t.Errorf("citizenship = %+v, want 1 diverse student", citizenship)
+	}
+
+	if err := repo.SaveLanguageStat(ctx, models.SchoolLanguageStat{SchoolNumber: "01Y02", NDHDiverseStudents: 2, NDHTotal: 312}); err != nil {
+		t.Fatal(err)
+	}
+	language, err := repo.GetLanguageStat(ctx, "01Y02")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if language.NDHDiverseStudents != 2 {
+		t.Errorf("language = %+v, want 2 diverse students", language)
+	}
+
+	// Statistics stored before the divers columns existed read as empty
+	stats := NewStatisticRepository(db)
+	if _, err := db.Writer.ExecContext(ctx, `
+		INSERT INTO school_statistics (school_number, school_name, district, school_type, school_year,
+			students, students_male, students_female, teachers, teachers_male, teachers_female, classes, metadata, scraped_at)
+		VALUES ('01Y02', '', '', '', '2019/20', '800', '', '', '', '', '', '', '{}', CURRENT_TIMESTAMP)`); err != nil {
+		t.Fatal(err)
+	}
+	if err := stats.CreateOrUpdate(ctx, models.StatisticData{SchoolNumber: "01Y02", SchoolYear: "2024/25", Students: "864", StudentsDiverse: "2", TeachersDiverse: "1"}); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := stats.GetBySchoolNumber(ctx, "01Y02")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 || rows[0].StudentsDiverse != "2" || rows[0].TeachersDiverse != "1" || rows[1].StudentsDiverse != "" {
+		t.Errorf("statistics = %+v", rows)
+	}
+}
+
+func TestStaffingRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	if _, err := NewSchoolRepository(db).Create(ctx, randomCreateSchoolInput(rand.New(rand.NewSource(1)), "01Y02")); err != nil {
+		t.Fatal(err)
+	}
+	repo := NewSchoolStatisticsRepository(db)
+
+	coverage := 101.2
+	save := func(stat models.SchoolStaffingStat) {
+		t.Helper()
+		if err := repo.SaveStaffingStat(ctx, stat); err != nil {
+			t.Fatal(err)
+		}
+	}
+	save(models.SchoolStaffingStat{SchoolNumber: "01Y02", StaffingCoverage: &coverage, Qualifications: []models.SchoolTeacherQualification{
+		{Qualification: "Quereinsteigende", Teachers: 6},
+		{Qualification: "Lehrkräfte mit Lehramtsbefähigung", Teachers: 45},
+	}})
+	// A new scrape replaces the qualifications of the school
+	save(models.SchoolStaffingStat{SchoolNumber: "01Y02", Qualifications: []models.SchoolTeacherQualification{
+		{Qualification: "Quereinsteigende", Teachers: 7},
+		{Qualification: "Lehrkräfte mit Lehramtsbefähigung", Teachers: 44},
+	}})
+
+	stat, err := repo.GetStaffingStat(ctx, "01Y02")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stat.StaffingCoverage != nil || len(stat.Qualifications) != 2 ||
+		stat.Qualifications[0].Teachers != 44 || stat.Qualifications[1].Qualification != "Quereinsteigende" {
+		t.Errorf("staffing = %+v", stat)
+	}
+
+	all, err := repo.GetAllStaffingStats(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 1 || len(all[0].Qualifications) != 2 {
+		t.Errorf("all staffing = %+v", all)
+	}
+}
@@ -4,8 +4,12 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"maps"
+	"slices"
 	"time"
 
+	"schools-be/internal/cache"
+	"schools-be/internal/database"
 	"schools-be/internal/errors"
 	"schools-be/internal/models"
 
@@ -13,63 +17,75 @@ import (
 )
 
 type StatisticRepository struct {
-	db *sqlx.DB
+	writer *sqlx.DB
+	reader *sqlx.DB
+	cache  *cache.Cache
 }
 
-func NewStatisticRepository(db *sqlx.DB) *StatisticRepository {
-	return &StatisticRepository{db: db}
+func NewStatisticRepository(db *database.DB) *StatisticRepository {
+	return &StatisticRepository{writer: db.Writer, reader: db.Reader}
+}
+
+// WithCache enables read-through caching of the statistics summary (invalidated on every write)
+func (r *StatisticRepository) WithCache(c *cache.Cache) *StatisticRepository {
+	r.cache = c
+	return r
 }
 
 // GetAll returns all statistics ordered by school year desc
 func (r *StatisticRepository) GetAll(ctx context.Context) ([]models.SchoolStatistic, error) {
-	var statistics []models.SchoolStatistic
 	query := `SELECT * FROM school_statistics ORDER BY school_year DESC, school_name`
 
-	err := r.db.SelectContext(ctx, &statistics, query)
-	if err != nil {
-		return nil, errors.NewDatabaseError("get all statistics", err)
-	}
-
-	return statistics, nil
+	return getList[models.SchoolStatistic](ctx, r.reader, "get all statistics", query)
 }
 
 // GetByID returns a statistic by its ID
 func (r *StatisticRepository) GetByID(ctx context.Context, id int64) (*models.SchoolStatistic, error) {
-	var statistic models.SchoolStatistic
 	query := `SELECT * FROM school_statistics WHERE id = ?`
 
-	err := r.db.GetContext(ctx, &statistic, query, id)
-	if err == sql.ErrNoRows {
-		return nil, errors.NewNotFoundError("statistic", id)
-	}
-	if err != nil {
-		return nil, errors.NewDatabaseError("get statistic by id", err)
-	}
-
-	return &statistic, nil
+	return getOne[models.SchoolStatistic](ctx, r.reader, "statistic", id, "get statistic by id", query, id)
 }
 
 // GetBySchoolNumber returns all statistics for a school
 func (r *StatisticRepository) GetBySchoolNumber(ctx context.Context, schoolNumber string) ([]models.SchoolStatistic, error) {
-	var statistics []models.SchoolStatistic
-	query := `SELECT * FROM school_statistics WHERE school_number = ? ORDER BY school_year DESC`
+	query := `SELECT * FROM school_statistics WHERE school_number = ` + currentSchoolNumber + ` ORDER BY school_year DESC`
 
-	err := r.db.SelectContext(ctx, &statistics, query, schoolNumber)
-	if err != nil {
-		return nil, errors.NewDatabaseError("get statistics by school number", err)
-	}
-
-	return statistics, nil
+	return getList[models.SchoolStatistic](ctx, r.reader, "get statistics by school number", query, schoolNumber, schoolNumber)
 }
 
 // GetBySchoolYear returns all statistics for a specific school year
 func (r *StatisticRepository) GetBySchoolYear(ctx context.Context, schoolYear string) ([]models.SchoolStatistic, error) {
-	var statistics []models.SchoolStatistic
 	query := `SELECT * FROM school_statistics WHERE school_year = ? ORDER BY school_name`
 
-	err := r.db.SelectContext(ctx, &statistics, query, schoolYear)
-	if err != nil {
-		return nil, errors.NewDatabaseError("get statistics by school year", err)
+	return getList[models.SchoolStatistic](ctx, r.reader, "get statistics by school year", query, schoolYear)
+}
+
+// Find returns statistics matching the filter, newest school year first
+func (r *StatisticRepository) Find(ctx context.Context, filter models.StatisticFilter) ([]models.SchoolStatistic, error) {
+	query := `SELECT * FROM school_statistics WHERE 1 = 1`
+	var args []interface{}
+
+	if filter.SchoolNumber != "" {
+		query += ` AND school_number = ` + currentSchoolNumber
+		args = append(args, filter.SchoolNumber, filter.SchoolNumber)
+	}
+	if filter.SchoolYear != "" {
+		query += ` AND school_year = ?`
+		args = append(args, filter.SchoolYear)
+	}
+	if filter.FromYear != "" {
+		query += ` AND school_year >= ?`
+		args = append(args, filter.FromYear)
+	}
+	if filter.ToYear != "" {
+		query += ` AND school_year <= ?`
+		args = append(args, filter.ToYear)
+	}
+	query += ` ORDER BY school_year DESC, school_name`
+
+	var statistics []models.SchoolStatistic
+	if err := r.reader.SelectContext(ctx, &statistics, query, args...); err != nil {
+		return nil, errors.NewDatabaseError("find statistics", err)
 	}
 
 	return statistics, nil
@@ -86,13 +102,14 @@ func (r *StatisticRepository) Create(ctx context.Context, data models.StatisticD
 	query := `
 		INSERT INTO school_statistics (
 			school_number, school_name, district, school_type, school_year,
-			students, students_male, students_female, teachers, teachers_male, teachers_female,
+			students, students_male, students_female, students_diverse,
+			teachers, teachers_male, teachers_female, teachers_diverse,
 			classes, metadata, scraped_at
 		)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
-	result, err := r.db.ExecContext(ctx, query,
+	result, err := r.writer.ExecContext(ctx, query,
 		data.SchoolNumber,
 		data.SchoolName,
 		data.District,
@@ -101,9 +118,11 @@ func (r *StatisticRepository) Create(ctx context.Context, data models.StatisticD
 		data.Students,
 		data.StudentsMale,
 		data.StudentsFemale,
+		data.StudentsDiverse,
 		data.Teachers,
 		data.TeachersMale,
 		data.TeachersFemale,
+		data.TeachersDiverse,
 		data.Classes,
 		string(metadataJSON),
 		data.ScrapedAt,
@@ -111,6 +130,7 @@ func (r *StatisticRepository) Create(ctx context.Context, data models.StatisticD
 	if err != nil {
 		return nil, errors.NewDatabaseError("create statistic", err)
 	}
+	r.cache.Invalidate()
 
 	id, err := result.LastInsertId()
 	if err != nil {
@@ -131,10 +151,11 @@ func (r *StatisticRepository) CreateOrUpdate(ctx context.Context, data models.St
 	query := `
 		INSERT INTO school_statistics (
 			school_number, school_name, district, school_type, school_year,
-			students, students_male, students_female, teachers, teachers_male, teachers_female,
+			students, students_male, students_female, students_diverse,
+			teachers, teachers_male, teachers_female, teachers_diverse,
 			classes, metadata, scraped_at
 		)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 		ON CONFLICT(school_number, school_year) DO UPDATE SET
 			school_name = excluded.school_name,
 			district = excluded.district,
@@ -142,15 +163,17 @@ func (r *StatisticRepository) CreateOrUpdate(ctx context.Context, data models.St
 			students = excluded.students,
 			students_male = excluded.students_male,
 			students_female = excluded.students_female,
+			students_diverse = excluded.students_diverse,
 			teachers = excluded.teachers,
 			teachers_male = excluded.teachers_male,
 			teachers_female = excluded.teachers_female,
+			teachers_diverse = excluded.teachers_diverse,
 			classes = excluded.classes,
 			metadata = excluded.metadata,
 			scraped_at = excluded.scraped_at
 	`
 
-	_, err = r.db.ExecContext(ctx, query,
+	_, err = r.writer.ExecContext(ctx, query,
 		data.SchoolNumber,
 		data.SchoolName,
 		data.District,
@@ -159,9 +182,11 @@ func (r *StatisticRepository) CreateOrUpdate(ctx context.Context, data models.St
 		data.Students,
 		data.StudentsMale,
 		data.StudentsFemale,
+		data.StudentsDiverse,
 		data.Teachers,
 		data.TeachersMale,
 		data.TeachersFemale,
+		data.TeachersDiverse,
 		data.Classes,
 		string(metadataJSON),
 		data.ScrapedAt,
@@ -169,25 +194,52 @@ func (r *StatisticRepository) CreateOrUpdate(ctx context.Context, data models.St
 	if err != nil {
 		return errors.NewDatabaseError("create or update statistic", err)
 	}
+	r.cache.Invalidate()
 
 	return nil
 }
 
-// BulkCreateOrUpdate creates or updates multiple statistics in a transaction
+// BulkCreateOrUpdate creates or updates multiple statistics in a transaction, using batched
+// multi-row upserts. Records with metadata that cannot be encoded are skipped; any other
+// failure rolls back the whole set. Records under a replaced school number are stored under
+// the current one. It returns the number of stored records.
 func (r *StatisticRepository) BulkCreateOrUpdate(ctx context.Context, statistics []models.StatisticData) (int, error) {
-	tx, err := r.db.BeginTxx(ctx, nil)
+	aliases, err := aliasMap(ctx, r.reader)
+	if err != nil {
+		return 0, err
+	}
+
+	rows := make([][]interface{}, 0, len(statistics))
+	for _, data := range statistics {
+		metadataJSON, err := json.Marshal(data.Metadata)
+		if err != nil {
+			continue // Skip invalid records
+		}
+		schoolNumber := data.SchoolNumber
+		if current, ok := aliases[schoolNumber]; ok {
+			schoolNumber = current
+		}
+		rows = append(rows, []interface{}{
+			schoolNumber, data.SchoolName, data.District, data.SchoolType, data.SchoolYear,
+			data.Students, data.StudentsMale, data.StudentsFemale, data.StudentsDiverse,
+			data.Teachers, data.TeachersMale, data.TeachersFemale, data.TeachersDiverse,
+			data.Classes, string(metadataJSON), data.ScrapedAt,
+		})
+	}
+
+	tx, err := r.writer.BeginTxx(ctx, nil)
 	if err != nil {
 		return 0, errors.NewDatabaseError("begin transaction", err)
 	}
 	defer tx.Rollback()
 
-	stmt, err := tx.PreparexContext(ctx, `
+	_, err = insertBatches(ctx, tx, "store statistics", `
 		INSERT INTO school_statistics (
 			school_number, school_name, district, school_type, school_year,
-			students, students_male, students_female, teachers, teachers_male, teachers_female,
+			students, students_male, students_female, students_diverse,
+			teachers, teachers_male, teachers_female, teachers_diverse,
 			classes, metadata, scraped_at
-		)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		)`, `
 		ON CONFLICT(school_number, school_year) DO UPDATE SET
 			school_name = excluded.school_name,
 			district = excluded.district,
@@ -195,63 +247,35 @@ func (r *StatisticRepository) BulkCreateOrUpdate(ctx context.Context, statistics
 			students = excluded.students,
 			students_male = excluded.students_male,
 			students_female = excluded.students_female,
+			students_diverse = excluded.students_diverse,
 			teachers = excluded.teachers,
 			teachers_male = excluded.teachers_male,
 			teachers_female = excluded.teachers_female,
+			teachers_diverse = excluded.teachers_diverse,
 			classes = excluded.classes,
 			metadata = excluded.metadata,
 			scraped_at = excluded.scraped_at
-	`)
+	`, rows)
 	if err != nil {
-		return 0, errors.NewDatabaseError("prepare statement", err)
-	}
-	defer stmt.Close()
-
-	saved := 0
-	for _, data := range statistics {
-		// Convert metadata to JSON
-		metadataJSON, err := json.Marshal(data.Metadata)
-		if err != nil {
-			continue // Skip invalid records
-		}
-
-		_, err = stmt.ExecContext(ctx,
-			data.SchoolNumber,
-			data.SchoolName,
-			data.District,
-			data.SchoolType,
-			data.SchoolYear,
-			data.Students,
-			data.StudentsMale,
-			data.StudentsFemale,
-			data.Teachers,
-			data.TeachersMale,
-			data.TeachersFemale,
-			data.Classes,
-			string(metadataJSON),
-			data.ScrapedAt,
-		)
-		if err != nil {
-			continue // Skip failed records
-		}
-		saved++
+		return 0, err
 	}
 
 	if err := tx.Commit(); err != nil {
 		return 0, errors.NewDatabaseError("commit transaction", err)
 	}
+	r.cache.Invalidate()
 
-	return saved, nil
+	return len(rows), nil
 }
 
 // DeleteBySchoolYear deletes all statistics for a specific school year
 func (r *StatisticRepository) DeleteBySchoolYear(ctx context.Context, schoolYear string) error {
 	query := `DELETE FROM school_statistics WHERE school_year = ?`
 
-	_, err := r.db.ExecContext(ctx, query, schoolYear)
-	if err != nil {
-		return errors.NewDatabaseError("delete statistics by school year", err)
+	if _, err := execQuery(ctx, r.writer, "delete statistics by school year", query, schoolYear); err != nil {
+		return err
 	}
+	r.cache.Invalidate()
 
 	return nil
 }
@@ -261,7 +285,7 @@ func (r *StatisticRepository) GetLatestScrapedAt(ctx context.Context) (*time.Tim
 	var scrapedAt sql.NullTime
 	query := `SELECT MAX(scraped_at) FROM school_statistics`
 
-	err := r.db.QueryRowContext(ctx, query).Scan(&scrapedAt)
+	err := r.reader.QueryRowContext(ctx, query).Scan(&scrapedAt)
 	if err != nil {
 		return nil, errors.NewDatabaseError("get latest scraped at", err)
 	}
@@ -273,25 +297,44 @@ func (r *StatisticRepository) GetLatestScrapedAt(ctx context.Context) (*time.Tim
 	return &scrapedAt.Time, nil
 }
 
+// statisticYearCount is the number of statistics rows of a school year in the summary
+type statisticYearCount struct {
+	SchoolYear string `db:"school_year"`
+	Count      int    `db:"count"`
+}
+
 // GetStatisticsSummary returns summary statistics
 func (r *StatisticRepository) GetStatisticsSummary(ctx context.Context) (map[string]interface{}, error) {
+	summary, err := cache.GetOrLoad(r.cache, "summary", func() (map[string]interface{}, error) {
+		return r.loadStatisticsSummary(ctx)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Return a copy so callers can't mutate the cached summary
+	summary = maps.Clone(summary)
+	if byYear, ok := summary["by_year"].([]statisticYearCount); ok {
+		summary["by_year"] = slices.Clone(byYear)
+	}
+	return summary, nil
+}
+
+// loadStatisticsSummary computes the summary statistics from the database
+func (r *StatisticRepository) loadStatisticsSummary(ctx context.Context) (map[string]interface{}, error) {
 	summary := make(map[string]interface{})
 
 	// Total count
 	var totalCount int
-	err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM school_statistics`).Scan(&totalCount)
+	err := r.reader.QueryRowContext(ctx, `SELECT COUNT(*) FROM school_statistics`).Scan(&totalCount)
 	if err != nil {
 		return nil, errors.NewDatabaseError("get total count", err)
 	}
 	summary["total_count"] = totalCount
 
 	// Count by school year
-	type yearCount struct {
-		SchoolYear string `db:"school_year"`
-		Count      int    `db:"count"`
-	}
-	var yearCounts []yearCount
-	err = r.db.SelectContext(ctx, &yearCounts, `
+	var yearCounts []statisticYearCount
+	err = r.reader.SelectContext(ctx, &yearCounts, `
 		SELECT school_year, COUNT(*) as count 
 		FROM school_statistics 
 		GROUP BY school_year 
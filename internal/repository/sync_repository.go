@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"context"
+
+	"schools-be/internal/database"
+	"schools-be/internal/errors"
+	"schools-be/internal/models"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// SyncRepository reads the change log filled by the sync triggers (see database.migrateSyncTriggers)
+type SyncRepository struct {
+	reader *sqlx.DB
+}
+
+func NewSyncRepository(db *database.DB) *SyncRepository {
+	return &SyncRepository{reader: db.Reader}
+}
+
+// GetAll returns every record that currently exists
+func (r *SyncRepository) GetAll(ctx context.Context) ([]models.SyncChange, error) {
+	var changes []models.SyncChange
+	query := `SELECT entity, record_key, deleted, content, created_at, updated_at
+	          FROM sync_changes
+	          WHERE deleted = 0
+	          ORDER BY entity, record_key`
+
+	if err := r.reader.SelectContext(ctx, &changes, query); err != nil {
+		return nil, errors.NewDatabaseError("get sync records", err)
+	}
+	return changes, nil
+}
+
+// GetChangedSince returns records changed after since (formatted as database.SyncTimestampFormat).
+// Records created and deleted again in that period are left out.
+func (r *SyncRepository) GetChangedSince(ctx context.Context, since string) ([]models.SyncChange, error) {
+	var changes []models.SyncChange
+	query := `SELECT entity, record_key, deleted, content, created_at, updated_at
+	          FROM sync_changes
+	          WHERE (deleted = 0 AND updated_at > ?)
+	             OR (deleted = 1 AND deleted_at > ? AND created_at <= ?)
+	          ORDER BY entity, record_key`
+
+	if err := r.reader.SelectContext(ctx, &changes, query, since, since, since); err != nil {
+		return nil, errors.NewDatabaseError("get sync changes", err)
+	}
+	return changes, nil
+}
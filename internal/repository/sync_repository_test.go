@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"schools-be/internal/models"
+)
+
+// TestSyncOmitsPrivateColumns checks that synced records only carry what the public DTOs
+// expose: no fax number and none of the scraped statistic table blobs
+func TestSyncOmitsPrivateColumns(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+
+	if _, err := NewSchoolRepository(db).Create(ctx, models.CreateSchoolInput{
+		SchoolNumber: "01Y02", Name: "Lessing-Gymnasium", SchoolType: "Gymnasium", Fax: "030 1234567",
+	}); err != nil {
+		t.Fatalf("create school: %v", err)
+	}
+	table := &models.StatisticTable{Headers: []string{"Schuljahr", "Anzahl"}, Rows: [][]string{{"2024/25", "12"}}}
+	if err := NewSchoolDetailRepository(db).Create(ctx, &models.SchoolDetailData{
+		SchoolNumber: "01Y02", SchoolName: "Lessing-Gymnasium", Languages: "Englisch",
+		CitizenshipTable: table, LanguageTable: table, ResidenceTable: table, AbsenceTable: table,
+	}); err != nil {
+		t.Fatalf("create school detail: %v", err)
+	}
+
+	changes, err := NewSyncRepository(db).GetAll(ctx)
+	if err != nil {
+		t.Fatalf("get sync records: %v", err)
+	}
+	synced := make(map[string]bool)
+	for _, change := range changes {
+		if change.Content == nil {
+			continue
+		}
+		var content map[string]any
+		if err := json.Unmarshal([]byte(*change.Content), &content); err != nil {
+			t.Fatalf("%s %s: invalid content: %v", change.Entity, change.Key, err)
+		}
+		synced[change.Entity] = true
+		for column := range content {
+			if column == "fax" || strings.HasSuffix(column, "_data") {
+				t.Errorf("%s %s: %s synced", change.Entity, change.Key, column)
+			}
+		}
+	}
+	for _, entity := range []string{"schools", "school_details"} {
+		if !synced[entity] {
+			t.Errorf("no %s record synced", entity)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"schools-be/internal/database"
+	"schools-be/internal/errors"
+	"schools-be/internal/models"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type TravelTimeRepository struct {
+	writer *sqlx.DB
+	reader *sqlx.DB
+}
+
+func NewTravelTimeRepository(db *database.DB) *TravelTimeRepository {
+	return &TravelTimeRepository{writer: db.Writer, reader: db.Reader}
+}
+
+// Get returns the cached travel time from a grid cell to a school computed after notBefore, or nil
+func (r *TravelTimeRepository) Get(ctx context.Context, cell, mode string, schoolID int64, notBefore time.Time) (*models.CachedTravelTime, error) {
+	var entry models.CachedTravelTime
+	query := `SELECT * FROM travel_time_cache WHERE cell = ? AND mode = ? AND school_id = ? AND computed_at >= ?`
+
+	err := r.reader.GetContext(ctx, &entry, query, cell, mode, schoolID, notBefore)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.NewDatabaseError("get cached travel time", err)
+	}
+
+	return &entry, nil
+}
+
+// GetByCell returns the cached travel times from a grid cell for a mode computed after notBefore, keyed by school ID
+func (r *TravelTimeRepository) GetByCell(ctx context.Context, cell, mode string, notBefore time.Time) (map[int64]models.CachedTravelTime, error) {
+	var entries []models.CachedTravelTime
+	query := `SELECT * FROM travel_time_cache WHERE cell = ? AND mode = ? AND computed_at >= ?`
+
+	err := r.reader.SelectContext(ctx, &entries, query, cell, mode, notBefore)
+	if err != nil {
+		return nil, errors.NewDatabaseError("get cached travel times", err)
+	}
+
+	byID := make(map[int64]models.CachedTravelTime, len(entries))
+	for _, entry := range entries {
+		byID[entry.SchoolID] = entry
+	}
+
+	return byID, nil
+}
+
+// Upsert stores travel times, replacing earlier results for the same cell, mode and school
+func (r *TravelTimeRepository) Upsert(ctx context.Context, entries []models.CachedTravelTime) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	tx, err := r.writer.BeginTxx(ctx, nil)
+	if err != nil {
+		return errors.NewDatabaseError("begin transaction", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PreparexContext(ctx, `
+		INSERT INTO travel_time_cache (cell, school_id, mode, duration_seconds, distance_meters, computed_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(cell, mode, school_id) DO UPDATE SET
+			duration_seconds = excluded.duration_seconds,
+			distance_meters = excluded.distance_meters,
+			computed_at = excluded.computed_at
+	`)
+	if err != nil {
+		return errors.NewDatabaseError("prepare statement", err)
+	}
+	defer stmt.Close()
+
+	for _, e := range entries {
+		if _, err := stmt.ExecContext(ctx, e.Cell, e.SchoolID, e.Mode, e.DurationSeconds, e.DistanceMeters, e.ComputedAt); err != nil {
+			return errors.NewDatabaseError("cache travel time", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.NewDatabaseError("commit transaction", err)
+	}
+
+	return nil
+}
+
+// DeleteExpired removes entries computed before the cutoff and returns how many were deleted
+func (r *TravelTimeRepository) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	result, err := r.writer.ExecContext(ctx, `DELETE FROM travel_time_cache WHERE computed_at < ?`, before)
+	if err != nil {
+		return 0, errors.NewDatabaseError("delete expired travel times", err)
+	}
+
+	return result.RowsAffected()
+}
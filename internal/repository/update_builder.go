@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+// updateField is a field of an update input struct that maps to a column
+type updateField struct {
+	column string
+	index  int
+}
+
+var updateFieldCache sync.Map // reflect.Type -> []updateField
+
+// updateFields returns the db-tagged fields of an update input struct in declaration order.
+// Tagged fields must be pointers, since nil is what marks a field as unchanged; anything else
+// is a programming error and panics on first use.
+func updateFields(t reflect.Type) []updateField {
+	if cached, ok := updateFieldCache.Load(t); ok {
+		return cached.([]updateField)
+	}
+
+	var fields []updateField
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		column := field.Tag.Get("db")
+		if column == "" || column == "-" {
+			continue
+		}
+		if field.Type.Kind() != reflect.Ptr {
+			panic(fmt.Sprintf("update input %s.%s: db-tagged field must be a pointer", t.Name(), field.Name))
+		}
+		fields = append(fields, updateField{column: column, index: i})
+	}
+
+	updateFieldCache.Store(t, fields)
+	return fields
+}
+
+// updateSet builds the SET clause and arguments for the non-nil fields of an update input
+// struct, after bumping updated_at and version. Column names come from the struct tags only,
+// never from request data.
+func updateSet(input interface{}) (string, []interface{}) {
+	v := reflect.ValueOf(input)
+	if v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+
+	set := []string{`updated_at = ?`, `version = version + 1`}
+	args := []interface{}{time.Now()}
+	for _, field := range updateFields(v.Type()) {
+		value := v.Field(field.index)
+		if value.IsNil() {
+			continue
+		}
+		set = append(set, field.column+` = ?`)
+		args = append(args, value.Elem().Interface())
+	}
+
+	return strings.Join(set, `, `), args
+}
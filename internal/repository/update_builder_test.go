@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"schools-be/internal/models"
+)
+
+// TestUpdateSchoolInputColumns checks that every field of UpdateSchoolInput is tagged with
+// an existing column of the schools table, so a new field cannot be dropped silently
+func TestUpdateSchoolInputColumns(t *testing.T) {
+	db := newTestDB(t)
+	var columns []string
+	if err := db.Reader.Select(&columns, `SELECT name FROM pragma_table_info('schools')`); err != nil {
+		t.Fatalf("read columns: %v", err)
+	}
+	known := make(map[string]bool, len(columns))
+	for _, column := range columns {
+		known[column] = true
+	}
+
+	inputType := reflect.TypeOf(models.UpdateSchoolInput{})
+	if got, want := len(updateFields(inputType)), inputType.NumField(); got != want {
+		t.Errorf("%d of %d fields are db-tagged", got, want)
+	}
+	for _, field := range updateFields(inputType) {
+		if !known[field.column] {
+			t.Errorf("field %s: column %q does not exist", inputType.Field(field.index).Name, field.column)
+		}
+		if field.column == "updated_at" || field.column == "version" {
+			t.Errorf("field %s: column %q is maintained by the repository", inputType.Field(field.index).Name, field.column)
+		}
+	}
+}
+
+func TestUpdateSet(t *testing.T) {
+	name, lat := "Schule am Park", 52.5
+	before := time.Now()
+
+	set, args := updateSet(models.UpdateSchoolInput{Name: &name, Latitude: &lat})
+	if want := `updated_at = ?, version = version + 1, name = ?, latitude = ?`; set != want {
+		t.Errorf("set = %q, want %q", set, want)
+	}
+	if len(args) != 3 {
+		t.Fatalf("args = %v, want 3", args)
+	}
+	if updatedAt, ok := args[0].(time.Time); !ok || updatedAt.Before(before) {
+		t.Errorf("args[0] = %v, want the current time", args[0])
+	}
+	if args[1] != name || args[2] != lat {
+		t.Errorf("args = %v, want dereferenced values", args[1:])
+	}
+
+	// A pointer to the input builds the same clause
+	if pointerSet, _ := updateSet(&models.UpdateSchoolInput{Name: &name, Latitude: &lat}); pointerSet != set {
+		t.Errorf("set of pointer = %q, want %q", pointerSet, set)
+	}
+
+	if set, args := updateSet(models.UpdateSchoolInput{}); set != `updated_at = ?, version = version + 1` || len(args) != 1 {
+		t.Errorf("empty update: set = %q, args = %v", set, args)
+	}
+}
+
+func TestUpdateSetRejectsNonPointerFields(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil || !strings.Contains(r.(string), "must be a pointer") {
+			t.Errorf("recovered %v, want a panic about the field type", r)
+		}
+	}()
+	updateSet(struct {
+		Name string `db:"name"`
+	}{Name: "x"})
+}
+
+// TestUpdateEachField updates one field at a time, so a field mapped to the wrong column
+// shows up as a change in another field
+func TestUpdateEachField(t *testing.T) {
+	repo := NewSchoolRepository(newTestDB(t))
+	ctx := context.Background()
+
+	created, err := repo.Create(ctx, models.CreateSchoolInput{SchoolNumber: "01Y02", Name: "Lessing-Gymnasium", SchoolType: "Gymnasium"})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	current := *created
+
+	inputType := reflect.TypeOf(models.UpdateSchoolInput{})
+	for _, field := range updateFields(inputType) {
+		name := inputType.Field(field.index).Name
+		var input models.UpdateSchoolInput
+		value := reflect.New(inputType.Field(field.index).Type.Elem())
+		switch v := value.Interface().(type) {
+		case *string:
+			*v = "neu-" + field.column
+			if field.column == "email" {
+				*v = "neu@schule.de"
+			}
+		case *float64:
+			*v = 12.5
+		default:
+			t.Fatalf("field %s: no test value for %T", name, v)
+		}
+		reflect.ValueOf(&input).Elem().Field(field.index).Set(value)
+
+		updated, err := repo.Update(ctx, created.ID, input, current.Version)
+		if err != nil {
+			t.Fatalf("update %s: %v", name, err)
+		}
+		want := applyUpdate(current, input)
+		if diffs := schoolFieldDiffs(*updated, want); len(diffs) > 0 {
+			t.Errorf("update %s: %s", name, strings.Join(diffs, "; "))
+		}
+		current = *updated
+	}
+}
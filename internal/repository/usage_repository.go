@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"context"
+
+	"schools-be/internal/database"
+	"schools-be/internal/errors"
+	"schools-be/internal/models"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type UsageRepository struct {
+	writer *sqlx.DB
+	reader *sqlx.DB
+}
+
+func NewUsageRepository(db *database.DB) *UsageRepository {
+	return &UsageRepository{writer: db.Writer, reader: db.Reader}
+}
+
+// AddUsage adds request, byte and rejection counts to the daily rollups
+func (r *UsageRepository) AddUsage(ctx context.Context, rollups []models.UsageRollup) error {
+	if len(rollups) == 0 {
+		return nil
+	}
+
+	tx, err := r.writer.BeginTxx(ctx, nil)
+	if err != nil {
+		return errors.NewDatabaseError("begin transaction", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PreparexContext(ctx, `
+		INSERT INTO api_usage_daily (day, subject, requests, bytes, rejected)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(day, subject) DO UPDATE SET
+			requests = requests + excluded.requests,
+			bytes = bytes + excluded.bytes,
+			rejected = rejected + excluded.rejected
+	`)
+	if err != nil {
+		return errors.NewDatabaseError("prepare statement", err)
+	}
+	defer stmt.Close()
+
+	for _, rollup := range rollups {
+		if _, err := stmt.ExecContext(ctx, rollup.Day, rollup.Subject, rollup.Requests, rollup.Bytes, rollup.Rejected); err != nil {
+			return errors.NewDatabaseError("add usage", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.NewDatabaseError("commit transaction", err)
+	}
+
+	return nil
+}
+
+// GetDay returns the stored usage of one key on one day (zero counts when there is none)
+func (r *UsageRepository) GetDay(ctx context.Context, day, subject string) (models.UsageRollup, error) {
+	usage := models.UsageRollup{Day: day, Subject: subject}
+	query := `SELECT * FROM api_usage_daily WHERE day = ? AND subject = ?`
+
+	var rows []models.UsageRollup
+	if err := r.reader.SelectContext(ctx, &rows, query, day, subject); err != nil {
+		return usage, errors.NewDatabaseError("get usage", err)
+	}
+	if len(rows) > 0 {
+		usage = rows[0]
+	}
+
+	return usage, nil
+}
+
+// GetRange returns the daily usage between two days (inclusive, YYYY-MM-DD)
+func (r *UsageRepository) GetRange(ctx context.Context, from, to string) ([]models.UsageRollup, error) {
+	var rollups []models.UsageRollup
+	query := `SELECT * FROM api_usage_daily WHERE day BETWEEN ? AND ? ORDER BY day, requests DESC`
+
+	err := r.reader.SelectContext(ctx, &rollups, query, from, to)
+	if err != nil {
+		return nil, errors.NewDatabaseError("get usage rollups", err)
+	}
+
+	return rollups, nil
+}
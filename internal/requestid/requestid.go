@@ -0,0 +1,42 @@
+package requestid
+
+import (
+	"context"
+	"net/http"
+
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
+)
+
+// Header carries the request ID on responses and on outbound calls to ORS and Gemini
+const Header = "X-Request-ID"
+
+// FromContext returns the request ID chi assigned to the inbound request, or "" outside a request
+func FromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	return chimiddleware.GetReqID(ctx)
+}
+
+// Transport adds the request ID from the request context to every outbound call,
+// so a slow user request can be correlated with the external calls it caused
+type Transport struct {
+	Base http.RoundTripper
+}
+
+func (t Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	id := FromContext(req.Context())
+	if id == "" || req.Header.Get(Header) != "" {
+		return base.RoundTrip(req)
+	}
+
+	// RoundTrippers must not modify the caller's request
+	req = req.Clone(req.Context())
+	req.Header.Set(Header, id)
+	return base.RoundTrip(req)
+}
@@ -2,10 +2,18 @@ package scheduler
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log/slog"
+	"runtime/debug"
+	"slices"
+	"strings"
+	"sync"
 	"time"
 
 	"schools-be/internal/config"
+	"schools-be/internal/reporting"
+	"schools-be/internal/repository"
 	"schools-be/internal/service"
 
 	"github.com/robfig/cron/v3"
@@ -15,41 +23,397 @@ type Scheduler struct {
 	cron                *cron.Cron
 	schoolService       *service.SchoolService
 	statisticService    *service.StatisticService
+	applicationService  *service.ApplicationService
+	examResultService   *service.ExamResultService
+	ratingService       *service.ExternalRatingService
 	schoolDetailService *service.SchoolDetailService
+	exportService       *service.ExportService
+	chatService         *service.ChatService
+	routesService       *service.RoutesService
+	leases              *repository.LeaseRepository
+	requests            *repository.JobRequestRepository
 	config              *config.Config
+	reporter            *reporting.Reporter
 	logger              *slog.Logger
+
+	// Whether Start was called; otherwise triggered jobs are queued for a worker
+	started bool
+	// Stops the queue consumers
+	stopConsumers context.CancelFunc
+
+	jobsMu sync.Mutex
+	jobs   map[string]*JobStatus
+}
+
+// Jobs that can be triggered manually
+const (
+	JobRefresh  = "refresh"
+	JobDetails  = "details"
+	JobSnapshot = "snapshot"
+)
+
+var (
+	// ErrJobRunning is returned when triggering a job that is already running
+	ErrJobRunning = errors.New("job is already running")
+
+	// ErrJobUnavailable is returned when triggering a job whose dependency is missing here
+	ErrJobUnavailable = errors.New("job cannot run on this instance")
+)
+
+// JobStatus is the state of a job and the outcome of its last run
+type JobStatus struct {
+	Name           string     `json:"name"`
+	Description    string     `json:"description"`
+	Running        bool       `json:"running"`
+	LastStartedAt  *time.Time `json:"last_started_at,omitempty"`
+	LastFinishedAt *time.Time `json:"last_finished_at,omitempty"`
+	LastError      string     `json:"last_error,omitempty"`
+	Queued         int        `json:"queued,omitempty"`      // Runs waiting for a worker
+	Unavailable    string     `json:"unavailable,omitempty"` // Why the job cannot run on this instance
 }
 
-func New(cfg *config.Config, schoolService *service.SchoolService, statisticService *service.StatisticService, schoolDetailService *service.SchoolDetailService) *Scheduler {
+func New(cfg *config.Config, schoolService *service.SchoolService, statisticService *service.StatisticService, applicationService *service.ApplicationService, examResultService *service.ExamResultService, ratingService *service.ExternalRatingService, schoolDetailService *service.SchoolDetailService, exportService *service.ExportService, chatService *service.ChatService, routesService *service.RoutesService, leases *repository.LeaseRepository, requests *repository.JobRequestRepository, reporter *reporting.Reporter, logger *slog.Logger) *Scheduler {
 	return &Scheduler{
 		cron:                cron.New(),
 		schoolService:       schoolService,
 		statisticService:    statisticService,
+		applicationService:  applicationService,
+		examResultService:   examResultService,
+		ratingService:       ratingService,
 		schoolDetailService: schoolDetailService,
+		exportService:       exportService,
+		chatService:         chatService,
+		routesService:       routesService,
+		leases:              leases,
+		requests:            requests,
 		config:              cfg,
-		logger:              slog.Default(),
+		reporter:            reporter,
+		logger:              logger.With(slog.String("component", "scheduler")),
+		jobs: map[string]*JobStatus{
+			JobRefresh:  {Name: JobRefresh, Description: "Fetch schools, construction projects, application numbers, exam results and external ratings, scrape statistics, rebuild snapshot and export"},
+			JobDetails:  {Name: JobDetails, Description: "Scrape school details (may take several hours)"},
+			JobSnapshot: {Name: JobSnapshot, Description: "Rebuild the enriched snapshot and full export"},
+		},
+	}
+}
+
+// Jobs returns the status of every job, sorted by name. Without a running scheduler, jobs
+// run on a worker and only their queued runs are known here.
+func (s *Scheduler) Jobs() []JobStatus {
+	queued := map[string]int{}
+	if !s.started {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		pending, err := s.requests.GetPending(ctx)
+		if err != nil {
+			s.logger.Warn("failed to load queued jobs", slog.String("error", err.Error()))
+		}
+		for _, request := range pending {
+			queued[request.Job]++
+		}
+	}
+
+	s.jobsMu.Lock()
+	defer s.jobsMu.Unlock()
+
+	statuses := make([]JobStatus, 0, len(s.jobs))
+	for _, job := range s.jobs {
+		status := *job
+		status.Queued = queued[job.Name]
+		statuses = append(statuses, status)
+	}
+	slices.SortFunc(statuses, func(a, b JobStatus) int { return strings.Compare(a.Name, b.Name) })
+	return statuses
+}
+
+// SetUnavailable keeps a job from running on this instance because a dependency, such as
+// the browser of the details scrape, is missing. Triggered runs are still queued for a
+// worker when this instance does not run the scheduler.
+func (s *Scheduler) SetUnavailable(name, reason string) {
+	s.jobsMu.Lock()
+	defer s.jobsMu.Unlock()
+	if job, ok := s.jobs[name]; ok {
+		job.Unavailable = reason
+	}
+}
+
+// Trigger starts a job in the background. When this instance does not run the scheduler
+// (SCHEDULER_ENABLED=false), the job is queued for a worker instead and queued is true.
+func (s *Scheduler) Trigger(name string) (queued bool, err error) {
+	s.jobsMu.Lock()
+	job, ok := s.jobs[name]
+	if !ok {
+		s.jobsMu.Unlock()
+		return false, fmt.Errorf("unknown job: %s", name)
+	}
+	if job.Running {
+		s.jobsMu.Unlock()
+		return false, ErrJobRunning
+	}
+	if s.started && job.Unavailable != "" {
+		s.jobsMu.Unlock()
+		return false, fmt.Errorf("%w: %s", ErrJobUnavailable, job.Unavailable)
+	}
+	s.jobsMu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if !s.started {
+		if err := s.requests.Create(ctx, name); err != nil {
+			return false, err
+		}
+		return true, nil
+	}
+
+	// runJob takes the lease anyway; this only reports a job running elsewhere right away
+	holder, err := s.leases.GetHolder(ctx, name)
+	if err != nil {
+		s.logger.Warn("failed to look up job lease", slog.String("job", name), slog.String("error", err.Error()))
+	}
+	if holder != "" && holder != s.config.InstanceID {
+		return false, fmt.Errorf("%w on instance %s", ErrJobRunning, holder)
+	}
+
+	go s.runJob(name)
+	return false, nil
+}
+
+// runRequestedJobs starts the jobs queued by instances without a scheduler
+func (s *Scheduler) runRequestedJobs() {
+	defer s.recoverPanic("job_requests", nil)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	requests, err := s.requests.Claim(ctx, s.config.InstanceID)
+	if err != nil {
+		s.logger.Error("failed to claim queued jobs", slog.String("error", err.Error()))
+		return
+	}
+	for _, request := range requests {
+		if _, ok := s.jobs[request.Job]; !ok {
+			s.logger.Warn("ignoring queued unknown job", slog.String("job", request.Job))
+			continue
+		}
+		s.logger.Info("starting queued job", slog.String("job", request.Job), slog.Time("requested_at", request.RequestedAt))
+		go s.runJob(request.Job)
+	}
+}
+
+// runJob runs a job unless it is already running, here or on another instance, and records
+// its outcome
+func (s *Scheduler) runJob(name string) {
+	s.jobsMu.Lock()
+	job := s.jobs[name]
+	if job.Running {
+		s.jobsMu.Unlock()
+		s.logger.Warn("skipping job that is already running", slog.String("job", name))
+		return
+	}
+	if job.Unavailable != "" {
+		s.jobsMu.Unlock()
+		s.logger.Warn("skipping job that cannot run on this instance", slog.String("job", name), slog.String("reason", job.Unavailable))
+		return
+	}
+	job.Running = true
+	s.jobsMu.Unlock()
+
+	release, ok := s.lease(name)
+	if !ok {
+		s.jobsMu.Lock()
+		job.Running = false
+		s.jobsMu.Unlock()
+		return
+	}
+	defer release()
+
+	started := time.Now()
+	s.jobsMu.Lock()
+	job.LastStartedAt = &started
+	s.jobsMu.Unlock()
+
+	err := s.execute(name)
+
+	finished := time.Now()
+	s.jobsMu.Lock()
+	job.Running = false
+	job.LastFinishedAt = &finished
+	job.LastError = ""
+	if err != nil {
+		job.LastError = err.Error()
+	}
+	s.jobsMu.Unlock()
+}
+
+// execute runs a job; a panic is reported and returned as the job's error instead of
+// crashing the server
+func (s *Scheduler) execute(name string) (err error) {
+	defer s.recoverPanic(name, &err)
+
+	switch name {
+	case JobRefresh:
+		return s.runFullDataRefresh()
+	case JobDetails:
+		return s.runDetailsScrape()
+	case JobSnapshot:
+		return s.rebuildSnapshot()
+	}
+	return nil
+}
+
+// lease takes the database lease of job for this instance (INSTANCE_ID) and renews it until
+// release is called, so that of several instances sharing the database only one runs the job.
+// ok is false, and the job must be skipped, while another instance holds the lease.
+func (s *Scheduler) lease(job string) (release func(), ok bool) {
+	ttl := s.config.JobLeaseTTL
+	ctx, cancel := context.WithTimeout(context.Background(), ttl)
+	acquired, err := s.leases.Acquire(ctx, job, s.config.InstanceID, ttl)
+	cancel()
+	if err != nil {
+		s.logger.Error("failed to acquire job lease", slog.String("job", job), slog.String("error", err.Error()))
+		return nil, false
+	}
+	if !acquired {
+		s.logger.Info("skipping job that another instance is running", slog.String("job", job))
+		return nil, false
+	}
+
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(ttl / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				ctx, cancel := context.WithTimeout(context.Background(), ttl/3)
+				renewed, err := s.leases.Acquire(ctx, job, s.config.InstanceID, ttl)
+				cancel()
+				if err != nil || !renewed {
+					// The job keeps running; another instance may start it once the lease expired
+					s.logger.Warn("failed to renew job lease", slog.String("job", job), slog.Bool("taken_over", err == nil))
+				}
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-stopped
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := s.leases.Release(ctx, job, s.config.InstanceID); err != nil {
+			s.logger.Warn("failed to release job lease", slog.String("job", job), slog.String("error", err.Error()))
+		}
+	}, true
+}
+
+// runExclusive runs a maintenance task on one instance only; it is skipped on the others
+func (s *Scheduler) runExclusive(name string, task func()) {
+	defer s.recoverPanic(name, nil)
+	release, ok := s.lease(name)
+	if !ok {
+		return
+	}
+	defer release()
+	task()
+}
+
+// recoverPanic must be deferred directly; it reports a panic of a job and stores it in err
+func (s *Scheduler) recoverPanic(job string, err *error) {
+	rec := recover()
+	if rec == nil {
+		return
+	}
+
+	s.reporter.CapturePanic(rec, map[string]string{"job": job})
+	s.logger.Error("job panicked",
+		slog.String("job", job),
+		slog.String("panic", fmt.Sprint(rec)),
+		slog.String("stack", string(debug.Stack())),
+	)
+	if err != nil {
+		*err = fmt.Errorf("panic: %v", rec)
 	}
 }
 
 func (s *Scheduler) Start() {
 	// Schedule full data refresh (all tasks run sequentially)
 	_, err := s.cron.AddFunc(s.config.FetchSchedule, func() {
-		s.runFullDataRefresh()
+		s.runJob(JobRefresh)
 	})
 	if err != nil {
 		s.logger.Error("failed to schedule data refresh job", slog.String("error", err.Error()))
 		return
 	}
 
+	// Delete expired chat sessions (only when the AI service is available)
+	if s.chatService != nil {
+		_, err = s.cron.AddFunc("@hourly", func() {
+			s.runExclusive("chat_cleanup", func() {
+				ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+				defer cancel()
+				if err := s.chatService.CleanupExpired(ctx); err != nil {
+					s.logger.Error("chat session cleanup failed", slog.String("error", err.Error()))
+				}
+			})
+		})
+		if err != nil {
+			s.logger.Error("failed to schedule chat cleanup job", slog.String("error", err.Error()))
+		}
+	}
+
+	// Delete expired travel time cache entries
+	_, err = s.cron.AddFunc("@hourly", func() {
+		s.runExclusive("travel_time_cache_cleanup", func() {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+			defer cancel()
+			deleted, err := s.routesService.PurgeExpiredCache(ctx)
+			if err != nil {
+				s.logger.Error("travel time cache cleanup failed", slog.String("error", err.Error()))
+				return
+			}
+			if deleted > 0 {
+				s.logger.Info("purged expired travel times", slog.Int64("deleted", deleted))
+			}
+		})
+	})
+	if err != nil {
+		s.logger.Error("failed to schedule travel time cache cleanup job", slog.String("error", err.Error()))
+	}
+
+	// Run the jobs triggered on API instances without a scheduler
+	_, err = s.cron.AddFunc("@every "+s.config.JobPollInterval.String(), s.runRequestedJobs)
+	if err != nil {
+		s.logger.Error("failed to schedule queued job polling", slog.String("error", err.Error()))
+	}
+
+	// Scrape the school pages queued by details jobs, also those started on other instances;
+	// without a browser the pages stay queued for an instance that has one
+	ctx, cancel := context.WithCancel(context.Background())
+	s.stopConsumers = cancel
+	s.jobsMu.Lock()
+	detailsUnavailable := s.jobs[JobDetails].Unavailable
+	s.jobsMu.Unlock()
+	if detailsUnavailable == "" {
+		go s.schoolDetailService.ConsumeDetailTasks(ctx)
+	}
+
+	s.started = true
 	s.cron.Start()
 	s.logger.Info("scheduler started",
 		slog.String("refresh_schedule", s.config.FetchSchedule),
+		slog.String("instance_id", s.config.InstanceID),
 	)
 }
 
-// runFullDataRefresh executes all data refresh tasks sequentially
-func (s *Scheduler) runFullDataRefresh() {
+// runFullDataRefresh executes all data refresh tasks sequentially.
+// A failing step does not stop the following ones; the failed steps are returned as the error.
+func (s *Scheduler) runFullDataRefresh() error {
 	startTime := time.Now()
+	var failed []string
 	s.logger.Info("starting full data refresh cycle")
 
 	// Step 1: Fetch schools and construction projects
@@ -59,12 +423,14 @@ func (s *Scheduler) runFullDataRefresh() {
 
 	if err := s.schoolService.FetchAndStoreSchools(ctx1); err != nil {
 		s.logger.Error("schools fetch failed", slog.String("error", err.Error()))
+		failed = append(failed, "schools")
 	} else {
 		s.logger.Info("schools fetch completed")
 	}
 
 	if err := s.schoolService.FetchAndStoreConstructionProjects(ctx1); err != nil {
 		s.logger.Error("construction projects fetch failed", slog.String("error", err.Error()))
+		failed = append(failed, "construction projects")
 	} else {
 		s.logger.Info("construction projects fetch completed")
 	}
@@ -76,21 +442,101 @@ func (s *Scheduler) runFullDataRefresh() {
 
 	if err := s.statisticService.ScrapeAndStoreStatistics(ctx2); err != nil {
 		s.logger.Error("statistics scrape failed", slog.String("error", err.Error()))
+		failed = append(failed, "statistics")
 	} else {
 		s.logger.Info("statistics scrape completed")
 	}
 
+	// Application numbers and exam results are published once a year, so an unconfigured source is not an error
+	if s.applicationService.Enabled() {
+		ctxApplications, cancelApplications := context.WithTimeout(context.Background(), 5*time.Minute)
+		defer cancelApplications()
+		if err := s.applicationService.FetchAndStoreApplications(ctxApplications); err != nil {
+			s.logger.Error("application numbers fetch failed", slog.String("error", err.Error()))
+			failed = append(failed, "applications")
+		} else {
+			s.logger.Info("application numbers fetch completed")
+		}
+	}
+	if s.examResultService.Enabled() {
+		ctxExamResults, cancelExamResults := context.WithTimeout(context.Background(), 5*time.Minute)
+		defer cancelExamResults()
+		if err := s.examResultService.FetchAndStoreExamResults(ctxExamResults); err != nil {
+			s.logger.Error("exam results fetch failed", slog.String("error", err.Error()))
+			failed = append(failed, "exam results")
+		} else {
+			s.logger.Info("exam results fetch completed")
+		}
+	}
+
+	// Ratings older than EXTERNAL_RATINGS_TTL are looked up one school at a time, which takes
+	// a few minutes for all schools
+	if s.ratingService.Enabled() {
+		ctxRatings, cancelRatings := context.WithTimeout(context.Background(), 30*time.Minute)
+		defer cancelRatings()
+		if err := s.ratingService.RefreshRatings(ctxRatings); err != nil {
+			s.logger.Error("external ratings refresh failed", slog.String("error", err.Error()))
+			failed = append(failed, "external ratings")
+		} else {
+			s.logger.Info("external ratings refresh completed")
+		}
+	}
+
 	// Step 3: Scrape school details (longest operation)
 	s.logger.Info("step 3/3: scraping school details (this may take several hours)")
 	s.logger.Warn("school details scraping is disabled")
 
+	// Rebuild the materialized enriched schools snapshot and export from the refreshed tables
+	if err := s.rebuildSnapshot(); err != nil {
+		failed = append(failed, "snapshot")
+	}
+
 	duration := time.Since(startTime)
 	s.logger.Info("full data refresh cycle completed",
 		slog.String("duration", duration.String()),
 	)
+
+	if len(failed) > 0 {
+		return fmt.Errorf("failed steps: %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// rebuildSnapshot rebuilds the enriched schools snapshot and regenerates the full dataset download
+func (s *Scheduler) rebuildSnapshot() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	if err := s.schoolService.RebuildEnrichedSnapshot(ctx); err != nil {
+		s.logger.Error("enriched snapshot rebuild failed", slog.String("error", err.Error()))
+		return err
+	}
+
+	if err := s.exportService.BuildFullExport(ctx); err != nil {
+		s.logger.Error("full dataset export failed", slog.String("error", err.Error()))
+		return err
+	}
+
+	return nil
+}
+
+// runDetailsScrape scrapes and stores school details; it is only run on demand
+func (s *Scheduler) runDetailsScrape() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 6*time.Hour)
+	defer cancel()
+
+	if err := s.schoolDetailService.ScrapeAndStoreDetails(ctx); err != nil {
+		s.logger.Error("school details scrape failed", slog.String("error", err.Error()))
+		return err
+	}
+
+	return nil
 }
 
 func (s *Scheduler) Stop() {
 	s.logger.Info("stopping scheduler")
+	if s.stopConsumers != nil {
+		s.stopConsumers()
+	}
 	s.cron.Stop()
 }
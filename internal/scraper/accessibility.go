@@ -0,0 +1,35 @@
+package scraper
+
+import (
+	"strings"
+
+	"schools-be/internal/models"
+)
+
+// Phrases of the equipment and notes texts stating whether a school is wheelchair accessible.
+// The negative phrases are checked first, since they contain the positive ones ("nicht
+// barrierefrei"); a school that is only partly accessible counts as not accessible.
+var (
+	notAccessiblePhrases = []string{
+		"nicht barrierefrei", "kein barrierefrei", "nicht rollstuhl", "kein aufzug", "keinen aufzug", "kein fahrstuhl",
+		"keinen fahrstuhl", "teilweise barrierefrei", "eingeschränkt barrierefrei", "bedingt barrierefrei",
+	}
+	accessiblePhrases = []string{
+		"barrierefrei", "rollstuhlgerecht", "rollstuhlgeeignet", "rollstuhlzugänglich", "rollstuhlfahrer",
+		"behindertengerecht", "aufzug", "fahrstuhl",
+	}
+)
+
+// parseWheelchairAccessible classifies a school as wheelchair accessible or not by its texts,
+// or as unknown when none of them mentions it
+func parseWheelchairAccessible(texts ...string) string {
+	text := strings.ToLower(strings.Join(texts, "\n"))
+	switch {
+	case containsAny(text, notAccessiblePhrases):
+		return models.AccessibilityNo
+	case containsAny(text, accessiblePhrases):
+		return models.AccessibilityYes
+	default:
+		return models.AccessibilityUnknown
+	}
+}
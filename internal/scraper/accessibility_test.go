@@ -0,0 +1,23 @@
+package scraper
+
+import "testing"
+
+func TestParseWheelchairAccessible(t *testing.T) {
+	tests := []struct {
+		texts []string
+		want  string
+	}{
+		{[]string{"Bibliothek, Sporthalle", ""}, "unknown"},
+		{[]string{"Bibliothek, Aufzug, Sporthalle"}, "yes"},
+		{[]string{"Mensa", "Das Schulgebäude ist barrierefrei."}, "yes"},
+		{[]string{"Rollstuhlgerechte Toiletten"}, "yes"},
+		{[]string{"Das Gebäude ist nicht barrierefrei"}, "no"},
+		{[]string{"Altbau, teilweise barrierefrei, Aufzug im Neubau"}, "no"},
+		{[]string{"Sporthalle", "Leider kein Aufzug vorhanden"}, "no"},
+	}
+	for _, tt := range tests {
+		if got := parseWheelchairAccessible(tt.texts...); got != tt.want {
+			t.Errorf("parseWheelchairAccessible(%q) = %q, want %q", tt.texts, got, tt.want)
+		}
+	}
+}
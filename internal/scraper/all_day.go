@@ -0,0 +1,34 @@
+package scraper
+
+import (
+	"strings"
+
+	"schools-be/internal/models"
+)
+
+// Phrases naming the all-day schooling (Ganztag) form of a school. Bound forms are checked
+// first: a school with a bound all-day branch often offers open care in its other classes too.
+var (
+	boundAllDayPhrases = []string{
+		"gebundener ganztag", "gebundenen ganztag", "gebundene ganztag", "teilgebunden", "voll gebunden",
+		"gebundener form", "gebundenen form",
+	}
+	openAllDayPhrases = []string{
+		"offener ganztag", "offenen ganztag", "offene ganztag", "offener form", "offenen form",
+		"ganztagsbetreuung", "ergänzende förderung und betreuung", "eföb",
+	}
+)
+
+// parseAllDayType classifies the all-day schooling of a school as bound (gebundener Ganztag,
+// compulsory afternoon lessons), open (offener Ganztag, optional afternoon care) or none
+func parseAllDayType(texts ...string) string {
+	text := strings.ToLower(strings.Join(texts, "\n"))
+	switch {
+	case containsAny(text, boundAllDayPhrases):
+		return models.AllDayBound
+	case containsAny(text, openAllDayPhrases):
+		return models.AllDayOpen
+	default:
+		return models.AllDayNone
+	}
+}
@@ -0,0 +1,21 @@
+package scraper
+
+import "testing"
+
+func TestParseAllDayType(t *testing.T) {
+	tests := []struct {
+		texts []string
+		want  string
+	}{
+		{[]string{"Jahrgangsübergreifendes Lernen", ""}, "none"},
+		{[]string{"Offener Ganztag, Jahrgangsübergreifendes Lernen (JüL)"}, "open"},
+		{[]string{"", "Ergänzende Förderung und Betreuung bis 18 Uhr"}, "open"},
+		{[]string{"Gebundener Ganztag, gymnasiale Oberstufe"}, "bound"},
+		{[]string{"Ganztagsschule in teilgebundener Form", "Hort für die Klassen 1 bis 4"}, "bound"},
+	}
+	for _, tt := range tests {
+		if got := parseAllDayType(tt.texts...); got != tt.want {
+			t.Errorf("parseAllDayType(%q) = %q, want %q", tt.texts, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package scraper
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// cacheLockName is the lock file in the cache directory that serializes writers, also
+// across processes such as the API server and a `reparse` run
+const cacheLockName = ".lock"
+
+// writeCacheFile replaces path with data so that readers see either the old or the new
+// content, never a partial file: data goes to a temporary file in the same directory, which
+// is then renamed over path. Writers hold an advisory lock on the cache directory, so two
+// overlapping scrapes do not interleave their updates of the same entry.
+func writeCacheFile(cacheDir, path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create cache subdirectory: %w", err)
+	}
+
+	unlock, err := lockCache(cacheDir)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	// Temporary files do not end in .json, so ReparseCache skips leftovers of a crash
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary cache file: %w", err)
+	}
+	committed := false
+	defer func() {
+		if !committed {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	if _, err := tmp.Write(data); err != nil {
+		return fmt.Errorf("failed to write cache file: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		return fmt.Errorf("failed to write cache file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write cache file: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return fmt.Errorf("failed to write cache file: %w", err)
+	}
+	// Replaces an existing file on all platforms, including Windows
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to replace cache file: %w", err)
+	}
+	committed = true
+	return nil
+}
+
+// lockCache takes the exclusive advisory lock of cacheDir, waiting for other writers
+func lockCache(cacheDir string) (unlock func(), err error) {
+	f, err := os.OpenFile(filepath.Join(cacheDir, cacheLockName), os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open cache lock: %w", err)
+	}
+	if err := lockFile(f); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to lock cache: %w", err)
+	}
+	return func() {
+		unlockFile(f)
+		f.Close()
+	}, nil
+}
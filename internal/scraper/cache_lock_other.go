@@ -0,0 +1,12 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd || windows)
+
+package scraper
+
+import "os"
+
+// Without file locks, writers still never leave a partial cache file behind, since
+// writeCacheFile renames complete files into place
+
+func lockFile(*os.File) error { return nil }
+
+func unlockFile(*os.File) error { return nil }
@@ -0,0 +1,71 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	"schools-be/internal/timeouts"
+
+	"github.com/chromedp/chromedp"
+)
+
+// unsafeKeyChars are replaced in failure capture file names
+var unsafeKeyChars = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+
+// failureKey names the captures of a school page: its IDSchulzweig query parameter, which
+// identifies the school in the directory, or the cache key of the URL
+func (s *SchoolDetailsScraper) failureKey(schoolURL string) string {
+	if u, err := url.Parse(schoolURL); err == nil {
+		if id := unsafeKeyChars.ReplaceAllString(u.Query().Get("IDSchulzweig"), ""); id != "" {
+			return "schulzweig-" + id
+		}
+	}
+	return s.getCacheKey(schoolURL)
+}
+
+// failurePaths returns where the screenshot and HTML of a failed scrape of schoolURL on day go:
+// failures/<date>/<key>.png and .html below the cache directory. A later failure of the same
+// school on the same day replaces them.
+func (s *SchoolDetailsScraper) failurePaths(schoolURL string, day time.Time) (screenshot, html string) {
+	base := filepath.Join(s.failuresDir, day.Format("2006-01-02"), s.failureKey(schoolURL))
+	return base + ".png", base + ".html"
+}
+
+// captureFailure saves a screenshot and the HTML of the page in the browser tab a scrape failed
+// on, so markup changes can be diagnosed without re-running the scraper. The page timeout of the
+// scrape may have run out; tabCtx is the tab itself. Errors are only logged.
+func (s *SchoolDetailsScraper) captureFailure(tabCtx context.Context, schoolURL string) {
+	if tabCtx.Err() != nil {
+		return // Shutting down: the tab is gone
+	}
+	ctx, cancel := timeouts.With(tabCtx, "capture failed page "+schoolURL, timeouts.FailureCapture)
+	defer cancel()
+
+	screenshotPath, htmlPath := s.failurePaths(schoolURL, time.Now())
+	if err := os.MkdirAll(filepath.Dir(screenshotPath), 0755); err != nil {
+		s.logger.Warn("failed to create failures directory", slog.String("error", err.Error()))
+		return
+	}
+
+	var html string
+	if err := chromedp.Run(ctx, chromedp.OuterHTML("html", &html, chromedp.ByQuery)); err != nil {
+		s.logger.Warn("failed to capture page HTML", slog.String("url", schoolURL), slog.String("error", err.Error()))
+	} else if err := os.WriteFile(htmlPath, []byte(fmt.Sprintf("<!-- %s -->\n%s", schoolURL, html)), 0644); err != nil {
+		s.logger.Warn("failed to save page HTML", slog.String("path", htmlPath), slog.String("error", err.Error()))
+	}
+
+	var screenshot []byte
+	if err := chromedp.Run(ctx, chromedp.FullScreenshot(&screenshot, 100)); err != nil {
+		s.logger.Warn("failed to capture screenshot", slog.String("url", schoolURL), slog.String("error", err.Error()))
+	} else if err := os.WriteFile(screenshotPath, screenshot, 0644); err != nil {
+		s.logger.Warn("failed to save screenshot", slog.String("path", screenshotPath), slog.String("error", err.Error()))
+	}
+
+	s.logger.Info("captured failed page", slog.String("url", schoolURL), slog.String("html", htmlPath), slog.String("screenshot", screenshotPath))
+}
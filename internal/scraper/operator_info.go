@@ -0,0 +1,72 @@
+package scraper
+
+import (
+	"math"
+	"regexp"
+	"strings"
+
+	"schools-be/internal/models"
+)
+
+// confessionKeywords recognize the confession of a private school operator by its name, e.g.
+// "Erzbistum Berlin" or "Evangelische Schulstiftung in der EKBO". The first match wins, so
+// specific confessions come before the general "christlich".
+var confessionKeywords = []struct {
+	confession string
+	keywords   []string
+}{
+	{models.ConfessionCatholic, []string{"katholisch", "bistum", "caritas", "jesuit", "ursulin"}},
+	{models.ConfessionProtestant, []string{"evangelisch", "landeskirche", "ekbo", "diakon", "lutherisch"}},
+	{models.ConfessionJewish, []string{"jüdisch", "juedisch"}},
+	{models.ConfessionIslamic, []string{"islamisch", "muslimisch"}},
+	{models.ConfessionChristian, []string{"christlich", "ökumenisch", "oekumenisch", "freikirch"}},
+}
+
+// parseConfession returns the confession of an operator organization, or "" for public and
+// secular operators
+func parseConfession(operator string) string {
+	operator = strings.ToLower(operator)
+	for _, c := range confessionKeywords {
+		for _, keyword := range c.keywords {
+			if strings.Contains(operator, keyword) {
+				return c.confession
+			}
+		}
+	}
+	return ""
+}
+
+// feeAmountPattern matches euro amounts and ranges such as "120 €", "50 bis 350 Euro" or
+// "1.200,50 EUR"; the unit may follow the range only
+var feeAmountPattern = regexp.MustCompile(`(?i)(\d[\d.]*(?:,\d+)?)\s*(?:(?:-|–|bis)\s*(\d[\d.]*(?:,\d+)?)\s*)?(?:€|euro\b|eur\b)`)
+
+// noFeePattern matches fee texts stating that no fee is charged
+var noFeePattern = regexp.MustCompile(`(?i)kein(?:e)?\s+schulgeld|schulgeldfrei|kostenfrei`)
+
+// parseFeeRange returns the lowest and highest euro amount of a fee text, rounded to whole
+// euros; both are 0 for schools charging no fee, and nil when the text has no amount
+func parseFeeRange(info string) (minEUR, maxEUR *int) {
+	var amounts []float64
+	for _, match := range feeAmountPattern.FindAllStringSubmatch(info, -1) {
+		for _, amount := range match[1:] {
+			if val, ok := parseNumber(amount); ok && amount != "" {
+				amounts = append(amounts, val)
+			}
+		}
+	}
+
+	if len(amounts) == 0 {
+		if noFeePattern.MatchString(info) {
+			zero := 0
+			return &zero, &zero
+		}
+		return nil, nil
+	}
+
+	lo, hi := amounts[0], amounts[0]
+	for _, amount := range amounts[1:] {
+		lo, hi = min(lo, amount), max(hi, amount)
+	}
+	lowest, highest := int(math.Round(lo)), int(math.Round(hi))
+	return &lowest, &highest
+}
@@ -0,0 +1,56 @@
+package scraper
+
+import (
+	"fmt"
+	"testing"
+
+	"schools-be/internal/models"
+)
+
+func TestParseConfession(t *testing.T) {
+	tests := []struct {
+		operator string
+		want     string
+	}{
+		{"", ""},
+		{"Erzbistum Berlin", models.ConfessionCatholic},
+		{"Evangelische Schulstiftung in der EKBO", models.ConfessionProtestant},
+		{"Jüdische Gemeinde zu Berlin", models.ConfessionJewish},
+		{"Freie Christliche Schule Berlin e.V.", models.ConfessionChristian},
+		{"Ökumenischer Schulverein", models.ConfessionChristian},
+		{"Phorms Education SE", ""},
+		{"Freie Waldorfschule Berlin-Mitte e.V.", ""},
+	}
+	for _, tt := range tests {
+		if got := parseConfession(tt.operator); got != tt.want {
+			t.Errorf("parseConfession(%q) = %q, want %q", tt.operator, got, tt.want)
+		}
+	}
+}
+
+func TestParseFeeRange(t *testing.T) {
+	format := func(min, max *int) string {
+		if min == nil || max == nil {
+			return fmt.Sprintf("%v-%v", min, max)
+		}
+		return fmt.Sprintf("%d-%d", *min, *max)
+	}
+	tests := []struct {
+		info string
+		want string
+	}{
+		{"", "<nil>-<nil>"},
+		{"einkommensabhängig", "<nil>-<nil>"},
+		{"120 € monatlich", "120-120"},
+		{"einkommensabhängig von 50 bis 350 Euro im Monat", "50-350"},
+		{"Schulgeld: 80 – 1.200 EUR, Geschwisterermäßigung 50 %", "80-1200"},
+		{"Schulgeld 95,50 € zzgl. Hortgebühr 30 €", "30-96"},
+		{"Es wird kein Schulgeld erhoben", "0-0"},
+		{"schulgeldfrei", "0-0"},
+	}
+	for _, tt := range tests {
+		if got := format(parseFeeRange(tt.info)); got != tt.want {
+			t.Errorf("parseFeeRange(%q) = %s, want %s", tt.info, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package scraper
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"schools-be/internal/models"
+)
+
+// programSentence splits the offerings and notes texts into sentences and list items, the
+// scope in which a language or confession has to appear next to its program marker
+var programSentence = regexp.MustCompile(`[^.;\n]+`)
+
+// europaschuleMarkers name the Staatliche Europa-Schule Berlin (SESB), whose classes are taught
+// in German and a partner language; bilingualMarkers name other bilingual branches
+var (
+	europaschuleMarkers = []string{"sesb", "europa-schule", "europaschule"}
+	bilingualMarkers    = append([]string{"bilingual", "zweisprachig"}, europaschuleMarkers...)
+)
+
+// bilingualLanguageWords map the German adjectives of partner languages to their codes, in
+// the order of models.BilingualLanguages
+var bilingualLanguageWords = []struct {
+	language string
+	word     string
+}{
+	{models.LanguageEnglish, "englisch"},
+	{models.LanguageFrench, "französisch"},
+	{models.LanguageSpanish, "spanisch"},
+	{models.LanguageItalian, "italienisch"},
+	{models.LanguagePortuguese, "portugiesisch"},
+	{models.LanguageRussian, "russisch"},
+	{models.LanguagePolish, "polnisch"},
+	{models.LanguageTurkish, "türkisch"},
+	{models.LanguageGreek, "griechisch"},
+}
+
+// religiousEducationPhrases recognize the religious and worldview education offered. The
+// subjects named on their own are matched anywhere; a confession only in a sentence about
+// religious education, since operator names and partners mention confessions too.
+var religiousEducationPhrases = []struct {
+	subject  string
+	anywhere []string
+	withRel  []string
+}{
+	{models.ReligiousEducationCatholic, nil, []string{"katholisch"}},
+	{models.ReligiousEducationProtestant, nil, []string{"evangelisch"}},
+	{models.ReligiousEducationOrthodox, nil, []string{"orthodox"}},
+	{models.ReligiousEducationIslamic, []string{"islamunterricht", "islamkunde"}, []string{"islamisch"}},
+	{models.ReligiousEducationAlevi, nil, []string{"alevitisch"}},
+	{models.ReligiousEducationJewish, nil, []string{"jüdisch"}},
+	{models.ReligiousEducationBuddhist, nil, []string{"buddhistisch"}},
+	{models.ReligiousEducationHumanist, []string{"lebenskunde"}, nil},
+	{models.ReligiousEducationEthics, []string{"ethikunterricht"}, []string{"ethik"}},
+}
+
+// parsePrograms returns whether the school is a Staatliche Europa-Schule, the partner
+// languages of its bilingual branches and its religious education subjects, in the order of
+// models.BilingualLanguages and models.ReligiousEducations
+func parsePrograms(texts ...string) (europaschule bool, languages, religions []string) {
+	text := strings.ToLower(strings.Join(texts, "\n"))
+	sentences := programSentence.FindAllString(text, -1)
+
+	var bilingual, religious []string
+	for _, sentence := range sentences {
+		if containsAny(sentence, europaschuleMarkers) {
+			europaschule = true
+		}
+		if containsAny(sentence, bilingualMarkers) {
+			bilingual = append(bilingual, sentence)
+		}
+		if strings.Contains(sentence, "religion") || strings.Contains(sentence, "ethik") {
+			religious = append(religious, sentence)
+		}
+	}
+
+	bilingualText := strings.Join(bilingual, "\n")
+	for _, l := range bilingualLanguageWords {
+		if strings.Contains(bilingualText, l.word) {
+			languages = append(languages, l.language)
+		}
+	}
+
+	religiousText := strings.Join(religious, "\n")
+	for _, p := range religiousEducationPhrases {
+		if containsAny(text, p.anywhere) || containsAnyWordPrefix(religiousText, p.withRel) {
+			religions = append(religions, p.subject)
+		}
+	}
+
+	return europaschule, languages, religions
+}
+
+// containsAnyWordPrefix reports whether text contains a word starting with one of the
+// prefixes ("katholische", "Ethik" but not "Bioethik")
+func containsAnyWordPrefix(text string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		for offset := 0; ; {
+			i := strings.Index(text[offset:], prefix)
+			if i < 0 {
+				break
+			}
+			start := offset + i
+			if before, _ := utf8.DecodeLastRuneInString(text[:start]); !unicode.IsLetter(before) {
+				return true
+			}
+			offset = start + len(prefix)
+		}
+	}
+	return false
+}
@@ -0,0 +1,56 @@
+package scraper
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParsePrograms(t *testing.T) {
+	tests := []struct {
+		name         string
+		texts        []string
+		europaschule bool
+		languages    []string
+		religions    []string
+	}{
+		{
+			name:  "none",
+			texts: []string{"Begabtenförderung, Schüleraustausch mit Frankreich", "Träger: Evangelische Schulstiftung"},
+		},
+		{
+			name:         "sesb",
+			texts:        []string{"Staatliche Europa-Schule Berlin (SESB) deutsch-französisch; Schüleraustausch"},
+			europaschule: true,
+			languages:    []string{"fr"},
+		},
+		{
+			name:      "bilingual branch",
+			texts:     []string{"Bilingualer Zug Englisch ab Klasse 7; Spanisch als dritte Fremdsprache"},
+			languages: []string{"en"},
+		},
+		{
+			name:      "religion and ethics",
+			texts:     []string{"Religionsunterricht: katholisch, evangelisch und islamisch", "Humanistische Lebenskunde. Ethik ab Klasse 7, Bioethik-AG"},
+			religions: []string{"catholic", "protestant", "islamic", "humanist", "ethics"},
+		},
+		{
+			name:      "bioethics only",
+			texts:     []string{"Bioethik im Biologieunterricht"},
+			religions: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			europaschule, languages, religions := parsePrograms(tt.texts...)
+			if europaschule != tt.europaschule {
+				t.Errorf("europaschule = %v, want %v", europaschule, tt.europaschule)
+			}
+			if !slices.Equal(languages, tt.languages) {
+				t.Errorf("languages = %q, want %q", languages, tt.languages)
+			}
+			if !slices.Equal(religions, tt.religions) {
+				t.Errorf("religions = %q, want %q", religions, tt.religions)
+			}
+		})
+	}
+}
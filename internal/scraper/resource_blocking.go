@@ -0,0 +1,49 @@
+package scraper
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/fetch"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+)
+
+// blockedRequests are the requests a school page does not need for scraping: images, fonts and
+// media, and analytics scripts. Stylesheets are kept, since visibility checks depend on them.
+var blockedRequests = []*fetch.RequestPattern{
+	{ResourceType: network.ResourceTypeImage},
+	{ResourceType: network.ResourceTypeFont},
+	{ResourceType: network.ResourceTypeMedia},
+	{URLPattern: "*google-analytics.com*"},
+	{URLPattern: "*googletagmanager.com*"},
+	{URLPattern: "*matomo*"},
+	{URLPattern: "*piwik*"},
+	{URLPattern: "*etracker*"},
+}
+
+// blockResources makes the browser tab of ctx fail the blockedRequests instead of loading them,
+// which roughly halves the load time and bandwidth of a school page. Only blocked requests are
+// intercepted, so every paused request is failed.
+func (s *SchoolDetailsScraper) blockResources(ctx context.Context) chromedp.Action {
+	chromedp.ListenTarget(ctx, func(ev interface{}) {
+		paused, ok := ev.(*fetch.EventRequestPaused)
+		if !ok {
+			return
+		}
+		// Listeners must not block; answer the browser from another goroutine
+		go func() {
+			c := chromedp.FromContext(ctx)
+			if c == nil || c.Target == nil {
+				return
+			}
+			err := fetch.FailRequest(paused.RequestID, network.ErrorReasonBlockedByClient).Do(cdp.WithExecutor(ctx, c.Target))
+			if err != nil && ctx.Err() == nil {
+				s.logger.Debug("failed to block request", slog.String("url", paused.Request.URL), slog.String("error", err.Error()))
+			}
+		}()
+	})
+
+	return fetch.Enable().WithPatterns(blockedRequests)
+}
@@ -0,0 +1,197 @@
+package scraper
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"schools-be/internal/models"
+)
+
+// Rules are the header and label texts the scrapers recognize in the scraped tables. The
+// built-in rules (DefaultRules) can be adapted to label changes on the source pages with a
+// rules file (NORMALIZATION_RULES), without a code change.
+type Rules struct {
+	StatisticsColumns    StatisticsColumns `yaml:"statistics_columns"`
+	CitizenshipTotalRows Match             `yaml:"citizenship_total_rows"` // The row of the citizenship table summing up the others
+	DiverseColumns       Match             `yaml:"diverse_columns"`        // The "divers" column of m/w/d breakdowns
+	ResidenceTotalRows   Match             `yaml:"residence_total_rows"`   // Rows of the residence table summing up the others; skipped
+	AbsenceRows          AbsenceRows       `yaml:"absence_rows"`
+	StaffingCoverageRows Match             `yaml:"staffing_coverage_rows"` // The row of the staffing table with the coverage in %
+	QualityRatings       QualityRatings    `yaml:"quality_ratings"`
+}
+
+// Match recognizes a header or label, ignoring case and surrounding space: it matches when
+// the text equals one of Equals or contains one of Contains, and contains none of Except
+type Match struct {
+	Equals   []string `yaml:"equals,omitempty"`
+	Contains []string `yaml:"contains,omitempty"`
+	Except   []string `yaml:"except,omitempty"`
+}
+
+// StatisticsColumns are the columns of the statistics page, by the field they fill. Headers are
+// tried against the fields in this order.
+type StatisticsColumns struct {
+	SchoolNumber    Match `yaml:"school_number"`
+	SchoolName      Match `yaml:"school_name"`
+	SchoolYear      Match `yaml:"school_year"`
+	Students        Match `yaml:"students"`
+	StudentsFemale  Match `yaml:"students_female"`
+	StudentsMale    Match `yaml:"students_male"`
+	StudentsDiverse Match `yaml:"students_diverse"`
+	Teachers        Match `yaml:"teachers"`
+	TeachersFemale  Match `yaml:"teachers_female"`
+	TeachersMale    Match `yaml:"teachers_male"`
+	TeachersDiverse Match `yaml:"teachers_diverse"`
+	District        Match `yaml:"district"`
+	SchoolType      Match `yaml:"school_type"`
+	Classes         Match `yaml:"classes"`
+}
+
+// AbsenceRows are the rows of the absence table, by the level the rates are for
+type AbsenceRows struct {
+	School     Match `yaml:"school"`
+	SchoolType Match `yaml:"school_type"`
+	Region     Match `yaml:"region"`
+	Berlin     Match `yaml:"berlin"`
+}
+
+// QualityRatings are the rating cells of the quality profile, by the rating they stand for
+type QualityRatings struct {
+	A Match `yaml:"a"`
+	B Match `yaml:"b"`
+	C Match `yaml:"c"`
+	D Match `yaml:"d"`
+}
+
+// DefaultRules returns the rules matching the source pages as of this version
+func DefaultRules() *Rules {
+	return &Rules{
+		StatisticsColumns: StatisticsColumns{
+			SchoolNumber:    Match{Equals: []string{"bsn"}},
+			SchoolName:      Match{Equals: []string{"name"}},
+			SchoolYear:      Match{Equals: []string{"schuljahr"}},
+			Students:        Match{Contains: []string{"schüler (m/w/d)", "schueler (m/w/d)"}},
+			StudentsFemale:  Match{Contains: []string{"schüler (w)", "schueler (w)"}},
+			StudentsMale:    Match{Contains: []string{"schüler (m)", "schueler (m)"}},
+			StudentsDiverse: Match{Contains: []string{"schüler (d)", "schueler (d)"}},
+			Teachers:        Match{Contains: []string{"lehrkräfte (m,w,d)", "lehrkraefte (m,w,d)"}},
+			TeachersFemale:  Match{Contains: []string{"lehrkräfte (w)", "lehrkraefte (w)"}},
+			TeachersMale:    Match{Contains: []string{"lehrkräfte (m)", "lehrkraefte (m)"}},
+			TeachersDiverse: Match{Contains: []string{"lehrkräfte (d)", "lehrkraefte (d)"}},
+			District:        Match{Equals: []string{"bezirk", "district"}},
+			SchoolType:      Match{Equals: []string{"schulart", "school type"}},
+			Classes:         Match{Equals: []string{"klassen", "classes"}},
+		},
+		CitizenshipTotalRows: Match{Equals: []string{"insgesamt"}},
+		DiverseColumns:       Match{Contains: []string{"divers", "(d)"}},
+		ResidenceTotalRows:   Match{Equals: []string{"insgesamt"}},
+		AbsenceRows: AbsenceRows{
+			School:     Match{Contains: []string{"schule"}, Except: []string{"schulart"}},
+			SchoolType: Match{Contains: []string{"schulart"}},
+			Region:     Match{Contains: []string{"region"}},
+			Berlin:     Match{Contains: []string{"berlin"}},
+		},
+		StaffingCoverageRows: Match{Contains: []string{"ausstattung"}},
+		// Equals only: "schwach ausgeprägt" is part of "eher schwach ausgeprägt"
+		QualityRatings: QualityRatings{
+			A: Match{Equals: []string{"a", "stark ausgeprägt"}},
+			B: Match{Equals: []string{"b", "eher stark ausgeprägt"}},
+			C: Match{Equals: []string{"c", "eher schwach ausgeprägt"}},
+			D: Match{Equals: []string{"d", "schwach ausgeprägt"}},
+		},
+	}
+}
+
+// Validate reports rules that can never match, which would silently drop a column or row
+func (r *Rules) Validate() error {
+	var empty []string
+	for _, group := range []struct {
+		name  string
+		value interface{}
+	}{
+		{"statistics_columns", r.StatisticsColumns},
+		{"absence_rows", r.AbsenceRows},
+		{"quality_ratings", r.QualityRatings},
+	} {
+		v := reflect.ValueOf(group.value)
+		for i := 0; i < v.NumField(); i++ {
+			if m := v.Field(i).Interface().(Match); len(m.Equals) == 0 && len(m.Contains) == 0 {
+				empty = append(empty, group.name+"."+v.Type().Field(i).Tag.Get("yaml"))
+			}
+		}
+	}
+	if len(empty) > 0 {
+		return fmt.Errorf("rules without equals or contains patterns: %s", strings.Join(empty, ", "))
+	}
+	return nil
+}
+
+func (m Match) matches(text string) bool {
+	text = strings.ToLower(strings.TrimSpace(text))
+	for _, except := range m.Except {
+		if strings.Contains(text, strings.ToLower(except)) {
+			return false
+		}
+	}
+	for _, equals := range m.Equals {
+		if text == strings.ToLower(equals) {
+			return true
+		}
+	}
+	for _, contains := range m.Contains {
+		if strings.Contains(text, strings.ToLower(contains)) {
+			return true
+		}
+	}
+	return false
+}
+
+// statisticsField returns the field of stat the column with header fills, or nil for columns
+// that are only kept in the metadata
+func (r *Rules) statisticsField(stat *models.StatisticData, header string) *string {
+	c := &r.StatisticsColumns
+	for _, column := range []struct {
+		match Match
+		field *string
+	}{
+		{c.SchoolNumber, &stat.SchoolNumber},
+		{c.SchoolName, &stat.SchoolName},
+		{c.SchoolYear, &stat.SchoolYear},
+		{c.Students, &stat.Students},
+		{c.StudentsFemale, &stat.StudentsFemale},
+		{c.StudentsMale, &stat.StudentsMale},
+		{c.StudentsDiverse, &stat.StudentsDiverse},
+		{c.Teachers, &stat.Teachers},
+		{c.TeachersFemale, &stat.TeachersFemale},
+		{c.TeachersMale, &stat.TeachersMale},
+		{c.TeachersDiverse, &stat.TeachersDiverse},
+		{c.District, &stat.District},
+		{c.SchoolType, &stat.SchoolType},
+		{c.Classes, &stat.Classes},
+	} {
+		if column.match.matches(header) {
+			return column.field
+		}
+	}
+	return nil
+}
+
+// qualityRating returns the rating a cell of the quality profile stands for, or "" for none
+func (r *Rules) qualityRating(cell string) string {
+	q := &r.QualityRatings
+	for _, rating := range []struct {
+		match Match
+		value string
+	}{
+		{q.A, models.QualityRatingA},
+		{q.B, models.QualityRatingB},
+		{q.C, models.QualityRatingC},
+		{q.D, models.QualityRatingD},
+	} {
+		if rating.match.matches(cell) {
+			return rating.value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,100 @@
+package scraper
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"schools-be/internal/models"
+)
+
+func TestMatch(t *testing.T) {
+	absence := DefaultRules().AbsenceRows
+	tests := []struct {
+		name  string
+		match Match
+		text  string
+		want  bool
+	}{
+		{"equals ignores case and space", Match{Equals: []string{"bsn"}}, "  BSN ", true},
+		{"equals is not contains", Match{Equals: []string{"name"}}, "Schulname", false},
+		{"contains", absence.SchoolType, "Schulart (ISS)", true},
+		{"except wins", absence.School, "Durchschnitt Schulart", false},
+		{"school row", absence.School, "Schule", true},
+		{"empty matches nothing", Match{}, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.match.matches(tt.text); got != tt.want {
+				t.Errorf("matches(%q) = %v, want %v", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStatisticsField(t *testing.T) {
+	rules := DefaultRules()
+	var stat models.StatisticData
+	for header, value := range map[string]string{
+		"BSN":                 "01Y02",
+		"Schüler (m/w/d)":     "864",
+		"Schueler (w)":        "414",
+		"Lehrkräfte (m,w,d)":  "61",
+		"Bezirk":              "Mitte",
+		"Anzahl Schüler (m)":  "450",
+		"Schüler (d)":         "2",
+		"Lehrkräfte (d)":      "1",
+		"Something unrelated": "x",
+	} {
+		if field := rules.statisticsField(&stat, header); field != nil {
+			*field = value
+		}
+	}
+	want := models.StatisticData{SchoolNumber: "01Y02", Students: "864", StudentsFemale: "414", StudentsMale: "450", StudentsDiverse: "2", Teachers: "61", TeachersDiverse: "1", District: "Mitte"}
+	if !reflect.DeepEqual(stat, want) {
+		t.Errorf("stat = %+v, want %+v", stat, want)
+	}
+}
+
+func TestNormalizeAbsenceTableRules(t *testing.T) {
+	table := &models.StatisticTable{Rows: [][]string{
+		{"Einrichtung", "6,1", "1,2"},
+		{"Schulart", "7,0", "1,5"},
+		{"Bezirk", "7,5", "1,8"},
+		{"Land", "8,9", "2,0"},
+	}}
+
+	s := newTestScraper()
+	if stat := s.NormalizeAbsenceTable("01Y02", table, time.Now()); stat.SchoolAbsenceRate != nil {
+		t.Errorf("default rules matched renamed school row: %+v", stat)
+	}
+
+	s.rules.AbsenceRows.School = Match{Equals: []string{"einrichtung"}}
+	s.rules.AbsenceRows.Region = Match{Equals: []string{"bezirk"}}
+	s.rules.AbsenceRows.Berlin = Match{Equals: []string{"land"}}
+	stat := s.NormalizeAbsenceTable("01Y02", table, time.Now())
+	for name, got := range map[string]*float64{
+		"school":      stat.SchoolAbsenceRate,
+		"school type": stat.SchoolTypeAbsenceRate,
+		"region":      stat.RegionAbsenceRate,
+		"berlin":      stat.BerlinAbsenceRate,
+	} {
+		if got == nil {
+			t.Errorf("%s rate not matched", name)
+		}
+	}
+}
+
+func TestRulesValidate(t *testing.T) {
+	if err := DefaultRules().Validate(); err != nil {
+		t.Fatalf("default rules: %v", err)
+	}
+
+	rules := DefaultRules()
+	rules.StatisticsColumns.SchoolNumber = Match{Except: []string{"x"}}
+	err := rules.Validate()
+	if err == nil || !strings.Contains(err.Error(), "statistics_columns.school_number") {
+		t.Errorf("err = %v, want statistics_columns.school_number", err)
+	}
+}
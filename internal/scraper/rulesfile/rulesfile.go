@@ -0,0 +1,39 @@
+// Package rulesfile loads the scraper normalization rules from a YAML file (NORMALIZATION_RULES)
+package rulesfile
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"gopkg.in/yaml.v3"
+
+	"schools-be/internal/scraper"
+)
+
+// Load returns the built-in rules overridden by the file at path; keys the file leaves out keep
+// their built-in value. An empty path returns the built-in rules.
+func Load(path string) (*scraper.Rules, error) {
+	rules := scraper.DefaultRules()
+	if path == "" {
+		return rules, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open normalization rules: %w", err)
+	}
+	defer f.Close()
+
+	dec := yaml.NewDecoder(f)
+	dec.KnownFields(true) // A misspelled key would otherwise silently keep the built-in rule
+	if err := dec.Decode(rules); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to parse normalization rules %s: %w", path, err)
+	}
+
+	if err := rules.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid normalization rules %s: %w", path, err)
+	}
+	return rules, nil
+}
@@ -5,7 +5,9 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io/fs"
 	"log/slog"
 	"os"
 	"path/filepath"
@@ -13,6 +15,7 @@ import (
 	"time"
 
 	"schools-be/internal/models"
+	"schools-be/internal/timeouts"
 
 	"golang.org/x/net/html"
 
@@ -21,35 +24,58 @@ import (
 
 const (
 	berlinSchoolListURL = "https://www.bildung.berlin.de/Schulverzeichnis/SchulListe.aspx"
-	cacheDir            = "./cache/school-details"
+
+	// ParserVersion is stamped on every scraped record. Bump it whenever parseTableHTML or the
+	// field extraction in parseDetail changes, so cached pages can be re-parsed with `reparse`.
+	ParserVersion = 9
+)
+
+// Titles of the statistics tabs on a school page
+const (
+	citizenshipTabTitle = "Staatsangehörigkeit"
+	languageTabTitle    = "Nichtdeutsche Herkunftssprache"
+	residenceTabTitle   = "Wohnorte"
+	absenceTabTitle     = "Fehlzeiten"
+	gradesTabTitle      = "Jahrgangsstufen"     // Classes (Züge) per grade and school year
+	staffingTabTitle    = "Personalausstattung" // Teachers by qualification and staffing coverage, in the personnel section
+	qualityTabTitle     = "Qualitätsprofil"     // Quality framework ratings, in the school inspection section
 )
 
 // SchoolDetailsScraper handles scraping detailed school information
 type SchoolDetailsScraper struct {
-	logger   *slog.Logger
-	cacheDir string
-	useCache bool
+	logger      *slog.Logger
+	cacheDir    string
+	failuresDir string // Screenshots and HTML of pages that failed to scrape
+	useCache    bool
+	rules       *Rules
 }
 
-// NewSchoolDetailsScraper creates a new school details scraper
-func NewSchoolDetailsScraper() *SchoolDetailsScraper {
+// NewSchoolDetailsScraper creates a new school details scraper caching pages in the
+// school-details directory below cacheDir (CACHE_DIR), capturing failed pages in its failures
+// directory and normalizing tables by rules
+func NewSchoolDetailsScraper(cacheDir string, rules *Rules, logger *slog.Logger) *SchoolDetailsScraper {
 	return &SchoolDetailsScraper{
-		logger:   slog.Default(),
-		cacheDir: cacheDir,
-		useCache: true,
+		logger:      logger.With(slog.String("scraper", "details")), // LOG_DEBUG=scraper shows every page step
+		cacheDir:    filepath.Join(cacheDir, "school-details"),
+		failuresDir: filepath.Join(cacheDir, "failures"),
+		useCache:    true,
+		rules:       rules,
 	}
 }
 
 // NewSchoolDetailsScraperWithCache creates a new school details scraper with cache control
-func NewSchoolDetailsScraperWithCache(useCache bool) *SchoolDetailsScraper {
-	scraper := NewSchoolDetailsScraper()
+func NewSchoolDetailsScraperWithCache(cacheDir string, useCache bool, rules *Rules, logger *slog.Logger) *SchoolDetailsScraper {
+	scraper := NewSchoolDetailsScraper(cacheDir, rules, logger)
 	scraper.useCache = useCache
 	return scraper
 }
 
 // ensureCacheDir creates the cache directory if it doesn't exist
 func (s *SchoolDetailsScraper) ensureCacheDir() error {
-	return os.MkdirAll(s.cacheDir, 0755)
+	if err := os.MkdirAll(s.cacheDir, 0755); err != nil {
+		return fmt.Errorf("failed to create cache directory %s (set CACHE_DIR to a writable directory): %w", s.cacheDir, err)
+	}
+	return nil
 }
 
 // getCacheKey generates a cache key (filename) for a given URL
@@ -89,22 +115,48 @@ func (s *SchoolDetailsScraper) loadFromCache(url string) (*models.SchoolDetailDa
 		return nil, false
 	}
 
-	s.logger.Info("loaded from cache", slog.String("url", url))
+	if details.ParserVersion < ParserVersion && details.RawPage != nil {
+		s.parseDetail(&details)
+		if err := s.saveToCache(url, &details); err != nil {
+			s.logger.Warn("failed to update re-parsed cache entry",
+				slog.String("cache_path", cachePath),
+				slog.String("error", err.Error()),
+			)
+		}
+	}
+
+	s.logger.Debug("loaded from cache", slog.String("url", url))
 	return &details, true
 }
 
-// saveToCache saves scraped data to cache
-func (s *SchoolDetailsScraper) saveToCache(url string, details *models.SchoolDetailData) error {
+// CacheInfo identifies the school on a cached page and when it was scraped
+type CacheInfo struct {
+	SchoolNumber string    `json:"school_number"`
+	ScrapedAt    time.Time `json:"scraped_at"`
+}
+
+// CacheInfo returns the school and scrape time of the page at url from the cache; ok is false
+// when the page is not cached
+func (s *SchoolDetailsScraper) CacheInfo(url string) (info CacheInfo, ok bool) {
 	if !s.useCache {
-		return nil
+		return CacheInfo{}, false
 	}
 
-	cachePath := s.getCachePath(url)
+	data, err := os.ReadFile(s.getCachePath(url))
+	if err != nil {
+		return CacheInfo{}, false
+	}
 
-	// Ensure the subdirectory exists
-	cacheSubdir := filepath.Dir(cachePath)
-	if err := os.MkdirAll(cacheSubdir, 0755); err != nil {
-		return fmt.Errorf("failed to create cache subdirectory: %w", err)
+	if err := json.Unmarshal(data, &info); err != nil {
+		return CacheInfo{}, false
+	}
+	return info, true
+}
+
+// saveToCache saves scraped data to cache
+func (s *SchoolDetailsScraper) saveToCache(url string, details *models.SchoolDetailData) error {
+	if !s.useCache {
+		return nil
 	}
 
 	data, err := json.MarshalIndent(details, "", "  ")
@@ -112,11 +164,7 @@ func (s *SchoolDetailsScraper) saveToCache(url string, details *models.SchoolDet
 		return fmt.Errorf("failed to marshal data: %w", err)
 	}
 
-	if err := os.WriteFile(cachePath, data, 0644); err != nil {
-		return fmt.Errorf("failed to write cache file: %w", err)
-	}
-
-	return nil
+	return writeCacheFile(s.cacheDir, s.getCachePath(url), data)
 }
 
 // ClearCache removes all cached data
@@ -128,83 +176,125 @@ func (s *SchoolDetailsScraper) ClearCache() error {
 	return nil
 }
 
-// ScrapeSchoolDetails scrapes detailed information for all schools
-func (s *SchoolDetailsScraper) ScrapeSchoolDetails(ctx context.Context) ([]models.SchoolDetailData, error) {
-	s.logger.Info("starting school details scrape", slog.String("url", berlinSchoolListURL))
+// ReparseCache re-runs parsing over the raw pages stored in the cache without touching the live site.
+// Records already produced by the current ParserVersion are skipped unless force is set; records
+// cached before raw pages were kept cannot be re-parsed and are skipped as well.
+// Returns the re-parsed records; their cache files are rewritten with the new version.
+func (s *SchoolDetailsScraper) ReparseCache(force bool) ([]models.SchoolDetailData, error) {
+	var reparsed []models.SchoolDetailData
+	skipped := 0
+	noRaw := 0
 
-	// Ensure cache directory exists
-	if err := s.ensureCacheDir(); err != nil {
-		s.logger.Warn("failed to create cache directory", slog.String("error", err.Error()))
+	err := filepath.WalkDir(s.cacheDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || filepath.Ext(path) != ".json" {
+			return nil
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read cache file %s: %w", path, err)
+		}
+
+		var details models.SchoolDetailData
+		if err := json.Unmarshal(data, &details); err != nil {
+			s.logger.Warn("failed to unmarshal cached data",
+				slog.String("cache_path", path),
+				slog.String("error", err.Error()),
+			)
+			return nil
+		}
+
+		if details.RawPage == nil {
+			noRaw++
+			return nil
+		}
+		if !force && details.ParserVersion >= ParserVersion {
+			skipped++
+			return nil
+		}
+
+		s.parseDetail(&details)
+
+		updated, err := json.MarshalIndent(&details, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal data: %w", err)
+		}
+		if err := writeCacheFile(s.cacheDir, path, updated); err != nil {
+			return err
+		}
+
+		reparsed = append(reparsed, details)
+		return nil
+	})
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("failed to walk cache: %w", err)
 	}
 
-	// Create chrome context
+	s.logger.Info("cache re-parse complete",
+		slog.Int("parser_version", ParserVersion),
+		slog.Int("reparsed", len(reparsed)),
+		slog.Int("up_to_date", skipped),
+		slog.Int("without_raw_page", noRaw),
+	)
+	return reparsed, nil
+}
+
+// SchoolLinks returns the URLs of all school detail pages
+func (s *SchoolDetailsScraper) SchoolLinks(ctx context.Context) ([]string, error) {
+	s.logger.Info("loading school list", slog.String("url", berlinSchoolListURL))
+
+	// Create chrome context and start the browser on it; a browser started under the page
+	// timeout of getSchoolLinks would be closed together with that timeout
 	allocCtx, cancel := chromedp.NewContext(ctx)
 	defer cancel()
+	if err := chromedp.Run(allocCtx); err != nil {
+		return nil, fmt.Errorf("failed to start browser: %w", err)
+	}
 
-	// Get list of school URLs
-	schoolLinks, err := s.getSchoolLinks(allocCtx)
+	links, err := s.getSchoolLinks(allocCtx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get school links: %w", err)
 	}
 
-	s.logger.Info("found schools", slog.Int("count", len(schoolLinks)))
-
-	// Scrape each school's details
-	var allDetails []models.SchoolDetailData
-	cachedCount := 0
-	scrapedCount := 0
-
-	for i, link := range schoolLinks {
-		s.logger.Info("processing school",
-			slog.Int("index", i+1),
-			slog.Int("total", len(schoolLinks)),
-			slog.String("url", link),
-		)
-
-		// Try to load from cache first
-		if cachedDetails, found := s.loadFromCache(link); found {
-			allDetails = append(allDetails, *cachedDetails)
-			cachedCount++
-			continue
-		}
-
-		// Not in cache, scrape it
-		details, err := s.ScrapeSchoolDetail(ctx, link)
-		if err != nil {
-			s.logger.Error("failed to scrape school",
-				slog.String("url", link),
-				slog.String("error", err.Error()),
-			)
-			continue
-		}
+	s.logger.Info("found schools", slog.Int("count", len(links)))
+	return links, nil
+}
 
-		// Save to cache
-		if err := s.saveToCache(link, details); err != nil {
-			s.logger.Warn("failed to save to cache",
-				slog.String("url", link),
-				slog.String("error", err.Error()),
-			)
-		}
+// ScrapeSchool returns the details of one school page, from the cache when it has them and
+// otherwise scraped and then cached; cached reports which
+func (s *SchoolDetailsScraper) ScrapeSchool(ctx context.Context, schoolURL string) (details *models.SchoolDetailData, cached bool, err error) {
+	if details, found := s.loadFromCache(schoolURL); found {
+		return details, true, nil
+	}
 
-		allDetails = append(allDetails, *details)
-		scrapedCount++
+	if err := s.ensureCacheDir(); err != nil {
+		return nil, false, err
+	}
 
-		// Be respectful to the server (only when scraping, not when using cache)
-		time.Sleep(2 * time.Second)
+	details, err = s.ScrapeSchoolDetail(ctx, schoolURL)
+	if err != nil {
+		scrapeFailures.Inc("details", FailureKind(err))
+		return nil, false, err
 	}
 
-	s.logger.Info("scraping complete",
-		slog.Int("total", len(allDetails)),
-		slog.Int("from_cache", cachedCount),
-		slog.Int("newly_scraped", scrapedCount),
-	)
-	return allDetails, nil
+	if err := s.saveToCache(schoolURL, details); err != nil {
+		s.logger.Warn("failed to save to cache",
+			slog.String("url", schoolURL),
+			slog.String("error", err.Error()),
+		)
+	}
+	return details, false, nil
 }
 
 // getSchoolLinks gets all school detail page URLs from the main list
 func (s *SchoolDetailsScraper) getSchoolLinks(ctx context.Context) ([]string, error) {
-	var links []string
+	ctx, cancel := timeouts.With(ctx, "load school list", timeouts.PageScrape)
+	defer cancel()
 
+	var links []string
 	err := chromedp.Run(ctx,
 		chromedp.Navigate(berlinSchoolListURL),
 		chromedp.WaitVisible(`#DataListSchulen`, chromedp.ByQuery),
@@ -215,43 +305,59 @@ func (s *SchoolDetailsScraper) getSchoolLinks(ctx context.Context) ([]string, er
 	)
 
 	if err != nil {
+		if ctx.Err() != nil {
+			err = context.Cause(ctx)
+		}
 		return nil, fmt.Errorf("failed to extract school links: %w", err)
 	}
 
 	return links, nil
 }
 
-// ScrapeSchoolDetail scrapes detailed information for a single school
-func (s *SchoolDetailsScraper) ScrapeSchoolDetail(ctx context.Context, schoolURL string) (*models.SchoolDetailData, error) {
+// ScrapeSchoolDetail scrapes detailed information for a single school. When it fails, the page
+// is captured for diagnosis (see captureFailure).
+func (s *SchoolDetailsScraper) ScrapeSchoolDetail(ctx context.Context, schoolURL string) (details *models.SchoolDetailData, err error) {
 	// Create new context for this school
 	allocCtx, cancel := chromedp.NewContext(ctx)
 	defer cancel()
+	defer func() {
+		if err != nil {
+			s.captureFailure(allocCtx, schoolURL)
+		}
+	}()
 
 	// Add timeout for this school
-	timeoutCtx, timeoutCancel := context.WithTimeout(allocCtx, 2*time.Minute)
+	timeoutCtx, timeoutCancel := timeouts.With(allocCtx, "scrape "+schoolURL, timeouts.PageScrape)
 	defer timeoutCancel()
 
-	details := &models.SchoolDetailData{
+	details = &models.SchoolDetailData{
 		SchoolURL: schoolURL,
 		ScrapedAt: time.Now(),
+		RawPage: &models.RawSchoolPage{
+			StatisticTablesHTML: make(map[string]string),
+		},
 	}
 
-	// Variable to hold the full school name with number
-	var schoolNameWithNumber string
-
-	// Navigate to school page and extract basic info
-	err := chromedp.Run(timeoutCtx,
+	// Navigate to school page, without images, fonts and analytics
+	err = chromedp.Run(timeoutCtx,
+		s.blockResources(allocCtx),
 		chromedp.Navigate(schoolURL),
 		chromedp.WaitVisible(`body`, chromedp.ByQuery),
 		chromedp.Sleep(1*time.Second),
+	)
+	if err != nil {
+		return nil, newScrapeError(timeoutCtx, models.ScrapeFailureNavigation, fmt.Errorf("failed to load page: %w", err))
+	}
 
+	// Extract basic info
+	err = chromedp.Run(timeoutCtx,
 		// Extract school name with number (e.g., "Georg-Friedrich-Händel-Gymnasium - 02Y04")
 		chromedp.Evaluate(`
 			(function() {
 				var el = document.getElementById('ContentPlaceHolderMenuListe_lblSchulname');
 				return el ? el.textContent.trim() : '';
 			})()
-		`, &schoolNameWithNumber),
+		`, &details.RawPage.SchoolNameWithNumber),
 
 		// Extract languages
 		chromedp.Evaluate(`
@@ -332,28 +438,80 @@ func (s *SchoolDetailsScraper) ScrapeSchoolDetail(ctx context.Context, schoolURL
 				return el ? el.textContent.trim() : '';
 			})()
 		`, &details.DualLearning),
+
+		// Extract operator organization (private schools only)
+		chromedp.Evaluate(`
+			(function() {
+				var el = document.getElementById('ContentPlaceHolderMenuListe_lblTraeger');
+				return el ? el.textContent.trim() : '';
+			})()
+		`, &details.OperatorOrganization),
+
+		// Extract school fees (private schools only)
+		chromedp.Evaluate(`
+			(function() {
+				var el = document.getElementById('ContentPlaceHolderMenuListe_lblSchulgeld');
+				return el ? el.textContent.trim() : '';
+			})()
+		`, &details.FeeInfo),
 	)
 
 	if err != nil {
-		return nil, fmt.Errorf("failed to extract basic info: %w", err)
+		return nil, newScrapeError(timeoutCtx, models.ScrapeFailureOther, fmt.Errorf("failed to extract basic info: %w", err))
+	}
+	// Every school page has its name; without it the page is an error page or its layout changed
+	if details.RawPage.SchoolNameWithNumber == "" {
+		return nil, newScrapeError(timeoutCtx, models.ScrapeFailureElementMissing, errors.New("school name not found on page"))
+	}
+
+	// Try to scrape statistics and staffing (might not always be available)
+	s.scrapeStatistics(timeoutCtx, details)
+	s.scrapeStaffing(timeoutCtx, details)
+	s.scrapeQuality(timeoutCtx, details)
+
+	// Derive structured fields from the raw captures
+	s.parseDetail(details)
+	if details.SchoolNumber == "" {
+		return nil, newScrapeError(timeoutCtx, models.ScrapeFailureParse,
+			fmt.Errorf("no school number in %q", details.RawPage.SchoolNameWithNumber))
 	}
 
+	return details, nil
+}
+
+// parseDetail derives all parsed fields of a record from its raw page captures and stamps
+// the current ParserVersion. It is used both after scraping and when re-parsing the cache.
+func (s *SchoolDetailsScraper) parseDetail(details *models.SchoolDetailData) {
+	raw := details.RawPage
+
 	// Parse school name and number from the format "School Name - SchoolNumber"
-	details.SchoolName, details.SchoolNumber = s.parseSchoolNameAndNumber(schoolNameWithNumber)
+	details.SchoolName, details.SchoolNumber = s.parseSchoolNameAndNumber(raw.SchoolNameWithNumber)
 
 	// Check if available after 4th grade
 	details.AvailableAfter4thGrade = strings.Contains(details.Offerings, "ab Jahrgangsstufe 5 beginnende") ||
 		strings.Contains(details.AdditionalInfo, "ab Jahrgangsstufe 5 beginnende")
 
-	// Try to scrape statistics (might not always be available)
-	s.scrapeStatistics(timeoutCtx, details)
-
-	return details, nil
+	details.Confession = parseConfession(details.OperatorOrganization)
+	details.FeeMinEUR, details.FeeMaxEUR = parseFeeRange(details.FeeInfo)
+	details.SupportFocuses = parseSupportFocuses(details.Offerings, details.AdditionalInfo, details.Differentiation)
+	details.WheelchairAccessible = parseWheelchairAccessible(details.Equipment, details.AdditionalInfo)
+	details.AllDayType = parseAllDayType(details.Offerings, details.AdditionalInfo)
+	details.Europaschule, details.BilingualLanguages, details.ReligiousEducation = parsePrograms(details.Languages, details.Offerings, details.AdditionalInfo)
+
+	details.CitizenshipTable = s.parseTableHTML(raw.StatisticTablesHTML[citizenshipTabTitle])
+	details.LanguageTable = s.parseTableHTML(raw.StatisticTablesHTML[languageTabTitle])
+	details.ResidenceTable = s.parseTableHTML(raw.StatisticTablesHTML[residenceTabTitle])
+	details.AbsenceTable = s.parseTableHTML(raw.StatisticTablesHTML[absenceTabTitle])
+	details.GradeStructureTable = s.parseTableHTML(raw.StatisticTablesHTML[gradesTabTitle])
+	details.StaffingTable = s.parseTableHTML(raw.StatisticTablesHTML[staffingTabTitle])
+	details.QualityTable = s.parseTableHTML(raw.StatisticTablesHTML[qualityTabTitle])
+
+	details.ParserVersion = ParserVersion
 }
 
 // scrapeStatistics attempts to scrape student statistics
 func (s *SchoolDetailsScraper) scrapeStatistics(ctx context.Context, details *models.SchoolDetailData) {
-	s.logger.Info("attempting to scrape statistics", slog.String("school", details.SchoolName))
+	s.logger.Debug("attempting to scrape statistics", slog.String("school", details.SchoolName))
 
 	// First, check if there's a Schülerschaft tab/link
 	var navInfo struct {
@@ -403,7 +561,7 @@ func (s *SchoolDetailsScraper) scrapeStatistics(ctx context.Context, details *mo
 		return
 	}
 
-	s.logger.Info("statistics navigation check",
+	s.logger.Debug("statistics navigation check",
 		slog.Bool("has_navi", navInfo.HasNaviSchuelerschaft),
 		slog.Int("tabs_found", len(navInfo.AvailableTabs)),
 		slog.Int("relevant_ids", len(navInfo.AllIDs)),
@@ -422,46 +580,102 @@ func (s *SchoolDetailsScraper) scrapeStatistics(ctx context.Context, details *mo
 				slog.String("error", err.Error()),
 			)
 		} else {
-			s.logger.Info("clicked NaviSchuelerschaft successfully")
+			s.logger.Debug("clicked NaviSchuelerschaft successfully")
 		}
 	}
 
-	// Try to scrape each statistic type
-	s.logger.Info("attempting to scrape citizenship data")
-	details.CitizenshipTable = s.scrapeStatisticTable(ctx, "Staatsangehörigkeit")
+	// Try to capture each statistic table; parsing happens in parseDetail
+	titles := []string{citizenshipTabTitle, languageTabTitle, residenceTabTitle, absenceTabTitle, gradesTabTitle}
+	for _, title := range titles {
+		s.logger.Debug("attempting to scrape statistic table", slog.String("title", title))
+		if tableHTML := s.scrapeStatisticTable(ctx, title); tableHTML != "" {
+			details.RawPage.StatisticTablesHTML[title] = tableHTML
+		}
+	}
 
-	s.logger.Info("attempting to scrape language data")
-	details.LanguageTable = s.scrapeStatisticTable(ctx, "Nichtdeutsche Herkunftssprache")
+	// Log summary
+	foundCount := len(details.RawPage.StatisticTablesHTML)
 
-	s.logger.Info("attempting to scrape residence data")
-	details.ResidenceTable = s.scrapeStatisticTable(ctx, "Wohnorte")
+	s.logger.Info("statistics scraping complete",
+		slog.Int("tables_found", foundCount),
+		slog.Int("tables_expected", len(titles)),
+	)
+}
 
-	s.logger.Info("attempting to scrape absence data")
-	details.AbsenceTable = s.scrapeStatisticTable(ctx, "Fehlzeiten")
+// scrapeStaffing captures the staffing table of the personnel section (Personal). Many school
+// pages have no such section; the table is then left out.
+func (s *SchoolDetailsScraper) scrapeStaffing(ctx context.Context, details *models.SchoolDetailData) {
+	var hasNavi bool
+	err := chromedp.Run(ctx,
+		chromedp.Evaluate(`document.getElementById('NaviPersonal') !== null`, &hasNavi),
+	)
+	if err != nil {
+		s.logger.Warn("error checking for personnel section",
+			slog.String("school", details.SchoolName),
+			slog.String("error", err.Error()),
+		)
+		return
+	}
+	if !hasNavi {
+		s.logger.Debug("no personnel section", slog.String("school", details.SchoolName))
+		return
+	}
 
-	// Log summary
-	var foundCount int
-	if details.CitizenshipTable != nil {
-		foundCount++
+	err = chromedp.Run(ctx,
+		chromedp.Click(`#NaviPersonal`, chromedp.ByQuery),
+		chromedp.Sleep(2*time.Second), // Wait for ASP.NET postback
+	)
+	if err != nil {
+		s.logger.Warn("failed to click NaviPersonal",
+			slog.String("school", details.SchoolName),
+			slog.String("error", err.Error()),
+		)
+		return
 	}
-	if details.LanguageTable != nil {
-		foundCount++
+
+	if tableHTML := s.scrapeStatisticTable(ctx, staffingTabTitle); tableHTML != "" {
+		details.RawPage.StatisticTablesHTML[staffingTabTitle] = tableHTML
 	}
-	if details.ResidenceTable != nil {
-		foundCount++
+}
+
+// scrapeQuality captures the quality profile of the school inspection section (Schulinspektion).
+// Only inspected schools whose report is published have the section; the table is then left out.
+func (s *SchoolDetailsScraper) scrapeQuality(ctx context.Context, details *models.SchoolDetailData) {
+	var hasNavi bool
+	err := chromedp.Run(ctx,
+		chromedp.Evaluate(`document.getElementById('NaviSchulinspektion') !== null`, &hasNavi),
+	)
+	if err != nil {
+		s.logger.Warn("error checking for school inspection section",
+			slog.String("school", details.SchoolName),
+			slog.String("error", err.Error()),
+		)
+		return
 	}
-	if details.AbsenceTable != nil {
-		foundCount++
+	if !hasNavi {
+		s.logger.Debug("no school inspection section", slog.String("school", details.SchoolName))
+		return
 	}
 
-	s.logger.Info("statistics scraping complete",
-		slog.Int("tables_found", foundCount),
-		slog.Int("tables_expected", 4),
+	err = chromedp.Run(ctx,
+		chromedp.Click(`#NaviSchulinspektion`, chromedp.ByQuery),
+		chromedp.Sleep(2*time.Second), // Wait for ASP.NET postback
 	)
+	if err != nil {
+		s.logger.Warn("failed to click NaviSchulinspektion",
+			slog.String("school", details.SchoolName),
+			slog.String("error", err.Error()),
+		)
+		return
+	}
+
+	if tableHTML := s.scrapeStatisticTable(ctx, qualityTabTitle); tableHTML != "" {
+		details.RawPage.StatisticTablesHTML[qualityTabTitle] = tableHTML
+	}
 }
 
-// scrapeStatisticTable clicks on a tab and scrapes the table data
-func (s *SchoolDetailsScraper) scrapeStatisticTable(ctx context.Context, tabTitle string) *models.StatisticTable {
+// scrapeStatisticTable clicks on a tab and returns the raw HTML of the table
+func (s *SchoolDetailsScraper) scrapeStatisticTable(ctx context.Context, tabTitle string) string {
 	var tableHTML string
 	var clickSuccess bool
 
@@ -487,14 +701,14 @@ func (s *SchoolDetailsScraper) scrapeStatisticTable(ctx context.Context, tabTitl
 			slog.String("title", tabTitle),
 			slog.String("error", err.Error()),
 		)
-		return nil
+		return ""
 	}
 
 	if !clickSuccess {
-		s.logger.Info("tab element not found",
+		s.logger.Debug("tab element not found",
 			slog.String("title", tabTitle),
 		)
-		return nil
+		return ""
 	}
 
 	// Wait for the table to appear after clicking
@@ -507,7 +721,7 @@ func (s *SchoolDetailsScraper) scrapeStatisticTable(ctx context.Context, tabTitl
 			slog.String("title", tabTitle),
 			slog.String("error", err.Error()),
 		)
-		return nil
+		return ""
 	}
 
 	// Extract table HTML with multiple strategies
@@ -602,11 +816,11 @@ func (s *SchoolDetailsScraper) scrapeStatisticTable(ctx context.Context, tabTitl
 			slog.String("title", tabTitle),
 			slog.String("error", err.Error()),
 		)
-		return nil
+		return ""
 	}
 
 	// Log detailed table information
-	s.logger.Info("table detection results",
+	s.logger.Debug("table detection results",
 		slog.String("title", tabTitle),
 		slog.Int("total_tables", tableInfo.TotalTables),
 		slog.Int("visible_tables", tableInfo.VisibleTables),
@@ -616,7 +830,7 @@ func (s *SchoolDetailsScraper) scrapeStatisticTable(ctx context.Context, tabTitl
 	// Log each table's information
 	for i, info := range tableInfo.TableInfo {
 		if i < 5 { // Limit to first 5 tables to avoid log spam
-			s.logger.Info("table details", slog.String("info", info))
+			s.logger.Debug("table details", slog.String("info", info))
 		}
 	}
 
@@ -625,17 +839,15 @@ func (s *SchoolDetailsScraper) scrapeStatisticTable(ctx context.Context, tabTitl
 			slog.String("title", tabTitle),
 			slog.Int("total_tables_on_page", tableInfo.TotalTables),
 		)
-		return nil
+		return ""
 	}
 
-	s.logger.Info("successfully extracted table",
+	s.logger.Debug("successfully extracted table",
 		slog.String("title", tabTitle),
 		slog.Int("html_length", len(tableHTML)),
 	)
 
-	// Parse the table HTML
-	table := s.parseTableHTML(tableHTML)
-	return table
+	return tableHTML
 }
 
 // parseTableHTML parses HTML table into structured data using Go's html parser
@@ -735,7 +947,7 @@ func (s *SchoolDetailsScraper) parseTableHTML(tableHTML string) *models.Statisti
 
 	parseNode(tableNode, false)
 
-	s.logger.Info("parsed table",
+	s.logger.Debug("parsed table",
 		slog.Int("headers", len(table.Headers)),
 		slog.Int("rows", len(table.Rows)),
 	)
@@ -751,14 +963,9 @@ func (s *SchoolDetailsScraper) parseSchoolNameAndNumber(fullName string) (string
 		return "", ""
 	}
 
-	// Split by " - " to separate name and number
-	parts := strings.Split(fullName, " - ")
-	if len(parts) >= 2 {
-		// The school name is everything except the last part
-		schoolName := strings.Join(parts[:len(parts)-1], " - ")
-		// The school number is the last part
-		schoolNumber := strings.TrimSpace(parts[len(parts)-1])
-		return strings.TrimSpace(schoolName), schoolNumber
+	// The school number follows the last " - "; names may contain the separator themselves
+	if i := strings.LastIndex(fullName, " - "); i >= 0 {
+		return strings.TrimSpace(fullName[:i]), strings.TrimSpace(fullName[i+len(" - "):])
 	}
 
 	// If no " - " separator found, return the full name as school name
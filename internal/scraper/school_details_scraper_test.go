@@ -0,0 +1,105 @@
+package scraper
+
+import (
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestScraper() *SchoolDetailsScraper {
+	return &SchoolDetailsScraper{logger: slog.New(slog.NewTextHandler(io.Discard, nil)), rules: DefaultRules()}
+}
+
+func FuzzParseTableHTML(f *testing.F) {
+	f.Add(`<table><thead><tr><th>Staatsangehörigkeit</th><th>gesamt</th></tr></thead><tbody><tr><td>Deutschland</td><td>773</td></tr></tbody></table>`)
+	f.Add(`<table><tr><td>Schule</td><td>6,1 %</td></tr><tr><td> Berlin </td><td><b>8,9</b> %</td></tr></table>`)
+	f.Add(`<div><table><tr><td><table><tr><td>nested</td></tr></table></td></tr></table></div>`)
+	f.Add(`<table><tr></tr><tr><td></td></tr><tbody><tr><th>x`)
+	f.Add(`<tr><td>no table</td></tr>`)
+	f.Add(`<table><thead><tr><th>a</th></tr></thead><thead><tr><th>b</th></tr></thead></table>`)
+	f.Add(``)
+
+	s := newTestScraper()
+	f.Fuzz(func(t *testing.T, tableHTML string) {
+		table := s.parseTableHTML(tableHTML)
+		if table == nil {
+			return
+		}
+
+		if table.Headers == nil || table.Rows == nil {
+			t.Fatalf("parsed table has nil headers or rows: %+v", table)
+		}
+		for _, row := range table.Rows {
+			if len(row) == 0 {
+				t.Errorf("empty row in %+v", table)
+			}
+			for _, cell := range row {
+				if cell != strings.TrimSpace(cell) {
+					t.Errorf("cell %q is not trimmed", cell)
+				}
+			}
+		}
+		for _, header := range table.Headers {
+			if header != strings.TrimSpace(header) {
+				t.Errorf("header %q is not trimmed", header)
+			}
+		}
+	})
+}
+
+func FuzzParseSchoolNameAndNumber(f *testing.F) {
+	f.Add("Georg-Friedrich-Händel-Gymnasium - 02Y04")
+	f.Add("Schule am Park - Grundschule - 07G14")
+	f.Add("Lessing-Gymnasium")
+	f.Add(" - 01Y02")
+	f.Add("Name - ")
+	f.Add(" - ")
+	f.Add("")
+
+	s := newTestScraper()
+	f.Fuzz(func(t *testing.T, fullName string) {
+		name, number := s.parseSchoolNameAndNumber(fullName)
+
+		if name != strings.TrimSpace(name) || number != strings.TrimSpace(number) {
+			t.Errorf("parseSchoolNameAndNumber(%q) = %q, %q: not trimmed", fullName, name, number)
+		}
+		if strings.Contains(number, " - ") {
+			t.Errorf("parseSchoolNameAndNumber(%q): number %q contains the separator", fullName, number)
+		}
+
+		// The school number is whatever follows the last separator; the name keeps the rest
+		i := strings.LastIndex(fullName, " - ")
+		if i < 0 {
+			if name != strings.TrimSpace(fullName) || number != "" {
+				t.Errorf("parseSchoolNameAndNumber(%q) = %q, %q, want the whole input as name", fullName, name, number)
+			}
+			return
+		}
+		if want := strings.TrimSpace(fullName[i+3:]); number != want {
+			t.Errorf("parseSchoolNameAndNumber(%q) number = %q, want %q", fullName, number, want)
+		}
+		if want := strings.TrimSpace(fullName[:i]); name != want {
+			t.Errorf("parseSchoolNameAndNumber(%q) name = %q, want %q", fullName, name, want)
+		}
+	})
+}
+
+func TestFailurePaths(t *testing.T) {
+	s := newTestScraper()
+	s.failuresDir = "cache/failures"
+	day := time.Date(2025, 9, 1, 23, 30, 0, 0, time.UTC)
+
+	screenshot, html := s.failurePaths("https://www.bildung.berlin.de/Schulverzeichnis/Schulportrait.aspx?IDSchulzweig=12345", day)
+	if screenshot != "cache/failures/2025-09-01/schulzweig-12345.png" || html != "cache/failures/2025-09-01/schulzweig-12345.html" {
+		t.Errorf("failurePaths = %q, %q", screenshot, html)
+	}
+
+	// Without the school id the cache key of the URL names the captures
+	schoolURL := "https://www.bildung.berlin.de/Schulverzeichnis/Schulportrait.aspx?id=../../etc"
+	screenshot, _ = s.failurePaths(schoolURL, day)
+	if want := "cache/failures/2025-09-01/" + s.getCacheKey(schoolURL) + ".png"; screenshot != want {
+		t.Errorf("failurePaths = %q, want %q", screenshot, want)
+	}
+}
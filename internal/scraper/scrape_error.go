@@ -0,0 +1,63 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"schools-be/internal/metrics"
+	"schools-be/internal/models"
+)
+
+var scrapeFailures = metrics.NewCounterVec("schools_scrape_failures_total",
+	"Number of failed page scrapes by kind (timeout, element_missing, navigation, parse, other)", "scraper", "kind")
+
+// ScrapeError is a failed page scrape classified by what went wrong. Its message starts with
+// the kind, so the kind survives being stored as text, e.g. as the last error of a queue task.
+type ScrapeError struct {
+	Kind string // One of models.ScrapeFailureKinds
+	Err  error
+}
+
+func (e *ScrapeError) Error() string {
+	return e.Kind + ": " + e.Err.Error()
+}
+
+func (e *ScrapeError) Unwrap() error {
+	return e.Err
+}
+
+// newScrapeError classifies a failed scrape step as kind, or as a timeout when the page
+// timeout of ctx ran out, whichever step was running
+func newScrapeError(ctx context.Context, kind string, err error) error {
+	if ctx.Err() != nil {
+		err = context.Cause(ctx)
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		kind = models.ScrapeFailureTimeout
+	}
+	return &ScrapeError{Kind: kind, Err: err}
+}
+
+// FailureKind returns the kind of a scrape error; errors not classified by the scraper, such
+// as a cache directory that cannot be created, are of kind other
+func FailureKind(err error) string {
+	var scrapeErr *ScrapeError
+	if errors.As(err, &scrapeErr) {
+		return scrapeErr.Kind
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return models.ScrapeFailureTimeout
+	}
+	return models.ScrapeFailureOther
+}
+
+// FailureKindOf returns the kind of a scrape error from its message
+func FailureKindOf(message string) string {
+	for _, kind := range models.ScrapeFailureKinds {
+		if strings.HasPrefix(message, kind+": ") {
+			return kind
+		}
+	}
+	return models.ScrapeFailureOther
+}
@@ -0,0 +1,43 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestScrapeErrorKinds(t *testing.T) {
+	live := context.Background()
+	expired, cancel := context.WithTimeoutCause(live, time.Nanosecond, fmt.Errorf("page timed out: %w", context.DeadlineExceeded))
+	defer cancel()
+	<-expired.Done()
+
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"navigation", newScrapeError(live, "navigation", errors.New("net::ERR_NAME_NOT_RESOLVED")), "navigation"},
+		{"timeout wins over step", newScrapeError(expired, "navigation", errors.New("context canceled")), "timeout"},
+		{"wrapped", fmt.Errorf("scrape: %w", newScrapeError(live, "parse", errors.New("no school number"))), "parse"},
+		{"unclassified", errors.New("failed to create cache directory"), "other"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FailureKind(tt.err); got != tt.want {
+				t.Errorf("FailureKind = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	// The queue stores the message of the scraper's error
+	stored := newScrapeError(live, "element_missing", errors.New("school name not found")).Error()
+	if got := FailureKindOf(stored); got != "element_missing" {
+		t.Errorf("FailureKindOf(%q) = %q, want element_missing", stored, got)
+	}
+	if got := FailureKindOf("database is locked"); got != "other" {
+		t.Errorf("FailureKindOf(unclassified) = %q, want other", got)
+	}
+}
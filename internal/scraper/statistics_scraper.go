@@ -4,10 +4,12 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"path/filepath"
 	"strings"
 	"time"
 
 	"schools-be/internal/models"
+	"schools-be/internal/timeouts"
 
 	"github.com/gocolly/colly/v2"
 )
@@ -20,11 +22,14 @@ const (
 type StatisticsScraper struct {
 	collector  *colly.Collector
 	statistics []models.StatisticData
+	rules      *Rules
 	logger     *slog.Logger
 }
 
-// NewStatisticsScraper creates a new statistics scraper
-func NewStatisticsScraper() *StatisticsScraper {
+// NewStatisticsScraper creates a new statistics scraper caching responses in the statistics
+// directory below cacheDir (CACHE_DIR) and recognizing columns by rules
+func NewStatisticsScraper(cacheDir string, rules *Rules, logger *slog.Logger) *StatisticsScraper {
+	logger = logger.With(slog.String("scraper", "statistics"))
 
 	// Create Colly collector with best practices
 	c := colly.NewCollector(
@@ -35,7 +40,7 @@ func NewStatisticsScraper() *StatisticsScraper {
 		colly.UserAgent("Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36"),
 
 		// Cache responses to avoid re-scraping
-		colly.CacheDir("./cache/statistics"),
+		colly.CacheDir(filepath.Join(cacheDir, "statistics")),
 	)
 
 	// Set timeouts
@@ -49,13 +54,14 @@ func NewStatisticsScraper() *StatisticsScraper {
 		RandomDelay: 1 * time.Second, // Random delay up to 1 second
 	})
 	if err != nil {
-		slog.Error("failed to set rate limit", slog.String("error", err.Error()))
+		logger.Error("failed to set rate limit", slog.String("error", err.Error()))
 	}
 
 	scraper := &StatisticsScraper{
 		collector:  c,
 		statistics: make([]models.StatisticData, 0),
-		logger:     slog.Default(),
+		rules:      rules,
+		logger:     logger,
 	}
 
 	// Set up callbacks
@@ -79,7 +85,7 @@ func (s *StatisticsScraper) setupCallbacks() {
 
 	// After receiving response
 	s.collector.OnResponse(func(r *colly.Response) {
-		s.logger.Info("received response",
+		s.logger.Debug("received response",
 			slog.String("url", r.Request.URL.String()),
 			slog.Int("status", r.StatusCode),
 			slog.Int("size", len(r.Body)),
@@ -97,7 +103,7 @@ func (s *StatisticsScraper) setupCallbacks() {
 
 	// Parse the specific data grid table using the correct selector
 	s.collector.OnHTML("#myDatagrid", func(e *colly.HTMLElement) {
-		s.logger.Info("found myDatagrid table")
+		s.logger.Debug("found myDatagrid table")
 
 		// Extract headers from the first row (tr) with orange background
 		var headers []string
@@ -119,7 +125,7 @@ func (s *StatisticsScraper) setupCallbacks() {
 			return
 		}
 
-		s.logger.Info("table headers", slog.Int("count", len(headers)), slog.Any("headers", headers))
+		s.logger.Debug("table headers", slog.Int("count", len(headers)), slog.Any("headers", headers))
 
 		// Parse all data rows (skip the first header row)
 		rowCount := 0
@@ -144,34 +150,10 @@ func (s *StatisticsScraper) setupCallbacks() {
 					stat.Metadata[headers[cellIndex]] = value
 				}
 
-				// Map to known fields based on actual column names from the website
+				// Map to known fields by the column names from the website (Rules.StatisticsColumns)
 				if cellIndex < len(headers) {
-					header := strings.ToLower(headers[cellIndex])
-					switch {
-					case header == "bsn":
-						stat.SchoolNumber = value
-					case header == "name":
-						stat.SchoolName = value
-					case header == "schuljahr":
-						stat.SchoolYear = value
-					case strings.Contains(header, "schüler (m/w/d)") || strings.Contains(header, "schueler (m/w/d)"):
-						stat.Students = value
-					case strings.Contains(header, "schüler (w)") || strings.Contains(header, "schueler (w)"):
-						stat.StudentsFemale = value
-					case strings.Contains(header, "schüler (m)") || strings.Contains(header, "schueler (m)"):
-						stat.StudentsMale = value
-					case strings.Contains(header, "lehrkräfte (m,w,d)") || strings.Contains(header, "lehrkraefte (m,w,d)"):
-						stat.Teachers = value
-					case strings.Contains(header, "lehrkräfte (w)") || strings.Contains(header, "lehrkraefte (w)"):
-						stat.TeachersFemale = value
-					case strings.Contains(header, "lehrkräfte (m)") || strings.Contains(header, "lehrkraefte (m)"):
-						stat.TeachersMale = value
-					case header == "bezirk", header == "district":
-						stat.District = value
-					case header == "schulart", header == "school type":
-						stat.SchoolType = value
-					case header == "klassen", header == "classes":
-						stat.Classes = value
+					if field := s.rules.statisticsField(&stat, headers[cellIndex]); field != nil {
+						*field = value
 					}
 				}
 			})
@@ -183,7 +165,7 @@ func (s *StatisticsScraper) setupCallbacks() {
 			}
 		})
 
-		s.logger.Info("parsed table rows", slog.Int("count", rowCount))
+		s.logger.Debug("parsed table rows", slog.Int("count", rowCount))
 	})
 
 	// When scraping is complete
@@ -202,8 +184,15 @@ func (s *StatisticsScraper) ScrapeStatistics(ctx context.Context) ([]models.Stat
 	// Reset statistics
 	s.statistics = make([]models.StatisticData, 0)
 
-	// Visit the page
+	// Visit the page; the collector's requests follow ctx
+	ctx, cancel := timeouts.With(ctx, "scrape statistics page", timeouts.PageScrape)
+	defer cancel()
+	s.collector.Context = ctx
+
 	if err := s.collector.Visit(berlinStatisticsURL); err != nil {
+		if ctx.Err() != nil {
+			err = context.Cause(ctx)
+		}
 		return nil, fmt.Errorf("failed to visit URL: %w", err)
 	}
 
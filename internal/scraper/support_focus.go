@@ -0,0 +1,77 @@
+package scraper
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"schools-be/internal/models"
+)
+
+// supportFocusPhrases recognize the special-needs support focus areas (sonderpädagogische
+// Förderschwerpunkte) of a school. Phrases specific enough to stand alone are matched
+// anywhere; the short ones ("Lernen", "Sehen", ...) only as whole words in a sentence naming
+// Förderschwerpunkte.
+var supportFocusPhrases = []struct {
+	focus    string
+	anywhere []string
+	listed   []string
+}{
+	{models.SupportFocusLearning, nil, []string{"lernen"}},
+	{models.SupportFocusSpeech, []string{"sprachheil"}, []string{"sprache"}},
+	{models.SupportFocusEmotionalSocial, []string{"emotionale und soziale entwicklung", "emotional-soziale entwicklung"}, nil},
+	{models.SupportFocusIntellectual, []string{"geistige entwicklung"}, nil},
+	{models.SupportFocusPhysicalMotor, []string{"körperliche und motorische entwicklung", "körperbehindert"}, nil},
+	{models.SupportFocusHearing, []string{"hörgeschädigt", "gehörlos", "schwerhörig"}, []string{"hören"}},
+	{models.SupportFocusVision, []string{"sehbehindert", "blinde"}, []string{"sehen"}},
+	{models.SupportFocusAutism, []string{"autismus", "autistisch"}, nil},
+}
+
+// supportFocusSentence matches a sentence (or list item) naming Förderschwerpunkte
+var supportFocusSentence = regexp.MustCompile(`(?i)förderschwerpunkte?[^.;\n]*`)
+
+// parseSupportFocuses returns the support focus areas named in the texts, in the order of
+// models.SupportFocuses
+func parseSupportFocuses(texts ...string) []string {
+	text := strings.ToLower(strings.Join(texts, "\n"))
+	listed := strings.Join(supportFocusSentence.FindAllString(text, -1), "\n")
+
+	var focuses []string
+	for _, p := range supportFocusPhrases {
+		if containsAny(text, p.anywhere) || containsAnyWord(listed, p.listed) {
+			focuses = append(focuses, p.focus)
+		}
+	}
+	return focuses
+}
+
+func containsAny(text string, phrases []string) bool {
+	for _, phrase := range phrases {
+		if strings.Contains(text, phrase) {
+			return true
+		}
+	}
+	return false
+}
+
+// containsAnyWord reports whether text contains one of the words, not as part of a longer word
+// ("hören" but not "gehören")
+func containsAnyWord(text string, words []string) bool {
+	for _, word := range words {
+		for offset := 0; ; {
+			i := strings.Index(text[offset:], word)
+			if i < 0 {
+				break
+			}
+			start, end := offset+i, offset+i+len(word)
+			before, _ := utf8.DecodeLastRuneInString(text[:start])
+			after, _ := utf8.DecodeRuneInString(text[end:])
+			if !unicode.IsLetter(before) && !unicode.IsLetter(after) {
+				return true
+			}
+			offset = end
+		}
+	}
+	return false
+}
@@ -0,0 +1,43 @@
+package scraper
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseSupportFocuses(t *testing.T) {
+	tests := []struct {
+		name  string
+		texts []string
+		want  []string
+	}{
+		{"none", []string{"Begabtenförderung, Schüleraustausch mit Frankreich"}, nil},
+		{
+			name:  "listed",
+			texts: []string{"Inklusive Schwerpunktschule mit den Förderschwerpunkten Lernen, Sprache und Hören"},
+			want:  []string{"learning", "speech", "hearing"},
+		},
+		{
+			name:  "phrases anywhere",
+			texts: []string{"Kleinklassen für Kinder mit Autismus", "Schwerpunkt geistige Entwicklung; Lernen im Freien"},
+			want:  []string{"intellectual", "autism"},
+		},
+		{
+			name:  "short words outside a Förderschwerpunkt sentence",
+			texts: []string{"Sprachen lernen und Musik hören. Förderschwerpunkte gehören nicht zum Profil"},
+			want:  nil,
+		},
+		{
+			name:  "across texts, in canonical order",
+			texts: []string{"Förderschwerpunkt Sehen", "emotionale und soziale Entwicklung"},
+			want:  []string{"emotional_social", "vision"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseSupportFocuses(tt.texts...); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,23 +1,33 @@
 package scraper
 
 import (
+	"fmt"
+	"math"
+	"regexp"
 	"strconv"
 	"strings"
 	"time"
+	"unicode"
 
 	"schools-be/internal/models"
 )
 
 // NormalizeCitizenshipTable converts citizenship table to normalized records
-func NormalizeCitizenshipTable(schoolNumber string, table *models.StatisticTable, scrapedAt time.Time) []models.SchoolCitizenshipStat {
+func (s *SchoolDetailsScraper) NormalizeCitizenshipTable(schoolNumber string, table *models.StatisticTable, scrapedAt time.Time) []models.SchoolCitizenshipStat {
 	if table == nil || len(table.Rows) == 0 {
 		return nil
 	}
 
 	var stats []models.SchoolCitizenshipStat
 
+	// Columns: citizenship, female, male, [diverse,] total
 	for _, row := range table.Rows {
-		if len(row) < 4 {
+		diverse := s.hasDiverseColumn(table, row, 4)
+		offset := 0
+		if diverse {
+			offset = 1
+		}
+		if len(row) < 4+offset {
 			continue
 		}
 
@@ -36,7 +46,10 @@ func NormalizeCitizenshipTable(schoolNumber string, table *models.StatisticTable
 		// Parse numbers (handle "45 %" format)
 		stat.FemaleStudents = parseInt(row[1])
 		stat.MaleStudents = parseInt(row[2])
-		stat.Total = parseInt(row[3])
+		stat.Total = parseInt(row[3+offset])
+		if diverse {
+			stat.DiverseStudents = parseInt(row[3])
+		}
 
 		stats = append(stats, stat)
 	}
@@ -44,33 +57,100 @@ func NormalizeCitizenshipTable(schoolNumber string, table *models.StatisticTable
 	return stats
 }
 
+// ValidateCitizenshipStats cross-checks normalized citizenship rows: female, male and diverse
+// students must add up to the total of each row, and the total row ("Insgesamt") must equal the sum of
+// the category rows. The table is broken, and should not be served, when the total row
+// disagrees or most rows do not add up; all its issues are then marked excluded.
+func (s *SchoolDetailsScraper) ValidateCitizenshipStats(stats []models.SchoolCitizenshipStat) (issues []models.DataQualityIssue, broken bool) {
+	var totalRow *models.SchoolCitizenshipStat
+	categorySum, mismatched := 0, 0
+	for i, stat := range stats {
+		if sum := stat.FemaleStudents + stat.MaleStudents + stat.DiverseStudents; sum != stat.Total {
+			issues = append(issues, citizenshipIssue(stat.SchoolNumber, stat.Citizenship, stat.Total, sum))
+			mismatched++
+		}
+		if s.rules.CitizenshipTotalRows.matches(stat.Citizenship) {
+			totalRow = &stats[i]
+		} else {
+			categorySum += stat.Total
+		}
+	}
+
+	broken = mismatched*2 > len(stats)
+	if totalRow != nil && totalRow.Total != categorySum {
+		issues = append(issues, citizenshipIssue(totalRow.SchoolNumber, totalRow.Citizenship+" vs. categories", totalRow.Total, categorySum))
+		broken = true
+	}
+
+	if broken {
+		for i := range issues {
+			issues[i].Excluded = true
+		}
+	}
+	return issues, broken
+}
+
+func citizenshipIssue(schoolNumber, detail string, total, sum int) models.DataQualityIssue {
+	return models.DataQualityIssue{
+		SchoolNumber: schoolNumber,
+		Dataset:      models.DatasetCitizenship,
+		Detail:       detail,
+		Total:        total,
+		Sum:          sum,
+	}
+}
+
+// hasDiverseColumn reports whether a table has the "divers" column of newer m/w/d breakdowns:
+// by its headers, or for tables without headers, by row having more than the legacyCells of the
+// female/male layout
+func (s *SchoolDetailsScraper) hasDiverseColumn(table *models.StatisticTable, row []string, legacyCells int) bool {
+	for _, header := range table.Headers {
+		if s.rules.DiverseColumns.matches(header) {
+			return true
+		}
+	}
+	return len(table.Headers) == 0 && len(row) > legacyCells
+}
+
 // NormalizeLanguageTable converts language table to normalized record
-func NormalizeLanguageTable(schoolNumber string, table *models.StatisticTable, scrapedAt time.Time) *models.SchoolLanguageStat {
+func (s *SchoolDetailsScraper) NormalizeLanguageTable(schoolNumber string, table *models.StatisticTable, scrapedAt time.Time) *models.SchoolLanguageStat {
 	if table == nil || len(table.Rows) < 2 {
 		return nil
 	}
 
-	// Usually the last row has the actual data
+	// Usually the last row has the actual data. Columns: students, female, male, [diverse,]
+	// total and percentage of students with a non-German heritage language
 	dataRow := table.Rows[len(table.Rows)-1]
 	if len(dataRow) < 5 {
 		return nil
 	}
+	diverse := s.hasDiverseColumn(table, dataRow, 5)
+	offset := 0
+	if diverse {
+		offset = 1
+	}
+	if len(dataRow) < 5+offset {
+		return nil
+	}
 
 	stat := models.SchoolLanguageStat{
 		SchoolNumber:      schoolNumber,
 		TotalStudents:     parseInt(dataRow[0]),
 		NDHFemaleStudents: parseInt(dataRow[1]),
 		NDHMaleStudents:   parseInt(dataRow[2]),
-		NDHTotal:          parseInt(dataRow[3]),
-		NDHPercentage:     parseFloat(dataRow[4]),
+		NDHTotal:          parseInt(dataRow[3+offset]),
+		NDHPercentage:     parseFloat(dataRow[4+offset]),
 		ScrapedAt:         scrapedAt,
 	}
+	if diverse {
+		stat.NDHDiverseStudents = parseInt(dataRow[3])
+	}
 
 	return &stat
 }
 
 // NormalizeResidenceTable converts residence table to normalized records
-func NormalizeResidenceTable(schoolNumber string, table *models.StatisticTable, scrapedAt time.Time) []models.SchoolResidenceStat {
+func (s *SchoolDetailsScraper) NormalizeResidenceTable(schoolNumber string, table *models.StatisticTable, scrapedAt time.Time) []models.SchoolResidenceStat {
 	if table == nil || len(table.Rows) == 0 {
 		return nil
 	}
@@ -84,7 +164,7 @@ func NormalizeResidenceTable(schoolNumber string, table *models.StatisticTable,
 
 		district := strings.TrimSpace(row[0])
 		// Skip empty rows or total rows
-		if district == "" || district == "Insgesamt" {
+		if district == "" || s.rules.ResidenceTotalRows.matches(district) {
 			continue
 		}
 
@@ -101,9 +181,11 @@ func NormalizeResidenceTable(schoolNumber string, table *models.StatisticTable,
 	return stats
 }
 
-// NormalizeAbsenceTable converts absence table to normalized record
-func NormalizeAbsenceTable(schoolNumber string, table *models.StatisticTable, scrapedAt time.Time) *models.SchoolAbsenceStat {
-	if table == nil || len(table.Rows) < 4 {
+// NormalizeAbsenceTable converts absence table to normalized record. Tables are sometimes
+// published partially, with rows or rate cells missing: those rates stay nil rather than 0.
+// Returns nil when no row of the table is recognized.
+func (s *SchoolDetailsScraper) NormalizeAbsenceTable(schoolNumber string, table *models.StatisticTable, scrapedAt time.Time) *models.SchoolAbsenceStat {
+	if table == nil {
 		return nil
 	}
 
@@ -113,73 +195,320 @@ func NormalizeAbsenceTable(schoolNumber string, table *models.StatisticTable, sc
 	}
 
 	// Parse rows by their labels
+	rows := &s.rules.AbsenceRows
+	found := false
 	for _, row := range table.Rows {
-		if len(row) < 3 {
+		if len(row) < 2 {
 			continue
 		}
 
-		label := strings.TrimSpace(strings.ToLower(row[0]))
-		totalRate := parseFloat(row[1])
-		unexcusedRate := parseFloat(row[2])
+		totalRate := parseRate(row[1])
+		var unexcusedRate *float64
+		if len(row) > 2 {
+			unexcusedRate = parseRate(row[2])
+		}
 
+		label := row[0]
 		switch {
-		case strings.Contains(label, "schule") && !strings.Contains(label, "schulart"):
-			stat.SchoolAbsenceRate = totalRate
-			stat.SchoolUnexcusedRate = unexcusedRate
-		case strings.Contains(label, "schulart"):
-			stat.SchoolTypeAbsenceRate = totalRate
-			stat.SchoolTypeUnexcusedRate = unexcusedRate
-		case strings.Contains(label, "region"):
-			stat.RegionAbsenceRate = totalRate
-			stat.RegionUnexcusedRate = unexcusedRate
-		case strings.Contains(label, "berlin"):
-			stat.BerlinAbsenceRate = totalRate
-			stat.BerlinUnexcusedRate = unexcusedRate
+		case rows.School.matches(label):
+			stat.SchoolAbsenceRate, stat.SchoolUnexcusedRate = totalRate, unexcusedRate
+		case rows.SchoolType.matches(label):
+			stat.SchoolTypeAbsenceRate, stat.SchoolTypeUnexcusedRate = totalRate, unexcusedRate
+		case rows.Region.matches(label):
+			stat.RegionAbsenceRate, stat.RegionUnexcusedRate = totalRate, unexcusedRate
+		case rows.Berlin.matches(label):
+			stat.BerlinAbsenceRate, stat.BerlinUnexcusedRate = totalRate, unexcusedRate
+		default:
+			continue
 		}
+		found = true
+	}
+	if !found {
+		return nil
 	}
 
 	return &stat
 }
 
-// parseInt safely parses an integer from string, handling various formats
-func parseInt(s string) int {
-	// Clean the string (remove spaces, %, etc.)
-	s = strings.TrimSpace(s)
-	s = strings.ReplaceAll(s, " ", "")
-	s = strings.ReplaceAll(s, "%", "")
-	s = strings.ReplaceAll(s, ".", "")
-	s = strings.ReplaceAll(s, ",", "")
+// parseRate parses a percentage cell, or returns nil for cells without a number, such as "–"
+func parseRate(s string) *float64 {
+	val, ok := parseNumber(s)
+	if !ok {
+		return nil
+	}
+	return &val
+}
 
-	if s == "" {
+var (
+	schoolYearPattern = regexp.MustCompile(`\b(\d{4})\s*/\s*(\d{4}|\d{2})\b`)
+	gradePattern      = regexp.MustCompile(`\b(\d{1,2})\b`)
+)
+
+// NormalizeGradeStructureTable converts the classes-per-grade table (Züge) to one record per
+// school year and grade. The table has school years as columns and grades as rows, or the other
+// way round; rows that are no grade, such as totals, and cells without a number are skipped.
+func (s *SchoolDetailsScraper) NormalizeGradeStructureTable(schoolNumber string, table *models.StatisticTable, scrapedAt time.Time) []models.SchoolGradeClasses {
+	if table == nil || len(table.Headers) < 2 {
+		return nil
+	}
+
+	yearColumns := true
+	for _, header := range table.Headers[1:] {
+		if parseSchoolYear(header) == "" {
+			yearColumns = false
+			break
+		}
+	}
+
+	var stats []models.SchoolGradeClasses
+	seen := make(map[string]bool)
+	for _, row := range table.Rows {
+		for i := 1; i < len(row) && i < len(table.Headers); i++ {
+			year, grade := parseSchoolYear(table.Headers[i]), parseGrade(row[0])
+			if !yearColumns {
+				year, grade = parseSchoolYear(row[0]), parseGrade(table.Headers[i])
+			}
+			classes, ok := parseNumber(row[i])
+			if year == "" || grade == 0 || !ok || classes < 0 || classes != math.Trunc(classes) {
+				continue
+			}
+
+			key := fmt.Sprintf("%s/%d", year, grade)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+
+			stats = append(stats, models.SchoolGradeClasses{
+				SchoolNumber: schoolNumber,
+				SchoolYear:   year,
+				Grade:        grade,
+				Classes:      int(classes),
+				ScrapedAt:    scrapedAt,
+			})
+		}
+	}
+
+	return stats
+}
+
+// parseSchoolYear returns the school year in s as "2024/25", or "" if there is none
+func parseSchoolYear(s string) string {
+	match := schoolYearPattern.FindStringSubmatch(s)
+	if match == nil {
+		return ""
+	}
+	return match[1] + "/" + match[2][len(match[2])-2:]
+}
+
+// parseGrade returns the grade (Jahrgangsstufe) in a label such as "Jgst. 7" or "7. Klasse",
+// or 0 if there is none
+func parseGrade(s string) int {
+	match := gradePattern.FindStringSubmatch(s)
+	if match == nil {
+		return 0
+	}
+	grade, _ := strconv.Atoi(match[1])
+	if grade < 1 || grade > 13 {
 		return 0
 	}
+	return grade
+}
+
+// NormalizeStaffingTable converts the staffing table of the personnel section to the staffing
+// coverage and the teachers by qualification. Rows are label and count; the count is taken from
+// the last cell, the total of tables broken down further. The coverage row is recognized by
+// StaffingCoverageRows; rows without a whole number, such as notes, are skipped. Returns nil
+// when no row is recognized.
+func (s *SchoolDetailsScraper) NormalizeStaffingTable(schoolNumber string, table *models.StatisticTable, scrapedAt time.Time) *models.SchoolStaffingStat {
+	if table == nil {
+		return nil
+	}
+
+	stat := models.SchoolStaffingStat{
+		SchoolNumber: schoolNumber,
+		ScrapedAt:    scrapedAt,
+	}
 
-	val, err := strconv.Atoi(s)
-	if err != nil {
+	found := false
+	for _, row := range table.Rows {
+		if len(row) < 2 {
+			continue
+		}
+
+		label := strings.TrimSpace(row[0])
+		value := row[len(row)-1]
+		if s.rules.StaffingCoverageRows.matches(label) {
+			if coverage := parseRate(value); coverage != nil {
+				stat.StaffingCoverage = coverage
+				found = true
+			}
+			continue
+		}
+
+		teachers, ok := parseNumber(value)
+		if label == "" || !ok || teachers < 0 || teachers != math.Trunc(teachers) {
+			continue
+		}
+		stat.Qualifications = append(stat.Qualifications, models.SchoolTeacherQualification{
+			SchoolNumber:  schoolNumber,
+			Qualification: label,
+			Teachers:      int(teachers),
+			ScrapedAt:     scrapedAt,
+		})
+		found = true
+	}
+	if !found {
+		return nil
+	}
+
+	return &stat
+}
+
+// qualityCodePattern splits the number off an indicator label such as "2.1 Unterrichtsgestaltung"
+var qualityCodePattern = regexp.MustCompile(`^(\d+(?:\.\d+)*)\.?\s+(.+)$`)
+
+// NormalizeQualityTable converts the quality profile of the school inspection section to one
+// rating per indicator. The rating is taken from the last cell (QualityRatings), the label
+// from the cells before it. Rows without a rating name the quality area (Qualitätsbereich) of
+// the rows below them. Returns nil when no rating is recognized.
+func (s *SchoolDetailsScraper) NormalizeQualityTable(schoolNumber string, table *models.StatisticTable, scrapedAt time.Time) []models.SchoolQualityIndicator {
+	if table == nil {
+		return nil
+	}
+
+	var indicators []models.SchoolQualityIndicator
+	area := ""
+	for _, row := range table.Rows {
+		var cells []string
+		for _, cell := range row {
+			if cell = strings.Join(strings.Fields(cell), " "); cell != "" {
+				cells = append(cells, cell)
+			}
+		}
+		if len(cells) == 0 {
+			continue
+		}
+
+		rating := s.rules.qualityRating(cells[len(cells)-1])
+		if rating == "" || len(cells) == 1 {
+			if len(cells) == 1 {
+				area = cells[0]
+			}
+			continue
+		}
+
+		indicator := models.SchoolQualityIndicator{
+			SchoolNumber: schoolNumber,
+			Area:         area,
+			Indicator:    strings.Join(cells[:len(cells)-1], " "),
+			Rating:       rating,
+			ScrapedAt:    scrapedAt,
+		}
+		if match := qualityCodePattern.FindStringSubmatch(indicator.Indicator); match != nil {
+			indicator.Code, indicator.Indicator = match[1], match[2]
+		}
+		indicators = append(indicators, indicator)
+	}
+
+	return indicators
+}
+
+// maxExactInt bounds the integers parseInt returns: beyond it a float64 no longer holds every
+// integer exactly
+const maxExactInt = 1 << 53
+
+// parseInt parses a count as printed on the school pages, such as "1.234" or "1 234". Anything
+// that is not a whole number, including decimals like "12,34" and markers like "–" for no data,
+// parses as 0.
+func parseInt(s string) int {
+	val, ok := parseNumber(s)
+	if !ok || val != math.Trunc(val) || math.Abs(val) > maxExactInt {
 		return 0
 	}
+	return int(val)
+}
 
+// parseFloat parses a decimal number or percentage as printed on the school pages, such as
+// "35,8 %" or "1.234,5". Anything that is not a number parses as 0.
+func parseFloat(s string) float64 {
+	val, _ := parseNumber(s)
 	return val
 }
 
-// parseFloat safely parses a float from string, handling German format (comma as decimal separator)
-func parseFloat(s string) float64 {
-	// Clean the string
-	s = strings.TrimSpace(s)
-	s = strings.ReplaceAll(s, " ", "")
-	s = strings.ReplaceAll(s, "%", "")
+// parseNumber parses a number in German format: an optional sign, digits with dots or spaces
+// as thousands separators, an optional decimal comma and an optional trailing percent sign.
+// A dot is only read as a decimal point where it cannot be a thousands separator, so "35.8" is
+// 35.8 but "1.234" is 1234. ok is false for anything else, such as "12,3,4", "1.23,4", "NaN"
+// or "Inf", so no value can break JSON encoding.
+func parseNumber(s string) (val float64, ok bool) {
+	s = strings.TrimSuffix(removeSpaces(s), "%")
+
+	sign := ""
+	if strings.HasPrefix(s, "-") || strings.HasPrefix(s, "+") {
+		sign, s = s[:1], s[1:]
+	}
+
+	whole, fraction, hasDecimals := strings.Cut(s, ",")
+	if strings.Contains(whole, ".") {
+		switch {
+		case isThousandsGrouped(whole):
+			whole = strings.ReplaceAll(whole, ".", "")
+		case !hasDecimals && strings.Count(whole, ".") == 1:
+			whole, fraction, hasDecimals = strings.Cut(whole, ".")
+		default:
+			return 0, false
+		}
+	}
+	if !isDigits(whole) || (hasDecimals && !isDigits(fraction)) {
+		return 0, false
+	}
 
-	// Replace comma with dot for decimal separator
-	s = strings.ReplaceAll(s, ",", ".")
+	number := sign + whole
+	if hasDecimals {
+		number += "." + fraction
+	}
+	val, err := strconv.ParseFloat(number, 64)
+	if err != nil || math.IsInf(val, 0) {
+		return 0, false
+	}
+	return val, true
+}
 
-	if s == "" {
-		return 0.0
+// isThousandsGrouped reports whether s is digits grouped by dots in threes, like "1.234.567"
+func isThousandsGrouped(s string) bool {
+	groups := strings.Split(s, ".")
+	if len(groups) < 2 || len(groups[0]) > 3 || !isDigits(groups[0]) {
+		return false
+	}
+	for _, group := range groups[1:] {
+		if len(group) != 3 || !isDigits(group) {
+			return false
+		}
 	}
+	return true
+}
 
-	val, err := strconv.ParseFloat(s, 64)
-	if err != nil {
-		return 0.0
+// isDigits reports whether s is a non-empty run of ASCII digits
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
 	}
+	return true
+}
 
-	return val
+// removeSpaces removes all whitespace, including the non-breaking spaces used as thousands
+// separators on the school pages
+func removeSpaces(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return -1
+		}
+		return r
+	}, s)
 }
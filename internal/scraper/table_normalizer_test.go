@@ -0,0 +1,658 @@
+package scraper
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"schools-be/internal/models"
+)
+
+// germanThousands formats n with dots as thousands separators, e.g. 1234567 as "1.234.567"
+func germanThousands(n int64) string {
+	digits := strconv.FormatInt(n, 10)
+	sign := ""
+	if n < 0 {
+		sign, digits = "-", digits[1:]
+	}
+	var b strings.Builder
+	for i, d := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			b.WriteByte('.')
+		}
+		b.WriteRune(d)
+	}
+	return sign + b.String()
+}
+
+func TestParseNumber(t *testing.T) {
+	tests := []struct {
+		in     string
+		want   float64
+		wantOK bool
+	}{
+		// Integers and thousands separators
+		{"0", 0, true},
+		{"45", 45, true},
+		{"1.234", 1234, true},
+		{"12.345", 12345, true},
+		{"1.234.567", 1234567, true},
+		{"1 234", 1234, true},
+		{"1\u00a0234", 1234, true}, // Non-breaking space
+		{" 812 ", 812, true},
+		{"-3", -3, true},
+		{"+7", 7, true},
+		{"007", 7, true},
+
+		// Decimal comma
+		{"12,34", 12.34, true},
+		{"35,8", 35.8, true},
+		{"0,5", 0.5, true},
+		{"1.234,5", 1234.5, true},
+		{"1 234,56", 1234.56, true},
+		{"-2,5", -2.5, true},
+
+		// Percentages
+		{"6,1 %", 6.1, true},
+		{"45%", 45, true},
+		{"100 %", 100, true},
+
+		// A dot that cannot be a thousands separator is a decimal point
+		{"35.8", 35.8, true},
+		{"1.2345", 1.2345, true},
+		{"0.5", 0.5, true},
+
+		// Not numbers
+		{"", 0, false},
+		{"%", 0, false},
+		{"-", 0, false},
+		{"–", 0, false},
+		{"k.A.", 0, false},
+		{"abc", 0, false},
+		{",5", 0, false},
+		{"12,", 0, false},
+		{"1,2,3", 0, false},
+		{"1.23,4", 0, false},
+		{"1.2.3", 0, false},
+		{"1.234.5", 0, false},
+		{"1,234.5", 0, false},
+		{"1.234,5,6", 0, false},
+		{"5 % %", 0, false},
+		{"NaN", 0, false},
+		{"Inf", 0, false},
+		{"1e3", 0, false},
+		{"0x1p-2", 0, false},
+		{"--1", 0, false},
+		{"1-", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseNumber(tt.in)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("parseNumber(%q) = %v, %v, want %v, %v", tt.in, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestParseInt(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int
+	}{
+		{"1.234", 1234},
+		{"1 234", 1234},
+		{"864", 864},
+		{"45 %", 45},
+		{"12,0", 12},
+		{"12,34", 0}, // Not a count; used to parse as 1234
+		{"1,234", 0}, // German decimal, not an English thousands separator
+		{"35.8", 0},
+		{"–", 0},
+		{"99999999999999999999", 0},
+	}
+	for _, tt := range tests {
+		if got := parseInt(tt.in); got != tt.want {
+			t.Errorf("parseInt(%q) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseFloat(t *testing.T) {
+	tests := []struct {
+		in   string
+		want float64
+	}{
+		{"35,8 %", 35.8},
+		{"1.234,5", 1234.5},
+		{"1.234", 1234},
+		{"12,34", 12.34},
+		{"1,2,3", 0},
+		{"NaN", 0},
+		{"", 0},
+	}
+	for _, tt := range tests {
+		if got := parseFloat(tt.in); got != tt.want {
+			t.Errorf("parseFloat(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func FuzzParseInt(f *testing.F) {
+	for _, seed := range []string{"", "0", "45", "45 %", "1.234", "1,234", " 812 ", "1 234", "-", "–", "-3", "+7", "abc", "99999999999999999999"} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, s string) {
+		// Must not panic on any input, and agrees with parseFloat on whole numbers
+		if n := parseInt(s); n != 0 && float64(n) != parseFloat(s) {
+			t.Errorf("parseInt(%q) = %d, parseFloat = %v", s, n, parseFloat(s))
+		}
+
+		// Numbers formatted the way the school pages print them parse back to themselves
+		if n, err := strconv.ParseInt(s, 10, 32); err == nil {
+			for _, formatted := range []string{
+				germanThousands(n),
+				strings.ReplaceAll(germanThousands(n), ".", " "),
+				" " + strconv.FormatInt(n, 10) + " %",
+			} {
+				if got := parseInt(formatted); got != int(n) {
+					t.Errorf("parseInt(%q) = %d, want %d", formatted, got, n)
+				}
+			}
+		}
+	})
+}
+
+func FuzzParseFloat(f *testing.F) {
+	for _, seed := range []string{"", "0", "35,8", "35.8", "6,1 %", "1.234,5", "NaN", "nan", "Inf", "-Infinity", "1e400", "0x1p-2", ",", "1,2,3"} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, s string) {
+		got := parseFloat(s)
+		if math.IsNaN(got) || math.IsInf(got, 0) {
+			t.Fatalf("parseFloat(%q) = %v, want a finite number", s, got)
+		}
+
+		// Decimal numbers written with a German decimal comma parse back to themselves
+		if v, err := strconv.ParseFloat(s, 64); err == nil && !math.IsNaN(v) && !math.IsInf(v, 0) {
+			formatted := strings.ReplaceAll(strconv.FormatFloat(v, 'f', -1, 64), ".", ",")
+			if got := parseFloat(formatted + " %"); got != v {
+				t.Errorf("parseFloat(%q) = %v, want %v", formatted+" %", got, v)
+			}
+		}
+	})
+}
+
+func TestNormalizeAbsenceTable(t *testing.T) {
+	rate := func(v float64) *float64 { return &v }
+	scrapedAt := time.Date(2025, 9, 1, 4, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		rows [][]string
+		want *models.SchoolAbsenceStat
+	}{
+		{
+			name: "complete",
+			rows: [][]string{
+				{"Fehlzeiten der Schule", "6,1 %", "1,2 %"},
+				{"Durchschnitt der Schulart", "7,0 %", "1,5 %"},
+				{"Durchschnitt der Region", "7,5 %", "1,8 %"},
+				{"Durchschnitt Berlin", "8,9 %", "2,0 %"},
+			},
+			want: &models.SchoolAbsenceStat{
+				SchoolAbsenceRate: rate(6.1), SchoolUnexcusedRate: rate(1.2),
+				SchoolTypeAbsenceRate: rate(7.0), SchoolTypeUnexcusedRate: rate(1.5),
+				RegionAbsenceRate: rate(7.5), RegionUnexcusedRate: rate(1.8),
+				BerlinAbsenceRate: rate(8.9), BerlinUnexcusedRate: rate(2.0),
+			},
+		},
+		{
+			name: "zero is reported",
+			rows: [][]string{{"Schule", "0,0 %", "0 %"}},
+			want: &models.SchoolAbsenceStat{SchoolAbsenceRate: rate(0), SchoolUnexcusedRate: rate(0)},
+		},
+		{
+			name: "missing rows",
+			rows: [][]string{
+				{"Schule", "6,1 %", "1,2 %"},
+				{"Berlin", "8,9 %", "2,0 %"},
+			},
+			want: &models.SchoolAbsenceStat{
+				SchoolAbsenceRate: rate(6.1), SchoolUnexcusedRate: rate(1.2),
+				BerlinAbsenceRate: rate(8.9), BerlinUnexcusedRate: rate(2.0),
+			},
+		},
+		{
+			name: "missing cells",
+			rows: [][]string{
+				{"Schule", "–", "1,2 %"},
+				{"Schulart", "7,0 %"},
+				{"Region"},
+			},
+			want: &models.SchoolAbsenceStat{SchoolUnexcusedRate: rate(1.2), SchoolTypeAbsenceRate: rate(7.0)},
+		},
+		{
+			name: "no known rows",
+			rows: [][]string{{"Zeitraum", "2024/25"}},
+		},
+		{
+			name: "empty",
+		},
+	}
+
+	s := newTestScraper()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := s.NormalizeAbsenceTable("01Y02", &models.StatisticTable{Rows: tt.rows}, scrapedAt)
+			if tt.want != nil {
+				tt.want.SchoolNumber, tt.want.ScrapedAt = "01Y02", scrapedAt
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %s, want %s", formatAbsence(got), formatAbsence(tt.want))
+			}
+		})
+	}
+}
+
+// formatAbsence prints the rates of stat rather than their addresses
+func formatAbsence(stat *models.SchoolAbsenceStat) string {
+	if stat == nil {
+		return "nil"
+	}
+	var b strings.Builder
+	for _, r := range []*float64{
+		stat.SchoolAbsenceRate, stat.SchoolUnexcusedRate, stat.SchoolTypeAbsenceRate, stat.SchoolTypeUnexcusedRate,
+		stat.RegionAbsenceRate, stat.RegionUnexcusedRate, stat.BerlinAbsenceRate, stat.BerlinUnexcusedRate,
+	} {
+		if r == nil {
+			b.WriteString(" nil")
+		} else {
+			b.WriteString(" " + strconv.FormatFloat(*r, 'f', -1, 64))
+		}
+	}
+	return "[" + strings.TrimSpace(b.String()) + "]"
+}
+
+// FuzzNormalizeCitizenshipTable feeds tables built from rows separated by newlines and
+// cells separated by "|"
+func FuzzNormalizeCitizenshipTable(f *testing.F) {
+	f.Add("Deutschland|402|371|773\nEU-Staaten|21|18|39\nInsgesamt|450|414|864")
+	f.Add("|1|2|3\nTürkei|1\n  Polen  | 1.234 | 5 % |x")
+	f.Add("")
+	f.Add("\n\n|||")
+
+	s := newTestScraper()
+	scrapedAt := time.Date(2025, 9, 1, 4, 0, 0, 0, time.UTC)
+	f.Fuzz(func(t *testing.T, data string) {
+		table := &models.StatisticTable{Headers: []string{"Staatsangehörigkeit", "weiblich", "männlich", "gesamt"}}
+		for _, line := range strings.Split(data, "\n") {
+			table.Rows = append(table.Rows, strings.Split(line, "|"))
+		}
+
+		stats := s.NormalizeCitizenshipTable("01Y02", table, scrapedAt)
+		if len(stats) > len(table.Rows) {
+			t.Fatalf("%d stats from %d rows", len(stats), len(table.Rows))
+		}
+
+		rows := 0
+		for _, row := range table.Rows {
+			if len(row) < 4 || strings.TrimSpace(row[0]) == "" {
+				continue
+			}
+			stat := stats[rows]
+			rows++
+
+			if stat.Citizenship != strings.TrimSpace(row[0]) {
+				t.Errorf("citizenship = %q, want %q", stat.Citizenship, strings.TrimSpace(row[0]))
+			}
+			if stat.SchoolNumber != "01Y02" || !stat.ScrapedAt.Equal(scrapedAt) {
+				t.Errorf("school number or scrape time not carried over: %+v", stat)
+			}
+			if stat.FemaleStudents != parseInt(row[1]) || stat.MaleStudents != parseInt(row[2]) || stat.Total != parseInt(row[3]) {
+				t.Errorf("counts of row %q = %d/%d/%d", row, stat.FemaleStudents, stat.MaleStudents, stat.Total)
+			}
+		}
+		if rows != len(stats) {
+			t.Errorf("%d stats, want %d", len(stats), rows)
+		}
+	})
+}
+
+func TestValidateCitizenshipStats(t *testing.T) {
+	row := func(citizenship string, female, male, total int) models.SchoolCitizenshipStat {
+		return models.SchoolCitizenshipStat{SchoolNumber: "01Y02", Citizenship: citizenship, FemaleStudents: female, MaleStudents: male, Total: total}
+	}
+
+	tests := []struct {
+		name       string
+		stats      []models.SchoolCitizenshipStat
+		wantIssues []string
+		wantBroken bool
+	}{
+		{
+			name:  "consistent",
+			stats: []models.SchoolCitizenshipStat{row("Deutschland", 402, 371, 773), row("Türkei", 21, 18, 39), row("Insgesamt", 423, 389, 812)},
+		},
+		{
+			name:  "no total row",
+			stats: []models.SchoolCitizenshipStat{row("Deutschland", 402, 371, 773), row("Türkei", 21, 18, 39)},
+		},
+		{
+			name:       "row does not add up",
+			stats:      []models.SchoolCitizenshipStat{row("Deutschland", 402, 371, 773), row("Türkei", 21, 17, 39), row("Insgesamt", 423, 389, 812)},
+			wantIssues: []string{"Türkei 39 38"},
+		},
+		{
+			name:       "total row differs from categories",
+			stats:      []models.SchoolCitizenshipStat{row("Deutschland", 402, 371, 773), row("Türkei", 21, 18, 39), row("Insgesamt", 450, 414, 864)},
+			wantIssues: []string{"Insgesamt vs. categories 864 812"},
+			wantBroken: true,
+		},
+		{
+			name:       "most rows do not add up",
+			stats:      []models.SchoolCitizenshipStat{row("Deutschland", 402, 0, 773), row("Türkei", 0, 18, 39), row("Polen", 3, 2, 5)},
+			wantIssues: []string{"Deutschland 773 402", "Türkei 39 18"},
+			wantBroken: true,
+		},
+		{
+			name: "empty",
+		},
+	}
+
+	s := newTestScraper()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issues, broken := s.ValidateCitizenshipStats(tt.stats)
+			if broken != tt.wantBroken {
+				t.Errorf("broken = %v, want %v", broken, tt.wantBroken)
+			}
+			var got []string
+			for _, issue := range issues {
+				got = append(got, issue.Detail+" "+strconv.Itoa(issue.Total)+" "+strconv.Itoa(issue.Sum))
+				if issue.Excluded != tt.wantBroken || issue.Dataset != models.DatasetCitizenship || issue.SchoolNumber != "01Y02" {
+					t.Errorf("issue = %+v", issue)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.wantIssues) {
+				t.Errorf("issues = %q, want %q", got, tt.wantIssues)
+			}
+		})
+	}
+}
+
+func TestNormalizeDiverseColumns(t *testing.T) {
+	s := newTestScraper()
+	scrapedAt := time.Date(2025, 9, 1, 4, 0, 0, 0, time.UTC)
+
+	citizenship := func(table *models.StatisticTable) string {
+		var got []string
+		for _, stat := range s.NormalizeCitizenshipTable("01Y02", table, scrapedAt) {
+			got = append(got, fmt.Sprintf("%s %d/%d/%d=%d", stat.Citizenship, stat.FemaleStudents, stat.MaleStudents, stat.DiverseStudents, stat.Total))
+		}
+		return strings.Join(got, ", ")
+	}
+	for _, tt := range []struct {
+		name  string
+		table *models.StatisticTable
+		want  string
+	}{
+		{
+			name: "legacy headers",
+			table: &models.StatisticTable{
+				Headers: []string{"Staatsangehörigkeit", "weiblich", "männlich", "gesamt"},
+				Rows:    [][]string{{"Türkei", "21", "18", "39"}},
+			},
+			want: "Türkei 21/18/0=39",
+		},
+		{
+			name: "divers header",
+			table: &models.StatisticTable{
+				Headers: []string{"Staatsangehörigkeit", "weiblich", "männlich", "divers", "gesamt"},
+				Rows:    [][]string{{"Türkei", "21", "18", "1", "40"}, {"Polen", "3", "2", "–", "5"}},
+			},
+			want: "Türkei 21/18/1=40, Polen 3/2/0=5",
+		},
+		{
+			name:  "no headers",
+			table: &models.StatisticTable{Rows: [][]string{{"Türkei", "21", "18", "1", "40"}, {"Polen", "3", "2", "5"}}},
+			want:  "Türkei 21/18/1=40, Polen 3/2/0=5",
+		},
+		{
+			name: "divers header, short row",
+			table: &models.StatisticTable{
+				Headers: []string{"Staatsangehörigkeit", "w", "m", "d (divers)", "gesamt"},
+				Rows:    [][]string{{"Türkei", "21", "18", "39"}},
+			},
+		},
+	} {
+		t.Run("citizenship "+tt.name, func(t *testing.T) {
+			if got := citizenship(tt.table); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	language := func(table *models.StatisticTable) string {
+		stat := s.NormalizeLanguageTable("01Y02", table, scrapedAt)
+		if stat == nil {
+			return "nil"
+		}
+		return fmt.Sprintf("%d: %d/%d/%d=%d (%v%%)", stat.TotalStudents, stat.NDHFemaleStudents, stat.NDHMaleStudents, stat.NDHDiverseStudents, stat.NDHTotal, stat.NDHPercentage)
+	}
+	for _, tt := range []struct {
+		name  string
+		table *models.StatisticTable
+		want  string
+	}{
+		{
+			name:  "legacy",
+			table: &models.StatisticTable{Rows: [][]string{{"Schüler", "w", "m", "ndH", "%"}, {"864", "150", "160", "310", "35,9 %"}}},
+			want:  "864: 150/160/0=310 (35.9%)",
+		},
+		{
+			name:  "divers",
+			table: &models.StatisticTable{Rows: [][]string{{"Schüler", "w", "m", "d", "ndH", "%"}, {"864", "150", "160", "2", "312", "36,1 %"}}},
+			want:  "864: 150/160/2=312 (36.1%)",
+		},
+		{
+			name: "divers header",
+			table: &models.StatisticTable{
+				Headers: []string{"Schüler", "weiblich", "männlich", "divers", "ndH gesamt", "Anteil"},
+				Rows:    [][]string{{"864", "150", "160", "2", "312", "36,1 %"}, {"864", "150", "160", "2", "312", "36,1 %"}},
+			},
+			want: "864: 150/160/2=312 (36.1%)",
+		},
+	} {
+		t.Run("language "+tt.name, func(t *testing.T) {
+			if got := language(tt.table); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	issues, broken := s.ValidateCitizenshipStats([]models.SchoolCitizenshipStat{
+		{Citizenship: "Türkei", FemaleStudents: 21, MaleStudents: 18, DiverseStudents: 1, Total: 40},
+		{Citizenship: "Insgesamt", FemaleStudents: 21, MaleStudents: 18, DiverseStudents: 1, Total: 40},
+	})
+	if len(issues) > 0 || broken {
+		t.Errorf("diverse students not counted: %+v", issues)
+	}
+}
+
+func TestNormalizeGradeStructureTable(t *testing.T) {
+	s := newTestScraper()
+	scrapedAt := time.Date(2025, 9, 1, 4, 0, 0, 0, time.UTC)
+
+	normalize := func(table *models.StatisticTable) string {
+		var got []string
+		for _, stat := range s.NormalizeGradeStructureTable("01Y02", table, scrapedAt) {
+			got = append(got, fmt.Sprintf("%s %d=%d", stat.SchoolYear, stat.Grade, stat.Classes))
+		}
+		return strings.Join(got, ", ")
+	}
+	for _, tt := range []struct {
+		name  string
+		table *models.StatisticTable
+		want  string
+	}{
+		{name: "nil", table: nil},
+		{
+			name: "school years as columns",
+			table: &models.StatisticTable{
+				Headers: []string{"Jahrgangsstufe", "2023/24", "2024/2025"},
+				Rows:    [][]string{{"Jgst. 7", "4", "5"}, {"8. Klasse", "4", "–"}, {"Insgesamt", "8", "5"}},
+			},
+			want: "2023/24 7=4, 2024/25 7=5, 2023/24 8=4",
+		},
+		{
+			name: "grades as columns",
+			table: &models.StatisticTable{
+				Headers: []string{"Schuljahr", "5", "6", "gesamt"},
+				Rows:    [][]string{{"2024/25", "3", "3", "6"}, {"2024/25", "9", "9", "18"}},
+			},
+			want: "2024/25 5=3, 2024/25 6=3",
+		},
+		{
+			name: "fractional and negative counts",
+			table: &models.StatisticTable{
+				Headers: []string{"Jahrgangsstufe", "2024/25"},
+				Rows:    [][]string{{"7", "4,5"}, {"8", "-1"}, {"14", "2"}, {"9", "3"}},
+			},
+			want: "2024/25 9=3",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := normalize(tt.table); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeStaffingTable(t *testing.T) {
+	s := newTestScraper()
+	scrapedAt := time.Date(2025, 9, 1, 4, 0, 0, 0, time.UTC)
+
+	normalize := func(table *models.StatisticTable) string {
+		stat := s.NormalizeStaffingTable("01Y02", table, scrapedAt)
+		if stat == nil {
+			return "nil"
+		}
+		got := "coverage=nil"
+		if stat.StaffingCoverage != nil {
+			got = fmt.Sprintf("coverage=%v", *stat.StaffingCoverage)
+		}
+		for _, q := range stat.Qualifications {
+			got += fmt.Sprintf(", %s=%d", q.Qualification, q.Teachers)
+		}
+		return got
+	}
+	for _, tt := range []struct {
+		name  string
+		table *models.StatisticTable
+		want  string
+	}{
+		{name: "nil", table: nil, want: "nil"},
+		{
+			name: "label and count",
+			table: &models.StatisticTable{
+				Headers: []string{"Personal", "Anzahl"},
+				Rows: [][]string{
+					{"Lehrkräfte mit Lehramtsbefähigung", "45"},
+					{"Quereinsteigende", "6"},
+					{"Personalausstattung in %", "101,2 %"},
+				},
+			},
+			want: "coverage=101.2, Lehrkräfte mit Lehramtsbefähigung=45, Quereinsteigende=6",
+		},
+		{
+			name: "gender breakdown, coverage not published",
+			table: &models.StatisticTable{
+				Rows: [][]string{
+					{"Lehrkräfte mit Lehramtsbefähigung", "30", "15", "45"},
+					{"Ausstattung in %", "–"},
+					{"Stand: 01.09.2025", ""},
+				},
+			},
+			want: "coverage=nil, Lehrkräfte mit Lehramtsbefähigung=45",
+		},
+		{
+			name:  "nothing recognized",
+			table: &models.StatisticTable{Rows: [][]string{{"Keine Angaben", "–"}}},
+			want:  "nil",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := normalize(tt.table); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeQualityTable(t *testing.T) {
+	s := newTestScraper()
+	scrapedAt := time.Date(2025, 9, 1, 4, 0, 0, 0, time.UTC)
+
+	normalize := func(table *models.StatisticTable) string {
+		indicators := s.NormalizeQualityTable("01Y02", table, scrapedAt)
+		if indicators == nil {
+			return "nil"
+		}
+		var got []string
+		for _, q := range indicators {
+			got = append(got, fmt.Sprintf("%s|%s|%s=%s", q.Area, q.Code, q.Indicator, q.Rating))
+		}
+		return strings.Join(got, ", ")
+	}
+	for _, tt := range []struct {
+		name  string
+		table *models.StatisticTable
+		want  string
+	}{
+		{name: "nil", table: nil, want: "nil"},
+		{
+			name: "areas and numbered indicators",
+			table: &models.StatisticTable{
+				Headers: []string{"Qualitätsmerkmal", "Bewertung"},
+				Rows: [][]string{
+					{"Qualitätsbereich 2: Lehr- und Lernprozesse"},
+					{"2.1 Unterrichtsgestaltung", "B"},
+					{"2.2", "Förderung", "eher schwach ausgeprägt"},
+					{"Qualitätsbereich 3: Schulkultur"},
+					{"3.1 Schulklima", " a "},
+				},
+			},
+			want: "Qualitätsbereich 2: Lehr- und Lernprozesse|2.1|Unterrichtsgestaltung=B, " +
+				"Qualitätsbereich 2: Lehr- und Lernprozesse|2.2|Förderung=C, " +
+				"Qualitätsbereich 3: Schulkultur|3.1|Schulklima=A",
+		},
+		{
+			name: "no areas, notes and unrated rows skipped",
+			table: &models.StatisticTable{
+				Rows: [][]string{
+					{"Schulführung", "schwach ausgeprägt"},
+					{"Kooperation", "nicht bewertet"},
+					{"Stand der Inspektion: 2023", ""},
+				},
+			},
+			want: "||Schulführung=D",
+		},
+		{
+			name:  "nothing recognized",
+			table: &models.StatisticTable{Rows: [][]string{{"Keine Angaben", "–"}}},
+			want:  "nil",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := normalize(tt.table); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -3,12 +3,20 @@ package server
 import (
 	"context"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"time"
 
+	"schools-be/api"
+	"schools-be/internal/analytics"
+	"schools-be/internal/auth"
 	"schools-be/internal/config"
 	"schools-be/internal/handler"
+	"schools-be/internal/logging"
+	"schools-be/internal/metrics"
 	appmiddleware "schools-be/internal/middleware"
+	"schools-be/internal/reporting"
+	"schools-be/internal/usage"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
@@ -16,22 +24,36 @@ import (
 )
 
 type Server struct {
-	router *chi.Mux
-	config *config.Config
-	server *http.Server
+	router   *chi.Mux
+	config   *config.Config
+	live     *config.Live
+	logger   *slog.Logger
+	redactor *logging.Redactor
+	oidc     *auth.OIDCVerifier // nil unless an identity provider is configured
+	signer   *auth.URLSigner
+	reporter *reporting.Reporter
+	server   *http.Server
 }
 
-func New(cfg *config.Config, schoolHandler *handler.SchoolHandler, constructionProjectHandler *handler.ConstructionProjectHandler) *Server {
+func New(cfg *config.Config, live *config.Live, logger *slog.Logger, redactor *logging.Redactor, signer *auth.URLSigner, reporter *reporting.Reporter, schoolHandler *handler.SchoolHandler, constructionProjectHandler *handler.ConstructionProjectHandler, exportHandler *handler.ExportHandler, chatHandler *handler.ChatHandler, locationHandler *handler.LocationHandler, statisticHandler *handler.StatisticHandler, schoolDetailHandler *handler.SchoolDetailHandler, forecastHandler *handler.ForecastHandler, examResultHandler *handler.ExamResultHandler, previewHandler *handler.PreviewHandler, syncHandler *handler.SyncHandler, queryHandler *handler.QueryHandler, publicHandler *handler.PublicHandler, correctionHandler *handler.CorrectionHandler, adminHandler *handler.AdminHandler, collector *analytics.Collector, meter *usage.Meter) *Server {
 	s := &Server{
-		router: chi.NewRouter(),
-		config: cfg,
+		router:   chi.NewRouter(),
+		config:   cfg,
+		live:     live,
+		logger:   logger.With(slog.String("component", "http")),
+		redactor: redactor,
+		signer:   signer,
+		reporter: reporter,
+	}
+	if cfg.OIDCEnabled() {
+		s.oidc = auth.NewOIDCVerifier(cfg.OIDCIssuer, cfg.OIDCAudience, cfg.OIDCRoleClaim, nil)
 	}
 
 	// Setup middleware
 	s.setupMiddleware()
 
 	// Setup routes
-	s.setupRoutes(schoolHandler, constructionProjectHandler)
+	s.setupRoutes(schoolHandler, constructionProjectHandler, exportHandler, chatHandler, locationHandler, statisticHandler, schoolDetailHandler, forecastHandler, examResultHandler, previewHandler, syncHandler, queryHandler, publicHandler, correctionHandler, adminHandler, collector, meter)
 
 	// Create HTTP server
 	s.server = &http.Server{
@@ -48,48 +70,186 @@ func New(cfg *config.Config, schoolHandler *handler.SchoolHandler, constructionP
 func (s *Server) setupMiddleware() {
 	// Basic middleware
 	s.router.Use(middleware.RequestID)
+	s.router.Use(appmiddleware.RequestIDHeader)
 	s.router.Use(middleware.RealIP)
-	s.router.Use(middleware.Logger)
-	s.router.Use(middleware.Recoverer)
+	s.router.Use(appmiddleware.RequestLogger(s.redactor, s.logger))
+	s.router.Use(appmiddleware.Recoverer(s.reporter, s.logger))
+
+	// Security headers (HSTS only outside development)
+	s.router.Use(appmiddleware.SecurityHeaders(!s.config.IsDevelopment()))
 
 	// Timeout middleware
 	s.router.Use(middleware.Timeout(120 * time.Second))
 
-	// CORS middleware
+	// CORS middleware (origins are looked up per request, so a config reload applies them)
 	s.router.Use(cors.Handler(cors.Options{
-		AllowedOrigins:   []string{"http://localhost:3000", "http://localhost:8080"},
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-API-Key"},
-		ExposedHeaders:   []string{"Link"},
+		AllowOriginFunc: func(r *http.Request, origin string) bool {
+			return s.live.OriginAllowed(origin)
+		},
+		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-API-Key", "X-Admin-Key", "X-Location-Token", "If-Match", "X-Request-ID"},
+		ExposedHeaders:   []string{"Link", "ETag", "X-Request-ID", "Retry-After", "Warning"},
 		AllowCredentials: true,
 		MaxAge:           300,
 	}))
 }
 
-func (s *Server) setupRoutes(schoolHandler *handler.SchoolHandler, constructionProjectHandler *handler.ConstructionProjectHandler) {
+func (s *Server) setupRoutes(schoolHandler *handler.SchoolHandler, constructionProjectHandler *handler.ConstructionProjectHandler, exportHandler *handler.ExportHandler, chatHandler *handler.ChatHandler, locationHandler *handler.LocationHandler, statisticHandler *handler.StatisticHandler, schoolDetailHandler *handler.SchoolDetailHandler, forecastHandler *handler.ForecastHandler, examResultHandler *handler.ExamResultHandler, previewHandler *handler.PreviewHandler, syncHandler *handler.SyncHandler, queryHandler *handler.QueryHandler, publicHandler *handler.PublicHandler, correctionHandler *handler.CorrectionHandler, adminHandler *handler.AdminHandler, collector *analytics.Collector, meter *usage.Meter) {
 	// Health check (no authentication required)
-	healthHandler := handler.NewHealthHandler()
+	healthHandler := handler.NewHealthHandler(s.logger)
 	s.router.Get("/health", healthHandler.HealthCheck)
 
+	// OpenAPI description of the public API (no authentication required)
+	s.router.Get("/openapi.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(api.Spec)
+	})
+
+	// Prometheus metrics (admin API key as Bearer token or Basic auth password, or an identity
+	// provider token); query parameters and error details of the instance are not public
+	s.router.Group(func(r chi.Router) {
+		r.Use(appmiddleware.Authenticate(s.live, s.oidc, s.signer, s.logger))
+		r.Use(appmiddleware.RequireRole(s.live, auth.RoleAdmin, s.logger))
+		r.Handle("/metrics", metrics.Default.Handler())
+	})
+
+	// Open data aggregates (no authentication required, cached for PUBLIC_CACHE_TTL)
+	s.router.Route("/public/v1", func(r chi.Router) {
+		r.Use(collector.Middleware)
+		r.Get("/districts", publicHandler.GetDistricts)
+		r.Get("/freshness", publicHandler.GetFreshness)
+		r.Get("/stats", publicHandler.GetStats)
+	})
+
+	// Admin dashboard (admin API key via Basic auth, or an identity provider token)
+	s.router.Route("/admin", func(r chi.Router) {
+		r.Use(appmiddleware.Authenticate(s.live, s.oidc, s.signer, s.logger))
+		r.Use(appmiddleware.RequireRole(s.live, auth.RoleAdmin, s.logger))
+		r.Use(appmiddleware.ContentSecurityPolicy(appmiddleware.AdminContentSecurityPolicy))
+		r.Get("/", adminHandler.Dashboard)
+		r.Post("/jobs/{name}", adminHandler.TriggerJob)
+	})
+
 	// API routes (with authentication)
 	s.router.Route("/api/v1", func(r chi.Router) {
-		// Apply API key authentication middleware to all API routes
-		r.Use(appmiddleware.APIKeyAuth(s.config))
+		// Resolve the caller's role from its key; read endpoints are open to every role
+		r.Use(appmiddleware.Authenticate(s.live, s.oidc, s.signer, s.logger))
+		r.Use(appmiddleware.RequireRole(s.live, auth.RolePublic, s.logger))
+
+		// JSON bodies only
+		r.Use(appmiddleware.RequireJSON)
+
+		// Count anonymous endpoint usage (no-op unless ANALYTICS_ENABLED)
+		r.Use(collector.Middleware)
+
+		// Account traffic per key and enforce partner quotas
+		r.Use(meter.Middleware)
+
+		// Canonical example responses of the documented endpoints, for partner contract tests and mocks
+		r.Get("/meta/examples", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write(api.Examples)
+		})
 
 		// Schools endpoints
 		r.Route("/schools", func(r chi.Router) {
 			r.Get("/", schoolHandler.GetSchoolsEnriched)
+			r.With(appmiddleware.RequireFeature(s.live, "search")).Get("/search", schoolHandler.SearchSchools)
+			r.Get("/nearby", schoolHandler.GetNearbySchools)
 			r.Get("/{id}", schoolHandler.GetSchoolEnriched)
-			r.Get("/{id}/summary", schoolHandler.GetSchoolSummary)
-			r.Post("/{id}/routes", schoolHandler.CalculateRoutes)
+			r.With(appmiddleware.RequireFeature(s.live, "ai")).Get("/{id}/summary", schoolHandler.GetSchoolSummary)
+			r.Get("/{id}/similar", schoolHandler.GetSimilarSchools)
+			r.With(appmiddleware.RequireFeature(s.live, "pdf")).Get("/{id}/profile.pdf", schoolHandler.GetSchoolProfilePDF)
+			r.Get("/{id}/map.png", schoolHandler.GetSchoolMap)
+			r.With(appmiddleware.RequireFeature(s.live, "previews")).Get("/{id}/preview", previewHandler.GetSchoolPreview)
+			r.With(appmiddleware.RequireFeature(s.live, "ai")).Post("/{id}/ask", schoolHandler.AskSchool)
+			r.With(appmiddleware.RequireFeature(s.live, "routes")).Post("/{id}/routes", schoolHandler.CalculateRoutes)
+			r.With(appmiddleware.RequireFeature(s.live, "corrections")).Post("/{id}/corrections", correctionHandler.Submit)
+			r.Get("/{bsn}/statistics", statisticHandler.GetBySchool)
+			r.Get("/{bsn}/staffing", statisticHandler.GetStaffingBySchool)
+			r.Get("/{bsn}/quality", statisticHandler.GetQualityBySchool)
+			r.Get("/{bsn}/exam-results", examResultHandler.GetBySchool)
+		})
+
+		// Scraped school statistics (students, teachers, classes by school year)
+		r.Route("/statistics", func(r chi.Router) {
+			r.Get("/", statisticHandler.GetAll)
+			r.Get("/summary", statisticHandler.GetSummary)
+			r.Get("/staffing", statisticHandler.GetStaffing)
 		})
 
+		// Scraped school portrait data with decoded statistic tables
+		r.Route("/school-details", func(r chi.Router) {
+			r.Get("/", schoolDetailHandler.GetAll)
+			r.Get("/programs", schoolDetailHandler.GetPrograms)
+			r.Get("/{bsn}", schoolDetailHandler.GetBySchoolNumber)
+			r.Get("/{bsn}/raw-tables", schoolDetailHandler.GetRawTables)
+			r.Get("/{bsn}/grade-structure", schoolDetailHandler.GetGradeStructure)
+		})
+
+		// Planning analytics derived from statistics and construction projects
+		r.Route("/analytics", func(r chi.Router) {
+			r.Get("/capacity-forecast", forecastHandler.GetCapacityForecast)
+		})
+
+		// Delta sync for offline copies of the dataset
+		r.Get("/sync", syncHandler.GetChanges)
+
+		// Read-only SQL over the dataset for power users
+		r.With(appmiddleware.RequireRole(s.live, auth.RoleAdmin, s.logger), appmiddleware.RequireFeature(s.live, "query")).Post("/query", queryHandler.RunQuery)
+
 		// Construction projects endpoints
 		r.Route("/construction-projects", func(r chi.Router) {
 			r.Get("/", constructionProjectHandler.GetAll)
 			r.Get("/standalone", constructionProjectHandler.GetStandalone)
+			r.Get("/timeline", constructionProjectHandler.GetTimeline)
+			r.Get("/budget-rollup", constructionProjectHandler.GetBudgetRollup)
 			r.Get("/{id}", constructionProjectHandler.GetByID)
 		})
+
+		// Anonymous home locations for commute-sorted school lists
+		r.Route("/locations", func(r chi.Router) {
+			r.Post("/", locationHandler.RegisterLocation)
+			r.Get("/{token}", locationHandler.GetLocation)
+		})
+
+		// Chat sessions with the school advisor
+		r.Route("/chat/sessions", func(r chi.Router) {
+			r.Use(appmiddleware.RequireFeature(s.live, "chat"))
+			r.Post("/", chatHandler.StartSession)
+			r.Get("/{id}", chatHandler.GetSession)
+			r.Post("/{id}/messages", chatHandler.SendMessage)
+			r.Delete("/{id}", chatHandler.DeleteSession)
+		})
+
+		// Management endpoints: school data corrections for partners, the rest for admins
+		r.Route("/admin", func(r chi.Router) {
+			r.Group(func(r chi.Router) {
+				r.Use(appmiddleware.RequireRole(s.live, auth.RolePartner, s.logger))
+				r.Patch("/schools:batch", schoolHandler.BatchUpdateSchools)
+				r.Patch("/schools/{id}", schoolHandler.UpdateSchool)
+			})
+
+			r.Group(func(r chi.Router) {
+				r.Use(appmiddleware.RequireRole(s.live, auth.RoleAdmin, s.logger))
+				r.Get("/analytics", adminHandler.GetAnalytics)
+				r.Get("/usage", adminHandler.GetUsage)
+				r.Get("/completeness", adminHandler.GetCompleteness)
+				r.Get("/verify", adminHandler.VerifyConsistency)
+				r.Post("/verify", adminHandler.VerifyConsistency)
+				r.Get("/export/database", adminHandler.ExportDatabase)
+				r.Post("/config/reload", adminHandler.ReloadConfig)
+				r.Get("/corrections", correctionHandler.List)
+				r.Post("/corrections/{id}/review", correctionHandler.Review)
+			})
+		})
+
+		// Dataset exports (regenerated after each refresh)
+		r.Route("/export", func(r chi.Router) {
+			r.Use(appmiddleware.RequireFeature(s.live, "exports"))
+			r.Get("/full.json.br", exportHandler.GetFullExport)
+			r.Post("/links", exportHandler.CreateDownloadLink)
+		})
 	})
 
 	// 404 handler
@@ -100,6 +260,12 @@ func (s *Server) setupRoutes(schoolHandler *handler.SchoolHandler, constructionP
 	})
 }
 
+// Router returns the routes of the server with their middleware, for serving requests
+// without a listener, e.g. in contract tests
+func (s *Server) Router() chi.Router {
+	return s.router
+}
+
 func (s *Server) Start() error {
 	return s.server.ListenAndServe()
 }
@@ -0,0 +1,206 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"schools-be/internal/errors"
+	"schools-be/internal/models"
+	"schools-be/internal/repository"
+)
+
+// AdminService gathers operational data for the admin dashboard
+type AdminService struct {
+	repo                *repository.AdminRepository
+	schoolService       *SchoolService
+	schoolDetailService *SchoolDetailService
+	exportDir           string
+	exportMu            sync.Mutex // One database snapshot at a time
+	logger              *slog.Logger
+}
+
+func NewAdminService(repo *repository.AdminRepository, schoolService *SchoolService, schoolDetailService *SchoolDetailService, exportDir string, logger *slog.Logger) *AdminService {
+	return &AdminService{
+		repo:                repo,
+		schoolService:       schoolService,
+		schoolDetailService: schoolDetailService,
+		exportDir:           exportDir,
+		logger:              logger.With(slog.String("service", "admin")),
+	}
+}
+
+// DataOverview is the state of the stored data
+type DataOverview struct {
+	Counts                []models.TableCount       `json:"counts"`
+	SnapshotBuiltAt       *time.Time                `json:"snapshot_built_at,omitempty"`
+	DetailsScrapeFailures []models.ScrapeFailure    `json:"details_scrape_failures"`
+	DetailsScrapeRuns     []models.ScrapeRun        `json:"details_scrape_runs"`
+	DataQualityIssues     []models.DataQualityIssue `json:"data_quality_issues"`
+}
+
+// GetDataOverview returns table counts, the last snapshot rebuild, the failed detail scrapes,
+// the failures by kind of the recent scrape runs and the data-quality log
+func (s *AdminService) GetDataOverview(ctx context.Context) (*DataOverview, error) {
+	counts, err := s.repo.TableCounts(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	builtAt, err := s.schoolService.GetEnrichedSnapshotBuiltAt(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	failures, err := s.schoolDetailService.LastScrapeFailures(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	runs, err := s.schoolDetailService.ScrapeRuns(ctx, 10)
+	if err != nil {
+		return nil, err
+	}
+
+	issues, err := s.schoolDetailService.DataQualityIssues(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &DataOverview{
+		Counts:                counts,
+		SnapshotBuiltAt:       builtAt,
+		DetailsScrapeFailures: failures,
+		DetailsScrapeRuns:     runs,
+		DataQualityIssues:     issues,
+	}, nil
+}
+
+// VerifyConsistency cross-checks the data tables: rows whose school number is missing
+// from schools, schools without details and totals that differ from the sum of their parts.
+// With fix, orphaned detail and statistics rows are deleted.
+func (s *AdminService) VerifyConsistency(ctx context.Context, fix bool) (*models.ConsistencyReport, error) {
+	orphans, err := s.repo.FindOrphans(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if fix {
+		for i := range orphans {
+			if !orphans[i].Fixable {
+				continue
+			}
+			deleted, err := s.repo.DeleteOrphans(ctx, orphans[i].Table)
+			if err != nil {
+				return nil, err
+			}
+			orphans[i].Deleted = deleted
+			s.logger.InfoContext(ctx, "deleted orphaned rows",
+				slog.String("table", orphans[i].Table),
+				slog.Int64("deleted", deleted),
+			)
+		}
+	}
+
+	missingDetails, err := s.repo.FindSchoolsMissingDetails(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	mismatches, err := s.repo.FindTotalMismatches(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.ConsistencyReport{
+		Orphans:               orphans,
+		SchoolsMissingDetails: missingDetails,
+		TotalMismatches:       mismatches,
+	}, nil
+}
+
+// CompletenessReport lists the data completeness of every school in the enriched snapshot,
+// computed during the last refresh, least complete first, to pick schools to re-scrape
+func (s *AdminService) CompletenessReport(ctx context.Context) (*models.CompletenessReport, error) {
+	report := &models.CompletenessReport{
+		MissingSections: make(map[string]int, len(models.CompletenessSections)),
+		Schools:         []models.SchoolCompleteness{},
+	}
+	for _, section := range models.CompletenessSections {
+		report.MissingSections[section] = 0
+	}
+
+	err := s.schoolService.StreamEnrichedSnapshot(ctx, func(school models.EnrichedSchool) error {
+		completeness := school.ComputeCompleteness()
+		if school.Completeness != nil {
+			completeness = *school.Completeness
+		}
+		for _, section := range completeness.Missing {
+			report.MissingSections[section]++
+		}
+		report.Schools = append(report.Schools, models.SchoolCompleteness{
+			SchoolNumber: school.School.SchoolNumber,
+			Name:         school.School.Name,
+			SchoolType:   school.School.SchoolType,
+			Score:        completeness.Score,
+			Missing:      completeness.Missing,
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(report.Schools, func(i, j int) bool {
+		a, b := report.Schools[i], report.Schools[j]
+		if a.Score != b.Score {
+			return a.Score < b.Score
+		}
+		return a.SchoolNumber < b.SchoolNumber
+	})
+
+	if len(report.Schools) > 0 {
+		total := 0
+		for _, school := range report.Schools {
+			total += school.Score
+		}
+		report.AverageScore = math.Round(float64(total)/float64(len(report.Schools))*10) / 10
+	}
+
+	return report, nil
+}
+
+// ExportDatabase writes a consistent snapshot of the SQLite database, without visitor data,
+// to a temp file in the export directory and returns its path. The caller removes the file.
+func (s *AdminService) ExportDatabase(ctx context.Context) (string, error) {
+	if !s.exportMu.TryLock() {
+		return "", fmt.Errorf("database export already running: %w", errors.ErrConflict)
+	}
+	defer s.exportMu.Unlock()
+
+	start := time.Now()
+	if err := os.MkdirAll(s.exportDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create export directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(s.exportDir, "schools-snapshot-*.db.tmp")
+	if err != nil {
+		return "", fmt.Errorf("failed to create snapshot file: %w", err)
+	}
+	tmp.Close()
+
+	if err := s.repo.SnapshotDatabase(ctx, tmp.Name()); err != nil {
+		os.Remove(tmp.Name())
+		return "", err
+	}
+
+	s.logger.InfoContext(ctx, "database snapshot created",
+		slog.String("path", tmp.Name()),
+		slog.String("duration", time.Since(start).String()),
+	)
+	return tmp.Name(), nil
+}
@@ -2,36 +2,96 @@ package service
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
 
+	"schools-be/internal/breaker"
 	"schools-be/internal/config"
 	"schools-be/internal/models"
+	"schools-be/internal/prompts"
+	"schools-be/internal/repository"
+	"schools-be/internal/requestid"
 
 	"github.com/google/generative-ai-go/genai"
 	"google.golang.org/api/option"
 )
 
 type AIService struct {
-	config *config.Config
-	client *genai.Client
+	config         *config.Config
+	client         *genai.Client
+	prompts        *prompts.Set
+	promptVariants []string
+	breaker        *breaker.Breaker
+	summaries      *repository.SchoolSearchRepository
+	logger         *slog.Logger
+
+	// Last valid summary per school, served while Gemini is unavailable
+	lastSummariesMu sync.Mutex
+	lastSummaries   map[int64]SchoolSummary
 }
 
-func NewAIService(ctx context.Context, config *config.Config) (*AIService, error) {
+func NewAIService(ctx context.Context, config *config.Config, logger *slog.Logger) (*AIService, error) {
 	if config.GeminiAPIKey == "" {
 		return nil, fmt.Errorf("Gemini API key is not configured")
 	}
 
-	client, err := genai.NewClient(ctx, option.WithAPIKey(config.GeminiAPIKey))
+	promptSet, err := prompts.Load(config.AIPromptDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load prompt templates: %w", err)
+	}
+	variants := config.AIPromptTemplates
+	if len(variants) == 0 {
+		variants = []string{prompts.SchoolSummaryTemplate}
+	}
+	for _, name := range variants {
+		if !promptSet.Has(name) {
+			return nil, fmt.Errorf("prompt template %q not found", name)
+		}
+	}
+
+	// A custom HTTP client replaces the one WithAPIKey would build, so the key is set
+	// by geminiTransport; WithAPIKey is kept for the cache client, which is built without it
+	httpClient := &http.Client{
+		Transport: requestid.Transport{Base: geminiTransport{apiKey: config.GeminiAPIKey}},
+	}
+	client, err := genai.NewClient(ctx, option.WithAPIKey(config.GeminiAPIKey), option.WithHTTPClient(httpClient))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create Gemini API client: %w", err)
 	}
 
 	return &AIService{
-		config: config,
-		client: client,
+		config:         config,
+		client:         client,
+		prompts:        promptSet,
+		promptVariants: variants,
+		breaker:        breaker.New("gemini", breaker.DefaultThreshold, breaker.DefaultCooldown, logger),
+		logger:         logger.With(slog.String("service", "ai")),
+		lastSummaries:  make(map[int64]SchoolSummary),
 	}, nil
 }
 
+// WithSummaryRepository stores every summary that passes validation, so the school search
+// finds schools by their summaries
+func (s *AIService) WithSummaryRepository(repo *repository.SchoolSearchRepository) *AIService {
+	s.summaries = repo
+	return s
+}
+
+// geminiTransport authenticates REST calls to the Gemini API with the API key header
+type geminiTransport struct {
+	apiKey string
+}
+
+func (t geminiTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set("x-goog-api-key", t.apiKey)
+	return http.DefaultTransport.RoundTrip(req)
+}
+
 func (s *AIService) Close() error {
 	if s.client != nil {
 		return s.client.Close()
@@ -39,231 +99,263 @@ func (s *AIService) Close() error {
 	return nil
 }
 
-// GenerateSchoolSummary generates a comprehensive summary for a school using Gemini AI
-func (s *AIService) GenerateSchoolSummary(ctx context.Context, school *models.EnrichedSchool) (string, error) {
+// SchoolSummary is a generated school summary together with its validation result.
+// Summaries that still fail validation after all attempts are returned flagged, not dropped.
+type SchoolSummary struct {
+	Text       string            `json:"summary"`
+	Validation SummaryValidation `json:"validation"`
+	Attempts   int               `json:"attempts"`
+
+	// Stale summaries are the last one generated for the school, served because Gemini failed
+	Stale bool `json:"stale"`
+}
+
+// GenerateSchoolSummary generates a comprehensive summary for a school using Gemini AI.
+// Each summary is validated against the source data; failures are re-prompted with the list of
+// problems up to the configured number of attempts. When Gemini fails, the last summary
+// generated for the school is returned as stale instead.
+func (s *AIService) GenerateSchoolSummary(ctx context.Context, school *models.EnrichedSchool) (*SchoolSummary, error) {
 	if s.client == nil {
-		return "", fmt.Errorf("AI client is not initialized")
+		return nil, fmt.Errorf("AI client is not initialized")
 	}
 
 	// Build the prompt with complete school information
-	prompt := s.createEnrichedSchoolPrompt(school)
-
-	// Get the generative model
-	model := s.client.GenerativeModel("gemini-2.5-flash")
-
-	// Generate content
-	resp, err := model.GenerateContent(ctx, genai.Text(prompt))
+	prompt, err := s.createEnrichedSchoolPrompt(school)
 	if err != nil {
-		return "", fmt.Errorf("failed to generate content: %w", err)
+		return nil, err
 	}
 
-	// Extract the text from the response
-	if len(resp.Candidates) == 0 || len(resp.Candidates[0].Content.Parts) == 0 {
-		return "", fmt.Errorf("no content generated")
+	maxAttempts := s.config.AISummaryMaxAttempts
+	if maxAttempts < 1 {
+		maxAttempts = 1
 	}
 
-	summary := ""
-	for _, part := range resp.Candidates[0].Content.Parts {
-		if text, ok := part.(genai.Text); ok {
-			summary += string(text)
+	result := &SchoolSummary{}
+	request := prompt
+	for result.Attempts < maxAttempts {
+		result.Attempts++
+
+		text, err := s.generateText(ctx, request)
+		if err != nil {
+			if last, ok := s.lastSummary(school.School.ID); ok {
+				s.logger.WarnContext(ctx, "serving last school summary while Gemini is failing",
+					slog.Int64("school_id", school.School.ID),
+					slog.String("error", err.Error()),
+				)
+				return last, nil
+			}
+			return nil, err
 		}
-	}
 
-	return summary, nil
-}
-
-func (s *AIService) createEnrichedSchoolPrompt(data *models.EnrichedSchool) string {
-	school := data.School
-	details := data.Details
-	languageStat := data.LanguageStat
-	citizenshipStats := data.CitizenshipStats
-	residenceStats := data.ResidenceStats
-	absenceStat := data.AbsenceStat
-	statistics := data.Statistics
-
-	var stats *models.SchoolStatistic
-	if len(statistics) > 0 {
-		stats = &statistics[0]
-	}
-
-	prompt := fmt.Sprintf(`You are an expert educational consultant creating brief, informative profiles of Berlin schools for international parents. Your tone should be professional yet accessible, clear, and direct.
-
-**School Basic Information:**
-- Name: %s
-- Type: %s
-- Operator: %s
-- Address: %s %s, %s Berlin
-- District: %s, %s
-- Website: %s
-- Phone: %s
-- Email: %s
-`, school.Name, school.SchoolCategory, school.Operator, school.Street, school.HouseNumber, school.PostalCode, school.District, school.Neighborhood, stringOrNA2(school.Website), stringOrNA2(school.Phone), stringOrNA2(school.Email))
-
-	// Add statistics if available
-	if stats != nil {
-		prompt += fmt.Sprintf(`
-**Student & Teacher Statistics (%s):**
-- Total Students: %s (%s female, %s male)
-- Total Teachers: %s (%s female, %s male)
-- Total Classes: %s
-`, stats.SchoolYear, stats.Students, stats.StudentsFemale, stats.StudentsMale, stats.Teachers, stats.TeachersFemale, stats.TeachersMale, stats.Classes)
-	}
-
-	// Add language/heritage statistics
-	if languageStat != nil {
-		prompt += fmt.Sprintf(`
-**Language & Heritage Statistics:**
-- Total Students: %d
-- Students with Non-German Heritage: %d (%.1f%%)
-  - Female: %d, Male: %d
-`, languageStat.TotalStudents, languageStat.NDHTotal, languageStat.NDHPercentage, languageStat.NDHFemaleStudents, languageStat.NDHMaleStudents)
-	}
-
-	// Add citizenship statistics
-	if len(citizenshipStats) > 0 {
-		var totalRow *models.SchoolCitizenshipStat
-		for i := range citizenshipStats {
-			if citizenshipStats[i].Citizenship == "Insgesamt" || citizenshipStats[i].Citizenship == "insgesamt" {
-				totalRow = &citizenshipStats[i]
-				break
-			}
+		result.Text = text
+		result.Validation = validateSummary(text, school, prompt, s.config.AISummaryMaxWords)
+		if result.Validation.Valid {
+			break
 		}
 
-		if totalRow != nil {
-			prompt += fmt.Sprintf(`
-**Citizenship Statistics:**
-- Students with Non-German Citizenship: %d (%d female, %d male)
-`, totalRow.Total, totalRow.FemaleStudents, totalRow.MaleStudents)
-
-			// Add regional distribution
-			regionalDist := "- Regional Distribution: "
-			count := 0
-			for _, stat := range citizenshipStats {
-				if stat.Citizenship != "Insgesamt" && stat.Citizenship != "insgesamt" && count < 5 {
-					if count > 0 {
-						regionalDist += ", "
-					}
-					regionalDist += fmt.Sprintf("%s: %d", stat.Citizenship, stat.Total)
-					count++
-				}
-			}
-			if count > 0 {
-				prompt += regionalDist + "\n"
+		s.logger.WarnContext(ctx, "school summary failed validation",
+			slog.Int64("school_id", school.School.ID),
+			slog.Int("attempt", result.Attempts),
+			slog.Any("issues", result.Validation.Issues),
+		)
+		request = summaryCorrectionPrompt(prompt, text, result.Validation, s.config.AISummaryMaxWords)
+	}
+
+	if result.Validation.Valid {
+		s.lastSummariesMu.Lock()
+		s.lastSummaries[school.School.ID] = *result
+		s.lastSummariesMu.Unlock()
+
+		if s.summaries != nil {
+			if err := s.summaries.SaveSummary(ctx, school.School.SchoolNumber, result.Text); err != nil {
+				s.logger.WarnContext(ctx, "failed to store school summary",
+					slog.Int64("school_id", school.School.ID),
+					slog.String("error", err.Error()),
+				)
 			}
 		}
 	}
 
-	// Add residence statistics
-	if len(residenceStats) > 0 {
-		prompt += "\n**Student Residence Distribution:**\n- Top districts where students live: "
+	return result, nil
+}
 
-		// Get top 5 districts by student count
-		topCount := 5
-		if len(residenceStats) < topCount {
-			topCount = len(residenceStats)
-		}
+// lastSummary returns the last valid summary of a school, marked as stale
+func (s *AIService) lastSummary(schoolID int64) (*SchoolSummary, bool) {
+	s.lastSummariesMu.Lock()
+	defer s.lastSummariesMu.Unlock()
 
-		for i := 0; i < topCount; i++ {
-			if i > 0 {
-				prompt += ", "
-			}
-			prompt += fmt.Sprintf("%s (%d)", residenceStats[i].District, residenceStats[i].StudentCount)
-		}
-		prompt += "\n"
+	last, ok := s.lastSummaries[schoolID]
+	if !ok {
+		return nil, false
 	}
+	last.Stale = true
+	return &last, true
+}
 
-	// Add absence statistics
-	if absenceStat != nil {
-		prompt += fmt.Sprintf(`
-**Absence Statistics:**
-- School Absence Rate: %.1f%% (Unexcused: %.1f%%)
-- School Type Average: %.1f%% (Unexcused: %.1f%%)
-- Berlin Average: %.1f%% (Unexcused: %.1f%%)
-`, absenceStat.SchoolAbsenceRate, absenceStat.SchoolUnexcusedRate, absenceStat.SchoolTypeAbsenceRate, absenceStat.SchoolTypeUnexcusedRate, absenceStat.BerlinAbsenceRate, absenceStat.BerlinUnexcusedRate)
-	}
+// AskRequest is a question about a single school
+type AskRequest struct {
+	Question string `json:"question" validate:"required,max=500"`
+}
 
-	// Add detailed school information
-	if details != nil {
-		if details.Languages != "" {
-			prompt += fmt.Sprintf("\n**Languages Offered:**\n%s\n", details.Languages)
-		}
+// Citation references a field of the school record that an answer is based on
+type Citation struct {
+	Field string `json:"field"`
+	Value string `json:"value"`
+}
 
-		if details.Courses != "" {
-			prompt += fmt.Sprintf("\n**Advanced Courses (Leistungskurse):**\n%s\n", details.Courses)
-		}
+// SchoolAnswer is an answer to a question about a school, grounded in the enriched record
+type SchoolAnswer struct {
+	Answer     string     `json:"answer"`
+	Answerable bool       `json:"answerable"`
+	Citations  []Citation `json:"citations"`
+}
 
-		if details.Offerings != "" {
-			prompt += fmt.Sprintf("\n**Programs & Special Offerings:**\n%s\n", details.Offerings)
-		}
+// AnswerSchoolQuestion answers a question strictly from the school's enriched record.
+// Citations returned by the model are checked against the record; unknown fields are dropped,
+// and an answer left without any valid citation is reported as not answerable.
+func (s *AIService) AnswerSchoolQuestion(ctx context.Context, school *models.EnrichedSchool, question string) (*SchoolAnswer, error) {
+	if s.client == nil {
+		return nil, fmt.Errorf("AI client is not initialized")
+	}
 
-		if details.Equipment != "" {
-			prompt += fmt.Sprintf("\n**Equipment & Facilities:**\n%s\n", details.Equipment)
-		}
+	fields, err := flattenSchool(school)
+	if err != nil {
+		return nil, err
+	}
 
-		if details.WorkingGroups != "" {
-			prompt += fmt.Sprintf("\n**Working Groups & Extracurricular Activities:**\n%s\n", details.WorkingGroups)
-		}
+	prompt, err := s.prompts.Render(prompts.SchoolQuestionTemplate, prompts.QuestionData{
+		SchoolName: school.School.Name,
+		Question:   question,
+		Fields:     fields,
+	})
+	if err != nil {
+		return nil, err
+	}
 
-		if details.Partners != "" {
-			prompt += fmt.Sprintf("\n**External Partners:**\n%s\n", details.Partners)
-		}
+	text, err := s.generateText(ctx, prompt)
+	if err != nil {
+		return nil, err
+	}
 
-		if details.Differentiation != "" {
-			prompt += fmt.Sprintf("\n**Differentiation & Teaching Methods:**\n%s\n", details.Differentiation)
-		}
+	var raw struct {
+		Answer     string   `json:"answer"`
+		Answerable bool     `json:"answerable"`
+		Citations  []string `json:"citations"`
+	}
+	if err := json.Unmarshal([]byte(extractJSONObject(text)), &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse answer: %w", err)
+	}
 
-		if details.LunchInfo != "" {
-			prompt += fmt.Sprintf("\n**Lunch & Meal Services:**\n%s\n", details.LunchInfo)
-		}
+	values := make(map[string]string, len(fields))
+	for _, field := range fields {
+		values[field.Path] = field.Value
+	}
 
-		if details.DualLearning != "" {
-			prompt += fmt.Sprintf("\n**Dual Learning Programs:**\n%s\n", details.DualLearning)
+	answer := &SchoolAnswer{
+		Answer:     raw.Answer,
+		Answerable: raw.Answerable,
+		Citations:  []Citation{},
+	}
+	for _, path := range raw.Citations {
+		value, ok := values[path]
+		if !ok {
+			s.logger.WarnContext(ctx, "dropping citation to unknown field",
+				slog.Int64("school_id", school.School.ID),
+				slog.String("field", path),
+			)
+			continue
 		}
+		answer.Citations = append(answer.Citations, Citation{Field: path, Value: value})
+	}
+	if answer.Answerable && len(answer.Citations) == 0 {
+		answer.Answerable = false
+	}
 
-		if details.AdditionalInfo != "" {
-			prompt += fmt.Sprintf("\n**Additional Information:**\n%s\n", details.AdditionalInfo)
-		}
+	return answer, nil
+}
 
-		available := "No"
-		if details.AvailableAfter4thGrade {
-			available = "Yes"
-		}
-		prompt += fmt.Sprintf("\n**Enrollment:** Accepts students after 4th grade: %s\n", available)
+// Chat continues a conversation: history holds the previous turns, systemPrompt the advisor instructions
+func (s *AIService) Chat(ctx context.Context, systemPrompt string, history []models.ChatMessage, message string) (string, error) {
+	if s.client == nil {
+		return "", fmt.Errorf("AI client is not initialized")
 	}
 
-	prompt += fmt.Sprintf(`
-**Task:**
-Synthesize all the data above into a concise, informative school profile.
+	model := s.client.GenerativeModel("gemini-2.5-flash")
+	model.SystemInstruction = &genai.Content{Parts: []genai.Part{genai.Text(systemPrompt)}}
+
+	cs := model.StartChat()
+	for _, msg := range history {
+		cs.History = append(cs.History, &genai.Content{
+			Role:  msg.Role,
+			Parts: []genai.Part{genai.Text(msg.Content)},
+		})
+	}
 
-1. **Prioritize the official website** (%s) for additional qualitative information if needed.
-2. **Use all the provided data** to create an accurate, comprehensive summary.
-3. **Focus on unique characteristics** that distinguish this school.
+	if err := s.breaker.Allow(); err != nil {
+		return "", err
+	}
+	resp, err := cs.SendMessage(ctx, genai.Text(message))
+	s.breaker.Record(err)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate content: %w", err)
+	}
 
-**Output Requirements:**
-- **Total Length:** Must be under 300 words (given the rich data available, be comprehensive but concise).
-- **Structure:** Use the following **bold** headers:
-    - **Profile:**
-    - **Academics & Languages:**
-    - **Diversity & Student Body:**
-    - **Extracurriculars & Facilities:**
-- **Formatting:** Use short, concise bullet points (•).
-- **Style:** Be factual and specific. Use actual numbers and statistics from the data. Avoid generic statements and conversational filler. Focus on concrete details that help parents make informed decisions.
-`, stringOrNA2(school.Website))
+	return responseText(resp)
+}
 
-	return prompt
+// extractJSONObject strips Markdown code fences and surrounding prose from a model answer
+func extractJSONObject(text string) string {
+	start := strings.Index(text, "{")
+	end := strings.LastIndex(text, "}")
+	if start == -1 || end < start {
+		return text
+	}
+	return text[start : end+1]
 }
 
-// Helper functions
-func stringOrNA(s *string) string {
-	if s == nil || *s == "" {
-		return "N/A"
+// generateText sends a prompt to the model and returns the concatenated text parts of the answer
+func (s *AIService) generateText(ctx context.Context, prompt string) (string, error) {
+	// Get the generative model
+	model := s.client.GenerativeModel("gemini-2.5-flash")
+
+	// Generate content, failing fast while Gemini is down
+	if err := s.breaker.Allow(); err != nil {
+		return "", err
+	}
+	resp, err := model.GenerateContent(ctx, genai.Text(prompt))
+	s.breaker.Record(err)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate content: %w", err)
 	}
-	return *s
+
+	return responseText(resp)
 }
 
-func stringOrNA2(s string) string {
-	if s == "" {
-		return "N/A"
+// responseText extracts the concatenated text parts of the first candidate
+func responseText(resp *genai.GenerateContentResponse) (string, error) {
+	if len(resp.Candidates) == 0 || resp.Candidates[0].Content == nil || len(resp.Candidates[0].Content.Parts) == 0 {
+		return "", fmt.Errorf("no content generated")
 	}
-	return s
+
+	text := ""
+	for _, part := range resp.Candidates[0].Content.Parts {
+		if t, ok := part.(genai.Text); ok {
+			text += string(t)
+		}
+	}
+
+	return text, nil
+}
+
+// createEnrichedSchoolPrompt renders the school summary prompt. When several template variants are
+// configured, each school is assigned one deterministically by ID so variants can be compared.
+func (s *AIService) createEnrichedSchoolPrompt(data *models.EnrichedSchool) (string, error) {
+	variant := s.promptVariants[int(data.School.ID%int64(len(s.promptVariants)))]
+
+	s.logger.Info("rendering school summary prompt",
+		slog.Int64("school_id", data.School.ID),
+		slog.String("template", variant),
+	)
+
+	return s.prompts.Render(variant, prompts.NewSchoolData(data))
 }
@@ -0,0 +1,63 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"schools-be/internal/fetcher"
+	"schools-be/internal/models"
+	"schools-be/internal/repository"
+)
+
+// ApplicationService imports the yearly first-choice application numbers of secondary schools
+type ApplicationService struct {
+	repo    *repository.SchoolApplicationRepository
+	fetcher *fetcher.ApplicationFetcher
+	logger  *slog.Logger
+}
+
+func NewApplicationService(repo *repository.SchoolApplicationRepository, fetcher *fetcher.ApplicationFetcher, logger *slog.Logger) *ApplicationService {
+	return &ApplicationService{
+		repo:    repo,
+		fetcher: fetcher,
+		logger:  logger.With(slog.String("service", "application")),
+	}
+}
+
+// Enabled reports whether an Anmeldezahlen source is configured
+func (s *ApplicationService) Enabled() bool {
+	return s.fetcher.Enabled()
+}
+
+// GetApplicationsBySchoolNumber returns a school's application numbers, newest school year first
+func (s *ApplicationService) GetApplicationsBySchoolNumber(ctx context.Context, schoolNumber string) ([]models.SchoolApplication, error) {
+	return s.repo.GetBySchoolNumber(ctx, schoolNumber)
+}
+
+// FetchAndStoreApplications downloads the Anmeldezahlen dataset and stores it per school and year
+func (s *ApplicationService) FetchAndStoreApplications(ctx context.Context) error {
+	s.logger.InfoContext(ctx, "starting application numbers fetch")
+
+	applications, err := s.fetcher.FetchApplications(ctx)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "failed to fetch application numbers", slog.String("error", err.Error()))
+		return fmt.Errorf("fetch application numbers: %w", err)
+	}
+	if len(applications) == 0 {
+		return fmt.Errorf("no application numbers found")
+	}
+
+	saved, skipped, err := s.repo.Upsert(ctx, applications)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "failed to save application numbers", slog.String("error", err.Error()))
+		return fmt.Errorf("save application numbers: %w", err)
+	}
+
+	s.logger.InfoContext(ctx, "application numbers saved",
+		slog.Int("saved", saved),
+		slog.Int("unknown_schools", skipped),
+		slog.Int("total", len(applications)),
+	)
+	return nil
+}
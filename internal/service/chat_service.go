@@ -0,0 +1,195 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"schools-be/internal/models"
+	"schools-be/internal/prompts"
+	"schools-be/internal/repository"
+)
+
+// ChatService manages stateful advisor conversations on top of the AI service.
+// Sessions expire after the configured TTL of inactivity; only the most recent
+// messages are kept and sent to the model.
+type ChatService struct {
+	repo          *repository.ChatRepository
+	schoolService *SchoolService
+	aiService     *AIService
+	ttl           time.Duration
+	maxHistory    int
+	logger        *slog.Logger
+}
+
+func NewChatService(repo *repository.ChatRepository, schoolService *SchoolService, aiService *AIService, ttl time.Duration, maxHistory int, logger *slog.Logger) *ChatService {
+	return &ChatService{
+		repo:          repo,
+		schoolService: schoolService,
+		aiService:     aiService,
+		ttl:           ttl,
+		maxHistory:    maxHistory,
+		logger:        logger.With(slog.String("service", "chat")),
+	}
+}
+
+// StartChatRequest starts a session, optionally about one school
+type StartChatRequest struct {
+	SchoolID *int64 `json:"school_id"`
+}
+
+// ChatMessageRequest is a user message sent to a session
+type ChatMessageRequest struct {
+	Message string `json:"message" validate:"required,max=2000"`
+}
+
+// ChatReply is the model's answer together with the stored turns
+type ChatReply struct {
+	SessionID string             `json:"session_id"`
+	Message   models.ChatMessage `json:"message"`
+	Reply     models.ChatMessage `json:"reply"`
+	ExpiresAt time.Time          `json:"expires_at"`
+}
+
+// StartSession creates a new chat session. A referenced school must exist.
+func (s *ChatService) StartSession(ctx context.Context, req StartChatRequest) (*models.ChatSession, error) {
+	if req.SchoolID != nil {
+		if _, err := s.schoolService.GetSchoolByID(ctx, *req.SchoolID); err != nil {
+			return nil, err
+		}
+	}
+
+	id, err := newSessionID()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	session := &models.ChatSession{
+		ID:        id,
+		SchoolID:  req.SchoolID,
+		CreatedAt: now,
+		UpdatedAt: now,
+		ExpiresAt: now.Add(s.ttl),
+	}
+	if err := s.repo.CreateSession(ctx, session); err != nil {
+		return nil, err
+	}
+
+	return session, nil
+}
+
+// GetSession returns an active session with its retained history
+func (s *ChatService) GetSession(ctx context.Context, id string) (*models.ChatSession, []models.ChatMessage, error) {
+	session, err := s.repo.GetSession(ctx, id, time.Now().UTC())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	messages, err := s.repo.GetRecentMessages(ctx, id, s.maxHistory)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return session, messages, nil
+}
+
+// SendMessage sends a user message to the model with the session's recent history and stores both turns
+func (s *ChatService) SendMessage(ctx context.Context, sessionID, message string) (*ChatReply, error) {
+	now := time.Now().UTC()
+	session, err := s.repo.GetSession(ctx, sessionID, now)
+	if err != nil {
+		return nil, err
+	}
+
+	systemPrompt, err := s.systemPrompt(ctx, session)
+	if err != nil {
+		return nil, err
+	}
+
+	history, err := s.repo.GetRecentMessages(ctx, sessionID, s.maxHistory)
+	if err != nil {
+		return nil, err
+	}
+
+	answer, err := s.aiService.Chat(ctx, systemPrompt, history, message)
+	if err != nil {
+		return nil, err
+	}
+
+	userMsg := models.ChatMessage{SessionID: sessionID, Role: models.ChatRoleUser, Content: message, CreatedAt: now}
+	if err := s.repo.AddMessage(ctx, &userMsg); err != nil {
+		return nil, err
+	}
+	replyMsg := models.ChatMessage{SessionID: sessionID, Role: models.ChatRoleModel, Content: answer, CreatedAt: time.Now().UTC()}
+	if err := s.repo.AddMessage(ctx, &replyMsg); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.TrimMessages(ctx, sessionID, s.maxHistory); err != nil {
+		s.logger.WarnContext(ctx, "failed to trim chat history",
+			slog.String("session_id", sessionID),
+			slog.String("error", err.Error()),
+		)
+	}
+
+	expiresAt := replyMsg.CreatedAt.Add(s.ttl)
+	if err := s.repo.TouchSession(ctx, sessionID, replyMsg.CreatedAt, expiresAt); err != nil {
+		return nil, err
+	}
+
+	return &ChatReply{
+		SessionID: sessionID,
+		Message:   userMsg,
+		Reply:     replyMsg,
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+// DeleteSession ends a session and deletes its history
+func (s *ChatService) DeleteSession(ctx context.Context, id string) error {
+	return s.repo.DeleteSession(ctx, id)
+}
+
+// CleanupExpired deletes sessions whose TTL has passed
+func (s *ChatService) CleanupExpired(ctx context.Context) error {
+	deleted, err := s.repo.DeleteExpired(ctx, time.Now().UTC())
+	if err != nil {
+		return err
+	}
+
+	s.logger.InfoContext(ctx, "expired chat sessions deleted", slog.Int64("count", deleted))
+	return nil
+}
+
+// systemPrompt renders the advisor instructions, embedding the school record for school-scoped sessions
+func (s *ChatService) systemPrompt(ctx context.Context, session *models.ChatSession) (string, error) {
+	data := prompts.ChatData{}
+
+	if session.SchoolID != nil {
+		school, err := s.schoolService.GetSchoolByIDEnriched(ctx, *session.SchoolID)
+		if err != nil {
+			return "", err
+		}
+
+		fields, err := flattenSchool(school)
+		if err != nil {
+			return "", err
+		}
+		data.SchoolName = school.School.Name
+		data.Fields = fields
+	}
+
+	return s.aiService.prompts.Render(prompts.ChatAdvisorTemplate, data)
+}
+
+func newSessionID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate session id: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
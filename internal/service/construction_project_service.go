@@ -1,8 +1,12 @@
 package service
 
 import (
+	"cmp"
 	"context"
 	"log/slog"
+	"slices"
+	"strings"
+	"time"
 
 	"schools-be/internal/models"
 	"schools-be/internal/repository"
@@ -13,21 +17,34 @@ type ConstructionProjectService struct {
 	logger *slog.Logger
 }
 
-func NewConstructionProjectService(repo *repository.ConstructionProjectRepository) *ConstructionProjectService {
+func NewConstructionProjectService(repo *repository.ConstructionProjectRepository, logger *slog.Logger) *ConstructionProjectService {
 	return &ConstructionProjectService{
 		repo:   repo,
-		logger: slog.Default(),
+		logger: logger.With(slog.String("service", "construction_project")),
 	}
 }
 
-// GetAll returns all construction projects
+// GetAll returns all construction projects with their assets
 func (s *ConstructionProjectService) GetAll(ctx context.Context) ([]models.ConstructionProject, error) {
-	return s.repo.GetAll(ctx)
+	projects, err := s.repo.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.withAssets(ctx, projects)
 }
 
-// GetByID returns a single construction project by ID
+// GetByID returns a single construction project by ID with its assets
 func (s *ConstructionProjectService) GetByID(ctx context.Context, id int64) (*models.ConstructionProject, error) {
-	return s.repo.GetByID(ctx, id)
+	project, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	project.Assets, err = s.repo.GetAssetsByProjectID(ctx, project.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+	return project, nil
 }
 
 // GetBySchoolNumber returns construction projects for a specific school
@@ -39,5 +56,126 @@ func (s *ConstructionProjectService) GetBySchoolNumber(ctx context.Context, scho
 // Only includes orphaned projects where school_number doesn't exist in the schools table
 // Excludes meta entries, legends, and projects with no meaningful data (empty school_name)
 func (s *ConstructionProjectService) GetStandalone(ctx context.Context) ([]models.ConstructionProject, error) {
-	return s.repo.GetStandalone(ctx)
+	projects, err := s.repo.GetStandalone(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.withAssets(ctx, projects)
+}
+
+// withAssets attaches the documents and images linked from each project
+func (s *ConstructionProjectService) withAssets(ctx context.Context, projects []models.ConstructionProject) ([]models.ConstructionProject, error) {
+	assets, err := s.repo.GetAllAssets(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range projects {
+		projects[i].Assets = assets[projects[i].ProjectID]
+	}
+	return projects, nil
+}
+
+// BudgetRollup sums the total costs of the current construction projects per group
+// (models.BudgetGroup*) and compares them with the import before the current one. With year,
+// only projects running in that year count.
+func (s *ConstructionProjectService) BudgetRollup(ctx context.Context, groupBy string, year *int) (*models.BudgetRollup, error) {
+	current, err := s.repo.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rollup := &models.BudgetRollup{GroupBy: groupBy, Year: year, Groups: []models.BudgetRollupGroup{}}
+
+	// The newest import holds the current projects; the one before is the comparison
+	imports, err := s.repo.GetImports(ctx, 2)
+	if err != nil {
+		return nil, err
+	}
+	var previous []models.ConstructionProject
+	if len(imports) > 0 {
+		rollup.ImportedAt = &imports[0].ImportedAt
+	}
+	if len(imports) > 1 {
+		rollup.PreviousImportedAt = &imports[1].ImportedAt
+		if previous, err = s.repo.GetHistory(ctx, imports[1].ID); err != nil {
+			return nil, err
+		}
+	}
+
+	currentYear := time.Now().Year()
+	counts := func(p models.ConstructionProject) bool {
+		return year == nil || p.Timeline(currentYear).Overlaps(*year, *year)
+	}
+	groupKey := func(p models.ConstructionProject) string {
+		if groupBy == models.BudgetGroupSchoolType {
+			return p.SchoolType
+		}
+		return p.District
+	}
+
+	groups := make(map[string]*models.BudgetRollupGroup)
+	group := func(key string) *models.BudgetRollupGroup {
+		if groups[key] == nil {
+			groups[key] = &models.BudgetRollupGroup{Key: key}
+			if rollup.PreviousImportedAt != nil {
+				groups[key].PreviousTotalEUR = new(float64)
+				groups[key].AddedEUR = new(float64)
+			}
+		}
+		return groups[key]
+	}
+
+	previousCosts := make(map[int]float64, len(previous))
+	for _, p := range previous {
+		costs, _ := p.TotalCostsEUR()
+		previousCosts[p.ProjectID] = costs
+		if counts(p) {
+			*group(groupKey(p)).PreviousTotalEUR += costs
+		}
+	}
+
+	for _, p := range current {
+		if !counts(p) {
+			continue
+		}
+		g := group(groupKey(p))
+		costs, _ := p.TotalCostsEUR()
+		g.Projects++
+		g.TotalEUR += costs
+		if g.AddedEUR != nil {
+			before, existed := previousCosts[p.ProjectID]
+			if !existed {
+				*g.AddedEUR += costs
+			} else if costs > before {
+				*g.AddedEUR += costs - before
+			}
+		}
+	}
+
+	rollup.Total = models.BudgetRollupGroup{Key: "total"}
+	if rollup.PreviousImportedAt != nil {
+		rollup.Total.PreviousTotalEUR = new(float64)
+		rollup.Total.AddedEUR = new(float64)
+	}
+	for _, g := range groups {
+		if g.PreviousTotalEUR != nil {
+			delta := g.TotalEUR - *g.PreviousTotalEUR
+			g.DeltaEUR = &delta
+			*rollup.Total.PreviousTotalEUR += *g.PreviousTotalEUR
+			*rollup.Total.AddedEUR += *g.AddedEUR
+		}
+		rollup.Total.Projects += g.Projects
+		rollup.Total.TotalEUR += g.TotalEUR
+		rollup.Groups = append(rollup.Groups, *g)
+	}
+	if rollup.Total.PreviousTotalEUR != nil {
+		delta := rollup.Total.TotalEUR - *rollup.Total.PreviousTotalEUR
+		rollup.Total.DeltaEUR = &delta
+	}
+
+	// Largest investment first
+	slices.SortFunc(rollup.Groups, func(a, b models.BudgetRollupGroup) int {
+		return cmp.Or(cmp.Compare(b.TotalEUR, a.TotalEUR), strings.Compare(a.Key, b.Key))
+	})
+	return rollup, nil
 }
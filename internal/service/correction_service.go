@@ -0,0 +1,120 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+
+	apperrors "schools-be/internal/errors"
+	"schools-be/internal/models"
+	"schools-be/internal/repository"
+)
+
+// CorrectionService queues the fixes users suggest for school data and applies the ones an
+// admin accepts
+type CorrectionService struct {
+	repo    *repository.SchoolCorrectionRepository
+	schools *SchoolService
+	logger  *slog.Logger
+}
+
+func NewCorrectionService(repo *repository.SchoolCorrectionRepository, schools *SchoolService, logger *slog.Logger) *CorrectionService {
+	return &CorrectionService{
+		repo:    repo,
+		schools: schools,
+		logger:  logger.With(slog.String("service", "correction")),
+	}
+}
+
+// Submit queues a correction of one field of a school for review. The value is expected
+// to be checked against the rules of the field (see models.CorrectionUpdate).
+func (s *CorrectionService) Submit(ctx context.Context, schoolID int64, input models.CreateCorrectionInput) (*models.SchoolCorrection, error) {
+	school, err := s.schools.GetSchoolByID(ctx, schoolID)
+	if err != nil {
+		return nil, err
+	}
+
+	current, ok := school.CorrectionValue(input.Field)
+	if !ok {
+		return nil, apperrors.NewValidationError("field", "must be one of "+strings.Join(models.CorrectableFields, ", "))
+	}
+	if input.Field == "name" && input.Value == "" {
+		return nil, apperrors.NewValidationError("value", "a school needs a name")
+	}
+	if input.Value == current {
+		return nil, apperrors.NewValidationError("value", "is the current value")
+	}
+
+	correction, err := s.repo.Create(ctx, models.SchoolCorrection{
+		SchoolNumber: school.SchoolNumber,
+		Field:        input.Field,
+		CurrentValue: current,
+		Value:        input.Value,
+		Comment:      input.Comment,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.InfoContext(ctx, "school correction submitted",
+		slog.Int64("correction_id", correction.ID),
+		slog.String("school_number", correction.SchoolNumber),
+		slog.String("field", correction.Field))
+	return correction, nil
+}
+
+// List returns the corrections with a status (all for an empty one), oldest first
+func (s *CorrectionService) List(ctx context.Context, status string) ([]models.SchoolCorrection, error) {
+	switch status {
+	case "", models.CorrectionStatusPending, models.CorrectionStatusAccepted, models.CorrectionStatusRejected:
+	default:
+		return nil, apperrors.NewValidationError("status", "must be pending, accepted or rejected")
+	}
+
+	corrections, err := s.repo.List(ctx, status)
+	if err != nil {
+		return nil, err
+	}
+	if corrections == nil {
+		corrections = []models.SchoolCorrection{}
+	}
+	return corrections, nil
+}
+
+// Review accepts or rejects a pending correction on behalf of reviewer. An accepted value
+// is stored as override, so refreshes of the school list keep it, and written to the school
+// right away.
+func (s *CorrectionService) Review(ctx context.Context, id int64, input models.ReviewCorrectionInput, reviewer string) (*models.SchoolCorrection, error) {
+	status := models.CorrectionStatusRejected
+	if input.Decision == models.CorrectionDecisionAccept {
+		status = models.CorrectionStatusAccepted
+	}
+
+	correction, err := s.repo.Review(ctx, id, status, input.Note, reviewer)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.InfoContext(ctx, "school correction reviewed",
+		slog.Int64("correction_id", correction.ID),
+		slog.String("school_number", correction.SchoolNumber),
+		slog.String("status", correction.Status),
+		slog.String("reviewed_by", reviewer))
+
+	if status != models.CorrectionStatusAccepted {
+		return correction, nil
+	}
+
+	update, ok := models.CorrectionUpdate(correction.Field, correction.Value)
+	if !ok {
+		return correction, nil
+	}
+	err = s.schools.BatchUpdateSchools(ctx, []models.SchoolBatchUpdate{{SchoolNumber: correction.SchoolNumber, UpdateSchoolInput: update}})
+	if err != nil {
+		// The override is stored and reaches the school with the next refresh of the list
+		s.logger.ErrorContext(ctx, "failed to apply accepted school correction",
+			slog.Int64("correction_id", correction.ID),
+			slog.String("error", err.Error()))
+	}
+	return correction, nil
+}
@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"schools-be/internal/fetcher"
+	"schools-be/internal/models"
+	"schools-be/internal/repository"
+)
+
+// ExamResultService imports Abitur results and compares a school's results with Berlin
+// and its school type
+type ExamResultService struct {
+	repo       *repository.ExamResultRepository
+	schoolRepo *repository.SchoolRepository
+	fetcher    *fetcher.ExamResultFetcher
+	logger     *slog.Logger
+}
+
+func NewExamResultService(repo *repository.ExamResultRepository, schoolRepo *repository.SchoolRepository, fetcher *fetcher.ExamResultFetcher, logger *slog.Logger) *ExamResultService {
+	return &ExamResultService{
+		repo:       repo,
+		schoolRepo: schoolRepo,
+		fetcher:    fetcher,
+		logger:     logger.With(slog.String("service", "exam_result")),
+	}
+}
+
+// Enabled reports whether an exam results source is configured
+func (s *ExamResultService) Enabled() bool {
+	return s.fetcher.Enabled()
+}
+
+// GetSchoolExamResults returns a school's results per school year, oldest first, each next to
+// the Berlin and school type benchmarks of that year. Suppressed years stay in the series
+// without figures so gaps are visible; benchmarks only include unsuppressed results.
+func (s *ExamResultService) GetSchoolExamResults(ctx context.Context, schoolNumber string) (*models.ExamResultSeries, error) {
+	school, err := s.schoolRepo.GetBySchoolNumber(ctx, schoolNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	results, err := s.repo.GetBySchoolNumber(ctx, school.SchoolNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	series := &models.ExamResultSeries{
+		SchoolNumber: school.SchoolNumber,
+		SchoolType:   school.SchoolType,
+		Years:        make([]models.ExamResultYear, 0, len(results)),
+	}
+	if len(results) == 0 {
+		return series, nil
+	}
+
+	berlin, err := s.repo.GetBenchmarks(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	byType, err := s.repo.GetBenchmarks(ctx, school.SchoolType)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, result := range results {
+		year := models.ExamResultYear{
+			SchoolYear:   result.SchoolYear,
+			Suppressed:   result.Suppressed,
+			Participants: result.Participants,
+			Passed:       result.Passed,
+			AverageGrade: result.AverageGrade,
+			PassRate:     result.PassRate(),
+		}
+		if benchmark, ok := berlin[result.SchoolYear]; ok {
+			year.Berlin = &benchmark
+		}
+		if benchmark, ok := byType[result.SchoolYear]; ok {
+			year.SchoolType = &benchmark
+		}
+		series.Years = append(series.Years, year)
+	}
+
+	return series, nil
+}
+
+// FetchAndStoreExamResults downloads the Abitur results dataset and stores it per school and year
+func (s *ExamResultService) FetchAndStoreExamResults(ctx context.Context) error {
+	s.logger.InfoContext(ctx, "starting exam results fetch")
+
+	results, err := s.fetcher.FetchExamResults(ctx)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "failed to fetch exam results", slog.String("error", err.Error()))
+		return fmt.Errorf("fetch exam results: %w", err)
+	}
+	if len(results) == 0 {
+		return fmt.Errorf("no exam results found")
+	}
+
+	saved, skipped, err := s.repo.Upsert(ctx, results)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "failed to save exam results", slog.String("error", err.Error()))
+		return fmt.Errorf("save exam results: %w", err)
+	}
+
+	s.logger.InfoContext(ctx, "exam results saved",
+		slog.Int("saved", saved),
+		slog.Int("unknown_schools", skipped),
+		slog.Int("total", len(results)),
+	)
+	return nil
+}
@@ -0,0 +1,132 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"schools-be/internal/dto"
+	"schools-be/internal/models"
+
+	"github.com/andybalholm/brotli"
+)
+
+const fullExportFileName = "full.json.br"
+
+// ExportService builds precomputed dataset downloads after each data refresh
+type ExportService struct {
+	schoolService *SchoolService
+	exportDir     string
+	logger        *slog.Logger
+}
+
+func NewExportService(schoolService *SchoolService, exportDir string, logger *slog.Logger) *ExportService {
+	return &ExportService{
+		schoolService: schoolService,
+		exportDir:     exportDir,
+		logger:        logger.With(slog.String("service", "export")),
+	}
+}
+
+// FullExportPath returns the location of the Brotli-compressed full dataset
+func (s *ExportService) FullExportPath() string {
+	return filepath.Join(s.exportDir, fullExportFileName)
+}
+
+// BuildFullExport writes all enriched schools as a Brotli-compressed JSON array.
+// The file is written to a temp file and renamed so downloads never see a partial export.
+func (s *ExportService) BuildFullExport(ctx context.Context) error {
+	start := time.Now()
+
+	if err := os.MkdirAll(s.exportDir, 0755); err != nil {
+		return fmt.Errorf("failed to create export directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(s.exportDir, fullExportFileName+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create temp export file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	bw := brotli.NewWriterLevel(tmp, brotli.BestCompression)
+	count, err := s.writeSchools(ctx, bw)
+	if err != nil {
+		bw.Close()
+		tmp.Close()
+		return err
+	}
+
+	if err := bw.Close(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to finish brotli stream: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close temp export file: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), s.FullExportPath()); err != nil {
+		return fmt.Errorf("failed to publish export file: %w", err)
+	}
+
+	s.logger.InfoContext(ctx, "full dataset export built",
+		slog.Int("schools", count),
+		slog.String("path", s.FullExportPath()),
+		slog.String("duration", time.Since(start).String()),
+	)
+	return nil
+}
+
+// writeSchools streams the enriched schools as a JSON array, preferring the materialized snapshot
+func (s *ExportService) writeSchools(ctx context.Context, w io.Writer) (int, error) {
+	count := 0
+	writeSchool := func(school models.EnrichedSchool) error {
+		data, err := json.Marshal(dto.NewEnrichedSchool(school, ""))
+		if err != nil {
+			return fmt.Errorf("failed to encode school %s: %w", school.School.SchoolNumber, err)
+		}
+		if count > 0 {
+			if _, err := w.Write([]byte(",")); err != nil {
+				return err
+			}
+		}
+		count++
+		_, err = w.Write(data)
+		return err
+	}
+
+	if _, err := w.Write([]byte("[")); err != nil {
+		return 0, err
+	}
+
+	builtAt, err := s.schoolService.GetEnrichedSnapshotBuiltAt(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	if builtAt != nil {
+		if err := s.schoolService.StreamEnrichedSnapshot(ctx, writeSchool); err != nil {
+			return 0, err
+		}
+	} else {
+		schools, err := s.schoolService.GetAllSchoolsEnriched(ctx)
+		if err != nil {
+			return 0, err
+		}
+		for _, school := range schools {
+			if err := writeSchool(school); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	if _, err := w.Write([]byte("]\n")); err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
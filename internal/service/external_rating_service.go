@@ -0,0 +1,228 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"schools-be/internal/breaker"
+	"schools-be/internal/config"
+	"schools-be/internal/models"
+	"schools-be/internal/repository"
+	"schools-be/internal/requestid"
+	"schools-be/internal/utils"
+)
+
+// placesSearchURL is the Text Search endpoint of the Google Places API (New)
+const placesSearchURL = "https://places.googleapis.com/v1/places:searchText"
+
+// placesFieldMask selects the fields of the found place; Google bills by the fields requested
+const placesFieldMask = "places.id,places.rating,places.userRatingCount,places.googleMapsUri,places.location"
+
+// placeMaxDistanceKm is how far the found place may be from a school with coordinates to
+// count as that school; a place further away is another school of a similar name
+const placeMaxDistanceKm = 0.3
+
+// placesRequestInterval spaces the lookups of a refresh, well below the Places API quota
+const placesRequestInterval = 200 * time.Millisecond
+
+// ExternalRatingService looks up the rating and review count of schools on Google Places by
+// name and address and caches them for EXTERNAL_RATINGS_TTL. Without GOOGLE_PLACES_API_KEY
+// nothing is looked up, for deployments that do not want third-party data.
+type ExternalRatingService struct {
+	config     *config.Config
+	repo       *repository.ExternalRatingRepository
+	httpClient *http.Client
+	breaker    *breaker.Breaker
+	logger     *slog.Logger
+}
+
+func NewExternalRatingService(config *config.Config, repo *repository.ExternalRatingRepository, logger *slog.Logger) *ExternalRatingService {
+	return &ExternalRatingService{
+		config: config,
+		repo:   repo,
+		httpClient: &http.Client{
+			Timeout:   10 * time.Second,
+			Transport: requestid.Transport{},
+		},
+		breaker: breaker.New("google_places", breaker.DefaultThreshold, breaker.DefaultCooldown, logger),
+		logger:  logger.With(slog.String("service", "external_rating")),
+	}
+}
+
+// Enabled reports whether a Google Places API key is configured
+func (s *ExternalRatingService) Enabled() bool {
+	return s.config.GooglePlacesAPIKey != ""
+}
+
+// placesSearchResponse is the part of a Text Search response selected by placesFieldMask
+type placesSearchResponse struct {
+	Places []struct {
+		ID              string   `json:"id"`
+		Rating          *float64 `json:"rating"`
+		UserRatingCount int      `json:"userRatingCount"`
+		GoogleMapsURI   string   `json:"googleMapsUri"`
+		Location        struct {
+			Latitude  float64 `json:"latitude"`
+			Longitude float64 `json:"longitude"`
+		} `json:"location"`
+	} `json:"places"`
+}
+
+// RefreshRatings looks up the schools whose rating is older than EXTERNAL_RATINGS_TTL or
+// missing. A failed lookup keeps the previous rating and is retried by the next refresh;
+// while Google Places is unavailable the remaining schools are left for the next refresh.
+func (s *ExternalRatingService) RefreshRatings(ctx context.Context) error {
+	due, err := s.repo.GetDue(ctx, time.Now().Add(-s.config.ExternalRatingsTTL))
+	if err != nil {
+		return err
+	}
+	s.logger.InfoContext(ctx, "starting external ratings refresh", slog.Int("schools", len(due)))
+
+	ticker := time.NewTicker(placesRequestInterval)
+	defer ticker.Stop()
+
+	var found, notFound, failed int
+	for i, school := range due {
+		if i > 0 {
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+
+		rating, err := s.lookup(ctx, school)
+		if errors.Is(err, breaker.ErrOpen) {
+			return fmt.Errorf("google places unavailable, %d schools left: %w", len(due)-i, err)
+		}
+		if err != nil {
+			failed++
+			s.logger.WarnContext(ctx, "failed to look up external rating",
+				slog.String("school_number", school.SchoolNumber),
+				slog.String("error", err.Error()))
+			continue
+		}
+
+		if err := s.repo.Upsert(ctx, rating); err != nil {
+			return err
+		}
+		if rating.PlaceID == "" {
+			notFound++
+		} else {
+			found++
+		}
+	}
+
+	s.logger.InfoContext(ctx, "external ratings refreshed",
+		slog.Int("found", found),
+		slog.Int("not_found", notFound),
+		slog.Int("failed", failed))
+	if failed > 0 {
+		return fmt.Errorf("%d of %d external rating lookups failed", failed, len(due))
+	}
+	return nil
+}
+
+// lookup searches Google Places for a school by name and address. Finding no place, or one
+// too far from the school, is a result without place ID rather than an error.
+func (s *ExternalRatingService) lookup(ctx context.Context, school models.School) (models.ExternalRating, error) {
+	rating := models.ExternalRating{
+		SchoolNumber: school.SchoolNumber,
+		Source:       models.ExternalRatingSourceGooglePlaces,
+		FetchedAt:    time.Now(),
+	}
+
+	search := map[string]interface{}{
+		"textQuery":    placesQuery(school),
+		"languageCode": "de",
+		"regionCode":   "DE",
+		"pageSize":     1,
+	}
+	lat, lon, hasCoordinates := school.Coordinates()
+	if hasCoordinates {
+		search["locationBias"] = map[string]interface{}{
+			"circle": map[string]interface{}{
+				"center": map[string]float64{"latitude": lat, "longitude": lon},
+				"radius": placeMaxDistanceKm * 1000,
+			},
+		}
+	}
+	body, err := json.Marshal(search)
+	if err != nil {
+		return rating, fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, placesSearchURL, bytes.NewReader(body))
+	if err != nil {
+		return rating, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Goog-Api-Key", s.config.GooglePlacesAPIKey)
+	req.Header.Set("X-Goog-FieldMask", placesFieldMask)
+
+	resp, err := s.do(req)
+	if err != nil {
+		return rating, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return rating, fmt.Errorf("API error: %d - %s", resp.StatusCode, strings.TrimSpace(string(message)))
+	}
+
+	var result placesSearchResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return rating, fmt.Errorf("failed to decode response: %w", err)
+	}
+	if len(result.Places) == 0 {
+		return rating, nil
+	}
+
+	place := result.Places[0]
+	if hasCoordinates && utils.DistanceKm(lat, lon, place.Location.Latitude, place.Location.Longitude) > placeMaxDistanceKm {
+		return rating, nil
+	}
+	rating.PlaceID = place.ID
+	rating.Rating = place.Rating
+	rating.ReviewCount = place.UserRatingCount
+	rating.URL = place.GoogleMapsURI
+	return rating, nil
+}
+
+// do sends a request to Google Places through the circuit breaker. Network errors, rate
+// limiting and server errors count as failures.
+func (s *ExternalRatingService) do(req *http.Request) (*http.Response, error) {
+	if err := s.breaker.Allow(); err != nil {
+		return nil, err
+	}
+
+	resp, err := s.httpClient.Do(req)
+	switch {
+	case err != nil:
+		s.breaker.Record(err)
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		s.breaker.Record(fmt.Errorf("status %d", resp.StatusCode))
+	default:
+		s.breaker.Record(nil)
+	}
+	return resp, err
+}
+
+// placesQuery is the text a school is searched by: its name and address in Berlin
+func placesQuery(school models.School) string {
+	parts := []string{school.Name}
+	if street := strings.TrimSpace(school.Street + " " + school.HouseNumber); street != "" {
+		parts = append(parts, street)
+	}
+	parts = append(parts, strings.TrimSpace(school.PostalCode+" Berlin"))
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,305 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"schools-be/internal/models"
+	"schools-be/internal/repository"
+)
+
+const (
+	// forecastYears is how many school years after the latest statistics are projected
+	forecastYears = 3
+
+	// forecastClassSize converts classes and class tracks (Züge) into places
+	forecastClassSize = 26
+
+	// forecastTrendYears caps how many past school years feed the enrolment trend
+	forecastTrendYears = 4
+)
+
+var (
+	leadingNumberPattern = regexp.MustCompile(`\d+(?:[.,]\d+)?`)
+	yearPattern          = regexp.MustCompile(`\b(?:19|20)\d{2}\b`)
+)
+
+// ForecastService projects school capacity from scraped statistics and construction projects
+type ForecastService struct {
+	statisticRepo    *repository.StatisticRepository
+	constructionRepo *repository.ConstructionProjectRepository
+	logger           *slog.Logger
+}
+
+func NewForecastService(statisticRepo *repository.StatisticRepository, constructionRepo *repository.ConstructionProjectRepository, logger *slog.Logger) *ForecastService {
+	return &ForecastService{
+		statisticRepo:    statisticRepo,
+		constructionRepo: constructionRepo,
+		logger:           logger.With(slog.String("service", "forecast")),
+	}
+}
+
+// GetCapacityForecast projects enrolment and capacity for the next school years.
+// Enrolment follows each school's average yearly change over its recent statistics;
+// capacity starts at the current classes and rises to PlacesAfterConstruction (or the
+// class tracks after construction) from the school year a project is handed over.
+// An empty district includes all districts.
+func (s *ForecastService) GetCapacityForecast(ctx context.Context, district string) (*models.CapacityForecast, error) {
+	statistics, err := s.statisticRepo.GetAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("load statistics: %w", err)
+	}
+	projects, err := s.constructionRepo.GetAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("load construction projects: %w", err)
+	}
+
+	statsBySchool := make(map[string][]models.SchoolStatistic)
+	for _, stat := range statistics {
+		if district != "" && !strings.EqualFold(stat.District, district) {
+			continue
+		}
+		statsBySchool[stat.SchoolNumber] = append(statsBySchool[stat.SchoolNumber], stat)
+	}
+	projectsBySchool := make(map[string][]models.ConstructionProject)
+	for _, project := range projects {
+		projectsBySchool[project.SchoolNumber] = append(projectsBySchool[project.SchoolNumber], project)
+	}
+
+	forecast := &models.CapacityForecast{
+		GeneratedAt: time.Now().UTC(),
+		Years:       forecastYears,
+		ClassSize:   forecastClassSize,
+		Schools:     []models.SchoolCapacityForecast{},
+		Districts:   []models.DistrictCapacityForecast{},
+	}
+
+	skipped := 0
+	for schoolNumber, stats := range statsBySchool {
+		school, ok := forecastSchool(stats, projectsBySchool[schoolNumber])
+		if !ok {
+			skipped++
+			continue
+		}
+		forecast.Schools = append(forecast.Schools, school)
+	}
+	sort.Slice(forecast.Schools, func(i, j int) bool {
+		return forecast.Schools[i].SchoolNumber < forecast.Schools[j].SchoolNumber
+	})
+	forecast.Districts = forecastDistricts(forecast.Schools)
+
+	s.logger.InfoContext(ctx, "capacity forecast computed",
+		slog.Int("schools", len(forecast.Schools)),
+		slog.Int("skipped", skipped),
+	)
+
+	return forecast, nil
+}
+
+// forecastSchool projects one school; ok is false when its latest statistics have no student count
+func forecastSchool(stats []models.SchoolStatistic, projects []models.ConstructionProject) (models.SchoolCapacityForecast, bool) {
+	sort.Slice(stats, func(i, j int) bool { return stats[i].SchoolYear < stats[j].SchoolYear })
+
+	type point struct {
+		year     int
+		students int
+	}
+	var points []point
+	for _, stat := range stats {
+		year, ok := schoolYearStart(stat.SchoolYear)
+		students, studentsOK := parseCount(stat.Students)
+		if ok && studentsOK {
+			points = append(points, point{year: year, students: students})
+		}
+	}
+	if len(points) == 0 {
+		return models.SchoolCapacityForecast{}, false
+	}
+	if len(points) > forecastTrendYears {
+		points = points[len(points)-forecastTrendYears:]
+	}
+
+	latest := stats[len(stats)-1]
+	base := points[len(points)-1]
+	var trend float64
+	if first := points[0]; base.year > first.year {
+		trend = float64(base.students-first.students) / float64(base.year-first.year)
+	}
+
+	classes, _ := parseCount(latest.Classes)
+	capacity := classes * forecastClassSize
+	if capacity < base.students {
+		// A school already teaching more students than its classes suggest has at least that many places
+		capacity = base.students
+	}
+
+	school := models.SchoolCapacityForecast{
+		SchoolNumber: latest.SchoolNumber,
+		SchoolName:   latest.SchoolName,
+		District:     latest.District,
+		SchoolType:   latest.SchoolType,
+		BaseYear:     formatSchoolYear(base.year),
+		Students:     base.students,
+		Classes:      classes,
+		Capacity:     capacity,
+		YearlyTrend:  math.Round(trend*10) / 10,
+	}
+
+	for offset := 1; offset <= forecastYears; offset++ {
+		year := base.year + offset
+		projected := int(math.Round(float64(base.students) + trend*float64(offset)))
+		if projected < 0 {
+			projected = 0
+		}
+
+		yearCapacity := capacity
+		completed := 0
+		for _, project := range projects {
+			handover, ok := handoverYear(project.HandoverDate)
+			if !ok || handover > year {
+				continue
+			}
+			completed++
+			if places := projectCapacity(project, latest.SchoolType); places > yearCapacity {
+				yearCapacity = places
+			}
+		}
+
+		school.Projections = append(school.Projections, newCapacityProjection(formatSchoolYear(year), projected, yearCapacity, completed))
+	}
+
+	return school, true
+}
+
+// forecastDistricts sums the school projections per district, ordered by district name
+func forecastDistricts(schools []models.SchoolCapacityForecast) []models.DistrictCapacityForecast {
+	byDistrict := make(map[string]*models.DistrictCapacityForecast)
+	var names []string
+	for _, school := range schools {
+		district, ok := byDistrict[school.District]
+		if !ok {
+			district = &models.DistrictCapacityForecast{District: school.District}
+			byDistrict[school.District] = district
+			names = append(names, school.District)
+		}
+		district.Schools++
+		for _, projection := range school.Projections {
+			idx := -1
+			for i, existing := range district.Projections {
+				if existing.SchoolYear == projection.SchoolYear {
+					idx = i
+					break
+				}
+			}
+			if idx < 0 {
+				district.Projections = append(district.Projections, models.CapacityProjection{SchoolYear: projection.SchoolYear})
+				idx = len(district.Projections) - 1
+			}
+			sum := &district.Projections[idx]
+			sum.ProjectedStudents += projection.ProjectedStudents
+			sum.Capacity += projection.Capacity
+			sum.CompletedProjects += projection.CompletedProjects
+		}
+	}
+	sort.Strings(names)
+
+	result := make([]models.DistrictCapacityForecast, 0, len(names))
+	for _, name := range names {
+		district := byDistrict[name]
+		sort.Slice(district.Projections, func(i, j int) bool {
+			return district.Projections[i].SchoolYear < district.Projections[j].SchoolYear
+		})
+		for i := range district.Projections {
+			p := &district.Projections[i]
+			*p = newCapacityProjection(p.SchoolYear, p.ProjectedStudents, p.Capacity, p.CompletedProjects)
+		}
+		result = append(result, *district)
+	}
+	return result
+}
+
+func newCapacityProjection(schoolYear string, students, capacity, completed int) models.CapacityProjection {
+	projection := models.CapacityProjection{
+		SchoolYear:        schoolYear,
+		ProjectedStudents: students,
+		Capacity:          capacity,
+		CompletedProjects: completed,
+	}
+	if capacity > 0 {
+		projection.Utilization = math.Round(float64(students)/float64(capacity)*100) / 100
+	}
+	return projection
+}
+
+// projectCapacity returns the places a school has after a construction project: the
+// published places, otherwise the class tracks times the grades of the school type
+func projectCapacity(project models.ConstructionProject, schoolType string) int {
+	if places, ok := parseCount(project.PlacesAfterConstruction); ok {
+		return places
+	}
+	match := leadingNumberPattern.FindString(project.ClassTracksAfterConstruction)
+	tracks, err := strconv.ParseFloat(strings.Replace(match, ",", ".", 1), 64)
+	if err != nil {
+		return 0
+	}
+	return int(math.Round(tracks * float64(gradesForSchoolType(schoolType)) * forecastClassSize))
+}
+
+// gradesForSchoolType returns the number of grades a school type teaches in Berlin
+func gradesForSchoolType(schoolType string) int {
+	switch t := strings.ToLower(schoolType); {
+	case strings.Contains(t, "gemeinschaftsschule"):
+		return 13 // Grades 1-13
+	default:
+		return 6 // Grundschule 1-6, Gymnasium and ISS 7-12
+	}
+}
+
+// handoverYear reads the last year from a handover date ("2026", "Q3/2027", "2025/2026")
+func handoverYear(s string) (int, bool) {
+	matches := yearPattern.FindAllString(s, -1)
+	if len(matches) == 0 {
+		return 0, false
+	}
+	year, err := strconv.Atoi(matches[len(matches)-1])
+	return year, err == nil
+}
+
+// parseCount reads a whole count such as "523" or "1.023", ignoring thousands separators
+func parseCount(s string) (int, bool) {
+	match := leadingNumberPattern.FindString(s)
+	if match == "" {
+		return 0, false
+	}
+	// Counts are whole numbers, so a separator followed by three digits groups thousands
+	if idx := strings.IndexAny(match, ".,"); idx >= 0 {
+		if len(match)-idx-1 != 3 {
+			match = match[:idx]
+		} else {
+			match = match[:idx] + match[idx+1:]
+		}
+	}
+	n, err := strconv.Atoi(match)
+	return n, err == nil
+}
+
+// schoolYearStart returns the calendar year a school year ("2024/25") starts in
+func schoolYearStart(schoolYear string) (int, bool) {
+	if !schoolYearPattern.MatchString(schoolYear) {
+		return 0, false
+	}
+	year, err := strconv.Atoi(schoolYear[:4])
+	return year, err == nil
+}
+
+// formatSchoolYear formats the school year starting in year as "2024/25"
+func formatSchoolYear(year int) string {
+	return fmt.Sprintf("%d/%02d", year, (year+1)%100)
+}
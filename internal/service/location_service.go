@@ -0,0 +1,325 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+
+	"schools-be/internal/errors"
+	"schools-be/internal/models"
+	"schools-be/internal/repository"
+)
+
+// commuteComputeTimeout bounds the background matrix computation for one location
+const commuteComputeTimeout = 5 * time.Minute
+
+// Locations are computed by a fixed number of workers, so anonymous registrations cannot
+// exhaust the OpenRouteService quota; registrations beyond the pending limit are rejected
+const (
+	commuteWorkers         = 2
+	maxPendingCommuteLists = 20
+)
+
+// commuteJob is a registered location waiting for its commute times
+type commuteJob struct {
+	location models.UserLocation
+	modes    []string
+}
+
+// LocationService stores anonymous home locations and computes commute times
+// from them to every school in the background
+type LocationService struct {
+	repo          *repository.LocationRepository
+	schoolService *SchoolService
+	routesService *RoutesService
+	logger        *slog.Logger
+
+	jobs    chan commuteJob // Locations waiting for a worker; capacity is the pending limit
+	workers sync.Once
+}
+
+func NewLocationService(repo *repository.LocationRepository, schoolService *SchoolService, routesService *RoutesService, logger *slog.Logger) *LocationService {
+	return &LocationService{
+		repo:          repo,
+		schoolService: schoolService,
+		routesService: routesService,
+		logger:        logger.With(slog.String("service", "location")),
+		jobs:          make(chan commuteJob, maxPendingCommuteLists),
+	}
+}
+
+// RegisterLocationRequest registers a home coordinate. Modes defaults to all supported modes.
+type RegisterLocationRequest struct {
+	Latitude  float64  `json:"latitude" validate:"required,latitude"`
+	Longitude float64  `json:"longitude" validate:"required,longitude"`
+	Modes     []string `json:"modes"`
+}
+
+// LocationStatus is a registered location with the state of its commute computations
+type LocationStatus struct {
+	Location models.UserLocation          `json:"location"`
+	Commutes []models.CommuteMatrixStatus `json:"commutes"`
+}
+
+// RegisterLocation stores the location under a new token and queues the computation of
+// commute times for the requested modes. It fails with ErrUnavailable while too many
+// locations are waiting.
+func (s *LocationService) RegisterLocation(ctx context.Context, req RegisterLocationRequest) (*LocationStatus, error) {
+	modes := req.Modes
+	if len(modes) == 0 {
+		modes = SupportedModes()
+	}
+	for _, mode := range modes {
+		if !IsSupportedMode(mode) {
+			return nil, errors.NewValidationError("modes", "unsupported travel mode: "+mode)
+		}
+	}
+
+	if len(s.jobs) == cap(s.jobs) {
+		return nil, fmt.Errorf("%w: too many locations are waiting for commute times", errors.ErrUnavailable)
+	}
+
+	token, err := newLocationToken()
+	if err != nil {
+		return nil, err
+	}
+
+	location := models.UserLocation{
+		Token:     token,
+		Latitude:  req.Latitude,
+		Longitude: req.Longitude,
+		CreatedAt: time.Now(),
+	}
+	if err := s.repo.Create(ctx, &location); err != nil {
+		return nil, err
+	}
+
+	for _, mode := range modes {
+		if err := s.repo.SetStatus(ctx, token, mode, models.CommuteStatusPending, ""); err != nil {
+			return nil, err
+		}
+	}
+
+	s.startWorkers()
+	select {
+	case s.jobs <- commuteJob{location: location, modes: modes}:
+	default:
+		// Filled up by concurrent registrations since the check above
+		for _, mode := range modes {
+			s.setStatus(token, mode, models.CommuteStatusFailed, "too many locations are waiting for commute times, register again later")
+		}
+	}
+
+	return s.GetLocation(ctx, token)
+}
+
+// GetLocation returns a registered location and its commute computation states
+func (s *LocationService) GetLocation(ctx context.Context, token string) (*LocationStatus, error) {
+	location, err := s.repo.GetByToken(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses, err := s.repo.GetStatuses(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	return &LocationStatus{Location: *location, Commutes: statuses}, nil
+}
+
+// GetSchoolsByCommute returns enriched schools ordered by commute time from the location.
+// Schools without a route are appended last. The schools are only returned once the
+// computation for the mode is ready, or unsorted when it failed (e.g. ORS is down);
+// while it is pending the current status is returned alone.
+func (s *LocationService) GetSchoolsByCommute(ctx context.Context, token, mode string) ([]models.EnrichedSchool, *models.CommuteMatrixStatus, error) {
+	if !IsSupportedMode(mode) {
+		return nil, nil, errors.NewValidationError("mode", "unsupported travel mode: "+mode)
+	}
+
+	location, err := s.GetLocation(ctx, token)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	idx := slices.IndexFunc(location.Commutes, func(c models.CommuteMatrixStatus) bool { return c.Mode == mode })
+	if idx < 0 {
+		return nil, nil, errors.NewNotFoundError("commute mode", mode)
+	}
+	status := location.Commutes[idx]
+	if status.Status == models.CommuteStatusFailed {
+		schools, err := s.loadEnrichedSchools(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+		return schools, &status, nil
+	}
+	if status.Status != models.CommuteStatusReady {
+		return nil, &status, nil
+	}
+
+	times, err := s.repo.GetCommuteTimes(ctx, token, mode)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	schools, err := s.loadEnrichedSchools(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rank := make(map[int64]int, len(times))
+	commutes := make(map[int64]models.CommuteTime, len(times))
+	for i, t := range times {
+		t.DurationMinutes = int(t.DurationSeconds / 60)
+		t.DistanceKm = float64(int(t.DistanceMeters/100)) / 10
+		rank[t.SchoolID] = i
+		commutes[t.SchoolID] = t
+	}
+
+	for i := range schools {
+		if t, ok := commutes[schools[i].School.ID]; ok {
+			schools[i].Commute = &t
+		}
+	}
+
+	slices.SortStableFunc(schools, func(a, b models.EnrichedSchool) int {
+		ra, okA := rank[a.School.ID]
+		rb, okB := rank[b.School.ID]
+		switch {
+		case okA && okB:
+			return ra - rb
+		case okA:
+			return -1
+		case okB:
+			return 1
+		default:
+			return 0
+		}
+	})
+
+	return schools, &status, nil
+}
+
+// loadEnrichedSchools reads the materialized snapshot when available, otherwise enriches live
+func (s *LocationService) loadEnrichedSchools(ctx context.Context) ([]models.EnrichedSchool, error) {
+	builtAt, err := s.schoolService.GetEnrichedSnapshotBuiltAt(ctx)
+	if err != nil || builtAt == nil {
+		return s.schoolService.GetAllSchoolsEnriched(ctx)
+	}
+
+	var schools []models.EnrichedSchool
+	err = s.schoolService.StreamEnrichedSnapshot(ctx, func(school models.EnrichedSchool) error {
+		schools = append(schools, school)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return schools, nil
+}
+
+// startWorkers starts the workers computing queued locations; the computations outlive the
+// requests, so they get their own context
+func (s *LocationService) startWorkers() {
+	s.workers.Do(func() {
+		for range commuteWorkers {
+			go func() {
+				for job := range s.jobs {
+					s.computeCommutes(job.location, job.modes)
+				}
+			}()
+		}
+	})
+}
+
+// computeCommutes runs the matrix requests for every mode and stores the results
+func (s *LocationService) computeCommutes(location models.UserLocation, modes []string) {
+	ctx, cancel := context.WithTimeout(context.Background(), commuteComputeTimeout)
+	defer cancel()
+
+	schools, err := s.schoolService.GetAllSchools(ctx)
+	if err != nil {
+		s.failAll(location.Token, modes, err)
+		return
+	}
+
+	// Only schools with coordinates can be routed to
+	var routable []models.School
+	for _, school := range schools {
+		if !school.HasCoordinates() {
+			continue
+		}
+		routable = append(routable, school)
+	}
+
+	origin := [2]float64{location.Longitude, location.Latitude}
+	for _, mode := range modes {
+		if err := s.computeMode(ctx, location.Token, mode, origin, routable); err != nil {
+			s.logger.ErrorContext(ctx, "failed to compute commute times",
+				slog.String("mode", mode),
+				slog.String("error", err.Error()),
+			)
+			s.setStatus(location.Token, mode, models.CommuteStatusFailed, err.Error())
+			continue
+		}
+		s.setStatus(location.Token, mode, models.CommuteStatusReady, "")
+	}
+}
+
+func (s *LocationService) computeMode(ctx context.Context, token, mode string, origin [2]float64, schools []models.School) error {
+	results, err := s.routesService.CalculateSchoolMatrix(ctx, origin, schools, mode)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	times := make([]models.CommuteTime, 0, len(results))
+	for i, result := range results {
+		if !result.OK {
+			continue
+		}
+		times = append(times, models.CommuteTime{
+			LocationToken:   token,
+			Mode:            mode,
+			SchoolID:        schools[i].ID,
+			DurationSeconds: result.DurationSeconds,
+			DistanceMeters:  result.DistanceMeters,
+			ComputedAt:      now,
+		})
+	}
+
+	return s.repo.ReplaceCommuteTimes(ctx, token, mode, times)
+}
+
+func (s *LocationService) failAll(token string, modes []string, err error) {
+	s.logger.Error("failed to load schools for commute times", slog.String("error", err.Error()))
+	for _, mode := range modes {
+		s.setStatus(token, mode, models.CommuteStatusFailed, err.Error())
+	}
+}
+
+// setStatus records a computation state; it uses its own context so a timed-out
+// computation is still marked as failed
+func (s *LocationService) setStatus(token, mode, status, errMsg string) {
+	if err := s.repo.SetStatus(context.Background(), token, mode, status, errMsg); err != nil {
+		s.logger.Error("failed to update commute status",
+			slog.String("mode", mode),
+			slog.String("error", err.Error()),
+		)
+	}
+}
+
+func newLocationToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate location token: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,218 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/jpeg" // Tile servers may serve JPEG
+	"image/png"
+	"log/slog"
+	"math"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"schools-be/internal/config"
+	"schools-be/internal/requestid"
+)
+
+const (
+	mapTileSize = 256
+
+	// Bounds for requested thumbnails; tiles are fetched one by one, so large images are slow
+	MapMinZoom   = 10
+	MapMaxZoom   = 18
+	MapMaxWidth  = 1200
+	MapMaxHeight = 800
+
+	// mapUserAgent identifies the service to tile servers, as the OpenStreetMap tile policy requires
+	mapUserAgent = "schools-be/1.0 (Berlin school finder)"
+)
+
+// MapAttribution must be shown next to maps built from the default OpenStreetMap tiles
+const MapAttribution = "© OpenStreetMap contributors"
+
+var (
+	mapMarkerFill    = color.RGBA{R: 0xd3, G: 0x2f, B: 0x2f, A: 0xff}
+	mapMarkerOutline = color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}
+)
+
+// MapOptions describes a static map thumbnail centred on a point
+type MapOptions struct {
+	Zoom   int
+	Width  int
+	Height int
+}
+
+// MapService stitches map tiles into static PNG thumbnails with a marker and caches them on disk
+type MapService struct {
+	tileURL    string
+	cacheDir   string
+	httpClient *http.Client
+	logger     *slog.Logger
+}
+
+func NewMapService(config *config.Config, logger *slog.Logger) *MapService {
+	return &MapService{
+		tileURL:  config.MapTileURL,
+		cacheDir: config.MapCacheDir,
+		httpClient: &http.Client{
+			Timeout:   15 * time.Second,
+			Transport: requestid.Transport{},
+		},
+		logger: logger.With(slog.String("service", "map")),
+	}
+}
+
+// Validate checks the requested size and zoom against the service limits
+func (o MapOptions) Validate() error {
+	if o.Zoom < MapMinZoom || o.Zoom > MapMaxZoom {
+		return fmt.Errorf("zoom must be between %d and %d", MapMinZoom, MapMaxZoom)
+	}
+	if o.Width < 1 || o.Width > MapMaxWidth || o.Height < 1 || o.Height > MapMaxHeight {
+		return fmt.Errorf("size must be at most %dx%d", MapMaxWidth, MapMaxHeight)
+	}
+	return nil
+}
+
+// RenderMap returns a PNG centred on lat/lon with a marker at the centre. Images are cached
+// by position and options, so a school's thumbnail is only stitched once.
+func (s *MapService) RenderMap(ctx context.Context, lat, lon float64, opts MapOptions) ([]byte, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+
+	cachePath := filepath.Join(s.cacheDir, fmt.Sprintf("%d_%.5f_%.5f_%dx%d.png", opts.Zoom, lat, lon, opts.Width, opts.Height))
+	if data, err := os.ReadFile(cachePath); err == nil {
+		return data, nil
+	}
+
+	img, err := s.stitch(ctx, lat, lon, opts)
+	if err != nil {
+		return nil, err
+	}
+	drawMarker(img, opts.Width/2, opts.Height/2)
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("failed to encode map: %w", err)
+	}
+
+	if err := writeFileAtomic(cachePath, buf.Bytes()); err != nil {
+		s.logger.WarnContext(ctx, "failed to cache map", slog.String("error", err.Error()))
+	}
+	return buf.Bytes(), nil
+}
+
+// stitch draws the tiles covering the image, using Web Mercator pixel coordinates at the zoom level
+func (s *MapService) stitch(ctx context.Context, lat, lon float64, opts MapOptions) (*image.RGBA, error) {
+	centerX, centerY := mercatorPixel(lat, lon, opts.Zoom)
+	left := int(math.Floor(centerX)) - opts.Width/2
+	top := int(math.Floor(centerY)) - opts.Height/2
+
+	img := image.NewRGBA(image.Rect(0, 0, opts.Width, opts.Height))
+	tiles := 1 << opts.Zoom
+	for tileY := floorDiv(top, mapTileSize); tileY*mapTileSize < top+opts.Height; tileY++ {
+		if tileY < 0 || tileY >= tiles {
+			continue
+		}
+		for tileX := floorDiv(left, mapTileSize); tileX*mapTileSize < left+opts.Width; tileX++ {
+			tile, err := s.fetchTile(ctx, opts.Zoom, ((tileX%tiles)+tiles)%tiles, tileY)
+			if err != nil {
+				return nil, err
+			}
+			offset := image.Pt(tileX*mapTileSize-left, tileY*mapTileSize-top)
+			draw.Draw(img, tile.Bounds().Add(offset), tile, tile.Bounds().Min, draw.Src)
+		}
+	}
+	return img, nil
+}
+
+func (s *MapService) fetchTile(ctx context.Context, zoom, x, y int) (image.Image, error) {
+	url := strings.NewReplacer(
+		"{z}", strconv.Itoa(zoom),
+		"{x}", strconv.Itoa(x),
+		"{y}", strconv.Itoa(y),
+	).Replace(s.tileURL)
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create tile request: %w", err)
+	}
+	req.Header.Set("User-Agent", mapUserAgent)
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch tile: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch tile %d/%d/%d: %s", zoom, x, y, resp.Status)
+	}
+
+	tile, _, err := image.Decode(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode tile %d/%d/%d: %w", zoom, x, y, err)
+	}
+	return tile, nil
+}
+
+// mercatorPixel converts a coordinate to global Web Mercator pixel coordinates at a zoom level
+func mercatorPixel(lat, lon float64, zoom int) (x, y float64) {
+	scale := float64(mapTileSize) * math.Exp2(float64(zoom))
+	sinLat := math.Sin(lat * math.Pi / 180)
+	x = (lon + 180) / 360 * scale
+	y = (0.5 - math.Log((1+sinLat)/(1-sinLat))/(4*math.Pi)) * scale
+	return x, y
+}
+
+// drawMarker draws a filled circle with an outline at the given pixel
+func drawMarker(img *image.RGBA, cx, cy int) {
+	const radius, outline = 7, 2
+	for dy := -radius - outline; dy <= radius+outline; dy++ {
+		for dx := -radius - outline; dx <= radius+outline; dx++ {
+			d := math.Hypot(float64(dx), float64(dy))
+			switch {
+			case d <= radius:
+				img.Set(cx+dx, cy+dy, mapMarkerFill)
+			case d <= radius+outline:
+				img.Set(cx+dx, cy+dy, mapMarkerOutline)
+			}
+		}
+	}
+}
+
+func floorDiv(a, b int) int {
+	q := a / b
+	if a%b != 0 && a < 0 {
+		q--
+	}
+	return q
+}
+
+// writeFileAtomic writes through a temporary file so concurrent readers never see a partial file
+func writeFileAtomic(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
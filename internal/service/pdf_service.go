@@ -0,0 +1,80 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/chromedp"
+)
+
+const (
+	// pdfRenderTimeout bounds starting Chrome and printing one document
+	pdfRenderTimeout = 30 * time.Second
+
+	// pdfMaxConcurrent caps the headless Chrome instances rendering at the same time
+	pdfMaxConcurrent = 2
+)
+
+// PDFService prints HTML documents to PDF with headless Chrome
+type PDFService struct {
+	slots  chan struct{}
+	logger *slog.Logger
+}
+
+func NewPDFService(logger *slog.Logger) *PDFService {
+	return &PDFService{
+		slots:  make(chan struct{}, pdfMaxConcurrent),
+		logger: logger.With(slog.String("service", "pdf")),
+	}
+}
+
+// RenderHTML prints an HTML document to an A4 PDF. The document must be self-contained:
+// it is loaded as content of a blank page, so relative URLs do not resolve.
+func (s *PDFService) RenderHTML(ctx context.Context, html string) ([]byte, error) {
+	select {
+	case s.slots <- struct{}{}:
+		defer func() { <-s.slots }()
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, pdfRenderTimeout)
+	defer cancel()
+	browserCtx, cancelBrowser := chromedp.NewContext(ctx)
+	defer cancelBrowser()
+
+	start := time.Now()
+	var pdf []byte
+	err := chromedp.Run(browserCtx,
+		chromedp.Navigate("about:blank"),
+		chromedp.ActionFunc(func(ctx context.Context) error {
+			tree, err := page.GetFrameTree().Do(ctx)
+			if err != nil {
+				return err
+			}
+			return page.SetDocumentContent(tree.Frame.ID, html).Do(ctx)
+		}),
+		chromedp.ActionFunc(func(ctx context.Context) error {
+			var err error
+			pdf, _, err = page.PrintToPDF().
+				WithPrintBackground(true).
+				WithPaperWidth(8.27). // A4 in inches
+				WithPaperHeight(11.69).
+				WithPreferCSSPageSize(true).
+				Do(ctx)
+			return err
+		}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render pdf: %w", err)
+	}
+
+	s.logger.DebugContext(ctx, "rendered pdf",
+		slog.Int("bytes", len(pdf)),
+		slog.Duration("duration", time.Since(start)),
+	)
+	return pdf, nil
+}
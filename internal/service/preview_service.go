@@ -0,0 +1,173 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"unicode/utf8"
+
+	"schools-be/internal/cache"
+	"schools-be/internal/i18n"
+	"schools-be/internal/models"
+)
+
+const (
+	// previewDescriptionMaxLength keeps descriptions within what messaging apps display
+	previewDescriptionMaxLength = 200
+
+	previewSiteName = "Berlin Schools"
+
+	// Size of the default map thumbnail, the 1.91:1 ratio messaging apps expect
+	PreviewImageWidth  = 600
+	PreviewImageHeight = 315
+)
+
+// Description sources reported with a preview
+const (
+	PreviewDescriptionAI   = "ai_summary"
+	PreviewDescriptionData = "school_data"
+)
+
+// PreviewService builds link preview metadata for schools. Previews are cached per school
+// version, so the AI summary is generated once per school rather than per shared link.
+type PreviewService struct {
+	schoolService *SchoolService
+	aiService     *AIService
+	cache         *cache.Cache
+	logger        *slog.Logger
+}
+
+// NewPreviewService creates the service; aiService may be nil, then descriptions are built from the data
+func NewPreviewService(schoolService *SchoolService, aiService *AIService, cache *cache.Cache, logger *slog.Logger) *PreviewService {
+	return &PreviewService{
+		schoolService: schoolService,
+		aiService:     aiService,
+		cache:         cache,
+		logger:        logger.With(slog.String("service", "preview")),
+	}
+}
+
+// GetSchoolPreview returns the preview of a school. imageURL is the absolute thumbnail URL,
+// used only when the school has a location.
+func (s *PreviewService) GetSchoolPreview(ctx context.Context, id int64, imageURL string) (*models.SchoolPreview, error) {
+	school, err := s.schoolService.GetSchoolByIDEnriched(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	key := fmt.Sprintf("%d:%d", school.School.ID, school.School.Version)
+	preview, err := cache.GetOrLoad(s.cache, key, func() (models.SchoolPreview, error) {
+		return s.buildPreview(ctx, school), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// The image URL depends on the request host, so it is not part of the cached preview
+	if school.School.HasCoordinates() {
+		preview.Image = imageURL
+		preview.ImageWidth = PreviewImageWidth
+		preview.ImageHeight = PreviewImageHeight
+		preview.ImageAlt = "Map showing the location of " + school.School.Name
+	}
+	return &preview, nil
+}
+
+func (s *PreviewService) buildPreview(ctx context.Context, school *models.EnrichedSchool) models.SchoolPreview {
+	preview := models.SchoolPreview{
+		Title:             school.School.Name,
+		Description:       dataDescription(school),
+		DescriptionSource: PreviewDescriptionData,
+		SiteName:          previewSiteName,
+		Type:              "website",
+		Locale:            i18n.English,
+	}
+
+	if s.aiService == nil {
+		return preview
+	}
+	summary, err := s.aiService.GenerateSchoolSummary(ctx, school)
+	if err != nil {
+		s.logger.WarnContext(ctx, "preview without ai summary",
+			slog.Int64("school_id", school.School.ID),
+			slog.String("error", err.Error()),
+		)
+		return preview
+	}
+	// A summary that failed validation may contain wrong facts, which is worse in a shared link
+	if !summary.Validation.Valid {
+		return preview
+	}
+	if description := truncateDescription(summary.Text, previewDescriptionMaxLength); description != "" {
+		preview.Description = description
+		preview.DescriptionSource = PreviewDescriptionAI
+	}
+	return preview
+}
+
+// dataDescription describes a school from its data: type, location and latest student count
+func dataDescription(school *models.EnrichedSchool) string {
+	var b strings.Builder
+	b.WriteString(i18n.Label(i18n.English, school.School.SchoolType))
+	if location := strings.TrimSpace(strings.Join(nonEmpty(school.School.Neighborhood, school.School.District), ", ")); location != "" {
+		b.WriteString(" in " + location)
+	}
+	b.WriteString(".")
+
+	var latest *models.SchoolStatistic
+	for i := range school.Statistics {
+		if latest == nil || school.Statistics[i].SchoolYear > latest.SchoolYear {
+			latest = &school.Statistics[i]
+		}
+	}
+	if latest != nil && latest.Students != "" {
+		fmt.Fprintf(&b, " %s students in %s.", latest.Students, latest.SchoolYear)
+	}
+	if application := latestApplication(school.Applications); application != nil {
+		if ratio, ok := application.OversubscriptionRatio(); ok {
+			fmt.Fprintf(&b, " %.1f first-choice applications per place.", ratio)
+		}
+	}
+	return truncateDescription(b.String(), previewDescriptionMaxLength)
+}
+
+// latestApplication returns the newest school year with places, or nil
+func latestApplication(applications []models.SchoolApplication) *models.SchoolApplication {
+	var latest *models.SchoolApplication
+	for i := range applications {
+		if applications[i].Places > 0 && (latest == nil || applications[i].SchoolYear > latest.SchoolYear) {
+			latest = &applications[i]
+		}
+	}
+	return latest
+}
+
+// truncateDescription shortens text to at most maxLen characters, preferring to end after a
+// sentence, otherwise at a word boundary with an ellipsis. Markdown emphasis is removed.
+func truncateDescription(text string, maxLen int) string {
+	text = strings.Join(strings.Fields(strings.NewReplacer("**", "", "__", "", "#", "").Replace(text)), " ")
+	if utf8.RuneCountInString(text) <= maxLen {
+		return text
+	}
+
+	runes := []rune(text)
+	cut := string(runes[:maxLen])
+	if end := strings.LastIndex(cut, ". "); end >= maxLen/2 {
+		return cut[:end+1]
+	}
+	if end := strings.LastIndex(cut[:len(cut)-len("…")], " "); end > 0 {
+		return strings.TrimRight(cut[:end], ",;:") + "…"
+	}
+	return string(runes[:maxLen-1]) + "…"
+}
+
+func nonEmpty(values ...string) []string {
+	var result []string
+	for _, v := range values {
+		if strings.TrimSpace(v) != "" {
+			result = append(result, v)
+		}
+	}
+	return result
+}
@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"schools-be/internal/cache"
+	"schools-be/internal/models"
+	"schools-be/internal/repository"
+)
+
+// PublicService builds the open data aggregates served without an API key. They are read by
+// anyone, so every result is cached for the public cache TTL.
+type PublicService struct {
+	publicRepo       *repository.PublicRepository
+	constructionRepo *repository.ConstructionProjectRepository
+	cache            *cache.Cache
+	logger           *slog.Logger
+}
+
+func NewPublicService(publicRepo *repository.PublicRepository, constructionRepo *repository.ConstructionProjectRepository, cache *cache.Cache, logger *slog.Logger) *PublicService {
+	return &PublicService{
+		publicRepo:       publicRepo,
+		constructionRepo: constructionRepo,
+		cache:            cache,
+		logger:           logger.With(slog.String("service", "public")),
+	}
+}
+
+// GetDistricts returns the school and student counts per district
+func (s *PublicService) GetDistricts(ctx context.Context) ([]models.DistrictCount, error) {
+	return cache.GetOrLoad(s.cache, "districts", func() ([]models.DistrictCount, error) {
+		districts, err := s.publicRepo.GetDistrictSchoolCounts(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("count schools per district: %w", err)
+		}
+		statistics, err := s.publicRepo.GetLatestYearStatistics(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("load latest statistics: %w", err)
+		}
+
+		students := make(map[string]int)
+		for _, stat := range statistics {
+			if n, ok := parseCount(stat.Students); ok {
+				students[stat.District] += n
+			}
+		}
+		for i := range districts {
+			districts[i].Students = students[districts[i].District]
+		}
+		return districts, nil
+	})
+}
+
+// GetFreshness returns the record count and last refresh of each dataset
+func (s *PublicService) GetFreshness(ctx context.Context) ([]models.DatasetFreshness, error) {
+	return cache.GetOrLoad(s.cache, "freshness", func() ([]models.DatasetFreshness, error) {
+		return s.publicRepo.GetFreshness(ctx)
+	})
+}
+
+// GetStats returns the city-wide totals of schools, students, teachers, classes and
+// construction projects
+func (s *PublicService) GetStats(ctx context.Context) (models.PublicStats, error) {
+	return cache.GetOrLoad(s.cache, "stats", func() (models.PublicStats, error) {
+		var stats models.PublicStats
+
+		schools, err := s.publicRepo.CountSchools(ctx)
+		if err != nil {
+			return stats, err
+		}
+		districts, err := s.publicRepo.GetDistrictSchoolCounts(ctx)
+		if err != nil {
+			return stats, fmt.Errorf("count schools per district: %w", err)
+		}
+		stats.Schools = schools
+		stats.Districts = len(districts)
+
+		statistics, err := s.publicRepo.GetLatestYearStatistics(ctx)
+		if err != nil {
+			return stats, fmt.Errorf("load latest statistics: %w", err)
+		}
+		for _, stat := range statistics {
+			stats.SchoolYear = stat.SchoolYear
+			if n, ok := parseCount(stat.Students); ok {
+				stats.Students += n
+			}
+			if n, ok := parseCount(stat.Teachers); ok {
+				stats.Teachers += n
+			}
+			if n, ok := parseCount(stat.Classes); ok {
+				stats.Classes += n
+			}
+		}
+
+		projects, err := s.constructionRepo.GetAll(ctx)
+		if err != nil {
+			return stats, fmt.Errorf("load construction projects: %w", err)
+		}
+		stats.ConstructionProjects = len(projects)
+		for _, project := range projects {
+			if amount, ok := project.TotalCostsEUR(); ok {
+				stats.ConstructionInvestmentEUR += amount
+			}
+		}
+		return stats, nil
+	})
+}
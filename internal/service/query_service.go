@@ -0,0 +1,208 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+	"unicode"
+
+	"schools-be/internal/errors"
+	"schools-be/internal/models"
+	"schools-be/internal/repository"
+)
+
+// maxQueryLength keeps statements to what someone would type for an analysis
+const maxQueryLength = 10000
+
+// queryStatementKeywords are the keywords a query may start with
+var queryStatementKeywords = map[string]bool{
+	"SELECT": true,
+	"WITH":   true,
+	"VALUES": true,
+}
+
+// queryWriteKeywords may not appear anywhere outside literals: a WITH clause can also
+// precede INSERT, UPDATE and DELETE. REPLACE is missing because it is also a string
+// function; REPLACE INTO is rejected by the plan check.
+var queryWriteKeywords = map[string]bool{
+	"INSERT":    true,
+	"UPDATE":    true,
+	"DELETE":    true,
+	"CREATE":    true,
+	"DROP":      true,
+	"ALTER":     true,
+	"ATTACH":    true,
+	"DETACH":    true,
+	"PRAGMA":    true,
+	"VACUUM":    true,
+	"REINDEX":   true,
+	"ANALYZE":   true,
+	"BEGIN":     true,
+	"COMMIT":    true,
+	"ROLLBACK":  true,
+	"SAVEPOINT": true,
+	"RELEASE":   true,
+	"RETURNING": true,
+}
+
+// QueryService runs ad-hoc SQL for power users. Queries pass three checks: a lexical
+// allowlist (one SELECT/WITH/VALUES statement without write keywords), a check of the
+// compiled program (no writes, only dataset tables) and the query-only reader connection.
+type QueryService struct {
+	repo    *repository.QueryRepository
+	maxRows int
+	timeout time.Duration
+	logger  *slog.Logger
+}
+
+func NewQueryService(repo *repository.QueryRepository, maxRows int, timeout time.Duration, logger *slog.Logger) *QueryService {
+	return &QueryService{
+		repo:    repo,
+		maxRows: maxRows,
+		timeout: timeout,
+		logger:  logger.With(slog.String("service", "query")),
+	}
+}
+
+// Run validates and executes a read-only query within the row and time limits
+func (s *QueryService) Run(ctx context.Context, req models.QueryRequest) (*models.QueryResult, error) {
+	query, err := validateQuery(req.SQL)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	if err := s.repo.CheckPlan(ctx, query, req.Params); err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	result, err := s.repo.Run(ctx, query, req.Params, s.maxRows)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, errors.NewValidationError("sql", "query exceeded the time limit of "+s.timeout.String())
+		}
+		return nil, err
+	}
+	result.DurationMS = time.Since(start).Milliseconds()
+
+	s.logger.InfoContext(ctx, "ad-hoc query executed",
+		slog.Int("rows", result.RowCount),
+		slog.Bool("truncated", result.Truncated),
+		slog.Int64("duration_ms", result.DurationMS),
+	)
+	return result, nil
+}
+
+// validateQuery checks a query against the lexical allowlist and returns it without a
+// trailing semicolon
+func validateQuery(sql string) (string, error) {
+	sql = strings.TrimSpace(sql)
+	if sql == "" {
+		return "", errors.NewValidationError("sql", "is required")
+	}
+	if len(sql) > maxQueryLength {
+		return "", errors.NewValidationError("sql", "is too long")
+	}
+
+	words, end, err := scanQuery(sql)
+	if err != nil {
+		return "", err
+	}
+	if strings.TrimSpace(stripComments(sql[end:])) != "" {
+		return "", errors.NewValidationError("sql", "only a single statement is allowed")
+	}
+	if len(words) == 0 || !queryStatementKeywords[words[0]] {
+		return "", errors.NewValidationError("sql", "only SELECT queries are allowed")
+	}
+	for _, w := range words {
+		if queryWriteKeywords[w] {
+			return "", errors.NewValidationError("sql", w+" is not allowed")
+		}
+	}
+	return strings.TrimSpace(sql[:end]), nil
+}
+
+// scanQuery returns the upper-cased words of the first statement outside string literals,
+// quoted identifiers and comments, and the offset where the statement ends (its semicolon
+// or the end of the input)
+func scanQuery(sql string) ([]string, int, error) {
+	var words []string
+	for i := 0; i < len(sql); {
+		c := sql[i]
+		switch {
+		case c == ';':
+			return words, i, nil
+		case c == '\'' || c == '"' || c == '`' || c == '[':
+			closing := c
+			if c == '[' {
+				closing = ']'
+			}
+			j := i + 1
+			for ; j < len(sql); j++ {
+				if sql[j] == closing {
+					// Doubled quotes escape a quote inside the literal
+					if closing != ']' && j+1 < len(sql) && sql[j+1] == closing {
+						j++
+						continue
+					}
+					break
+				}
+			}
+			if j >= len(sql) {
+				return nil, 0, errors.NewValidationError("sql", "unterminated quote")
+			}
+			i = j + 1
+		case c == '-' && strings.HasPrefix(sql[i:], "--"):
+			j := strings.IndexByte(sql[i:], '\n')
+			if j < 0 {
+				return words, len(sql), nil
+			}
+			i += j + 1
+		case c == '/' && strings.HasPrefix(sql[i:], "/*"):
+			j := strings.Index(sql[i+2:], "*/")
+			if j < 0 {
+				return nil, 0, errors.NewValidationError("sql", "unterminated comment")
+			}
+			i += j + 4
+		case c == '_' || unicode.IsLetter(rune(c)):
+			j := i
+			for j < len(sql) && (sql[j] == '_' || sql[j] == '$' || unicode.IsLetter(rune(sql[j])) || unicode.IsDigit(rune(sql[j]))) {
+				j++
+			}
+			words = append(words, strings.ToUpper(sql[i:j]))
+			i = j
+		default:
+			i++
+		}
+	}
+	return words, len(sql), nil
+}
+
+// stripComments removes SQL comments from text following a statement
+func stripComments(s string) string {
+	for {
+		s = strings.TrimSpace(s)
+		switch {
+		case strings.HasPrefix(s, "--"):
+			j := strings.IndexByte(s, '\n')
+			if j < 0 {
+				return ""
+			}
+			s = s[j+1:]
+		case strings.HasPrefix(s, "/*"):
+			j := strings.Index(s, "*/")
+			if j < 0 {
+				return s
+			}
+			s = s[j+2:]
+		case strings.HasPrefix(s, ";"):
+			s = s[1:]
+		default:
+			return s
+		}
+	}
+}
@@ -1,19 +1,26 @@
 package service
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"time"
 
+	"schools-be/internal/breaker"
 	"schools-be/internal/config"
+	"schools-be/internal/models"
+	"schools-be/internal/repository"
+	"schools-be/internal/requestid"
+	"schools-be/internal/utils"
 )
 
-const (
-	openRouteServiceBaseURL = "https://api.openrouteservice.org/v2"
-)
+// matrixMaxDestinations caps the destinations per matrix request (ORS limits sources × destinations)
+const matrixMaxDestinations = 1000
 
 // Map our internal mode names to OpenRouteService profiles
 var profileMap = map[string]string{
@@ -22,17 +29,26 @@ var profileMap = map[string]string{
 	"car":     "driving-car",
 }
 
+// RoutesService calculates travel times with OpenRouteService. Results to schools are
+// cached per ~100m origin grid cell, so nearby origins share them until the TTL expires.
 type RoutesService struct {
 	config     *config.Config
+	cache      *repository.TravelTimeRepository
 	httpClient *http.Client
+	breaker    *breaker.Breaker
+	logger     *slog.Logger
 }
 
-func NewRoutesService(config *config.Config) *RoutesService {
+func NewRoutesService(config *config.Config, cache *repository.TravelTimeRepository, logger *slog.Logger) *RoutesService {
 	return &RoutesService{
 		config: config,
+		cache:  cache,
 		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
+			Timeout:   30 * time.Second,
+			Transport: requestid.Transport{},
 		},
+		breaker: breaker.New("openrouteservice", breaker.DefaultThreshold, breaker.DefaultCooldown, logger),
+		logger:  logger.With(slog.String("service", "routes")),
 	}
 }
 
@@ -40,6 +56,9 @@ type TravelTimeRequest struct {
 	Start [2]float64 `json:"start"` // [lng, lat]
 	End   [2]float64 `json:"end"`   // [lng, lat]
 	Modes []string   `json:"modes"` // Array of mode names
+
+	// IncludeGeometry returns the route path so the frontend can draw it
+	IncludeGeometry bool `json:"include_geometry"`
 }
 
 type TravelTimeResponse struct {
@@ -47,6 +66,29 @@ type TravelTimeResponse struct {
 	DurationMinutes int     `json:"durationMinutes"`
 	DistanceKm      float64 `json:"distanceKm"`
 	Error           string  `json:"error,omitempty"`
+
+	// Geometry is the route as a GeoJSON LineString ([lng, lat] positions), when requested
+	Geometry *RouteGeometry `json:"geometry,omitempty"`
+
+	// Unrounded values, kept for the travel time cache
+	durationSeconds float64
+	distanceMeters  float64
+}
+
+func newTravelTimeResponse(mode string, durationSeconds, distanceMeters float64) TravelTimeResponse {
+	return TravelTimeResponse{
+		Mode:            mode,
+		DurationMinutes: int(durationSeconds / 60),
+		DistanceKm:      float64(int(distanceMeters/100)) / 10, // Round to 1 decimal
+		durationSeconds: durationSeconds,
+		distanceMeters:  distanceMeters,
+	}
+}
+
+// RouteGeometry is a GeoJSON LineString
+type RouteGeometry struct {
+	Type        string       `json:"type"`
+	Coordinates [][2]float64 `json:"coordinates"`
 }
 
 type openRouteServiceResponse struct {
@@ -57,13 +99,60 @@ type openRouteServiceResponse struct {
 				Distance float64 `json:"distance"`
 			} `json:"summary"`
 		} `json:"properties"`
+		Geometry RouteGeometry `json:"geometry"`
 	} `json:"features"`
 }
 
-// CalculateTravelTimes calculates travel times for multiple modes from start to end
-func (s *RoutesService) CalculateTravelTimes(ctx context.Context, req TravelTimeRequest) ([]TravelTimeResponse, error) {
-	if s.config.OpenRouteServiceAPIKey == "" {
-		return nil, fmt.Errorf("OpenRouteService API key is not configured")
+// checkConfigured ensures an API key is set for the public API. A self-hosted
+// instance (OPENROUTESERVICE_BASE_URL) usually runs without authentication.
+func (s *RoutesService) checkConfigured() error {
+	if s.config.OpenRouteServiceAPIKey == "" && s.config.OpenRouteServiceBaseURL == config.DefaultOpenRouteServiceBaseURL {
+		return fmt.Errorf("OpenRouteService API key is not configured")
+	}
+	return nil
+}
+
+func (s *RoutesService) setAuthorization(req *http.Request) {
+	if s.config.OpenRouteServiceAPIKey != "" {
+		req.Header.Set("Authorization", s.config.OpenRouteServiceAPIKey)
+	}
+}
+
+// Available reports whether ORS is called; while its circuit breaker is open, only cached
+// travel times are returned
+func (s *RoutesService) Available() bool {
+	return s.breaker.Available()
+}
+
+// do sends a request to ORS through the circuit breaker. Network errors, rate limiting and
+// server errors count as failures; other error responses are answers about the route.
+func (s *RoutesService) do(req *http.Request) (*http.Response, error) {
+	if err := s.breaker.Allow(); err != nil {
+		return nil, err
+	}
+
+	resp, err := s.httpClient.Do(req)
+	switch {
+	case err != nil:
+		s.breaker.Record(err)
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		s.breaker.Record(fmt.Errorf("status %d", resp.StatusCode))
+	default:
+		s.breaker.Record(nil)
+	}
+	return resp, err
+}
+
+// cacheEnabled reports whether travel times are cached
+func (s *RoutesService) cacheEnabled() bool {
+	return s.cache != nil && s.config.TravelTimeCacheTTL > 0
+}
+
+// CalculateTravelTimes calculates travel times for multiple modes from start to end.
+// When end is the location of a school (schoolID > 0), results are served from and stored in the cache.
+func (s *RoutesService) CalculateTravelTimes(ctx context.Context, schoolID int64, req TravelTimeRequest) ([]TravelTimeResponse, error) {
+	if err := s.checkConfigured(); err != nil {
+		return nil, err
 	}
 
 	if len(req.Start) != 2 || len(req.End) != 2 {
@@ -76,16 +165,58 @@ func (s *RoutesService) CalculateTravelTimes(ctx context.Context, req TravelTime
 
 	results := make([]TravelTimeResponse, 0, len(req.Modes))
 
+	// Geometry is not cached, so those requests always go to ORS
+	cacheable := schoolID > 0 && !req.IncludeGeometry && s.cacheEnabled()
+	cell := utils.GridCell(req.Start[1], req.Start[0])
+	notBefore := time.Now().Add(-s.config.TravelTimeCacheTTL)
+
 	// Process each mode sequentially (to respect API rate limits)
 	for _, mode := range req.Modes {
-		result := s.fetchTravelTime(ctx, req.Start, req.End, mode)
+		cacheMode := cacheable && IsSupportedMode(mode)
+		if cacheMode {
+			entry, err := s.cache.Get(ctx, cell, mode, schoolID, notBefore)
+			if err != nil {
+				s.logger.WarnContext(ctx, "failed to read travel time cache", slog.String("error", err.Error()))
+			} else if entry != nil {
+				results = append(results, newTravelTimeResponse(mode, entry.DurationSeconds, entry.DistanceMeters))
+				continue
+			}
+		}
+
+		result := s.fetchTravelTime(ctx, req.Start, req.End, mode, req.IncludeGeometry)
 		results = append(results, result)
+
+		if cacheMode && result.Error == "" {
+			s.storeCached(ctx, []models.CachedTravelTime{{
+				Cell:            cell,
+				SchoolID:        schoolID,
+				Mode:            mode,
+				DurationSeconds: result.durationSeconds,
+				DistanceMeters:  result.distanceMeters,
+				ComputedAt:      time.Now(),
+			}})
+		}
 	}
 
 	return results, nil
 }
 
-func (s *RoutesService) fetchTravelTime(ctx context.Context, start, end [2]float64, mode string) TravelTimeResponse {
+// storeCached writes travel times to the cache; failures only cost a future ORS call
+func (s *RoutesService) storeCached(ctx context.Context, entries []models.CachedTravelTime) {
+	if err := s.cache.Upsert(ctx, entries); err != nil {
+		s.logger.WarnContext(ctx, "failed to write travel time cache", slog.String("error", err.Error()))
+	}
+}
+
+// PurgeExpiredCache deletes cached travel times older than the TTL
+func (s *RoutesService) PurgeExpiredCache(ctx context.Context) (int64, error) {
+	if !s.cacheEnabled() {
+		return 0, nil
+	}
+	return s.cache.DeleteExpired(ctx, time.Now().Add(-s.config.TravelTimeCacheTTL))
+}
+
+func (s *RoutesService) fetchTravelTime(ctx context.Context, start, end [2]float64, mode string, includeGeometry bool) TravelTimeResponse {
 	profile, ok := profileMap[mode]
 	if !ok {
 		profile = "foot-walking" // default
@@ -93,7 +224,7 @@ func (s *RoutesService) fetchTravelTime(ctx context.Context, start, end [2]float
 
 	url := fmt.Sprintf(
 		"%s/directions/%s?start=%f,%f&end=%f,%f",
-		openRouteServiceBaseURL,
+		s.config.OpenRouteServiceBaseURL,
 		profile,
 		start[0], start[1],
 		end[0], end[1],
@@ -107,10 +238,16 @@ func (s *RoutesService) fetchTravelTime(ctx context.Context, start, end [2]float
 		}
 	}
 
-	req.Header.Set("Authorization", s.config.OpenRouteServiceAPIKey)
+	s.setAuthorization(req)
 	req.Header.Set("Accept", "application/geo+json;charset=UTF-8")
 
-	resp, err := s.httpClient.Do(req)
+	resp, err := s.do(req)
+	if errors.Is(err, breaker.ErrOpen) {
+		return TravelTimeResponse{
+			Mode:  mode,
+			Error: "routing service is temporarily unavailable",
+		}
+	}
 	if err != nil {
 		return TravelTimeResponse{
 			Mode:  mode,
@@ -146,9 +283,201 @@ func (s *RoutesService) fetchTravelTime(ctx context.Context, start, end [2]float
 	durationSeconds := orsResp.Features[0].Properties.Summary.Duration
 	distanceMeters := orsResp.Features[0].Properties.Summary.Distance
 
-	return TravelTimeResponse{
-		Mode:            mode,
-		DurationMinutes: int(durationSeconds / 60),
-		DistanceKm:      float64(int(distanceMeters/100)) / 10, // Round to 1 decimal
+	result := newTravelTimeResponse(mode, durationSeconds, distanceMeters)
+
+	// The GeoJSON directions response always carries the LineString; only pass it on when asked
+	if includeGeometry {
+		geometry := orsResp.Features[0].Geometry
+		result.Geometry = &geometry
+	}
+
+	return result
+}
+
+// IsSupportedMode reports whether a travel mode maps to an OpenRouteService profile.
+// Public transport is not offered by OpenRouteService, so "transit" is not supported.
+func IsSupportedMode(mode string) bool {
+	_, ok := profileMap[mode]
+	return ok
+}
+
+// SupportedModes returns the travel modes that can be routed
+func SupportedModes() []string {
+	return []string{"walking", "bicycle", "car"}
+}
+
+// MatrixResult is the travel time from the matrix origin to one destination.
+// Unreachable destinations have OK set to false.
+type MatrixResult struct {
+	DurationSeconds float64
+	DistanceMeters  float64
+	OK              bool
+}
+
+type openRouteServiceMatrixResponse struct {
+	Durations [][]*float64 `json:"durations"`
+	Distances [][]*float64 `json:"distances"`
+}
+
+// CalculateSchoolMatrix calculates travel times from one origin to each school, using cached
+// results for the origin's grid cell and requesting only the missing schools from ORS.
+// The results are aligned with schools.
+func (s *RoutesService) CalculateSchoolMatrix(ctx context.Context, origin [2]float64, schools []models.School, mode string) ([]MatrixResult, error) {
+	results := make([]MatrixResult, len(schools))
+	missing := make([]int, 0, len(schools))
+
+	cell := utils.GridCell(origin[1], origin[0])
+	var cached map[int64]models.CachedTravelTime
+	if s.cacheEnabled() {
+		var err error
+		cached, err = s.cache.GetByCell(ctx, cell, mode, time.Now().Add(-s.config.TravelTimeCacheTTL))
+		if err != nil {
+			s.logger.WarnContext(ctx, "failed to read travel time cache", slog.String("error", err.Error()))
+		}
 	}
+
+	for i, school := range schools {
+		if !school.HasCoordinates() {
+			continue // Cannot be routed to; the result stays not OK
+		}
+		if entry, ok := cached[school.ID]; ok {
+			results[i] = MatrixResult{DurationSeconds: entry.DurationSeconds, DistanceMeters: entry.DistanceMeters, OK: true}
+			continue
+		}
+		missing = append(missing, i)
+	}
+
+	if len(missing) == 0 {
+		return results, nil
+	}
+
+	destinations := make([][2]float64, len(missing))
+	for j, i := range missing {
+		lat, lon, _ := schools[i].Coordinates()
+		destinations[j] = [2]float64{lon, lat}
+	}
+
+	fetched, err := s.CalculateMatrix(ctx, origin, destinations, mode)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	entries := make([]models.CachedTravelTime, 0, len(fetched))
+	for j, result := range fetched {
+		i := missing[j]
+		results[i] = result
+		if result.OK {
+			entries = append(entries, models.CachedTravelTime{
+				Cell:            cell,
+				SchoolID:        schools[i].ID,
+				Mode:            mode,
+				DurationSeconds: result.DurationSeconds,
+				DistanceMeters:  result.DistanceMeters,
+				ComputedAt:      now,
+			})
+		}
+	}
+
+	if s.cacheEnabled() {
+		s.storeCached(ctx, entries)
+	}
+
+	s.logger.InfoContext(ctx, "computed commute matrix",
+		slog.String("mode", mode),
+		slog.Int("cached", len(schools)-len(missing)),
+		slog.Int("fetched", len(missing)),
+	)
+
+	return results, nil
+}
+
+// CalculateMatrix calculates travel times from one origin to many destinations ([lng, lat] pairs),
+// splitting the destinations into batches that fit the matrix API limits
+func (s *RoutesService) CalculateMatrix(ctx context.Context, origin [2]float64, destinations [][2]float64, mode string) ([]MatrixResult, error) {
+	if err := s.checkConfigured(); err != nil {
+		return nil, err
+	}
+
+	profile, ok := profileMap[mode]
+	if !ok {
+		return nil, fmt.Errorf("unsupported travel mode: %s", mode)
+	}
+
+	results := make([]MatrixResult, 0, len(destinations))
+	for start := 0; start < len(destinations); start += matrixMaxDestinations {
+		end := min(start+matrixMaxDestinations, len(destinations))
+
+		batch, err := s.fetchMatrix(ctx, profile, origin, destinations[start:end])
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, batch...)
+	}
+
+	return results, nil
+}
+
+func (s *RoutesService) fetchMatrix(ctx context.Context, profile string, origin [2]float64, destinations [][2]float64) ([]MatrixResult, error) {
+	locations := make([][2]float64, 0, len(destinations)+1)
+	locations = append(locations, origin)
+	locations = append(locations, destinations...)
+
+	targets := make([]int, len(destinations))
+	for i := range targets {
+		targets[i] = i + 1
+	}
+
+	body, err := json.Marshal(map[string]interface{}{
+		"locations":    locations,
+		"sources":      []int{0},
+		"destinations": targets,
+		"metrics":      []string{"duration", "distance"},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode matrix request: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/matrix/%s", s.config.OpenRouteServiceBaseURL, profile)
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	s.setAuthorization(req)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := s.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch matrix: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("API error: %d - %s", resp.StatusCode, string(body))
+	}
+
+	var orsResp openRouteServiceMatrixResponse
+	if err := json.NewDecoder(resp.Body).Decode(&orsResp); err != nil {
+		return nil, fmt.Errorf("failed to decode matrix response: %w", err)
+	}
+
+	if len(orsResp.Durations) != 1 || len(orsResp.Durations[0]) != len(destinations) {
+		return nil, fmt.Errorf("unexpected matrix response size")
+	}
+
+	results := make([]MatrixResult, len(destinations))
+	for i, duration := range orsResp.Durations[0] {
+		if duration == nil {
+			continue
+		}
+		results[i] = MatrixResult{DurationSeconds: *duration, OK: true}
+		if len(orsResp.Distances) == 1 && i < len(orsResp.Distances[0]) && orsResp.Distances[0][i] != nil {
+			results[i].DistanceMeters = *orsResp.Distances[0][i]
+		}
+	}
+
+	return results, nil
 }
@@ -0,0 +1,84 @@
+package service
+
+import (
+	"strings"
+
+	"schools-be/internal/models"
+)
+
+// detectSchoolAliases matches schools missing from the feed to numbers that are new in it,
+// since a renumbered school otherwise loses its history. A missing school is taken to have
+// the new number when exactly one new school of the same type has its address, or failing
+// that its name in the same district. Schools merged into one new number all match it;
+// ambiguous matches are left to the maintained mapping.
+func detectSchoolAliases(existing []models.School, feed []models.CreateSchoolInput) []models.SchoolAlias {
+	inFeed := make(map[string]bool, len(feed))
+	for _, input := range feed {
+		inFeed[input.SchoolNumber] = true
+	}
+	known := make(map[string]bool, len(existing))
+	for _, school := range existing {
+		known[school.SchoolNumber] = true
+	}
+
+	byAddress := make(map[string][]string)
+	byName := make(map[string][]string)
+	for _, input := range feed {
+		if known[input.SchoolNumber] {
+			continue
+		}
+		if key := addressKey(input.SchoolType, input.PostalCode, input.Street, input.HouseNumber); key != "" {
+			byAddress[key] = append(byAddress[key], input.SchoolNumber)
+		}
+		if key := nameKey(input.SchoolType, input.Name, input.District); key != "" {
+			byName[key] = append(byName[key], input.SchoolNumber)
+		}
+	}
+
+	var aliases []models.SchoolAlias
+	for _, school := range existing {
+		if inFeed[school.SchoolNumber] {
+			continue
+		}
+		if matches := byAddress[addressKey(school.SchoolType, school.PostalCode, school.Street, school.HouseNumber)]; len(matches) == 1 {
+			aliases = append(aliases, heuristicAlias(school.SchoolNumber, matches[0], "same address"))
+			continue
+		}
+		if matches := byName[nameKey(school.SchoolType, school.Name, school.District)]; len(matches) == 1 {
+			aliases = append(aliases, heuristicAlias(school.SchoolNumber, matches[0], "same name"))
+		}
+	}
+	return aliases
+}
+
+func heuristicAlias(oldNumber, newNumber, reason string) models.SchoolAlias {
+	return models.SchoolAlias{
+		OldNumber: oldNumber,
+		NewNumber: newNumber,
+		Source:    models.AliasSourceHeuristic,
+		Reason:    reason,
+	}
+}
+
+// addressKey is empty when the address is incomplete, so schools without one never match
+func addressKey(schoolType, postalCode, street, houseNumber string) string {
+	if postalCode == "" || street == "" || houseNumber == "" {
+		return ""
+	}
+	return matchKey(schoolType, postalCode, street, houseNumber)
+}
+
+func nameKey(schoolType, name, district string) string {
+	if name == "" {
+		return ""
+	}
+	return matchKey(schoolType, name, district)
+}
+
+// matchKey joins the parts case- and whitespace-insensitively
+func matchKey(parts ...string) string {
+	for i, part := range parts {
+		parts[i] = strings.ToLower(strings.Join(strings.Fields(part), " "))
+	}
+	return strings.Join(parts, "|")
+}
@@ -4,74 +4,206 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"slices"
+	"strings"
+	"time"
 
+	"schools-be/internal/metrics"
 	"schools-be/internal/models"
+	"schools-be/internal/queue"
 	"schools-be/internal/repository"
 	"schools-be/internal/scraper"
 )
 
+// detailTaskKind is the queue task scraping one school page; its payload is the page URL
+const detailTaskKind = "school_detail"
+
+// detailsScraper names the school details scraper in scrape runs and metrics
+const detailsScraper = "details"
+
+var lastRunFailures = metrics.NewGaugeVec("schools_scrape_last_run_failures",
+	"Pages that failed after all retries in the last completed scrape run, by kind", "scraper", "kind")
+
 type SchoolDetailService struct {
 	repo      *repository.SchoolDetailRepository
 	statsRepo *repository.SchoolStatisticsRepository
 	scraper   *scraper.SchoolDetailsScraper
+	queue     queue.Queue
+	order     ScrapeOrder
+	budget    time.Duration // Time a details scrape may take; 0 is unlimited
 	logger    *slog.Logger
 }
 
-func NewSchoolDetailService(repo *repository.SchoolDetailRepository, statsRepo *repository.SchoolStatisticsRepository, scraper *scraper.SchoolDetailsScraper) *SchoolDetailService {
+func NewSchoolDetailService(repo *repository.SchoolDetailRepository, statsRepo *repository.SchoolStatisticsRepository, scraper *scraper.SchoolDetailsScraper, queue queue.Queue, order ScrapeOrder, budget time.Duration, logger *slog.Logger) *SchoolDetailService {
 	return &SchoolDetailService{
 		repo:      repo,
 		statsRepo: statsRepo,
 		scraper:   scraper,
-		logger:    slog.Default(),
+		queue:     queue,
+		order:     order,
+		budget:    budget,
+		logger:    logger.With(slog.String("service", "school_detail")),
 	}
 }
 
-// ScrapeAndStoreDetails scrapes school details and stores them in the database
+// ScrapeAndStoreDetails queues one task per school page in the scrape order and waits until the
+// consumers (ConsumeDetailTasks, on this instance or others sharing the queue) scraped and stored
+// them all. Once the time budget is used up, the pages not started yet are dropped from the queue
+// and recorded as skipped; the pages in flight are finished.
 func (s *SchoolDetailService) ScrapeAndStoreDetails(ctx context.Context) error {
-	s.logger.Info("starting school details scrape and store")
+	s.logger.InfoContext(ctx, "starting school details scrape and store")
+	run := models.ScrapeRun{Scraper: detailsScraper, StartedAt: time.Now()}
 
-	// Scrape details from website
-	details, err := s.scraper.ScrapeSchoolDetails(ctx)
+	links, err := s.scraper.SchoolLinks(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to scrape school details: %w", err)
 	}
 
-	s.logger.Info("scraped school details", slog.Int("count", len(details)))
+	// Start over: leftovers and failures of the previous scrape are dropped
+	if err := s.queue.Clear(ctx, detailTaskKind); err != nil {
+		return err
+	}
+	links, err = s.order.Order(ctx, links)
+	if err != nil {
+		return fmt.Errorf("failed to order school pages: %w", err)
+	}
+	if err := s.queue.Enqueue(ctx, detailTaskKind, links...); err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(5 * time.Second)
+	defer ticker.Stop()
+	var skipped []string
+	for {
+		if s.budget > 0 && time.Since(run.StartedAt) >= s.budget {
+			dropped, err := s.queue.Drop(ctx, detailTaskKind)
+			if err != nil {
+				return err
+			}
+			if len(skipped) == 0 && len(dropped) > 0 {
+				s.logger.WarnContext(ctx, "scrape time budget used up, skipping the schools not started yet",
+					slog.Duration("budget", s.budget),
+					slog.Int("skipped", len(dropped)),
+				)
+			}
+			skipped = append(skipped, dropped...)
+		}
+
+		pending, err := s.queue.Pending(ctx, detailTaskKind)
+		if err != nil {
+			return err
+		}
+		if pending == 0 {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("school details scrape incomplete, %d of %d schools left: %w", pending, len(links), context.Cause(ctx))
+		case <-ticker.C:
+		}
+	}
+
+	failed, err := s.queue.Failed(ctx, detailTaskKind)
+	if err != nil {
+		return err
+	}
+
+	run.FinishedAt = time.Now()
+	run.Pages = len(links)
+	run.Skipped = len(skipped)
+	run.SkippedPages = strings.Join(skipped, "\n")
+	for _, task := range failed {
+		kind := models.ScrapeFailureOther
+		if task.LastError != nil {
+			kind = scraper.FailureKindOf(*task.LastError)
+		}
+		run.AddFailure(kind)
+	}
+	for kind, count := range run.FailuresByKind() {
+		lastRunFailures.Set(float64(count), detailsScraper, kind)
+	}
+	if err := s.repo.SaveScrapeRun(ctx, run); err != nil {
+		s.logger.WarnContext(ctx, "failed to record scrape run", slog.String("error", err.Error()))
+	}
+
+	s.logger.InfoContext(ctx, "scraping complete",
+		slog.Int("total", len(links)),
+		slog.Int("failed", run.Failed),
+		slog.Int("timeout", run.Timeout),
+		slog.Int("navigation", run.Navigation),
+		slog.Int("element_missing", run.ElementMissing),
+		slog.Int("parse", run.Parse),
+		slog.Int("other", run.Other),
+		slog.Int("skipped", run.Skipped),
+	)
+	if len(skipped) > 0 {
+		s.logger.WarnContext(ctx, "schools skipped by the scrape time budget", slog.Any("urls", skipped))
+	}
+	return nil
+}
+
+// ConsumeDetailTasks scrapes and stores the queued school pages until ctx is done. A page that
+// fails is retried by the queue.
+func (s *SchoolDetailService) ConsumeDetailTasks(ctx context.Context) {
+	s.queue.Consume(ctx, detailTaskKind, func(ctx context.Context, task models.QueueTask) error {
+		s.logger.InfoContext(ctx, "processing school",
+			slog.String("url", task.Payload),
+			slog.Int("attempt", task.Attempts),
+		)
+
+		detail, cached, err := s.scraper.ScrapeSchool(ctx, task.Payload)
+		if err != nil {
+			return err
+		}
+		if err := s.storeDetail(ctx, detail); err != nil {
+			return err
+		}
+
+		// Be respectful to the server (only when scraping, not when using cache)
+		if !cached {
+			select {
+			case <-ctx.Done():
+			case <-time.After(2 * time.Second):
+			}
+		}
+		return nil
+	})
+}
+
+// ReparseAndStoreDetails re-parses the cached raw school pages with the current parser version
+// and stores the results, without scraping the live site
+func (s *SchoolDetailService) ReparseAndStoreDetails(ctx context.Context, force bool) error {
+	s.logger.InfoContext(ctx, "starting school details re-parse", slog.Int("parser_version", scraper.ParserVersion))
+
+	details, err := s.scraper.ReparseCache(force)
+	if err != nil {
+		return fmt.Errorf("failed to re-parse cached school details: %w", err)
+	}
+
+	return s.storeDetails(ctx, details)
+}
 
+// storeDetails upserts school details and their normalized statistics
+func (s *SchoolDetailService) storeDetails(ctx context.Context, details []models.SchoolDetailData) error {
 	// Store each detail in database
 	successCount := 0
 	errorCount := 0
 
 	for i, detail := range details {
-		s.logger.Info("storing school detail",
+		s.logger.InfoContext(ctx, "storing school detail",
 			slog.Int("index", i+1),
 			slog.Int("total", len(details)),
 			slog.String("school", detail.SchoolName),
 		)
 
-		err := s.repo.Upsert(ctx, &detail)
-		if err != nil {
-			s.logger.Error("failed to store school detail",
-				slog.String("school", detail.SchoolName),
-				slog.String("error", err.Error()),
-			)
+		if err := s.storeDetail(ctx, &detail); err != nil {
 			errorCount++
 			continue
 		}
-
-		// Store normalized statistics
-		if err := s.saveNormalizedStatistics(ctx, &detail); err != nil {
-			s.logger.Warn("failed to store normalized statistics",
-				slog.String("school", detail.SchoolName),
-				slog.String("error", err.Error()),
-			)
-			// Don't fail the whole operation, just log the warning
-		}
-
 		successCount++
 	}
 
-	s.logger.Info("finished storing school details",
+	s.logger.InfoContext(ctx, "finished storing school details",
 		slog.Int("success", successCount),
 		slog.Int("errors", errorCount),
 	)
@@ -83,6 +215,60 @@ func (s *SchoolDetailService) ScrapeAndStoreDetails(ctx context.Context) error {
 	return nil
 }
 
+// storeDetail upserts a school detail and its normalized statistics
+func (s *SchoolDetailService) storeDetail(ctx context.Context, detail *models.SchoolDetailData) error {
+	if err := s.repo.Upsert(ctx, detail); err != nil {
+		s.logger.ErrorContext(ctx, "failed to store school detail",
+			slog.String("school", detail.SchoolName),
+			slog.String("error", err.Error()),
+		)
+		return err
+	}
+
+	// Store normalized statistics
+	if err := s.saveNormalizedStatistics(ctx, detail); err != nil {
+		s.logger.WarnContext(ctx, "failed to store normalized statistics",
+			slog.String("school", detail.SchoolName),
+			slog.String("error", err.Error()),
+		)
+		// Don't fail the whole operation, just log the warning
+	}
+	return nil
+}
+
+// LastScrapeFailures returns the schools that failed during the most recent details scrape,
+// after the queue used up their retries
+func (s *SchoolDetailService) LastScrapeFailures(ctx context.Context) ([]models.ScrapeFailure, error) {
+	tasks, err := s.queue.Failed(ctx, detailTaskKind)
+	if err != nil {
+		return nil, err
+	}
+
+	failures := make([]models.ScrapeFailure, 0, len(tasks))
+	for _, task := range tasks {
+		failure := models.ScrapeFailure{URL: task.Payload, Kind: models.ScrapeFailureOther}
+		if task.LastError != nil {
+			failure.Error = *task.LastError
+			failure.Kind = scraper.FailureKindOf(*task.LastError)
+		}
+		if task.FailedAt != nil {
+			failure.FailedAt = *task.FailedAt
+		}
+		failures = append(failures, failure)
+	}
+	return failures, nil
+}
+
+// ScrapeRuns returns the most recent completed details scrapes with their failures by kind
+func (s *SchoolDetailService) ScrapeRuns(ctx context.Context, limit int) ([]models.ScrapeRun, error) {
+	return s.repo.GetScrapeRuns(ctx, detailsScraper, limit)
+}
+
+// DataQualityIssues returns the data-quality log of the normalized statistics
+func (s *SchoolDetailService) DataQualityIssues(ctx context.Context) ([]models.DataQualityIssue, error) {
+	return s.statsRepo.GetDataQualityIssues(ctx)
+}
+
 // GetAll retrieves all school details
 func (s *SchoolDetailService) GetAll(ctx context.Context) ([]models.SchoolDetail, error) {
 	return s.repo.GetAll(ctx)
@@ -93,11 +279,42 @@ func (s *SchoolDetailService) GetBySchoolNumber(ctx context.Context, schoolNumbe
 	return s.repo.GetBySchoolNumber(ctx, schoolNumber)
 }
 
+// GetGradeStructure retrieves the classes per grade of a school, newest school year first
+func (s *SchoolDetailService) GetGradeStructure(ctx context.Context, schoolNumber string) ([]models.SchoolGradeClasses, error) {
+	return s.statsRepo.GetGradeStructure(ctx, schoolNumber)
+}
+
 // GetAvailableAfter4thGrade retrieves schools available after 4th grade
 func (s *SchoolDetailService) GetAvailableAfter4thGrade(ctx context.Context) ([]models.SchoolDetail, error) {
 	return s.repo.GetAvailableAfter4thGrade(ctx)
 }
 
+// GetByAvailableAfter4thGrade retrieves schools that do (or do not) start after 4th grade
+func (s *SchoolDetailService) GetByAvailableAfter4thGrade(ctx context.Context, available bool) ([]models.SchoolDetail, error) {
+	return s.repo.GetByAvailableAfter4thGrade(ctx, available)
+}
+
+// GetPrograms retrieves the schools with a bilingual branch or religious education, keeping
+// those with the partner language and the religious education subject when given
+func (s *SchoolDetailService) GetPrograms(ctx context.Context, language, religion string) ([]models.SchoolDetail, error) {
+	details, err := s.repo.GetWithPrograms(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	matching := details[:0]
+	for _, d := range details {
+		if language != "" && !slices.Contains(d.BilingualLanguageList(), language) {
+			continue
+		}
+		if religion != "" && !slices.Contains(d.ReligiousEducationList(), religion) {
+			continue
+		}
+		matching = append(matching, d)
+	}
+	return matching, nil
+}
+
 // GetSummary returns a summary of school details in the database
 func (s *SchoolDetailService) GetSummary(ctx context.Context) (map[string]interface{}, error) {
 	totalCount, err := s.repo.GetCount(ctx)
@@ -137,10 +354,29 @@ func (s *SchoolDetailService) saveNormalizedStatistics(ctx context.Context, deta
 
 	// Normalize and save citizenship stats
 	if detail.CitizenshipTable != nil {
-		citizenshipStats := scraper.NormalizeCitizenshipTable(detail.SchoolNumber, detail.CitizenshipTable, detail.ScrapedAt)
-		if len(citizenshipStats) > 0 {
+		citizenshipStats := s.scraper.NormalizeCitizenshipTable(detail.SchoolNumber, detail.CitizenshipTable, detail.ScrapedAt)
+		issues, broken := s.scraper.ValidateCitizenshipStats(citizenshipStats)
+		if err := s.statsRepo.SaveDataQualityIssues(ctx, detail.SchoolNumber, models.DatasetCitizenship, issues); err != nil {
+			s.logger.WarnContext(ctx, "failed to save data quality issues",
+				slog.String("school", detail.SchoolNumber),
+				slog.String("error", err.Error()),
+			)
+		}
+		if broken {
+			// Drop the stats stored from an earlier scrape too: the API serves none for this school
+			s.logger.WarnContext(ctx, "excluding inconsistent citizenship table",
+				slog.String("school", detail.SchoolNumber),
+				slog.Int("issues", len(issues)),
+			)
+			if err := s.statsRepo.DeleteCitizenshipStats(ctx, detail.SchoolNumber); err != nil {
+				s.logger.WarnContext(ctx, "failed to delete citizenship stats",
+					slog.String("school", detail.SchoolNumber),
+					slog.String("error", err.Error()),
+				)
+			}
+		} else if len(citizenshipStats) > 0 {
 			if err := s.statsRepo.SaveCitizenshipStats(ctx, citizenshipStats); err != nil {
-				s.logger.Warn("failed to save citizenship stats",
+				s.logger.WarnContext(ctx, "failed to save citizenship stats",
 					slog.String("school", detail.SchoolNumber),
 					slog.String("error", err.Error()),
 				)
@@ -150,10 +386,10 @@ func (s *SchoolDetailService) saveNormalizedStatistics(ctx context.Context, deta
 
 	// Normalize and save language stats
 	if detail.LanguageTable != nil {
-		languageStat := scraper.NormalizeLanguageTable(detail.SchoolNumber, detail.LanguageTable, detail.ScrapedAt)
+		languageStat := s.scraper.NormalizeLanguageTable(detail.SchoolNumber, detail.LanguageTable, detail.ScrapedAt)
 		if languageStat != nil {
 			if err := s.statsRepo.SaveLanguageStat(ctx, *languageStat); err != nil {
-				s.logger.Warn("failed to save language stats",
+				s.logger.WarnContext(ctx, "failed to save language stats",
 					slog.String("school", detail.SchoolNumber),
 					slog.String("error", err.Error()),
 				)
@@ -163,10 +399,10 @@ func (s *SchoolDetailService) saveNormalizedStatistics(ctx context.Context, deta
 
 	// Normalize and save residence stats
 	if detail.ResidenceTable != nil {
-		residenceStats := scraper.NormalizeResidenceTable(detail.SchoolNumber, detail.ResidenceTable, detail.ScrapedAt)
+		residenceStats := s.scraper.NormalizeResidenceTable(detail.SchoolNumber, detail.ResidenceTable, detail.ScrapedAt)
 		if len(residenceStats) > 0 {
 			if err := s.statsRepo.SaveResidenceStats(ctx, residenceStats); err != nil {
-				s.logger.Warn("failed to save residence stats",
+				s.logger.WarnContext(ctx, "failed to save residence stats",
 					slog.String("school", detail.SchoolNumber),
 					slog.String("error", err.Error()),
 				)
@@ -176,10 +412,49 @@ func (s *SchoolDetailService) saveNormalizedStatistics(ctx context.Context, deta
 
 	// Normalize and save absence stats
 	if detail.AbsenceTable != nil {
-		absenceStat := scraper.NormalizeAbsenceTable(detail.SchoolNumber, detail.AbsenceTable, detail.ScrapedAt)
+		absenceStat := s.scraper.NormalizeAbsenceTable(detail.SchoolNumber, detail.AbsenceTable, detail.ScrapedAt)
 		if absenceStat != nil {
 			if err := s.statsRepo.SaveAbsenceStat(ctx, *absenceStat); err != nil {
-				s.logger.Warn("failed to save absence stats",
+				s.logger.WarnContext(ctx, "failed to save absence stats",
+					slog.String("school", detail.SchoolNumber),
+					slog.String("error", err.Error()),
+				)
+			}
+		}
+	}
+
+	// Normalize and save staffing
+	if detail.StaffingTable != nil {
+		staffingStat := s.scraper.NormalizeStaffingTable(detail.SchoolNumber, detail.StaffingTable, detail.ScrapedAt)
+		if staffingStat != nil {
+			if err := s.statsRepo.SaveStaffingStat(ctx, *staffingStat); err != nil {
+				s.logger.WarnContext(ctx, "failed to save staffing stats",
+					slog.String("school", detail.SchoolNumber),
+					slog.String("error", err.Error()),
+				)
+			}
+		}
+	}
+
+	// Normalize and save quality framework ratings
+	if detail.QualityTable != nil {
+		indicators := s.scraper.NormalizeQualityTable(detail.SchoolNumber, detail.QualityTable, detail.ScrapedAt)
+		if len(indicators) > 0 {
+			if err := s.statsRepo.SaveQualityIndicators(ctx, detail.SchoolNumber, indicators); err != nil {
+				s.logger.WarnContext(ctx, "failed to save quality indicators",
+					slog.String("school", detail.SchoolNumber),
+					slog.String("error", err.Error()),
+				)
+			}
+		}
+	}
+
+	// Normalize and save classes per grade
+	if detail.GradeStructureTable != nil {
+		gradeStructure := s.scraper.NormalizeGradeStructureTable(detail.SchoolNumber, detail.GradeStructureTable, detail.ScrapedAt)
+		if len(gradeStructure) > 0 {
+			if err := s.statsRepo.SaveGradeStructure(ctx, detail.SchoolNumber, gradeStructure); err != nil {
+				s.logger.WarnContext(ctx, "failed to save grade structure",
 					slog.String("school", detail.SchoolNumber),
 					slog.String("error", err.Error()),
 				)
@@ -0,0 +1,71 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"schools-be/internal/models"
+	"schools-be/internal/prompts"
+)
+
+// bookkeepingFields are omitted when flattening a school record for the model
+var bookkeepingFields = map[string]bool{
+	"id":         true,
+	"created_at": true,
+	"updated_at": true,
+	"scraped_at": true,
+	"labels":     true,
+}
+
+// flattenSchool turns an enriched school into "path: value" fields (e.g. "details.lunch_info",
+// "residence_stats[0].district"), skipping empty values and bookkeeping columns
+func flattenSchool(school *models.EnrichedSchool) ([]prompts.Field, error) {
+	payload, err := json.Marshal(school)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal school: %w", err)
+	}
+
+	var tree interface{}
+	if err := json.Unmarshal(payload, &tree); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal school: %w", err)
+	}
+
+	var fields []prompts.Field
+	flattenValue("", tree, &fields)
+	return fields, nil
+}
+
+func flattenValue(path string, value interface{}, fields *[]prompts.Field) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			if !bookkeepingFields[key] {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			child := key
+			if path != "" {
+				child = path + "." + key
+			}
+			flattenValue(child, v[key], fields)
+		}
+	case []interface{}:
+		for i, item := range v {
+			flattenValue(path+"["+strconv.Itoa(i)+"]", item, fields)
+		}
+	case string:
+		if text := strings.TrimSpace(v); text != "" && text != "{}" {
+			*fields = append(*fields, prompts.Field{Path: path, Value: text})
+		}
+	case float64:
+		*fields = append(*fields, prompts.Field{Path: path, Value: strconv.FormatFloat(v, 'f', -1, 64)})
+	case bool:
+		*fields = append(*fields, prompts.Field{Path: path, Value: strconv.FormatBool(v)})
+	}
+}
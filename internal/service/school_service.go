@@ -2,8 +2,15 @@ package service
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"log/slog"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 
 	apperrors "schools-be/internal/errors"
 	"schools-be/internal/fetcher"
@@ -18,18 +25,37 @@ type SchoolService struct {
 	detailRepo       *repository.SchoolDetailRepository
 	statsRepo        *repository.SchoolStatisticsRepository
 	statisticRepo    *repository.StatisticRepository
+	enrichedRepo     *repository.EnrichedSchoolRepository
+	applicationRepo  *repository.SchoolApplicationRepository
+	aliasRepo        *repository.SchoolAliasRepository
+	aliasMapping     []models.SchoolAlias
+	searchRepo       *repository.SchoolSearchRepository
+	ratingRepo       *repository.ExternalRatingRepository // nil unless external ratings are enabled
 	fetcher          *fetcher.SchoolFetcher
 	geocoder         *utils.Geocoder
 	logger           *slog.Logger
+
+	rebuildMu     sync.Mutex    // Serializes snapshot rebuilds, so an older one cannot publish last
+	rebuilds      chan struct{} // Pending rebuild after partner edits; holds at most one
+	rebuildWorker sync.Once
 }
 
+// snapshotRebuildTimeout bounds a rebuild of the enriched snapshot after partner edits
+const snapshotRebuildTimeout = 5 * time.Minute
+
 func NewSchoolService(
 	repo *repository.SchoolRepository,
 	constructionRepo *repository.ConstructionProjectRepository,
 	detailRepo *repository.SchoolDetailRepository,
 	statsRepo *repository.SchoolStatisticsRepository,
 	statisticRepo *repository.StatisticRepository,
+	enrichedRepo *repository.EnrichedSchoolRepository,
+	applicationRepo *repository.SchoolApplicationRepository,
+	aliasRepo *repository.SchoolAliasRepository,
+	aliasMapping []models.SchoolAlias,
+	searchRepo *repository.SchoolSearchRepository,
 	fetcher *fetcher.SchoolFetcher,
+	logger *slog.Logger,
 ) *SchoolService {
 	return &SchoolService{
 		repo:             repo,
@@ -37,12 +63,25 @@ func NewSchoolService(
 		detailRepo:       detailRepo,
 		statsRepo:        statsRepo,
 		statisticRepo:    statisticRepo,
+		enrichedRepo:     enrichedRepo,
+		applicationRepo:  applicationRepo,
+		aliasRepo:        aliasRepo,
+		aliasMapping:     aliasMapping,
+		searchRepo:       searchRepo,
 		fetcher:          fetcher,
-		geocoder:         utils.NewGeocoder(),
-		logger:           slog.Default(),
+		geocoder:         utils.NewGeocoder(logger),
+		logger:           logger.With(slog.String("service", "school")),
+		rebuilds:         make(chan struct{}, 1),
 	}
 }
 
+// WithExternalRatings adds the cached Google Places rating to enriched schools as
+// external_rating; without it they have none
+func (s *SchoolService) WithExternalRatings(repo *repository.ExternalRatingRepository) *SchoolService {
+	s.ratingRepo = repo
+	return s
+}
+
 // GetAllSchools returns all schools from the database
 func (s *SchoolService) GetAllSchools(ctx context.Context) ([]models.School, error) {
 	return s.repo.GetAll(ctx)
@@ -62,20 +101,86 @@ func (s *SchoolService) GetSchoolsByType(ctx context.Context, schoolType string)
 	return s.repo.GetByType(ctx, schoolType)
 }
 
-// CreateSchool creates a new school
+// CreateSchool creates a new school, or updates the school with the same school number
 func (s *SchoolService) CreateSchool(ctx context.Context, input models.CreateSchoolInput) (*models.School, error) {
+	if strings.TrimSpace(input.SchoolNumber) == "" {
+		return nil, apperrors.NewValidationError("school_number", "school number is required")
+	}
 	return s.repo.Create(ctx, input)
 }
 
-// UpdateSchool updates an existing school
-func (s *SchoolService) UpdateSchool(ctx context.Context, id int64, input models.UpdateSchoolInput) (*models.School, error) {
+// UpdateSchool updates an existing school if it is still at expectedVersion, so concurrent
+// edits are rejected with a version conflict instead of silently overwriting each other
+func (s *SchoolService) UpdateSchool(ctx context.Context, id int64, input models.UpdateSchoolInput, expectedVersion int) (*models.School, error) {
 	// Check if school exists
 	_, err := s.repo.GetByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
 
-	return s.repo.Update(ctx, id, input)
+	if input.IsEmpty() {
+		return nil, apperrors.NewValidationError("body", "no fields to update")
+	}
+
+	school, err := s.repo.Update(ctx, id, input, expectedVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	s.scheduleSnapshotRebuild()
+
+	return school, nil
+}
+
+// MaxBatchUpdates caps the number of schools changed by one batch update
+const MaxBatchUpdates = 500
+
+// BatchUpdateSchools applies all updates in one transaction (all or nothing) and then
+// rebuilds the enriched snapshot in the background so listings show the corrections
+func (s *SchoolService) BatchUpdateSchools(ctx context.Context, updates []models.SchoolBatchUpdate) error {
+	if len(updates) == 0 {
+		return apperrors.NewValidationError("updates", "at least one update is required")
+	}
+	if len(updates) > MaxBatchUpdates {
+		return apperrors.NewValidationError("updates", fmt.Sprintf("at most %d updates per batch", MaxBatchUpdates))
+	}
+	for i, update := range updates {
+		if update.IsEmpty() {
+			return apperrors.NewValidationError(fmt.Sprintf("updates[%d]", i), "no fields to update")
+		}
+	}
+
+	if err := s.repo.BatchUpdate(ctx, updates); err != nil {
+		return err
+	}
+
+	s.logger.InfoContext(ctx, "applied school batch update", slog.Int("count", len(updates)))
+
+	s.scheduleSnapshotRebuild()
+
+	return nil
+}
+
+// scheduleSnapshotRebuild rebuilds the enriched snapshot in the background after an edit.
+// Edits made while a rebuild is pending are merged into it, and rebuilds run one at a time.
+func (s *SchoolService) scheduleSnapshotRebuild() {
+	s.rebuildWorker.Do(func() {
+		go func() {
+			for range s.rebuilds {
+				ctx, cancel := context.WithTimeout(context.Background(), snapshotRebuildTimeout)
+				if err := s.RebuildEnrichedSnapshot(ctx); err != nil {
+					s.logger.Error("failed to rebuild enriched snapshot after school update", slog.String("error", err.Error()))
+				}
+				cancel()
+			}
+		}()
+	})
+
+	select {
+	case s.rebuilds <- struct{}{}:
+	default:
+		// A rebuild is already pending and will start after this edit was committed
+	}
 }
 
 // DeleteSchool deletes a school
@@ -91,19 +196,30 @@ func (s *SchoolService) DeleteSchool(ctx context.Context, id int64) error {
 
 // FetchAndStoreSchools fetches schools from WFS API and stores them in the database
 func (s *SchoolService) FetchAndStoreSchools(ctx context.Context) error {
-	s.logger.Info("starting school data fetch")
+	s.logger.InfoContext(ctx, "starting school data fetch")
 
 	// Fetch GeoJSON from WFS
 	geoJSON, err := s.fetcher.FetchBerlinSchools()
 	if err != nil {
-		s.logger.Error("failed to fetch schools", slog.String("error", err.Error()))
+		s.logger.ErrorContext(ctx, "failed to fetch schools", slog.String("error", err.Error()))
 		return apperrors.NewDatabaseError("fetch schools", err)
 	}
 
 	// Convert to CreateSchoolInput
 	schools := make([]models.CreateSchoolInput, 0, len(geoJSON.Features))
+	seen := make(map[string]bool, len(geoJSON.Features))
 	for _, feature := range geoJSON.Features {
 		props := feature.Properties
+		if props.BSN == "" || seen[props.BSN] {
+			s.logger.WarnContext(ctx, "skipping school without unique school number",
+				slog.String("school_name", props.Schulname),
+				slog.String("school_number", props.BSN),
+			)
+			continue
+		}
+		seen[props.BSN] = true
+		lat, lon := feature.Coordinates()
+
 		school := models.CreateSchoolInput{
 			SchoolNumber:   props.BSN,
 			Name:           props.Schulname,
@@ -120,54 +236,76 @@ func (s *SchoolService) FetchAndStoreSchools(ctx context.Context) error {
 			Email:          props.Email,
 			Website:        props.Internet,
 			SchoolYear:     props.Schuljahr,
-			Longitude:      feature.Geometry.Coordinates[0],
-			Latitude:       feature.Geometry.Coordinates[1],
+			Latitude:       lat,
+			Longitude:      lon,
 		}
 		schools = append(schools, school)
 	}
 
-	// Clear existing data
-	if err := s.repo.DeleteAll(ctx); err != nil {
-		s.logger.Error("failed to clear existing schools", slog.String("error", err.Error()))
+	// An empty feed would delete every school and, through the foreign keys, all scraped data
+	if len(schools) == 0 {
+		s.logger.ErrorContext(ctx, "school feed contained no schools, keeping existing data")
+		return apperrors.NewDatabaseError("fetch schools", fmt.Errorf("feed contained no schools"))
+	}
+
+	// Record renumbered schools first, the sync then moves their data to the new number
+	if err := s.saveSchoolAliases(ctx, schools); err != nil {
+		s.logger.ErrorContext(ctx, "failed to store school aliases", slog.String("error", err.Error()))
 		return err
 	}
 
-	// Insert new data
-	successCount := 0
-	for _, school := range schools {
-		_, err := s.repo.Create(ctx, school)
-		if err != nil {
-			s.logger.Warn("failed to create school",
-				slog.String("school_name", school.Name),
-				slog.String("error", err.Error()),
-			)
-			continue
-		}
-		successCount++
+	// Update schools in place so their IDs and scraped data survive the refresh
+	stored, deleted, err := s.repo.Sync(ctx, schools)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "failed to store schools", slog.String("error", err.Error()))
+		return err
 	}
 
-	s.logger.Info("school data fetch completed",
-		slog.Int("success_count", successCount),
-		slog.Int("total_count", len(schools)),
+	s.logger.InfoContext(ctx, "school data fetch completed",
+		slog.Int("success_count", stored),
+		slog.Int("total_count", len(geoJSON.Features)),
+		slog.Int64("deleted_count", deleted),
 	)
 	return nil
 }
 
+// saveSchoolAliases stores the maintained school number mapping and the renumberings
+// detected between the stored schools and the feed; the mapping wins over detection
+func (s *SchoolService) saveSchoolAliases(ctx context.Context, feed []models.CreateSchoolInput) error {
+	if s.aliasRepo == nil {
+		return nil
+	}
+	existing, err := s.repo.GetAll(ctx)
+	if err != nil {
+		return err
+	}
+
+	aliases := append(slices.Clone(s.aliasMapping), detectSchoolAliases(existing, feed)...)
+	saved, err := s.aliasRepo.Save(ctx, aliases)
+	if err != nil {
+		return err
+	}
+	if saved > 0 {
+		s.logger.InfoContext(ctx, "stored school aliases", slog.Int("count", saved))
+	}
+	return nil
+}
+
 // FetchAndStoreConstructionProjects fetches construction projects from Berlin API and stores them in the database
 func (s *SchoolService) FetchAndStoreConstructionProjects(ctx context.Context) error {
-	s.logger.Info("starting construction projects data fetch")
+	s.logger.InfoContext(ctx, "starting construction projects data fetch")
 
 	// Fetch construction projects
 	response, err := s.fetcher.FetchConstructionProjects()
 	if err != nil {
-		s.logger.Error("failed to fetch construction projects", slog.String("error", err.Error()))
+		s.logger.ErrorContext(ctx, "failed to fetch construction projects", slog.String("error", err.Error()))
 		return apperrors.NewDatabaseError("fetch construction projects", err)
 	}
 
 	// Get all existing school numbers to determine which projects need geocoding
 	schools, err := s.repo.GetAll(ctx)
 	if err != nil {
-		s.logger.Error("failed to fetch existing schools", slog.String("error", err.Error()))
+		s.logger.ErrorContext(ctx, "failed to fetch existing schools", slog.String("error", err.Error()))
 		return apperrors.NewDatabaseError("fetch existing schools", err)
 	}
 
@@ -193,12 +331,23 @@ func (s *SchoolService) FetchAndStoreConstructionProjects(ctx context.Context) e
 		}
 	}
 
-	s.logger.Info("processing construction projects",
+	s.logger.InfoContext(ctx, "processing construction projects",
 		slog.Int("total", len(response.Index)),
 		slog.Int("with_existing_schools", projectsWithExistingSchools),
 		slog.Int("standalone_to_geocode", standaloneProjects),
 	)
 
+	// Keep the coordinates of the previous import, so projects keep their location while
+	// Nominatim is unavailable
+	previousCoords := make(map[int]models.ConstructionProject)
+	if existing, err := s.constructionRepo.GetAll(ctx); err == nil {
+		for _, p := range existing {
+			if p.Latitude != 0 || p.Longitude != 0 {
+				previousCoords[p.ProjectID] = p
+			}
+		}
+	}
+
 	// Convert to CreateConstructionProjectInput and geocode standalone/orphaned projects
 	projects := make([]models.CreateConstructionProjectInput, 0, len(response.Index))
 	geocodedCount := 0
@@ -215,21 +364,28 @@ func (s *SchoolService) FetchAndStoreConstructionProjects(ctx context.Context) e
 			address := fmt.Sprintf("%s, %s %s", proj.Street, proj.PostalCode, proj.City)
 
 			// Geocode the address
-			coords := s.geocoder.GeocodeAddressSafe(address)
+			coords := s.geocoder.GeocodeAddressSafe(ctx, address)
 
 			if coords != nil {
 				lat = coords.Latitude
 				lon = coords.Longitude
 				geocodedCount++
-				s.logger.Debug("geocoded standalone construction project",
+				s.logger.DebugContext(ctx, "geocoded standalone construction project",
 					slog.Int("project_id", proj.ID),
 					slog.String("school_number", proj.SchoolNumber),
 					slog.String("address", address),
 					slog.Float64("lat", lat),
 					slog.Float64("lon", lon),
 				)
+			} else if prev, ok := previousCoords[proj.ID]; ok && prev.Street == proj.Street && prev.PostalCode == proj.PostalCode {
+				lat = prev.Latitude
+				lon = prev.Longitude
+				s.logger.WarnContext(ctx, "failed to geocode construction project, keeping previous coordinates",
+					slog.Int("project_id", proj.ID),
+					slog.String("address", address),
+				)
 			} else {
-				s.logger.Warn("failed to geocode construction project",
+				s.logger.WarnContext(ctx, "failed to geocode construction project",
 					slog.Int("project_id", proj.ID),
 					slog.String("school_number", proj.SchoolNumber),
 					slog.String("address", address),
@@ -258,46 +414,34 @@ func (s *SchoolService) FetchAndStoreConstructionProjects(ctx context.Context) e
 			City:                         proj.City,
 			Latitude:                     lat,
 			Longitude:                    lon,
+			Assets:                       proj.Assets,
 		}
 		projects = append(projects, project)
 
 		// Log progress every 10 geocoding operations (not every project)
 		if standaloneProjects > 0 && geocodedCount > 0 && geocodedCount%10 == 0 {
-			s.logger.Info("geocoding progress",
+			s.logger.InfoContext(ctx, "geocoding progress",
 				slog.Int("geocoded", geocodedCount),
 				slog.Int("standalone_total", standaloneProjects),
 			)
 		}
 	}
 
-	s.logger.Info("construction projects processing completed",
+	s.logger.InfoContext(ctx, "construction projects processing completed",
 		slog.Int("total_projects", len(response.Index)),
 		slog.Int("skipped_with_school_number", skippedCount),
 		slog.Int("standalone_geocoded", geocodedCount),
 		slog.Int("standalone_failed", standaloneProjects-geocodedCount),
 	)
 
-	// Clear existing data
-	if err := s.constructionRepo.DeleteAll(ctx); err != nil {
-		s.logger.Error("failed to clear existing construction projects", slog.String("error", err.Error()))
+	// Replace existing data
+	successCount, err := s.constructionRepo.ReplaceAll(ctx, projects)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "failed to store construction projects", slog.String("error", err.Error()))
 		return err
 	}
 
-	// Insert new data
-	successCount := 0
-	for _, project := range projects {
-		_, err := s.constructionRepo.Create(ctx, project)
-		if err != nil {
-			s.logger.Warn("failed to create construction project",
-				slog.String("school_name", project.SchoolName),
-				slog.String("error", err.Error()),
-			)
-			continue
-		}
-		successCount++
-	}
-
-	s.logger.Info("construction projects data fetch completed",
+	s.logger.InfoContext(ctx, "construction projects data fetch completed",
 		slog.Int("success_count", successCount),
 		slog.Int("total_count", len(projects)),
 	)
@@ -306,8 +450,12 @@ func (s *SchoolService) FetchAndStoreConstructionProjects(ctx context.Context) e
 
 // GetAllSchoolsEnriched returns all schools enriched with details, statistics, and construction projects
 func (s *SchoolService) GetAllSchoolsEnriched(ctx context.Context) ([]models.EnrichedSchool, error) {
-	// Get all schools
-	schools, err := s.repo.GetAll(ctx)
+	return s.FindSchoolsEnriched(ctx, models.SchoolFilter{})
+}
+
+// FindSchoolsEnriched returns the enriched schools matching the filter
+func (s *SchoolService) FindSchoolsEnriched(ctx context.Context, filter models.SchoolFilter) ([]models.EnrichedSchool, error) {
+	schools, err := s.repo.Find(ctx, filter)
 	if err != nil {
 		return nil, err
 	}
@@ -317,7 +465,7 @@ func (s *SchoolService) GetAllSchoolsEnriched(ctx context.Context) ([]models.Enr
 	for _, school := range schools {
 		enriched, err := s.enrichSchool(ctx, school)
 		if err != nil {
-			s.logger.Warn("failed to enrich school",
+			s.logger.WarnContext(ctx, "failed to enrich school",
 				slog.String("school_number", school.SchoolNumber),
 				slog.String("school_name", school.Name),
 				slog.String("error", err.Error()),
@@ -342,7 +490,7 @@ func (s *SchoolService) GetSchoolByIDEnriched(ctx context.Context, id int64) (*m
 
 	enriched, err := s.enrichSchool(ctx, *school)
 	if err != nil {
-		s.logger.Warn("failed to enrich school",
+		s.logger.WarnContext(ctx, "failed to enrich school",
 			slog.Int64("school_id", id),
 			slog.String("error", err.Error()),
 		)
@@ -353,6 +501,165 @@ func (s *SchoolService) GetSchoolByIDEnriched(ctx context.Context, id int64) (*m
 	return &enriched, nil
 }
 
+// GetSimilarSchools returns up to limit schools of the same type within radiusKm of the given school,
+// nearest first, with headline statistics
+func (s *SchoolService) GetSimilarSchools(ctx context.Context, id int64, limit int, radiusKm float64) ([]models.SimilarSchool, error) {
+	school, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	lat, lon, ok := school.Coordinates()
+	if !ok {
+		return nil, apperrors.NewValidationError("coordinates", "school has no coordinates")
+	}
+
+	minLat, maxLat, minLon, maxLon := utils.BoundingBox(lat, lon, radiusKm)
+	candidates, err := s.repo.GetByTypeWithinBox(ctx, school.SchoolType, school.ID, minLat, maxLat, minLon, maxLon)
+	if err != nil {
+		return nil, err
+	}
+
+	similar := make([]models.SimilarSchool, 0, len(candidates))
+	for _, candidate := range candidates {
+		candidateLat, candidateLon, ok := candidate.Coordinates()
+		if !ok {
+			continue
+		}
+		distance := utils.DistanceKm(lat, lon, candidateLat, candidateLon)
+		if distance > radiusKm {
+			continue // Inside the bounding box but outside the circle
+		}
+		similar = append(similar, models.SimilarSchool{
+			School:     candidate,
+			DistanceKm: math.Round(distance*100) / 100,
+		})
+	}
+
+	sort.Slice(similar, func(i, j int) bool {
+		return similar[i].DistanceKm < similar[j].DistanceKm
+	})
+	if len(similar) > limit {
+		similar = similar[:limit]
+	}
+
+	for i := range similar {
+		s.addHeadlineStats(ctx, &similar[i])
+	}
+
+	return similar, nil
+}
+
+// GetNearbySchools returns up to limit schools of any type within radiusKm of a point,
+// nearest first, with their distance rounded to 10 m
+func (s *SchoolService) GetNearbySchools(ctx context.Context, lat, lon, radiusKm float64, limit int) ([]models.NearbySchool, error) {
+	minLat, maxLat, minLon, maxLon := utils.BoundingBox(lat, lon, radiusKm)
+	nearby, err := s.repo.FindNearby(ctx, lat, lon, radiusKm, minLat, maxLat, minLon, maxLon, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range nearby {
+		nearby[i].DistanceKm = math.Round(nearby[i].DistanceKm*100) / 100
+	}
+	return nearby, nil
+}
+
+// addHeadlineStats fills in the latest student/teacher numbers and the heritage language share
+func (s *SchoolService) addHeadlineStats(ctx context.Context, similar *models.SimilarSchool) {
+	schoolNumber := similar.School.SchoolNumber
+
+	statistics, err := s.statisticRepo.GetBySchoolNumber(ctx, schoolNumber)
+	if err == nil && len(statistics) > 0 {
+		latest := statistics[0]
+		similar.SchoolYear = latest.SchoolYear
+		similar.Students = latest.Students
+		similar.Teachers = latest.Teachers
+		similar.Classes = latest.Classes
+	}
+
+	languageStat, err := s.statsRepo.GetLanguageStat(ctx, schoolNumber)
+	if err == nil && languageStat != nil {
+		similar.NDHPercentage = &languageStat.NDHPercentage
+	}
+}
+
+// RebuildEnrichedSnapshot recomputes the enriched payload of every school and replaces the
+// materialized enriched_schools_json table, then the full-text search index. Called at the
+// end of each data refresh and after partner edits; rebuilds run one at a time.
+func (s *SchoolService) RebuildEnrichedSnapshot(ctx context.Context) error {
+	s.rebuildMu.Lock()
+	defer s.rebuildMu.Unlock()
+
+	start := time.Now()
+
+	schools, err := s.GetAllSchoolsEnriched(ctx)
+	if err != nil {
+		return err
+	}
+
+	builtAt := time.Now()
+	snapshots := make([]repository.EnrichedSchoolSnapshot, 0, len(schools))
+	for _, school := range schools {
+		payload, err := json.Marshal(school)
+		if err != nil {
+			s.logger.WarnContext(ctx, "failed to serialize enriched school",
+				slog.String("school_number", school.School.SchoolNumber),
+				slog.String("error", err.Error()),
+			)
+			continue
+		}
+		snapshots = append(snapshots, repository.EnrichedSchoolSnapshot{
+			SchoolID:     school.School.ID,
+			SchoolNumber: school.School.SchoolNumber,
+			Payload:      string(payload),
+			BuiltAt:      builtAt,
+		})
+	}
+
+	if err := s.enrichedRepo.ReplaceAll(ctx, snapshots); err != nil {
+		return err
+	}
+	indexed, err := s.searchRepo.Rebuild(ctx)
+	if err != nil {
+		return err
+	}
+
+	s.logger.InfoContext(ctx, "enriched schools snapshot rebuilt",
+		slog.Int("schools", len(snapshots)),
+		slog.Int64("indexed", indexed),
+		slog.String("duration", time.Since(start).String()),
+	)
+	return nil
+}
+
+// SearchSchools returns up to limit schools whose name, offerings, working groups or AI
+// summary contain all words of text, best match first. The index is as of the last rebuild
+// of the enriched snapshot.
+func (s *SchoolService) SearchSchools(ctx context.Context, text string, limit int) ([]models.SchoolSearchResult, error) {
+	return s.searchRepo.Search(ctx, text, limit)
+}
+
+// GetEnrichedSnapshotBuiltAt returns when the enriched snapshot was last rebuilt (nil if never)
+func (s *SchoolService) GetEnrichedSnapshotBuiltAt(ctx context.Context) (*time.Time, error) {
+	return s.enrichedRepo.GetBuiltAt(ctx)
+}
+
+// StreamEnrichedSnapshot calls fn for every school in the materialized snapshot
+func (s *SchoolService) StreamEnrichedSnapshot(ctx context.Context, fn func(models.EnrichedSchool) error) error {
+	return s.StreamMatchingSnapshot(ctx, models.SchoolFilter{}, fn)
+}
+
+// StreamMatchingSnapshot calls fn for every school in the materialized snapshot matching the filter
+func (s *SchoolService) StreamMatchingSnapshot(ctx context.Context, filter models.SchoolFilter, fn func(models.EnrichedSchool) error) error {
+	return s.enrichedRepo.Stream(ctx, filter, func(payload []byte) error {
+		var school models.EnrichedSchool
+		if err := json.Unmarshal(payload, &school); err != nil {
+			return fmt.Errorf("decode enriched school snapshot: %w", err)
+		}
+		return fn(school)
+	})
+}
+
 // enrichSchool enriches a single school with all related data
 func (s *SchoolService) enrichSchool(ctx context.Context, school models.School) (models.EnrichedSchool, error) {
 	enriched := models.EnrichedSchool{
@@ -363,7 +670,7 @@ func (s *SchoolService) enrichSchool(ctx context.Context, school models.School)
 	details, err := s.detailRepo.GetBySchoolNumber(ctx, school.SchoolNumber)
 	if err != nil {
 		// Log but don't fail - some schools might not have details
-		s.logger.Debug("no details found for school",
+		s.logger.DebugContext(ctx, "no details found for school",
 			slog.String("school_number", school.SchoolNumber),
 		)
 	} else {
@@ -373,7 +680,7 @@ func (s *SchoolService) enrichSchool(ctx context.Context, school models.School)
 	// Fetch citizenship stats
 	citizenshipStats, err := s.statsRepo.GetCitizenshipStats(ctx, school.SchoolNumber)
 	if err != nil {
-		s.logger.Debug("no citizenship stats found for school",
+		s.logger.DebugContext(ctx, "no citizenship stats found for school",
 			slog.String("school_number", school.SchoolNumber),
 		)
 	} else {
@@ -383,7 +690,7 @@ func (s *SchoolService) enrichSchool(ctx context.Context, school models.School)
 	// Fetch language stats
 	languageStat, err := s.statsRepo.GetLanguageStat(ctx, school.SchoolNumber)
 	if err != nil {
-		s.logger.Debug("no language stats found for school",
+		s.logger.DebugContext(ctx, "no language stats found for school",
 			slog.String("school_number", school.SchoolNumber),
 		)
 	} else {
@@ -393,7 +700,7 @@ func (s *SchoolService) enrichSchool(ctx context.Context, school models.School)
 	// Fetch residence stats
 	residenceStats, err := s.statsRepo.GetResidenceStats(ctx, school.SchoolNumber)
 	if err != nil {
-		s.logger.Debug("no residence stats found for school",
+		s.logger.DebugContext(ctx, "no residence stats found for school",
 			slog.String("school_number", school.SchoolNumber),
 		)
 	} else {
@@ -403,17 +710,27 @@ func (s *SchoolService) enrichSchool(ctx context.Context, school models.School)
 	// Fetch absence stats
 	absenceStat, err := s.statsRepo.GetAbsenceStat(ctx, school.SchoolNumber)
 	if err != nil {
-		s.logger.Debug("no absence stats found for school",
+		s.logger.DebugContext(ctx, "no absence stats found for school",
 			slog.String("school_number", school.SchoolNumber),
 		)
 	} else {
 		enriched.AbsenceStat = absenceStat
 	}
 
+	// Fetch application numbers (secondary schools only)
+	applications, err := s.applicationRepo.GetBySchoolNumber(ctx, school.SchoolNumber)
+	if err != nil {
+		s.logger.DebugContext(ctx, "no application numbers found for school",
+			slog.String("school_number", school.SchoolNumber),
+		)
+	} else {
+		enriched.Applications = applications
+	}
+
 	// Fetch construction projects
 	constructionProjects, err := s.constructionRepo.GetBySchoolNumber(ctx, school.SchoolNumber)
 	if err != nil {
-		s.logger.Debug("no construction projects found for school",
+		s.logger.DebugContext(ctx, "no construction projects found for school",
 			slog.String("school_number", school.SchoolNumber),
 		)
 	} else {
@@ -423,12 +740,27 @@ func (s *SchoolService) enrichSchool(ctx context.Context, school models.School)
 	// Fetch school statistics
 	statistics, err := s.statisticRepo.GetBySchoolNumber(ctx, school.SchoolNumber)
 	if err != nil {
-		s.logger.Debug("no statistics found for school",
+		s.logger.DebugContext(ctx, "no statistics found for school",
 			slog.String("school_number", school.SchoolNumber),
 		)
 	} else {
 		enriched.Statistics = statistics
 	}
 
+	// Fetch the external rating (only when enabled)
+	if s.ratingRepo != nil {
+		rating, err := s.ratingRepo.GetBySchoolNumber(ctx, school.SchoolNumber)
+		if err != nil {
+			s.logger.DebugContext(ctx, "no external rating found for school",
+				slog.String("school_number", school.SchoolNumber),
+			)
+		} else {
+			enriched.ExternalRating = rating
+		}
+	}
+
+	completeness := enriched.ComputeCompleteness()
+	enriched.Completeness = &completeness
+
 	return enriched, nil
 }
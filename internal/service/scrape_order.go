@@ -0,0 +1,89 @@
+package service
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"schools-be/internal/repository"
+	"schools-be/internal/scraper"
+)
+
+// Scrape orders
+const (
+	ScrapeOrderOldest  = "oldest"
+	ScrapeOrderPopular = "popular"
+)
+
+// popularityWindow is how far back school views count towards popularity
+const popularityWindow = 30 * 24 * time.Hour
+
+// ScrapeOrder decides in which order a details scrape queues the school pages. A run that runs
+// out of its time budget skips the pages at the end.
+type ScrapeOrder interface {
+	Order(ctx context.Context, links []string) ([]string, error)
+}
+
+// NewScrapeOrder returns the named scrape order
+func NewScrapeOrder(name string, scraper *scraper.SchoolDetailsScraper, analyticsRepo *repository.AnalyticsRepository) (ScrapeOrder, error) {
+	switch name {
+	case ScrapeOrderOldest:
+		return &OldestFirst{scraper: scraper}, nil
+	case ScrapeOrderPopular:
+		return &PopularFirst{scraper: scraper, analyticsRepo: analyticsRepo}, nil
+	}
+	return nil, fmt.Errorf("unknown scrape order: %s", name)
+}
+
+// OldestFirst queues the pages never scraped first, then the pages whose cached scrape is oldest
+type OldestFirst struct {
+	scraper *scraper.SchoolDetailsScraper
+}
+
+func (o *OldestFirst) Order(_ context.Context, links []string) ([]string, error) {
+	ordered := slices.Clone(links)
+	cached := cacheInfos(o.scraper, links)
+	slices.SortStableFunc(ordered, func(a, b string) int {
+		return cached[a].ScrapedAt.Compare(cached[b].ScrapedAt)
+	})
+	return ordered, nil
+}
+
+// PopularFirst queues the schools viewed most through the API in the last 30 days first, so
+// they get fresh data early in the run. Schools with as many views, such as the ones never
+// viewed or not cached yet, are queued oldest data first. Without analytics it orders like
+// OldestFirst.
+type PopularFirst struct {
+	scraper       *scraper.SchoolDetailsScraper
+	analyticsRepo *repository.AnalyticsRepository
+}
+
+func (o *PopularFirst) Order(ctx context.Context, links []string) ([]string, error) {
+	views, err := o.analyticsRepo.GetSchoolViews(ctx, time.Now().UTC().Add(-popularityWindow).Format(time.DateOnly))
+	if err != nil {
+		return nil, err
+	}
+
+	ordered := slices.Clone(links)
+	cached := cacheInfos(o.scraper, links)
+	slices.SortStableFunc(ordered, func(a, b string) int {
+		// Pages not in the cache have no school number and thus no views
+		return cmp.Or(
+			cmp.Compare(views[cached[b].SchoolNumber], views[cached[a].SchoolNumber]),
+			cached[a].ScrapedAt.Compare(cached[b].ScrapedAt),
+		)
+	})
+	return ordered, nil
+}
+
+// cacheInfos looks up the cached school and scrape time of each page; pages not in the cache
+// get the zero CacheInfo, so they sort as never scraped
+func cacheInfos(s *scraper.SchoolDetailsScraper, links []string) map[string]scraper.CacheInfo {
+	infos := make(map[string]scraper.CacheInfo, len(links))
+	for _, link := range links {
+		infos[link], _ = s.CacheInfo(link)
+	}
+	return infos
+}
@@ -4,23 +4,30 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"regexp"
 
+	apperrors "schools-be/internal/errors"
 	"schools-be/internal/models"
 	"schools-be/internal/repository"
 	"schools-be/internal/scraper"
 )
 
+// schoolYearPattern matches school years as published by the Senate ("2024/25")
+var schoolYearPattern = regexp.MustCompile(`^\d{4}/\d{2}$`)
+
 type StatisticService struct {
-	repo    *repository.StatisticRepository
-	scraper *scraper.StatisticsScraper
-	logger  *slog.Logger
+	repo      *repository.StatisticRepository
+	statsRepo *repository.SchoolStatisticsRepository
+	scraper   *scraper.StatisticsScraper
+	logger    *slog.Logger
 }
 
-func NewStatisticService(repo *repository.StatisticRepository, scraper *scraper.StatisticsScraper) *StatisticService {
+func NewStatisticService(repo *repository.StatisticRepository, statsRepo *repository.SchoolStatisticsRepository, scraper *scraper.StatisticsScraper, logger *slog.Logger) *StatisticService {
 	return &StatisticService{
-		repo:    repo,
-		scraper: scraper,
-		logger:  slog.Default(),
+		repo:      repo,
+		statsRepo: statsRepo,
+		scraper:   scraper,
+		logger:    logger.With(slog.String("service", "statistic")),
 	}
 }
 
@@ -44,37 +51,75 @@ func (s *StatisticService) GetStatisticsBySchoolYear(ctx context.Context, school
 	return s.repo.GetBySchoolYear(ctx, schoolYear)
 }
 
+// FindStatistics returns statistics matching the filter after validating its school years
+func (s *StatisticService) FindStatistics(ctx context.Context, filter models.StatisticFilter) ([]models.SchoolStatistic, error) {
+	for field, year := range map[string]string{
+		"school_year": filter.SchoolYear,
+		"from_year":   filter.FromYear,
+		"to_year":     filter.ToYear,
+	} {
+		if year != "" && !schoolYearPattern.MatchString(year) {
+			return nil, apperrors.NewValidationError(field, "school year must look like 2024/25")
+		}
+	}
+	return s.repo.Find(ctx, filter)
+}
+
 // GetStatisticsSummary returns summary information about statistics
 func (s *StatisticService) GetStatisticsSummary(ctx context.Context) (map[string]interface{}, error) {
 	return s.repo.GetStatisticsSummary(ctx)
 }
 
+// GetStaffing returns the staffing coverage and teachers by qualification of a school, scraped
+// from the personnel section of its page
+func (s *StatisticService) GetStaffing(ctx context.Context, schoolNumber string) (*models.SchoolStaffingStat, error) {
+	return s.statsRepo.GetStaffingStat(ctx, schoolNumber)
+}
+
+// GetQuality returns the quality framework ratings of a school, scraped from the school
+// inspection section of its page; not found when the page publishes none
+func (s *StatisticService) GetQuality(ctx context.Context, schoolNumber string) ([]models.SchoolQualityIndicator, error) {
+	indicators, err := s.statsRepo.GetQualityIndicators(ctx, schoolNumber)
+	if err != nil {
+		return nil, err
+	}
+	if len(indicators) == 0 {
+		return nil, apperrors.NewNotFoundError("quality indicators", schoolNumber)
+	}
+	return indicators, nil
+}
+
+// GetAllStaffing returns the staffing of all schools whose page has a personnel section
+func (s *StatisticService) GetAllStaffing(ctx context.Context) ([]models.SchoolStaffingStat, error) {
+	return s.statsRepo.GetAllStaffingStats(ctx)
+}
+
 // ScrapeAndStoreStatistics scrapes statistics from the website and stores them in the database
 func (s *StatisticService) ScrapeAndStoreStatistics(ctx context.Context) error {
-	s.logger.Info("starting statistics scrape and store")
+	s.logger.InfoContext(ctx, "starting statistics scrape and store")
 
 	// Scrape the data
 	statistics, err := s.scraper.ScrapeStatistics(ctx)
 	if err != nil {
-		s.logger.Error("failed to scrape statistics", slog.String("error", err.Error()))
+		s.logger.ErrorContext(ctx, "failed to scrape statistics", slog.String("error", err.Error()))
 		return fmt.Errorf("scrape statistics: %w", err)
 	}
 
 	if len(statistics) == 0 {
-		s.logger.Warn("no statistics scraped")
+		s.logger.WarnContext(ctx, "no statistics scraped")
 		return fmt.Errorf("no statistics found")
 	}
 
-	s.logger.Info("scraped statistics", slog.Int("count", len(statistics)))
+	s.logger.InfoContext(ctx, "scraped statistics", slog.Int("count", len(statistics)))
 
 	// Save to database using bulk insert
 	saved, err := s.repo.BulkCreateOrUpdate(ctx, statistics)
 	if err != nil {
-		s.logger.Error("failed to save statistics", slog.String("error", err.Error()))
+		s.logger.ErrorContext(ctx, "failed to save statistics", slog.String("error", err.Error()))
 		return fmt.Errorf("save statistics: %w", err)
 	}
 
-	s.logger.Info("statistics saved successfully",
+	s.logger.InfoContext(ctx, "statistics saved successfully",
 		slog.Int("saved", saved),
 		slog.Int("total", len(statistics)),
 	)
@@ -0,0 +1,110 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"schools-be/internal/metrics"
+	"schools-be/internal/models"
+)
+
+var summaryValidationFailures = metrics.NewCounterVec("schools_ai_summary_validation_failures_total",
+	"AI summaries that failed validation against the source data, by reason", "reason")
+
+// numberPattern matches integers and decimals with either decimal separator ("32.6", "5,2")
+var numberPattern = regexp.MustCompile(`\d+(?:[.,]\d+)?`)
+
+// maxUncheckedNumber is the largest number not checked against the source data. Small integers
+// show up naturally in prose (grade levels, "two languages", list positions) and are not facts
+// taken from the payload.
+const maxUncheckedNumber = 12
+
+// SummaryValidation is the outcome of checking a generated summary against its source data
+type SummaryValidation struct {
+	Valid   bool     `json:"valid"`
+	Issues  []string `json:"issues,omitempty"`
+	Words   int      `json:"words"`
+	Unknown []string `json:"unknown_numbers,omitempty"` // Numbers in the summary not found in the source data
+}
+
+// validateSummary checks that the summary respects the word limit and only mentions numbers
+// present in the enriched payload or the prompt built from it
+func validateSummary(summary string, school *models.EnrichedSchool, prompt string, maxWords int) SummaryValidation {
+	result := SummaryValidation{Words: len(strings.Fields(summary))}
+
+	if strings.TrimSpace(summary) == "" {
+		result.Issues = append(result.Issues, "summary is empty")
+		summaryValidationFailures.Inc("empty")
+	}
+
+	if maxWords > 0 && result.Words > maxWords {
+		result.Issues = append(result.Issues, fmt.Sprintf("summary has %d words, limit is %d", result.Words, maxWords))
+		summaryValidationFailures.Inc("word_limit")
+	}
+
+	known := sourceNumbers(school, prompt)
+	seen := make(map[string]bool)
+	for _, token := range numberPattern.FindAllString(summary, -1) {
+		value, ok := parseNumber(token)
+		if !ok || value <= maxUncheckedNumber || seen[token] {
+			continue
+		}
+		seen[token] = true
+		if !known[normalizeNumber(value)] {
+			result.Unknown = append(result.Unknown, token)
+		}
+	}
+	if len(result.Unknown) > 0 {
+		result.Issues = append(result.Issues, fmt.Sprintf("numbers not found in the source data: %s", strings.Join(result.Unknown, ", ")))
+		summaryValidationFailures.Inc("unknown_number")
+	}
+
+	result.Valid = len(result.Issues) == 0
+	return result
+}
+
+// sourceNumbers collects every number from the enriched payload and the prompt, normalized
+func sourceNumbers(school *models.EnrichedSchool, prompt string) map[string]bool {
+	known := make(map[string]bool)
+
+	sources := []string{prompt}
+	if payload, err := json.Marshal(school); err == nil {
+		sources = append(sources, string(payload))
+	}
+
+	for _, source := range sources {
+		for _, token := range numberPattern.FindAllString(source, -1) {
+			if value, ok := parseNumber(token); ok {
+				known[normalizeNumber(value)] = true
+			}
+		}
+	}
+	return known
+}
+
+func parseNumber(token string) (float64, bool) {
+	value, err := strconv.ParseFloat(strings.Replace(token, ",", ".", 1), 64)
+	return value, err == nil
+}
+
+// normalizeNumber rounds to one decimal, matching the precision used for rates in the prompt
+func normalizeNumber(value float64) string {
+	return strconv.FormatFloat(value, 'f', 1, 64)
+}
+
+// summaryCorrectionPrompt asks the model to rewrite a summary that failed validation
+func summaryCorrectionPrompt(prompt, summary string, validation SummaryValidation, maxWords int) string {
+	return fmt.Sprintf(`%s
+
+**Previous Answer:**
+%s
+
+**Problems With the Previous Answer:**
+- %s
+
+Rewrite the profile so that it fixes these problems. Only use numbers that appear in the data above, and stay under %d words.
+`, prompt, summary, strings.Join(validation.Issues, "\n- "), maxWords)
+}
@@ -0,0 +1,26 @@
+// Package timeouts bounds single operations of long-running jobs. The nightly refresh runs
+// under a context of several hours; without a limit per call, one hung query, geocoding
+// request or page load holds the whole pipeline until that deadline.
+package timeouts
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Limits per operation. A shorter deadline of the parent context still wins.
+const (
+	DB         = 5 * time.Second // One SQL statement
+	Geocode    = 10 * time.Second
+	PageScrape = 2 * time.Minute // Loading and reading one page in the browser
+
+	FailureCapture = 15 * time.Second // Screenshot and HTML of a page a scrape failed on
+)
+
+// With returns a context for one operation that is cancelled after d. When this limit
+// rather than the parent ends the context, context.Cause names the operation that timed
+// out.
+func With(ctx context.Context, operation string, d time.Duration) (context.Context, context.CancelFunc) {
+	return context.WithTimeoutCause(ctx, d, fmt.Errorf("%s timed out after %s: %w", operation, d, context.DeadlineExceeded))
+}
@@ -0,0 +1,321 @@
+// Package usage accounts requests and response bytes per API key and day, and optionally
+// enforces daily quotas on partner keys. Keys are identified by their principal subject
+// (e.g. "partner_key:2"), never by their value.
+package usage
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"schools-be/internal/auth"
+	"schools-be/internal/models"
+	"schools-be/internal/repository"
+
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
+)
+
+const dayFormat = "2006-01-02"
+
+// Quota limits the daily traffic of a partner key; zero values are unlimited
+type Quota struct {
+	Requests int64 `json:"requests"`
+	Bytes    int64 `json:"bytes"`
+}
+
+// Enabled reports whether any limit is set
+func (q Quota) Enabled() bool {
+	return q.Requests > 0 || q.Bytes > 0
+}
+
+type rollupKey struct {
+	day     string
+	subject string
+}
+
+type counts struct {
+	requests int64
+	bytes    int64
+	rejected int64
+}
+
+func (c *counts) add(o counts) {
+	c.requests += o.requests
+	c.bytes += o.bytes
+	c.rejected += o.rejected
+}
+
+// Meter counts traffic in memory, periodically adds it to the daily rollups and tracks the
+// totals of the current day for quota checks
+type Meter struct {
+	repo     *repository.UsageRepository
+	interval time.Duration
+	quota    atomic.Pointer[Quota] // Replaced by SetQuota on config reloads
+	logger   *slog.Logger
+
+	// flushMu keeps loading a key's stored total from racing with a flush of its pending counts
+	flushMu sync.Mutex
+
+	mu      sync.Mutex
+	pending map[rollupKey]counts
+	day     string
+	totals  map[string]counts // Today's totals of quota-limited keys, stored plus pending
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+func NewMeter(repo *repository.UsageRepository, flushInterval time.Duration, quota Quota, logger *slog.Logger) *Meter {
+	m := &Meter{
+		repo:     repo,
+		interval: flushInterval,
+		logger:   logger.With(slog.String("component", "usage")),
+		pending:  make(map[rollupKey]counts),
+		totals:   make(map[string]counts),
+	}
+	m.quota.Store(&quota)
+	return m
+}
+
+// SetQuota changes the daily limit of partner keys for all following requests; counts of the
+// current day are kept
+func (m *Meter) SetQuota(quota Quota) {
+	m.quota.Store(&quota)
+}
+
+// Middleware counts each authenticated request and the bytes of its response. Partner keys
+// that used up their daily quota get 429 until midnight UTC.
+func (m *Meter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal, ok := auth.FromContext(r.Context())
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+		now := time.Now().UTC()
+		day := now.Format(dayFormat)
+		quota := *m.quota.Load()
+
+		if limited(quota, principal) {
+			total, err := m.today(r.Context(), day, principal.Subject)
+			if err != nil {
+				// Fail open: an accounting problem must not lock partners out
+				m.logger.ErrorContext(r.Context(), "failed to load usage", slog.String("error", err.Error()))
+			} else if !setQuotaHeaders(w, quota, total, now) {
+				m.record(day, principal.Subject, counts{rejected: 1})
+				respondError(w, http.StatusTooManyRequests, "daily quota exceeded")
+				return
+			}
+		}
+
+		ww := chimiddleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r)
+		m.record(day, principal.Subject, counts{requests: 1, bytes: int64(ww.BytesWritten())})
+	})
+}
+
+// limited reports whether quotas apply to a caller: partner keys only, since the public key
+// is shared by the frontend and OIDC users are not told apart
+func limited(quota Quota, p auth.Principal) bool {
+	return quota.Enabled() && p.Role == auth.RolePartner && p.UserID == ""
+}
+
+// setQuotaHeaders tells the caller its remaining quota; it returns false when it is used up
+func setQuotaHeaders(w http.ResponseWriter, quota Quota, total counts, now time.Time) bool {
+	reset := now.Truncate(24 * time.Hour).Add(24 * time.Hour)
+	if quota.Requests > 0 {
+		w.Header().Set("X-RateLimit-Limit", strconv.FormatInt(quota.Requests, 10))
+		w.Header().Set("X-RateLimit-Remaining", strconv.FormatInt(max(quota.Requests-total.requests-1, 0), 10)) // After this request
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(reset.Unix(), 10))
+	}
+
+	exceeded := (quota.Requests > 0 && total.requests >= quota.Requests) ||
+		(quota.Bytes > 0 && total.bytes >= quota.Bytes)
+	if exceeded {
+		w.Header().Set("Retry-After", strconv.Itoa(int(reset.Sub(now).Seconds())+1))
+	}
+	return !exceeded
+}
+
+// today returns a key's totals for the day, loading what earlier runs stored on first use
+func (m *Meter) today(ctx context.Context, day, subject string) (counts, error) {
+	m.mu.Lock()
+	if m.day != day {
+		m.day = day
+		m.totals = make(map[string]counts)
+	}
+	if total, ok := m.totals[subject]; ok {
+		m.mu.Unlock()
+		return total, nil
+	}
+	m.mu.Unlock()
+
+	m.flushMu.Lock()
+	defer m.flushMu.Unlock()
+
+	stored, err := m.repo.GetDay(ctx, day, subject)
+	if err != nil {
+		return counts{}, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if total, ok := m.totals[subject]; ok || m.day != day {
+		return total, nil
+	}
+	total := counts{requests: stored.Requests, bytes: stored.Bytes, rejected: stored.Rejected}
+	total.add(m.pending[rollupKey{day: day, subject: subject}])
+	m.totals[subject] = total
+	return total, nil
+}
+
+func (m *Meter) record(day, subject string, c counts) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	k := rollupKey{day: day, subject: subject}
+	pending := m.pending[k]
+	pending.add(c)
+	m.pending[k] = pending
+
+	if total, ok := m.totals[subject]; ok && m.day == day {
+		total.add(c)
+		m.totals[subject] = total
+	}
+}
+
+// Flush adds the in-memory counts to the database and resets them
+func (m *Meter) Flush(ctx context.Context) error {
+	m.flushMu.Lock()
+	defer m.flushMu.Unlock()
+
+	m.mu.Lock()
+	pending := m.pending
+	m.pending = make(map[rollupKey]counts)
+	m.mu.Unlock()
+
+	rollups := make([]models.UsageRollup, 0, len(pending))
+	for k, c := range pending {
+		rollups = append(rollups, models.UsageRollup{Day: k.day, Subject: k.subject, Requests: c.requests, Bytes: c.bytes, Rejected: c.rejected})
+	}
+
+	if err := m.repo.AddUsage(ctx, rollups); err != nil {
+		// Put the counts back so they are retried on the next flush
+		m.mu.Lock()
+		for k, c := range pending {
+			p := m.pending[k]
+			p.add(c)
+			m.pending[k] = p
+		}
+		m.mu.Unlock()
+		return err
+	}
+
+	return nil
+}
+
+// Start flushes the counts periodically until Stop is called
+func (m *Meter) Start() {
+	m.stop = make(chan struct{})
+	m.done = make(chan struct{})
+
+	go func() {
+		defer close(m.done)
+		ticker := time.NewTicker(m.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				m.flushWithTimeout()
+			case <-m.stop:
+				m.flushWithTimeout()
+				return
+			}
+		}
+	}()
+}
+
+// Stop flushes the remaining counts and stops the background flushing
+func (m *Meter) Stop() {
+	close(m.stop)
+	<-m.done
+}
+
+func (m *Meter) flushWithTimeout() {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if err := m.Flush(ctx); err != nil {
+		m.logger.Error("failed to flush usage", slog.String("error", err.Error()))
+	}
+}
+
+// Total is the traffic of one key over a report range
+type Total struct {
+	Subject  string `json:"subject"`
+	Requests int64  `json:"requests"`
+	Bytes    int64  `json:"bytes"`
+	Rejected int64  `json:"rejected"`
+}
+
+// Report summarizes the usage of a day range
+type Report struct {
+	From  string               `json:"from"`
+	To    string               `json:"to"`
+	Quota Quota                `json:"quota"` // Daily limit of partner keys
+	Keys  []Total              `json:"keys"`
+	Daily []models.UsageRollup `json:"daily"`
+}
+
+// Report flushes pending counts and summarizes the last days (including today), busiest key first
+func (m *Meter) Report(ctx context.Context, days int) (*Report, error) {
+	if err := m.Flush(ctx); err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	from := now.AddDate(0, 0, -(days - 1)).Format(dayFormat)
+	to := now.Format(dayFormat)
+
+	rollups, err := m.repo.GetRange(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	totals := map[string]*Total{}
+	for _, rollup := range rollups {
+		t, ok := totals[rollup.Subject]
+		if !ok {
+			t = &Total{Subject: rollup.Subject}
+			totals[rollup.Subject] = t
+		}
+		t.Requests += rollup.Requests
+		t.Bytes += rollup.Bytes
+		t.Rejected += rollup.Rejected
+	}
+
+	keys := make([]Total, 0, len(totals))
+	for _, t := range totals {
+		keys = append(keys, *t)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].Requests != keys[j].Requests {
+			return keys[i].Requests > keys[j].Requests
+		}
+		return keys[i].Subject < keys[j].Subject
+	})
+
+	return &Report{From: from, To: to, Quota: *m.quota.Load(), Keys: keys, Daily: rollups}, nil
+}
+
+func respondError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write([]byte(`{"error":"` + message + `"}`))
+}
@@ -0,0 +1,37 @@
+package utils
+
+import (
+	"fmt"
+	"math"
+)
+
+const earthRadiusKm = 6371.0
+
+// DistanceKm returns the great-circle (haversine) distance between two coordinates in kilometers
+func DistanceKm(lat1, lon1, lat2, lon2 float64) float64 {
+	dLat := toRadians(lat2 - lat1)
+	dLon := toRadians(lon2 - lon1)
+
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRadians(lat1))*math.Cos(toRadians(lat2))*math.Sin(dLon/2)*math.Sin(dLon/2)
+
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(a))
+}
+
+// BoundingBox returns the latitude/longitude box enclosing a circle of radiusKm around a point.
+// It is used to prefilter candidates with an indexable range query before computing exact distances.
+func BoundingBox(lat, lon, radiusKm float64) (minLat, maxLat, minLon, maxLon float64) {
+	dLat := radiusKm / earthRadiusKm * 180 / math.Pi
+	dLon := dLat / math.Cos(toRadians(lat))
+	return lat - dLat, lat + dLat, lon - dLon, lon + dLon
+}
+
+// GridCell returns the key of the ~100m grid cell containing a coordinate.
+// Coordinates are rounded to 3 decimals (about 111m of latitude, 68m of longitude in Berlin).
+func GridCell(lat, lon float64) string {
+	return fmt.Sprintf("%.3f,%.3f", lat, lon)
+}
+
+func toRadians(deg float64) float64 {
+	return deg * math.Pi / 180
+}
@@ -1,12 +1,16 @@
 package utils
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"log/slog"
 	"net/http"
 	"net/url"
 	"time"
+
+	"schools-be/internal/breaker"
+	"schools-be/internal/timeouts"
 )
 
 // GeocodeResult represents a single result from Nominatim API
@@ -25,28 +29,42 @@ type Coordinates struct {
 type Geocoder struct {
 	httpClient *http.Client
 	userAgent  string
+	breaker    *breaker.Breaker
 	logger     *slog.Logger
 	// Rate limiter: channel to enforce 1 request per second
 	rateLimiter <-chan time.Time
 }
 
 // NewGeocoder creates a new Geocoder instance with rate limiting (1 req/sec)
-func NewGeocoder() *Geocoder {
+func NewGeocoder(logger *slog.Logger) *Geocoder {
 	return &Geocoder{
 		httpClient: &http.Client{
 			Timeout: 10 * time.Second,
 		},
 		userAgent:   "Berlin Schools Go Backend",
-		logger:      slog.Default(),
+		breaker:     breaker.New("nominatim", breaker.DefaultThreshold, breaker.DefaultCooldown, logger),
+		logger:      logger.With(slog.String("component", "geocoder")),
 		rateLimiter: time.Tick(1100 * time.Millisecond), // 1.1 seconds between requests
 	}
 }
 
 // GeocodeAddress geocodes a single address using Nominatim API
-// Returns coordinates or nil if geocoding fails
-func (g *Geocoder) GeocodeAddress(address string) (*Coordinates, error) {
+// Returns coordinates or nil if geocoding fails; fails fast while Nominatim is down.
+// The request itself is limited to timeouts.Geocode, not counting the rate limiter wait.
+func (g *Geocoder) GeocodeAddress(ctx context.Context, address string) (*Coordinates, error) {
+	if err := g.breaker.Allow(); err != nil {
+		return nil, err
+	}
+
 	// Wait for rate limiter
-	<-g.rateLimiter
+	select {
+	case <-g.rateLimiter:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	parent := ctx
+	ctx, cancel := timeouts.With(ctx, "geocode", timeouts.Geocode)
+	defer cancel()
 
 	// Build the API URL
 	apiURL := fmt.Sprintf(
@@ -55,7 +73,7 @@ func (g *Geocoder) GeocodeAddress(address string) (*Coordinates, error) {
 	)
 
 	// Create request
-	req, err := http.NewRequest("GET", apiURL, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -67,10 +85,23 @@ func (g *Geocoder) GeocodeAddress(address string) (*Coordinates, error) {
 	// Make the request
 	resp, err := g.httpClient.Do(req)
 	if err != nil {
+		if parent.Err() != nil {
+			return nil, parent.Err() // Cancelled by the caller, not a Nominatim failure
+		}
+		if ctx.Err() != nil {
+			err = context.Cause(ctx)
+		}
+		g.breaker.Record(err)
 		return nil, fmt.Errorf("failed to make request: %w", err)
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+		g.breaker.Record(fmt.Errorf("status %d", resp.StatusCode))
+	} else {
+		g.breaker.Record(nil)
+	}
+
 	if resp.StatusCode != http.StatusOK {
 		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
 	}
@@ -102,10 +133,10 @@ func (g *Geocoder) GeocodeAddress(address string) (*Coordinates, error) {
 
 // GeocodeAddressSafe geocodes an address and logs errors instead of returning them
 // Returns nil if geocoding fails
-func (g *Geocoder) GeocodeAddressSafe(address string) *Coordinates {
-	coords, err := g.GeocodeAddress(address)
+func (g *Geocoder) GeocodeAddressSafe(ctx context.Context, address string) *Coordinates {
+	coords, err := g.GeocodeAddress(ctx, address)
 	if err != nil {
-		g.logger.Warn("failed to geocode address",
+		g.logger.WarnContext(ctx, "failed to geocode address",
 			slog.String("address", address),
 			slog.String("error", err.Error()),
 		)